@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"time"
 
 	btcctypes "github.com/babylonchain/babylon/x/btccheckpoint/types"
@@ -55,13 +56,24 @@ var (
 	ErrFinalityProviderIsSlashed           = errors.New("finality provider is slashed")
 	ErrDelegationNotFound                  = errors.New("delegation not found")
 	ErrInvalidValueReceivedFromBabylonNode = errors.New("invalid value received from babylon node")
+	ErrRewardsNotSupported                 = errors.New("babylon node does not expose rewards")
+	ErrGasCeilingExhausted                 = errors.New("submission rejected due to insufficient fee with maximum gas adjustment")
 )
 
 type BabylonController struct {
-	bbnClient *bbnclient.Client
-	cfg       *stakercfg.BBNConfig
-	btcParams *chaincfg.Params
-	logger    *logrus.Logger
+	bbnClient   *bbnclient.Client
+	cfg         *stakercfg.BBNConfig
+	btcParams   *chaincfg.Params
+	logger      *logrus.Logger
+	submissions *submissionQueue
+	gas         *gasEscalation
+	retry       *RetryConfig
+
+	// clients used to resubmit transactions with escalated gas adjustment, keyed
+	// by gas adjustment
+	clientLogger *zap.Logger
+	gasClientsMu sync.Mutex
+	gasClients   map[float64]*bbnclient.Client
 }
 
 var _ BabylonClient = (*BabylonController)(nil)
@@ -90,10 +102,23 @@ func NewBabylonController(
 
 	// wrap to our type
 	client := &BabylonController{
-		bc,
-		cfg,
-		btcParams,
-		logger,
+		bbnClient:   bc,
+		cfg:         cfg,
+		btcParams:   btcParams,
+		logger:      logger,
+		submissions: newSubmissionQueue(cfg.DisableSubmissionQueue),
+		gas: &gasEscalation{
+			initial: cfg.GasAdjustment,
+			factor:  cfg.InsufficientFeeGasFactor,
+			ceiling: cfg.MaxGasAdjustment,
+		},
+		retry: &RetryConfig{
+			MaxAttempts: cfg.SubmissionMaxAttempts,
+			BaseDelay:   cfg.SubmissionRetryBaseDelay,
+			MaxDelay:    cfg.SubmissionRetryMaxDelay,
+		},
+		clientLogger: clientLogger,
+		gasClients:   make(map[float64]*bbnclient.Client),
 	}
 
 	return client, nil
@@ -125,10 +150,19 @@ type FinalityProviderClientResponse struct {
 
 // Copied from vigilante. Weirdly, there is only Stop function (no Start function ?)
 func (bc *BabylonController) Stop() error {
+	bc.gasClientsMu.Lock()
+	defer bc.gasClientsMu.Unlock()
+
+	for _, c := range bc.gasClients {
+		if err := c.Stop(); err != nil {
+			return err
+		}
+	}
+
 	return bc.bbnClient.Stop()
 }
 
-func (bc *BabylonController) Params() (*StakingParams, error) {
+func (bc *BabylonController) Params(ctx context.Context) (*StakingParams, error) {
 	// TODO: uint64 are quite silly types for these params, probably uint8 or uint16 would be enough
 	// as we do not expect finalization to be more than 255 or in super extreme 65535
 	// TODO: it would probably be good to have separate methods for those
@@ -141,7 +175,7 @@ func (bc *BabylonController) Params() (*StakingParams, error) {
 		}
 		bccParams = &response.Params
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -153,13 +187,13 @@ func (bc *BabylonController) Params() (*StakingParams, error) {
 
 	var stakingTrackerParams *StakingTrackerResponse
 	if err := retry.Do(func() error {
-		trackerParams, err := bc.QueryStakingTracker()
+		trackerParams, err := bc.QueryStakingTracker(ctx)
 		if err != nil {
 			return err
 		}
 		stakingTrackerParams = trackerParams
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -187,6 +221,16 @@ func (bc *BabylonController) Params() (*StakingParams, error) {
 		SlashingRate:              stakingTrackerParams.SlashingRate,
 		CovenantQuruomThreshold:   stakingTrackerParams.CovenantQuruomThreshold,
 		MinUnbondingTime:          minUnbondingTime,
+		// TODO: babylon btcstaking params do not expose limit of active delegations
+		// per staker yet. Once they do, it should be queried here. Until then
+		// zero means no limit is enforced.
+		MaxActiveDelegationsPerStaker: 0,
+		// TODO: babylon btcstaking params do not expose minimum staking value
+		// yet. Until they do, only dust limit of staking output is enforced.
+		MinStakingValue: 0,
+		// TODO: same as above, babylon btcstaking params do not expose maximum
+		// staking time yet
+		MaxStakingTime: 0,
 	}, nil
 }
 
@@ -304,10 +348,37 @@ type UndelegationInfo struct {
 }
 
 type DelegationInfo struct {
-	Active           bool
+	Active bool
+	// public keys of covenant members which already submitted their signatures
+	// for the delegation
+	CovenantSigners  []*btcec.PublicKey
 	UndelegationInfo *UndelegationInfo
 }
 
+// DelegationMsgPayload returns serialized babylon message which is submitted for
+// given delegation data. Message contains only public keys and signatures, so the
+// payload can be shared for debugging without exposing any private key.
+func DelegationMsgPayload(dg *DelegationData) ([]byte, error) {
+	msg, err := delegationDataToMsg(dg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return msg.Marshal()
+}
+
+// DelegationMsgFromPayload deserializes payload returned by DelegationMsgPayload
+func DelegationMsgFromPayload(payload []byte) (*btcstypes.MsgCreateBTCDelegation, error) {
+	var msg btcstypes.MsgCreateBTCDelegation
+
+	if err := msg.Unmarshal(payload); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
 func delegationDataToMsg(dg *DelegationData) (*btcstypes.MsgCreateBTCDelegation, error) {
 	if dg == nil {
 		return nil, fmt.Errorf("nil delegation data")
@@ -396,11 +467,66 @@ func delegationDataToMsg(dg *DelegationData) (*btcstypes.MsgCreateBTCDelegation,
 	}, nil
 }
 
+// clientWithGasAdjustment returns babylon client which estimates gas with given
+// gas adjustment
+func (bc *BabylonController) clientWithGasAdjustment(gasAdjustment float64) (*bbnclient.Client, error) {
+	if gasAdjustment == bc.cfg.GasAdjustment {
+		return bc.bbnClient, nil
+	}
+
+	bc.gasClientsMu.Lock()
+	defer bc.gasClientsMu.Unlock()
+
+	if c, found := bc.gasClients[gasAdjustment]; found {
+		return c, nil
+	}
+
+	babylonConfig := stakercfg.BBNConfigToBabylonConfig(bc.cfg)
+	babylonConfig.GasAdjustment = gasAdjustment
+
+	c, err := bbnclient.New(&babylonConfig, bc.clientLogger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bc.gasClients[gasAdjustment] = c
+
+	return c, nil
+}
+
+// reliablySendMsgs submits messages to babylon. Submissions rejected due to
+// retryable errors are resubmitted with backoff, outside of submission queue so
+// that other submissions are not blocked while waiting. If included is provided,
+// it is used to check whether failed submission was included anyway before it
+// is resent.
 func (bc *BabylonController) reliablySendMsgs(
 	msgs []sdk.Msg,
+	included func() (bool, error),
 ) (*pv.RelayerTxResponse, error) {
-	// TODO Empty errors ??
-	return bc.bbnClient.ReliablySendMsgs(context.Background(), msgs, []*sdkErr.Error{}, []*sdkErr.Error{})
+	return bc.retry.send(bc.logger, func() (*pv.RelayerTxResponse, error) {
+		return bc.submissions.submit(bc.GetKeyAddress().String(), func() (*pv.RelayerTxResponse, error) {
+			return bc.gas.send(func(gasAdjustment float64) (*pv.RelayerTxResponse, error) {
+				c, err := bc.clientWithGasAdjustment(gasAdjustment)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if gasAdjustment != bc.cfg.GasAdjustment {
+					bc.logger.WithFields(logrus.Fields{
+						"gasAdjustment": gasAdjustment,
+					}).Warn("Resubmitting transaction rejected due to insufficient fee with higher gas adjustment")
+				}
+
+				// insufficient fee errors are not retried by the client, as they
+				// are handled by gas escalation. Retryable submission errors are
+				// not retried by the client either, so that they are resent only
+				// by retry config after inclusion check.
+				return c.ReliablySendMsgs(context.Background(), msgs, []*sdkErr.Error{}, clientUnrecoverableErrors)
+			})
+		})
+	}, included)
 }
 
 // TODO: for now return sdk.TxResponse, it will ease up debugging/testing
@@ -411,7 +537,16 @@ func (bc *BabylonController) Delegate(dg *DelegationData) (*pv.RelayerTxResponse
 		return nil, err
 	}
 
-	return bc.reliablySendMsgs([]sdk.Msg{delegateMsg})
+	stakingTxHash := dg.StakingTransaction.TxHash()
+
+	// delegation already included in babylon cannot be submitted again
+	included := func() (bool, error) {
+		ctx, cancel := getQueryContext(context.Background(), bc.cfg.Timeout)
+		defer cancel()
+		return bc.IsTxAlreadyPartOfDelegation(ctx, &stakingTxHash)
+	}
+
+	return bc.reliablySendMsgs([]sdk.Msg{delegateMsg}, included)
 }
 
 func (bc *BabylonController) Undelegate(
@@ -426,16 +561,18 @@ func (bc *BabylonController) Undelegate(
 		UnbondingTxSig: ubSig,
 	}
 
-	return bc.reliablySendMsgs([]sdk.Msg{msg})
+	return bc.reliablySendMsgs([]sdk.Msg{msg}, nil)
 }
 
-func getQueryContext(timeout time.Duration) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// getQueryContext returns context of single babylon query, which is cancelled
+// when parent is cancelled or after timeout
+func getQueryContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	return ctx, cancel
 }
 
-func (bc *BabylonController) QueryStakingTracker() (*StakingTrackerResponse, error) {
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+func (bc *BabylonController) QueryStakingTracker(ctx context.Context) (*StakingTrackerResponse, error) {
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
 	defer cancel()
 
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
@@ -489,9 +626,10 @@ func (bc *BabylonController) QueryStakingTracker() (*StakingTrackerResponse, err
 }
 
 func (bc *BabylonController) QueryFinalityProviders(
+	ctx context.Context,
 	limit uint64,
 	offset uint64) (*FinalityProvidersClientResponse, error) {
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
 	defer cancel()
 
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
@@ -514,7 +652,7 @@ func (bc *BabylonController) QueryFinalityProviders(
 		}
 		response = resp
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -555,12 +693,12 @@ func (bc *BabylonController) QueryFinalityProviders(
 	}, nil
 }
 
-func (bc *BabylonController) QueryFinalityProvider(btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error) {
+func (bc *BabylonController) QueryFinalityProvider(ctx context.Context, btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error) {
 	if btcPubKey == nil {
 		return nil, fmt.Errorf("cannot query finality provider with nil btc public key")
 	}
 
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
 	defer cancel()
 
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
@@ -586,7 +724,7 @@ func (bc *BabylonController) QueryFinalityProvider(btcPubKey *btcec.PublicKey) (
 		}
 		response = resp
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -619,8 +757,8 @@ func (bc *BabylonController) QueryFinalityProvider(btcPubKey *btcec.PublicKey) (
 	}, nil
 }
 
-func (bc *BabylonController) QueryHeaderDepth(headerHash *chainhash.Hash) (uint64, error) {
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+func (bc *BabylonController) QueryHeaderDepth(ctx context.Context, headerHash *chainhash.Hash) (uint64, error) {
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
 	defer cancel()
 
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
@@ -634,7 +772,7 @@ func (bc *BabylonController) QueryHeaderDepth(headerHash *chainhash.Hash) (uint6
 		}
 		response = depthResponse
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -655,6 +793,68 @@ func (bc *BabylonController) QueryHeaderDepth(headerHash *chainhash.Hash) (uint6
 
 }
 
+// GetBTCHeaderChainTip returns height and hash of the tip of babylon btc light
+// client
+func (bc *BabylonController) GetBTCHeaderChainTip(ctx context.Context) (uint32, chainhash.Hash, error) {
+	var tip *btclctypes.BTCHeaderInfoResponse
+	if err := retry.Do(func() error {
+		tipResponse, err := bc.QueryBtcLightClientTip()
+		if err != nil {
+			return err
+		}
+		tip = tipResponse
+		return nil
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+		bc.logger.WithFields(logrus.Fields{
+			"attempt":      n + 1,
+			"max_attempts": RtyAttNum,
+			"error":        err,
+		}).Error("Failed to query babylon for the tip of btc light client")
+	})); err != nil {
+		return 0, chainhash.Hash{}, err
+	}
+
+	return parseBtcHeaderChainTip(tip)
+}
+
+// parseBtcHeaderChainTip validates tip of btc light client received from
+// babylon node
+func parseBtcHeaderChainTip(tip *btclctypes.BTCHeaderInfoResponse) (uint32, chainhash.Hash, error) {
+	if tip == nil {
+		return 0, chainhash.Hash{}, fmt.Errorf("empty btc light client tip: %w", ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	if tip.Height > math.MaxUint32 {
+		return 0, chainhash.Hash{}, fmt.Errorf("btc light client tip height %d is too large: %w",
+			tip.Height, ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	tipHash, err := chainhash.NewHashFromStr(tip.HashHex)
+
+	if err != nil {
+		return 0, chainhash.Hash{}, fmt.Errorf("malformed btc light client tip hash %s: %w",
+			tip.HashHex, ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	return uint32(tip.Height), *tipHash, nil
+}
+
+// GetLatestBlockHeight returns height of the latest block of babylon node,
+// queried from node status without retries, so that unreachable node is
+// reported quickly
+func (bc *BabylonController) GetLatestBlockHeight(ctx context.Context) (uint64, error) {
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
+	defer cancel()
+
+	status, err := bc.bbnClient.RPCClient.Status(ctx)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to query babylon node status: %w", err)
+	}
+
+	return uint64(status.SyncInfo.LatestBlockHeight), nil
+}
+
 // Insert BTC block header using rpc client
 func (bc *BabylonController) InsertBtcBlockHeaders(headers []*wire.BlockHeader) (*pv.RelayerTxResponse, error) {
 	msg := &btclctypes.MsgInsertHeaders{
@@ -662,7 +862,7 @@ func (bc *BabylonController) InsertBtcBlockHeaders(headers []*wire.BlockHeader)
 		Headers: chainToChainBytes(headers),
 	}
 
-	return bc.reliablySendMsgs([]sdk.Msg{msg})
+	return bc.reliablySendMsgs([]sdk.Msg{msg}, nil)
 }
 
 func chainToChainBytes(chain []*wire.BlockHeader) []bbntypes.BTCHeaderBytes {
@@ -694,15 +894,17 @@ func (bc *BabylonController) RegisterFinalityProvider(
 	fpPrivKeyBBN.PubKey()
 	relayerMsgs := bbnclient.ToProviderMsgs([]sdk.Msg{registerMsg})
 
-	_, err := bc.bbnClient.SendMessageWithSigner(context.Background(), fpAddr, fpPrivKeyBBN, relayerMsgs)
+	_, err := bc.submissions.submit(fpAddr.String(), func() (*pv.RelayerTxResponse, error) {
+		return bc.bbnClient.SendMessageWithSigner(context.Background(), fpAddr, fpPrivKeyBBN, relayerMsgs)
+	})
 	return err
 }
 
-func (bc *BabylonController) QueryDelegationInfo(stakingTxHash *chainhash.Hash) (*DelegationInfo, error) {
+func (bc *BabylonController) QueryDelegationInfo(ctx context.Context, stakingTxHash *chainhash.Hash) (*DelegationInfo, error) {
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
 	queryClient := btcstypes.NewQueryClient(clientCtx)
 
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
 	defer cancel()
 
 	var di *DelegationInfo
@@ -765,12 +967,26 @@ func (bc *BabylonController) QueryDelegationInfo(stakingTxHash *chainhash.Hash)
 			}
 		}
 
+		var covenantSigners []*btcec.PublicKey
+
+		for _, covenantSigs := range resp.BtcDelegation.CovenantSigs {
+			pk, err := covenantSigs.CovPk.ToBTCPK()
+
+			if err != nil {
+				return retry.Unrecoverable(fmt.Errorf("malformed covenant pk: %s : %w", err.Error(),
+					ErrInvalidValueReceivedFromBabylonNode))
+			}
+
+			covenantSigners = append(covenantSigners, pk)
+		}
+
 		di = &DelegationInfo{
 			Active:           resp.BtcDelegation.Active,
+			CovenantSigners:  covenantSigners,
 			UndelegationInfo: udi,
 		}
 		return nil
-	}, RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
+	}, retry.Context(ctx), RtyAtt, RtyDel, RtyErr, retry.OnRetry(func(n uint, err error) {
 		bc.logger.WithFields(logrus.Fields{
 			"attempt":      n + 1,
 			"max_attempts": RtyAttNum,
@@ -783,8 +999,8 @@ func (bc *BabylonController) QueryDelegationInfo(stakingTxHash *chainhash.Hash)
 	return di, nil
 }
 
-func (bc *BabylonController) IsTxAlreadyPartOfDelegation(stakingTxHash *chainhash.Hash) (bool, error) {
-	_, err := bc.QueryDelegationInfo(stakingTxHash)
+func (bc *BabylonController) IsTxAlreadyPartOfDelegation(ctx context.Context, stakingTxHash *chainhash.Hash) (bool, error) {
+	_, err := bc.QueryDelegationInfo(ctx, stakingTxHash)
 
 	if err != nil {
 		if errors.Is(err, ErrDelegationNotFound) {
@@ -815,11 +1031,11 @@ func (bc *BabylonController) SubmitCovenantSig(
 		SlashingUnbondingTxSigs: slashUnbondingAdaptorSigs,
 	}
 
-	return bc.reliablySendMsgs([]sdk.Msg{msg})
+	return bc.reliablySendMsgs([]sdk.Msg{msg}, nil)
 }
 
 func (bc *BabylonController) QueryPendingBTCDelegations() ([]*btcstypes.BTCDelegationResponse, error) {
-	ctx, cancel := getQueryContext(bc.cfg.Timeout)
+	ctx, cancel := getQueryContext(context.Background(), bc.cfg.Timeout)
 	defer cancel()
 
 	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
@@ -848,7 +1064,7 @@ func (bc *BabylonController) InsertSpvProofs(submitter string, proofs []*btcctyp
 		Proofs:    proofs,
 	}
 
-	res, err := bc.reliablySendMsgs([]sdk.Msg{msg})
+	res, err := bc.reliablySendMsgs([]sdk.Msg{msg}, nil)
 	if err != nil {
 		return nil, err
 	}
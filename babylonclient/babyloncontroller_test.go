@@ -0,0 +1,105 @@
+package babylonclient
+
+import (
+	"math"
+	"testing"
+
+	"github.com/babylonchain/babylon/testutil/datagen"
+	btclctypes "github.com/babylonchain/babylon/x/btclightclient/types"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestTx(value int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(value, []byte{0x51}))
+	return tx
+}
+
+func makeTestSig(t *testing.T, key *btcec.PrivateKey) *schnorr.Signature {
+	sig, err := schnorr.Sign(key, chainhash.HashB([]byte("msg")))
+	require.NoError(t, err)
+	return sig
+}
+
+func TestDelegationMsgPayloadMatchesSubmittedMsg(t *testing.T) {
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	bc := GetMockClient()
+	inclusionBlockHash := chainhash.Hash{1}
+
+	dg := &DelegationData{
+		StakingTransaction:                   makeTestTx(100000),
+		StakingTransactionIdx:                1,
+		StakingTransactionInclusionProof:     []byte{1, 2, 3},
+		StakingTransactionInclusionBlockHash: &inclusionBlockHash,
+		StakingTime:                          1000,
+		StakingValue:                         btcutil.Amount(100000),
+		FinalityProvidersBtcPks:              []*btcec.PublicKey{&bc.ActiveFinalityProvider.BtcPk},
+		SlashingTransaction:                  makeTestTx(10000),
+		SlashingTransactionSig:               makeTestSig(t, stakerKey),
+		BabylonStakerAddr:                    datagen.GenRandomAccount().GetAddress(),
+		StakerBtcPk:                          stakerKey.PubKey(),
+		BabylonPop:                           stakerdb.NewProofOfPossession([]byte{4, 5, 6}),
+		Ud: &UndelegationData{
+			UnbondingTransaction:         makeTestTx(90000),
+			UnbondingTxValue:             btcutil.Amount(90000),
+			UnbondingTxUnbondingTime:     100,
+			SlashUnbondingTransaction:    makeTestTx(9000),
+			SlashUnbondingTransactionSig: makeTestSig(t, stakerKey),
+		},
+	}
+
+	payload, err := DelegationMsgPayload(dg)
+	require.NoError(t, err)
+
+	delegateErr := make(chan error, 1)
+	go func() {
+		_, err := bc.Delegate(dg)
+		delegateErr <- err
+	}()
+
+	submitted := <-bc.SentMessages
+	require.NoError(t, <-delegateErr)
+	submittedPayload, err := submitted.Marshal()
+	require.NoError(t, err)
+	require.Equal(t, submittedPayload, payload)
+
+	msg, err := DelegationMsgFromPayload(payload)
+	require.NoError(t, err)
+	require.Equal(t, submitted, msg)
+}
+
+func TestParseBtcHeaderChainTip(t *testing.T) {
+	tipHash := chainhash.Hash{1, 2, 3}
+
+	height, hash, err := parseBtcHeaderChainTip(&btclctypes.BTCHeaderInfoResponse{
+		HashHex: tipHash.String(),
+		Height:  100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint32(100), height)
+	require.Equal(t, tipHash, hash)
+
+	_, _, err = parseBtcHeaderChainTip(&btclctypes.BTCHeaderInfoResponse{
+		HashHex: tipHash.String(),
+		Height:  math.MaxUint32 + 1,
+	})
+	require.ErrorIs(t, err, ErrInvalidValueReceivedFromBabylonNode)
+
+	_, _, err = parseBtcHeaderChainTip(&btclctypes.BTCHeaderInfoResponse{
+		HashHex: "not a hash",
+		Height:  100,
+	})
+	require.ErrorIs(t, err, ErrInvalidValueReceivedFromBabylonNode)
+
+	_, _, err = parseBtcHeaderChainTip(nil)
+	require.ErrorIs(t, err, ErrInvalidValueReceivedFromBabylonNode)
+}
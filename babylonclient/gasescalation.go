@@ -0,0 +1,65 @@
+package babylonclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	sdkErr "cosmossdk.io/errors"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+)
+
+// insufficientFeeErrors are errors returned by babylon node when transaction
+// did not provide enough gas or fee. Submission rejected with one of those
+// errors can succeed if resubmitted with higher gas.
+var insufficientFeeErrors = []*sdkErr.Error{
+	sdkerrors.ErrInsufficientFee,
+	sdkerrors.ErrOutOfGas,
+}
+
+func isInsufficientFeeErr(err error) bool {
+	for _, feeErr := range insufficientFeeErrors {
+		// errors returned from node are not always wrapped, so fallback to
+		// checking the message
+		if errors.Is(err, feeErr) || strings.Contains(err.Error(), feeErr.Error()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// gasEscalation resubmits transactions rejected due to insufficient fee or gas
+// with gas adjustment raised by factor, until ceiling is reached
+type gasEscalation struct {
+	initial float64
+	factor  float64
+	ceiling float64
+}
+
+// send calls send function with initial gas adjustment and escalates the
+// adjustment each time the submission is rejected due to insufficient fee.
+// It returns ErrGasCeilingExhausted if submission with gas adjustment equal to
+// ceiling is also rejected.
+func (g *gasEscalation) send(
+	send func(gasAdjustment float64) (*pv.RelayerTxResponse, error),
+) (*pv.RelayerTxResponse, error) {
+	gasAdjustment := g.initial
+
+	for {
+		resp, err := send(gasAdjustment)
+
+		if err == nil || g.factor <= 1 || !isInsufficientFeeErr(err) {
+			return resp, err
+		}
+
+		if gasAdjustment >= g.ceiling {
+			return nil, fmt.Errorf("submission rejected with gas adjustment %.2f: %s: %w",
+				gasAdjustment, err.Error(), ErrGasCeilingExhausted)
+		}
+
+		gasAdjustment = math.Min(gasAdjustment*g.factor, g.ceiling)
+	}
+}
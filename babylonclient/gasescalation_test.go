@@ -0,0 +1,98 @@
+package babylonclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFeeNode rejects transactions whose gas adjustment is lower than required
+// one with given error
+type mockFeeNode struct {
+	required    float64
+	rejectErr   error
+	adjustments []float64
+}
+
+func (n *mockFeeNode) send(gasAdjustment float64) (*pv.RelayerTxResponse, error) {
+	n.adjustments = append(n.adjustments, gasAdjustment)
+
+	if gasAdjustment < n.required {
+		return nil, n.rejectErr
+	}
+
+	return &pv.RelayerTxResponse{Code: 0}, nil
+}
+
+func TestGasEscalation(t *testing.T) {
+	// node errors are not always wrapped, only error message is preserved
+	unwrappedFeeErr := errors.New(sdkerrors.ErrInsufficientFee.Wrap("got 10ubbn, required 20ubbn").Error())
+
+	tests := []struct {
+		name                string
+		escalation          gasEscalation
+		node                mockFeeNode
+		expectedAdjustments []float64
+		expectErr           error
+	}{
+		{
+			name:                "insufficient fee rejection followed by success",
+			escalation:          gasEscalation{initial: 1, factor: 1.5, ceiling: 4},
+			node:                mockFeeNode{required: 2, rejectErr: sdkerrors.ErrInsufficientFee},
+			expectedAdjustments: []float64{1, 1.5, 2.25},
+		},
+		{
+			name:                "out of gas rejection followed by success",
+			escalation:          gasEscalation{initial: 1, factor: 2, ceiling: 4},
+			node:                mockFeeNode{required: 1.5, rejectErr: fmt.Errorf("tx failed: %w", sdkerrors.ErrOutOfGas)},
+			expectedAdjustments: []float64{1, 2},
+		},
+		{
+			name:                "unwrapped insufficient fee error",
+			escalation:          gasEscalation{initial: 1, factor: 2, ceiling: 4},
+			node:                mockFeeNode{required: 2, rejectErr: unwrappedFeeErr},
+			expectedAdjustments: []float64{1, 2},
+		},
+		{
+			name:                "ceiling exhausted",
+			escalation:          gasEscalation{initial: 1, factor: 1.5, ceiling: 3},
+			node:                mockFeeNode{required: 5, rejectErr: sdkerrors.ErrInsufficientFee},
+			expectedAdjustments: []float64{1, 1.5, 2.25, 3},
+			expectErr:           ErrGasCeilingExhausted,
+		},
+		{
+			name:                "escalation disabled",
+			escalation:          gasEscalation{initial: 1, factor: 1, ceiling: 4},
+			node:                mockFeeNode{required: 2, rejectErr: sdkerrors.ErrInsufficientFee},
+			expectedAdjustments: []float64{1},
+			expectErr:           sdkerrors.ErrInsufficientFee,
+		},
+		{
+			name:                "other errors are not escalated",
+			escalation:          gasEscalation{initial: 1, factor: 1.5, ceiling: 4},
+			node:                mockFeeNode{required: 2, rejectErr: sdkerrors.ErrUnauthorized},
+			expectedAdjustments: []float64{1},
+			expectErr:           sdkerrors.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := tc.escalation.send(tc.node.send)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+				require.Nil(t, resp)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, resp)
+			}
+
+			require.Equal(t, tc.expectedAdjustments, tc.node.adjustments)
+		})
+	}
+}
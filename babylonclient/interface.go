@@ -1,6 +1,7 @@
 package babylonclient
 
 import (
+	"context"
 	"fmt"
 
 	sdkmath "cosmossdk.io/math"
@@ -38,6 +39,18 @@ type StakingParams struct {
 
 	// Minimum unbonding time required by bayblon
 	MinUnbondingTime uint16
+
+	// Maximum number of active delegations a single staker can have. Zero means
+	// there is no limit
+	MaxActiveDelegationsPerStaker uint32
+
+	// Minimum value of staking output accepted by babylon. Zero means there is
+	// no minimum other than dust limit
+	MinStakingValue btcutil.Amount
+
+	// Maximum staking time in btc blocks accepted by babylon. Zero means there
+	// is no maximum other than max uint16 value
+	MaxStakingTime uint16
 }
 
 // SingleKeyCosmosKeyring represents a keyring that supports only one pritvate/public key pair
@@ -47,16 +60,26 @@ type SingleKeyKeyring interface {
 	GetPubKey() *secp256k1.PubKey
 }
 
+// BabylonClient is client of babylon node. Queries are aborted when provided
+// context is cancelled. Delegate and Undelegate are executed by BabylonMsgSender
+// in background and are not bound to context of the caller.
 type BabylonClient interface {
 	SingleKeyKeyring
-	Params() (*StakingParams, error)
+	Params(ctx context.Context) (*StakingParams, error)
 	Delegate(dg *DelegationData) (*pv.RelayerTxResponse, error)
 	Undelegate(req *UndelegationRequest) (*pv.RelayerTxResponse, error)
-	QueryFinalityProviders(limit uint64, offset uint64) (*FinalityProvidersClientResponse, error)
-	QueryFinalityProvider(btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error)
-	QueryHeaderDepth(headerHash *chainhash.Hash) (uint64, error)
-	IsTxAlreadyPartOfDelegation(stakingTxHash *chainhash.Hash) (bool, error)
-	QueryDelegationInfo(stakingTxHash *chainhash.Hash) (*DelegationInfo, error)
+	QueryFinalityProviders(ctx context.Context, limit uint64, offset uint64) (*FinalityProvidersClientResponse, error)
+	QueryFinalityProvider(ctx context.Context, btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error)
+	QueryHeaderDepth(ctx context.Context, headerHash *chainhash.Hash) (uint64, error)
+	// GetBTCHeaderChainTip returns height and hash of the tip of babylon btc
+	// light client
+	GetBTCHeaderChainTip(ctx context.Context) (uint32, chainhash.Hash, error)
+	// GetLatestBlockHeight returns height of the latest babylon block known to
+	// the babylon node
+	GetLatestBlockHeight(ctx context.Context) (uint64, error)
+	IsTxAlreadyPartOfDelegation(ctx context.Context, stakingTxHash *chainhash.Hash) (bool, error)
+	QueryDelegationInfo(ctx context.Context, stakingTxHash *chainhash.Hash) (*DelegationInfo, error)
+	GetDelegationRewards(ctx context.Context, stakingTxHash *chainhash.Hash) (*RewardInfo, error)
 }
 
 type MockBabylonClient struct {
@@ -64,11 +87,23 @@ type MockBabylonClient struct {
 	babylonKey             *secp256k1.PrivKey
 	SentMessages           chan *types.MsgCreateBTCDelegation
 	ActiveFinalityProvider *FinalityProviderInfo
+	// returned by QueryDelegationInfo, if nil delegation is treated as not found
+	DelegationInfo *DelegationInfo
+	// height and hash of babylon btc light client tip returned by
+	// GetBTCHeaderChainTip
+	BtcTipHeight uint32
+	BtcTipHash   chainhash.Hash
+	// height of babylon chain returned by GetLatestBlockHeight
+	LatestBlockHeight uint64
+	// if set, returned by GetLatestBlockHeight
+	LatestBlockHeightErr error
+	// returned by GetDelegationRewards, if nil rewards are treated as not supported
+	Rewards *RewardInfo
 }
 
 var _ BabylonClient = (*MockBabylonClient)(nil)
 
-func (m *MockBabylonClient) Params() (*StakingParams, error) {
+func (m *MockBabylonClient) Params(_ context.Context) (*StakingParams, error) {
 	return m.ClientParams, nil
 }
 
@@ -109,14 +144,14 @@ func (m *MockBabylonClient) Delegate(dg *DelegationData) (*pv.RelayerTxResponse,
 	return &pv.RelayerTxResponse{Code: 0}, nil
 }
 
-func (m *MockBabylonClient) QueryFinalityProviders(limit uint64, offset uint64) (*FinalityProvidersClientResponse, error) {
+func (m *MockBabylonClient) QueryFinalityProviders(_ context.Context, limit uint64, offset uint64) (*FinalityProvidersClientResponse, error) {
 	return &FinalityProvidersClientResponse{
 		FinalityProviders: []FinalityProviderInfo{*m.ActiveFinalityProvider},
 		Total:             1,
 	}, nil
 }
 
-func (m *MockBabylonClient) QueryFinalityProvider(btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error) {
+func (m *MockBabylonClient) QueryFinalityProvider(_ context.Context, btcPubKey *btcec.PublicKey) (*FinalityProviderClientResponse, error) {
 	if m.ActiveFinalityProvider.BtcPk.IsEqual(btcPubKey) {
 		return &FinalityProviderClientResponse{
 			FinalityProvider: *m.ActiveFinalityProvider,
@@ -126,17 +161,40 @@ func (m *MockBabylonClient) QueryFinalityProvider(btcPubKey *btcec.PublicKey) (*
 	}
 }
 
-func (m *MockBabylonClient) QueryHeaderDepth(headerHash *chainhash.Hash) (uint64, error) {
+func (m *MockBabylonClient) QueryHeaderDepth(_ context.Context, headerHash *chainhash.Hash) (uint64, error) {
 	// return always confirmed depth
 	return uint64(m.ClientParams.ConfirmationTimeBlocks) + 1, nil
 }
 
-func (m *MockBabylonClient) IsTxAlreadyPartOfDelegation(stakingTxHash *chainhash.Hash) (bool, error) {
+func (m *MockBabylonClient) GetBTCHeaderChainTip(_ context.Context) (uint32, chainhash.Hash, error) {
+	return m.BtcTipHeight, m.BtcTipHash, nil
+}
+
+func (m *MockBabylonClient) GetLatestBlockHeight(_ context.Context) (uint64, error) {
+	if m.LatestBlockHeightErr != nil {
+		return 0, m.LatestBlockHeightErr
+	}
+	return m.LatestBlockHeight, nil
+}
+
+func (m *MockBabylonClient) IsTxAlreadyPartOfDelegation(_ context.Context, stakingTxHash *chainhash.Hash) (bool, error) {
 	return false, nil
 }
 
-func (m *MockBabylonClient) QueryDelegationInfo(stakingTxHash *chainhash.Hash) (*DelegationInfo, error) {
-	return nil, fmt.Errorf("delegation do not exist")
+func (m *MockBabylonClient) QueryDelegationInfo(_ context.Context, stakingTxHash *chainhash.Hash) (*DelegationInfo, error) {
+	if m.DelegationInfo == nil {
+		return nil, fmt.Errorf("delegation do not exist: %w", ErrDelegationNotFound)
+	}
+
+	return m.DelegationInfo, nil
+}
+
+func (m *MockBabylonClient) GetDelegationRewards(_ context.Context, stakingTxHash *chainhash.Hash) (*RewardInfo, error) {
+	if m.Rewards == nil {
+		return nil, ErrRewardsNotSupported
+	}
+
+	return m.Rewards, nil
 }
 
 func (m *MockBabylonClient) Undelegate(
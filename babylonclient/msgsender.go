@@ -103,7 +103,7 @@ func (b *BabylonMsgSender) isBabylonBtcLcReady(
 	requiredInclusionBlockDepth uint64,
 	req *DelegationData,
 ) error {
-	depth, err := b.cl.QueryHeaderDepth(req.StakingTransactionInclusionBlockHash)
+	depth, err := b.cl.QueryHeaderDepth(context.Background(), req.StakingTransactionInclusionBlockHash)
 
 	if err != nil {
 		// If header is not known to babylon, or it is on LCFork, then most probably
@@ -215,7 +215,7 @@ func (m *BabylonMsgSender) handleSentToBabylon() {
 			m.sendDelegationAsync(&stakingTxHash, req)
 
 		case req := <-m.sendUndelegationRequestChan:
-			di, err := m.cl.QueryDelegationInfo(req.stakingTxHash)
+			di, err := m.cl.QueryDelegationInfo(context.Background(), req.stakingTxHash)
 
 			if err != nil {
 				req.ErrorChan() <- fmt.Errorf("failed to retrieve delegation info for staking tx with hash: %s: %w", req.stakingTxHash.String(), err)
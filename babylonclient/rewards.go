@@ -0,0 +1,124 @@
+package babylonclient
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	sdkmath "cosmossdk.io/math"
+	btcstypes "github.com/babylonchain/babylon/x/btcstaking/types"
+	incentivetypes "github.com/babylonchain/babylon/x/incentive/types"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/cosmos/cosmos-sdk/client"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// RewardInfo describes rewards and penalties related to the delegation.
+// Babylon accrues btc staking rewards in a single gauge per staker babylon
+// address, so rewards are shared by all delegations of the same staker.
+type RewardInfo struct {
+	StakerAddress sdk.AccAddress
+	// rewards accrued and not yet withdrawn
+	AccruedRewards sdk.Coins
+	// rewards already withdrawn
+	WithdrawnRewards sdk.Coins
+	// amount which is sent to slashing address if delegation is slashed, slashing
+	// transaction fee is not included
+	SlashingPenalty btcutil.Amount
+}
+
+// GetDelegationRewards queries babylon for rewards accrued by the staker of the
+// delegation with given staking transaction hash, together with penalty which
+// would be paid if the delegation is slashed. Returns ErrRewardsNotSupported if
+// babylon node does not run incentive module.
+func (bc *BabylonController) GetDelegationRewards(ctx context.Context, stakingTxHash *chainhash.Hash) (*RewardInfo, error) {
+	ctx, cancel := getQueryContext(ctx, bc.cfg.Timeout)
+	defer cancel()
+
+	clientCtx := client.Context{Client: bc.bbnClient.RPCClient}
+	stakingQueryClient := btcstypes.NewQueryClient(clientCtx)
+	incentiveQueryClient := incentivetypes.NewQueryClient(clientCtx)
+
+	delegation, err := stakingQueryClient.BTCDelegation(ctx, &btcstypes.QueryBTCDelegationRequest{
+		StakingTxHashHex: stakingTxHash.String(),
+	})
+
+	if err != nil {
+		if strings.Contains(err.Error(), btcstypes.ErrBTCDelegationNotFound.Error()) {
+			return nil, fmt.Errorf("%s: %w", stakingTxHash, ErrDelegationNotFound)
+		}
+		return nil, err
+	}
+
+	params, err := stakingQueryClient.Params(ctx, &btcstypes.QueryParamsRequest{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	gauges, err := incentiveQueryClient.RewardGauges(ctx, &incentivetypes.QueryRewardGaugesRequest{
+		Address: delegation.BtcDelegation.StakerAddr,
+	})
+
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "unknown service"):
+			return nil, ErrRewardsNotSupported
+		case strings.Contains(err.Error(), incentivetypes.ErrRewardGaugeNotFound.Error()):
+			// nothing was accrued by the staker yet
+			gauges = &incentivetypes.QueryRewardGaugesResponse{}
+		default:
+			return nil, err
+		}
+	}
+
+	return rewardInfoFromResponses(delegation.BtcDelegation, params.Params.SlashingRate, gauges)
+}
+
+func rewardInfoFromResponses(
+	delegation *btcstypes.BTCDelegationResponse,
+	slashingRate sdkmath.LegacyDec,
+	gauges *incentivetypes.QueryRewardGaugesResponse,
+) (*RewardInfo, error) {
+	stakerAddress, err := sdk.AccAddressFromBech32(delegation.StakerAddr)
+
+	if err != nil {
+		return nil, fmt.Errorf("malformed staker address: %s: %w", err.Error(), ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	if delegation.TotalSat > math.MaxInt64 {
+		return nil, fmt.Errorf("malformed delegation amount: %d: %w", delegation.TotalSat, ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	if slashingRate.IsNil() || slashingRate.IsNegative() || slashingRate.GT(sdkmath.LegacyOneDec()) {
+		return nil, fmt.Errorf("malformed slashing rate: %w", ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	info := &RewardInfo{
+		StakerAddress:    stakerAddress,
+		AccruedRewards:   sdk.NewCoins(),
+		WithdrawnRewards: sdk.NewCoins(),
+		SlashingPenalty:  btcutil.Amount(slashingRate.MulInt64(int64(delegation.TotalSat)).TruncateInt64()),
+	}
+
+	gauge, found := gauges.RewardGauges[incentivetypes.BTCDelegationType.String()]
+
+	if !found || gauge == nil {
+		return info, nil
+	}
+
+	// gauge keeps all rewards ever accrued, withdrawn ones included
+	accrued, negative := gauge.Coins.SafeSub(gauge.WithdrawnCoins...)
+
+	if negative {
+		return nil, fmt.Errorf("withdrawn rewards %s exceed accrued rewards %s: %w",
+			gauge.WithdrawnCoins, gauge.Coins, ErrInvalidValueReceivedFromBabylonNode)
+	}
+
+	info.AccruedRewards = accrued
+	info.WithdrawnRewards = gauge.WithdrawnCoins
+
+	return info, nil
+}
@@ -0,0 +1,57 @@
+package babylonclient
+
+import (
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/babylonchain/babylon/testutil/datagen"
+	btcstypes "github.com/babylonchain/babylon/x/btcstaking/types"
+	incentivetypes "github.com/babylonchain/babylon/x/incentive/types"
+	"github.com/btcsuite/btcd/btcutil"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewardInfoFromResponses(t *testing.T) {
+	stakerAddr := datagen.GenRandomAccount().GetAddress()
+	delegation := &btcstypes.BTCDelegationResponse{
+		StakerAddr: stakerAddr.String(),
+		TotalSat:   100000,
+	}
+	slashingRate := sdkmath.LegacyNewDecWithPrec(1, 1)
+
+	gauges := &incentivetypes.QueryRewardGaugesResponse{
+		RewardGauges: map[string]*incentivetypes.RewardGaugesResponse{
+			incentivetypes.BTCDelegationType.String(): {
+				Coins:          sdk.NewCoins(sdk.NewInt64Coin("ubbn", 1500)),
+				WithdrawnCoins: sdk.NewCoins(sdk.NewInt64Coin("ubbn", 500)),
+			},
+			// rewards of other stakeholder types are not related to delegation
+			incentivetypes.SubmitterType.String(): {
+				Coins: sdk.NewCoins(sdk.NewInt64Coin("ubbn", 7000)),
+			},
+		},
+	}
+
+	info, err := rewardInfoFromResponses(delegation, slashingRate, gauges)
+	require.NoError(t, err)
+	require.Equal(t, stakerAddr, info.StakerAddress)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("ubbn", 1000)), info.AccruedRewards)
+	require.Equal(t, sdk.NewCoins(sdk.NewInt64Coin("ubbn", 500)), info.WithdrawnRewards)
+	require.Equal(t, btcutil.Amount(10000), info.SlashingPenalty)
+
+	// staker without btc delegation rewards
+	info, err = rewardInfoFromResponses(delegation, slashingRate, &incentivetypes.QueryRewardGaugesResponse{})
+	require.NoError(t, err)
+	require.True(t, info.AccruedRewards.IsZero())
+	require.True(t, info.WithdrawnRewards.IsZero())
+	require.Equal(t, btcutil.Amount(10000), info.SlashingPenalty)
+
+	gauges.RewardGauges[incentivetypes.BTCDelegationType.String()].WithdrawnCoins = sdk.NewCoins(sdk.NewInt64Coin("ubbn", 2000))
+	_, err = rewardInfoFromResponses(delegation, slashingRate, gauges)
+	require.ErrorIs(t, err, ErrInvalidValueReceivedFromBabylonNode)
+
+	delegation.StakerAddr = "invalid"
+	_, err = rewardInfoFromResponses(delegation, slashingRate, gauges)
+	require.ErrorIs(t, err, ErrInvalidValueReceivedFromBabylonNode)
+}
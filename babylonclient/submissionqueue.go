@@ -0,0 +1,59 @@
+package babylonclient
+
+import (
+	"sync"
+
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+)
+
+// submissionQueue serializes submissions of messages signed by the same babylon
+// key. Cosmos sdk accounts have single sequence number, and two transactions
+// built concurrently with the same key will use the same sequence, which makes
+// one of them fail with sequence mismatch. Submissions signed with different
+// keys do not share sequence and are not serialized with each other.
+type submissionQueue struct {
+	mu sync.Mutex
+	// disabled queue passes submissions through without serializing them
+	disabled bool
+	keyLocks map[string]*sync.Mutex
+}
+
+func newSubmissionQueue(disabled bool) *submissionQueue {
+	return &submissionQueue{
+		disabled: disabled,
+		keyLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+func (q *submissionQueue) keyLock(key string) *sync.Mutex {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	lock, found := q.keyLocks[key]
+
+	if !found {
+		lock = &sync.Mutex{}
+		q.keyLocks[key] = lock
+	}
+
+	return lock
+}
+
+// submit runs submission function once all previous submissions signed with
+// given key are finished. Submission function must cover whole process of
+// building, signing and broadcasting the transaction, as sequence number is
+// read during building.
+func (q *submissionQueue) submit(
+	key string,
+	submitFn func() (*pv.RelayerTxResponse, error),
+) (*pv.RelayerTxResponse, error) {
+	if q.disabled {
+		return submitFn()
+	}
+
+	lock := q.keyLock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return submitFn()
+}
@@ -0,0 +1,127 @@
+package babylonclient
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAccount simulates cosmos account sequence handling: transaction is built
+// with current sequence and rejected when it is broadcast after another
+// transaction already used this sequence.
+type mockAccount struct {
+	mu       sync.Mutex
+	sequence uint64
+}
+
+func (a *mockAccount) currentSequence() uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sequence
+}
+
+func (a *mockAccount) broadcast(sequence uint64) (*pv.RelayerTxResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if sequence != a.sequence {
+		return nil, fmt.Errorf("account sequence mismatch, expected %d, got %d", a.sequence, sequence)
+	}
+
+	a.sequence++
+
+	return &pv.RelayerTxResponse{}, nil
+}
+
+func (a *mockAccount) send() (*pv.RelayerTxResponse, error) {
+	sequence := a.currentSequence()
+	// give other submissions chance to read the same sequence
+	time.Sleep(time.Millisecond)
+	return a.broadcast(sequence)
+}
+
+func submitConcurrently(q *submissionQueue, key string, account *mockAccount, n int) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = q.submit(key, account.send)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+func TestSubmissionQueueSerializesSameKey(t *testing.T) {
+	const submissions = 50
+
+	q := newSubmissionQueue(false)
+	account := &mockAccount{}
+
+	for _, err := range submitConcurrently(q, "key", account, submissions) {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, uint64(submissions), account.currentSequence())
+}
+
+func TestDisabledSubmissionQueueCausesSequenceMismatch(t *testing.T) {
+	q := newSubmissionQueue(true)
+	account := &mockAccount{}
+
+	var failed int
+	for _, err := range submitConcurrently(q, "key", account, 50) {
+		if err != nil {
+			failed++
+		}
+	}
+
+	require.Greater(t, failed, 0)
+}
+
+func TestSubmissionQueueDoesNotSerializeDifferentKeys(t *testing.T) {
+	q := newSubmissionQueue(false)
+
+	// both submissions must be running at the same time to finish
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	submit := func() (*pv.RelayerTxResponse, error) {
+		entered <- struct{}{}
+		<-release
+		return &pv.RelayerTxResponse{}, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, key := range []string{"key1", "key2"} {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			_, errs[i] = q.submit(key, submit)
+		}(i, key)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-entered:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("submissions with different keys were serialized")
+		}
+	}
+
+	close(release)
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,124 @@
+package babylonclient
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	sdkErr "cosmossdk.io/errors"
+	"github.com/avast/retry-go/v4"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/sirupsen/logrus"
+)
+
+// retryableSubmissionErrors are errors returned by babylon node which are
+// transient i.e the same submission can succeed if it is sent again
+var retryableSubmissionErrors = []*sdkErr.Error{
+	sdkerrors.ErrWrongSequence,
+	sdkerrors.ErrMempoolIsFull,
+	sdkerrors.ErrTxTimeoutHeight,
+}
+
+// clientUnrecoverableErrors are errors which babylon client must not retry on
+// its own. Insufficient fee errors are handled by gas escalation and retryable
+// submission errors by RetryConfig.
+var clientUnrecoverableErrors = append(
+	append([]*sdkErr.Error{}, insufficientFeeErrors...),
+	retryableSubmissionErrors...,
+)
+
+// isRetryableSubmissionErr returns true if submission was rejected by babylon
+// node due to account sequence mismatch, full mempool or timeout height. Those
+// errors mean submitted transaction was not included, so it can be safely
+// resent. All other errors, including timeouts waiting for inclusion after
+// successful broadcast, are considered permanent.
+func isRetryableSubmissionErr(err error) bool {
+	for _, retryableErr := range retryableSubmissionErrors {
+		// errors returned from node are not always wrapped, so fallback to
+		// checking the message
+		if errors.Is(err, retryableErr) || strings.Contains(err.Error(), retryableErr.Error()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryConfig configures exponential backoff with jitter used to resubmit
+// transactions rejected by babylon due to retryable errors
+type RetryConfig struct {
+	// MaxAttempts is total number of submissions, including the first one
+	MaxAttempts uint
+	// BaseDelay is delay before the first resubmission, it doubles with each
+	// next resubmission
+	BaseDelay time.Duration
+	// MaxDelay bounds delay between resubmissions
+	MaxDelay time.Duration
+}
+
+// send calls send function until it succeeds, fails with permanent error or
+// number of attempts is exhausted. Response of the last attempt is returned
+// together with its error.
+// If included is provided, it is called after each failed attempt to check
+// whether the submission was included in babylon anyway e.g by earlier attempt
+// whose result was lost. In that case nothing is resent, and nil response is
+// returned without error.
+func (c *RetryConfig) send(
+	logger *logrus.Logger,
+	send func() (*pv.RelayerTxResponse, error),
+	included func() (bool, error),
+) (*pv.RelayerTxResponse, error) {
+	var resp *pv.RelayerTxResponse
+
+	attempts := c.MaxAttempts
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	err := retry.Do(func() error {
+		var err error
+		resp, err = send()
+
+		if err == nil || included == nil {
+			return err
+		}
+
+		isIncluded, inclusionErr := included()
+
+		if inclusionErr != nil {
+			logger.WithFields(logrus.Fields{
+				"error":          err,
+				"inclusionError": inclusionErr,
+			}).Warn("Failed to check whether failed submission was included in babylon")
+			return err
+		}
+
+		if isIncluded {
+			logger.WithFields(logrus.Fields{
+				"error": err,
+			}).Info("Failed submission was already included in babylon, not resending it")
+			resp = nil
+			return nil
+		}
+
+		return err
+	},
+		retry.Attempts(attempts),
+		retry.Delay(c.BaseDelay),
+		retry.MaxDelay(c.MaxDelay),
+		retry.MaxJitter(c.BaseDelay),
+		retry.DelayType(retry.CombineDelay(retry.BackOffDelay, retry.RandomDelay)),
+		retry.RetryIf(isRetryableSubmissionErr),
+		RtyErr,
+		retry.OnRetry(func(n uint, err error) {
+			logger.WithFields(logrus.Fields{
+				"attempt":      n + 1,
+				"max_attempts": attempts,
+				"error":        err,
+			}).Warn("Submission to babylon failed with retryable error")
+		}),
+	)
+
+	return resp, err
+}
@@ -0,0 +1,130 @@
+package babylonclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFlakyNode rejects given number of first submissions with rejectErr.
+// Submissions with index in includedFrom or later are included in babylon even
+// if they were reported as failed.
+type mockFlakyNode struct {
+	failures  int
+	rejectErr error
+	attempts  int
+	// zero means failed submissions are never included
+	includedFrom int
+	// number of inclusion checks
+	inclusionChecks int
+}
+
+func (n *mockFlakyNode) included() (bool, error) {
+	n.inclusionChecks++
+	return n.includedFrom > 0 && n.attempts >= n.includedFrom, nil
+}
+
+func (n *mockFlakyNode) send() (*pv.RelayerTxResponse, error) {
+	n.attempts++
+
+	if n.attempts <= n.failures {
+		return nil, n.rejectErr
+	}
+
+	return &pv.RelayerTxResponse{Code: 0}, nil
+}
+
+func TestSubmissionRetry(t *testing.T) {
+	// node errors are not always wrapped, only error message is preserved
+	unwrappedSequenceErr := errors.New(sdkerrors.ErrWrongSequence.Wrap("account sequence mismatch, expected 5, got 4").Error())
+	permanentErr := fmt.Errorf("tx failed: %w", sdkerrors.ErrUnauthorized)
+	timeoutErr := errors.New("timed out waiting for tx to be included in a block")
+
+	cfg := RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name             string
+		node             mockFlakyNode
+		expectedAttempts int
+		expectedChecks   int
+		expectErr        error
+		// response is nil if failed submission was found included
+		expectNilResp bool
+	}{
+		{
+			name:             "sequence mismatch followed by success",
+			node:             mockFlakyNode{failures: 2, rejectErr: sdkerrors.ErrWrongSequence},
+			expectedAttempts: 3,
+			expectedChecks:   2,
+		},
+		{
+			name:             "unwrapped sequence mismatch followed by success",
+			node:             mockFlakyNode{failures: 1, rejectErr: unwrappedSequenceErr},
+			expectedAttempts: 2,
+			expectedChecks:   1,
+		},
+		{
+			name:             "timeout is not retried",
+			node:             mockFlakyNode{failures: 1, rejectErr: timeoutErr},
+			expectedAttempts: 1,
+			expectedChecks:   1,
+			expectErr:        timeoutErr,
+		},
+		{
+			name:             "timed out submission which was included is not resent",
+			node:             mockFlakyNode{failures: 1, rejectErr: timeoutErr, includedFrom: 1},
+			expectedAttempts: 1,
+			expectedChecks:   1,
+			expectNilResp:    true,
+		},
+		{
+			name:             "sequence mismatch after included submission is not resent",
+			node:             mockFlakyNode{failures: 5, rejectErr: sdkerrors.ErrWrongSequence, includedFrom: 2},
+			expectedAttempts: 2,
+			expectedChecks:   2,
+			expectNilResp:    true,
+		},
+		{
+			name:             "attempts exhausted",
+			node:             mockFlakyNode{failures: 5, rejectErr: sdkerrors.ErrWrongSequence},
+			expectedAttempts: 3,
+			expectedChecks:   3,
+			expectErr:        sdkerrors.ErrWrongSequence,
+		},
+		{
+			name:             "permanent error is not retried",
+			node:             mockFlakyNode{failures: 5, rejectErr: permanentErr},
+			expectedAttempts: 1,
+			expectedChecks:   1,
+			expectErr:        sdkerrors.ErrUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			node := tc.node
+			resp, err := cfg.send(logrus.New(), node.send, node.included)
+
+			require.Equal(t, tc.expectedAttempts, node.attempts)
+			require.Equal(t, tc.expectedChecks, node.inclusionChecks)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+			if tc.expectNilResp {
+				require.Nil(t, resp)
+			} else {
+				require.NotNil(t, resp)
+			}
+		})
+	}
+}
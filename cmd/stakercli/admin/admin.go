@@ -73,7 +73,7 @@ func dumpCfg(c *cli.Context) error {
 	defaultConfig := stakercfg.DefaultConfig()
 	fileParser := flags.NewParser(&defaultConfig, flags.Default)
 
-	err := flags.NewIniParser(fileParser).WriteFile(configPath, flags.IniIncludeComments|flags.IniIncludeDefaults)
+	err := stakercfg.WriteConfigFile(fileParser, configPath)
 
 	if err != nil {
 		return err
@@ -1,11 +1,16 @@
 package daemon
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
 
 	"github.com/babylonchain/btc-staker/cmd/stakercli/helpers"
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	service "github.com/babylonchain/btc-staker/stakerservice"
 	dc "github.com/babylonchain/btc-staker/stakerservice/client"
 	"github.com/urfave/cli"
 )
@@ -19,10 +24,14 @@ var DaemonCommands = []cli.Command{
 		Subcommands: []cli.Command{
 			checkDaemonHealthCmd,
 			listOutputsCmd,
+			newAddressCmd,
 			babylonFinalityProvidersCmd,
 			stakeCmd,
+			estimateStakeCostCmd,
 			unstakeCmd,
 			stakingDetailsCmd,
+			stakingConfirmationsCmd,
+			covenantSignatureProgressCmd,
 			listStakingTransactionsCmd,
 			withdrawableTransactionsCmd,
 			unbondCmd,
@@ -38,6 +47,11 @@ const (
 	stakingTransactionHashFlag = "staking-transaction-hash"
 	feeRateFlag                = "fee-rate"
 	stakerAddressFlag          = "staker-address"
+	allowDuplicateFpFlag       = "allow-duplicate-fp"
+	dryRunFlag                 = "dry-run"
+	addressTypeFlag            = "address-type"
+	walletNameFlag             = "wallet"
+	confirmCostFlag            = "confirm-cost"
 )
 
 var (
@@ -72,6 +86,25 @@ var listOutputsCmd = cli.Command{
 	Action: listOutputs,
 }
 
+var newAddressCmd = cli.Command{
+	Name:      "new-address",
+	ShortName: "na",
+	Usage:     "Generate new address in connected wallet.",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  stakingDaemonAddressFlag,
+			Usage: "Full address of the staker daemon in format tcp:://<host>:<port>",
+			Value: defaultStakingDaemonAddress,
+		},
+		cli.StringFlag{
+			Name:  addressTypeFlag,
+			Usage: "Type of generated address {legacy, p2sh-segwit, bech32, bech32m}",
+			Value: "bech32",
+		},
+	},
+	Action: newAddress,
+}
+
 var babylonFinalityProvidersCmd = cli.Command{
 	Name:      "babylon-finality-providers",
 	ShortName: "bfp",
@@ -126,10 +159,58 @@ var stakeCmd = cli.Command{
 			Usage:    "Staking time in BTC blocks",
 			Required: true,
 		},
+		cli.BoolFlag{
+			Name:  allowDuplicateFpFlag,
+			Usage: "Create delegation even if staker already has active delegation to one of the finality providers and daemon is configured to refuse such delegations",
+		},
+		cli.StringFlag{
+			Name:  walletNameFlag,
+			Usage: "Name of additional wallet configured in the daemon which funds staking transaction. Staker address must belong to this wallet. If not provided, main wallet is used",
+		},
+		cli.Int64Flag{
+			Name:  feeRateFlag,
+			Usage: "fee rate to pay for staking tx in sats/kb, empty to use estimated fee rate. Fee rate far from the estimate is handled according to daemon fee rate sanity policy",
+		},
+		cli.BoolFlag{
+			Name:  dryRunFlag,
+			Usage: "Only print unsigned staking transaction which would be created, together with its inputs and fee, without sending it",
+		},
+		cli.BoolFlag{
+			Name:  confirmCostFlag,
+			Usage: "Print estimated total cost of the stake, including funding and withdrawal fees, and ask for confirmation before staking",
+		},
 	},
 	Action: stake,
 }
 
+var estimateStakeCostCmd = cli.Command{
+	Name:      "estimate-stake-cost",
+	ShortName: "esc",
+	Usage:     "Estimate total cost of a stake: staking amount, fee of staking transaction and fee of withdrawing staked funds",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  stakingDaemonAddressFlag,
+			Usage: "full address of the staker daemon in format tcp:://<host>:<port>",
+			Value: defaultStakingDaemonAddress,
+		},
+		cli.Int64Flag{
+			Name:     helpers.StakingAmountFlag,
+			Usage:    "Staking amount in satoshis",
+			Required: true,
+		},
+		cli.Int64Flag{
+			Name:     helpers.StakingTimeBlocksFlag,
+			Usage:    "Staking time in BTC blocks",
+			Required: true,
+		},
+		cli.Int64Flag{
+			Name:  feeRateFlag,
+			Usage: "fee rate in sats/kb used to estimate fees, empty to use estimated fee rate",
+		},
+	},
+	Action: estimateStakeCost,
+}
+
 var unstakeCmd = cli.Command{
 	Name:      "unstake",
 	ShortName: "ust",
@@ -145,6 +226,10 @@ var unstakeCmd = cli.Command{
 			Usage:    "Hash of original staking transaction in bitcoin hex format",
 			Required: true,
 		},
+		cli.IntFlag{
+			Name:  feeRateFlag,
+			Usage: "fee rate to pay for spend tx in sats/kb, empty to use estimated fee rate",
+		},
 	},
 	Action: unstake,
 }
@@ -191,6 +276,44 @@ var stakingDetailsCmd = cli.Command{
 	Action: stakingDetails,
 }
 
+var stakingConfirmationsCmd = cli.Command{
+	Name:      "staking-confirmations",
+	ShortName: "sc",
+	Usage:     "Displays number of confirmations of staking transaction with given hash, 0 if transaction is in mempool and -1 if it is not found",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  stakingDaemonAddressFlag,
+			Usage: "full address of the staker daemon in format tcp:://<host>:<port>",
+			Value: defaultStakingDaemonAddress,
+		},
+		cli.StringFlag{
+			Name:     stakingTransactionHashFlag,
+			Usage:    "Hash of original staking transaction in bitcoin hex format",
+			Required: true,
+		},
+	},
+	Action: stakingConfirmations,
+}
+
+var covenantSignatureProgressCmd = cli.Command{
+	Name:      "covenant-signature-progress",
+	ShortName: "csp",
+	Usage:     "Displays number of covenant signatures gathered by delegation of staking transaction with given hash and number of signatures required to activate it",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  stakingDaemonAddressFlag,
+			Usage: "full address of the staker daemon in format tcp:://<host>:<port>",
+			Value: defaultStakingDaemonAddress,
+		},
+		cli.StringFlag{
+			Name:     stakingTransactionHashFlag,
+			Usage:    "Hash of original staking transaction in bitcoin hex format",
+			Required: true,
+		},
+	},
+	Action: covenantSignatureProgress,
+}
+
 var listStakingTransactionsCmd = cli.Command{
 	Name:      "list-staking-transactions",
 	ShortName: "lst",
@@ -279,6 +402,26 @@ func listOutputs(ctx *cli.Context) error {
 	return nil
 }
 
+func newAddress(ctx *cli.Context) error {
+	daemonAddress := ctx.String(stakingDaemonAddressFlag)
+	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
+	if err != nil {
+		return err
+	}
+
+	sctx := context.Background()
+
+	addr, err := client.NewAddress(sctx, ctx.String(addressTypeFlag))
+
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintRespJSON(addr)
+
+	return nil
+}
+
 func babylonFinalityProviders(ctx *cli.Context) error {
 	daemonAddress := ctx.String(stakingDaemonAddressFlag)
 	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
@@ -324,8 +467,61 @@ func stake(ctx *cli.Context) error {
 	stakingAmount := ctx.Int64(helpers.StakingAmountFlag)
 	fpPks := ctx.StringSlice(fpPksFlag)
 	stakingTimeBlocks := ctx.Int64(helpers.StakingTimeBlocksFlag)
+	feeRate := ctx.Int64(feeRateFlag)
+
+	if feeRate < 0 {
+		return cli.NewExitError("Fee rate must be non-negative", 1)
+	}
+
+	if ctx.Bool(dryRunFlag) {
+		preview, err := client.BuildStakingTx(sctx, ctx.String(walletNameFlag), stakerAddress, stakingAmount, fpPks, stakingTimeBlocks)
+
+		if err != nil {
+			return err
+		}
+
+		helpers.PrintRespJSON(preview)
+
+		return nil
+	}
+
+	if ctx.Bool(confirmCostFlag) {
+		var fr *int64 = nil
+		if feeRate > 0 {
+			fr = &feeRate
+		}
+
+		estimate, err := client.EstimateStakeCost(sctx, stakingAmount, stakingTimeBlocks, fr)
+
+		if err != nil {
+			return err
+		}
+
+		helpers.PrintRespJSON(estimate)
+
+		confirmed, err := confirm("Proceed with staking?")
+
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			return cli.NewExitError("Staking aborted", 1)
+		}
+	}
+
+	var results *service.ResultStake
+	switch {
+	case feeRate > 0:
+		results, err = client.StakeWithFeeRate(sctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, feeRate)
+	case ctx.Bool(allowDuplicateFpFlag):
+		results, err = client.StakeAllowDuplicateFp(sctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks)
+	case ctx.String(walletNameFlag) != "":
+		results, err = client.StakeFromWallet(sctx, ctx.String(walletNameFlag), stakerAddress, stakingAmount, fpPks, stakingTimeBlocks)
+	default:
+		results, err = client.Stake(sctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks)
+	}
 
-	results, err := client.Stake(sctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks)
 	if err != nil {
 		return err
 	}
@@ -335,6 +531,60 @@ func stake(ctx *cli.Context) error {
 	return nil
 }
 
+// confirm asks user yes/no question on standard input. Only explicit yes is
+// treated as confirmation.
+func confirm(question string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	if err != nil && answer == "" {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func estimateStakeCost(ctx *cli.Context) error {
+	daemonAddress := ctx.String(stakingDaemonAddressFlag)
+	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
+	if err != nil {
+		return err
+	}
+
+	sctx := context.Background()
+
+	feeRate := ctx.Int64(feeRateFlag)
+
+	if feeRate < 0 {
+		return cli.NewExitError("Fee rate must be non-negative", 1)
+	}
+
+	var fr *int64 = nil
+	if feeRate > 0 {
+		fr = &feeRate
+	}
+
+	result, err := client.EstimateStakeCost(
+		sctx,
+		ctx.Int64(helpers.StakingAmountFlag),
+		ctx.Int64(helpers.StakingTimeBlocksFlag),
+		fr,
+	)
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintRespJSON(result)
+
+	return nil
+}
+
 func unstake(ctx *cli.Context) error {
 	daemonAddress := ctx.String(stakingDaemonAddressFlag)
 	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
@@ -346,7 +596,18 @@ func unstake(ctx *cli.Context) error {
 
 	stakingTransactionHash := ctx.String(stakingTransactionHashFlag)
 
-	result, err := client.SpendStakingTransaction(sctx, stakingTransactionHash)
+	feeRate := ctx.Int(feeRateFlag)
+
+	if feeRate < 0 {
+		return cli.NewExitError("Fee rate must be non-negative", 1)
+	}
+
+	var fr *int = nil
+	if feeRate > 0 {
+		fr = &feeRate
+	}
+
+	result, err := client.SpendStakingTransaction(sctx, stakingTransactionHash, fr)
 	if err != nil {
 		return err
 	}
@@ -409,6 +670,48 @@ func stakingDetails(ctx *cli.Context) error {
 	return nil
 }
 
+func covenantSignatureProgress(ctx *cli.Context) error {
+	daemonAddress := ctx.String(stakingDaemonAddressFlag)
+	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
+	if err != nil {
+		return err
+	}
+
+	sctx := context.Background()
+
+	stakingTransactionHash := ctx.String(stakingTransactionHashFlag)
+
+	result, err := client.CovenantSignatureProgress(sctx, stakingTransactionHash)
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintRespJSON(result)
+
+	return nil
+}
+
+func stakingConfirmations(ctx *cli.Context) error {
+	daemonAddress := ctx.String(stakingDaemonAddressFlag)
+	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
+	if err != nil {
+		return err
+	}
+
+	sctx := context.Background()
+
+	stakingTransactionHash := ctx.String(stakingTransactionHashFlag)
+
+	result, err := client.StakingTxConfirmations(sctx, stakingTransactionHash)
+	if err != nil {
+		return err
+	}
+
+	helpers.PrintRespJSON(result)
+
+	return nil
+}
+
 func listStakingTransactions(ctx *cli.Context) error {
 	daemonAddress := ctx.String(stakingDaemonAddressFlag)
 	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
@@ -0,0 +1,139 @@
+package delegations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/babylonchain/btc-staker/cmd/stakercli/helpers"
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	service "github.com/babylonchain/btc-staker/stakerservice"
+	dc "github.com/babylonchain/btc-staker/stakerservice/client"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/urfave/cli"
+)
+
+var DelegationsCommands = []cli.Command{
+	{
+		Name:      "delegations",
+		ShortName: "del",
+		Usage:     "Commands to inspect delegations tracked by staker daemon.",
+		Category:  "Daemon commands",
+		Subcommands: []cli.Command{
+			listDelegationsCmd,
+		},
+	},
+}
+
+const (
+	stakingDaemonAddressFlag = "daemon-address"
+	stateFlag                = "state"
+	limitFlag                = "limit"
+	jsonFlag                 = "json"
+)
+
+var (
+	defaultStakingDaemonAddress = "tcp://127.0.0.1:" + strconv.Itoa(scfg.DefaultRPCPort)
+)
+
+var listDelegationsCmd = cli.Command{
+	Name:      "list",
+	ShortName: "ls",
+	Usage:     "List delegations tracked by staker daemon",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  stakingDaemonAddressFlag,
+			Usage: "full address of the staker daemon in format tcp:://<host>:<port>",
+			Value: defaultStakingDaemonAddress,
+		},
+		cli.StringFlag{
+			Name:  stateFlag,
+			Usage: "list only delegations in given state e.g. delegation_active, empty to list all delegations",
+		},
+		cli.IntFlag{
+			Name:  limitFlag,
+			Usage: "maximum number of delegations to return",
+			Value: 100,
+		},
+		cli.BoolFlag{
+			Name:  jsonFlag,
+			Usage: "print delegations as json instead of table",
+		},
+	},
+	Action: listDelegations,
+}
+
+func listDelegations(ctx *cli.Context) error {
+	daemonAddress := ctx.String(stakingDaemonAddressFlag)
+	client, err := dc.NewStakerServiceJsonRpcClient(daemonAddress)
+	if err != nil {
+		return err
+	}
+
+	sctx := context.Background()
+
+	limit := ctx.Int(limitFlag)
+	state := ctx.String(stateFlag)
+
+	var resp *service.ListStakingTransactionsResponse
+
+	if state != "" {
+		resp, err = client.ListStakingTransactionsInState(sctx, nil, &limit, state)
+	} else {
+		resp, err = client.ListStakingTransactions(sctx, nil, &limit)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if ctx.Bool(jsonFlag) {
+		helpers.PrintRespJSON(resp)
+		return nil
+	}
+
+	return printDelegationsTable(resp.Transactions)
+}
+
+// printDelegationsTable prints delegations as table with aligned columns
+func printDelegationsTable(delegations []service.StakingDetails) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "STAKING TX HASH\tAMOUNT\tSTAKING TIME\tSTATE\tCREATED\tLAST STATE CHANGE")
+
+	for _, d := range delegations {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.StakingTxHash,
+			formatAmount(d.StakingAmount),
+			orDash(d.StakingTime),
+			d.StakingState,
+			orDash(d.CreatedAt),
+			orDash(d.LastStateChangeAt),
+		)
+	}
+
+	return w.Flush()
+}
+
+// formatAmount formats amount in satoshis as BTC
+func formatAmount(amount string) string {
+	sat, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return orDash(amount)
+	}
+
+	return btcutil.Amount(sat).String()
+}
+
+// orDash returns "-" for empty values, so that table columns stay aligned
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
@@ -6,6 +6,7 @@ import (
 
 	cmdadmin "github.com/babylonchain/btc-staker/cmd/stakercli/admin"
 	cmddaemon "github.com/babylonchain/btc-staker/cmd/stakercli/daemon"
+	cmddelegations "github.com/babylonchain/btc-staker/cmd/stakercli/delegations"
 	cmdtx "github.com/babylonchain/btc-staker/cmd/stakercli/transaction"
 	"github.com/urfave/cli"
 )
@@ -63,6 +64,7 @@ func main() {
 	app.Commands = append(app.Commands, cmddaemon.DaemonCommands...)
 	app.Commands = append(app.Commands, cmdadmin.AdminCommands...)
 	app.Commands = append(app.Commands, cmdtx.TransactionCommands...)
+	app.Commands = append(app.Commands, cmddelegations.DelegationsCommands...)
 
 	if err := app.Run(os.Args); err != nil {
 		fatal(err)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	staker "github.com/babylonchain/btc-staker/staker"
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
 	service "github.com/babylonchain/btc-staker/stakerservice"
+	"github.com/babylonchain/btc-staker/tracing"
 
 	"github.com/jessevdk/go-flags"
 	"github.com/lightningnetwork/lnd/signal"
@@ -61,7 +63,23 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	dbBackend, err := scfg.GetDbBackend(cfg.DBConfig)
+	dbConfig := cfg.DBConfig
+
+	// simulated delegations must not be mixed with real ones
+	if cfg.StakerConfig.SimulateOnly {
+		dbConfig, err = scfg.TemporaryDBConfig(cfg.DBConfig)
+
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		defer os.RemoveAll(dbConfig.DBPath)
+
+		cfgLogger.Warnf("Simulate only mode, data is stored in temporary database in %s", dbConfig.DBPath)
+	}
+
+	dbBackend, err := scfg.GetDbBackend(dbConfig)
 
 	if err != nil {
 		err = fmt.Errorf("failed to load db backend: %w", err)
@@ -71,13 +89,27 @@ func main() {
 
 	stakerMetrics := metrics.NewStakerMetrics()
 
+	tracerProvider, shutdownTracing, err := tracing.NewTracerProvider(context.Background(), cfg.TracingConfig)
+
+	if err != nil {
+		cfgLogger.Errorf("failed to create tracer provider: %v", err)
+		os.Exit(1)
+	}
+
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			cfgLogger.Errorf("failed to shutdown tracer provider: %v", err)
+		}
+	}()
+
 	// TODO: consider moving this to stakerservice
 	staker, err := staker.NewStakerAppFromConfig(
 		cfg,
-		cfgLogger,
+		staker.NewLogrusLogger(cfgLogger),
 		zapLogger,
 		dbBackend,
 		stakerMetrics,
+		tracerProvider,
 	)
 
 	if err != nil {
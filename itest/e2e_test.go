@@ -32,6 +32,7 @@ import (
 	"github.com/babylonchain/btc-staker/proto"
 	"github.com/babylonchain/btc-staker/staker"
 	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/stakerdb"
 	service "github.com/babylonchain/btc-staker/stakerservice"
 	dc "github.com/babylonchain/btc-staker/stakerservice/client"
 	"github.com/babylonchain/btc-staker/types"
@@ -40,11 +41,14 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
 	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	sdkquerytypes "github.com/cosmos/cosmos-sdk/types/query"
@@ -283,7 +287,7 @@ func StartManager(
 	require.NoError(t, err)
 
 	m := metrics.NewStakerMetrics()
-	stakerApp, err := staker.NewStakerAppFromConfig(cfg, logger, zapLogger, dbbackend, m)
+	stakerApp, err := staker.NewStakerAppFromConfig(cfg, staker.NewLogrusLogger(logger), zapLogger, dbbackend, m, nil)
 	require.NoError(t, err)
 	// we require separate client to send BTC headers to babylon node (interface does not need this method?)
 	bl, err := babylonclient.NewBabylonController(cfg.BabylonConfig, &cfg.ActiveNetParams, logger, zapLogger)
@@ -291,7 +295,7 @@ func StartManager(
 
 	walletClient := stakerApp.Wallet()
 
-	err = walletClient.UnlockWallet(20)
+	err = walletClient.UnlockWallet(cfg.WalletConfig.UnlockTimeoutSecs())
 	require.NoError(t, err)
 
 	walletPrivKey, err := walletClient.DumpPrivateKey(minerAddressDecoded)
@@ -356,11 +360,19 @@ func (tm *TestManager) Stop(t *testing.T) {
 }
 
 func (tm *TestManager) RestartApp(t *testing.T) {
-	// First stop the app
+	tm.StopApp()
+	tm.StartApp(t)
+}
+
+// StopApp stops running staker app, it can be started again with StartApp
+func (tm *TestManager) StopApp() {
 	tm.serverStopper.RequestShutdown()
 	tm.wg.Wait()
+}
 
-	// Now reset all components and start again
+// StartApp starts new staker app, using the same config and database as
+// previously stopped one
+func (tm *TestManager) StartApp(t *testing.T) {
 	logger := logrus.New()
 	logger.SetLevel(logrus.DebugLevel)
 	logger.Out = os.Stdout
@@ -368,7 +380,7 @@ func (tm *TestManager) RestartApp(t *testing.T) {
 	dbbackend, err := stakercfg.GetDbBackend(tm.Config.DBConfig)
 	require.NoError(t, err)
 	m := metrics.NewStakerMetrics()
-	stakerApp, err := staker.NewStakerAppFromConfig(tm.Config, logger, zapLogger, dbbackend, m)
+	stakerApp, err := staker.NewStakerAppFromConfig(tm.Config, staker.NewLogrusLogger(logger), zapLogger, dbbackend, m, nil)
 	require.NoError(t, err)
 
 	interceptor, err := signal.Intercept()
@@ -502,30 +514,34 @@ func (tm *TestManager) FinalizeUntilEpoch(t *testing.T, epoch uint64) {
 			btcCheckpoint,
 		)
 
-		err = tm.Sa.Wallet().UnlockWallet(60)
+		err = tm.Sa.Wallet().UnlockWallet(tm.Config.WalletConfig.UnlockTimeoutSecs())
 		require.NoError(t, err)
 		tx1, err := tm.Sa.Wallet().CreateAndSignTx(
+			context.Background(),
 			[]*wire.TxOut{
 				wire.NewTxOut(0, opReturnScript(p1)),
 			},
 			2000,
 			tm.MinerAddr,
+			false,
 		)
 		require.NoError(t, err)
-		_, err = tm.Sa.Wallet().SendRawTransaction(tx1, true)
+		_, err = tm.Sa.Wallet().SendRawTransaction(context.Background(), tx1, true)
 		require.NoError(t, err)
 
 		resp1 := tm.BitcoindHandler.GenerateBlocks(1)
 
 		tx2, err := tm.Sa.Wallet().CreateAndSignTx(
+			context.Background(),
 			[]*wire.TxOut{
 				wire.NewTxOut(0, opReturnScript(p2)),
 			},
 			2000,
 			tm.MinerAddr,
+			false,
 		)
 		require.NoError(t, err)
-		_, err = tm.Sa.Wallet().SendRawTransaction(tx2, true)
+		_, err = tm.Sa.Wallet().SendRawTransaction(context.Background(), tx2, true)
 		require.NoError(t, err)
 		resp2 := tm.BitcoindHandler.GenerateBlocks(1)
 
@@ -582,12 +598,12 @@ func (tm *TestManager) FinalizeUntilEpoch(t *testing.T, epoch uint64) {
 }
 
 func (tm *TestManager) createAndRegisterFinalityProviders(t *testing.T, testStakingData *testStakingData) {
-	params, err := tm.BabylonClient.QueryStakingTracker()
+	params, err := tm.BabylonClient.QueryStakingTracker(context.Background())
 	require.NoError(t, err)
 
 	for i := 0; i < testStakingData.GetNumRestakedFPs(); i++ {
 		// ensure the finality provider in testStakingData does not exist yet
-		fpResp, err := tm.BabylonClient.QueryFinalityProvider(testStakingData.FinalityProviderBtcKeys[i])
+		fpResp, err := tm.BabylonClient.QueryFinalityProvider(context.Background(), testStakingData.FinalityProviderBtcKeys[i])
 		require.Nil(t, fpResp)
 		require.Error(t, err)
 		require.True(t, errors.Is(err, babylonclient.ErrFinalityProviderDoesNotExist))
@@ -598,7 +614,7 @@ func (tm *TestManager) createAndRegisterFinalityProviders(t *testing.T, testStak
 		btcFpKey := bbntypes.NewBIP340PubKeyFromBTCPK(testStakingData.FinalityProviderBtcKeys[i])
 
 		// get current finality providers
-		resp, err := tm.BabylonClient.QueryFinalityProviders(100, 0)
+		resp, err := tm.BabylonClient.QueryFinalityProviders(context.Background(), 100, 0)
 		require.NoError(t, err)
 		// register the generated finality provider
 		err = tm.BabylonClient.RegisterFinalityProvider(
@@ -614,7 +630,7 @@ func (tm *TestManager) createAndRegisterFinalityProviders(t *testing.T, testStak
 		require.NoError(t, err)
 
 		require.Eventually(t, func() bool {
-			resp2, err := tm.BabylonClient.QueryFinalityProviders(100, 0)
+			resp2, err := tm.BabylonClient.QueryFinalityProviders(context.Background(), 100, 0)
 			require.NoError(t, err)
 
 			// After registration we should have one finality provider
@@ -750,17 +766,19 @@ func (tm *TestManager) sendWatchedStakingTx(
 	)
 	require.NoError(t, err)
 
-	err = tm.Sa.Wallet().UnlockWallet(20)
+	err = tm.Sa.Wallet().UnlockWallet(tm.Config.WalletConfig.UnlockTimeoutSecs())
 	require.NoError(t, err)
 
 	tx, err := tm.Sa.Wallet().CreateAndSignTx(
+		context.Background(),
 		[]*wire.TxOut{stakingInfo.StakingOutput},
 		2000,
 		tm.MinerAddr,
+		false,
 	)
 	require.NoError(t, err)
 	txHash := tx.TxHash()
-	_, err = tm.Sa.Wallet().SendRawTransaction(tx, true)
+	_, err = tm.Sa.Wallet().SendRawTransaction(context.Background(), tx, true)
 	require.NoError(t, err)
 
 	// Wait for tx to be in mempool
@@ -900,7 +918,7 @@ func (tm *TestManager) sendWatchedStakingTx(
 }
 
 func (tm *TestManager) spendStakingTxWithHash(t *testing.T, stakingTxHash *chainhash.Hash) (*chainhash.Hash, *btcutil.Amount) {
-	res, err := tm.StakerClient.SpendStakingTransaction(context.Background(), stakingTxHash.String())
+	res, err := tm.StakerClient.SpendStakingTransaction(context.Background(), stakingTxHash.String(), nil)
 	require.NoError(t, err)
 	spendTxHash, err := chainhash.NewHashFromStr(res.TxHash)
 	require.NoError(t, err)
@@ -978,7 +996,7 @@ func (tm *TestManager) insertCovenantSigForDelegation(t *testing.T, btcDel *btcs
 	require.NoError(t, err)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 
 	stakingInfo, err := staking.BuildStakingInfo(
@@ -1060,7 +1078,7 @@ func TestStakingFailures(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 
@@ -1100,7 +1118,7 @@ func TestSendingStakingTransaction(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 
@@ -1175,7 +1193,7 @@ func TestMultipleWithdrawableStakingTransactions(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	minStakingTime := uint16(staker.GetMinStakingTime(params))
 	stakingTime1 := minStakingTime
@@ -1241,7 +1259,7 @@ func TestSendingWatchedStakingTransaction(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
@@ -1253,6 +1271,122 @@ func TestSendingWatchedStakingTransaction(t *testing.T) {
 	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
 }
 
+func TestPreparedDelegationSignedByOfflineKey(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	// staker key is never imported into the wallet, it simulates key held offline
+	coldStakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	testStakingData := tm.getTestStakingData(t, coldStakerKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	fpBTCPK := hex.EncodeToString(schnorr.SerializePubKey(testStakingData.FinalityProviderBtcKeys[0]))
+	prepared, err := tm.StakerClient.PrepareDelegation(
+		context.Background(),
+		tm.MinerAddr.String(),
+		hex.EncodeToString(schnorr.SerializePubKey(coldStakerKey.PubKey())),
+		testStakingData.StakingAmount,
+		[]string{fpBTCPK},
+		int64(testStakingData.StakingTime),
+	)
+	require.NoError(t, err)
+
+	details, err := tm.StakerClient.StakingDetails(context.Background(), prepared.StakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_PREPARED.String(), details.StakingState)
+
+	stakingTxHash, err := chainhash.NewHashFromStr(prepared.StakingTxHash)
+	require.NoError(t, err)
+	// prepared staking transaction must not be broadcasted
+	_, err = tm.TestRpcClient.GetRawTransaction(stakingTxHash)
+	require.Error(t, err)
+
+	// offline signer receives prepared data and signs it with staker key
+	decodeTx := func(txHex string) *wire.MsgTx {
+		txBytes, err := hex.DecodeString(txHex)
+		require.NoError(t, err)
+		var tx wire.MsgTx
+		require.NoError(t, tx.Deserialize(bytes.NewReader(txBytes)))
+		return &tx
+	}
+
+	stakingTx := decodeTx(prepared.StakingTx)
+	stakingOutputIdx, err := strconv.ParseUint(prepared.StakingOutputIdx, 10, 32)
+	require.NoError(t, err)
+	slashingPathScript, err := hex.DecodeString(prepared.SlashingPathScript)
+	require.NoError(t, err)
+	unbondingTx := decodeTx(prepared.UnbondingTx)
+	unbondingSlashingPathScript, err := hex.DecodeString(prepared.UnbondingSlashingPathScript)
+	require.NoError(t, err)
+
+	slashSig, err := staking.SignTxWithOneScriptSpendInputFromScript(
+		decodeTx(prepared.SlashingTx),
+		stakingTx.TxOut[stakingOutputIdx],
+		coldStakerKey,
+		slashingPathScript,
+	)
+	require.NoError(t, err)
+
+	slashUnbondingSig, err := staking.SignTxWithOneScriptSpendInputFromScript(
+		decodeTx(prepared.SlashUnbondingTx),
+		unbondingTx.TxOut[0],
+		coldStakerKey,
+		unbondingSlashingPathScript,
+	)
+	require.NoError(t, err)
+
+	stakerBabylonAddr, err := sdk.AccAddressFromBech32(prepared.StakerBabylonAddr)
+	require.NoError(t, err)
+	pop, err := btcstypes.NewPoPBTC(stakerBabylonAddr, coldStakerKey)
+	require.NoError(t, err)
+
+	res, err := tm.StakerClient.SubmitPreparedDelegation(
+		context.Background(),
+		prepared.StakingTxHash,
+		hex.EncodeToString(pop.BtcSig),
+		int(btcstypes.BTCSigType_BIP340),
+		hex.EncodeToString(slashSig.Serialize()),
+		hex.EncodeToString(slashUnbondingSig.Serialize()),
+	)
+	require.NoError(t, err)
+	require.Equal(t, prepared.StakingTxHash, res.TxHash)
+
+	txs := retrieveTransactionFromMempool(t, tm.TestRpcClient, []*chainhash.Hash{stakingTxHash})
+	require.Len(t, txs, 1)
+
+	// delegation can be submitted only once
+	_, err = tm.StakerClient.SubmitPreparedDelegation(
+		context.Background(),
+		prepared.StakingTxHash,
+		hex.EncodeToString(pop.BtcSig),
+		int(btcstypes.BTCSigType_BIP340),
+		hex.EncodeToString(slashSig.Serialize()),
+		hex.EncodeToString(slashUnbondingSig.Serialize()),
+	)
+	require.Error(t, err)
+
+	mBlock := tm.mineBlock(t)
+	require.Equal(t, 2, len(mBlock.Transactions))
+	_, err = tm.BabylonClient.InsertBtcBlockHeaders([]*wire.BlockHeader{&mBlock.Header})
+	require.NoError(t, err)
+
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, stakingTxHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	details, err = tm.StakerClient.StakingDetails(context.Background(), prepared.StakingTxHash)
+	require.NoError(t, err)
+	require.True(t, details.Watched)
+}
+
 func TestRestartingTxNotDeepEnough(t *testing.T) {
 	// need to have at least 300 block on testnet as only then segwit is activated.
 	// Mature output is out which has 100 confirmations, which means 200mature outputs
@@ -1263,7 +1397,7 @@ func TestRestartingTxNotDeepEnough(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
@@ -1278,6 +1412,169 @@ func TestRestartingTxNotDeepEnough(t *testing.T) {
 	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
 }
 
+func TestStakingSpendInfoMatchesTaprootCommitment(t *testing.T) {
+	// need to have at least 300 block on testnet as only then segwit is activated.
+	// Mature output is out which has 100 confirmations, which means 200mature outputs
+	// will generate 300 blocks
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	script, controlBlockBytes, leafVersion, err := tm.Sa.GetStakingSpendInfo(context.Background(), txHash)
+	require.NoError(t, err)
+	require.Equal(t, byte(txscript.BaseLeafVersion), leafVersion)
+
+	storedTx, err := tm.Sa.GetStoredTransaction(txHash)
+	require.NoError(t, err)
+	stakingPkScript := storedTx.StakingTx.TxOut[storedTx.StakingOutputIndex].PkScript
+	require.True(t, txscript.IsPayToTaproot(stakingPkScript))
+
+	controlBlock, err := txscript.ParseControlBlock(controlBlockBytes)
+	require.NoError(t, err)
+
+	// taproot output key is committed in the pk script after version and push opcodes
+	err = txscript.VerifyTaprootLeafCommitment(controlBlock, stakingPkScript[2:], script)
+	require.NoError(t, err)
+}
+
+func TestSpendingStakeRespectsDestinationAllowList(t *testing.T) {
+	// need to have at least 300 block on testnet as only then segwit is activated.
+	// Mature output is out which has 100 confirmations, which means 200mature outputs
+	// will generate 300 blocks
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	pend, err := tm.BabylonClient.QueryPendingBTCDelegations()
+	require.NoError(t, err)
+	require.Len(t, pend, 1)
+	tm.insertCovenantSigForDelegation(t, pend[0])
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_DELEGATION_ACTIVE)
+
+	// mine enough blocks for staking time lock to expire
+	tm.mineNEmptyBlocks(t, uint32(testStakingData.StakingTime), false)
+
+	require.Eventually(t, func() bool {
+		withdrawableTransactionsResp, err := tm.StakerClient.WithdrawableTransactions(context.Background(), nil, nil)
+		require.NoError(t, err)
+		return len(withdrawableTransactionsResp.Transactions) > 0
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	// staker address is not on the allow-list, withdrawal must be refused
+	otherAddress, err := datagen.GenRandomBTCAddress(r, regtestParams)
+	require.NoError(t, err)
+	tm.Config.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{otherAddress}
+	tm.RestartApp(t)
+
+	_, err = tm.StakerClient.SpendStakingTransaction(context.Background(), txHash.String(), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), staker.ErrDestinationNotAllowed.Error())
+
+	// withdrawal to allow-listed staker address succeeds
+	tm.Config.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{otherAddress, tm.MinerAddr}
+	tm.RestartApp(t)
+
+	spendTxHash, _ := tm.spendStakingTxWithHash(t, txHash)
+	require.NotNil(t, spendTxHash)
+}
+
+func TestStakingRefusedUntilBabylonLightClientCatchesUp(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+
+	// babylon btc light client did not receive any of the mined headers yet
+	tm.Config.StakerConfig.MaxBabylonBtcLag = 1
+	tm.RestartApp(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	fpBTCPK := hex.EncodeToString(schnorr.SerializePubKey(testStakingData.FinalityProviderBtcKeys[0]))
+	_, err = tm.StakerClient.Stake(
+		context.Background(),
+		tm.MinerAddr.String(),
+		testStakingData.StakingAmount,
+		[]string{fpBTCPK},
+		int64(testStakingData.StakingTime),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), staker.ErrBabylonLightClientNotReady.Error())
+
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+}
+
+func TestRestartingManyTxNotDeepEnough(t *testing.T) {
+	// need to have at least 300 block on testnet as only then segwit is activated.
+	// Mature output is out which has 100 confirmations, which means 200mature outputs
+	// will generate 300 blocks
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	baseStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, baseStakingData)
+
+	// more delegations than concurrent status checks, so that checks are done
+	// in multiple batches
+	numDelegations := 12
+	var stakingData []*testStakingData
+	for i := 0; i < numDelegations; i++ {
+		stakingData = append(stakingData, baseStakingData.withStakingAmout(int64(10000+i*1000)))
+	}
+
+	txHashes := tm.sendMultipleStakingTx(t, stakingData)
+
+	// restart app when txs are not deep enough
+	tm.Config.StakerConfig.MaxConcurrentStatusChecks = 5
+	tm.RestartApp(t)
+
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+
+	for _, txHash := range txHashes {
+		tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+	}
+}
+
 func TestRestartingTxNotOnBabylon(t *testing.T) {
 	// need to have at least 300 block on testnet as only then segwit is activated.
 	// Mature output is out which has 100 confirmations, which means 200mature outputs
@@ -1288,7 +1585,7 @@ func TestRestartingTxNotOnBabylon(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 
@@ -1330,7 +1627,7 @@ func TestStakingUnbonding(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	// large staking time
 	stakingTime := uint16(1000)
@@ -1401,7 +1698,7 @@ func TestUnbondingRestartWaitingForSignatures(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	// large staking time
 	stakingTime := uint16(1000)
@@ -1502,19 +1799,22 @@ func TestBitcoindWalletRpcApi(t *testing.T) {
 	toSend, err := btcutil.NewAmount(1)
 	require.NoError(t, err)
 	newOutput := wire.NewTxOut(int64(toSend), payScript)
-	err = wc.UnlockWallet(20)
+	err = wc.UnlockWallet(scfg.WalletConfig.UnlockTimeoutSecs())
 	require.NoError(t, err)
 
 	// create transaction which shouls split one of the wallet outputs into two
 	tx, err := wc.CreateAndSignTx(
+		context.Background(),
 		[]*wire.TxOut{newOutput},
 		btcutil.Amount(2000),
 		walletAddress,
+		false,
 	)
 	require.NoError(t, err)
 
 	// send transaction to bitcoin node, it should be accepted
 	txHash, err := wc.SendRawTransaction(
+		context.Background(),
 		tx,
 		false,
 	)
@@ -1548,12 +1848,12 @@ func TestBitcoindWalletBip322Signing(t *testing.T) {
 	controller, err := walletcontroller.NewRpcWalletController(cfg)
 	require.NoError(t, err)
 
-	err = controller.UnlockWallet(30)
+	err = controller.UnlockWallet(cfg.WalletConfig.UnlockTimeoutSecs())
 	require.NoError(t, err)
 
 	msg := []byte("test message")
 
-	bip322Signature, err := controller.SignBip322NativeSegwit(msg, segwitAddress)
+	bip322Signature, err := controller.SignBip322NativeSegwit(context.Background(), msg, segwitAddress)
 	require.NoError(t, err)
 
 	err = bip322.Verify(msg, bip322Signature, segwitAddress, regtestParams)
@@ -1570,7 +1870,7 @@ func TestSendingStakingTransaction_Restaking(t *testing.T) {
 	tm.insertAllMinedBlocksToBabylon(t)
 
 	cl := tm.Sa.BabylonController()
-	params, err := cl.Params()
+	params, err := cl.Params(context.Background())
 	require.NoError(t, err)
 	stakingTime := uint16(staker.GetMinStakingTime(params))
 
@@ -1600,3 +1900,740 @@ func TestSendingStakingTransaction_Restaking(t *testing.T) {
 	tm.insertCovenantSigForDelegation(t, pend[0])
 	tm.waitForStakingTxState(t, txHash, proto.TransactionState_DELEGATION_ACTIVE)
 }
+
+func TestStakingWithPerOperationPassphrase(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	// start from locked wallet
+	err = tm.Sa.Wallet().LockWallet()
+	require.NoError(t, err)
+
+	passphraseRequested := false
+	txHash, err := tm.Sa.StakeFunds(
+		context.Background(),
+		tm.MinerAddr,
+		btcutil.Amount(testStakingData.StakingAmount),
+		testStakingData.FinalityProviderBtcKeys,
+		testStakingData.StakingTime,
+		staker.StakeOptions{
+			PassphraseProvider: func() (string, error) {
+				passphraseRequested = true
+				return "pass", nil
+			},
+		},
+	)
+	require.NoError(t, err)
+	require.True(t, passphraseRequested)
+
+	// wallet must be locked right after the operation
+	walletInfo, err := tm.TestRpcClient.GetWalletInfo()
+	require.NoError(t, err)
+	require.NotNil(t, walletInfo.UnlockedUntil)
+	require.Equal(t, 0, *walletInfo.UnlockedUntil)
+
+	require.Eventually(t, func() bool {
+		txFromMempool := retrieveTransactionFromMempool(t, tm.TestRpcClient, []*chainhash.Hash{txHash})
+		return len(txFromMempool) == 1
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	// wrong passphrase must not unlock the wallet
+	_, err = tm.Sa.StakeFunds(
+		context.Background(),
+		tm.MinerAddr,
+		btcutil.Amount(testStakingData.StakingAmount),
+		testStakingData.FinalityProviderBtcKeys,
+		testStakingData.StakingTime,
+		staker.StakeOptions{
+			PassphraseProvider: func() (string, error) {
+				return "wrong-pass", nil
+			},
+		},
+	)
+	require.Error(t, err)
+
+	// delegation was signed while the wallet was unlocked for staking, so it is
+	// sent to babylon without unlocking the wallet again
+	store, err := stakerdb.NewTrackedTransactionStore(tm.Db)
+	require.NoError(t, err)
+	delegationData, err := store.GetWatchedTransactionData(txHash)
+	require.NoError(t, err)
+	require.True(t, delegationData.StakerBtcPubKey.IsEqual(tm.WalletPrivKey.PubKey()))
+
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	walletInfo, err = tm.TestRpcClient.GetWalletInfo()
+	require.NoError(t, err)
+	require.NotNil(t, walletInfo.UnlockedUntil)
+	require.Equal(t, 0, *walletInfo.UnlockedUntil)
+
+	pend, err := tm.BabylonClient.QueryPendingBTCDelegations()
+	require.NoError(t, err)
+	require.Len(t, pend, 1)
+	tm.insertCovenantSigForDelegation(t, pend[0])
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_DELEGATION_ACTIVE)
+}
+
+func TestStakingTxConfirmations(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	fpBTCPK := hex.EncodeToString(schnorr.SerializePubKey(testStakingData.FinalityProviderBtcKeys[0]))
+	res, err := tm.StakerClient.Stake(
+		context.Background(),
+		tm.MinerAddr.String(),
+		testStakingData.StakingAmount,
+		[]string{fpBTCPK},
+		int64(testStakingData.StakingTime),
+	)
+	require.NoError(t, err)
+	txHash, err := chainhash.NewHashFromStr(res.TxHash)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		txFromMempool := retrieveTransactionFromMempool(t, tm.TestRpcClient, []*chainhash.Hash{txHash})
+		return len(txFromMempool) == 1
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	confirmations, err := tm.Sa.GetConfirmations(txHash)
+	require.NoError(t, err)
+	require.Equal(t, 0, confirmations)
+
+	for expected := 1; expected <= 3; expected++ {
+		tm.mineBlock(t)
+
+		require.Eventually(t, func() bool {
+			confirmations, err := tm.Sa.GetConfirmations(txHash)
+			require.NoError(t, err)
+			return confirmations == expected
+		}, eventuallyWaitTimeOut, eventuallyPollTime)
+	}
+
+	confResp, err := tm.StakerClient.StakingTxConfirmations(context.Background(), txHash.String())
+	require.NoError(t, err)
+	require.Equal(t, "3", confResp.Confirmations)
+
+	// transaction not tracked by staker
+	unknownHash := datagen.GenRandomBtcdHash(r)
+	_, err = tm.Sa.GetConfirmations(&unknownHash)
+	require.Error(t, err)
+}
+
+func TestBitcoindWalletImportPrivKeys(t *testing.T) {
+	h := NewBitcoindHandler(t)
+	h.Start()
+	passphrase := "pass"
+	numMatureOutputs := 1
+	_ = h.CreateWallet("test-wallet", passphrase)
+	_ = h.GenerateBlocks(numMatureOutputs + 100)
+
+	cfg, _ := defaultStakerConfig(t, passphrase)
+
+	wc, err := walletcontroller.NewRpcWalletController(cfg)
+	require.NoError(t, err)
+
+	outputs, err := wc.ListOutputs(true)
+	require.NoError(t, err)
+	require.Len(t, outputs, numMatureOutputs)
+	walletAddress, err := btcutil.DecodeAddress(outputs[0].Address, regtestParams)
+	require.NoError(t, err)
+
+	// fund few addresses which are not controlled by the wallet
+	numKeys := 5
+	amount := btcutil.Amount(100000)
+	var keys []*btcutil.WIF
+	var addresses []btcutil.Address
+	var txOuts []*wire.TxOut
+	for i := 0; i < numKeys; i++ {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		wif, err := btcutil.NewWIF(privKey, regtestParams, true)
+		require.NoError(t, err)
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(
+			btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+			regtestParams,
+		)
+		require.NoError(t, err)
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+
+		keys = append(keys, wif)
+		addresses = append(addresses, addr)
+		txOuts = append(txOuts, wire.NewTxOut(int64(amount), pkScript))
+	}
+
+	err = wc.UnlockWallet(cfg.WalletConfig.UnlockTimeoutSecs())
+	require.NoError(t, err)
+	tx, err := wc.CreateAndSignTx(context.Background(), txOuts, btcutil.Amount(2000), walletAddress, false)
+	require.NoError(t, err)
+	_, err = wc.SendRawTransaction(context.Background(), tx, false)
+	require.NoError(t, err)
+	h.GenerateBlocks(1)
+
+	outputs, err = wc.ListOutputs(false)
+	require.NoError(t, err)
+	for _, addr := range addresses {
+		require.False(t, containsOutput(outputs, addr.EncodeAddress(), amount))
+	}
+
+	err = wc.ImportPrivKeys(keys, true)
+	require.NoError(t, err)
+
+	outputs, err = wc.ListOutputs(false)
+	require.NoError(t, err)
+	for _, addr := range addresses {
+		require.True(t, containsOutput(outputs, addr.EncodeAddress(), amount), "Not found expected output")
+	}
+}
+
+func TestBitcoindWalletImportXpubGapLimit(t *testing.T) {
+	h := NewBitcoindHandler(t)
+	h.Start()
+	passphrase := "pass"
+	numMatureOutputs := 1
+	_ = h.CreateWallet("test-wallet", passphrase)
+	_ = h.GenerateBlocks(numMatureOutputs + 100)
+
+	cfg, _ := defaultStakerConfig(t, passphrase)
+
+	wc, err := walletcontroller.NewRpcWalletController(cfg)
+	require.NoError(t, err)
+
+	outputs, err := wc.ListOutputs(true)
+	require.NoError(t, err)
+	require.Len(t, outputs, numMatureOutputs)
+	walletAddress, err := btcutil.DecodeAddress(outputs[0].Address, regtestParams)
+	require.NoError(t, err)
+
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.RecommendedSeedLen)
+	require.NoError(t, err)
+	master, err := hdkeychain.NewMaster(seed, regtestParams)
+	require.NoError(t, err)
+	xpub, err := master.Neuter()
+	require.NoError(t, err)
+
+	// fund address at index beyond the gap limit used during import
+	gapLimit := uint32(5)
+	highIndex := uint32(12)
+	child, err := xpub.Derive(highIndex)
+	require.NoError(t, err)
+	childPubKey, err := child.ECPubKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(childPubKey.SerializeCompressed()),
+		regtestParams,
+	)
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	amount := btcutil.Amount(100000)
+	err = wc.UnlockWallet(cfg.WalletConfig.UnlockTimeoutSecs())
+	require.NoError(t, err)
+	tx, err := wc.CreateAndSignTx(context.Background(), []*wire.TxOut{wire.NewTxOut(int64(amount), pkScript)}, btcutil.Amount(2000), walletAddress, false)
+	require.NoError(t, err)
+	_, err = wc.SendRawTransaction(context.Background(), tx, false)
+	require.NoError(t, err)
+	h.GenerateBlocks(1)
+
+	err = wc.ImportXpub(xpub, gapLimit, true)
+	require.NoError(t, err)
+
+	outputs, err = wc.ListOutputs(false)
+	require.NoError(t, err)
+	require.False(t, containsOutput(outputs, addr.EncodeAddress(), amount))
+
+	err = wc.RescanAddressRange(xpub, gapLimit, highIndex+1)
+	require.NoError(t, err)
+
+	outputs, err = wc.ListOutputs(false)
+	require.NoError(t, err)
+	require.True(t, containsOutput(outputs, addr.EncodeAddress(), amount), "Not found expected output")
+}
+
+func TestEstimateLifecycleFees(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	stakingTx, err := tm.TestRpcClient.GetRawTransaction(txHash)
+	require.NoError(t, err)
+
+	var inputsValue int64
+	for _, in := range stakingTx.MsgTx().TxIn {
+		prevTx, err := tm.TestRpcClient.GetRawTransaction(&in.PreviousOutPoint.Hash)
+		require.NoError(t, err)
+		inputsValue += prevTx.MsgTx().TxOut[in.PreviousOutPoint.Index].Value
+	}
+
+	var outputsValue int64
+	for _, out := range stakingTx.MsgTx().TxOut {
+		outputsValue += out.Value
+	}
+
+	actualStakingFee := btcutil.Amount(inputsValue - outputsValue)
+	// derive fee rate used by staker from actual staking transaction
+	feeRate := btcutil.Amount(int64(actualStakingFee) * 1000 / mempool.GetTxVirtualSize(stakingTx))
+
+	estimate, err := tm.Sa.EstimateLifecycleFees(
+		context.Background(),
+		btcutil.Amount(testStakingData.StakingAmount),
+		stakingTime,
+		feeRate,
+	)
+	require.NoError(t, err)
+
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	// mine enough blocks for staking time lock to expire
+	tm.mineNEmptyBlocks(t, uint32(stakingTime)-params.ConfirmationTimeBlocks-1, false)
+
+	require.Eventually(t, func() bool {
+		withdrawableTransactionsResp, err := tm.StakerClient.WithdrawableTransactions(context.Background(), nil, nil)
+		require.NoError(t, err)
+		return len(withdrawableTransactionsResp.Transactions) > 0
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	_, spendTxValue := tm.spendStakingTxWithHash(t, txHash)
+	actualWithdrawalFee := btcutil.Amount(testStakingData.StakingAmount) - *spendTxValue
+
+	// estimate funds staking transaction from remaining wallet outputs, so only
+	// approximate match is expected
+	require.InEpsilon(t, float64(actualStakingFee+actualWithdrawalFee), float64(estimate.FundingFee+estimate.WithdrawalFee), 0.25)
+}
+
+func TestEstimateLifecycleFeesDryRun(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+	stakingAmount := btcutil.Amount(10000)
+	feeRate := btcutil.Amount(2000)
+
+	outputsBefore, err := tm.Sa.ListUnspentOutputs()
+	require.NoError(t, err)
+
+	estimate, err := tm.Sa.EstimateLifecycleFees(context.Background(), stakingAmount, stakingTime, feeRate)
+	require.NoError(t, err)
+	require.Equal(t, stakingAmount, estimate.StakingAmount)
+	require.Equal(t, feeRate, estimate.FeeRate)
+	require.Positive(t, estimate.FundingFee)
+	require.Positive(t, estimate.WithdrawalFee)
+	require.Equal(t, stakingAmount+estimate.FundingFee+estimate.WithdrawalFee, estimate.TotalCost)
+	require.Positive(t, estimate.FundingTxVSize)
+	require.Equal(t, txrules.FeeForSerializeSize(feeRate, int(estimate.WithdrawalTxVSize)), estimate.WithdrawalFee)
+
+	// dry run does not lock any wallet outputs
+	outputsAfter, err := tm.Sa.ListUnspentOutputs()
+	require.NoError(t, err)
+	require.Len(t, outputsAfter, len(outputsBefore))
+
+	// zero fee rate selects estimated fee rate
+	estimate, err = tm.Sa.EstimateLifecycleFees(context.Background(), stakingAmount, stakingTime, 0)
+	require.NoError(t, err)
+	require.Positive(t, estimate.FeeRate)
+}
+
+func TestStakingTxFeeInfoIsStored(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	stakingTx, err := tm.TestRpcClient.GetRawTransaction(txHash)
+	require.NoError(t, err)
+
+	var inputsValue int64
+	for _, in := range stakingTx.MsgTx().TxIn {
+		prevTx, err := tm.TestRpcClient.GetRawTransaction(&in.PreviousOutPoint.Hash)
+		require.NoError(t, err)
+		inputsValue += prevTx.MsgTx().TxOut[in.PreviousOutPoint.Index].Value
+	}
+
+	var outputsValue int64
+	for _, out := range stakingTx.MsgTx().TxOut {
+		outputsValue += out.Value
+	}
+
+	stakingTxVSize := mempool.GetTxVirtualSize(stakingTx)
+	stakingFee := inputsValue - outputsValue
+
+	details, err := tm.StakerClient.StakingDetails(context.Background(), txHash.String())
+	require.NoError(t, err)
+	require.NotNil(t, details.StakingTxFee)
+	require.Nil(t, details.SpendTxFee)
+	require.Equal(t, strconv.FormatInt(stakingFee, 10), details.StakingTxFee.Fee)
+	require.Equal(t, strconv.FormatInt(stakingTxVSize, 10), details.StakingTxFee.VSize)
+	require.Equal(t, strconv.FormatInt(stakingFee*1000/stakingTxVSize, 10), details.StakingTxFee.FeeRate)
+
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	// mine enough blocks for staking time lock to expire
+	tm.mineNEmptyBlocks(t, uint32(stakingTime)-params.ConfirmationTimeBlocks-1, false)
+
+	require.Eventually(t, func() bool {
+		withdrawableTransactionsResp, err := tm.StakerClient.WithdrawableTransactions(context.Background(), nil, nil)
+		require.NoError(t, err)
+		return len(withdrawableTransactionsResp.Transactions) > 0
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	spendTxHash, spendTxValue := tm.spendStakingTxWithHash(t, txHash)
+
+	spendTx, err := tm.TestRpcClient.GetRawTransaction(spendTxHash)
+	require.NoError(t, err)
+	spendTxVSize := mempool.GetTxVirtualSize(spendTx)
+	spendFee := testStakingData.StakingAmount - int64(*spendTxValue)
+
+	details, err = tm.StakerClient.StakingDetails(context.Background(), txHash.String())
+	require.NoError(t, err)
+	require.NotNil(t, details.SpendTxFee)
+	require.Equal(t, strconv.FormatInt(spendFee, 10), details.SpendTxFee.Fee)
+	require.Equal(t, strconv.FormatInt(spendTxVSize, 10), details.SpendTxFee.VSize)
+	require.Equal(t, strconv.FormatInt(spendFee*1000/spendTxVSize, 10), details.SpendTxFee.FeeRate)
+}
+
+func TestStakingParamsSnapshotIsStored(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	details, err := tm.StakerClient.StakingDetails(context.Background(), txHash.String())
+	require.NoError(t, err)
+	require.NotNil(t, details.StakingParams)
+
+	snapshot := details.StakingParams
+	require.Equal(t, strconv.FormatUint(uint64(params.ConfirmationTimeBlocks), 10), snapshot.ConfirmationTimeBlocks)
+	require.Equal(t, strconv.FormatUint(uint64(params.FinalizationTimeoutBlocks), 10), snapshot.FinalizationTimeoutBlocks)
+	require.Equal(t, strconv.FormatInt(int64(params.MinSlashingTxFeeSat), 10), snapshot.MinSlashingTxFeeSat)
+	require.Equal(t, strconv.FormatUint(uint64(params.CovenantQuruomThreshold), 10), snapshot.CovenantQuorum)
+	require.Equal(t, strconv.FormatUint(uint64(params.MinUnbondingTime), 10), snapshot.MinUnbondingTime)
+	require.Equal(t, params.SlashingAddress.EncodeAddress(), snapshot.SlashingAddress)
+	require.Equal(t, params.SlashingRate.String(), snapshot.SlashingRate)
+	require.Len(t, snapshot.CovenantPks, len(params.CovenantPks))
+
+	for i, pk := range params.CovenantPks {
+		require.Equal(t, hex.EncodeToString(schnorr.SerializePubKey(pk)), snapshot.CovenantPks[i])
+	}
+
+	// snapshot is kept once delegation progresses through its lifecycle
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+
+	details, err = tm.StakerClient.StakingDetails(context.Background(), txHash.String())
+	require.NoError(t, err)
+	require.Equal(t, snapshot, details.StakingParams)
+}
+
+func TestStakingWithPreSignHook(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	// hook appends harmless output paying back to the staker
+	extraOutputScript, err := txscript.PayToAddrScript(tm.MinerAddr)
+	require.NoError(t, err)
+	extraOutput := wire.NewTxOut(5000, extraOutputScript)
+
+	hookCalls := 0
+	tm.Sa.SetPreSignHook(func(tx *wire.MsgTx) (*wire.MsgTx, error) {
+		hookCalls++
+		tx.AddTxOut(extraOutput)
+		return tx, nil
+	})
+	defer tm.Sa.SetPreSignHook(nil)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+	require.Equal(t, 1, hookCalls)
+
+	stakingTx, err := tm.TestRpcClient.GetRawTransaction(txHash)
+	require.NoError(t, err)
+
+	hasExtraOutput := false
+	for _, out := range stakingTx.MsgTx().TxOut {
+		if out.Value == extraOutput.Value && bytes.Equal(out.PkScript, extraOutput.PkScript) {
+			hasExtraOutput = true
+		}
+	}
+	require.True(t, hasExtraOutput)
+
+	// delegation built from modified transaction is accepted by babylon
+	go tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, true)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+}
+
+func TestStakingInSimulateOnlyMode(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	// run separate staker app in simulate only mode, using the same wallet and
+	// babylon node, but its own database
+	simCfg := *tm.Config
+	simStakerCfg := *tm.Config.StakerConfig
+	simStakerCfg.SimulateOnly = true
+	simStakerCfg.SimulatedBlockInterval = 500 * time.Millisecond
+	simCfg.StakerConfig = &simStakerCfg
+	simDbCfg := *tm.Config.DBConfig
+	simDbCfg.DBPath = t.TempDir()
+	simCfg.DBConfig = &simDbCfg
+
+	dbbackend, err := stakercfg.GetDbBackend(simCfg.DBConfig)
+	require.NoError(t, err)
+	defer dbbackend.Close()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.DebugLevel)
+	logger.Out = os.Stdout
+
+	simApp, err := staker.NewStakerAppFromConfig(&simCfg, staker.NewLogrusLogger(logger), zapLogger, dbbackend, metrics.NewStakerMetrics(), nil)
+	require.NoError(t, err)
+	err = simApp.Start()
+	require.NoError(t, err)
+	defer func() {
+		err := simApp.Stop()
+		require.NoError(t, err)
+	}()
+
+	txHash, err := simApp.StakeFunds(
+		context.Background(),
+		tm.MinerAddr,
+		btcutil.Amount(testStakingData.StakingAmount),
+		testStakingData.FinalityProviderBtcKeys,
+		stakingTime,
+		staker.StakeOptions{},
+	)
+	require.NoError(t, err)
+
+	waitForSimulatedState := func(expectedState proto.TransactionState) {
+		require.Eventually(t, func() bool {
+			storedTx, err := simApp.GetStoredTransaction(txHash)
+			require.NoError(t, err)
+			return storedTx.State == expectedState
+		}, 1*time.Minute, eventuallyPollTime)
+	}
+
+	// staking tx is confirmed in simulated chain, and delegation is activated
+	// by simulated covenant committee
+	waitForSimulatedState(proto.TransactionState_DELEGATION_ACTIVE)
+
+	storedTx, err := simApp.GetStoredTransaction(txHash)
+	require.NoError(t, err)
+	require.NotNil(t, storedTx.StakingTxConfirmationInfo)
+	require.NotNil(t, storedTx.UnbondingTxData)
+	require.Len(t, storedTx.UnbondingTxData.CovenantSignatures, int(params.CovenantQuruomThreshold))
+
+	spendTxHash, _, err := simApp.SpendStake(context.Background(), txHash, nil)
+	require.NoError(t, err)
+
+	waitForSimulatedState(proto.TransactionState_SPENT_ON_BTC)
+
+	// nothing was broadcasted to btc network
+	for _, hash := range []*chainhash.Hash{txHash, spendTxHash} {
+		_, err := tm.TestRpcClient.GetRawTransaction(hash)
+		require.Error(t, err)
+	}
+
+	// nothing was submitted to babylon
+	pend, err := tm.BabylonClient.QueryPendingBTCDelegations()
+	require.NoError(t, err)
+	require.Len(t, pend, 0)
+}
+
+func TestRestartingTxConfirmedWhileStakerDown(t *testing.T) {
+	// need to have at least 300 block on testnet as only then segwit is activated.
+	// Mature output is out which has 100 confirmations, which means 200mature outputs
+	// will generate 300 blocks
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	minedBlocks := tm.mineNEmptyBlocks(t, 1, false)
+
+	require.Eventually(t, func() bool {
+		confirmations, err := tm.Sa.GetConfirmations(txHash)
+		return err == nil && confirmations == 1
+	}, eventuallyWaitTimeOut, eventuallyPollTime)
+
+	// restart in the middle of confirmation, confirmations must not go back
+	tm.RestartApp(t)
+
+	confirmations, err := tm.Sa.GetConfirmations(txHash)
+	require.NoError(t, err)
+	require.Equal(t, 1, confirmations)
+	storedTx, err := tm.Sa.GetStoredTransaction(txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+
+	// transaction gets deep enough while staker is down
+	tm.StopApp()
+	minedBlocks = append(minedBlocks, tm.mineNEmptyBlocks(t, params.ConfirmationTimeBlocks, false)...)
+	tm.StartApp(t)
+
+	// state is updated during start up, without waiting for new blocks. Headers
+	// are not on babylon yet, so delegation cannot progress further
+	storedTx, err = tm.Sa.GetStoredTransaction(txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, storedTx.State)
+
+	go tm.sendHeadersToBabylon(t, minedBlocks)
+	tm.waitForStakingTxState(t, txHash, proto.TransactionState_SENT_TO_BABYLON)
+}
+
+func TestStakingChangeToImportedAddressCanBeSpent(t *testing.T) {
+	numMatureOutputs := uint32(200)
+	tm := StartManager(t, numMatureOutputs)
+	defer tm.Stop(t)
+	tm.insertAllMinedBlocksToBabylon(t)
+
+	tm.Config.WalletConfig.AutoImportAddresses = true
+	tm.Config.WalletConfig.ActiveChangeAddressType = types.P2WPKHChangeAddress
+	tm.RestartApp(t)
+
+	cl := tm.Sa.BabylonController()
+	params, err := cl.Params(context.Background())
+	require.NoError(t, err)
+	stakingTime := uint16(staker.GetMinStakingTime(params))
+
+	testStakingData := tm.getTestStakingData(t, tm.WalletPrivKey.PubKey(), stakingTime, 10000, 1)
+	tm.createAndRegisterFinalityProviders(t, testStakingData)
+
+	txHash := tm.sendStakingTxBTC(t, testStakingData)
+
+	storedTx, err := tm.Sa.GetStoredTransaction(txHash)
+	require.NoError(t, err)
+
+	// find change output sent to freshly generated address
+	var changeOutpoint *wire.OutPoint
+	var changeOutput *wire.TxOut
+	for i, out := range storedTx.StakingTx.TxOut {
+		if uint32(i) == storedTx.StakingOutputIndex || !txscript.IsPayToWitnessPubKeyHash(out.PkScript) {
+			continue
+		}
+
+		changeOutpoint = wire.NewOutPoint(txHash, uint32(i))
+		changeOutput = out
+	}
+	require.NotNil(t, changeOutput)
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(changeOutput.PkScript, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	require.NotEqual(t, tm.MinerAddr.EncodeAddress(), addrs[0].EncodeAddress())
+
+	info, err := tm.TestRpcClient.GetAddressInfo(addrs[0].EncodeAddress())
+	require.NoError(t, err)
+	require.True(t, info.IsMine)
+
+	// wallet is able to sign transaction spending the change
+	minerScript, err := txscript.PayToAddrScript(tm.MinerAddr)
+	require.NoError(t, err)
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(wire.NewTxIn(changeOutpoint, nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(changeOutput.Value-1000, minerScript))
+
+	err = tm.Sa.Wallet().UnlockWallet(20)
+	require.NoError(t, err)
+
+	signedTx, signed, err := tm.Sa.Wallet().SignRawTransaction(context.Background(), spendTx)
+	require.NoError(t, err)
+	require.True(t, signed)
+
+	spendTxHash, err := tm.Sa.Wallet().SendRawTransaction(context.Background(), signedTx, true)
+	require.NoError(t, err)
+
+	block := tm.mineBlock(t)
+
+	var blockTxHashes []chainhash.Hash
+	for _, tx := range block.Transactions {
+		blockTxHashes = append(blockTxHashes, tx.TxHash())
+	}
+	require.Contains(t, blockTxHashes, *spendTxHash)
+}
@@ -9,10 +9,18 @@ type StakerMetrics struct {
 	Registry                        *prometheus.Registry
 	ValidReceivedDelegationRequests prometheus.Counter
 	DelegationsConfirmedOnBtc       prometheus.Counter
+	DelegationsReorgedOnBtc         prometheus.Counter
 	DelegationsSentToBabylon        prometheus.Counter
 	DelegationsActivatedOnBabylon   prometheus.Counter
 	NumberOfFatalErrors             prometheus.Counter
 	CurrentBtcBlockHeight           prometheus.Gauge
+	WalletBalance                   prometheus.Gauge
+	LowWalletBalanceAlerts          prometheus.Counter
+	FeeBumpEscalations              prometheus.Counter
+	DelegationsByState              *prometheus.GaugeVec
+	StakingTxsBroadcast             prometheus.Counter
+	BabylonSubmissionFailures       prometheus.Counter
+	FeesPaid                        prometheus.Counter
 }
 
 func NewStakerMetrics() *StakerMetrics {
@@ -29,6 +37,10 @@ func NewStakerMetrics() *StakerMetrics {
 			Name: "staker_delegations_confirmed_on_btc",
 			Help: "Total number of delegations confirmed on btc",
 		}),
+		DelegationsReorgedOnBtc: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_delegations_reorged_on_btc",
+			Help: "Total number of delegations whose staking transaction confirmation was reorged out of btc chain",
+		}),
 		DelegationsSentToBabylon: registerer.NewCounter(prometheus.CounterOpts{
 			Name: "staker_delegations_send_to_babylon",
 			Help: "Total number of delegations sent to babylon",
@@ -45,6 +57,34 @@ func NewStakerMetrics() *StakerMetrics {
 			Name: "staker_current_btc_block_height",
 			Help: "Current block height of the btc chain",
 		}),
+		WalletBalance: registerer.NewGauge(prometheus.GaugeOpts{
+			Name: "staker_wallet_balance",
+			Help: "Confirmed balance of the staker wallet in satoshis",
+		}),
+		LowWalletBalanceAlerts: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_low_wallet_balance_alerts",
+			Help: "Total number of times wallet balance dropped below low balance threshold",
+		}),
+		FeeBumpEscalations: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_fee_bump_escalations",
+			Help: "Total number of fee bump escalations raised for staking transactions stuck in mempool",
+		}),
+		DelegationsByState: registerer.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "staker_delegations_by_state",
+			Help: "Number of tracked delegations in each state",
+		}, []string{"state"}),
+		StakingTxsBroadcast: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_staking_transactions_broadcast",
+			Help: "Total number of staking transactions broadcast to btc network",
+		}),
+		BabylonSubmissionFailures: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_babylon_submission_failures",
+			Help: "Total number of failed attempts to submit delegation to babylon",
+		}),
+		FeesPaid: registerer.NewCounter(prometheus.CounterOpts{
+			Name: "staker_fees_paid",
+			Help: "Total fees in satoshis paid by staking and fee bumping transactions created by the staker",
+		}),
 	}
 	return metrics
 }
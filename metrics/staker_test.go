@@ -0,0 +1,33 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/babylonchain/btc-staker/metrics"
+)
+
+func TestStakerMetricsRegistered(t *testing.T) {
+	m := metrics.NewStakerMetrics()
+	m.DelegationsByState.WithLabelValues("SENT_TO_BTC").Set(2)
+
+	families, err := m.Registry.Gather()
+	require.NoError(t, err)
+
+	names := make(map[string]struct{}, len(families))
+	for _, family := range families {
+		names[family.GetName()] = struct{}{}
+	}
+
+	for _, name := range []string{
+		"staker_delegations_by_state",
+		"staker_staking_transactions_broadcast",
+		"staker_babylon_submission_failures",
+		"staker_fees_paid",
+		"staker_delegations_send_to_babylon",
+		"staker_current_btc_block_height",
+	} {
+		require.Contains(t, names, name)
+	}
+}
@@ -29,6 +29,12 @@ const (
 	TransactionState_DELEGATION_ACTIVE          TransactionState = 3
 	TransactionState_UNBONDING_CONFIRMED_ON_BTC TransactionState = 4
 	TransactionState_SPENT_ON_BTC               TransactionState = 5
+	// delegation was prepared by staker, but it still waits for signatures
+	// produced externally by staker key. Staking transaction is not sent to btc
+	TransactionState_PREPARED TransactionState = 6
+	// unbonding of delegation was started. Unbonding transaction is being sent
+	// to btc or waits for confirmation
+	TransactionState_UNBONDING_STARTED TransactionState = 7
 )
 
 // Enum value maps for TransactionState.
@@ -40,6 +46,8 @@ var (
 		3: "DELEGATION_ACTIVE",
 		4: "UNBONDING_CONFIRMED_ON_BTC",
 		5: "SPENT_ON_BTC",
+		6: "PREPARED",
+		7: "UNBONDING_STARTED",
 	}
 	TransactionState_value = map[string]int32{
 		"SENT_TO_BTC":                0,
@@ -48,6 +56,8 @@ var (
 		"DELEGATION_ACTIVE":          3,
 		"UNBONDING_CONFIRMED_ON_BTC": 4,
 		"SPENT_ON_BTC":               5,
+		"PREPARED":                   6,
+		"UNBONDING_STARTED":          7,
 	}
 )
 
@@ -240,6 +250,178 @@ func (x *BTCConfirmationInfo) GetBlockHash() []byte {
 	return nil
 }
 
+// Contains information about fee paid by btc transaction
+type TxFeeInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// fee paid by transaction in satoshis
+	Fee int64 `protobuf:"varint,1,opt,name=fee,proto3" json:"fee,omitempty"`
+	// virtual size of transaction in vbytes
+	Vsize int64 `protobuf:"varint,2,opt,name=vsize,proto3" json:"vsize,omitempty"`
+	// effective fee rate of transaction in sat/kvb
+	FeeRate int64 `protobuf:"varint,3,opt,name=fee_rate,json=feeRate,proto3" json:"fee_rate,omitempty"`
+}
+
+func (x *TxFeeInfo) Reset() {
+	*x = TxFeeInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transaction_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TxFeeInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TxFeeInfo) ProtoMessage() {}
+
+func (x *TxFeeInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_transaction_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TxFeeInfo.ProtoReflect.Descriptor instead.
+func (*TxFeeInfo) Descriptor() ([]byte, []int) {
+	return file_transaction_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TxFeeInfo) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *TxFeeInfo) GetVsize() int64 {
+	if x != nil {
+		return x.Vsize
+	}
+	return 0
+}
+
+func (x *TxFeeInfo) GetFeeRate() int64 {
+	if x != nil {
+		return x.FeeRate
+	}
+	return 0
+}
+
+// Snapshot of babylon staking params which were in effect when delegation
+// was created
+type StakingParamsSnapshot struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ConfirmationTimeBlocks    uint32   `protobuf:"varint,1,opt,name=confirmation_time_blocks,json=confirmationTimeBlocks,proto3" json:"confirmation_time_blocks,omitempty"`
+	FinalizationTimeoutBlocks uint32   `protobuf:"varint,2,opt,name=finalization_timeout_blocks,json=finalizationTimeoutBlocks,proto3" json:"finalization_timeout_blocks,omitempty"`
+	MinSlashingTxFeeSat       int64    `protobuf:"varint,3,opt,name=min_slashing_tx_fee_sat,json=minSlashingTxFeeSat,proto3" json:"min_slashing_tx_fee_sat,omitempty"`
+	CovenantPks               [][]byte `protobuf:"bytes,4,rep,name=covenant_pks,json=covenantPks,proto3" json:"covenant_pks,omitempty"`
+	CovenantQuorum            uint32   `protobuf:"varint,5,opt,name=covenant_quorum,json=covenantQuorum,proto3" json:"covenant_quorum,omitempty"`
+	MinUnbondingTime          uint32   `protobuf:"varint,6,opt,name=min_unbonding_time,json=minUnbondingTime,proto3" json:"min_unbonding_time,omitempty"`
+	SlashingAddress           string   `protobuf:"bytes,7,opt,name=slashing_address,json=slashingAddress,proto3" json:"slashing_address,omitempty"`
+	SlashingRate              string   `protobuf:"bytes,8,opt,name=slashing_rate,json=slashingRate,proto3" json:"slashing_rate,omitempty"`
+}
+
+func (x *StakingParamsSnapshot) Reset() {
+	*x = StakingParamsSnapshot{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transaction_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StakingParamsSnapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StakingParamsSnapshot) ProtoMessage() {}
+
+func (x *StakingParamsSnapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_transaction_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StakingParamsSnapshot.ProtoReflect.Descriptor instead.
+func (*StakingParamsSnapshot) Descriptor() ([]byte, []int) {
+	return file_transaction_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StakingParamsSnapshot) GetConfirmationTimeBlocks() uint32 {
+	if x != nil {
+		return x.ConfirmationTimeBlocks
+	}
+	return 0
+}
+
+func (x *StakingParamsSnapshot) GetFinalizationTimeoutBlocks() uint32 {
+	if x != nil {
+		return x.FinalizationTimeoutBlocks
+	}
+	return 0
+}
+
+func (x *StakingParamsSnapshot) GetMinSlashingTxFeeSat() int64 {
+	if x != nil {
+		return x.MinSlashingTxFeeSat
+	}
+	return 0
+}
+
+func (x *StakingParamsSnapshot) GetCovenantPks() [][]byte {
+	if x != nil {
+		return x.CovenantPks
+	}
+	return nil
+}
+
+func (x *StakingParamsSnapshot) GetCovenantQuorum() uint32 {
+	if x != nil {
+		return x.CovenantQuorum
+	}
+	return 0
+}
+
+func (x *StakingParamsSnapshot) GetMinUnbondingTime() uint32 {
+	if x != nil {
+		return x.MinUnbondingTime
+	}
+	return 0
+}
+
+func (x *StakingParamsSnapshot) GetSlashingAddress() string {
+	if x != nil {
+		return x.SlashingAddress
+	}
+	return ""
+}
+
+func (x *StakingParamsSnapshot) GetSlashingRate() string {
+	if x != nil {
+		return x.SlashingRate
+	}
+	return ""
+}
+
 type CovenantSig struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -252,7 +434,7 @@ type CovenantSig struct {
 func (x *CovenantSig) Reset() {
 	*x = CovenantSig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_transaction_proto_msgTypes[2]
+		mi := &file_transaction_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -265,7 +447,7 @@ func (x *CovenantSig) String() string {
 func (*CovenantSig) ProtoMessage() {}
 
 func (x *CovenantSig) ProtoReflect() protoreflect.Message {
-	mi := &file_transaction_proto_msgTypes[2]
+	mi := &file_transaction_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +460,7 @@ func (x *CovenantSig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CovenantSig.ProtoReflect.Descriptor instead.
 func (*CovenantSig) Descriptor() ([]byte, []int) {
-	return file_transaction_proto_rawDescGZIP(), []int{2}
+	return file_transaction_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CovenantSig) GetCovenantSig() []byte {
@@ -311,7 +493,7 @@ type UnbondingTxData struct {
 func (x *UnbondingTxData) Reset() {
 	*x = UnbondingTxData{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_transaction_proto_msgTypes[3]
+		mi := &file_transaction_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -324,7 +506,7 @@ func (x *UnbondingTxData) String() string {
 func (*UnbondingTxData) ProtoMessage() {}
 
 func (x *UnbondingTxData) ProtoReflect() protoreflect.Message {
-	mi := &file_transaction_proto_msgTypes[3]
+	mi := &file_transaction_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -337,7 +519,7 @@ func (x *UnbondingTxData) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UnbondingTxData.ProtoReflect.Descriptor instead.
 func (*UnbondingTxData) Descriptor() ([]byte, []int) {
-	return file_transaction_proto_rawDescGZIP(), []int{3}
+	return file_transaction_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UnbondingTxData) GetUnbondingTransaction() []byte {
@@ -391,12 +573,44 @@ type TrackedTransaction struct {
 	Watched                      bool                 `protobuf:"varint,11,opt,name=watched,proto3" json:"watched,omitempty"`
 	// this data is only filled if tracked transactions state is >= SENT_TO_BABYLON
 	UnbondingTxData *UnbondingTxData `protobuf:"bytes,12,opt,name=unbonding_tx_data,json=unbondingTxData,proto3" json:"unbonding_tx_data,omitempty"`
+	// this data is only filled for transactions created by staker i.e not watched ones
+	StakingTxFeeInfo *TxFeeInfo `protobuf:"bytes,13,opt,name=staking_tx_fee_info,json=stakingTxFeeInfo,proto3" json:"staking_tx_fee_info,omitempty"`
+	// this data is only filled if staking or unbonding output was spent by staker
+	SpendTxFeeInfo *TxFeeInfo `protobuf:"bytes,14,opt,name=spend_tx_fee_info,json=spendTxFeeInfo,proto3" json:"spend_tx_fee_info,omitempty"`
+	// staking params in effect when delegation was created, not filled for
+	// transactions tracked before this field was introduced
+	StakingParamsSnapshot *StakingParamsSnapshot `protobuf:"bytes,15,opt,name=staking_params_snapshot,json=stakingParamsSnapshot,proto3" json:"staking_params_snapshot,omitempty"`
+	// transaction spending staking or unbonding output, only filled if it was
+	// sent by staker
+	SpendTx []byte `protobuf:"bytes,16,opt,name=spend_tx,json=spendTx,proto3" json:"spend_tx,omitempty"`
+	// serialized delegation message of the last submission of the delegation
+	// to babylon, only filled for delegations submitted by staker
+	BabylonSubmissionPayload []byte `protobuf:"bytes,17,opt,name=babylon_submission_payload,json=babylonSubmissionPayload,proto3" json:"babylon_submission_payload,omitempty"`
+	// hash of the last child-pays-for-parent transaction spending change of the
+	// staking transaction, only filled if staker bumped fee of staking transaction
+	CpfpTxHash []byte `protobuf:"bytes,18,opt,name=cpfp_tx_hash,json=cpfpTxHash,proto3" json:"cpfp_tx_hash,omitempty"`
+	// unix time in seconds when transaction started to be tracked, 0 for
+	// transactions tracked before this field was introduced
+	CreatedAt int64 `protobuf:"varint,19,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// unix time in seconds of the last change of the state, 0 if state did not
+	// change since this field was introduced
+	LastStateChangeAt int64 `protobuf:"varint,20,opt,name=last_state_change_at,json=lastStateChangeAt,proto3" json:"last_state_change_at,omitempty"`
+	// name of the additional wallet which funded staking transaction, empty
+	// if it was funded by the main wallet or is not owned by staker
+	WalletName string `protobuf:"bytes,21,opt,name=wallet_name,json=walletName,proto3" json:"wallet_name,omitempty"`
+	// change output of staking transaction created by staker, only filled if
+	// staking transaction has change
+	StakingTxChangePkScript  []byte `protobuf:"bytes,22,opt,name=staking_tx_change_pk_script,json=stakingTxChangePkScript,proto3" json:"staking_tx_change_pk_script,omitempty"`
+	StakingTxChangeOutputIdx uint32 `protobuf:"varint,23,opt,name=staking_tx_change_output_idx,json=stakingTxChangeOutputIdx,proto3" json:"staking_tx_change_output_idx,omitempty"`
+	// fee paid by the last child-pays-for-parent transaction, only filled if
+	// staker bumped fee of staking transaction
+	CpfpTxFee int64 `protobuf:"varint,24,opt,name=cpfp_tx_fee,json=cpfpTxFee,proto3" json:"cpfp_tx_fee,omitempty"`
 }
 
 func (x *TrackedTransaction) Reset() {
 	*x = TrackedTransaction{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_transaction_proto_msgTypes[4]
+		mi := &file_transaction_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -409,7 +623,7 @@ func (x *TrackedTransaction) String() string {
 func (*TrackedTransaction) ProtoMessage() {}
 
 func (x *TrackedTransaction) ProtoReflect() protoreflect.Message {
-	mi := &file_transaction_proto_msgTypes[4]
+	mi := &file_transaction_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -422,7 +636,7 @@ func (x *TrackedTransaction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrackedTransaction.ProtoReflect.Descriptor instead.
 func (*TrackedTransaction) Descriptor() ([]byte, []int) {
-	return file_transaction_proto_rawDescGZIP(), []int{4}
+	return file_transaction_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *TrackedTransaction) GetTrackedTransactionIdx() uint64 {
@@ -509,6 +723,165 @@ func (x *TrackedTransaction) GetUnbondingTxData() *UnbondingTxData {
 	return nil
 }
 
+func (x *TrackedTransaction) GetStakingTxFeeInfo() *TxFeeInfo {
+	if x != nil {
+		return x.StakingTxFeeInfo
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetSpendTxFeeInfo() *TxFeeInfo {
+	if x != nil {
+		return x.SpendTxFeeInfo
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetStakingParamsSnapshot() *StakingParamsSnapshot {
+	if x != nil {
+		return x.StakingParamsSnapshot
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetSpendTx() []byte {
+	if x != nil {
+		return x.SpendTx
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetBabylonSubmissionPayload() []byte {
+	if x != nil {
+		return x.BabylonSubmissionPayload
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetCpfpTxHash() []byte {
+	if x != nil {
+		return x.CpfpTxHash
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *TrackedTransaction) GetLastStateChangeAt() int64 {
+	if x != nil {
+		return x.LastStateChangeAt
+	}
+	return 0
+}
+
+func (x *TrackedTransaction) GetWalletName() string {
+	if x != nil {
+		return x.WalletName
+	}
+	return ""
+}
+
+func (x *TrackedTransaction) GetStakingTxChangePkScript() []byte {
+	if x != nil {
+		return x.StakingTxChangePkScript
+	}
+	return nil
+}
+
+func (x *TrackedTransaction) GetStakingTxChangeOutputIdx() uint32 {
+	if x != nil {
+		return x.StakingTxChangeOutputIdx
+	}
+	return 0
+}
+
+func (x *TrackedTransaction) GetCpfpTxFee() int64 {
+	if x != nil {
+		return x.CpfpTxFee
+	}
+	return 0
+}
+
+// Single delegation in export of staker delegation database
+type DelegationRecord struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// hash of staking transaction under which delegation is tracked
+	StakingTxHash      []byte              `protobuf:"bytes,1,opt,name=staking_tx_hash,json=stakingTxHash,proto3" json:"staking_tx_hash,omitempty"`
+	TrackedTransaction *TrackedTransaction `protobuf:"bytes,2,opt,name=tracked_transaction,json=trackedTransaction,proto3" json:"tracked_transaction,omitempty"`
+	// only filled for watched transactions which were signed
+	WatchedTxData *WatchedTxData `protobuf:"bytes,3,opt,name=watched_tx_data,json=watchedTxData,proto3" json:"watched_tx_data,omitempty"`
+	// only filled for prepared transactions which still wait for signatures
+	PreparedTxData *WatchedTxData `protobuf:"bytes,4,opt,name=prepared_tx_data,json=preparedTxData,proto3" json:"prepared_tx_data,omitempty"`
+}
+
+func (x *DelegationRecord) Reset() {
+	*x = DelegationRecord{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_transaction_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DelegationRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DelegationRecord) ProtoMessage() {}
+
+func (x *DelegationRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_transaction_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DelegationRecord.ProtoReflect.Descriptor instead.
+func (*DelegationRecord) Descriptor() ([]byte, []int) {
+	return file_transaction_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DelegationRecord) GetStakingTxHash() []byte {
+	if x != nil {
+		return x.StakingTxHash
+	}
+	return nil
+}
+
+func (x *DelegationRecord) GetTrackedTransaction() *TrackedTransaction {
+	if x != nil {
+		return x.TrackedTransaction
+	}
+	return nil
+}
+
+func (x *DelegationRecord) GetWatchedTxData() *WatchedTxData {
+	if x != nil {
+		return x.WatchedTxData
+	}
+	return nil
+}
+
+func (x *DelegationRecord) GetPreparedTxData() *WatchedTxData {
+	if x != nil {
+		return x.PreparedTxData
+	}
+	return nil
+}
+
 var File_transaction_proto protoreflect.FileDescriptor
 
 var file_transaction_proto_rawDesc = []byte{
@@ -547,84 +920,171 @@ var file_transaction_proto_rawDesc = []byte{
 	0x67, 0x68, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
 	0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x5f,
 	0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x62, 0x6c, 0x6f, 0x63,
-	0x6b, 0x48, 0x61, 0x73, 0x68, 0x22, 0x5f, 0x0a, 0x0b, 0x43, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e,
-	0x74, 0x53, 0x69, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74,
-	0x5f, 0x73, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x63, 0x6f, 0x76, 0x65,
-	0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x12, 0x2d, 0x0a, 0x13, 0x63, 0x6f, 0x76, 0x65, 0x6e,
-	0x61, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x62, 0x74, 0x63, 0x5f, 0x70, 0x6b, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69,
-	0x67, 0x42, 0x74, 0x63, 0x50, 0x6b, 0x22, 0x9a, 0x02, 0x0a, 0x0f, 0x55, 0x6e, 0x62, 0x6f, 0x6e,
-	0x64, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x12, 0x33, 0x0a, 0x15, 0x75, 0x6e,
-	0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x14, 0x75, 0x6e, 0x62, 0x6f, 0x6e,
-	0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x69, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69,
-	0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x43, 0x0a, 0x13, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61,
-	0x6e, 0x74, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43, 0x6f, 0x76, 0x65,
-	0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x52, 0x12, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e,
-	0x74, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x66, 0x0a, 0x22, 0x75,
-	0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x62, 0x74, 0x63, 0x5f,
-	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x66,
-	0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
-	0x42, 0x54, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
-	0x6e, 0x66, 0x6f, 0x52, 0x1e, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x78,
-	0x42, 0x74, 0x63, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49,
-	0x6e, 0x66, 0x6f, 0x22, 0x84, 0x05, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x36, 0x0a, 0x17, 0x74, 0x72,
-	0x61, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x5f, 0x69, 0x64, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x15, 0x74, 0x72, 0x61,
-	0x63, 0x6b, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49,
-	0x64, 0x78, 0x12, 0x2f, 0x0a, 0x13, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x72,
-	0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x12, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x6f,
-	0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x64, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x10, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x49, 0x64,
-	0x78, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x5f, 0x61, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x6b, 0x65,
-	0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x6b,
-	0x69, 0x6e, 0x67, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b,
-	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x3b, 0x0a, 0x1a, 0x66,
-	0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x5f, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72,
-	0x73, 0x5f, 0x62, 0x74, 0x63, 0x5f, 0x70, 0x6b, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0c, 0x52,
-	0x17, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65,
-	0x72, 0x73, 0x42, 0x74, 0x63, 0x50, 0x6b, 0x73, 0x12, 0x62, 0x0a, 0x20, 0x73, 0x74, 0x61, 0x6b,
-	0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x62, 0x74, 0x63, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x07, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x54, 0x43, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x1c,
-	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x42, 0x74, 0x63, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x20, 0x0a, 0x0c,
-	0x62, 0x74, 0x63, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x08, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x0a, 0x62, 0x74, 0x63, 0x53, 0x69, 0x67, 0x54, 0x79, 0x70, 0x65, 0x12, 0x3d,
-	0x0a, 0x1c, 0x62, 0x74, 0x63, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x5f, 0x62,
-	0x62, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x18, 0x09,
-	0x20, 0x01, 0x28, 0x0c, 0x52, 0x17, 0x62, 0x74, 0x63, 0x53, 0x69, 0x67, 0x4f, 0x76, 0x65, 0x72,
-	0x42, 0x62, 0x6e, 0x53, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x12, 0x2d, 0x0a,
-	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07,
-	0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x77,
-	0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x12, 0x42, 0x0a, 0x11, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64,
-	0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0c, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x6e, 0x62, 0x6f, 0x6e, 0x64,
-	0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x52, 0x0f, 0x75, 0x6e, 0x62, 0x6f, 0x6e,
-	0x64, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x2a, 0x97, 0x01, 0x0a, 0x10, 0x54,
-	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12,
-	0x0f, 0x0a, 0x0b, 0x53, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x4f, 0x5f, 0x42, 0x54, 0x43, 0x10, 0x00,
-	0x12, 0x14, 0x0a, 0x10, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x52, 0x4d, 0x45, 0x44, 0x5f, 0x4f, 0x4e,
-	0x5f, 0x42, 0x54, 0x43, 0x10, 0x01, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x45, 0x4e, 0x54, 0x5f, 0x54,
-	0x4f, 0x5f, 0x42, 0x41, 0x42, 0x59, 0x4c, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x44,
-	0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45,
-	0x10, 0x03, 0x12, 0x1e, 0x0a, 0x1a, 0x55, 0x4e, 0x42, 0x4f, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x5f,
-	0x43, 0x4f, 0x4e, 0x46, 0x49, 0x52, 0x4d, 0x45, 0x44, 0x5f, 0x4f, 0x4e, 0x5f, 0x42, 0x54, 0x43,
-	0x10, 0x04, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x50, 0x45, 0x4e, 0x54, 0x5f, 0x4f, 0x4e, 0x5f, 0x42,
-	0x54, 0x43, 0x10, 0x05, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x62, 0x61, 0x62, 0x79, 0x6c, 0x6f, 0x6e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f,
-	0x62, 0x74, 0x63, 0x2d, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6b, 0x48, 0x61, 0x73, 0x68, 0x22, 0x4e, 0x0a, 0x09, 0x54, 0x78, 0x46, 0x65, 0x65, 0x49, 0x6e,
+	0x66, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x03, 0x66, 0x65, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x66, 0x65,
+	0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x66, 0x65,
+	0x65, 0x52, 0x61, 0x74, 0x65, 0x22, 0x91, 0x03, 0x0a, 0x15, 0x53, 0x74, 0x61, 0x6b, 0x69, 0x6e,
+	0x67, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
+	0x38, 0x0a, 0x18, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x16, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54,
+	0x69, 0x6d, 0x65, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x3e, 0x0a, 0x1b, 0x66, 0x69, 0x6e,
+	0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x19,
+	0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x12, 0x34, 0x0a, 0x17, 0x6d, 0x69, 0x6e,
+	0x5f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x66, 0x65, 0x65,
+	0x5f, 0x73, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x6d, 0x69, 0x6e, 0x53,
+	0x6c, 0x61, 0x73, 0x68, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x46, 0x65, 0x65, 0x53, 0x61, 0x74, 0x12,
+	0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x70, 0x6b, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0b, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x50,
+	0x6b, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x71,
+	0x75, 0x6f, 0x72, 0x75, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x63, 0x6f, 0x76,
+	0x65, 0x6e, 0x61, 0x6e, 0x74, 0x51, 0x75, 0x6f, 0x72, 0x75, 0x6d, 0x12, 0x2c, 0x0a, 0x12, 0x6d,
+	0x69, 0x6e, 0x5f, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x6d, 0x69, 0x6e, 0x55, 0x6e, 0x62, 0x6f,
+	0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6c, 0x61,
+	0x73, 0x68, 0x69, 0x6e, 0x67, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x69, 0x6e, 0x67, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x69, 0x6e, 0x67,
+	0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x6c, 0x61,
+	0x73, 0x68, 0x69, 0x6e, 0x67, 0x52, 0x61, 0x74, 0x65, 0x22, 0x5f, 0x0a, 0x0b, 0x43, 0x6f, 0x76,
+	0x65, 0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x76, 0x65,
+	0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b,
+	0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x12, 0x2d, 0x0a, 0x13, 0x63,
+	0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x62, 0x74, 0x63, 0x5f,
+	0x70, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x10, 0x63, 0x6f, 0x76, 0x65, 0x6e, 0x61,
+	0x6e, 0x74, 0x53, 0x69, 0x67, 0x42, 0x74, 0x63, 0x50, 0x6b, 0x22, 0x9a, 0x02, 0x0a, 0x0f, 0x55,
+	0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x12, 0x33,
+	0x0a, 0x15, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x14, 0x75,
+	0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x75, 0x6e, 0x62,
+	0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x43, 0x0a, 0x13, 0x63, 0x6f,
+	0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x5f, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x43, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x52, 0x12, 0x63, 0x6f, 0x76,
+	0x65, 0x6e, 0x61, 0x6e, 0x74, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12,
+	0x66, 0x0a, 0x22, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f,
+	0x62, 0x74, 0x63, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x42, 0x54, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x1e, 0x75, 0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69,
+	0x6e, 0x67, 0x54, 0x78, 0x42, 0x74, 0x63, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0xe2, 0x09, 0x0a, 0x12, 0x54, 0x72, 0x61, 0x63,
+	0x6b, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x36,
+	0x0a, 0x17, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x15, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x49, 0x64, 0x78, 0x12, 0x2f, 0x0a, 0x13, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e,
+	0x67, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x12, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x72, 0x61, 0x6e,
+	0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x12, 0x73, 0x74, 0x61, 0x6b, 0x69,
+	0x6e, 0x67, 0x5f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x64, 0x78, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x10, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x4f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x49, 0x64, 0x78, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x5f,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x73,
+	0x74, 0x61, 0x6b, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x21, 0x0a, 0x0c,
+	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x3b, 0x0a, 0x1a, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x5f, 0x70, 0x72, 0x6f, 0x76,
+	0x69, 0x64, 0x65, 0x72, 0x73, 0x5f, 0x62, 0x74, 0x63, 0x5f, 0x70, 0x6b, 0x73, 0x18, 0x06, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x17, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x50, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x72, 0x73, 0x42, 0x74, 0x63, 0x50, 0x6b, 0x73, 0x12, 0x62, 0x0a, 0x20,
+	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x62, 0x74, 0x63, 0x5f, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x66, 0x6f,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x42,
+	0x54, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x1c, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x42, 0x74, 0x63,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x20, 0x0a, 0x0c, 0x62, 0x74, 0x63, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x62, 0x74, 0x63, 0x53, 0x69, 0x67, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x3d, 0x0a, 0x1c, 0x62, 0x74, 0x63, 0x5f, 0x73, 0x69, 0x67, 0x5f, 0x6f, 0x76,
+	0x65, 0x72, 0x5f, 0x62, 0x62, 0x6e, 0x5f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x5f, 0x61, 0x64,
+	0x64, 0x72, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x17, 0x62, 0x74, 0x63, 0x53, 0x69, 0x67,
+	0x4f, 0x76, 0x65, 0x72, 0x42, 0x62, 0x6e, 0x53, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x41, 0x64, 0x64,
+	0x72, 0x12, 0x2d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x12, 0x42, 0x0a, 0x11, 0x75, 0x6e,
+	0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x55, 0x6e,
+	0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x52, 0x0f, 0x75,
+	0x6e, 0x62, 0x6f, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3f,
+	0x0a, 0x13, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x66, 0x65, 0x65,
+	0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x54, 0x78, 0x46, 0x65, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x10, 0x73,
+	0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x46, 0x65, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x3b, 0x0a, 0x11, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x78, 0x5f, 0x66, 0x65, 0x65, 0x5f,
+	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x54, 0x78, 0x46, 0x65, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0e, 0x73, 0x70,
+	0x65, 0x6e, 0x64, 0x54, 0x78, 0x46, 0x65, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x54, 0x0a, 0x17,
+	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x5f, 0x73,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x53, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x15, 0x73, 0x74, 0x61,
+	0x6b, 0x69, 0x6e, 0x67, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x5f, 0x74, 0x78, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x54, 0x78, 0x12, 0x3c, 0x0a,
+	0x1a, 0x62, 0x61, 0x62, 0x79, 0x6c, 0x6f, 0x6e, 0x5f, 0x73, 0x75, 0x62, 0x6d, 0x69, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x11, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x18, 0x62, 0x61, 0x62, 0x79, 0x6c, 0x6f, 0x6e, 0x53, 0x75, 0x62, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x20, 0x0a, 0x0c, 0x63,
+	0x70, 0x66, 0x70, 0x5f, 0x74, 0x78, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x12, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x0a, 0x63, 0x70, 0x66, 0x70, 0x54, 0x78, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1d, 0x0a,
+	0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x2f, 0x0a, 0x14,
+	0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x5f, 0x61, 0x74, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x6c, 0x61, 0x73, 0x74,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x41, 0x74, 0x12, 0x1f, 0x0a,
+	0x0b, 0x77, 0x61, 0x6c, 0x6c, 0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x15, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x77, 0x61, 0x6c, 0x6c, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x3c,
+	0x0a, 0x1b, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x63, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x5f, 0x70, 0x6b, 0x5f, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x16, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x17, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x43, 0x68,
+	0x61, 0x6e, 0x67, 0x65, 0x50, 0x6b, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x3e, 0x0a, 0x1c,
+	0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67,
+	0x65, 0x5f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x69, 0x64, 0x78, 0x18, 0x17, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x18, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x54, 0x78, 0x43, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x49, 0x64, 0x78, 0x12, 0x1e, 0x0a, 0x0b,
+	0x63, 0x70, 0x66, 0x70, 0x5f, 0x74, 0x78, 0x5f, 0x66, 0x65, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x63, 0x70, 0x66, 0x70, 0x54, 0x78, 0x46, 0x65, 0x65, 0x22, 0x84, 0x02, 0x0a,
+	0x10, 0x44, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x63, 0x6f, 0x72,
+	0x64, 0x12, 0x26, 0x0a, 0x0f, 0x73, 0x74, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x5f, 0x74, 0x78, 0x5f,
+	0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x73, 0x74, 0x61, 0x6b,
+	0x69, 0x6e, 0x67, 0x54, 0x78, 0x48, 0x61, 0x73, 0x68, 0x12, 0x4a, 0x0a, 0x13, 0x74, 0x72, 0x61,
+	0x63, 0x6b, 0x65, 0x64, 0x5f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x54,
+	0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x12, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x65, 0x64, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3c, 0x0a, 0x0f, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64,
+	0x5f, 0x74, 0x78, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x54, 0x78,
+	0x44, 0x61, 0x74, 0x61, 0x52, 0x0d, 0x77, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x54, 0x78, 0x44,
+	0x61, 0x74, 0x61, 0x12, 0x3e, 0x0a, 0x10, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x5f,
+	0x74, 0x78, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x54, 0x78, 0x44,
+	0x61, 0x74, 0x61, 0x52, 0x0e, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x64, 0x54, 0x78, 0x44,
+	0x61, 0x74, 0x61, 0x2a, 0xbc, 0x01, 0x0a, 0x10, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x53, 0x45, 0x4e, 0x54,
+	0x5f, 0x54, 0x4f, 0x5f, 0x42, 0x54, 0x43, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x43, 0x4f, 0x4e,
+	0x46, 0x49, 0x52, 0x4d, 0x45, 0x44, 0x5f, 0x4f, 0x4e, 0x5f, 0x42, 0x54, 0x43, 0x10, 0x01, 0x12,
+	0x13, 0x0a, 0x0f, 0x53, 0x45, 0x4e, 0x54, 0x5f, 0x54, 0x4f, 0x5f, 0x42, 0x41, 0x42, 0x59, 0x4c,
+	0x4f, 0x4e, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49,
+	0x4f, 0x4e, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x03, 0x12, 0x1e, 0x0a, 0x1a, 0x55,
+	0x4e, 0x42, 0x4f, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x43, 0x4f, 0x4e, 0x46, 0x49, 0x52, 0x4d,
+	0x45, 0x44, 0x5f, 0x4f, 0x4e, 0x5f, 0x42, 0x54, 0x43, 0x10, 0x04, 0x12, 0x10, 0x0a, 0x0c, 0x53,
+	0x50, 0x45, 0x4e, 0x54, 0x5f, 0x4f, 0x4e, 0x5f, 0x42, 0x54, 0x43, 0x10, 0x05, 0x12, 0x0c, 0x0a,
+	0x08, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x44, 0x10, 0x06, 0x12, 0x15, 0x0a, 0x11, 0x55,
+	0x4e, 0x42, 0x4f, 0x4e, 0x44, 0x49, 0x4e, 0x47, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x45, 0x44,
+	0x10, 0x07, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x62, 0x61, 0x62, 0x79, 0x6c, 0x6f, 0x6e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x2f, 0x62, 0x74,
+	0x63, 0x2d, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -640,26 +1100,35 @@ func file_transaction_proto_rawDescGZIP() []byte {
 }
 
 var file_transaction_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_transaction_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_transaction_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_transaction_proto_goTypes = []interface{}{
-	(TransactionState)(0),       // 0: proto.TransactionState
-	(*WatchedTxData)(nil),       // 1: proto.WatchedTxData
-	(*BTCConfirmationInfo)(nil), // 2: proto.BTCConfirmationInfo
-	(*CovenantSig)(nil),         // 3: proto.CovenantSig
-	(*UnbondingTxData)(nil),     // 4: proto.UnbondingTxData
-	(*TrackedTransaction)(nil),  // 5: proto.TrackedTransaction
+	(TransactionState)(0),         // 0: proto.TransactionState
+	(*WatchedTxData)(nil),         // 1: proto.WatchedTxData
+	(*BTCConfirmationInfo)(nil),   // 2: proto.BTCConfirmationInfo
+	(*TxFeeInfo)(nil),             // 3: proto.TxFeeInfo
+	(*StakingParamsSnapshot)(nil), // 4: proto.StakingParamsSnapshot
+	(*CovenantSig)(nil),           // 5: proto.CovenantSig
+	(*UnbondingTxData)(nil),       // 6: proto.UnbondingTxData
+	(*TrackedTransaction)(nil),    // 7: proto.TrackedTransaction
+	(*DelegationRecord)(nil),      // 8: proto.DelegationRecord
 }
 var file_transaction_proto_depIdxs = []int32{
-	3, // 0: proto.UnbondingTxData.covenant_signatures:type_name -> proto.CovenantSig
-	2, // 1: proto.UnbondingTxData.unbonding_tx_btc_confirmation_info:type_name -> proto.BTCConfirmationInfo
-	2, // 2: proto.TrackedTransaction.staking_tx_btc_confirmation_info:type_name -> proto.BTCConfirmationInfo
-	0, // 3: proto.TrackedTransaction.state:type_name -> proto.TransactionState
-	4, // 4: proto.TrackedTransaction.unbonding_tx_data:type_name -> proto.UnbondingTxData
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	5,  // 0: proto.UnbondingTxData.covenant_signatures:type_name -> proto.CovenantSig
+	2,  // 1: proto.UnbondingTxData.unbonding_tx_btc_confirmation_info:type_name -> proto.BTCConfirmationInfo
+	2,  // 2: proto.TrackedTransaction.staking_tx_btc_confirmation_info:type_name -> proto.BTCConfirmationInfo
+	0,  // 3: proto.TrackedTransaction.state:type_name -> proto.TransactionState
+	6,  // 4: proto.TrackedTransaction.unbonding_tx_data:type_name -> proto.UnbondingTxData
+	3,  // 5: proto.TrackedTransaction.staking_tx_fee_info:type_name -> proto.TxFeeInfo
+	3,  // 6: proto.TrackedTransaction.spend_tx_fee_info:type_name -> proto.TxFeeInfo
+	4,  // 7: proto.TrackedTransaction.staking_params_snapshot:type_name -> proto.StakingParamsSnapshot
+	7,  // 8: proto.DelegationRecord.tracked_transaction:type_name -> proto.TrackedTransaction
+	1,  // 9: proto.DelegationRecord.watched_tx_data:type_name -> proto.WatchedTxData
+	1,  // 10: proto.DelegationRecord.prepared_tx_data:type_name -> proto.WatchedTxData
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
 }
 
 func init() { file_transaction_proto_init() }
@@ -693,7 +1162,7 @@ func file_transaction_proto_init() {
 			}
 		}
 		file_transaction_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CovenantSig); i {
+			switch v := v.(*TxFeeInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -705,7 +1174,7 @@ func file_transaction_proto_init() {
 			}
 		}
 		file_transaction_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*UnbondingTxData); i {
+			switch v := v.(*StakingParamsSnapshot); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -717,6 +1186,30 @@ func file_transaction_proto_init() {
 			}
 		}
 		file_transaction_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CovenantSig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_transaction_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnbondingTxData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_transaction_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TrackedTransaction); i {
 			case 0:
 				return &v.state
@@ -728,6 +1221,18 @@ func file_transaction_proto_init() {
 				return nil
 			}
 		}
+		file_transaction_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DelegationRecord); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -735,7 +1240,7 @@ func file_transaction_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_transaction_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   5,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,227 @@
+package simulation
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	staking "github.com/babylonchain/babylon/btcstaking"
+	cl "github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	pv "github.com/cosmos/relayer/v2/relayer/provider"
+)
+
+// BabylonClient wraps real babylon client. All queries about finality providers
+// and keyring operations are answered by wrapped client, but delegations are
+// never submitted to babylon. Instead they are stored in memory and are treated
+// as accepted and immediately signed by simulated covenant committee.
+// To be able to produce valid covenant signatures, params returned by this client
+// have covenant keys replaced by keys of simulated covenant committee.
+type BabylonClient struct {
+	cl.BabylonClient
+	chain  *Chain
+	params *chaincfg.Params
+
+	mu           sync.Mutex
+	covenantKeys []*btcec.PrivateKey
+	delegations  map[chainhash.Hash]*cl.DelegationData
+}
+
+var _ cl.BabylonClient = (*BabylonClient)(nil)
+
+func NewBabylonClient(bc cl.BabylonClient, chain *Chain, params *chaincfg.Params) *BabylonClient {
+	return &BabylonClient{
+		BabylonClient: bc,
+		chain:         chain,
+		params:        params,
+		delegations:   make(map[chainhash.Hash]*cl.DelegationData),
+	}
+}
+
+// simulatedTxHash computes deterministic hash of babylon transaction, in the same
+// format as babylon uses
+func simulatedTxHash(msgType string, stakingTxHash *chainhash.Hash) string {
+	hash := sha256.Sum256(append([]byte(msgType), stakingTxHash[:]...))
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+func (b *BabylonClient) Params(ctx context.Context) (*cl.StakingParams, error) {
+	params, err := b.BabylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// committee has the same size as the real one, so that quorum stays valid
+	for len(b.covenantKeys) < len(params.CovenantPks) {
+		key, err := btcec.NewPrivateKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		b.covenantKeys = append(b.covenantKeys, key)
+	}
+
+	covenantPks := make([]*btcec.PublicKey, len(params.CovenantPks))
+	for i := range covenantPks {
+		covenantPks[i] = b.covenantKeys[i].PubKey()
+	}
+
+	simulatedParams := *params
+	simulatedParams.CovenantPks = covenantPks
+
+	return &simulatedParams, nil
+}
+
+func (b *BabylonClient) Delegate(dg *cl.DelegationData) (*pv.RelayerTxResponse, error) {
+	stakingTxHash := dg.StakingTransaction.TxHash()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.delegations[stakingTxHash]; exists {
+		return nil, fmt.Errorf("delegation for staking tx %s already exists", stakingTxHash)
+	}
+
+	b.delegations[stakingTxHash] = dg
+
+	return &pv.RelayerTxResponse{
+		TxHash: simulatedTxHash("MsgCreateBTCDelegation", &stakingTxHash),
+		Code:   0,
+	}, nil
+}
+
+func (b *BabylonClient) Undelegate(req *cl.UndelegationRequest) (*pv.RelayerTxResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.delegations[req.StakingTxHash]; !exists {
+		return nil, fmt.Errorf("delegation do not exist: %w", cl.ErrDelegationNotFound)
+	}
+
+	return &pv.RelayerTxResponse{
+		TxHash: simulatedTxHash("MsgBTCUndelegate", &req.StakingTxHash),
+		Code:   0,
+	}, nil
+}
+
+// QueryHeaderDepth returns depth of the header in simulated chain, as simulated
+// babylon btc light client is always in sync with simulated chain
+func (b *BabylonClient) QueryHeaderDepth(_ context.Context, headerHash *chainhash.Hash) (uint64, error) {
+	depth, found := b.chain.HeaderDepth(headerHash)
+
+	if !found {
+		return 0, fmt.Errorf("header %s not found in simulated chain: %w", headerHash, cl.ErrHeaderNotKnownToBabylon)
+	}
+
+	return depth, nil
+}
+
+// GetBTCHeaderChainTip returns best block of simulated chain, as simulated
+// babylon btc light client is always in sync with simulated chain
+func (b *BabylonClient) GetBTCHeaderChainTip(_ context.Context) (uint32, chainhash.Hash, error) {
+	height, hash := b.chain.BestBlock()
+	return height, hash, nil
+}
+
+func (b *BabylonClient) IsTxAlreadyPartOfDelegation(_ context.Context, stakingTxHash *chainhash.Hash) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, exists := b.delegations[*stakingTxHash]
+	return exists, nil
+}
+
+func (b *BabylonClient) QueryDelegationInfo(ctx context.Context, stakingTxHash *chainhash.Hash) (*cl.DelegationInfo, error) {
+	b.mu.Lock()
+	dg, exists := b.delegations[*stakingTxHash]
+	b.mu.Unlock()
+
+	if !exists {
+		return nil, fmt.Errorf("delegation do not exist: %w", cl.ErrDelegationNotFound)
+	}
+
+	covenantSigs, err := b.signUnbondingTx(ctx, dg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	covenantSigners := make([]*btcec.PublicKey, len(covenantSigs))
+	for i, sig := range covenantSigs {
+		covenantSigners[i] = sig.PubKey
+	}
+
+	return &cl.DelegationInfo{
+		Active:          true,
+		CovenantSigners: covenantSigners,
+		UndelegationInfo: &cl.UndelegationInfo{
+			CovenantUnbondingSignatures: covenantSigs,
+			UnbondingTransaction:        dg.Ud.UnbondingTransaction,
+			UnbondingTime:               dg.Ud.UnbondingTxUnbondingTime,
+		},
+	}, nil
+}
+
+// signUnbondingTx signs unbonding transaction of the delegation by quorum of
+// simulated covenant committee
+func (b *BabylonClient) signUnbondingTx(ctx context.Context, dg *cl.DelegationData) ([]cl.CovenantSignatureInfo, error) {
+	params, err := b.Params(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		dg.StakerBtcPk,
+		dg.FinalityProvidersBtcPks,
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		dg.StakingTime,
+		dg.StakingValue,
+		b.params,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build staking info: %w", err)
+	}
+
+	unbondingPathInfo, err := stakingInfo.UnbondingPathSpendInfo()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build unbonding path info: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var sigs []cl.CovenantSignatureInfo
+	for _, key := range b.covenantKeys[:params.CovenantQuruomThreshold] {
+		sig, err := staking.SignTxWithOneScriptSpendInputFromScript(
+			dg.Ud.UnbondingTransaction,
+			dg.StakingTransaction.TxOut[dg.StakingTransactionIdx],
+			key,
+			unbondingPathInfo.RevealedLeaf.Script,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign unbonding tx: %w", err)
+		}
+
+		sigs = append(sigs, cl.CovenantSignatureInfo{
+			Signature: sig,
+			PubKey:    key.PubKey(),
+		})
+	}
+
+	return sigs, nil
+}
@@ -0,0 +1,501 @@
+package simulation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	notifier "github.com/lightningnetwork/lnd/chainntnfs"
+)
+
+// version of blocks mined by simulated chain, the same as blocks produced by
+// current bitcoin core
+const simulatedBlockVersion = 0x20000000
+
+// minedTx is transaction included in simulated block
+type minedTx struct {
+	blockHash   chainhash.Hash
+	blockHeight uint32
+	txIndex     uint32
+}
+
+type simulatedTx struct {
+	tx    *wire.MsgTx
+	mined *minedTx
+}
+
+type confSubscription struct {
+	txHash   chainhash.Hash
+	numConfs uint32
+	event    *notifier.ConfirmationEvent
+}
+
+type epochSubscription struct {
+	epochs chan *notifier.BlockEpoch
+	cancel chan struct{}
+}
+
+// Chain is in memory btc chain, which does not connect to any btc node. Every
+// transaction sent to it lands in simulated mempool and is included in the next
+// block mined by the chain. Blocks are mined on timer, every blockInterval.
+// Chain implements chainntnfs.ChainNotifier, so it can be used in place of
+// real node backend.
+// Chain does not validate transactions in any way i.e it does not check
+// signatures, time locks nor double spends.
+type Chain struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	quit      chan struct{}
+	started   bool
+
+	params        *chaincfg.Params
+	blockInterval time.Duration
+
+	mu                 sync.Mutex
+	bestHeight         uint32
+	bestHash           chainhash.Hash
+	blockHeights       map[chainhash.Hash]uint32
+	blocks             map[chainhash.Hash]*wire.MsgBlock
+	mempool            []*wire.MsgTx
+	txs                map[chainhash.Hash]*simulatedTx
+	spentOutputs       map[wire.OutPoint]chainhash.Hash
+	confSubscriptions  map[uint64]*confSubscription
+	epochSubscriptions map[uint64]*epochSubscription
+	nextSubscriptionId uint64
+	// number of reorgs performed so far, used as block nonce so that blocks of
+	// competing chain have different hashes than replaced blocks
+	reorgs uint32
+}
+
+var _ notifier.ChainNotifier = (*Chain)(nil)
+
+// NewChain creates simulated chain starting at genesis block of provided network
+func NewChain(params *chaincfg.Params, blockInterval time.Duration) *Chain {
+	genesisHash := *params.GenesisHash
+
+	return &Chain{
+		quit:               make(chan struct{}),
+		params:             params,
+		blockInterval:      blockInterval,
+		bestHeight:         0,
+		bestHash:           genesisHash,
+		blockHeights:       map[chainhash.Hash]uint32{genesisHash: 0},
+		blocks:             map[chainhash.Hash]*wire.MsgBlock{genesisHash: params.GenesisBlock},
+		txs:                make(map[chainhash.Hash]*simulatedTx),
+		spentOutputs:       make(map[wire.OutPoint]chainhash.Hash),
+		confSubscriptions:  make(map[uint64]*confSubscription),
+		epochSubscriptions: make(map[uint64]*epochSubscription),
+	}
+}
+
+func (c *Chain) Start() error {
+	c.startOnce.Do(func() {
+		c.mu.Lock()
+		c.started = true
+		c.mu.Unlock()
+
+		c.wg.Add(1)
+		go c.mineBlocks()
+	})
+	return nil
+}
+
+func (c *Chain) Started() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.started
+}
+
+func (c *Chain) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.quit)
+		c.wg.Wait()
+	})
+	return nil
+}
+
+func (c *Chain) mineBlocks() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.blockInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.MineBlock()
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// SendTransaction adds transaction to simulated mempool. Transaction will be
+// included in the next mined block.
+func (c *Chain) SendTransaction(tx *wire.MsgTx) (*chainhash.Hash, error) {
+	txHash := tx.TxHash()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.txs[txHash]; exists {
+		return nil, fmt.Errorf("transaction %s already sent to simulated chain", txHash)
+	}
+
+	c.txs[txHash] = &simulatedTx{tx: tx}
+	c.mempool = append(c.mempool, tx)
+
+	return &txHash, nil
+}
+
+func coinbaseTx(height uint32) *wire.MsgTx {
+	var heightBytes [4]byte
+	binary.LittleEndian.PutUint32(heightBytes[:], height)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(
+		wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		heightBytes[:],
+		nil,
+	))
+	tx.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+	return tx
+}
+
+// MineBlock mines new block containing all transactions from simulated mempool
+// and notifies all subscribers about it.
+func (c *Chain) MineBlock() *wire.MsgBlock {
+	c.mu.Lock()
+
+	height := c.bestHeight + 1
+	txs := append([]*wire.MsgTx{coinbaseTx(height)}, c.mempool...)
+	c.mempool = nil
+
+	utilTxs := make([]*btcutil.Tx, len(txs))
+	for i, tx := range txs {
+		utilTxs[i] = btcutil.NewTx(tx)
+	}
+
+	merkleRoot := blockchain.CalcMerkleRoot(utilTxs, false)
+
+	block := wire.NewMsgBlock(wire.NewBlockHeader(
+		simulatedBlockVersion,
+		&c.bestHash,
+		&merkleRoot,
+		c.params.PowLimitBits,
+		c.reorgs,
+	))
+	block.Header.Timestamp = time.Unix(time.Now().Unix(), 0)
+
+	for _, tx := range txs {
+		// error is returned only when block is full, which is not the case
+		// for simulated blocks
+		_ = block.AddTransaction(tx)
+	}
+
+	blockHash := block.BlockHash()
+
+	for i, tx := range txs[1:] {
+		simTx := c.txs[tx.TxHash()]
+		simTx.mined = &minedTx{
+			blockHash:   blockHash,
+			blockHeight: height,
+			txIndex:     uint32(i + 1),
+		}
+
+		for _, in := range tx.TxIn {
+			c.spentOutputs[in.PreviousOutPoint] = tx.TxHash()
+		}
+	}
+
+	c.bestHeight = height
+	c.bestHash = blockHash
+	c.blockHeights[blockHash] = height
+	c.blocks[blockHash] = block
+
+	epoch := &notifier.BlockEpoch{
+		Hash:        &blockHash,
+		Height:      int32(height),
+		BlockHeader: &block.Header,
+	}
+
+	var epochSubs []*epochSubscription
+	for _, sub := range c.epochSubscriptions {
+		epochSubs = append(epochSubs, sub)
+	}
+
+	for id, sub := range c.confSubscriptions {
+		if c.notifyConfSubscription(sub) {
+			delete(c.confSubscriptions, id)
+		}
+	}
+
+	c.mu.Unlock()
+
+	for _, sub := range epochSubs {
+		select {
+		case sub.epochs <- epoch:
+		case <-sub.cancel:
+		case <-c.quit:
+		}
+	}
+
+	return block
+}
+
+// Reorg replaces last depth blocks of simulated chain with competing chain one
+// block longer. Transactions from disconnected blocks are moved back to mempool
+// and included in the first block of competing chain, apart from droppedTxs
+// which are forgotten by the chain, as if they were double spent by competing
+// chain. Confirmation subscriptions which were already notified are not notified
+// about reorg. Returns blocks of competing chain.
+func (c *Chain) Reorg(depth uint32, droppedTxs ...chainhash.Hash) []*wire.MsgBlock {
+	c.mu.Lock()
+
+	if depth > c.bestHeight {
+		depth = c.bestHeight
+	}
+
+	dropped := make(map[chainhash.Hash]struct{}, len(droppedTxs))
+	for _, txHash := range droppedTxs {
+		dropped[txHash] = struct{}{}
+	}
+
+	// disconnect blocks from the tip, so that transactions are collected in
+	// reverse order
+	var disconnectedTxs []*wire.MsgTx
+	for i := uint32(0); i < depth; i++ {
+		block := c.blocks[c.bestHash]
+
+		for j := len(block.Transactions) - 1; j > 0; j-- {
+			tx := block.Transactions[j]
+			txHash := tx.TxHash()
+
+			for _, in := range tx.TxIn {
+				delete(c.spentOutputs, in.PreviousOutPoint)
+			}
+
+			if _, drop := dropped[txHash]; drop {
+				delete(c.txs, txHash)
+				continue
+			}
+
+			c.txs[txHash].mined = nil
+			disconnectedTxs = append(disconnectedTxs, tx)
+		}
+
+		delete(c.blocks, c.bestHash)
+		delete(c.blockHeights, c.bestHash)
+		c.bestHash = block.Header.PrevBlock
+		c.bestHeight--
+	}
+
+	mempool := make([]*wire.MsgTx, 0, len(disconnectedTxs)+len(c.mempool))
+	for i := len(disconnectedTxs) - 1; i >= 0; i-- {
+		mempool = append(mempool, disconnectedTxs[i])
+	}
+
+	for _, tx := range c.mempool {
+		txHash := tx.TxHash()
+		if _, drop := dropped[txHash]; drop {
+			delete(c.txs, txHash)
+			continue
+		}
+		mempool = append(mempool, tx)
+	}
+
+	c.mempool = mempool
+	c.reorgs++
+	c.mu.Unlock()
+
+	blocks := make([]*wire.MsgBlock, 0, depth+1)
+	for i := uint32(0); i <= depth; i++ {
+		blocks = append(blocks, c.MineBlock())
+	}
+
+	return blocks
+}
+
+// notifyConfSubscription sends updates to confirmation subscription. It returns
+// true if transaction reached required number of confirmations. Must be called
+// with mutex held.
+func (c *Chain) notifyConfSubscription(sub *confSubscription) bool {
+	simTx, found := c.txs[sub.txHash]
+
+	if !found || simTx.mined == nil {
+		return false
+	}
+
+	numConfs := c.bestHeight - simTx.mined.blockHeight + 1
+
+	if numConfs < sub.numConfs {
+		select {
+		case sub.event.Updates <- sub.numConfs - numConfs:
+		default:
+		}
+		return false
+	}
+
+	sub.event.Confirmed <- &notifier.TxConfirmation{
+		BlockHash:   &simTx.mined.blockHash,
+		BlockHeight: simTx.mined.blockHeight,
+		TxIndex:     simTx.mined.txIndex,
+		Tx:          simTx.tx,
+		Block:       c.blocks[simTx.mined.blockHash],
+	}
+
+	return true
+}
+
+func (c *Chain) RegisterConfirmationsNtfn(
+	txid *chainhash.Hash,
+	pkScript []byte,
+	numConfs, heightHint uint32,
+	opts ...notifier.NotifierOption,
+) (*notifier.ConfirmationEvent, error) {
+	if numConfs == 0 {
+		return nil, fmt.Errorf("number of confirmations must be greater than 0")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextSubscriptionId
+	c.nextSubscriptionId++
+
+	sub := &confSubscription{
+		txHash:   *txid,
+		numConfs: numConfs,
+	}
+
+	sub.event = notifier.NewConfirmationEvent(numConfs, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.confSubscriptions, id)
+	})
+
+	if !c.notifyConfSubscription(sub) {
+		c.confSubscriptions[id] = sub
+	}
+
+	return sub.event, nil
+}
+
+func (c *Chain) RegisterSpendNtfn(
+	outpoint *wire.OutPoint,
+	pkScript []byte,
+	heightHint uint32,
+) (*notifier.SpendEvent, error) {
+	return nil, fmt.Errorf("spend notifications are not supported by simulated chain")
+}
+
+func (c *Chain) RegisterBlockEpochNtfn(bestBlock *notifier.BlockEpoch) (*notifier.BlockEpochEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.nextSubscriptionId
+	c.nextSubscriptionId++
+
+	sub := &epochSubscription{
+		// buffer of size one, so that we can send current best block without blocking
+		epochs: make(chan *notifier.BlockEpoch, 1),
+		cancel: make(chan struct{}),
+	}
+
+	if bestBlock == nil {
+		bestHash := c.bestHash
+		sub.epochs <- &notifier.BlockEpoch{
+			Hash:        &bestHash,
+			Height:      int32(c.bestHeight),
+			BlockHeader: &c.blocks[bestHash].Header,
+		}
+	}
+
+	c.epochSubscriptions[id] = sub
+
+	var cancelOnce sync.Once
+
+	return &notifier.BlockEpochEvent{
+		Epochs: sub.epochs,
+		Cancel: func() {
+			cancelOnce.Do(func() {
+				c.mu.Lock()
+				delete(c.epochSubscriptions, id)
+				c.mu.Unlock()
+				close(sub.cancel)
+			})
+		},
+	}, nil
+}
+
+// TxDetails returns status of transaction in simulated chain
+func (c *Chain) TxDetails(txHash *chainhash.Hash) (*notifier.TxConfirmation, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	simTx, found := c.txs[*txHash]
+
+	if !found {
+		return nil, false
+	}
+
+	if simTx.mined == nil {
+		return nil, true
+	}
+
+	return &notifier.TxConfirmation{
+		BlockHash:   &simTx.mined.blockHash,
+		BlockHeight: simTx.mined.blockHeight,
+		TxIndex:     simTx.mined.txIndex,
+		Tx:          simTx.tx,
+		Block:       c.blocks[simTx.mined.blockHash],
+	}, true
+}
+
+// OutputSpent returns true if given output was spent by transaction included
+// in simulated chain
+func (c *Chain) OutputSpent(outpoint *wire.OutPoint) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, spent := c.spentOutputs[*outpoint]
+	return spent
+}
+
+// BestHeight returns height of the best block in simulated chain
+func (c *Chain) BestHeight() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bestHeight
+}
+
+// BestBlock returns height and hash of the best block in simulated chain
+func (c *Chain) BestBlock() (uint32, chainhash.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bestHeight, c.bestHash
+}
+
+// HeaderDepth returns depth of the block with given hash in simulated chain
+func (c *Chain) HeaderDepth(blockHash *chainhash.Hash) (uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	height, found := c.blockHeights[*blockHash]
+
+	if !found {
+		return 0, false
+	}
+
+	return uint64(c.bestHeight - height), true
+}
@@ -0,0 +1,123 @@
+package simulation_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/simulation"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(100000, []byte{0x51}))
+	return tx
+}
+
+func TestSimulatedChainConfirmations(t *testing.T) {
+	// large interval, so that blocks are mined only manually
+	chain := simulation.NewChain(&chaincfg.SimNetParams, time.Hour)
+	err := chain.Start()
+	require.NoError(t, err)
+	defer chain.Stop()
+
+	epochs, err := chain.RegisterBlockEpochNtfn(nil)
+	require.NoError(t, err)
+	defer epochs.Cancel()
+
+	genesis := <-epochs.Epochs
+	require.Equal(t, int32(0), genesis.Height)
+
+	tx := makeTestTx()
+	txHash, err := chain.SendTransaction(tx)
+	require.NoError(t, err)
+	require.Equal(t, tx.TxHash(), *txHash)
+
+	// sending the same transaction twice is an error
+	_, err = chain.SendTransaction(tx)
+	require.Error(t, err)
+
+	details, found := chain.TxDetails(txHash)
+	require.True(t, found)
+	require.Nil(t, details)
+
+	confEvent, err := chain.RegisterConfirmationsNtfn(txHash, nil, 2, 0)
+	require.NoError(t, err)
+	defer confEvent.Cancel()
+
+	block := chain.MineBlock()
+	require.Len(t, block.Transactions, 2)
+	require.Equal(t, int32(1), (<-epochs.Epochs).Height)
+
+	details, found = chain.TxDetails(txHash)
+	require.True(t, found)
+	require.NotNil(t, details)
+	require.Equal(t, uint32(1), details.BlockHeight)
+	require.Equal(t, uint32(1), details.TxIndex)
+	require.True(t, chain.OutputSpent(&tx.TxIn[0].PreviousOutPoint))
+
+	require.Equal(t, uint32(1), <-confEvent.Updates)
+	select {
+	case <-confEvent.Confirmed:
+		t.Fatalf("transaction should not have enough confirmations")
+	default:
+	}
+
+	chain.MineBlock()
+	require.Equal(t, int32(2), (<-epochs.Epochs).Height)
+
+	conf := <-confEvent.Confirmed
+	require.Equal(t, block.BlockHash(), *conf.BlockHash)
+	require.Equal(t, uint32(1), conf.BlockHeight)
+	require.Equal(t, *txHash, conf.Tx.TxHash())
+
+	depth, found := chain.HeaderDepth(conf.BlockHash)
+	require.True(t, found)
+	require.Equal(t, uint64(1), depth)
+}
+
+func TestSimulatedChainReorg(t *testing.T) {
+	chain := simulation.NewChain(&chaincfg.SimNetParams, time.Hour)
+
+	tx := makeTestTx()
+	txHash, err := chain.SendTransaction(tx)
+	require.NoError(t, err)
+
+	droppedTx := makeTestTx()
+	droppedTx.TxIn[0].PreviousOutPoint.Index = 1
+	droppedTxHash, err := chain.SendTransaction(droppedTx)
+	require.NoError(t, err)
+
+	block := chain.MineBlock()
+	chain.MineBlock()
+	require.Equal(t, uint32(2), chain.BestHeight())
+
+	blocks := chain.Reorg(2, *droppedTxHash)
+	require.Len(t, blocks, 3)
+	require.Equal(t, uint32(3), chain.BestHeight())
+
+	// replaced block is not part of the chain anymore
+	_, found := chain.HeaderDepth(&block.Header.PrevBlock)
+	require.True(t, found)
+	blockHash := block.BlockHash()
+	_, found = chain.HeaderDepth(&blockHash)
+	require.False(t, found)
+
+	// transaction is included in the first block of competing chain
+	details, found := chain.TxDetails(txHash)
+	require.True(t, found)
+	require.NotNil(t, details)
+	require.Equal(t, blocks[0].BlockHash(), *details.BlockHash)
+	require.Equal(t, uint32(1), details.BlockHeight)
+	require.NotEqual(t, blockHash, *details.BlockHash)
+
+	// dropped transaction is forgotten by the chain
+	_, found = chain.TxDetails(droppedTxHash)
+	require.False(t, found)
+	require.False(t, chain.OutputSpent(&droppedTx.TxIn[0].PreviousOutPoint))
+	require.True(t, chain.OutputSpent(&tx.TxIn[0].PreviousOutPoint))
+}
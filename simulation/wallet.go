@@ -0,0 +1,61 @@
+package simulation
+
+import (
+	"context"
+
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	notifier "github.com/lightningnetwork/lnd/chainntnfs"
+)
+
+// WalletController wraps real wallet controller. All signing and funding is
+// done by the wrapped wallet, but transactions are sent to simulated chain
+// instead of btc network, and all chain queries are answered by simulated chain.
+// As sent transactions never reach real wallet, outputs used to fund them are
+// not marked as spent in the wallet.
+type WalletController struct {
+	walletcontroller.WalletController
+	chain *Chain
+}
+
+var _ walletcontroller.WalletController = (*WalletController)(nil)
+
+func NewWalletController(wc walletcontroller.WalletController, chain *Chain) *WalletController {
+	return &WalletController{
+		WalletController: wc,
+		chain:            chain,
+	}
+}
+
+func (w *WalletController) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return w.chain.SendTransaction(tx)
+}
+
+func (w *WalletController) TxDetails(txHash *chainhash.Hash, pkScript []byte) (*notifier.TxConfirmation, walletcontroller.TxStatus, error) {
+	details, found := w.chain.TxDetails(txHash)
+
+	if !found {
+		return nil, walletcontroller.TxNotFound, nil
+	}
+
+	if details == nil {
+		return nil, walletcontroller.TxInMemPool, nil
+	}
+
+	return details, walletcontroller.TxInChain, nil
+}
+
+func (w *WalletController) OutputSpent(txHash *chainhash.Hash, outputIdx uint32) (bool, error) {
+	return w.chain.OutputSpent(wire.NewOutPoint(txHash, outputIdx)), nil
+}
+
+// TestMempoolAccept is not supported, as transactions are never sent to the
+// mempool of real node
+func (w *WalletController) TestMempoolAccept(tx *wire.MsgTx) (bool, string, error) {
+	return false, "", walletcontroller.ErrUnsupportedByBackend
+}
@@ -1,6 +1,7 @@
 package staker
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -8,6 +9,7 @@ import (
 	cl "github.com/babylonchain/btc-staker/babylonclient"
 	"github.com/babylonchain/btc-staker/stakerdb"
 	"github.com/babylonchain/btc-staker/utils"
+	"github.com/babylonchain/btc-staker/walletcontroller"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -27,12 +29,18 @@ type sendDelegationRequest struct {
 }
 
 func (app *StakerApp) buildOwnedDelegation(
+	ctx context.Context,
 	req *sendDelegationRequest,
 	stakerAddress btcutil.Address,
 	storedTx *stakerdb.StoredTransaction,
 	stakingTxInclusionProof []byte,
 ) (*cl.DelegationData, error) {
-	externalData, err := app.retrieveExternalDelegationData(stakerAddress)
+	w, err := app.walletForTx(storedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	externalData, err := app.retrieveExternalDelegationData(ctx, w.wc, stakerAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -89,54 +97,117 @@ func (app *StakerApp) buildOwnedDelegation(
 	return dg, nil
 }
 
+// presignDelegation builds slashing and unbonding transactions of owned
+// delegation and signs them with staker key, so that delegation can be sent to
+// babylon once staking transaction is confirmed without access to the wallet.
+func (app *StakerApp) presignDelegation(
+	wc walletcontroller.WalletController,
+	stakerAddress btcutil.Address,
+	storedTx *stakerdb.StoredTransaction,
+	params *cl.StakingParams,
+) (*stakerdb.WatchedTransactionData, error) {
+	stakerPrivKey, err := app.stakerPrivateKey(wc, stakerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	externalData := &externalDelegationData{
+		stakerPrivKey:     stakerPrivKey,
+		babylonStakerAddr: app.babylonClient.GetKeyAddress(),
+		babylonParams:     params,
+	}
+
+	slashingFee := app.getSlashingFee(params.MinSlashingTxFeeSat)
+
+	slashingTx, slashingTxSig, err := buildSlashingTxAndSig(slashingFee, externalData, storedTx, app.network)
+	if err != nil {
+		return nil, err
+	}
+
+	unbondingTime := uint16(params.MinUnbondingTime) + 1
+
+	undelegationData, err := createUndelegationData(
+		storedTx,
+		stakerPrivKey,
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		params.SlashingAddress,
+		btcutil.Amount(app.feeEstimator.EstimateFeePerKb()),
+		unbondingTime,
+		slashingFee,
+		params.SlashingRate,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating undelegation data: %w", err)
+	}
+
+	return &stakerdb.WatchedTransactionData{
+		SlashingTx:             slashingTx,
+		SlashingTxSig:          slashingTxSig,
+		StakerBabylonAddr:      externalData.babylonStakerAddr,
+		StakerBtcPubKey:        stakerPrivKey.PubKey(),
+		UnbondingTx:            undelegationData.UnbondingTransaction,
+		SlashingUnbondingTx:    undelegationData.SlashUnbondingTransaction,
+		SlashingUnbondingTxSig: undelegationData.SlashUnbondingTransactionSig,
+		UnbondingTime:          unbondingTime,
+	}, nil
+}
+
 func (app *StakerApp) buildDelegation(
+	ctx context.Context,
 	req *sendDelegationRequest,
 	stakerAddress btcutil.Address,
 	storedTx *stakerdb.StoredTransaction) (*cl.DelegationData, error) {
 
 	stakingTxInclusionProof := app.mustBuildInclusionProof(req)
 
-	if storedTx.Watched {
-		watchedData, err := app.txTracker.GetWatchedTransactionData(&req.txHash)
-
-		if err != nil {
-			// Fatal error as if delegation is watched, the watched data must be in database
-			// and must be not malformed
-			app.logger.WithFields(logrus.Fields{
-				"btcTxHash":     req.txHash,
-				"stakerAddress": stakerAddress,
-				"err":           err,
-			}).Fatalf("Failed to build delegation data for already confirmed staking transaction")
-		}
+	// watched delegations are always signed up front. Owned delegations are
+	// signed up front only if staker key may not be available at this point
+	watchedData, err := app.txTracker.GetWatchedTransactionData(&req.txHash)
 
-		undelegationData := cl.UndelegationData{
-			UnbondingTransaction:         watchedData.UnbondingTx,
-			UnbondingTxValue:             btcutil.Amount(watchedData.UnbondingTx.TxOut[0].Value),
-			UnbondingTxUnbondingTime:     watchedData.UnbondingTime,
-			SlashUnbondingTransaction:    watchedData.SlashingUnbondingTx,
-			SlashUnbondingTransactionSig: watchedData.SlashingUnbondingTxSig,
-		}
-
-		dg := createDelegationData(
-			watchedData.StakerBtcPubKey,
-			req.inclusionBlock,
-			req.txIndex,
-			storedTx,
-			watchedData.SlashingTx,
-			watchedData.SlashingTxSig,
-			watchedData.StakerBabylonAddr,
-			stakingTxInclusionProof,
-			&undelegationData,
-		)
-		return dg, nil
-	} else {
+	if errors.Is(err, stakerdb.ErrWatchedDataNotFound) && !storedTx.Watched {
 		return app.buildOwnedDelegation(
+			ctx,
 			req,
 			stakerAddress,
 			storedTx,
 			stakingTxInclusionProof,
 		)
 	}
+
+	if err != nil {
+		// Fatal error as if delegation is watched, the watched data must be in database
+		// and must be not malformed
+		app.logger.WithFields(logrus.Fields{
+			"btcTxHash":     req.txHash,
+			"stakerAddress": stakerAddress,
+			"err":           err,
+		}).Fatalf("Failed to build delegation data for already confirmed staking transaction")
+	}
+
+	undelegationData := cl.UndelegationData{
+		UnbondingTransaction:         watchedData.UnbondingTx,
+		UnbondingTxValue:             btcutil.Amount(watchedData.UnbondingTx.TxOut[0].Value),
+		UnbondingTxUnbondingTime:     watchedData.UnbondingTime,
+		SlashUnbondingTransaction:    watchedData.SlashingUnbondingTx,
+		SlashUnbondingTransactionSig: watchedData.SlashingUnbondingTxSig,
+	}
+
+	dg := createDelegationData(
+		watchedData.StakerBtcPubKey,
+		req.inclusionBlock,
+		req.txIndex,
+		storedTx,
+		watchedData.SlashingTx,
+		watchedData.SlashingTxSig,
+		watchedData.StakerBabylonAddr,
+		stakingTxInclusionProof,
+		&undelegationData,
+	)
+
+	return dg, nil
 }
 
 // TODO for now we launch this handler indefinitly. At some point we may introduce
@@ -147,10 +218,13 @@ func (app *StakerApp) checkForUnbondingTxSignaturesOnBabylon(stakingTxHash *chai
 	defer checkSigTicker.Stop()
 	defer app.wg.Done()
 
+	ctx, cancel := app.appQuitContext()
+	defer cancel()
+
 	for {
 		select {
 		case <-checkSigTicker.C:
-			di, err := app.babylonClient.QueryDelegationInfo(stakingTxHash)
+			di, err := app.babylonClient.QueryDelegationInfo(ctx, stakingTxHash)
 
 			if err != nil {
 				if errors.Is(err, cl.ErrDelegationNotFound) {
@@ -181,7 +255,7 @@ func (app *StakerApp) checkForUnbondingTxSignaturesOnBabylon(stakingTxHash *chai
 				continue
 			}
 
-			params, err := app.babylonClient.Params()
+			params, err := app.babylonClient.Params(ctx)
 
 			if err != nil {
 				app.logger.WithFields(logrus.Fields{
@@ -226,12 +300,12 @@ func (app *StakerApp) checkForUnbondingTxSignaturesOnBabylon(stakingTxHash *chai
 	}
 }
 
-func (app *StakerApp) finalityProviderExists(fpPk *btcec.PublicKey) error {
+func (app *StakerApp) finalityProviderExists(ctx context.Context, fpPk *btcec.PublicKey) error {
 	if fpPk == nil {
 		return fmt.Errorf("provided finality provider public key is nil")
 	}
 
-	_, err := app.babylonClient.QueryFinalityProvider(fpPk)
+	_, err := app.babylonClient.QueryFinalityProvider(ctx, fpPk)
 
 	if err != nil {
 		return fmt.Errorf("error checking if finality provider exists on babylon chain: %w", err)
@@ -0,0 +1,96 @@
+package staker
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// stakerCommand is state changing operation requested through public api of the
+// app. Commands are executed one at a time by the main event loop, between
+// staking events, so that operations which check state of delegation and act
+// upon it do not interleave with each other nor with events changing the same
+// delegation e.g the same stake is never spent or unbonded twice by concurrent
+// callers.
+type stakerCommand struct {
+	name    string
+	ctx     context.Context
+	execute func(ctx context.Context) error
+	err     error
+	done    chan struct{}
+}
+
+// commandCtxKey marks context of command executed by event loop
+type commandCtxKey struct{}
+
+// startEventLoop starts main event loop, which handles staking events and
+// commands. Event loop is started either by Start or by first command, so that
+// commands can be executed also by app which was not started.
+func (app *StakerApp) startEventLoop() {
+	app.eventLoopOnce.Do(func() {
+		app.wg.Add(1)
+		go app.handleStakingEvents()
+	})
+}
+
+// executeCommand runs command in event loop. Command is bounded by configured
+// command timeout, so that hung backend does not block event loop forever.
+func (app *StakerApp) executeCommand(cmd *stakerCommand) {
+	app.logger.WithFields(logrus.Fields{
+		"command": cmd.name,
+	}).Debug("Executing command")
+
+	ctx, cancel := context.WithTimeout(cmd.ctx, app.config.StakerConfig.CommandTimeout)
+	defer cancel()
+
+	cmd.err = cmd.execute(context.WithValue(ctx, commandCtxKey{}, cmd.name))
+	close(cmd.done)
+}
+
+// runCommand executes fn in event loop and waits for its result. fn should only
+// check and change state of delegations, slow operations like queries to btc
+// node, babylon or signing should be done by caller before running command.
+// If ctx is done before command is picked up by event loop, ctx.Err() is
+// returned and fn is not executed.
+// Command which runs other command passes its ctx to it, and nested command is
+// then executed inline, as waiting for event loop from within the event loop
+// would dead lock it.
+func (app *StakerApp) runCommand(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	if ctx.Value(commandCtxKey{}) != nil {
+		return fn(ctx)
+	}
+
+	// check we are not shutting down
+	select {
+	case <-app.quit:
+		return ErrStakerAppStopped
+	default:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	app.startEventLoop()
+
+	cmd := &stakerCommand{
+		name:    name,
+		ctx:     ctx,
+		execute: fn,
+		done:    make(chan struct{}),
+	}
+
+	select {
+	case app.commandChan <- cmd:
+	case <-app.quit:
+		return ErrStakerAppStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// event loop always finishes command it received, even if app is shutting
+	// down
+	<-cmd.done
+
+	return cmd.err
+}
@@ -0,0 +1,305 @@
+package staker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyTrackingWallet records calls made by operations running
+// concurrently. UnlockOutputs is called only by commands executed by event loop,
+// and maximum number of its concurrent calls is recorded.
+type concurrencyTrackingWallet struct {
+	*mockWallet
+	dumpPrivateKeyCalls atomic.Int32
+	unlockOutputsCalls  atomic.Int32
+	inFlight            atomic.Int32
+	maxInFlight         atomic.Int32
+
+	importMu sync.Mutex
+	imported []btcutil.Address
+}
+
+func (w *concurrencyTrackingWallet) DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error) {
+	w.dumpPrivateKeyCalls.Add(1)
+	return nil, errors.New("private key not available in mock wallet")
+}
+
+func (w *concurrencyTrackingWallet) TrackAddress(address btcutil.Address) error {
+	w.importMu.Lock()
+	defer w.importMu.Unlock()
+	w.imported = append(w.imported, address)
+	return nil
+}
+
+func (w *concurrencyTrackingWallet) importedAddresses() []btcutil.Address {
+	w.importMu.Lock()
+	defer w.importMu.Unlock()
+	return w.imported
+}
+
+func (w *concurrencyTrackingWallet) UnlockOutputs(outpoints []wire.OutPoint) error {
+	n := w.inFlight.Add(1)
+	defer w.inFlight.Add(-1)
+	w.unlockOutputsCalls.Add(1)
+
+	for {
+		currentMax := w.maxInFlight.Load()
+		if n <= currentMax || w.maxInFlight.CompareAndSwap(currentMax, n) {
+			break
+		}
+	}
+
+	// give other callers chance to overlap
+	time.Sleep(time.Millisecond)
+
+	return w.mockWallet.UnlockOutputs(outpoints)
+}
+
+// Run with -race to detect unsynchronized access to shared state
+func TestConcurrentOperationsAreSerialized(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &concurrencyTrackingWallet{
+		mockWallet: &mockWallet{txStatus: walletcontroller.TxInMemPool},
+	}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.WalletConfig.AutoImportAddresses = true
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	stakerAddress := makeTestStakerAddress(t)
+	externalAddress := makeTestStakerAddress(t)
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		stakerAddress,
+		nil,
+	)
+	require.NoError(t, err)
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	preparedTx := makeTestStakingTx()
+	preparedTx.TxOut[0].Value++
+	preparedTxHash := preparedTx.TxHash()
+
+	err = store.AddPreparedTransaction(
+		preparedTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerAddress,
+		makeTestStakingTx(),
+		bc.GetKeyAddress(),
+		stakerKey.PubKey(),
+		makeTestStakingTx(),
+		makeTestStakingTx(),
+		101,
+	)
+	require.NoError(t, err)
+
+	const callers = 20
+
+	// none of the operations modifying delegation can succeed with mock wallet,
+	// except cancelling prepared delegation, which must succeed exactly once
+	operations := []func() error{
+		func() error {
+			_, _, err := app.SpendStake(context.Background(), &stakingTxHash, nil)
+			return err
+		},
+		func() error {
+			_, _, err := app.SpendStakingOutputTo(context.Background(), &stakingTxHash, externalAddress, 1000)
+			return err
+		},
+		func() error {
+			_, err := app.UnbondStaking(context.Background(), stakingTxHash, nil)
+			return err
+		},
+		func() error {
+			_, err := app.BumpSpendTxFee(context.Background(), &stakingTxHash, 20000)
+			return err
+		},
+		func() error {
+			_, err := app.RepairDelegationStates(context.Background())
+			return err
+		},
+		func() error {
+			return app.CancelPreparedDelegation(context.Background(), &preparedTxHash)
+		},
+	}
+
+	// errors are checked once all goroutines finish, as test must not fail
+	// from other goroutines
+	opErrs := make([][]error, len(operations))
+	queryErrs := make([]error, callers*len(operations))
+	for i := range opErrs {
+		opErrs[i] = make([]error, callers)
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < callers; i++ {
+		for opIdx, op := range operations {
+			wg.Add(2)
+			go func(i, opIdx int, run func() error) {
+				defer wg.Done()
+				opErrs[opIdx][i] = run()
+			}(i, opIdx, op)
+
+			// queries are served concurrently with commands
+			go func(queryIdx int) {
+				defer wg.Done()
+				storedTx, err := app.GetStoredTransaction(&stakingTxHash)
+				if err == nil && storedTx.StakingTx.TxHash() != stakingTxHash {
+					err = errors.New("unexpected staking transaction returned")
+				}
+
+				if err == nil {
+					_, err = app.GetDelegationsByState(proto.TransactionState_SENT_TO_BTC)
+				}
+
+				queryErrs[queryIdx] = err
+			}(i*len(operations) + opIdx)
+		}
+	}
+
+	wg.Wait()
+
+	for _, err := range queryErrs {
+		require.NoError(t, err)
+	}
+
+	// spends, unbondings and fee bumps fail
+	for opIdx := 0; opIdx < 4; opIdx++ {
+		for _, err := range opErrs[opIdx] {
+			require.Error(t, err)
+		}
+	}
+
+	// there is nothing to repair
+	for _, err := range opErrs[4] {
+		require.NoError(t, err)
+	}
+
+	// prepared delegation is cancelled and its inputs released exactly once,
+	// other callers find it already removed
+	cancelled := 0
+	for _, err := range opErrs[5] {
+		if err == nil {
+			cancelled++
+			continue
+		}
+		require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+	}
+	require.Equal(t, 1, cancelled)
+	require.Equal(t, int32(1), wallet.unlockOutputsCalls.Load())
+	require.Equal(t, int32(1), wallet.maxInFlight.Load())
+
+	// every spend reached the wallet, delegation is left untouched
+	require.Len(t, wallet.importedAddresses(), callers)
+	require.Equal(t, int32(2*callers), wallet.dumpPrivateKeyCalls.Load())
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+}
+
+func TestOperationsRefusedAfterStop(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxNotFound,
+	}
+	nodeNotifier := &mockNotifier{bestBlockHeight: 100}
+
+	app, _, stakingTx := startAppAfterCrash(t, bc, wallet, nodeNotifier)
+	stakingTxHash := stakingTx.TxHash()
+
+	require.NoError(t, app.Stop())
+
+	_, _, err := app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.ErrorIs(t, err, staker.ErrStakerAppStopped)
+
+	_, err = app.BumpStakingTxFee(context.Background(), &stakingTxHash, 20000)
+	require.ErrorIs(t, err, staker.ErrStakerAppStopped)
+}
+
+func TestOperationsAbortedWithCancelledContext(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &concurrencyTrackingWallet{
+		mockWallet: &mockWallet{txStatus: walletcontroller.TxInMemPool},
+	}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.WalletConfig.AutoImportAddresses = true
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	stakerAddress := makeTestStakerAddress(t)
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		stakerAddress,
+		nil,
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = app.StakeFunds(ctx, stakerAddress, 10000, []*btcec.PublicKey{&fpPk}, 1000, staker.StakeOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, _, err = app.SpendStake(ctx, &stakingTxHash, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = app.UnbondStaking(ctx, stakingTxHash, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	results := app.UnbondStakingBatch(ctx, []*chainhash.Hash{&stakingTxHash}, nil)
+	require.Len(t, results, 1)
+	require.ErrorIs(t, results[0].Err, context.Canceled)
+
+	// nothing reached the wallet and delegation is left untouched
+	require.Equal(t, int32(0), wallet.dumpPrivateKeyCalls.Load())
+	require.Empty(t, wallet.importedAddresses())
+
+	storedTx, err := app.GetStoredTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+}
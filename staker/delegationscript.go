@@ -0,0 +1,151 @@
+package staker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	staking "github.com/babylonchain/babylon/btcstaking"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// stakerPublicKey returns btc public key of staker of tracked transaction. Key of
+// watched transaction is stored with it, key of owned transaction is provided by
+// the wallet which funded it.
+func (app *StakerApp) stakerPublicKey(
+	stakingTxHash *chainhash.Hash,
+	tx *stakerdb.StoredTransaction,
+) (*btcec.PublicKey, error) {
+	if tx.Watched {
+		watchedData, err := app.txTracker.GetWatchedTransactionData(stakingTxHash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return watchedData.StakerBtcPubKey, nil
+	}
+
+	stakerAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
+
+	if err != nil {
+		return nil, fmt.Errorf("error decoding staker address: %w", err)
+	}
+
+	w, err := app.walletForTx(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.wc.AddressPublicKey(stakerAddress)
+}
+
+// delegationCovenant returns covenant committee of delegation. Committee from
+// params snapshot taken when delegation was created is preferred, as current
+// committee could have changed since then.
+func (app *StakerApp) delegationCovenant(
+	ctx context.Context,
+	tx *stakerdb.StoredTransaction,
+) ([]*btcec.PublicKey, uint32, error) {
+	if tx.StakingParamsSnapshot != nil && len(tx.StakingParamsSnapshot.CovenantPks) > 0 {
+		return tx.StakingParamsSnapshot.CovenantPks, tx.StakingParamsSnapshot.CovenantQuorum, nil
+	}
+
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return params.CovenantPks, params.CovenantQuruomThreshold, nil
+}
+
+// VerifyDelegationScript rebuilds staking output script of tracked delegation
+// from its stored staker, finality provider and covenant keys, staking time and
+// amount, and checks it is byte for byte the same as script of the stored staking
+// transaction. If staking transaction is already included in btc chain, script
+// of its output on chain is checked as well. Mismatch is reported with
+// ErrDelegationScriptMismatch.
+func (app *StakerApp) VerifyDelegationScript(ctx context.Context, stakingTxHash *chainhash.Hash) error {
+	tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return err
+	}
+
+	if int(tx.StakingOutputIndex) >= len(tx.StakingTx.TxOut) {
+		return fmt.Errorf("%w: staking output index %d out of range of stored transaction with %d outputs",
+			ErrDelegationScriptMismatch, tx.StakingOutputIndex, len(tx.StakingTx.TxOut))
+	}
+
+	stakingOutput := tx.StakingTx.TxOut[tx.StakingOutputIndex]
+
+	stakerPubKey, err := app.stakerPublicKey(stakingTxHash, tx)
+
+	if err != nil {
+		return fmt.Errorf("cannot verify delegation script. Error getting staker public key: %w", err)
+	}
+
+	covenantPks, covenantQuorum, err := app.delegationCovenant(ctx, tx)
+
+	if err != nil {
+		return fmt.Errorf("cannot verify delegation script. Error getting covenant committee: %w", err)
+	}
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerPubKey,
+		tx.FinalityProvidersBtcPks,
+		covenantPks,
+		covenantQuorum,
+		tx.StakingTime,
+		btcutil.Amount(stakingOutput.Value),
+		app.network,
+	)
+
+	if err != nil {
+		return fmt.Errorf("cannot verify delegation script. Failed to build staking info: %w", err)
+	}
+
+	expectedScript := stakingInfo.StakingOutput.PkScript
+
+	if !bytes.Equal(expectedScript, stakingOutput.PkScript) {
+		return fmt.Errorf("%w: stored staking output script %x, rebuilt script %x",
+			ErrDelegationScriptMismatch, stakingOutput.PkScript, expectedScript)
+	}
+
+	details, status, err := app.wc.TxDetails(stakingTxHash, stakingOutput.PkScript)
+
+	if err != nil {
+		return fmt.Errorf("cannot verify delegation script. Error getting staking transaction from btc node: %w", err)
+	}
+
+	if status != walletcontroller.TxInChain || details == nil || details.Tx == nil {
+		app.logger.WithField("stakingTxHash", stakingTxHash).
+			Debug("Staking transaction not in btc chain. Skipping verification of on chain script")
+		return nil
+	}
+
+	if int(tx.StakingOutputIndex) >= len(details.Tx.TxOut) {
+		return fmt.Errorf("%w: staking output index %d out of range of transaction on chain with %d outputs",
+			ErrDelegationScriptMismatch, tx.StakingOutputIndex, len(details.Tx.TxOut))
+	}
+
+	onChainOutput := details.Tx.TxOut[tx.StakingOutputIndex]
+
+	if !bytes.Equal(expectedScript, onChainOutput.PkScript) {
+		return fmt.Errorf("%w: on chain staking output script %x, rebuilt script %x",
+			ErrDelegationScriptMismatch, onChainOutput.PkScript, expectedScript)
+	}
+
+	if onChainOutput.Value != stakingOutput.Value {
+		return fmt.Errorf("%w: on chain staking output value %d, stored value %d",
+			ErrDelegationScriptMismatch, onChainOutput.Value, stakingOutput.Value)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package staker
 
 import (
 	cl "github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/babylonchain/btc-staker/stakerdb"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
@@ -19,6 +20,7 @@ type StakingEvent interface {
 
 var _ StakingEvent = (*stakingRequestedEvent)(nil)
 var _ StakingEvent = (*stakingTxBtcConfirmedEvent)(nil)
+var _ StakingEvent = (*stakingTxReorgedEvent)(nil)
 var _ StakingEvent = (*delegationSubmittedToBabylonEvent)(nil)
 var _ StakingEvent = (*unbondingTxSignaturesConfirmedOnBabylonEvent)(nil)
 var _ StakingEvent = (*unbondingTxConfirmedOnBtcEvent)(nil)
@@ -36,15 +38,31 @@ type stakingRequestedEvent struct {
 	fpBtcPks                []*btcec.PublicKey
 	requiredDepthOnBtcChain uint32
 	pop                     *cl.BabylonPop
-	watchTxData             *watchTxData
-	errChan                 chan error
-	successChan             chan *chainhash.Hash
+	stakingTxFeeInfo        *stakerdb.TxFeeInfo
+	// change output of owned staking transaction, nil if it has no change
+	stakingTxChange *stakerdb.ChangeOutput
+	paramsSnapshot  *stakerdb.StakingParamsSnapshot
+	// name of the additional wallet which funded owned staking transaction,
+	// empty for the main wallet
+	walletName string
+	// slashing and unbonding transactions of owned delegation signed when
+	// staking transaction was created, nil if they are signed once staking
+	// transaction is confirmed
+	delegationData *stakerdb.WatchedTransactionData
+	watchTxData    *watchTxData
+	// prepared requests are watched requests completing delegation which was
+	// already tracked in PREPARED state
+	prepared bool
 }
 
 func (req *stakingRequestedEvent) isWatched() bool {
 	return req.watchTxData != nil
 }
 
+func (req *stakingRequestedEvent) isPrepared() bool {
+	return req.isWatched() && req.prepared
+}
+
 func newOwnedStakingRequest(
 	stakerAddress btcutil.Address,
 	stakingTx *wire.MsgTx,
@@ -55,6 +73,11 @@ func newOwnedStakingRequest(
 	fpBtcPks []*btcec.PublicKey,
 	confirmationTimeBlocks uint32,
 	pop *cl.BabylonPop,
+	stakingTxFeeInfo *stakerdb.TxFeeInfo,
+	stakingTxChange *stakerdb.ChangeOutput,
+	paramsSnapshot *stakerdb.StakingParamsSnapshot,
+	walletName string,
+	delegationData *stakerdb.WatchedTransactionData,
 ) *stakingRequestedEvent {
 	return &stakingRequestedEvent{
 		stakerAddress:           stakerAddress,
@@ -67,9 +90,12 @@ func newOwnedStakingRequest(
 		fpBtcPks:                fpBtcPks,
 		requiredDepthOnBtcChain: confirmationTimeBlocks,
 		pop:                     pop,
+		stakingTxFeeInfo:        stakingTxFeeInfo,
+		stakingTxChange:         stakingTxChange,
+		paramsSnapshot:          paramsSnapshot,
+		walletName:              walletName,
+		delegationData:          delegationData,
 		watchTxData:             nil,
-		errChan:                 make(chan error, 1),
-		successChan:             make(chan *chainhash.Hash, 1),
 	}
 }
 
@@ -95,6 +121,7 @@ func newWatchedStakingRequest(
 	fpBtcPks []*btcec.PublicKey,
 	confirmationTimeBlocks uint32,
 	pop *cl.BabylonPop,
+	paramsSnapshot *stakerdb.StakingParamsSnapshot,
 	slashingTx *wire.MsgTx,
 	slashingTxSignature *schnorr.Signature,
 	stakerBabylonAddr sdk.AccAddress,
@@ -115,6 +142,7 @@ func newWatchedStakingRequest(
 		fpBtcPks:                fpBtcPks,
 		requiredDepthOnBtcChain: confirmationTimeBlocks,
 		pop:                     pop,
+		paramsSnapshot:          paramsSnapshot,
 		watchTxData: &watchTxData{
 			slashingTx:          slashingTx,
 			slashingTxSig:       slashingTxSignature,
@@ -125,8 +153,6 @@ func newWatchedStakingRequest(
 			slashUnbondingTxSig: slashUnbondingTxSig,
 			unbondingTime:       unbondingTime,
 		},
-		errChan:     make(chan error, 1),
-		successChan: make(chan *chainhash.Hash, 1),
 	}
 }
 
@@ -156,6 +182,20 @@ func (event *stakingTxBtcConfirmedEvent) EventDesc() string {
 	return "STAKING_TX_BTC_CONFIRMED"
 }
 
+type stakingTxReorgedEvent struct {
+	stakingTxHash chainhash.Hash
+	// hash of the block in which transaction was confirmed before reorg
+	blockHash chainhash.Hash
+}
+
+func (event *stakingTxReorgedEvent) EventId() chainhash.Hash {
+	return event.stakingTxHash
+}
+
+func (event *stakingTxReorgedEvent) EventDesc() string {
+	return "STAKING_TX_REORGED"
+}
+
 type delegationSubmittedToBabylonEvent struct {
 	stakingTxHash chainhash.Hash
 	unbondingTx   *wire.MsgTx
@@ -0,0 +1,121 @@
+package staker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+)
+
+const (
+	// maxFeeApiResponseSize limits size of response read from fee api
+	maxFeeApiResponseSize = 1 << 20
+)
+
+// FeeRateSource is a source of fee rate estimates tried by
+// DynamicBtcFeeEstimator when connected btc node cannot estimate fee rate
+type FeeRateSource interface {
+	// Name identifies source of estimates in logs
+	Name() string
+	// EstimateFeeRate returns fee rate for confirmation within confTarget
+	// blocks
+	EstimateFeeRate(confTarget uint32) (chainfee.SatPerKVByte, error)
+}
+
+// HTTPFeeRateSource estimates fee rate using external fee api. Api must respond
+// to GET request with json object mapping confirmation target in blocks to fee
+// rate in sat/vB e.g {"1": 25.1, "3": 20, "6": 12.5}, which is the format used
+// by esplora /fee-estimates endpoint.
+type HTTPFeeRateSource struct {
+	url    string
+	client *http.Client
+}
+
+var _ FeeRateSource = (*HTTPFeeRateSource)(nil)
+
+func NewHTTPFeeRateSource(url string, timeout time.Duration) *HTTPFeeRateSource {
+	return &HTTPFeeRateSource{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *HTTPFeeRateSource) Name() string {
+	return "fee api"
+}
+
+func (s *HTTPFeeRateSource) EstimateFeeRate(confTarget uint32) (chainfee.SatPerKVByte, error) {
+	resp, err := s.client.Get(s.url)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("fee api responded with status %s", resp.Status)
+	}
+
+	var buckets map[string]float64
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxFeeApiResponseSize)).Decode(&buckets); err != nil {
+		return 0, fmt.Errorf("invalid fee api response: %w", err)
+	}
+
+	satPerVByte, err := feeRateFromBuckets(buckets, confTarget)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return chainfee.SatPerKVByte(math.Ceil(satPerVByte * 1000)), nil
+}
+
+// feeRateFromBuckets returns fee rate of bucket with the widest target not
+// exceeding confTarget. If all buckets have wider target, fee rate of the
+// narrowest one is returned, as it is the closest overestimate.
+func feeRateFromBuckets(buckets map[string]float64, confTarget uint32) (float64, error) {
+	var (
+		// widest bucket not exceeding confTarget
+		withinTarget  uint64
+		withinFeeRate float64
+		// narrowest bucket exceeding confTarget
+		overTarget  uint64 = math.MaxUint64
+		overFeeRate float64
+	)
+
+	for targetStr, feeRate := range buckets {
+		target, err := strconv.ParseUint(targetStr, 10, 32)
+
+		if err != nil || target == 0 {
+			return 0, fmt.Errorf("invalid fee api response: invalid confirmation target %q", targetStr)
+		}
+
+		if feeRate <= 0 || math.IsNaN(feeRate) || math.IsInf(feeRate, 0) {
+			return 0, fmt.Errorf("invalid fee api response: invalid fee rate %v for target %d", feeRate, target)
+		}
+
+		if target <= uint64(confTarget) {
+			if target > withinTarget {
+				withinTarget, withinFeeRate = target, feeRate
+			}
+		} else if target < overTarget {
+			overTarget, overFeeRate = target, feeRate
+		}
+	}
+
+	switch {
+	case withinTarget > 0:
+		return withinFeeRate, nil
+	case overTarget < math.MaxUint64:
+		return overFeeRate, nil
+	default:
+		return 0, errors.New("fee api returned no fee rates")
+	}
+}
@@ -0,0 +1,113 @@
+package staker_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/stretchr/testify/require"
+)
+
+func makeFeeApi(t *testing.T, status int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestHTTPFeeRateSource(t *testing.T) {
+	buckets := `{"1": 25.5, "3": 20, "6": 12.0001, "144": 1}`
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		confTarget      uint32
+		expectedFeeRate chainfee.SatPerKVByte
+		expectErr       bool
+	}{
+		{
+			name:            "exact target",
+			status:          http.StatusOK,
+			body:            buckets,
+			confTarget:      3,
+			expectedFeeRate: 20000,
+		},
+		{
+			name:            "widest target within requested one",
+			status:          http.StatusOK,
+			body:            buckets,
+			confTarget:      10,
+			expectedFeeRate: 12001,
+		},
+		{
+			name:            "requested target narrower than all targets",
+			status:          http.StatusOK,
+			body:            `{"2": 22, "6": 12}`,
+			confTarget:      1,
+			expectedFeeRate: 22000,
+		},
+		{
+			name:       "error status",
+			status:     http.StatusServiceUnavailable,
+			body:       buckets,
+			confTarget: 3,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid json",
+			status:     http.StatusOK,
+			body:       `{"1": `,
+			confTarget: 3,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid target",
+			status:     http.StatusOK,
+			body:       `{"soon": 10}`,
+			confTarget: 3,
+			expectErr:  true,
+		},
+		{
+			name:       "invalid fee rate",
+			status:     http.StatusOK,
+			body:       `{"1": 0}`,
+			confTarget: 3,
+			expectErr:  true,
+		},
+		{
+			name:       "no fee rates",
+			status:     http.StatusOK,
+			body:       `{}`,
+			confTarget: 3,
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := makeFeeApi(t, tc.status, tc.body)
+			source := staker.NewHTTPFeeRateSource(server.URL, time.Second)
+
+			feeRate, err := source.EstimateFeeRate(tc.confTarget)
+
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedFeeRate, feeRate)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package staker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// estimateCpfpTxVSize returns virtual size of child transaction spending given
+// change output of staking transaction to given outputs
+func estimateCpfpTxVSize(changePkScript []byte, outputs []*wire.TxOut) (int, error) {
+	switch {
+	case txscript.IsPayToPubKeyHash(changePkScript):
+		return txsizes.EstimateVirtualSize(1, 0, 0, 0, outputs, 0), nil
+	case txscript.IsPayToTaproot(changePkScript):
+		return txsizes.EstimateVirtualSize(0, 1, 0, 0, outputs, 0), nil
+	case txscript.IsPayToWitnessPubKeyHash(changePkScript):
+		return txsizes.EstimateVirtualSize(0, 0, 1, 0, outputs, 0), nil
+	case txscript.IsPayToScriptHash(changePkScript):
+		// wallet change sent to p2sh is nested p2wpkh
+		return txsizes.EstimateVirtualSize(0, 0, 0, 1, outputs, 0), nil
+	default:
+		return 0, fmt.Errorf("unsupported change output script type: %s",
+			txscript.GetScriptClass(changePkScript))
+	}
+}
+
+// findChangeOutput returns output of staking transaction paying change to
+// changeScript, or nil if staking transaction has no change
+func findChangeOutput(stakingTx *wire.MsgTx, stakingOutputIdx uint32, changeScript []byte) *stakerdb.ChangeOutput {
+	for i, out := range stakingTx.TxOut {
+		if uint32(i) != stakingOutputIdx && bytes.Equal(out.PkScript, changeScript) {
+			return &stakerdb.ChangeOutput{
+				OutputIdx: uint32(i),
+				PkScript:  changeScript,
+			}
+		}
+	}
+
+	return nil
+}
+
+// stakingTxChangeOutput returns change output of staking transaction recorded
+// when staking transaction was created
+func stakingTxChangeOutput(tx *stakerdb.StoredTransaction) (uint32, *wire.TxOut, error) {
+	change := tx.StakingTxChange
+
+	if change == nil {
+		return 0, nil, ErrNoChangeOutput
+	}
+
+	if change.OutputIdx >= uint32(len(tx.StakingTx.TxOut)) ||
+		!bytes.Equal(tx.StakingTx.TxOut[change.OutputIdx].PkScript, change.PkScript) {
+		return 0, nil, fmt.Errorf("recorded change output %d does not match staking transaction %s",
+			change.OutputIdx, tx.StakingTx.TxHash())
+	}
+
+	return change.OutputIdx, tx.StakingTx.TxOut[change.OutputIdx], nil
+}
+
+// BumpStakingTxFee bumps fee of unconfirmed staking transaction sent by staker
+// using child-pays-for-parent transaction, which spends change output of staking
+// transaction back to the wallet. Fee of the child transaction is chosen so that
+// parent and child together pay newFeeRate in sat/kvB. Hash of the child
+// transaction is recorded against the delegation and returned.
+func (app *StakerApp) BumpStakingTxFee(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	newFeeRate btcutil.Amount,
+) (*chainhash.Hash, error) {
+	req, err := app.buildCpfpTx(ctx, stakingTxHash, newFeeRate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var childTxHash *chainhash.Hash
+
+	err = app.runCommand(ctx, "BumpStakingTxFee", func(ctx context.Context) error {
+		var err error
+		childTxHash, err = app.sendCpfpTx(ctx, req)
+		return err
+	})
+
+	return childTxHash, err
+}
+
+// cpfpRequest is signed child transaction bumping fee of staking transaction,
+// built from state of delegation observed before it is sent
+type cpfpRequest struct {
+	observed   *stakerdb.StoredTransaction
+	childTx    *wire.MsgTx
+	childFee   btcutil.Amount
+	newFeeRate btcutil.Amount
+}
+
+// buildCpfpTx builds and signs child transaction bumping fee of staking
+// transaction. It queries the wallet, so it is run outside of event loop, and
+// built transaction is sent by sendCpfpTx.
+func (app *StakerApp) buildCpfpTx(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	newFeeRate btcutil.Amount,
+) (*cpfpRequest, error) {
+	// check we are not shutting down
+	select {
+	case <-app.quit:
+		return nil, ErrStakerAppStopped
+
+	default:
+	}
+
+	if err := app.checkNotWatchOnly("bump fee"); err != nil {
+		return nil, err
+	}
+
+	tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.Watched || tx.StakingTxFeeInfo == nil {
+		return nil, fmt.Errorf("cannot bump fee of staking transaction %s which was not sent by staker", stakingTxHash)
+	}
+
+	stakingOutput := tx.StakingTx.TxOut[tx.StakingOutputIndex]
+
+	_, status, err := app.wc.TxDetails(stakingTxHash, stakingOutput.PkScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case walletcontroller.TxInChain:
+		return nil, fmt.Errorf("cannot bump fee of staking transaction %s: %w", stakingTxHash, ErrStakingTxAlreadyConfirmed)
+	case walletcontroller.TxNotFound:
+		return nil, fmt.Errorf("cannot bump fee of staking transaction %s which is not in mempool", stakingTxHash)
+	}
+
+	parentFeeInfo := tx.StakingTxFeeInfo
+
+	if newFeeRate <= btcutil.Amount(parentFeeInfo.FeeRate) {
+		return nil, fmt.Errorf("new fee rate %d sat/kvB must be higher than current fee rate %d sat/kvB of staking transaction",
+			newFeeRate, parentFeeInfo.FeeRate)
+	}
+
+	// child pays for the whole package, so package fee rate is what is limited
+	if err := app.feeLimits().CheckFeeRate(newFeeRate); err != nil {
+		return nil, fmt.Errorf("cannot bump fee of staking transaction %s: %w", stakingTxHash, err)
+	}
+
+	changeIdx, change, err := stakingTxChangeOutput(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakerAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// change output belongs to the wallet which funded staking transaction
+	w, err := app.walletForTx(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	destination := app.changeAddress(w.wc, stakerAddress)
+
+	if err := app.checkDestinationAllowed(destination); err != nil {
+		return nil, fmt.Errorf("cannot send child transaction bumping fee: %w", err)
+	}
+
+	if err := app.trackAddress(w.wc, destination); err != nil {
+		return nil, fmt.Errorf("cannot send child transaction bumping fee. Error importing destination address: %w", err)
+	}
+
+	destinationScript, err := txscript.PayToAddrScript(destination)
+
+	if err != nil {
+		return nil, err
+	}
+
+	childOutput := wire.NewTxOut(change.Value, destinationScript)
+
+	childVSize, err := estimateCpfpTxVSize(change.PkScript, []*wire.TxOut{childOutput})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// child pays for the difference between fee of the whole package at new fee
+	// rate and fee already paid by parent
+	packageFee := txrules.FeeForSerializeSize(newFeeRate, int(parentFeeInfo.VSize)+childVSize)
+	childFee := packageFee - parentFeeInfo.Fee
+
+	// child of previous bump spends the same change output, so it is replaced.
+	// Replacement must pay more than replaced child, plus relay fee of its own
+	// size.
+	if tx.CpfpTxHash != nil {
+		minReplacementFee := tx.CpfpTxFee + txrules.FeeForSerializeSize(MinFeePerKb, childVSize)
+
+		if childFee < minReplacementFee {
+			childFee = minReplacementFee
+		}
+	}
+
+	childOutput.Value -= int64(childFee)
+
+	if childOutput.Value <= 0 || txrules.IsDustOutput(childOutput, MinFeePerKb) {
+		return nil, fmt.Errorf("change output of staking transaction with value %d cannot pay fee %d of child transaction",
+			change.Value, childFee)
+	}
+
+	childTx := wire.NewMsgTx(2)
+	changeInput := wire.NewTxIn(wire.NewOutPoint(stakingTxHash, changeIdx), nil, nil)
+	// signal replaceability, so that fee can be bumped again
+	changeInput.Sequence = walletcontroller.RbfSequence
+	childTx.AddTxIn(changeInput)
+	childTx.AddTxOut(childOutput)
+
+	lockWallet, err := w.unlocker.acquire(nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer lockWallet()
+
+	signedTx, fullySigned, err := w.wc.SignRawTransaction(ctx, childTx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !fullySigned {
+		return nil, fmt.Errorf("failed to sign child transaction. Change output of staking transaction %s is not controlled by wallet", stakingTxHash)
+	}
+
+	return &cpfpRequest{
+		observed:   tx,
+		childTx:    signedTx,
+		childFee:   childFee,
+		newFeeRate: newFeeRate,
+	}, nil
+}
+
+// sendCpfpTx sends child transaction built by buildCpfpTx to btc, unless
+// delegation changed since the transaction was built. Must be run as command.
+func (app *StakerApp) sendCpfpTx(ctx context.Context, req *cpfpRequest) (*chainhash.Hash, error) {
+	stakingTxHash := req.observed.StakingTx.TxHash()
+
+	if err := app.checkDelegationUnchanged(req.observed); err != nil {
+		return nil, fmt.Errorf("cannot bump fee of staking transaction: %w", err)
+	}
+
+	childTxHash, err := app.wc.SendRawTransaction(ctx, req.childTx, true)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to send child transaction bumping fee: %w", err)
+	}
+
+	app.m.FeesPaid.Add(float64(req.childFee))
+
+	if err := app.txTracker.SetCpfpTx(&stakingTxHash, childTxHash, req.childFee); err != nil {
+		// child transaction is already in mempool, so bump succeeded
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"cpfpTxHash":    childTxHash,
+			"err":           err,
+		}).Error("Failed to persist hash of child transaction bumping fee")
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"stakingTxHash": stakingTxHash,
+		"cpfpTxHash":    childTxHash,
+		"newFeeRate":    req.newFeeRate,
+		"childFee":      req.childFee,
+	}).Info("Bumped fee of staking transaction using child-pays-for-parent transaction")
+
+	return childTxHash, nil
+}
+
+// BumpSpendTxFee bumps fee of unconfirmed transaction spending stake sent by
+// staker. Spend transaction signals replaceability, as its input has relative
+// time lock, so it is replaced by new spend transaction paying newFeeRate in
+// sat/kvB. Hash of the replacement transaction is returned.
+func (app *StakerApp) BumpSpendTxFee(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	newFeeRate btcutil.Amount,
+) (*chainhash.Hash, error) {
+	// check we are not shutting down
+	select {
+	case <-app.quit:
+		return nil, ErrStakerAppStopped
+
+	default:
+	}
+
+	if err := app.checkNotWatchOnly("bump spend fee"); err != nil {
+		return nil, err
+	}
+
+	tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.SpendTx == nil || tx.SpendTxFeeInfo == nil {
+		return nil, fmt.Errorf("cannot bump fee of spend transaction. Stake of staking transaction %s was not spent by staker", stakingTxHash)
+	}
+
+	if tx.State == proto.TransactionState_SPENT_ON_BTC {
+		return nil, fmt.Errorf("cannot bump fee of spend transaction of stake %s: %w", stakingTxHash, ErrSpendTxAlreadyConfirmed)
+	}
+
+	spendTxHash := tx.SpendTx.TxHash()
+
+	_, status, err := app.wc.TxDetails(&spendTxHash, tx.SpendTx.TxOut[0].PkScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case walletcontroller.TxInChain:
+		return nil, fmt.Errorf("cannot bump fee of spend transaction %s: %w", spendTxHash, ErrSpendTxAlreadyConfirmed)
+	case walletcontroller.TxNotFound:
+		return nil, fmt.Errorf("cannot bump fee of spend transaction %s which is not in mempool", spendTxHash)
+	}
+
+	if newFeeRate <= btcutil.Amount(tx.SpendTxFeeInfo.FeeRate) {
+		return nil, fmt.Errorf("new fee rate %d sat/kvB must be higher than current fee rate %d sat/kvB of spend transaction",
+			newFeeRate, tx.SpendTxFeeInfo.FeeRate)
+	}
+
+	// replacement must send funds to the same destination as replaced transaction,
+	// which could be external address
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(tx.SpendTx.TxOut[0].PkScript, app.network)
+
+	if err != nil || len(addresses) != 1 {
+		return nil, fmt.Errorf("cannot bump fee of spend transaction %s. Cannot decode its destination address", spendTxHash)
+	}
+
+	destination := addresses[0]
+
+	if destination.EncodeAddress() == tx.StakerAddress {
+		destination = nil
+	}
+
+	ctx, span := app.startSpan(
+		ctx,
+		"BumpSpendTxFee",
+		attribute.String(attrTxHash, stakingTxHash.String()),
+	)
+
+	// replacement spends the same output as replaced transaction, so btc node
+	// evicts replaced transaction from mempool. It is sent only if replaced
+	// transaction is still the latest one spending the stake.
+	var replacementTxHash *chainhash.Hash
+
+	req, err := app.buildSpendStakeTx(ctx, stakingTxHash, destination, &newFeeRate, nil)
+
+	if err == nil && !sameTxHash(req.observed.SpendTx, tx.SpendTx) {
+		err = fmt.Errorf("cannot bump fee of spend transaction %s: %w", spendTxHash, ErrDelegationChanged)
+	}
+
+	if err == nil {
+		err = app.runCommand(ctx, "BumpSpendTxFee", func(ctx context.Context) error {
+			var err error
+			replacementTxHash, _, err = app.sendSpendStakeTx(ctx, req)
+			return err
+		})
+	}
+
+	if replacementTxHash != nil {
+		span.SetAttributes(attribute.String(attrSpendTxHash, replacementTxHash.String()))
+	}
+
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"stakingTxHash":     stakingTxHash,
+		"replacedTxHash":    spendTxHash,
+		"replacementTxHash": replacementTxHash,
+		"newFeeRate":        newFeeRate,
+	}).Info("Bumped fee of spend transaction by replacing it")
+
+	return replacementTxHash, nil
+}
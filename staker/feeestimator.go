@@ -2,10 +2,14 @@ package staker
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/babylonchain/btc-staker/types"
 
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
@@ -17,21 +21,95 @@ const (
 	// 1 means we want our transactions to be confirmed in the next block.
 	// TODO: make this configurable ?
 	DefaultNumBlockForEstimation = 1
+
+	// maxEstimateBlocksDrift is the maximum number of blocks by which target at
+	// which bitcoind found its estimate can exceed requested target. Estimates
+	// found further away are considered stale.
+	maxEstimateBlocksDrift = 2
 )
 
+// SmartFeeClient is the part of bitcoind rpc client used to estimate fees
+type SmartFeeClient interface {
+	EstimateSmartFee(confTarget int64, mode *btcjson.EstimateSmartFeeMode) (*btcjson.EstimateSmartFeeResult, error)
+	Shutdown()
+}
+
 type FeeEstimator interface {
 	Start() error
 	Stop() error
 	EstimateFeePerKb() chainfee.SatPerKVByte
+	// EstimateFeePerKbForTarget returns fee rate for confirmation within
+	// confTarget blocks
+	EstimateFeePerKbForTarget(confTarget uint32) chainfee.SatPerKVByte
+	// SetFeeRateBounds changes fee rate bounds configured by min and max fee
+	// rate while estimator is running
+	SetFeeRateBounds(minFeeRate, maxFeeRate chainfee.SatPerKVByte)
 }
 
 type DynamicBtcFeeEstimator struct {
-	estimator  chainfee.Estimator
-	logger     *logrus.Logger
+	// estimator is used with btcd backend, which does not support estimatesmartfee
+	estimator chainfee.Estimator
+	// smartFeeClient is used with bitcoind backend
+	smartFeeClient SmartFeeClient
+	estimateMode   btcjson.EstimateSmartFeeMode
+	maxConfTarget  uint32
+	// fallbackSources are tried in order when connected btc node cannot
+	// estimate fee rate
+	fallbackSources []FeeRateSource
+	// fallbackFeeRate is used when neither connected btc node nor fallback
+	// sources can estimate fee rate. If zero, max fee rate is used.
+	fallbackFeeRate chainfee.SatPerKVByte
+	logger          *logrus.Logger
+	// mu guards fee rate bounds, which can be changed by config reload
+	mu         sync.RWMutex
 	MinFeeRate chainfee.SatPerKVByte
 	MaxFeeRate chainfee.SatPerKVByte
 }
 
+// NewBitcoindFeeEstimator creates fee estimator which uses estimatesmartfee of
+// bitcoind node. If estimate at the requested target is stale or unreliable, the
+// target is widened up to maxConfTarget.
+func NewBitcoindFeeEstimator(
+	client SmartFeeClient,
+	estimateMode string,
+	maxConfTarget uint32,
+	minFeeRate chainfee.SatPerKVByte,
+	maxFeeRate chainfee.SatPerKVByte,
+	logger *logrus.Logger,
+) *DynamicBtcFeeEstimator {
+	return &DynamicBtcFeeEstimator{
+		smartFeeClient: client,
+		estimateMode:   btcjson.EstimateSmartFeeMode(estimateMode),
+		maxConfTarget:  maxConfTarget,
+		logger:         logger,
+		MinFeeRate:     minFeeRate,
+		MaxFeeRate:     maxFeeRate,
+	}
+}
+
+// WithFallback sets fee rate sources tried in order when connected btc node
+// cannot estimate fee rate, and static fee rate used when none of them can.
+func (e *DynamicBtcFeeEstimator) WithFallback(
+	fallbackFeeRate chainfee.SatPerKVByte,
+	sources ...FeeRateSource,
+) *DynamicBtcFeeEstimator {
+	e.fallbackFeeRate = fallbackFeeRate
+	e.fallbackSources = sources
+	return e
+}
+
+// fallbackFromConfig returns fee api source if it is configured and configured
+// fallback fee rate
+func fallbackFromConfig(cfg *scfg.BtcNodeBackendConfig) (chainfee.SatPerKVByte, []FeeRateSource) {
+	var sources []FeeRateSource
+
+	if cfg.FeeApiUrl != "" {
+		sources = append(sources, NewHTTPFeeRateSource(cfg.FeeApiUrl, cfg.FeeApiTimeout))
+	}
+
+	return chainfee.SatPerKVByte(cfg.FallbackFeeRate * 1000), sources
+}
+
 func NewDynamicBtcFeeEstimator(
 	cfg *scfg.BtcNodeBackendConfig,
 	_ *chaincfg.Params,
@@ -39,6 +117,7 @@ func NewDynamicBtcFeeEstimator(
 
 	minFeeRate := chainfee.SatPerKVByte(cfg.MinFeeRate * 1000)
 	maxFeeRate := chainfee.SatPerKVByte(cfg.MaxFeeRate * 1000)
+	fallbackFeeRate, fallbackSources := fallbackFromConfig(cfg)
 
 	switch cfg.ActiveNodeBackend {
 	case types.BitcoindNodeBackend:
@@ -52,21 +131,22 @@ func NewDynamicBtcFeeEstimator(
 			HTTPPostMode:         true,
 		}
 
-		// TODO: we should probably create our own estimator backend, as those from lnd
-		// have hardcoded loggers, so we do not log stuff to file as we want
-		est, err := chainfee.NewBitcoindEstimator(
-			rpcConfig, cfg.Bitcoind.EstimateMode, maxFeeRate.FeePerKWeight(),
-		)
+		// we query estimatesmartfee directly instead of using lnd bitcoind
+		// estimator, as the latter hides whether returned estimate is reliable
+		client, err := rpcclient.New(&rpcConfig, nil)
 
 		if err != nil {
 			return nil, err
 		}
-		return &DynamicBtcFeeEstimator{
-			estimator:  est,
-			logger:     logger,
-			MinFeeRate: minFeeRate,
-			MaxFeeRate: maxFeeRate,
-		}, nil
+
+		return NewBitcoindFeeEstimator(
+			client,
+			cfg.Bitcoind.EstimateMode,
+			cfg.MaxEstimationTarget,
+			minFeeRate,
+			maxFeeRate,
+			logger,
+		).WithFallback(fallbackFeeRate, fallbackSources...), nil
 
 	case types.BtcdNodeBackend:
 		cert, err := scfg.ReadCertFile(cfg.Btcd.RawRPCCert, cfg.Btcd.RPCCert)
@@ -94,12 +174,14 @@ func NewDynamicBtcFeeEstimator(
 			return nil, err
 		}
 
-		return &DynamicBtcFeeEstimator{
+		e := &DynamicBtcFeeEstimator{
 			estimator:  est,
 			logger:     logger,
 			MinFeeRate: minFeeRate,
 			MaxFeeRate: maxFeeRate,
-		}, nil
+		}
+
+		return e.WithFallback(fallbackFeeRate, fallbackSources...), nil
 
 	default:
 		return nil, fmt.Errorf("unknown node backend: %v", cfg.ActiveNodeBackend)
@@ -109,52 +191,200 @@ func NewDynamicBtcFeeEstimator(
 var _ FeeEstimator = (*DynamicBtcFeeEstimator)(nil)
 
 func (e *DynamicBtcFeeEstimator) Start() error {
+	if e.estimator == nil {
+		return nil
+	}
+
 	return e.estimator.Start()
 }
 
 func (e *DynamicBtcFeeEstimator) Stop() error {
+	if e.estimator == nil {
+		e.smartFeeClient.Shutdown()
+		return nil
+	}
+
 	return e.estimator.Stop()
 }
 
-func (e *DynamicBtcFeeEstimator) EstimateFeePerKb() chainfee.SatPerKVByte {
-	fee, err := e.estimator.EstimateFeePerKW(DefaultNumBlockForEstimation)
+// estimateSmartFee returns fee rate estimated by bitcoind at given target. It
+// returns error if estimate is unreliable i.e bitcoind reported errors, did not
+// return fee rate or found its estimate too far from the requested target.
+func (e *DynamicBtcFeeEstimator) estimateSmartFee(confTarget uint32) (chainfee.SatPerKVByte, error) {
+	res, err := e.smartFeeClient.EstimateSmartFee(int64(confTarget), &e.estimateMode)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(res.Errors) > 0 {
+		return 0, fmt.Errorf("unreliable estimate: %s", strings.Join(res.Errors, ", "))
+	}
+
+	if res.FeeRate == nil || *res.FeeRate <= 0 {
+		return 0, fmt.Errorf("unreliable estimate: no fee rate returned")
+	}
+
+	if res.Blocks > int64(confTarget)+maxEstimateBlocksDrift {
+		return 0, fmt.Errorf("stale estimate: estimate found at target %d", res.Blocks)
+	}
+
+	// estimatesmartfee returns fee rate in BTC/kvB
+	feeRate, err := btcutil.NewAmount(*res.FeeRate)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return chainfee.SatPerKVByte(feeRate), nil
+}
+
+// estimateSmartFeeWithWidening tries to estimate fee rate at requested target,
+// and if estimate is stale or unreliable, widens the target up to max target
+func (e *DynamicBtcFeeEstimator) estimateSmartFeeWithWidening(confTarget uint32) (chainfee.SatPerKVByte, error) {
+	for {
+		feeRate, err := e.estimateSmartFee(confTarget)
+
+		if err == nil {
+			return feeRate, nil
+		}
+
+		if confTarget >= e.maxConfTarget {
+			return 0, err
+		}
+
+		widenedTarget := confTarget * 2
+		if widenedTarget > e.maxConfTarget {
+			widenedTarget = e.maxConfTarget
+		}
+
+		e.logger.WithFields(logrus.Fields{
+			"err":           err,
+			"target":        confTarget,
+			"widenedTarget": widenedTarget,
+		}).Warn("Fee estimate at target is not usable. Widening estimation target")
+
+		confTarget = widenedTarget
+	}
+}
+
+func (e *DynamicBtcFeeEstimator) estimateNodeFee(confTarget uint32) (chainfee.SatPerKVByte, error) {
+	if e.smartFeeClient != nil {
+		return e.estimateSmartFeeWithWidening(confTarget)
+	}
+
+	fee, err := e.estimator.EstimateFeePerKW(confTarget)
 
 	if err != nil {
+		return 0, err
+	}
+
+	return fee.FeePerKVByte(), nil
+}
+
+// estimateFee tries connected btc node and then fallback sources in order. If
+// none of them can estimate fee rate, fallback fee rate is returned.
+func (e *DynamicBtcFeeEstimator) estimateFee(confTarget uint32, maxFeeRate chainfee.SatPerKVByte) chainfee.SatPerKVByte {
+	estimatedFee, err := e.estimateNodeFee(confTarget)
+
+	if err == nil {
+		e.logger.WithFields(logrus.Fields{
+			"confTarget": confTarget,
+			"fee":        estimatedFee,
+		}).Debug("Fee rate estimated by connected btc node")
+		return estimatedFee
+	}
+
+	e.logger.WithFields(logrus.Fields{
+		"confTarget": confTarget,
+		"err":        err,
+	}).Warn("Failed to estimate transaction fee using connected btc node")
+
+	for _, source := range e.fallbackSources {
+		estimatedFee, err := source.EstimateFeeRate(confTarget)
+
+		if err == nil {
+			e.logger.WithFields(logrus.Fields{
+				"source":     source.Name(),
+				"confTarget": confTarget,
+				"fee":        estimatedFee,
+			}).Debug("Fee rate estimated by fallback source")
+			return estimatedFee
+		}
+
 		e.logger.WithFields(logrus.Fields{
-			"err":     err,
-			"default": e.MaxFeeRate,
-		}).Error("Failed to estimate transaction fee using connected btc node. Using max fee from config")
-		return e.MaxFeeRate
+			"source":     source.Name(),
+			"confTarget": confTarget,
+			"err":        err,
+		}).Warn("Failed to estimate transaction fee using fallback source")
+	}
+
+	fallbackFeeRate := e.fallbackFeeRate
+	if fallbackFeeRate == 0 {
+		fallbackFeeRate = maxFeeRate
 	}
 
-	estimatedFee := fee.FeePerKVByte()
+	e.logger.WithFields(logrus.Fields{
+		"confTarget": confTarget,
+		"default":    fallbackFeeRate,
+	}).Error("None of fee sources can estimate transaction fee. Using fallback fee rate")
+
+	return fallbackFeeRate
+}
 
-	if estimatedFee < e.MinFeeRate {
+func (e *DynamicBtcFeeEstimator) EstimateFeePerKb() chainfee.SatPerKVByte {
+	return e.EstimateFeePerKbForTarget(DefaultNumBlockForEstimation)
+}
+
+// EstimateFeePerKbForTarget returns fee rate for confirmation within confTarget
+// blocks, bounded by configured min and max fee rate
+func (e *DynamicBtcFeeEstimator) EstimateFeePerKbForTarget(confTarget uint32) chainfee.SatPerKVByte {
+	minFeeRate, maxFeeRate := e.feeRateBounds()
+	estimatedFee := e.estimateFee(confTarget, maxFeeRate)
+
+	if estimatedFee < minFeeRate {
 		e.logger.WithFields(logrus.Fields{
-			"minFeeRate": e.MinFeeRate,
+			"minFeeRate": minFeeRate,
 			"estimated":  estimatedFee,
 		}).Debug("Estimated fee is lower than min fee rate. Using min fee rate")
-		return e.MinFeeRate
+		return minFeeRate
 	}
 
-	if estimatedFee > e.MaxFeeRate {
+	if estimatedFee > maxFeeRate {
 		e.logger.WithFields(logrus.Fields{
-			"maxFeeRate": e.MaxFeeRate,
+			"maxFeeRate": maxFeeRate,
 			"estimated":  estimatedFee,
 		}).Debug("Estimated fee is higher than max fee rate. Using max fee rate")
-		return e.MaxFeeRate
+		return maxFeeRate
 	}
 
 	e.logger.WithFields(logrus.Fields{
 		"fee":        estimatedFee,
-		"maxFeeRate": e.MaxFeeRate,
-		"minFeeRate": e.MinFeeRate,
-	}).Debug("Using fee rate estimated by connected btc node")
+		"maxFeeRate": maxFeeRate,
+		"minFeeRate": minFeeRate,
+	}).Debug("Using estimated fee rate")
 
 	return estimatedFee
 }
 
+func (e *DynamicBtcFeeEstimator) feeRateBounds() (chainfee.SatPerKVByte, chainfee.SatPerKVByte) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.MinFeeRate, e.MaxFeeRate
+}
+
+func (e *DynamicBtcFeeEstimator) SetFeeRateBounds(minFeeRate, maxFeeRate chainfee.SatPerKVByte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.MinFeeRate = minFeeRate
+	e.MaxFeeRate = maxFeeRate
+}
+
 type StaticFeeEstimator struct {
+	// mu guards default fee, which can be changed by config reload
+	mu         sync.RWMutex
 	DefaultFee chainfee.SatPerKVByte
 }
 
@@ -175,5 +405,21 @@ func (e *StaticFeeEstimator) Stop() error {
 }
 
 func (e *StaticFeeEstimator) EstimateFeePerKb() chainfee.SatPerKVByte {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
 	return e.DefaultFee
 }
+
+func (e *StaticFeeEstimator) EstimateFeePerKbForTarget(_ uint32) chainfee.SatPerKVByte {
+	return e.EstimateFeePerKb()
+}
+
+// SetFeeRateBounds sets default fee to max fee rate, the same way as it is
+// initialized from config
+func (e *StaticFeeEstimator) SetFeeRateBounds(_, maxFeeRate chainfee.SatPerKVByte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.DefaultFee = maxFeeRate
+}
@@ -0,0 +1,164 @@
+package staker_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSmartFeeClient returns fixed estimatesmartfee results for each target
+// and records requested targets
+type mockSmartFeeClient struct {
+	results          map[int64]*btcjson.EstimateSmartFeeResult
+	requestedTargets []int64
+}
+
+func (c *mockSmartFeeClient) EstimateSmartFee(confTarget int64, mode *btcjson.EstimateSmartFeeMode) (*btcjson.EstimateSmartFeeResult, error) {
+	c.requestedTargets = append(c.requestedTargets, confTarget)
+	return c.results[confTarget], nil
+}
+
+func (c *mockSmartFeeClient) Shutdown() {}
+
+func feeRateBtcPerKvB(rate float64) *float64 {
+	return &rate
+}
+
+func TestFeeEstimatorWidensTargetOfUnreliableEstimate(t *testing.T) {
+	client := &mockSmartFeeClient{
+		results: map[int64]*btcjson.EstimateSmartFeeResult{
+			1: {
+				Errors: []string{"Insufficient data or no feerate found"},
+				Blocks: 2,
+			},
+			// estimate found much further than requested target is stale
+			2: {
+				FeeRate: feeRateBtcPerKvB(0.00001),
+				Blocks:  12,
+			},
+			4: {
+				FeeRate: feeRateBtcPerKvB(0.00015),
+				Blocks:  4,
+			},
+		},
+	}
+
+	est := staker.NewBitcoindFeeEstimator(
+		client,
+		string(btcjson.EstimateModeConservative),
+		6,
+		chainfee.SatPerKVByte(1000),
+		chainfee.SatPerKVByte(100000),
+		logrus.New(),
+	)
+
+	require.Equal(t, chainfee.SatPerKVByte(15000), est.EstimateFeePerKb())
+	require.Equal(t, []int64{1, 2, 4}, client.requestedTargets)
+}
+
+func TestFeeEstimatorFallsBackToMaxFeeAfterMaxTarget(t *testing.T) {
+	unreliable := &btcjson.EstimateSmartFeeResult{
+		Errors: []string{"Insufficient data or no feerate found"},
+	}
+
+	client := &mockSmartFeeClient{
+		results: map[int64]*btcjson.EstimateSmartFeeResult{
+			1: unreliable,
+			2: unreliable,
+			4: unreliable,
+			6: unreliable,
+		},
+	}
+
+	maxFeeRate := chainfee.SatPerKVByte(100000)
+
+	est := staker.NewBitcoindFeeEstimator(
+		client,
+		string(btcjson.EstimateModeConservative),
+		6,
+		chainfee.SatPerKVByte(1000),
+		maxFeeRate,
+		logrus.New(),
+	)
+
+	require.Equal(t, maxFeeRate, est.EstimateFeePerKb())
+	require.Equal(t, []int64{1, 2, 4, 6}, client.requestedTargets)
+}
+
+type mockFeeRateSource struct {
+	feeRate chainfee.SatPerKVByte
+	err     error
+	targets []uint32
+}
+
+func (s *mockFeeRateSource) Name() string {
+	return "mock"
+}
+
+func (s *mockFeeRateSource) EstimateFeeRate(confTarget uint32) (chainfee.SatPerKVByte, error) {
+	s.targets = append(s.targets, confTarget)
+	return s.feeRate, s.err
+}
+
+func TestFeeEstimatorFallbackChain(t *testing.T) {
+	unreliable := &btcjson.EstimateSmartFeeResult{
+		Errors: []string{"Insufficient data or no feerate found"},
+	}
+
+	newEstimator := func() *staker.DynamicBtcFeeEstimator {
+		client := &mockSmartFeeClient{
+			results: map[int64]*btcjson.EstimateSmartFeeResult{
+				3: unreliable,
+				6: unreliable,
+			},
+		}
+
+		return staker.NewBitcoindFeeEstimator(
+			client,
+			string(btcjson.EstimateModeConservative),
+			6,
+			chainfee.SatPerKVByte(1000),
+			chainfee.SatPerKVByte(100000),
+			logrus.New(),
+		)
+	}
+
+	const fallbackFeeRate = chainfee.SatPerKVByte(2000)
+
+	// first source which can estimate fee rate is used
+	failing := &mockFeeRateSource{err: errors.New("no estimate")}
+	working := &mockFeeRateSource{feeRate: 15000}
+	unused := &mockFeeRateSource{feeRate: 30000}
+
+	est := newEstimator().WithFallback(fallbackFeeRate, failing, working, unused)
+	require.Equal(t, chainfee.SatPerKVByte(15000), est.EstimateFeePerKbForTarget(3))
+	require.Equal(t, []uint32{3}, failing.targets)
+	require.Equal(t, []uint32{3}, working.targets)
+	require.Empty(t, unused.targets)
+
+	// fee api is used if node cannot estimate fee rate
+	est = newEstimator().WithFallback(
+		fallbackFeeRate,
+		staker.NewHTTPFeeRateSource(makeFeeApi(t, http.StatusOK, `{"2": 7.5}`).URL, time.Second),
+	)
+	require.Equal(t, chainfee.SatPerKVByte(7500), est.EstimateFeePerKbForTarget(3))
+
+	// fallback fee rate is used if all sources fail
+	est = newEstimator().WithFallback(
+		fallbackFeeRate,
+		failing,
+		staker.NewHTTPFeeRateSource(makeFeeApi(t, http.StatusInternalServerError, "").URL, time.Second),
+	)
+	require.Equal(t, fallbackFeeRate, est.EstimateFeePerKbForTarget(3))
+
+	// fee rate from fallback source is bounded by max fee rate
+	est = newEstimator().WithFallback(fallbackFeeRate, &mockFeeRateSource{feeRate: 500000})
+	require.Equal(t, chainfee.SatPerKVByte(100000), est.EstimateFeePerKbForTarget(3))
+}
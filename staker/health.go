@@ -0,0 +1,105 @@
+package staker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// healthCheckTimeout bounds how long health check waits for single component,
+// so that hanging backend is reported as unhealthy instead of blocking the check
+const healthCheckTimeout = 10 * time.Second
+
+var errHealthCheckTimeout = errors.New("health check timed out")
+
+// ComponentHealth is result of checking connectivity to single backend
+type ComponentHealth struct {
+	Healthy bool
+	// empty if component is healthy
+	Error string
+}
+
+func newComponentHealth(err error) ComponentHealth {
+	if err != nil {
+		return ComponentHealth{Healthy: false, Error: err.Error()}
+	}
+
+	return ComponentHealth{Healthy: true}
+}
+
+// HealthStatus is result of checking connectivity to all backends of the staker
+type HealthStatus struct {
+	BtcNode ComponentHealth
+	Wallet  ComponentHealth
+	Babylon ComponentHealth
+	// best block height reported by btc node, zero if node is not healthy
+	BtcNodeHeight int64
+	// best btc block height processed by the staker
+	StakerBtcHeight uint32
+	// latest babylon block height, zero if babylon is not healthy
+	BabylonHeight uint64
+}
+
+// Healthy returns true if all backends are reachable
+func (h *HealthStatus) Healthy() bool {
+	return h.BtcNode.Healthy && h.Wallet.Healthy && h.Babylon.Healthy
+}
+
+type checkResult[T any] struct {
+	value T
+	err   error
+}
+
+// runCheck runs check in background, result is delivered on returned channel
+func runCheck[T any](check func() (T, error)) <-chan checkResult[T] {
+	res := make(chan checkResult[T], 1)
+
+	go func() {
+		value, err := check()
+		res <- checkResult[T]{value: value, err: err}
+	}()
+
+	return res
+}
+
+// awaitCheck waits for result of check started by runCheck until ctx is done
+func awaitCheck[T any](ctx context.Context, res <-chan checkResult[T]) (T, error) {
+	select {
+	case r := <-res:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, errHealthCheckTimeout
+	}
+}
+
+// Healthcheck checks connectivity to btc node, wallet and babylon node. Backends
+// are checked concurrently and all of them must respond within healthCheckTimeout.
+func (app *StakerApp) Healthcheck() *HealthStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	btcNodeRes := runCheck(app.wc.NodeBestHeight)
+	walletRes := runCheck(func() (btcutil.Amount, error) {
+		spendable, _, err := app.wc.GetBalance()
+		return spendable, err
+	})
+	babylonRes := runCheck(func() (uint64, error) {
+		return app.babylonClient.GetLatestBlockHeight(ctx)
+	})
+
+	btcNodeHeight, btcNodeErr := awaitCheck(ctx, btcNodeRes)
+	_, walletErr := awaitCheck(ctx, walletRes)
+	babylonHeight, babylonErr := awaitCheck(ctx, babylonRes)
+
+	return &HealthStatus{
+		BtcNode:         newComponentHealth(btcNodeErr),
+		Wallet:          newComponentHealth(walletErr),
+		Babylon:         newComponentHealth(babylonErr),
+		BtcNodeHeight:   btcNodeHeight,
+		StakerBtcHeight: app.currentBestBlockHeight.Load(),
+		BabylonHeight:   babylonHeight,
+	}
+}
@@ -0,0 +1,105 @@
+package staker
+
+import (
+	"sort"
+
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// disjointSet is minimal union-find over delegation indexes
+type disjointSet []int
+
+func newDisjointSet(n int) disjointSet {
+	s := make(disjointSet, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+func (s disjointSet) find(i int) int {
+	for s[i] != i {
+		s[i] = s[s[i]]
+		i = s[i]
+	}
+	return i
+}
+
+func (s disjointSet) union(i, j int) {
+	s[s.find(i)] = s.find(j)
+}
+
+// delegationCreatedTxs returns hashes of transactions sent by staker for given
+// delegation, outputs of those transactions which are not staking outputs end
+// up in staker wallet
+func delegationCreatedTxs(tx *stakerdb.StoredTransaction) []chainhash.Hash {
+	created := []chainhash.Hash{tx.StakingTx.TxHash()}
+
+	if tx.SpendTx != nil {
+		created = append(created, tx.SpendTx.TxHash())
+	}
+
+	return created
+}
+
+// linkedDelegations returns delegations linked to the delegation with given
+// staking transaction hash. Two delegations are linked if staking transaction
+// of one of them spends change output of staking transaction or output of spend
+// transaction of the other one, or if their staking transactions spend outputs
+// of the same transaction. Linkage is transitive, so delegations funded from one
+// change chain are all linked together. Returned delegations are sorted by their
+// index in the store. It returns false if delegation is not found.
+func linkedDelegations(
+	txs []stakerdb.StoredTransaction,
+	stakingTxHash *chainhash.Hash,
+) ([]*stakerdb.StoredTransaction, bool) {
+	target := -1
+	createdBy := make(map[chainhash.Hash]int)
+
+	for i := range txs {
+		if txs[i].StakingTx.TxHash() == *stakingTxHash {
+			target = i
+		}
+
+		for _, txHash := range delegationCreatedTxs(&txs[i]) {
+			createdBy[txHash] = i
+		}
+	}
+
+	if target < 0 {
+		return nil, false
+	}
+
+	set := newDisjointSet(len(txs))
+	fundedFrom := make(map[chainhash.Hash]int)
+
+	for i := range txs {
+		for _, in := range txs[i].StakingTx.TxIn {
+			prevTxHash := in.PreviousOutPoint.Hash
+
+			if creator, found := createdBy[prevTxHash]; found {
+				set.union(i, creator)
+			}
+
+			if other, found := fundedFrom[prevTxHash]; found {
+				set.union(i, other)
+			} else {
+				fundedFrom[prevTxHash] = i
+			}
+		}
+	}
+
+	var linked []*stakerdb.StoredTransaction
+	for i := range txs {
+		if i != target && set.find(i) == set.find(target) {
+			linked = append(linked, &txs[i])
+		}
+	}
+
+	sort.Slice(linked, func(i, j int) bool {
+		return linked[i].StoredTransactionIdx < linked[j].StoredTransactionIdx
+	})
+
+	return linked, true
+}
@@ -0,0 +1,132 @@
+package staker
+
+import (
+	"io"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields are structured fields attached to log message
+type Fields map[string]interface{}
+
+// Logger is logger used by the staker. It allows applications embedding the
+// staker to pass their own logger, so that staker logs are consistent with logs
+// of the application.
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Warn(msg string, fields Fields)
+	Error(msg string, fields Fields)
+}
+
+// Level is verbosity of log messages. Higher level is more verbose.
+type Level uint32
+
+const (
+	ErrorLevel Level = iota
+	WarnLevel
+	InfoLevel
+	DebugLevel
+)
+
+// LevelLogger is Logger which reports the most verbose level it logs. Messages
+// above it are not even formatted by staker. Logger which does not implement it
+// is assumed to log all levels.
+type LevelLogger interface {
+	Logger
+	Level() Level
+}
+
+// logrusLogger is Logger backed by logrus
+type logrusLogger struct {
+	logger *logrus.Logger
+}
+
+var _ Logger = (*logrusLogger)(nil)
+
+// NewLogrusLogger returns Logger which logs to provided logrus logger. This is
+// the logger used by stakerd.
+func NewLogrusLogger(logger *logrus.Logger) Logger {
+	return &logrusLogger{logger: logger}
+}
+
+func (l *logrusLogger) Debug(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Debug(msg)
+}
+
+func (l *logrusLogger) Info(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Info(msg)
+}
+
+func (l *logrusLogger) Warn(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Warn(msg)
+}
+
+func (l *logrusLogger) Error(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Error(msg)
+}
+
+// LogrusFromLogger returns logrus logger which forwards entries to logger.
+// Staker components log through logrus, so this is used to route their logs to
+// logger provided by embedding application. Entries above level of LevelLogger
+// are dropped, otherwise level filtering and formatting is left to the provided
+// logger. Trace entries are never forwarded, as Logger has no trace level.
+// Fatal and panic entries are forwarded as errors, but still terminate the
+// process as with plain logrus.
+func LogrusFromLogger(logger Logger) *logrus.Logger {
+	if l, ok := logger.(*logrusLogger); ok {
+		return l.logger
+	}
+
+	bridge := logrus.New()
+	bridge.Out = io.Discard
+	bridge.Level = logrus.DebugLevel
+	if l, ok := logger.(LevelLogger); ok {
+		bridge.Level = toLogrusLevel(l.Level())
+	}
+	bridge.AddHook(&forwardingHook{logger: logger})
+
+	return bridge
+}
+
+func toLogrusLevel(level Level) logrus.Level {
+	switch level {
+	case ErrorLevel:
+		return logrus.ErrorLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	case InfoLevel:
+		return logrus.InfoLevel
+	default:
+		return logrus.DebugLevel
+	}
+}
+
+// forwardingHook forwards logrus entries to Logger
+type forwardingHook struct {
+	logger Logger
+}
+
+func (h *forwardingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *forwardingHook) Fire(entry *logrus.Entry) error {
+	fields := make(Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		h.logger.Error(entry.Message, fields)
+	case logrus.WarnLevel:
+		h.logger.Warn(entry.Message, fields)
+	case logrus.InfoLevel:
+		h.logger.Info(entry.Message, fields)
+	default:
+		h.logger.Debug(entry.Message, fields)
+	}
+
+	return nil
+}
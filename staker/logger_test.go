@@ -0,0 +1,75 @@
+package staker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+type logRecord struct {
+	level  string
+	msg    string
+	fields staker.Fields
+}
+
+type recordingLogger struct {
+	records []logRecord
+}
+
+func (l *recordingLogger) record(level, msg string, fields staker.Fields) {
+	l.records = append(l.records, logRecord{level: level, msg: msg, fields: fields})
+}
+
+func (l *recordingLogger) Debug(msg string, fields staker.Fields) { l.record("debug", msg, fields) }
+func (l *recordingLogger) Info(msg string, fields staker.Fields)  { l.record("info", msg, fields) }
+func (l *recordingLogger) Warn(msg string, fields staker.Fields)  { l.record("warn", msg, fields) }
+func (l *recordingLogger) Error(msg string, fields staker.Fields) { l.record("error", msg, fields) }
+
+func TestLogrusFromLoggerForwardsEntries(t *testing.T) {
+	logger := &recordingLogger{}
+	bridge := staker.LogrusFromLogger(logger)
+
+	err := errors.New("connection refused")
+	bridge.Trace("tracing")
+	bridge.WithFields(logrus.Fields{"height": 100}).Debug("new block")
+	bridge.Infof("Connecting to node backend: %s", "bitcoind")
+	bridge.WithFields(logrus.Fields{"err": err}).Warn("retrying")
+	bridge.WithFields(logrus.Fields{"err": err, "txHash": "abc"}).Error("failed to send tx")
+
+	require.Equal(t, []logRecord{
+		{level: "debug", msg: "new block", fields: staker.Fields{"height": 100}},
+		{level: "info", msg: "Connecting to node backend: bitcoind", fields: staker.Fields{}},
+		{level: "warn", msg: "retrying", fields: staker.Fields{"err": err}},
+		{level: "error", msg: "failed to send tx", fields: staker.Fields{"err": err, "txHash": "abc"}},
+	}, logger.records)
+}
+
+type levelRecordingLogger struct {
+	recordingLogger
+	level staker.Level
+}
+
+func (l *levelRecordingLogger) Level() staker.Level { return l.level }
+
+func TestLogrusFromLoggerUsesLoggerLevel(t *testing.T) {
+	logger := &levelRecordingLogger{level: staker.WarnLevel}
+	bridge := staker.LogrusFromLogger(logger)
+
+	require.Equal(t, logrus.WarnLevel, bridge.GetLevel())
+
+	bridge.Debug("new block")
+	bridge.Info("connected")
+	bridge.Warn("retrying")
+
+	require.Equal(t, []logRecord{
+		{level: "warn", msg: "retrying", fields: staker.Fields{}},
+	}, logger.records)
+}
+
+func TestLogrusFromLoggerUnwrapsLogrusAdapter(t *testing.T) {
+	logrusLogger := logrus.New()
+	require.Same(t, logrusLogger, staker.LogrusFromLogger(staker.NewLogrusLogger(logrusLogger)))
+}
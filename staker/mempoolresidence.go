@@ -0,0 +1,200 @@
+package staker
+
+import (
+	"context"
+	"time"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// autoFeeBumpStep is fraction of fee rate of staking transaction by which
+	// automatic fee bump raises fee rate on each escalation
+	autoFeeBumpStep = 0.25
+)
+
+// mempoolResidence tracks time spent in mempool by unconfirmed staking transaction
+type mempoolResidence struct {
+	firstSeen time.Time
+	// number of fee bump escalations already raised for transaction
+	escalations uint64
+}
+
+// monitorMempoolResidence periodically checks how long unconfirmed staking
+// transactions stay in mempool
+func (app *StakerApp) monitorMempoolResidence() {
+	defer app.wg.Done()
+
+	ctx, cancel := app.appQuitContext()
+	defer cancel()
+
+	ticker := time.NewTicker(app.config.StakerConfig.MempoolCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := app.CheckMempoolResidence(ctx, time.Now()); err != nil {
+			app.logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Warn("Failed to check mempool residence of staking transactions")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-app.quit:
+			return
+		}
+	}
+}
+
+// escalation is staking transaction which exceeded maximum mempool residence
+type escalation struct {
+	tx    *stakerdb.StoredTransaction
+	count uint64
+}
+
+// CheckMempoolResidence checks time spent in mempool by staking transactions
+// sent by staker and escalates fee bump for those which exceeded configured
+// maximum mempool residence. Time in mempool is counted from the first check at
+// which transaction was seen in mempool. Fee bump is escalated once per each
+// full maximum residence period spent in mempool. If automatic fee bump is
+// enabled, fee of every escalated transaction is bumped with CPFP. It returns
+// hashes of staking transactions escalated by this check.
+func (app *StakerApp) CheckMempoolResidence(ctx context.Context, now time.Time) ([]*chainhash.Hash, error) {
+	maxResidence := app.config.StakerConfig.MaxMempoolResidence
+
+	if maxResidence <= 0 {
+		return nil, nil
+	}
+
+	escalations, err := app.escalateMempoolResidence(now, maxResidence)
+
+	if err != nil {
+		return nil, err
+	}
+
+	escalated := make([]*chainhash.Hash, len(escalations))
+	for i, e := range escalations {
+		stakingTxHash := e.tx.StakingTx.TxHash()
+		escalated[i] = &stakingTxHash
+
+		if app.config.StakerConfig.AutoFeeBump {
+			app.autoBumpStakingTxFee(ctx, e)
+		}
+	}
+
+	return escalated, nil
+}
+
+func (app *StakerApp) escalateMempoolResidence(now time.Time, maxResidence time.Duration) ([]escalation, error) {
+	txs, err := app.txQueries.GetAllStoredTransactions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	app.mempoolResidenceMu.Lock()
+	defer app.mempoolResidenceMu.Unlock()
+
+	inMempool := make(map[chainhash.Hash]struct{})
+	var escalations []escalation
+
+	for _, tx := range txs {
+		// fee of watched transactions is not under staker control
+		if tx.Watched || tx.State != proto.TransactionState_SENT_TO_BTC {
+			continue
+		}
+
+		stakingTxHash := tx.StakingTx.TxHash()
+
+		_, status, err := app.wc.TxDetails(
+			&stakingTxHash,
+			tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if status != walletcontroller.TxInMemPool {
+			continue
+		}
+
+		inMempool[stakingTxHash] = struct{}{}
+
+		residence, found := app.mempoolResidence[stakingTxHash]
+
+		if !found {
+			app.mempoolResidence[stakingTxHash] = &mempoolResidence{firstSeen: now}
+			continue
+		}
+
+		elapsed := now.Sub(residence.firstSeen)
+		count := uint64(elapsed / maxResidence)
+
+		if count <= residence.escalations {
+			continue
+		}
+
+		residence.escalations = count
+		app.m.FeeBumpEscalations.Inc()
+		escalations = append(escalations, escalation{tx: tx, count: count})
+
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash":       stakingTxHash,
+			"timeInMempool":       elapsed,
+			"maxMempoolResidence": maxResidence,
+			"escalation":          count,
+		}).Warn("Staking transaction exceeded maximum mempool residence. Escalating fee bump")
+	}
+
+	// forget transactions which left mempool, either confirmed or evicted
+	for txHash := range app.mempoolResidence {
+		if _, found := inMempool[txHash]; !found {
+			delete(app.mempoolResidence, txHash)
+		}
+	}
+
+	return escalations, nil
+}
+
+// autoBumpStakingTxFee bumps fee of escalated staking transaction. Each
+// escalation raises fee rate by autoFeeBumpStep of fee rate of staking
+// transaction, and fee rate is never lower than current estimate. Failure is
+// only logged, as transaction is escalated again after next residence period.
+func (app *StakerApp) autoBumpStakingTxFee(ctx context.Context, e escalation) {
+	stakingTxHash := e.tx.StakingTx.TxHash()
+
+	// only staking transactions funded by staker have fee info
+	if e.tx.StakingTxFeeInfo == nil {
+		return
+	}
+
+	parentFeeRate := btcutil.Amount(e.tx.StakingTxFeeInfo.FeeRate)
+	newFeeRate := parentFeeRate + btcutil.Amount(float64(parentFeeRate)*autoFeeBumpStep*float64(e.count))
+
+	if estimated := btcutil.Amount(app.feeEstimator.EstimateFeePerKb()); estimated > newFeeRate {
+		newFeeRate = estimated
+	}
+
+	childTxHash, err := app.BumpStakingTxFee(ctx, &stakingTxHash, newFeeRate)
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"newFeeRate":    newFeeRate,
+			"err":           err,
+		}).Error("Failed to automatically bump fee of staking transaction")
+		return
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"stakingTxHash": stakingTxHash,
+		"childTxHash":   childTxHash,
+		"newFeeRate":    newFeeRate,
+	}).Info("Automatically bumped fee of staking transaction")
+}
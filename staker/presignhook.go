@@ -0,0 +1,84 @@
+package staker
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PreSignHook is invoked with staking transaction after it is funded by the
+// wallet, but before it is signed. It may return modified transaction e.g with
+// additional outputs or different input sequences. Returned transaction is
+// validated, then signed by the wallet.
+type PreSignHook func(tx *wire.MsgTx) (*wire.MsgTx, error)
+
+func sameOutput(a, b *wire.TxOut) bool {
+	return a.Value == b.Value && bytes.Equal(a.PkScript, b.PkScript)
+}
+
+// ValidatePreSignHookTx checks that transaction returned by pre-sign hook is
+// still valid staking transaction built from the original one i.e it is sane,
+// spends all the inputs of the original transaction, and keeps the staking
+// output and all OP_RETURN outputs of the original transaction. It returns index
+// of the staking output in modified transaction.
+func ValidatePreSignHookTx(original, modified *wire.MsgTx, stakingOutput *wire.TxOut) (uint32, error) {
+	if modified == nil {
+		return 0, fmt.Errorf("%w: hook returned no transaction", ErrInvalidPreSignHookTx)
+	}
+
+	if err := blockchain.CheckTransactionSanity(btcutil.NewTx(modified)); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidPreSignHookTx, err)
+	}
+
+	inputs := make(map[wire.OutPoint]struct{}, len(modified.TxIn))
+	for _, in := range modified.TxIn {
+		inputs[in.PreviousOutPoint] = struct{}{}
+	}
+
+	for _, in := range original.TxIn {
+		if _, found := inputs[in.PreviousOutPoint]; !found {
+			return 0, fmt.Errorf("%w: input %s was removed", ErrInvalidPreSignHookTx, in.PreviousOutPoint)
+		}
+	}
+
+	for i, out := range original.TxOut {
+		if txscript.GetScriptClass(out.PkScript) != txscript.NullDataTy {
+			continue
+		}
+
+		found := false
+		for _, modifiedOut := range modified.TxOut {
+			if sameOutput(out, modifiedOut) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return 0, fmt.Errorf("%w: OP_RETURN output %d was removed or modified", ErrInvalidPreSignHookTx, i)
+		}
+	}
+
+	stakingOutputIdx := -1
+	for i, out := range modified.TxOut {
+		if !sameOutput(out, stakingOutput) {
+			continue
+		}
+
+		if stakingOutputIdx >= 0 {
+			return 0, fmt.Errorf("%w: staking output is duplicated", ErrInvalidPreSignHookTx)
+		}
+
+		stakingOutputIdx = i
+	}
+
+	if stakingOutputIdx < 0 {
+		return 0, fmt.Errorf("%w: staking output was removed or modified", ErrInvalidPreSignHookTx)
+	}
+
+	return uint32(stakingOutputIdx), nil
+}
@@ -0,0 +1,102 @@
+package staker_test
+
+import (
+	"testing"
+
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestFundedStakingTx(t *testing.T) (*wire.MsgTx, *wire.TxOut) {
+	stakingOutput := wire.NewTxOut(100000, append([]byte{txscript.OP_1, txscript.OP_DATA_32}, make([]byte, 32)...))
+
+	opReturnScript, err := txscript.NullDataScript([]byte("staking"))
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 1), nil, nil))
+	tx.AddTxOut(stakingOutput)
+	tx.AddTxOut(wire.NewTxOut(0, opReturnScript))
+	// change
+	tx.AddTxOut(wire.NewTxOut(50000, append([]byte{txscript.OP_0, txscript.OP_DATA_20}, make([]byte, 20)...)))
+
+	return tx, stakingOutput
+}
+
+func TestValidatePreSignHookTx(t *testing.T) {
+	harmlessOutput := wire.NewTxOut(1000, append([]byte{txscript.OP_0, txscript.OP_DATA_20}, make([]byte, 20)...))
+
+	tests := []struct {
+		name        string
+		hook        func(tx *wire.MsgTx) *wire.MsgTx
+		expectedIdx uint32
+		valid       bool
+	}{
+		{"unchanged", func(tx *wire.MsgTx) *wire.MsgTx {
+			return tx
+		}, 0, true},
+		{"appended output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.AddTxOut(harmlessOutput)
+			return tx
+		}, 0, true},
+		{"adjusted sequence", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum - 2
+			return tx
+		}, 0, true},
+		{"moved staking output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxOut[0], tx.TxOut[2] = tx.TxOut[2], tx.TxOut[0]
+			return tx
+		}, 2, true},
+		{"nil transaction", func(tx *wire.MsgTx) *wire.MsgTx {
+			return nil
+		}, 0, false},
+		{"removed staking output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxOut = tx.TxOut[1:]
+			return tx
+		}, 0, false},
+		{"modified staking output value", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxOut[0].Value--
+			return tx
+		}, 0, false},
+		{"duplicated staking output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.AddTxOut(wire.NewTxOut(tx.TxOut[0].Value, tx.TxOut[0].PkScript))
+			return tx
+		}, 0, false},
+		{"removed op_return output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxOut = append(tx.TxOut[:1], tx.TxOut[2:]...)
+			return tx
+		}, 0, false},
+		{"removed input", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.TxIn = tx.TxIn[:1]
+			return tx
+		}, 0, false},
+		{"duplicated input", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.AddTxIn(wire.NewTxIn(&tx.TxIn[0].PreviousOutPoint, nil, nil))
+			return tx
+		}, 0, false},
+		{"negative output", func(tx *wire.MsgTx) *wire.MsgTx {
+			tx.AddTxOut(wire.NewTxOut(-1, harmlessOutput.PkScript))
+			return tx
+		}, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			original, stakingOutput := makeTestFundedStakingTx(t)
+
+			idx, err := staker.ValidatePreSignHookTx(original, tc.hook(original.Copy()), stakingOutput)
+
+			if !tc.valid {
+				require.ErrorIs(t, err, staker.ErrInvalidPreSignHookTx)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedIdx, idx)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package staker
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	cl "github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+)
+
+// readModelSubscriptionBuffer is number of updates buffered for each read model
+// subscriber. Subscribers which fall behind by more updates are dropped.
+const readModelSubscriptionBuffer = 100
+
+// DelegationBabylonStatus is status of the delegation on babylon as of the last
+// refresh of the read model
+type DelegationBabylonStatus struct {
+	Active             bool
+	CovenantSignatures int
+	// true if babylon received unbonding transaction of the delegation
+	Unbonding bool
+	UpdatedAt time.Time
+}
+
+// DelegationView is flat, denormalized view of the current state of delegation,
+// combining data from the store with cached babylon status. Views do not
+// reference internal store types, so consumers are decoupled from store schema.
+type DelegationView struct {
+	StakingTxHash        string
+	StoredTransactionIdx uint64
+	StakerAddress        string
+	State                string
+	Watched              bool
+	StakingValue         btcutil.Amount
+	StakingTime          uint16
+	// hex encoded BIP340 public keys
+	FinalityProviderPks []string
+	// zero if staking transaction is not confirmed
+	StakingTxConfirmationHeight uint32
+	// empty if delegation does not have unbonding transaction
+	UnbondingTxHash string
+	// empty if stake was not spent by staker
+	SpendTxHash string
+	// nil if status was not yet queried from babylon
+	BabylonStatus *DelegationBabylonStatus
+	// increased each time the view of this delegation changes
+	Version uint64
+}
+
+// ReadModelSubscription delivers updated views of delegations. Updates channel
+// is closed when subscription is cancelled or when subscriber does not keep up
+// with updates, in which case subscriber should resubscribe and fetch whole
+// read model again.
+type ReadModelSubscription struct {
+	Updates <-chan DelegationView
+	cancel  func()
+}
+
+func (s *ReadModelSubscription) Cancel() {
+	s.cancel()
+}
+
+type readModel struct {
+	mu sync.Mutex
+	// views are loaded lazily from the store on first access
+	loaded        bool
+	views         map[chainhash.Hash]*DelegationView
+	babylonStatus map[chainhash.Hash]*DelegationBabylonStatus
+
+	subscribers      map[uint64]chan DelegationView
+	nextSubscriberID uint64
+}
+
+func newReadModel() *readModel {
+	return &readModel{
+		views:         make(map[chainhash.Hash]*DelegationView),
+		babylonStatus: make(map[chainhash.Hash]*DelegationBabylonStatus),
+		subscribers:   make(map[uint64]chan DelegationView),
+	}
+}
+
+func storedTxToDelegationView(
+	tx *stakerdb.StoredTransaction,
+	babylonStatus *DelegationBabylonStatus,
+) *DelegationView {
+	fpPks := make([]string, len(tx.FinalityProvidersBtcPks))
+	for i, pk := range tx.FinalityProvidersBtcPks {
+		fpPks[i] = hex.EncodeToString(schnorr.SerializePubKey(pk))
+	}
+
+	view := &DelegationView{
+		StakingTxHash:        tx.StakingTx.TxHash().String(),
+		StoredTransactionIdx: tx.StoredTransactionIdx,
+		StakerAddress:        tx.StakerAddress,
+		State:                tx.State.String(),
+		Watched:              tx.Watched,
+		StakingValue:         btcutil.Amount(tx.StakingTx.TxOut[tx.StakingOutputIndex].Value),
+		StakingTime:          tx.StakingTime,
+		FinalityProviderPks:  fpPks,
+	}
+
+	if tx.StakingTxConfirmationInfo != nil {
+		view.StakingTxConfirmationHeight = tx.StakingTxConfirmationInfo.Height
+	}
+
+	if tx.UnbondingTxData != nil && tx.UnbondingTxData.UnbondingTx != nil {
+		view.UnbondingTxHash = tx.UnbondingTxData.UnbondingTx.TxHash().String()
+	}
+
+	if tx.SpendTx != nil {
+		view.SpendTxHash = tx.SpendTx.TxHash().String()
+	}
+
+	if babylonStatus != nil {
+		status := *babylonStatus
+		view.BabylonStatus = &status
+	}
+
+	return view
+}
+
+// viewChanged returns true if views differ in anything but version and time of
+// babylon status refresh
+func viewChanged(prev, next *DelegationView) bool {
+	if prev == nil {
+		return true
+	}
+
+	withoutRefreshTime := func(v DelegationView) DelegationView {
+		v.Version = 0
+		if v.BabylonStatus != nil {
+			status := *v.BabylonStatus
+			status.UpdatedAt = time.Time{}
+			v.BabylonStatus = &status
+		}
+		return v
+	}
+
+	return !reflect.DeepEqual(withoutRefreshTime(*prev), withoutRefreshTime(*next))
+}
+
+// setView stores new view of the delegation and publishes it to subscribers if
+// it changed. Must be called with lock held.
+func (rm *readModel) setView(txHash chainhash.Hash, view *DelegationView) {
+	old := rm.views[txHash]
+	changed := viewChanged(old, view)
+
+	switch {
+	case old == nil:
+		view.Version = 1
+	case changed:
+		view.Version = old.Version + 1
+	default:
+		view.Version = old.Version
+	}
+
+	rm.views[txHash] = view
+
+	if !changed {
+		return
+	}
+
+	for id, sub := range rm.subscribers {
+		select {
+		case sub <- *view:
+		default:
+			// subscriber does not keep up, drop it so that it does not miss
+			// updates silently
+			close(sub)
+			delete(rm.subscribers, id)
+		}
+	}
+}
+
+// loadReadModel loads views of all stored delegations, if they were not loaded
+// yet. Must be called with lock held.
+func (app *StakerApp) loadReadModel() error {
+	rm := app.readModel
+
+	if rm.loaded {
+		return nil
+	}
+
+	txs, err := app.txQueries.GetAllStoredTransactions()
+
+	if err != nil {
+		return err
+	}
+
+	for i := range txs {
+		txHash := txs[i].StakingTx.TxHash()
+		// views which survived from previous load keep their versions
+		rm.setView(txHash, storedTxToDelegationView(&txs[i], rm.babylonStatus[txHash]))
+	}
+
+	rm.loaded = true
+
+	return nil
+}
+
+// onTransactionUpdated refreshes view of delegation updated in the store
+func (app *StakerApp) onTransactionUpdated(txHash *chainhash.Hash) {
+	rm := app.readModel
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if !rm.loaded {
+		// nothing to refresh, all views are read from store on first access
+		return
+	}
+
+	tx, err := app.txQueries.GetTransaction(txHash)
+
+	if errors.Is(err, stakerdb.ErrTransactionNotFound) {
+		// cancelled prepared delegation is removed from the store
+		delete(rm.views, *txHash)
+		delete(rm.babylonStatus, *txHash)
+		return
+	}
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": txHash,
+			"err":           err,
+		}).Error("Failed to refresh read model view of delegation")
+		// force reload on next access, so that read model does not diverge
+		// from the store
+		rm.loaded = false
+		return
+	}
+
+	rm.setView(*txHash, storedTxToDelegationView(tx, rm.babylonStatus[*txHash]))
+}
+
+// GetReadModel returns views of all tracked delegations sorted by their index in
+// the store
+func (app *StakerApp) GetReadModel() ([]DelegationView, error) {
+	rm := app.readModel
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if err := app.loadReadModel(); err != nil {
+		return nil, err
+	}
+
+	views := make([]DelegationView, 0, len(rm.views))
+	for _, view := range rm.views {
+		views = append(views, *view)
+	}
+
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].StoredTransactionIdx < views[j].StoredTransactionIdx
+	})
+
+	return views, nil
+}
+
+// SubscribeReadModel returns subscription delivering views of delegations each
+// time they change. To obtain consistent state, consumer should subscribe first
+// and then fetch the read model, applying updates with higher version than
+// fetched view.
+func (app *StakerApp) SubscribeReadModel() (*ReadModelSubscription, error) {
+	rm := app.readModel
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if err := app.loadReadModel(); err != nil {
+		return nil, err
+	}
+
+	id := rm.nextSubscriberID
+	rm.nextSubscriberID++
+
+	updates := make(chan DelegationView, readModelSubscriptionBuffer)
+	rm.subscribers[id] = updates
+
+	return &ReadModelSubscription{
+		Updates: updates,
+		cancel: func() {
+			rm.mu.Lock()
+			defer rm.mu.Unlock()
+
+			if sub, found := rm.subscribers[id]; found {
+				close(sub)
+				delete(rm.subscribers, id)
+			}
+		},
+	}, nil
+}
+
+// refreshReadModelBabylonStatus queries babylon for status of delegations which
+// were sent to babylon and updates cached status in the read model
+func (app *StakerApp) refreshReadModelBabylonStatus(ctx context.Context) error {
+	views, err := app.GetReadModel()
+
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		if view.State != proto.TransactionState_SENT_TO_BABYLON.String() &&
+			view.State != proto.TransactionState_DELEGATION_ACTIVE.String() {
+			continue
+		}
+
+		txHash, err := chainhash.NewHashFromStr(view.StakingTxHash)
+
+		if err != nil {
+			return err
+		}
+
+		di, err := app.babylonClient.QueryDelegationInfo(ctx, txHash)
+
+		if errors.Is(err, cl.ErrDelegationNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		status := &DelegationBabylonStatus{
+			Active:             di.Active,
+			CovenantSignatures: len(di.CovenantSigners),
+			Unbonding:          di.UndelegationInfo != nil,
+			UpdatedAt:          time.Now(),
+		}
+
+		app.readModel.mu.Lock()
+		app.readModel.babylonStatus[*txHash] = status
+		if current, found := app.readModel.views[*txHash]; found {
+			updated := *current
+			updated.BabylonStatus = status
+			app.readModel.setView(*txHash, &updated)
+		}
+		app.readModel.mu.Unlock()
+	}
+
+	return nil
+}
+
+// refreshReadModelPeriodically keeps babylon status cached in the read model up
+// to date
+func (app *StakerApp) refreshReadModelPeriodically() {
+	defer app.wg.Done()
+
+	ticker := time.NewTicker(app.config.StakerConfig.ReadModelRefreshInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := app.appQuitContext()
+	defer cancel()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := app.refreshReadModelBabylonStatus(ctx); err != nil {
+				app.logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Warn("Failed to refresh babylon status of delegations in read model")
+			}
+		case <-app.quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package staker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadConfig applies settings from newConfig which can be safely changed while
+// staker is running, without restarting connections or delegation state machine:
+// babylon stalling interval, min staking tx confirmations and min and max fee
+// rate. Changes of other settings which need restart, like network, database or
+// backend types, are logged as ignored.
+func (app *StakerApp) ReloadConfig(ctx context.Context, newConfig *scfg.Config) error {
+	newStakerCfg := newConfig.StakerConfig
+	newBackendCfg := newConfig.BtcNodeBackendConfig
+
+	if err := app.checkMinStakingTxConfirmations(ctx, newStakerCfg.MinStakingTxConfirmations); err != nil {
+		return fmt.Errorf("invalid reloaded config: %w", err)
+	}
+
+	// fee limits cannot be changed at runtime, so new fee rate bounds must fit
+	// into current ones
+	reloadedBackendCfg := *app.config.BtcNodeBackendConfig
+	reloadedBackendCfg.MinFeeRate = newBackendCfg.MinFeeRate
+	reloadedBackendCfg.MaxFeeRate = newBackendCfg.MaxFeeRate
+
+	if err := reloadedBackendCfg.ValidateFeeLimits(); err != nil {
+		return fmt.Errorf("invalid reloaded config: %w", err)
+	}
+
+	app.logIgnoredConfigChanges(newConfig)
+
+	app.configMu.Lock()
+	defer app.configMu.Unlock()
+
+	stakerCfg := app.config.StakerConfig
+	backendCfg := app.config.BtcNodeBackendConfig
+
+	feeRateBoundsChanged := backendCfg.MinFeeRate != newBackendCfg.MinFeeRate ||
+		backendCfg.MaxFeeRate != newBackendCfg.MaxFeeRate
+
+	stakerCfg.BabylonStallingInterval = newStakerCfg.BabylonStallingInterval
+	stakerCfg.MinStakingTxConfirmations = newStakerCfg.MinStakingTxConfirmations
+	backendCfg.MinFeeRate = newBackendCfg.MinFeeRate
+	backendCfg.MaxFeeRate = newBackendCfg.MaxFeeRate
+
+	if feeRateBoundsChanged && app.feeEstimator != nil {
+		app.feeEstimator.SetFeeRateBounds(
+			chainfee.SatPerKVByte(backendCfg.MinFeeRate*1000),
+			chainfee.SatPerKVByte(backendCfg.MaxFeeRate*1000),
+		)
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"babylonStallingInterval":   stakerCfg.BabylonStallingInterval,
+		"minStakingTxConfirmations": stakerCfg.MinStakingTxConfirmations,
+		"minFeeRate":                backendCfg.MinFeeRate,
+		"maxFeeRate":                backendCfg.MaxFeeRate,
+	}).Info("Config reloaded")
+
+	return nil
+}
+
+// logIgnoredConfigChanges warns about changed settings which take effect only
+// after restart
+func (app *StakerApp) logIgnoredConfigChanges(newConfig *scfg.Config) {
+	ignored := []struct {
+		option   string
+		old, new string
+	}{
+		{"chain.network", app.config.ChainConfig.Network, newConfig.ChainConfig.Network},
+		{"dbconfig.backend", app.config.DBConfig.Backend, newConfig.DBConfig.Backend},
+		{"dbconfig.dbpath", app.config.DBConfig.DBPath, newConfig.DBConfig.DBPath},
+		{"dbconfig.dbfilename", app.config.DBConfig.DBFileName, newConfig.DBConfig.DBFileName},
+		{"btcnodebackend.nodetype", app.config.BtcNodeBackendConfig.Nodetype, newConfig.BtcNodeBackendConfig.Nodetype},
+		{"btcnodebackend.wallettype", app.config.BtcNodeBackendConfig.WalletType, newConfig.BtcNodeBackendConfig.WalletType},
+		{"btcnodebackend.feemode", app.config.BtcNodeBackendConfig.FeeMode, newConfig.BtcNodeBackendConfig.FeeMode},
+		{
+			"btcnodebackend.minrelayfeerateperkb",
+			strconv.FormatUint(app.config.BtcNodeBackendConfig.MinRelayFeeRatePerKb, 10),
+			strconv.FormatUint(newConfig.BtcNodeBackendConfig.MinRelayFeeRatePerKb, 10),
+		},
+		{
+			"btcnodebackend.maxfeerateperkb",
+			strconv.FormatUint(app.config.BtcNodeBackendConfig.MaxFeeRatePerKb, 10),
+			strconv.FormatUint(newConfig.BtcNodeBackendConfig.MaxFeeRatePerKb, 10),
+		},
+	}
+
+	for _, o := range ignored {
+		if o.old == o.new {
+			continue
+		}
+
+		app.logger.WithFields(logrus.Fields{
+			"option":  o.option,
+			"current": o.old,
+			"new":     o.new,
+		}).Warn("Option cannot be changed at runtime, change ignored until restart")
+	}
+}
+
+func (app *StakerApp) babylonStallingInterval() time.Duration {
+	app.configMu.RLock()
+	defer app.configMu.RUnlock()
+
+	return app.config.StakerConfig.BabylonStallingInterval
+}
+
+func (app *StakerApp) minStakingTxConfirmations() uint32 {
+	app.configMu.RLock()
+	defer app.configMu.RUnlock()
+
+	return app.config.StakerConfig.MinStakingTxConfirmations
+}
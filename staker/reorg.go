@@ -0,0 +1,120 @@
+package staker
+
+import (
+	"errors"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/utils"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+)
+
+// errStakingTxReorged is returned by tasks working on confirmed staking
+// transaction, when its confirmation was invalidated by btc chain reorg
+var errStakingTxReorged = errors.New("staking transaction confirmation was reorged out of btc chain")
+
+// checkConfirmedTxsForReorg checks whether staking transactions confirmed on btc
+// are still included in the same block of the best btc chain. For every
+// transaction which was reorged out, stakingTxReorgedEvent is emitted.
+// Only delegations in CONFIRMED_ON_BTC state are checked, as delegations in later
+// states were already accepted by babylon.
+func (app *StakerApp) checkConfirmedTxsForReorg() {
+	confirmedTxs, err := app.txTracker.GetTransactionsByState(proto.TransactionState_CONFIRMED_ON_BTC)
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to retrieve confirmed transactions to check for reorg")
+		return
+	}
+
+	for _, tx := range confirmedTxs {
+		if tx.StakingTxConfirmationInfo == nil {
+			continue
+		}
+
+		stakingTxHash := tx.StakingTx.TxHash()
+		confirmationBlockHash := tx.StakingTxConfirmationInfo.BlockHash
+
+		details, status, err := app.wc.TxDetails(
+			&stakingTxHash,
+			tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript,
+		)
+
+		if err != nil {
+			app.logger.WithFields(logrus.Fields{
+				"stakingTxHash": stakingTxHash,
+				"err":           err,
+			}).Error("Failed to check whether confirmed staking transaction is still in btc chain")
+			continue
+		}
+
+		if status == walletcontroller.TxInChain && details.BlockHash.IsEqual(&confirmationBlockHash) {
+			continue
+		}
+
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash":         stakingTxHash,
+			"confirmationBlockHash": confirmationBlockHash,
+			"txStatus":              status,
+		}).Warn("Confirmed staking transaction was reorged out of btc chain")
+
+		utils.PushOrQuit[*stakingTxReorgedEvent](
+			app.stakingTxReorgedEvChan,
+			&stakingTxReorgedEvent{
+				stakingTxHash: stakingTxHash,
+				blockHash:     confirmationBlockHash,
+			},
+			app.quit,
+		)
+	}
+}
+
+// stakingTxStillConfirmed returns errStakingTxReorged if staking transaction is
+// no longer confirmed in the block it was confirmed in when delegation request
+// was created
+func (app *StakerApp) stakingTxStillConfirmed(req *sendDelegationRequest) error {
+	tx, err := app.txTracker.GetTransaction(&req.txHash)
+
+	if err != nil {
+		return err
+	}
+
+	if tx.State != proto.TransactionState_CONFIRMED_ON_BTC || tx.StakingTxConfirmationInfo == nil {
+		return errStakingTxReorged
+	}
+
+	if req.inclusionBlock != nil {
+		inclusionBlockHash := req.inclusionBlock.BlockHash()
+		if !tx.StakingTxConfirmationInfo.BlockHash.IsEqual(&inclusionBlockHash) {
+			return errStakingTxReorged
+		}
+	}
+
+	return nil
+}
+
+// resumeWaitingForConfirmation restarts waiting for confirmation of staking
+// transaction which was reorged out of btc chain. Transaction which is not known
+// to btc node anymore is rebroadcasted.
+func (app *StakerApp) resumeWaitingForConfirmation(stakingTxHash chainhash.Hash) {
+	defer app.wg.Done()
+
+	ctx, cancel := app.appQuitContext()
+	defer cancel()
+
+	params, err := app.babylonClient.Params(ctx)
+
+	if err == nil {
+		err = app.checkSentToBtcTxStatus(ctx, &stakingTxHash, params)
+	}
+
+	if err != nil {
+		app.reportCriticialError(
+			stakingTxHash,
+			err,
+			"Failed to resume waiting for confirmation of reorged staking transaction",
+		)
+	}
+}
@@ -1,5 +1,7 @@
 package staker
 
+import "time"
+
 type GenerateScriptResponse struct {
 	Script  string `json:"script"`
 	Address string `json:"address"`
@@ -13,3 +15,31 @@ type SendTransactionResponse struct {
 	TransactionHashHex string `json:"transactionHashHex"`
 	TransactionHex     string `json:"transactionHex"`
 }
+
+type UtxoSnapshotEntry struct {
+	TxHash        string `json:"tx_hash"`
+	OutputIdx     uint32 `json:"output_idx"`
+	Amount        int64  `json:"amount"`
+	PkScript      string `json:"pk_script"`
+	Address       string `json:"address"`
+	Confirmations int64  `json:"confirmations"`
+	Spendable     bool   `json:"spendable"`
+	Locked        bool   `json:"locked"`
+}
+
+type UtxoSnapshot struct {
+	Timestamp      time.Time           `json:"timestamp"`
+	ChainTipHeight uint32              `json:"chain_tip_height"`
+	Utxos          []UtxoSnapshotEntry `json:"utxos"`
+}
+
+// Destination is output of transaction which spent staking or unbonding output
+// i.e place where withdrawn stake went
+type Destination struct {
+	OutputIdx uint32 `json:"output_idx"`
+	// empty if output script does not correspond to single address
+	Address string `json:"address"`
+	Amount  int64  `json:"amount"`
+	// true if output was spent by confirmed transaction
+	Spent bool `json:"spent"`
+}
@@ -0,0 +1,61 @@
+package staker
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// timeLockPathSequence returns sequence of input spending staking or unbonding
+// output through time lock path. Time lock path script checks relative lock
+// time in blocks with OP_CHECKSEQUENCEVERIFY, so sequence must encode it as
+// defined by BIP68.
+func timeLockPathSequence(lockTime uint16) uint32 {
+	return blockchain.LockTimeToSequence(false, uint32(lockTime))
+}
+
+// ValidateSpendTx checks that signed spend stake transaction passes script
+// validation of funding output it spends. Scripts are executed with the same
+// flags btc node uses to accept transactions to mempool. Whether relative lock
+// time of funding output already expired depends on chain state, so it is
+// left to btc node.
+func ValidateSpendTx(spendTx *wire.MsgTx, fundingOutput *wire.TxOut) error {
+	if len(spendTx.TxIn) != 1 {
+		return fmt.Errorf("%w: expected 1 input, got %d", ErrInvalidSpendTx, len(spendTx.TxIn))
+	}
+
+	input := spendTx.TxIn[0]
+
+	if spendTx.Version < 2 {
+		return fmt.Errorf("%w: version %d does not enable relative lock time", ErrInvalidSpendTx, spendTx.Version)
+	}
+
+	if input.Sequence&wire.SequenceLockTimeDisabled != 0 {
+		return fmt.Errorf("%w: relative lock time disabled by input sequence %d", ErrInvalidSpendTx, input.Sequence)
+	}
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(fundingOutput.PkScript, fundingOutput.Value)
+
+	vm, err := txscript.NewEngine(
+		fundingOutput.PkScript,
+		spendTx,
+		0,
+		txscript.StandardVerifyFlags,
+		nil,
+		txscript.NewTxSigHashes(spendTx, prevOutFetcher),
+		fundingOutput.Value,
+		prevOutFetcher,
+	)
+
+	if err != nil {
+		return fmt.Errorf("%w: cannot create script engine: %v", ErrInvalidSpendTx, err)
+	}
+
+	if err := vm.Execute(); err != nil {
+		return fmt.Errorf("%w: script validation failed: %v", ErrInvalidSpendTx, err)
+	}
+
+	return nil
+}
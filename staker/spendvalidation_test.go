@@ -0,0 +1,167 @@
+package staker_test
+
+import (
+	"testing"
+
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpendLockTime = 100
+
+// timeLockOutput is taproot output with single leaf locked by staker key and
+// relative lock time, the same way as time lock path of staking output
+type timeLockOutput struct {
+	output       *wire.TxOut
+	leaf         txscript.TapLeaf
+	controlBlock []byte
+}
+
+func makeTimeLockOutput(t *testing.T, stakerKey *btcec.PublicKey, lockTime int64) *timeLockOutput {
+	script, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(stakerKey)).
+		AddOp(txscript.OP_CHECKSIGVERIFY).
+		AddInt64(lockTime).
+		AddOp(txscript.OP_CHECKSEQUENCEVERIFY).
+		Script()
+	require.NoError(t, err)
+
+	internalKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	leaf := txscript.NewBaseTapLeaf(script)
+	tree := txscript.AssembleTaprootScriptTree(leaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := txscript.ComputeTaprootOutputKey(internalKey.PubKey(), rootHash[:])
+
+	pkScript, err := txscript.PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+
+	controlBlock := tree.LeafMerkleProofs[0].ToControlBlock(internalKey.PubKey())
+	controlBlockBytes, err := controlBlock.ToBytes()
+	require.NoError(t, err)
+
+	return &timeLockOutput{
+		output:       wire.NewTxOut(100000, pkScript),
+		leaf:         leaf,
+		controlBlock: controlBlockBytes,
+	}
+}
+
+func makeSignedSpendTx(
+	t *testing.T,
+	out *timeLockOutput,
+	signer *btcec.PrivateKey,
+	version int32,
+	sequence uint32,
+) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(version)
+	input := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil)
+	input.Sequence = sequence
+	spendTx.AddTxIn(input)
+	spendTx.AddTxOut(wire.NewTxOut(90000, out.output.PkScript))
+
+	prevOutFetcher := txscript.NewCannedPrevOutputFetcher(out.output.PkScript, out.output.Value)
+	sig, err := txscript.RawTxInTapscriptSignature(
+		spendTx,
+		txscript.NewTxSigHashes(spendTx, prevOutFetcher),
+		0,
+		out.output.Value,
+		out.output.PkScript,
+		out.leaf,
+		txscript.SigHashDefault,
+		signer,
+	)
+	require.NoError(t, err)
+
+	spendTx.TxIn[0].Witness = wire.TxWitness{sig, out.leaf.Script, out.controlBlock}
+
+	return spendTx
+}
+
+func TestValidateSpendTx(t *testing.T) {
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	otherKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	out := makeTimeLockOutput(t, stakerKey.PubKey(), testSpendLockTime)
+
+	tests := []struct {
+		name      string
+		signer    *btcec.PrivateKey
+		version   int32
+		sequence  uint32
+		expectErr bool
+	}{
+		{
+			name:     "valid spend",
+			signer:   stakerKey,
+			version:  2,
+			sequence: testSpendLockTime,
+		},
+		{
+			name:     "sequence above lock time",
+			signer:   stakerKey,
+			version:  2,
+			sequence: testSpendLockTime + 1,
+		},
+		{
+			name:      "sequence below lock time",
+			signer:    stakerKey,
+			version:   2,
+			sequence:  testSpendLockTime - 1,
+			expectErr: true,
+		},
+		{
+			name:      "relative lock time disabled",
+			signer:    stakerKey,
+			version:   2,
+			sequence:  wire.SequenceLockTimeDisabled | testSpendLockTime,
+			expectErr: true,
+		},
+		{
+			name:      "version without relative lock time",
+			signer:    stakerKey,
+			version:   1,
+			sequence:  testSpendLockTime,
+			expectErr: true,
+		},
+		{
+			name:      "signed by other key",
+			signer:    otherKey,
+			version:   2,
+			sequence:  testSpendLockTime,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			spendTx := makeSignedSpendTx(t, out, tc.signer, tc.version, tc.sequence)
+
+			err := staker.ValidateSpendTx(spendTx, out.output)
+
+			if tc.expectErr {
+				require.ErrorIs(t, err, staker.ErrInvalidSpendTx)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// spend transaction spends only funding output
+	spendTx := makeSignedSpendTx(t, out, stakerKey, 2, testSpendLockTime)
+	spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil))
+	require.ErrorIs(t, staker.ValidateSpendTx(spendTx, out.output), staker.ErrInvalidSpendTx)
+
+	// spend of different output fails
+	otherOut := makeTimeLockOutput(t, stakerKey.PubKey(), testSpendLockTime)
+	spendTx = makeSignedSpendTx(t, out, stakerKey, 2, testSpendLockTime)
+	require.ErrorIs(t, staker.ValidateSpendTx(spendTx, otherOut.output), staker.ErrInvalidSpendTx)
+}
@@ -1,9 +1,14 @@
 package staker
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -13,6 +18,7 @@ import (
 	cl "github.com/babylonchain/btc-staker/babylonclient"
 	"github.com/babylonchain/btc-staker/metrics"
 	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/simulation"
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
 	"github.com/babylonchain/btc-staker/stakerdb"
 	"github.com/babylonchain/btc-staker/types"
@@ -24,8 +30,10 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
@@ -36,6 +44,10 @@ import (
 	"github.com/lightningnetwork/lnd/kvdb"
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/sync/errgroup"
 )
 
 type externalDelegationData struct {
@@ -52,6 +64,119 @@ type stakingDbInfo struct {
 	stakingTxState proto.TransactionState
 }
 
+var (
+	// ErrMaxActiveDelegationsReached is returned when creating new delegation
+	// would exceed the maximum number of active delegations allowed per staker
+	ErrMaxActiveDelegationsReached = errors.New("maximum number of active delegations per staker reached")
+
+	// ErrTxNotConfirmed is returned when requested data is available only for
+	// transactions included in the btc chain
+	ErrTxNotConfirmed = errors.New("transaction is not yet confirmed")
+
+	// ErrDestinationNotAllowed is returned when staker would send funds to the
+	// address which is not on configured allow-list of destinations
+	ErrDestinationNotAllowed = errors.New("destination address is not allowed")
+
+	// ErrBabylonLightClientNotReady is returned when babylon btc light client
+	// is too far behind btc chain for new delegations to be safely created
+	ErrBabylonLightClientNotReady = errors.New("babylon btc light client not ready")
+
+	// ErrDuplicateFpDelegation is returned when staker already has active
+	// delegation to finality provider and configured policy refuses new one
+	ErrDuplicateFpDelegation = errors.New("staker already has active delegation to finality provider")
+
+	// ErrStakingTxAlreadyConfirmed is returned when bumping fee of staking
+	// transaction which is already confirmed on btc
+	ErrStakingTxAlreadyConfirmed = errors.New("staking transaction already confirmed")
+
+	// ErrSpendTxAlreadyConfirmed is returned when bumping fee of transaction
+	// spending stake which is already confirmed on btc
+	ErrSpendTxAlreadyConfirmed = errors.New("spend stake transaction already confirmed")
+
+	// ErrNoChangeOutput is returned when bumping fee of staking transaction
+	// which does not have change output to spend
+	ErrNoChangeOutput = errors.New("staking transaction does not have change output")
+
+	// ErrFeeRateOutOfTolerance is returned when fee rate supplied by caller is
+	// too far from fee rate estimated by btc node and configured policy refuses it
+	ErrFeeRateOutOfTolerance = errors.New("fee rate out of tolerance band around estimated fee rate")
+
+	// ErrInvalidPreSignHookTx is returned when transaction returned by pre-sign
+	// hook is not valid staking transaction
+	ErrInvalidPreSignHookTx = errors.New("invalid transaction returned by pre-sign hook")
+
+	// ErrSpendTxNotSent is returned when querying spend transaction of staking
+	// transaction whose output was not spent by staker
+	ErrSpendTxNotSent = errors.New("spend transaction was not sent")
+
+	// ErrBabylonSubmissionPayloadNotFound is returned when querying submission
+	// payload of delegation which was not yet submitted to babylon
+	ErrBabylonSubmissionPayloadNotFound = errors.New("babylon submission payload not found")
+
+	// ErrDelegationNotActive is returned when unbonding delegation which is not
+	// active on babylon i.e it was not yet confirmed on babylon, or it is already
+	// unbonded
+	ErrDelegationNotActive = errors.New("delegation is not active on babylon")
+
+	// ErrUnbondingInProgress is returned when unbonding delegation whose
+	// unbonding tx is still being sent to btc
+	ErrUnbondingInProgress = errors.New("unbonding is already in progress")
+
+	// ErrInsufficientFunds is returned when spendable wallet balance is lower
+	// than staking amount
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrStakingAmountTooLow is returned when staking amount is below dust limit
+	// of staking output or below minimum staking value required by babylon
+	ErrStakingAmountTooLow = errors.New("staking amount too low")
+
+	// ErrInvalidStakingTime is returned when staking time is outside of range
+	// accepted by babylon
+	ErrInvalidStakingTime = errors.New("invalid staking time")
+
+	// ErrWatchOnlyMode is returned by operations which require staker private
+	// key when staker runs in watch-only mode
+	ErrWatchOnlyMode = errors.New("operation unavailable in watch-only mode")
+
+	// ErrStakingTxNotSigned is returned when signed staking transaction does
+	// not match prepared one or when prepared delegation is submitted before
+	// its staking transaction was signed
+	ErrStakingTxNotSigned = errors.New("staking transaction is not signed")
+
+	// ErrStakingTxRejectedByMempool is returned when btc node reports that it
+	// would not accept staking transaction to its mempool
+	ErrStakingTxRejectedByMempool = errors.New("staking transaction rejected by mempool")
+
+	// ErrStakerAppStopped is returned when operation is requested after staker
+	// app started shutting down
+	ErrStakerAppStopped = errors.New("staker app stopped")
+
+	// ErrInvalidSpendTx is returned when signed spend stake transaction fails
+	// local script validation and would be rejected by btc network
+	ErrInvalidSpendTx = errors.New("invalid spend stake transaction")
+
+	// ErrDelegationScriptMismatch is returned when staking output script rebuilt
+	// from stored delegation data differs from stored or on chain script
+	ErrDelegationScriptMismatch = errors.New("delegation staking script mismatch")
+
+	// ErrUnknownWallet is returned when operation selects wallet which is not
+	// registered in the staker
+	ErrUnknownWallet = errors.New("unknown wallet")
+
+	// ErrUnsupportedStakerAddress is returned when staking from address for
+	// which wallet cannot produce proof of possession
+	ErrUnsupportedStakerAddress = errors.New("unsupported staker address")
+
+	// ErrDelegationChanged is returned when delegation changed while operation
+	// on it was being prepared e.g it was spent or unbonded by other caller.
+	// Operation can be retried against the new state of delegation.
+	ErrDelegationChanged = errors.New("delegation changed while operation was prepared")
+
+	// ErrStakerAppStarted is returned when operation which must run before
+	// staker app starts tracking delegations is requested after Start
+	ErrStakerAppStarted = errors.New("staker app already started")
+)
+
 // TODO: stop-gap solution for long running retry operations. Ultimately we need to
 // bound number of total pending bonding/unboning operation.
 var (
@@ -96,8 +221,6 @@ const (
 	// probabilistic nature of bitcoin
 	timeoutWaitingForSpendConfirmation = 2 * time.Hour
 
-	defaultWalletUnlockTimeout = 15
-
 	// Actual virtual size of transaction which spends staking transaction through slashing
 	// path. In reality it highly depends on slashingAddress size:
 	// for p2pk - 222vb
@@ -127,38 +250,87 @@ const (
 
 type StakerApp struct {
 	startOnce sync.Once
+	started   atomic.Bool
 	stopOnce  sync.Once
 	wg        sync.WaitGroup
 	quit      chan struct{}
 
-	babylonClient    cl.BabylonClient
-	wc               walletcontroller.WalletController
+	babylonClient cl.BabylonClient
+	wc            walletcontroller.WalletController
+	// unlocks the wallet for operations which need wallet private keys
+	walletUnlocker *walletUnlocker
+	// additional wallets from which delegations can be funded, by name
+	walletsMu        sync.RWMutex
+	wallets          map[string]*stakerWallet
 	notifier         notifier.ChainNotifier
 	feeEstimator     FeeEstimator
 	network          *chaincfg.Params
 	config           *scfg.Config
 	logger           *logrus.Logger
 	txTracker        *stakerdb.TrackedTransactionStore
+	txQueries        *stakerdb.ReadOnlyTrackedTransactionStore
 	babylonMsgSender *cl.BabylonMsgSender
 	m                *metrics.StakerMetrics
+	tracer           trace.Tracer
+
+	// guards settings of config which can be changed by config reload
+	configMu sync.RWMutex
+
+	preSignHookMu sync.RWMutex
+	preSignHook   PreSignHook
+
+	// serializes funding of staking transactions with locking of their inputs,
+	// so that concurrent stakes do not select the same wallet outputs
+	fundingMu sync.Mutex
+
+	// true from the moment wallet balance drops below low balance threshold
+	// until it rises above threshold plus hysteresis
+	lowBalanceMu sync.Mutex
+	lowBalance   bool
+
+	// unconfirmed staking transactions seen in mempool by mempool residence
+	// monitor
+	mempoolResidenceMu sync.Mutex
+	mempoolResidence   map[chainhash.Hash]*mempoolResidence
+
+	// delegations whose unbonding tx is being sent to btc or waits for
+	// confirmation
+	unbondingTasksMu sync.Mutex
+	unbondingTasks   map[chainhash.Hash]struct{}
+
+	readModel        *readModel
+	stateUpdates     *stateUpdates
+	timelockExpiries *timelockExpiries
+	// nil if webhook is not configured
+	webhookSender *WebhookSender
 
-	stakingRequestedEvChan                        chan *stakingRequestedEvent
 	stakingTxBtcConfirmedEvChan                   chan *stakingTxBtcConfirmedEvent
+	stakingTxReorgedEvChan                        chan *stakingTxReorgedEvent
 	delegationSubmittedToBabylonEvChan            chan *delegationSubmittedToBabylonEvent
 	unbondingTxSignaturesConfirmedOnBabylonEvChan chan *unbondingTxSignaturesConfirmedOnBabylonEvent
 	unbondingTxConfirmedOnBtcEvChan               chan *unbondingTxConfirmedOnBtcEvent
 	spendStakeTxConfirmedOnBtcEvChan              chan *spendStakeTxConfirmedOnBtcEvent
 	criticalErrorEvChan                           chan *criticalErrorEvent
 	currentBestBlockHeight                        atomic.Uint32
+
+	// state changing operations requested through public api, executed one at
+	// a time by event loop
+	commandChan   chan *stakerCommand
+	eventLoopOnce sync.Once
 }
 
+// NewStakerAppFromConfig creates staker connected to backends described by config.
+// Logs of all staker components are written to appLogger.
 func NewStakerAppFromConfig(
 	config *scfg.Config,
-	logger *logrus.Logger,
+	appLogger Logger,
 	rpcClientLogger *zap.Logger,
 	db kvdb.Backend,
 	m *metrics.StakerMetrics,
+	tp trace.TracerProvider,
 ) (*StakerApp, error) {
+	logger := LogrusFromLogger(appLogger)
+
 	// TODO: If we want to support multiple wallet types, this is most probably the place to decide
 	// on concrete implementation
 	walletClient, err := walletcontroller.NewRpcWalletController(config)
@@ -178,21 +350,41 @@ func NewStakerAppFromConfig(
 		return nil, err
 	}
 
-	hintCache, err := channeldb.NewHeightHintCache(
-		channeldb.CacheConfig{
-			// TODO: Investigate this option. Lighting docs mention that this is necessary for some edge case
-			QueryDisable: false,
-		}, db,
+	var (
+		wc           walletcontroller.WalletController = walletClient
+		bc           cl.BabylonClient                  = babylonClient
+		nodeNotifier notifier.ChainNotifier
+		chain        *simulation.Chain
 	)
 
-	if err != nil {
-		return nil, fmt.Errorf("unable to create height hint cache: %v", err)
-	}
+	if config.StakerConfig.SimulateOnly {
+		logger.Warn("Running in simulate only mode. Transactions will not be broadcasted to btc network nor submitted to babylon")
+
+		// wallet is still used to fund and sign transactions, and babylon node to
+		// query params and finality providers, but all transactions land in simulated chain
+		chain = simulation.NewChain(&config.ActiveNetParams, config.StakerConfig.SimulatedBlockInterval)
+		wc = simulation.NewWalletController(walletClient, chain)
+		bc = simulation.NewBabylonClient(babylonClient, chain, &config.ActiveNetParams)
+		nodeNotifier = chain
+	} else {
+		hintCache, err := channeldb.NewHeightHintCache(
+			channeldb.CacheConfig{
+				// TODO: Investigate this option. Lighting docs mention that this is necessary for some edge case
+				QueryDisable: false,
+			}, db,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to create height hint cache: %v", err)
+		}
 
-	nodeNotifier, err := NewNodeBackend(config.BtcNodeBackendConfig, &config.ActiveNetParams, hintCache)
+		nodeBackend, err := NewNodeBackend(config.BtcNodeBackendConfig, &config.ActiveNetParams, hintCache)
 
-	if err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		nodeNotifier = nodeBackend
 	}
 
 	var feeEstimator FeeEstimator
@@ -208,24 +400,49 @@ func NewStakerAppFromConfig(
 		return nil, fmt.Errorf("unknown fee estimation mode: %d", config.BtcNodeBackendConfig.EstimationMode)
 	}
 
-	babylonMsgSender := cl.NewBabylonMsgSender(babylonClient, logger, config.StakerConfig.MaxConcurrentTransactions)
+	babylonMsgSender := cl.NewBabylonMsgSender(bc, logger, config.StakerConfig.MaxConcurrentTransactions)
 
-	return NewStakerAppFromDeps(
+	app, err := NewStakerAppFromDeps(
 		config,
-		logger,
-		babylonClient,
-		walletClient,
+		appLogger,
+		bc,
+		wc,
 		nodeNotifier,
 		feeEstimator,
 		tracker,
 		babylonMsgSender,
 		m,
+		tp,
 	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, walletName := range config.WalletConfig.AdditionalWallets {
+		namedWalletClient, err := walletcontroller.NewNamedRpcWalletController(config, walletName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create controller of wallet %s: %w", walletName, err)
+		}
+
+		var namedWallet walletcontroller.WalletController = namedWalletClient
+		if chain != nil {
+			namedWallet = simulation.NewWalletController(namedWalletClient, chain)
+		}
+
+		if err := app.RegisterWallet(walletName, namedWallet); err != nil {
+			return nil, err
+		}
+	}
+
+	return app, nil
 }
 
+// NewStakerAppFromDeps creates staker from already constructed dependencies.
+// Logs of the app are written to appLogger.
 func NewStakerAppFromDeps(
 	config *scfg.Config,
-	logger *logrus.Logger,
+	appLogger Logger,
 	cl cl.BabylonClient,
 	walletClient walletcontroller.WalletController,
 	nodeNotifier notifier.ChainNotifier,
@@ -233,23 +450,38 @@ func NewStakerAppFromDeps(
 	tracker *stakerdb.TrackedTransactionStore,
 	babylonMsgSender *cl.BabylonMsgSender,
 	metrics *metrics.StakerMetrics,
+	tp trace.TracerProvider,
 ) (*StakerApp, error) {
-	return &StakerApp{
-		babylonClient:          cl,
-		wc:                     walletClient,
-		notifier:               nodeNotifier,
-		feeEstimator:           feeEestimator,
-		network:                &config.ActiveNetParams,
-		txTracker:              tracker,
-		babylonMsgSender:       babylonMsgSender,
-		m:                      metrics,
-		config:                 config,
-		logger:                 logger,
-		quit:                   make(chan struct{}),
-		stakingRequestedEvChan: make(chan *stakingRequestedEvent),
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	logger := LogrusFromLogger(appLogger)
+
+	unlocker := newWalletUnlocker(walletClient, config.WalletConfig, logger)
+
+	app := &StakerApp{
+		babylonClient:    cl,
+		wc:               &unlockingWallet{WalletController: walletClient, unlocker: unlocker},
+		walletUnlocker:   unlocker,
+		wallets:          make(map[string]*stakerWallet),
+		notifier:         nodeNotifier,
+		feeEstimator:     feeEestimator,
+		network:          &config.ActiveNetParams,
+		txTracker:        tracker,
+		txQueries:        tracker.ReadOnly(),
+		babylonMsgSender: babylonMsgSender,
+		m:                metrics,
+		tracer:           tp.Tracer(tracerName),
+		config:           config,
+		logger:           logger,
+		quit:             make(chan struct{}),
 		// event for when transaction is confirmed on BTC
 		stakingTxBtcConfirmedEvChan: make(chan *stakingTxBtcConfirmedEvent),
 
+		// event for when confirmed transaction is reorged out of BTC chain
+		stakingTxReorgedEvChan: make(chan *stakingTxReorgedEvent),
+
 		// event for when delegation is sent to babylon and included in babylon
 		delegationSubmittedToBabylonEvChan: make(chan *delegationSubmittedToBabylonEvent),
 
@@ -267,14 +499,43 @@ func NewStakerAppFromDeps(
 		// how to handle, so we just log them. It is up to user to investigate, what had happend
 		// and report the situation
 		criticalErrorEvChan: make(chan *criticalErrorEvent),
-	}, nil
+
+		commandChan: make(chan *stakerCommand),
+
+		mempoolResidence: make(map[chainhash.Hash]*mempoolResidence),
+		unbondingTasks:   make(map[chainhash.Hash]struct{}),
+		readModel:        newReadModel(),
+		stateUpdates:     newStateUpdates(),
+		timelockExpiries: newTimelockExpiries(),
+	}
+
+	if config.WebhookConfig != nil && config.WebhookConfig.Enabled() {
+		app.webhookSender = NewWebhookSender(config.WebhookConfig, logger)
+	}
+
+	tracker.AddUpdateListener(app.onTransactionUpdated)
+	tracker.AddStateChangeListener(app.onTransactionStateChanged)
+	tracker.AddTrackedListener(app.onTransactionTracked)
+
+	return app, nil
 }
 
 func (app *StakerApp) Start() error {
 	var startErr error
 	app.startOnce.Do(func() {
+		app.started.Store(true)
 		app.logger.Infof("Starting StakerApp")
 
+		if app.config.WalletConfig.KeepWalletUnlocked {
+			if err := app.walletUnlocker.renew(); err != nil {
+				startErr = fmt.Errorf("failed to unlock the wallet: %w", err)
+				return
+			}
+
+			app.wg.Add(1)
+			go app.keepWalletUnlocked()
+		}
+
 		// TODO: This can take a long time as it connects to node. Maybe make it cancellable?
 		// although staker without node is not very useful
 
@@ -315,11 +576,41 @@ func (app *StakerApp) Start() error {
 
 		app.babylonMsgSender.Start()
 
-		app.wg.Add(2)
+		if app.webhookSender != nil {
+			app.webhookSender.Start()
+		}
+
+		app.wg.Add(1)
 		go app.handleNewBlocks(blockEventNotifier)
-		go app.handleStakingEvents()
+		app.startEventLoop()
+
+		if app.config.StakerConfig.LowBalanceThreshold > 0 {
+			app.wg.Add(1)
+			go app.monitorWalletBalance()
+		}
+
+		if app.config.StakerConfig.ReadModelRefreshInterval > 0 {
+			app.wg.Add(1)
+			go app.refreshReadModelPeriodically()
+		}
+
+		if app.config.StakerConfig.MaxMempoolResidence > 0 {
+			app.wg.Add(1)
+			go app.monitorMempoolResidence()
+		}
+
+		// gauges are kept up to date by store listeners from now on
+		app.updateDelegationsByStateMetric()
+
+		ctx, cancel := app.appQuitContext()
+		defer cancel()
 
-		if err := app.checkTransactionsStatus(); err != nil {
+		if err := app.checkMinStakingTxConfirmations(ctx, app.minStakingTxConfirmations()); err != nil {
+			startErr = err
+			return
+		}
+
+		if err := app.checkTransactionsStatus(ctx); err != nil {
 			startErr = err
 			return
 		}
@@ -344,12 +635,144 @@ func (app *StakerApp) handleNewBlocks(blockNotifier *notifier.BlockEpochEvent) {
 				"btcBlockHeight": block.Height,
 				"btcBlockHash":   block.Hash.String(),
 			}).Debug("Received new best btc block")
+
+			// new best block may come from competing chain, which no longer
+			// contains some of confirmed staking transactions
+			app.checkConfirmedTxsForReorg()
+
+			if _, err := app.CheckTimelockExpiries(uint32(block.Height)); err != nil {
+				app.logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Warn("Failed to check staking timelock expiries")
+			}
 		case <-app.quit:
 			return
 		}
 	}
 }
 
+// monitorWalletBalance periodically checks wallet balance against low balance
+// threshold
+func (app *StakerApp) monitorWalletBalance() {
+	defer app.wg.Done()
+
+	ticker := time.NewTicker(app.config.StakerConfig.BalanceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := app.CheckWalletBalance(); err != nil {
+			app.logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Warn("Failed to check wallet balance")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-app.quit:
+			return
+		}
+	}
+}
+
+// updateDelegationsByStateMetric sets number of tracked delegations in each
+// state from the store. It scans all delegations, so it is only used to
+// initialize the gauges.
+func (app *StakerApp) updateDelegationsByStateMetric() {
+	counts, err := app.txTracker.CountTransactionsByState()
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Warn("Failed to count delegations by state")
+		return
+	}
+
+	for state, name := range proto.TransactionState_name {
+		app.m.DelegationsByState.WithLabelValues(name).Set(float64(counts[proto.TransactionState(state)]))
+	}
+}
+
+// onTransactionTracked updates delegations by state gauge when delegation is
+// added to or removed from the store
+func (app *StakerApp) onTransactionTracked(_ *chainhash.Hash, state proto.TransactionState, tracked bool) {
+	gauge := app.m.DelegationsByState.WithLabelValues(state.String())
+
+	if tracked {
+		gauge.Inc()
+	} else {
+		gauge.Dec()
+	}
+}
+
+// WalletBalance returns balance of the wallet which can be spent and balance of
+// unconfirmed transactions received by the wallet, which cannot be spent yet
+func (app *StakerApp) WalletBalance() (btcutil.Amount, btcutil.Amount, error) {
+	return app.wc.GetBalance()
+}
+
+// checkSufficientFunds fails if spendable wallet balance does not cover staking
+// amount. Fee is not known before transaction is funded, so passing the check
+// does not guarantee that wallet can fund staking transaction.
+func (app *StakerApp) checkSufficientFunds(wc walletcontroller.WalletController, stakingAmount btcutil.Amount) error {
+	spendable, unconfirmed, err := wc.GetBalance()
+
+	if err != nil {
+		return fmt.Errorf("failed to retrieve wallet balance: %w", err)
+	}
+
+	if spendable < stakingAmount {
+		return fmt.Errorf("%w: staking amount %v, spendable balance %v, unconfirmed balance %v, shortfall %v",
+			ErrInsufficientFunds, stakingAmount, spendable, unconfirmed, stakingAmount-spendable)
+	}
+
+	return nil
+}
+
+// CheckWalletBalance returns current wallet balance and raises low balance alert
+// if balance dropped below configured threshold. Alert is raised once per
+// crossing, next alert can be raised only after balance rises above threshold
+// plus configured hysteresis.
+func (app *StakerApp) CheckWalletBalance() (btcutil.Amount, error) {
+	balance, _, err := app.WalletBalance()
+
+	if err != nil {
+		return 0, err
+	}
+
+	app.m.WalletBalance.Set(float64(balance))
+
+	threshold := btcutil.Amount(app.config.StakerConfig.LowBalanceThreshold)
+
+	if threshold == 0 {
+		return balance, nil
+	}
+
+	recoveryLevel := threshold + btcutil.Amount(app.config.StakerConfig.LowBalanceHysteresis)
+
+	app.lowBalanceMu.Lock()
+	defer app.lowBalanceMu.Unlock()
+
+	switch {
+	case !app.lowBalance && balance < threshold:
+		app.lowBalance = true
+		app.m.LowWalletBalanceAlerts.Inc()
+
+		app.logger.WithFields(logrus.Fields{
+			"balance":   balance,
+			"threshold": threshold,
+		}).Warn("Wallet balance dropped below low balance threshold")
+	case app.lowBalance && balance >= recoveryLevel:
+		app.lowBalance = false
+
+		app.logger.WithFields(logrus.Fields{
+			"balance":   balance,
+			"threshold": threshold,
+		}).Info("Wallet balance recovered above low balance threshold")
+	}
+
+	return balance, nil
+}
+
 func (app *StakerApp) Stop() error {
 	var stopErr error
 	app.stopOnce.Do(func() {
@@ -359,6 +782,10 @@ func (app *StakerApp) Stop() error {
 
 		app.babylonMsgSender.Stop()
 
+		if app.webhookSender != nil {
+			app.webhookSender.Stop()
+		}
+
 		err := app.feeEstimator.Stop()
 
 		if err != nil {
@@ -419,6 +846,7 @@ func (app *StakerApp) waitForStakingTransactionConfirmation(
 }
 
 func (app *StakerApp) handleBtcTxInfo(
+	ctx context.Context,
 	stakingTxHash *chainhash.Hash,
 	txInfo *stakerdb.StoredTransaction,
 	params *cl.StakingParams,
@@ -428,13 +856,33 @@ func (app *StakerApp) handleBtcTxInfo(
 
 	switch txStatus {
 	case walletcontroller.TxNotFound:
-		// Most probable reason this happened is transaction was included in btc chain (removed from mempool)
-		// and wallet also lost data and is not synced far enough to see transaction.
-		// Log it as error so that user can investigate.
-		// TODO: Set tx to some new state, like `Unknown` and periodically check if it is in mempool or chain ?
+		// Transaction could be dropped from mempool while staker was down e.g when
+		// btc node restarted. Stored transaction is fully signed, so rebroadcast
+		// it and resume waiting for confirmation.
+		if _, err := app.wc.SendRawTransaction(ctx, txInfo.StakingTx, true); err != nil {
+			// Most probable reason this happened is transaction was included in btc chain (removed from mempool)
+			// and wallet also lost data and is not synced far enough to see transaction.
+			// Log it as error so that user can investigate.
+			// TODO: Set tx to some new state, like `Unknown` and periodically check if it is in mempool or chain ?
+			app.logger.WithFields(logrus.Fields{
+				"btcTxHash": stakingTxHash,
+				"err":       err,
+			}).Error("Transaction from database not found in BTC mempool or chain and could not be rebroadcast")
+			return nil
+		}
+
 		app.logger.WithFields(logrus.Fields{
 			"btcTxHash": stakingTxHash,
-		}).Error("Transaction from database not found in BTC mempool or chain")
+		}).Info("Transaction from database not found in BTC mempool or chain. Rebroadcasted it")
+
+		if err := app.waitForStakingTransactionConfirmation(
+			stakingTxHash,
+			txInfo.StakingTx.TxOut[txInfo.StakingOutputIndex].PkScript,
+			app.requiredStakingTxConfirmations(params),
+			currentBestBlockHeight,
+		); err != nil {
+			return err
+		}
 	case walletcontroller.TxInMemPool:
 		app.logger.WithFields(logrus.Fields{
 			"btcTxHash": stakingTxHash,
@@ -443,7 +891,7 @@ func (app *StakerApp) handleBtcTxInfo(
 		if err := app.waitForStakingTransactionConfirmation(
 			stakingTxHash,
 			txInfo.StakingTx.TxOut[txInfo.StakingOutputIndex].PkScript,
-			params.ConfirmationTimeBlocks,
+			app.requiredStakingTxConfirmations(params),
 			currentBestBlockHeight,
 		); err != nil {
 			return err
@@ -456,45 +904,32 @@ func (app *StakerApp) handleBtcTxInfo(
 			"currentBestBlockHeight": currentBestBlockHeight,
 		}).Debug("Transaction found in chain")
 
-		if currentBestBlockHeight < btcTxInfo.BlockHeight {
-			// This is wierd case, we retrieved transaction from btc wallet, even though wallet best height
-			// is lower than block height of transaction.
-			// Log it as error so that user can investigate.
-			app.logger.WithFields(logrus.Fields{
-				"btcTxHash":              stakingTxHash,
-				"btcTxBlockHeight":       btcTxInfo.BlockHeight,
-				"currentBestBlockHeight": currentBestBlockHeight,
-			}).Error("Current best block height is lower than block height of transaction")
-
-			return nil
-		}
+		// depth is computed from current number of confirmations, so that
+		// transaction which got deep enough while staker was down moves to
+		// the confirmed state right away instead of waiting for new notification
+		blockDepth := uint32(confirmationsAtHeight(btcTxInfo, currentBestBlockHeight) - 1)
 
-		blockDepth := currentBestBlockHeight - btcTxInfo.BlockHeight
-
-		if blockDepth >= params.ConfirmationTimeBlocks {
+		if blockDepth >= app.requiredStakingTxConfirmations(params) {
 			app.logger.WithFields(logrus.Fields{
 				"btcTxHash":              stakingTxHash,
 				"btcTxBlockHeight":       btcTxInfo.BlockHeight,
 				"currentBestBlockHeight": currentBestBlockHeight,
 			}).Debug("Transaction deep enough in btc chain to be sent to Babylon")
 
-			// block is deep enough to init sent to babylon
-			ev := &stakingTxBtcConfirmedEvent{
-				stakingTxHash: *stakingTxHash,
-				txIndex:       btcTxInfo.TxIndex,
-				blockDepth:    params.ConfirmationTimeBlocks,
-				blockHash:     *btcTxInfo.BlockHash,
-				blockHeight:   btcTxInfo.BlockHeight,
-				tx:            txInfo.StakingTx,
-				inlusionBlock: btcTxInfo.Block,
+			// persist confirmed state before continuing, so that transaction is
+			// not observed in SENT_TO_BTC state after staker finishes recovery
+			if err := app.txTracker.SetTxConfirmed(
+				stakingTxHash,
+				btcTxInfo.BlockHash,
+				btcTxInfo.BlockHeight,
+			); err != nil {
+				return err
 			}
 
-			utils.PushOrQuit[*stakingTxBtcConfirmedEvent](
-				app.stakingTxBtcConfirmedEvChan,
-				ev,
-				app.quit,
-			)
-
+			// from now on transaction is handled the same way as transaction which
+			// was confirmed before crash. Delegation could be already submitted
+			// to babylon, so it must be checked before sending it again.
+			return app.checkConfirmedOnBtcTxStatus(ctx, stakingTxHash, params)
 		} else {
 			app.logger.WithFields(logrus.Fields{
 				"btcTxHash":              stakingTxHash,
@@ -505,7 +940,7 @@ func (app *StakerApp) handleBtcTxInfo(
 			if err := app.waitForStakingTransactionConfirmation(
 				stakingTxHash,
 				txInfo.StakingTx.TxOut[txInfo.StakingOutputIndex].PkScript,
-				params.ConfirmationTimeBlocks,
+				app.requiredStakingTxConfirmations(params),
 				currentBestBlockHeight,
 			); err != nil {
 				return err
@@ -518,8 +953,108 @@ func (app *StakerApp) handleBtcTxInfo(
 // TODO: We should also handle case when btc node or babylon node lost data and start from scratch
 // i.e keep track what is last known block height on both chains and detect if after restart
 // for some reason they are behind staker
-func (app *StakerApp) checkTransactionsStatus() error {
-	stakingParams, err := app.babylonClient.Params()
+// forEachTxConcurrently runs checkFn for every transaction hash, running at most
+// MaxConcurrentStatusChecks checks at the same time. Every hash is checked exactly
+// once. First error returned by any check is returned after all started checks finish.
+func (app *StakerApp) forEachTxConcurrently(
+	txHashes []*chainhash.Hash,
+	checkFn func(stakingTxHash *chainhash.Hash) error,
+) error {
+	var g errgroup.Group
+	g.SetLimit(int(app.config.StakerConfig.MaxConcurrentStatusChecks))
+
+	for _, txHash := range txHashes {
+		stakingTxHash := txHash
+		g.Go(func() error {
+			return checkFn(stakingTxHash)
+		})
+	}
+
+	return g.Wait()
+}
+
+// checkSentToBtcTxStatus checks status of staking transaction sent to btc and
+// either starts waiting for its confirmation or initiates sending it to babylon
+func (app *StakerApp) checkSentToBtcTxStatus(ctx context.Context, stakingTxHash *chainhash.Hash, stakingParams *cl.StakingParams) error {
+	tx, _ := app.mustGetTransactionAndStakerAddress(stakingTxHash)
+	details, status, err := app.wc.TxDetails(stakingTxHash, tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript)
+
+	if err != nil {
+		// we got some communication err, return error and kill app startup
+		return err
+	}
+
+	return app.handleBtcTxInfo(ctx, stakingTxHash, tx, stakingParams, app.currentBestBlockHeight.Load(), status, details)
+}
+
+// checkConfirmedOnBtcTxStatus resumes delegation of staking transaction already
+// confirmed on btc, depending on whether it was already received by babylon
+func (app *StakerApp) checkConfirmedOnBtcTxStatus(ctx context.Context, stakingTxHash *chainhash.Hash, stakingParams *cl.StakingParams) error {
+	delegationInfo, err := app.babylonClient.QueryDelegationInfo(ctx, stakingTxHash)
+
+	if err != nil && !errors.Is(err, cl.ErrDelegationNotFound) {
+		return err
+	}
+
+	// delegation is already on babylon restart delegation process from this point
+	if delegationInfo != nil {
+		app.logger.WithFields(logrus.Fields{
+			"btcTxHash": stakingTxHash,
+		}).Debug("Already confirmed transaction found on Babylon as part of delegation. Fix db state")
+
+		ev := &delegationSubmittedToBabylonEvent{
+			stakingTxHash: *stakingTxHash,
+			unbondingTx:   delegationInfo.UndelegationInfo.UnbondingTransaction,
+			unbondingTime: delegationInfo.UndelegationInfo.UnbondingTime,
+		}
+
+		utils.PushOrQuit[*delegationSubmittedToBabylonEvent](
+			app.delegationSubmittedToBabylonEvChan,
+			ev,
+			app.quit,
+		)
+	} else {
+		// transaction which is not on babylon, is already confirmed on btc chain
+		// get all necessary info and send it to babylon
+
+		tx, stakerAddress := app.mustGetTransactionAndStakerAddress(stakingTxHash)
+		details, status, err := app.wc.TxDetails(stakingTxHash, tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript)
+
+		if err != nil {
+			// we got some communication err, return error and kill app startup
+			return err
+		}
+
+		if status != walletcontroller.TxInChain {
+			// we have confirmed transaction which is not in chain. Most probably btc node
+			// we are connected to lost data
+			app.logger.WithFields(logrus.Fields{
+				"btcTxHash": stakingTxHash,
+			}).Error("Already confirmed transaction not found on btc chain.")
+			return nil
+		}
+
+		app.logger.WithFields(logrus.Fields{
+			"btcTxHash":                    stakingTxHash,
+			"btcTxConfirmationBlockHeight": details.BlockHeight,
+		}).Debug("Already confirmed transaction not sent to babylon yet. Initiate sending")
+
+		req := &sendDelegationRequest{
+			txHash:                      *stakingTxHash,
+			txIndex:                     details.TxIndex,
+			inclusionBlock:              details.Block,
+			requiredInclusionBlockDepth: uint64(app.requiredStakingTxConfirmations(stakingParams)),
+		}
+
+		app.wg.Add(1)
+		go app.sendDelegationToBabylonTask(req, stakerAddress, tx)
+	}
+
+	return nil
+}
+
+func (app *StakerApp) checkTransactionsStatus(ctx context.Context) error {
+	stakingParams, err := app.babylonClient.Params(ctx)
 
 	if err != nil {
 		return err
@@ -530,20 +1065,21 @@ func (app *StakerApp) checkTransactionsStatus() error {
 	var transactionsSentToBtc []*chainhash.Hash
 	var transactionConfirmedOnBtc []*chainhash.Hash
 	var transactionsOnBabylon []*stakingDbInfo
+	var transactionsUnbondingStarted []*chainhash.Hash
+	var preparedTxInputs []wire.OutPoint
 
 	reset := func() {
 		transactionsSentToBtc = make([]*chainhash.Hash, 0)
 		transactionConfirmedOnBtc = make([]*chainhash.Hash, 0)
 		transactionsOnBabylon = make([]*stakingDbInfo, 0)
+		transactionsUnbondingStarted = make([]*chainhash.Hash, 0)
+		preparedTxInputs = make([]wire.OutPoint, 0)
 	}
 
 	// In our scan we only record transactions which state need to be checked, as`ScanTrackedTransactions`
 	// is long running read transaction, it could dead lock with write transactions which we would need
 	// to use to update transaction state.
 	err = app.txTracker.ScanTrackedTransactions(func(tx *stakerdb.StoredTransaction) error {
-		// TODO : We need to have another stare like UnstakeTransaction sent and store
-		// info about transaction sent (hash) to check wheter it was confirmed after staker
-		// restarts
 		stakingTxHash := tx.StakingTx.TxHash()
 		switch tx.State {
 		case proto.TransactionState_SENT_TO_BTC:
@@ -572,6 +1108,16 @@ func (app *StakerApp) checkTransactionsStatus() error {
 		case proto.TransactionState_SPENT_ON_BTC:
 			// nothing to do, staking transaction is already spent
 			return nil
+		case proto.TransactionState_PREPARED:
+			// delegation waits for staker signatures. Output locks do not survive
+			// restart, so inputs of its staking transaction are locked again.
+			preparedTxInputs = append(preparedTxInputs, txInputs(tx.StakingTx)...)
+			return nil
+		case proto.TransactionState_UNBONDING_STARTED:
+			// unbonding tx may not have been sent, or confirmation of it may have
+			// been missed, resume unbonding
+			transactionsUnbondingStarted = append(transactionsUnbondingStarted, &stakingTxHash)
+			return nil
 		default:
 			return fmt.Errorf("unknown transaction state: %d", tx.State)
 		}
@@ -581,98 +1127,53 @@ func (app *StakerApp) checkTransactionsStatus() error {
 		return err
 	}
 
-	for _, txHash := range transactionsSentToBtc {
-		stakingTxHash := txHash
-		tx, _ := app.mustGetTransactionAndStakerAddress(stakingTxHash)
-		details, status, err := app.wc.TxDetails(stakingTxHash, tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript)
+	if err := app.wc.LockOutputs(preparedTxInputs); err != nil {
+		return fmt.Errorf("failed to lock inputs of prepared staking transactions: %w", err)
+	}
 
-		if err != nil {
-			// we got some communication err, return error and kill app startup
-			return err
+	err = app.forEachTxConcurrently(transactionsSentToBtc, func(stakingTxHash *chainhash.Hash) error {
+		return app.checkSentToBtcTxStatus(ctx, stakingTxHash, stakingParams)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = app.forEachTxConcurrently(transactionConfirmedOnBtc, func(stakingTxHash *chainhash.Hash) error {
+		return app.checkConfirmedOnBtcTxStatus(ctx, stakingTxHash, stakingParams)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, localInfo := range transactionsOnBabylon {
+		// we only can have one local states here
+		if localInfo.stakingTxState == proto.TransactionState_SENT_TO_BABYLON {
+			stakingTxHash := localInfo.stakingTxHash
+			// we crashed after succesful send to babaylon, restart checking for unbonding signatures
+			app.wg.Add(1)
+			go app.checkForUnbondingTxSignaturesOnBabylon(stakingTxHash)
+		} else {
+			// we should not have any other state here, so kill app
+			return fmt.Errorf("unexpected local transaction state: %s, expected: %s", localInfo.stakingTxState, proto.TransactionState_SENT_TO_BABYLON)
 		}
+	}
 
-		err = app.handleBtcTxInfo(stakingTxHash, tx, stakingParams, app.currentBestBlockHeight.Load(), status, details)
+	for _, stakingTxHash := range transactionsUnbondingStarted {
+		tx, err := app.txTracker.GetTransaction(stakingTxHash)
 
 		if err != nil {
 			return err
 		}
-	}
 
-	for _, txHash := range transactionConfirmedOnBtc {
-		stakingTxHash := txHash
-
-		delegationInfo, err := app.babylonClient.QueryDelegationInfo(stakingTxHash)
-
-		if err != nil && !errors.Is(cl.ErrDelegationNotFound, err) {
-			return err
-		}
-
-		// delegation is already on babylon restart delegation process from this point
-		if delegationInfo != nil {
-			app.logger.WithFields(logrus.Fields{
-				"btcTxHash": stakingTxHash,
-			}).Debug("Already confirmed transaction found on Babylon as part of delegation. Fix db state")
-
-			ev := &delegationSubmittedToBabylonEvent{
-				stakingTxHash: *stakingTxHash,
-				unbondingTx:   delegationInfo.UndelegationInfo.UnbondingTransaction,
-				unbondingTime: delegationInfo.UndelegationInfo.UnbondingTime,
-			}
-
-			utils.PushOrQuit[*delegationSubmittedToBabylonEvent](
-				app.delegationSubmittedToBabylonEvChan,
-				ev,
-				app.quit,
-			)
-		} else {
-			// transaction which is not on babylon, is already confirmed on btc chain
-			// get all necessary info and send it to babylon
-
-			tx, stakerAddress := app.mustGetTransactionAndStakerAddress(stakingTxHash)
-			details, status, err := app.wc.TxDetails(stakingTxHash, tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript)
+		stakerAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
 
-			if err != nil {
-				// we got some communication err, return error and kill app startup
-				return err
-			}
-
-			if status != walletcontroller.TxInChain {
-				// we have confirmed transaction which is not in chain. Most probably btc node
-				// we are connected to lost data
-				app.logger.WithFields(logrus.Fields{
-					"btcTxHash": stakingTxHash,
-				}).Error("Already confirmed transaction not found on btc chain.")
-				continue
-			}
-
-			app.logger.WithFields(logrus.Fields{
-				"btcTxHash":                    stakingTxHash,
-				"btcTxConfirmationBlockHeight": details.BlockHeight,
-			}).Debug("Already confirmed transaction not sent to babylon yet. Initiate sending")
-
-			req := &sendDelegationRequest{
-				txHash:                      *stakingTxHash,
-				txIndex:                     details.TxIndex,
-				inclusionBlock:              details.Block,
-				requiredInclusionBlockDepth: uint64(stakingParams.ConfirmationTimeBlocks),
-			}
-
-			app.wg.Add(1)
-			go app.sendDelegationToBabylonTask(req, stakerAddress, tx)
+		if err != nil {
+			return fmt.Errorf("error decoding staker address: %s. Err: %v", tx.StakerAddress, err)
 		}
-	}
 
-	for _, localInfo := range transactionsOnBabylon {
-		// we only can have one local states here
-		if localInfo.stakingTxState == proto.TransactionState_SENT_TO_BABYLON {
-			stakingTxHash := localInfo.stakingTxHash
-			// we crashed after succesful send to babaylon, restart checking for unbonding signatures
-			app.wg.Add(1)
-			go app.checkForUnbondingTxSignaturesOnBabylon(stakingTxHash)
-		} else {
-			// we should not have any other state here, so kill app
-			return fmt.Errorf("unexpected local transaction state: %s, expected: %s", localInfo.stakingTxState, proto.TransactionState_SENT_TO_BABYLON)
-		}
+		app.startUnbondingTask(stakingTxHash, stakerAddress, tx)
 	}
 
 	return nil
@@ -755,6 +1256,38 @@ func (app *StakerApp) mustGetTransactionAndStakerAddress(txHash *chainhash.Hash)
 	return ts, stakerAddress
 }
 
+// sameTxHash returns true if both transactions are nil or have the same hash
+func sameTxHash(a, b *wire.MsgTx) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.TxHash() == b.TxHash()
+}
+
+// checkDelegationUnchanged fails with ErrDelegationChanged if state of
+// delegation, or transactions sent on its behalf, changed since observed was
+// read from the store. Commands use it before applying operation prepared
+// outside of event loop.
+func (app *StakerApp) checkDelegationUnchanged(observed *stakerdb.StoredTransaction) error {
+	stakingTxHash := observed.StakingTx.TxHash()
+
+	current, err := app.txTracker.GetTransaction(&stakingTxHash)
+
+	if err != nil {
+		return err
+	}
+
+	cpfpUnchanged := (current.CpfpTxHash == nil && observed.CpfpTxHash == nil) ||
+		(current.CpfpTxHash != nil && observed.CpfpTxHash != nil && current.CpfpTxHash.IsEqual(observed.CpfpTxHash))
+
+	if current.State != observed.State || !sameTxHash(current.SpendTx, observed.SpendTx) || !cpfpUnchanged {
+		return fmt.Errorf("%w: delegation %s is in state %s", ErrDelegationChanged, stakingTxHash, current.State)
+	}
+
+	return nil
+}
+
 func (app *StakerApp) mustBuildInclusionProof(req *sendDelegationRequest) []byte {
 	proof, err := cl.GenerateProof(req.inclusionBlock, req.txIndex)
 
@@ -768,14 +1301,17 @@ func (app *StakerApp) mustBuildInclusionProof(req *sendDelegationRequest) []byte
 	return proof
 }
 
-func (app *StakerApp) stakerPrivateKey(stakerAddress btcutil.Address) (*btcec.PrivateKey, error) {
-	err := app.wc.UnlockWallet(defaultWalletUnlockTimeout)
+// PassphraseProvider supplies wallet passphrase for a single operation. It is
+// called only when the wallet needs to be unlocked, so the passphrase does not need
+// to be held in memory for the whole lifetime of the program.
+type PassphraseProvider func() (string, error)
 
-	if err != nil {
+func (app *StakerApp) stakerPrivateKey(wc walletcontroller.WalletController, stakerAddress btcutil.Address) (*btcec.PrivateKey, error) {
+	if err := app.checkNotWatchOnly("retrieve staker private key"); err != nil {
 		return nil, err
 	}
 
-	privkey, err := app.wc.DumpPrivateKey(stakerAddress)
+	privkey, err := wc.DumpPrivateKey(stakerAddress)
 
 	if err != nil {
 		return nil, err
@@ -784,13 +1320,17 @@ func (app *StakerApp) stakerPrivateKey(stakerAddress btcutil.Address) (*btcec.Pr
 	return privkey, nil
 }
 
-func (app *StakerApp) retrieveExternalDelegationData(stakerAddress btcutil.Address) (*externalDelegationData, error) {
-	params, err := app.babylonClient.Params()
+func (app *StakerApp) retrieveExternalDelegationData(
+	ctx context.Context,
+	wc walletcontroller.WalletController,
+	stakerAddress btcutil.Address,
+) (*externalDelegationData, error) {
+	params, err := app.babylonClient.Params(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	stakerPrivKey, err := app.stakerPrivateKey(stakerAddress)
+	stakerPrivKey, err := app.stakerPrivateKey(wc, stakerAddress)
 	if err != nil {
 		return nil, err
 	}
@@ -803,12 +1343,19 @@ func (app *StakerApp) retrieveExternalDelegationData(stakerAddress btcutil.Addre
 }
 
 func (app *StakerApp) sendUnbondingTxToBtcWithWitness(
+	ctx context.Context,
 	stakingTxHash *chainhash.Hash,
 	stakerAddress btcutil.Address,
 	storedTx *stakerdb.StoredTransaction,
 	unbondingData *stakerdb.UnbondingStoreData,
 ) error {
-	privkey, err := app.stakerPrivateKey(stakerAddress)
+	w, err := app.walletForTx(storedTx)
+
+	if err != nil {
+		return err
+	}
+
+	privkey, err := app.stakerPrivateKey(w.wc, stakerAddress)
 
 	if err != nil {
 		app.logger.WithFields(logrus.Fields{
@@ -819,7 +1366,7 @@ func (app *StakerApp) sendUnbondingTxToBtcWithWitness(
 	}
 
 	// TODO: As covenant committee is static, consider quering it once and storing in database
-	params, err := app.babylonClient.Params()
+	params, err := app.babylonClient.Params(ctx)
 
 	if err != nil {
 		return err
@@ -842,10 +1389,23 @@ func (app *StakerApp) sendUnbondingTxToBtcWithWitness(
 	}
 
 	unbondingTx := unbondingData.UnbondingTx
+	unbondingTxHash := unbondingTx.TxHash()
+
+	// unbonding may be resumed after restart, do not resend transaction which
+	// is already known to the node
+	_, status, err := app.wc.TxDetails(&unbondingTxHash, unbondingTx.TxOut[0].PkScript)
+
+	if err != nil {
+		return err
+	}
+
+	if status != walletcontroller.TxNotFound {
+		return nil
+	}
 
 	unbondingTx.TxIn[0].Witness = witness
 
-	_, err = app.wc.SendRawTransaction(unbondingTx, true)
+	_, err = app.wc.SendRawTransaction(ctx, unbondingTx, true)
 
 	if err != nil {
 		return err
@@ -866,6 +1426,7 @@ func (app *StakerApp) sendUnbondingTxToBtc(
 
 	err := retry.Do(func() error {
 		return app.sendUnbondingTxToBtcWithWitness(
+			ctx,
 			stakingTxHash,
 			stakerAddress,
 			storedTx,
@@ -956,14 +1517,48 @@ func (app *StakerApp) waitForUnbondingTxConfirmation(
 	}
 }
 
+// startUnbondingTask starts sending unbonding tx of delegation to btc, unless
+// it is already being sent. Returns false if unbonding task of delegation is
+// already running.
+func (app *StakerApp) startUnbondingTask(
+	stakingTxHash *chainhash.Hash,
+	stakerAddress btcutil.Address,
+	storedTx *stakerdb.StoredTransaction,
+) bool {
+	app.unbondingTasksMu.Lock()
+	defer app.unbondingTasksMu.Unlock()
+
+	if _, running := app.unbondingTasks[*stakingTxHash]; running {
+		return false
+	}
+
+	app.unbondingTasks[*stakingTxHash] = struct{}{}
+
+	app.wg.Add(1)
+	go app.sendUnbondingTxToBtcTask(
+		stakingTxHash,
+		stakerAddress,
+		storedTx,
+		storedTx.UnbondingTxData,
+	)
+
+	return true
+}
+
 // sendUnbondingTxToBtcTask tries to send unbonding tx to btc and register for confirmation notification.
-// it should be run in separate go routine.
+// it should be run in separate go routine. Once it finishes, unbonding of
+// delegation which failed to be sent can be retried.
 func (app *StakerApp) sendUnbondingTxToBtcTask(
 	stakingTxHash *chainhash.Hash,
 	stakerAddress btcutil.Address,
 	storedTx *stakerdb.StoredTransaction,
 	unbondingData *stakerdb.UnbondingStoreData) {
 	defer app.wg.Done()
+	defer func() {
+		app.unbondingTasksMu.Lock()
+		delete(app.unbondingTasks, *stakingTxHash)
+		app.unbondingTasksMu.Unlock()
+	}()
 	quitCtx, cancel := app.appQuitContext()
 	defer cancel()
 
@@ -1006,22 +1601,48 @@ func (app *StakerApp) appQuitContext() (context.Context, func()) {
 }
 
 func (app *StakerApp) buildAndSendDelegation(
+	ctx context.Context,
 	req *sendDelegationRequest,
 	stakerAddress btcutil.Address,
 	storedTx *stakerdb.StoredTransaction,
 ) (*pv.RelayerTxResponse, *cl.DelegationData, error) {
-	delegation, err := app.buildDelegation(req, stakerAddress, storedTx)
+	delegation, err := app.buildDelegation(ctx, req, stakerAddress, storedTx)
 	if err != nil {
 		return nil, nil, err
 	}
+
+	// payload is persisted before sending, so that it can be inspected if the
+	// submission fails
+	app.persistBabylonSubmissionPayload(&req.txHash, delegation)
+
 	resp, err := app.babylonMsgSender.SendDelegation(delegation, req.requiredInclusionBlockDepth)
 	if err != nil {
+		app.m.BabylonSubmissionFailures.Inc()
 		return nil, nil, err
 	}
 
 	return resp, delegation, nil
 }
 
+func (app *StakerApp) persistBabylonSubmissionPayload(
+	stakingTxHash *chainhash.Hash,
+	delegation *cl.DelegationData,
+) {
+	payload, err := cl.DelegationMsgPayload(delegation)
+
+	if err == nil {
+		err = app.txTracker.SetBabylonSubmissionPayload(stakingTxHash, payload)
+	}
+
+	if err != nil {
+		// payload is only used for debugging, do not fail the submission
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"err":           err,
+		}).Error("Failed to persist babylon submission payload")
+	}
+}
+
 func (app *StakerApp) sendDelegationToBabylonTask(
 	req *sendDelegationRequest,
 	stakerAddress btcutil.Address,
@@ -1035,10 +1656,16 @@ func (app *StakerApp) sendDelegationToBabylonTask(
 
 	var delegationData *cl.DelegationData
 	err := retry.Do(func() error {
-		_, del, err := app.buildAndSendDelegation(req, stakerAddress, storedTx)
+		// staking transaction could be reorged out of btc chain while we were
+		// retrying, in that case delegation will be sent after it is confirmed again
+		if err := app.stakingTxStillConfirmed(req); err != nil {
+			return retry.Unrecoverable(err)
+		}
+
+		_, del, err := app.buildAndSendDelegation(ctx, req, stakerAddress, storedTx)
 
 		if err != nil {
-			if errors.Is(err, cl.ErrInvalidBabylonExecution) {
+			if errors.Is(err, cl.ErrInvalidBabylonExecution) || errors.Is(err, cl.ErrGasCeilingExhausted) {
 				return retry.Unrecoverable(err)
 			}
 			return err
@@ -1049,12 +1676,16 @@ func (app *StakerApp) sendDelegationToBabylonTask(
 	},
 		longRetryOps(
 			ctx,
-			app.config.StakerConfig.BabylonStallingInterval,
+			app.babylonStallingInterval(),
 			app.onLongRetryFunc(&req.txHash, "Failed to deliver delegation to babylon due to error."),
 		)...,
 	)
 
-	if err != nil {
+	if errors.Is(err, errStakingTxReorged) {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": req.txHash,
+		}).Info("Staking transaction reorged out of btc chain. Stop sending delegation to babylon")
+	} else if err != nil {
 		app.reportCriticialError(
 			req.txHash,
 			err,
@@ -1076,75 +1707,120 @@ func (app *StakerApp) sendDelegationToBabylonTask(
 	}
 }
 
-// main event loop for the staker app
+// handleStakingRequested sends staking transaction of requested delegation to
+// btc, if it is sent by staker, starts tracking the delegation and waiting for
+// confirmation of its staking transaction. Must be run as command.
+func (app *StakerApp) handleStakingRequested(ctx context.Context, ev *stakingRequestedEvent) (*chainhash.Hash, error) {
+	app.logStakingEventReceived(ev)
+
+	bestBlockHeight := app.currentBestBlockHeight.Load()
+
+	if ev.isPrepared() {
+		// prepared transaction is already tracked, it only waited for staker
+		// signatures. Send it to btc and store the signatures.
+		_, err := app.wc.SendRawTransaction(ctx, ev.stakingTx, true)
+		if err != nil {
+			return nil, err
+		}
+
+		// inputs were locked since delegation was prepared. Once staking
+		// transaction is in mempool, wallet does not select them anymore.
+		app.unlockTxInputs(app.wc, ev.stakingTx)
+		app.m.StakingTxsBroadcast.Inc()
+
+		err = app.txTracker.SetPreparedTransactionSigned(
+			&ev.stakingTxHash,
+			babylonPopToDbPop(ev.pop),
+			ev.watchTxData.slashingTxSig,
+			ev.watchTxData.slashUnbondingTxSig,
+			ev.paramsSnapshot,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ev.isWatched() {
+		err := app.txTracker.AddWatchedTransaction(
+			ev.stakingTx,
+			ev.stakingOutputIdx,
+			ev.stakingTime,
+			ev.fpBtcPks,
+			babylonPopToDbPop(ev.pop),
+			ev.stakerAddress,
+			ev.watchTxData.slashingTx,
+			ev.watchTxData.slashingTxSig,
+			ev.watchTxData.stakerBabylonAddr,
+			ev.watchTxData.stakerBtcPk,
+			ev.watchTxData.unbondingTx,
+			ev.watchTxData.slashUnbondingTx,
+			ev.watchTxData.slashUnbondingTxSig,
+			ev.watchTxData.unbondingTime,
+			ev.paramsSnapshot,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// in case of owend transaction we need to send it, and then add to our tracking db.
+		_, err := app.wc.SendRawTransaction(ctx, ev.stakingTx, true)
+		if err != nil {
+			return nil, err
+		}
+
+		app.m.StakingTxsBroadcast.Inc()
+		if ev.stakingTxFeeInfo != nil {
+			app.m.FeesPaid.Add(float64(ev.stakingTxFeeInfo.Fee))
+		}
+
+		// funding is stored together with the transaction, so that sent
+		// transaction is never tracked without it
+		funding := &stakerdb.StakingTxFunding{
+			WalletName: ev.walletName,
+			FeeInfo:    ev.stakingTxFeeInfo,
+			Change:     ev.stakingTxChange,
+		}
+
+		if err := app.txTracker.AddFundedTransaction(
+			ev.stakingTx,
+			ev.stakingOutputIdx,
+			ev.stakingTime,
+			ev.fpBtcPks,
+			babylonPopToDbPop(ev.pop),
+			ev.stakerAddress,
+			ev.paramsSnapshot,
+			ev.delegationData,
+			funding,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := app.waitForStakingTransactionConfirmation(
+		&ev.stakingTxHash,
+		ev.stakingOutputPkScript,
+		ev.requiredDepthOnBtcChain,
+		uint32(bestBlockHeight),
+	); err != nil {
+		return nil, err
+	}
+
+	app.m.ValidReceivedDelegationRequests.Inc()
+	app.logStakingEventProcessed(ev)
+
+	return &ev.stakingTxHash, nil
+}
+
+// handleStakingEvents is main event loop of the app. It is the only goroutine
+// changing state of tracked delegations, either in response to staking events
+// or by executing commands requested through public api.
 func (app *StakerApp) handleStakingEvents() {
 	defer app.wg.Done()
 
 	for {
 		select {
-		case ev := <-app.stakingRequestedEvChan:
-			app.logStakingEventReceived(ev)
-
-			bestBlockHeight := app.currentBestBlockHeight.Load()
-
-			if ev.isWatched() {
-				err := app.txTracker.AddWatchedTransaction(
-					ev.stakingTx,
-					ev.stakingOutputIdx,
-					ev.stakingTime,
-					ev.fpBtcPks,
-					babylonPopToDbPop(ev.pop),
-					ev.stakerAddress,
-					ev.watchTxData.slashingTx,
-					ev.watchTxData.slashingTxSig,
-					ev.watchTxData.stakerBabylonAddr,
-					ev.watchTxData.stakerBtcPk,
-					ev.watchTxData.unbondingTx,
-					ev.watchTxData.slashUnbondingTx,
-					ev.watchTxData.slashUnbondingTxSig,
-					ev.watchTxData.unbondingTime,
-				)
-
-				if err != nil {
-					ev.errChan <- err
-					continue
-				}
-			} else {
-				// in case of owend transaction we need to send it, and then add to our tracking db.
-				_, err := app.wc.SendRawTransaction(ev.stakingTx, true)
-				if err != nil {
-					ev.errChan <- err
-					continue
-				}
-
-				err = app.txTracker.AddTransaction(
-					ev.stakingTx,
-					ev.stakingOutputIdx,
-					ev.stakingTime,
-					ev.fpBtcPks,
-					babylonPopToDbPop(ev.pop),
-					ev.stakerAddress,
-				)
-
-				if err != nil {
-					ev.errChan <- err
-					continue
-				}
-			}
-
-			if err := app.waitForStakingTransactionConfirmation(
-				&ev.stakingTxHash,
-				ev.stakingOutputPkScript,
-				ev.requiredDepthOnBtcChain,
-				uint32(bestBlockHeight),
-			); err != nil {
-				ev.errChan <- err
-				continue
-			}
-
-			app.m.ValidReceivedDelegationRequests.Inc()
-			ev.successChan <- &ev.stakingTxHash
-			app.logStakingEventProcessed(ev)
+		case cmd := <-app.commandChan:
+			app.executeCommand(cmd)
 
 		case ev := <-app.stakingTxBtcConfirmedEvChan:
 			app.logStakingEventReceived(ev)
@@ -1176,6 +1852,38 @@ func (app *StakerApp) handleStakingEvents() {
 			go app.sendDelegationToBabylonTask(req, stakerAddress, storedTx)
 			app.logStakingEventProcessed(ev)
 
+		case ev := <-app.stakingTxReorgedEvChan:
+			app.logStakingEventReceived(ev)
+
+			storedTx, err := app.txTracker.GetTransaction(&ev.stakingTxHash)
+
+			if err != nil {
+				app.logger.Fatalf("Error getting reorged tx %s: %s", ev.stakingTxHash, err)
+			}
+
+			// transaction could be already handled by previous event or moved
+			// further to babylon, in both cases there is nothing to do
+			if storedTx.State != proto.TransactionState_CONFIRMED_ON_BTC ||
+				storedTx.StakingTxConfirmationInfo == nil ||
+				!storedTx.StakingTxConfirmationInfo.BlockHash.IsEqual(&ev.blockHash) {
+				app.logger.WithFields(logrus.Fields{
+					"stakingTxHash": ev.stakingTxHash,
+					"state":         storedTx.State,
+				}).Debug("Staking transaction no longer waits for babylon submission. Skipping reorg handling")
+				continue
+			}
+
+			if err := app.txTracker.SetTxUnconfirmed(&ev.stakingTxHash); err != nil {
+				app.logger.Fatalf("Error setting state for tx %s: %s", ev.stakingTxHash, err)
+			}
+
+			app.m.DelegationsReorgedOnBtc.Inc()
+			// checking transaction status requires btc and babylon nodes, so it
+			// is done outside of main event loop
+			app.wg.Add(1)
+			go app.resumeWaitingForConfirmation(ev.stakingTxHash)
+			app.logStakingEventProcessed(ev)
+
 		case ev := <-app.delegationSubmittedToBabylonEvChan:
 			app.logStakingEventReceived(ev)
 			if err := app.txTracker.SetTxSentToBabylon(&ev.stakingTxHash, ev.unbondingTx, ev.unbondingTime); err != nil {
@@ -1273,280 +1981,2551 @@ func (app *StakerApp) BabylonController() cl.BabylonClient {
 	return app.babylonClient
 }
 
-func GetMinStakingTime(p *cl.StakingParams) uint32 {
-	// Actual minimum staking time in babylon is k+w, but setting it to that would
-	// result in delegation which have voting power for 0 btc blocks.
-	// therefore setting it to 2*w + k, will result in delegation with voting power
-	// for at least w blocks. Therefore this conditions enforces min staking time i.e time
-	// when stake is active of w blocks
-	return 2*p.FinalizationTimeoutBlocks + p.ConfirmationTimeBlocks
-}
-
-func (app *StakerApp) WatchStaking(
-	stakingTx *wire.MsgTx,
-	stakingTime uint16,
-	stakingValue btcutil.Amount,
-	fpPks []*btcec.PublicKey,
-	slashingTx *wire.MsgTx,
-	slashingTxSig *schnorr.Signature,
-	stakerBabylonAddr sdk.AccAddress,
-	stakerBtcPk *btcec.PublicKey,
-	stakerAddress btcutil.Address,
-	pop *cl.BabylonPop,
-	unbondingTx *wire.MsgTx,
-	slashUnbondingTx *wire.MsgTx,
-	slashUnbondingTxSig *schnorr.Signature,
-	unbondingTime uint16,
-) (*chainhash.Hash, error) {
-	currentParams, err := app.babylonClient.Params()
+// checkDestinationAllowed returns error if funds cannot be sent to given address
+// due to configured allow-list of destinations. Empty allow-list allows sending
+// funds to any address.
+func (app *StakerApp) checkDestinationAllowed(addr btcutil.Address) error {
+	allowed := app.config.WalletConfig.ActiveAllowedDestinations
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to watch staking tx. Failed to get params: %w", err)
+	if len(allowed) == 0 {
+		return nil
 	}
 
-	if len(fpPks) == 0 {
-		return nil, fmt.Errorf("no finality provider public keys provided")
+	for _, allowedAddr := range allowed {
+		if allowedAddr.EncodeAddress() == addr.EncodeAddress() {
+			return nil
+		}
 	}
 
-	if haveDuplicates(fpPks) {
-		return nil, fmt.Errorf("duplicate finality provider public keys provided")
+	return fmt.Errorf("%s: %w", addr.EncodeAddress(), ErrDestinationNotAllowed)
+}
+
+// changeAddress returns address of wallet wc which should receive change of the
+// staking transaction funded by staker address. If wallet cannot generate address
+// of configured type, change is sent back to the staker address.
+func (app *StakerApp) changeAddress(wc walletcontroller.WalletController, stakerAddress btcutil.Address) btcutil.Address {
+	addrType := app.config.WalletConfig.ActiveChangeAddressType
+
+	if addrType == types.DefaultChangeAddress {
+		return stakerAddress
 	}
 
-	watchedRequest, err := parseWatchStakingRequest(
-		stakingTx,
-		stakingTime,
-		stakingValue,
-		fpPks,
-		slashingTx,
-		slashingTxSig,
-		stakerBabylonAddr,
-		stakerBtcPk,
-		stakerAddress,
-		pop,
-		unbondingTx,
-		slashUnbondingTx,
-		slashUnbondingTxSig,
-		unbondingTime,
-		currentParams,
-		app.network,
-	)
+	changeAddress, err := wc.NewChangeAddress(addrType)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to watch staking tx. Invalid request: %w", err)
+		app.logger.WithFields(logrus.Fields{
+			"changeAddressType": addrType,
+			"err":               err,
+		}).Warn("Failed to generate change address of configured type. Sending change to staker address")
+		return stakerAddress
 	}
 
-	// we have valid request, check whether finality providers exists on babylon
-	for _, fpPk := range fpPks {
-		if err := app.finalityProviderExists(fpPk); err != nil {
-			return nil, err
-		}
+	return changeAddress
+}
+
+// throwawayChangeAddress returns address of configured change address type
+// derived from pubKey. It is used to size change output of transactions which
+// are never sent.
+func (app *StakerApp) throwawayChangeAddress(pubKey *btcec.PublicKey) (btcutil.Address, error) {
+	if app.config.WalletConfig.ActiveChangeAddressType == types.P2TRChangeAddress {
+		return btcutil.NewAddressTaproot(schnorr.SerializePubKey(pubKey), app.network)
 	}
 
-	app.logger.WithFields(logrus.Fields{
-		"stakerAddress": stakerAddress,
-		"stakingAmount": watchedRequest.stakingTx.TxOut[watchedRequest.stakingOutputIdx].Value,
-		"btxTxHash":     stakingTx.TxHash(),
-	}).Info("Received valid staking tx to watch")
+	return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), app.network)
+}
 
-	utils.PushOrQuit[*stakingRequestedEvent](
-		app.stakingRequestedEvChan,
-		watchedRequest,
-		app.quit,
-	)
+// trackAddress makes sure wallet wc tracks address which staker sends funds to,
+// if automatic address import is enabled. Backends which cannot import addresses
+// are skipped.
+func (app *StakerApp) trackAddress(wc walletcontroller.WalletController, addr btcutil.Address) error {
+	if !app.config.WalletConfig.AutoImportAddresses {
+		return nil
+	}
 
-	select {
-	case reqErr := <-watchedRequest.errChan:
+	err := wc.TrackAddress(addr)
+
+	if errors.Is(err, walletcontroller.ErrUnsupportedByBackend) {
 		app.logger.WithFields(logrus.Fields{
-			"stakerAddress": stakerAddress,
-			"err":           reqErr,
-		}).Debugf("Sending staking tx failed")
+			"address": addr,
+		}).Debug("Wallet backend cannot import addresses, skipping address import")
+		return nil
+	}
 
-		return nil, reqErr
-	case hash := <-watchedRequest.successChan:
-		return hash, nil
-	case <-app.quit:
-		return nil, nil
+	return err
+}
+
+// checkActiveDelegationsLimit returns ErrMaxActiveDelegationsReached if staker
+// already has maxActiveDelegations delegations which are not yet spent or unbonded.
+// Zero limit means there is no limit.
+func (app *StakerApp) checkActiveDelegationsLimit(stakerAddress btcutil.Address, maxActiveDelegations uint32) error {
+	if maxActiveDelegations == 0 {
+		return nil
+	}
+
+	encodedAddress := stakerAddress.EncodeAddress()
+	var activeDelegations uint32
+
+	err := app.txTracker.ScanTrackedTransactions(func(tx *stakerdb.StoredTransaction) error {
+		if tx.StakerAddress != encodedAddress {
+			return nil
+		}
+
+		if tx.State == proto.TransactionState_SPENT_ON_BTC ||
+			tx.State == proto.TransactionState_UNBONDING_CONFIRMED_ON_BTC {
+			return nil
+		}
+
+		activeDelegations++
+		return nil
+	}, func() {
+		activeDelegations = 0
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if activeDelegations >= maxActiveDelegations {
+		return fmt.Errorf("staker %s has %d active delegations, limit is %d: %w",
+			encodedAddress, activeDelegations, maxActiveDelegations, ErrMaxActiveDelegationsReached)
 	}
+
+	return nil
 }
 
-func (app *StakerApp) StakeFunds(
+// checkDuplicateFpDelegation applies configured duplicate delegation policy to
+// finality providers of new delegation. allowDuplicate overrides refuse policy
+// for a single request.
+func (app *StakerApp) checkDuplicateFpDelegation(
 	stakerAddress btcutil.Address,
-	stakingAmount btcutil.Amount,
 	fpPks []*btcec.PublicKey,
-	stakingTimeBlocks uint16,
-) (*chainhash.Hash, error) {
+	allowDuplicate bool,
+) error {
+	policy := app.config.StakerConfig.ActiveDuplicateFpDelegationPolicy
 
-	// check we are not shutting down
-	select {
-	case <-app.quit:
-		return nil, nil
+	if policy == types.AllowDuplicateFpDelegation {
+		return nil
+	}
 
-	default:
+	encodedAddress := stakerAddress.EncodeAddress()
+
+	for _, fpPk := range fpPks {
+		delegations, err := app.txTracker.GetTransactionsByFinalityProvider(fpPk)
+
+		if err != nil {
+			return err
+		}
+
+		var existing *stakerdb.StoredTransaction
+		for _, tx := range delegations {
+			if tx.StakerAddress != encodedAddress ||
+				tx.State == proto.TransactionState_SPENT_ON_BTC ||
+				tx.State == proto.TransactionState_UNBONDING_CONFIRMED_ON_BTC {
+				continue
+			}
+
+			existing = tx
+			break
+		}
+
+		if existing == nil {
+			continue
+		}
+
+		existingHash := existing.StakingTx.TxHash()
+		fpPkHex := hex.EncodeToString(schnorr.SerializePubKey(fpPk))
+
+		if policy == types.RefuseDuplicateFpDelegation && !allowDuplicate {
+			return fmt.Errorf("%w: staker %s, finality provider %s, existing delegation %s",
+				ErrDuplicateFpDelegation, encodedAddress, fpPkHex, existingHash)
+		}
+
+		app.logger.WithFields(logrus.Fields{
+			"stakerAddress":         encodedAddress,
+			"fpBtcPk":               fpPkHex,
+			"existingStakingTxHash": existingHash,
+		}).Warn("Staker already has active delegation to finality provider")
+	}
+
+	return nil
+}
+
+// checkFeeRateSanity applies configured fee rate sanity policy to fee rate
+// supplied by caller, if it is lower or higher than estimated fee rate by more
+// than configured tolerance factor
+func (app *StakerApp) checkFeeRateSanity(requested, estimated btcutil.Amount) error {
+	tolerance := app.config.BtcNodeBackendConfig.FeeRateTolerance
+
+	minFeeRate := btcutil.Amount(float64(estimated) / tolerance)
+	maxFeeRate := btcutil.Amount(float64(estimated) * tolerance)
+
+	if requested >= minFeeRate && requested <= maxFeeRate {
+		return nil
+	}
+
+	if app.config.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy == types.RefuseFeeRateOutOfTolerance {
+		return fmt.Errorf("%w: fee rate %d sat/kvB, estimated fee rate %d sat/kvB, tolerance factor %.2f",
+			ErrFeeRateOutOfTolerance, requested, estimated, tolerance)
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"feeRate":          requested,
+		"estimatedFeeRate": estimated,
+		"toleranceFactor":  tolerance,
+	}).Warn("Fee rate is out of tolerance band around estimated fee rate")
+
+	return nil
+}
+
+// feeLimits returns limits of fee rate of transactions created by staker, the
+// same which are applied by the wallet when funding staking transactions
+func (app *StakerApp) feeLimits() walletcontroller.FeeLimits {
+	return walletcontroller.NewFeeLimits(app.config.BtcNodeBackendConfig)
+}
+
+// requiredStakingTxConfirmations returns depth staking transaction must reach in
+// btc chain before delegation is sent to babylon. Configured value is used only
+// if it is higher than value required by babylon, as babylon params could have
+// changed since the configuration was validated.
+func (app *StakerApp) requiredStakingTxConfirmations(p *cl.StakingParams) uint32 {
+	if minConfirmations := app.minStakingTxConfirmations(); minConfirmations > p.ConfirmationTimeBlocks {
+		return minConfirmations
+	}
+
+	return p.ConfirmationTimeBlocks
+}
+
+// checkMinStakingTxConfirmations validates that configured number of staking
+// transaction confirmations is not lower than confirmation time required by
+// babylon
+func (app *StakerApp) checkMinStakingTxConfirmations(ctx context.Context, minConfirmations uint32) error {
+	if minConfirmations == 0 {
+		return nil
+	}
+
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if minConfirmations < params.ConfirmationTimeBlocks {
+		return fmt.Errorf("configured min staking tx confirmations %d is lower than confirmation time required by babylon %d",
+			minConfirmations, params.ConfirmationTimeBlocks)
+	}
+
+	return nil
+}
+
+func GetMinStakingTime(p *cl.StakingParams) uint32 {
+	// Actual minimum staking time in babylon is k+w, but setting it to that would
+	// result in delegation which have voting power for 0 btc blocks.
+	// therefore setting it to 2*w + k, will result in delegation with voting power
+	// for at least w blocks. Therefore this conditions enforces min staking time i.e time
+	// when stake is active of w blocks
+	return 2*p.FinalizationTimeoutBlocks + p.ConfirmationTimeBlocks
+}
+
+// GetMaxStakingTime returns maximum staking time accepted by babylon
+func GetMaxStakingTime(p *cl.StakingParams) uint32 {
+	if p.MaxStakingTime == 0 {
+		return math.MaxUint16
+	}
+
+	return uint32(p.MaxStakingTime)
+}
+
+// checkStakingTime fails if staking time is outside of range accepted by
+// babylon. Minimum staking time makes sure delegation has voting power after it
+// is finalized, see GetMinStakingTime.
+func checkStakingTime(stakingTime uint16, p *cl.StakingParams) error {
+	minStakingTime := GetMinStakingTime(p)
+	maxStakingTime := GetMaxStakingTime(p)
+
+	if uint32(stakingTime) < minStakingTime || uint32(stakingTime) > maxStakingTime {
+		return fmt.Errorf("%w: staking time %d is outside of allowed range [%d, %d]. Minimum staking time is 2 * finalization timeout (%d) + confirmation time (%d)",
+			ErrInvalidStakingTime, stakingTime, minStakingTime, maxStakingTime, p.FinalizationTimeoutBlocks, p.ConfirmationTimeBlocks)
+	}
+
+	return nil
+}
+
+func (app *StakerApp) WatchStaking(
+	ctx context.Context,
+	stakingTx *wire.MsgTx,
+	stakingTime uint16,
+	stakingValue btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	slashingTx *wire.MsgTx,
+	slashingTxSig *schnorr.Signature,
+	stakerBabylonAddr sdk.AccAddress,
+	stakerBtcPk *btcec.PublicKey,
+	stakerAddress btcutil.Address,
+	pop *cl.BabylonPop,
+	unbondingTx *wire.MsgTx,
+	slashUnbondingTx *wire.MsgTx,
+	slashUnbondingTxSig *schnorr.Signature,
+	unbondingTime uint16,
+) (*chainhash.Hash, error) {
+	currentParams, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch staking tx. Failed to get params: %w", err)
 	}
 
 	if len(fpPks) == 0 {
-		return nil, fmt.Errorf("no finality providers public keys provided")
+		return nil, fmt.Errorf("no finality provider public keys provided")
 	}
 
 	if haveDuplicates(fpPks) {
 		return nil, fmt.Errorf("duplicate finality provider public keys provided")
 	}
 
+	watchedRequest, err := parseWatchStakingRequest(
+		stakingTx,
+		stakingTime,
+		stakingValue,
+		fpPks,
+		slashingTx,
+		slashingTxSig,
+		stakerBabylonAddr,
+		stakerBtcPk,
+		stakerAddress,
+		pop,
+		unbondingTx,
+		slashUnbondingTx,
+		slashUnbondingTxSig,
+		unbondingTime,
+		currentParams,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch staking tx. Invalid request: %w", err)
+	}
+
+	watchedRequest.requiredDepthOnBtcChain = app.requiredStakingTxConfirmations(currentParams)
+
+	// we have valid request, check whether finality providers exists on babylon
 	for _, fpPk := range fpPks {
-		if err := app.finalityProviderExists(fpPk); err != nil {
+		if err := app.finalityProviderExists(ctx, fpPk); err != nil {
 			return nil, err
 		}
 	}
 
-	params, err := app.babylonClient.Params()
+	app.logger.WithFields(logrus.Fields{
+		"stakerAddress": stakerAddress,
+		"stakingAmount": watchedRequest.stakingTx.TxOut[watchedRequest.stakingOutputIdx].Value,
+		"btxTxHash":     stakingTx.TxHash(),
+	}).Info("Received valid staking tx to watch")
+
+	var stakingTxHash *chainhash.Hash
+
+	err = app.runCommand(ctx, "WatchStaking", func(ctx context.Context) error {
+		var err error
+		stakingTxHash, err = app.handleStakingRequested(ctx, watchedRequest)
+		return err
+	})
 
 	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakerAddress": stakerAddress,
+			"err":           err,
+		}).Debugf("Sending staking tx failed")
+
 		return nil, err
 	}
 
-	slashingFee := app.getSlashingFee(params.MinSlashingTxFeeSat)
+	return stakingTxHash, nil
+}
 
-	if stakingAmount <= slashingFee {
-		return nil, fmt.Errorf("staking amount %d is less than minimum slashing fee %d",
-			stakingAmount, slashingFee)
+// StakeOptions customize how StakeFunds creates staking transaction. Zero value
+// stakes from the main wallet, unlocked with passphrase from config, using fee
+// rate estimated by btc node.
+type StakeOptions struct {
+	// WalletName selects additional wallet which funds and signs staking
+	// transaction, empty name selects the main wallet. Staker address must belong
+	// to selected wallet. Delegation records which wallet funded it, so that it
+	// is unbonded and withdrawn using the same wallet. Delegation funded from
+	// additional wallet is signed before StakeFunds returns, so it is sent to
+	// babylon even if the wallet is locked by the time staking transaction
+	// confirms.
+	WalletName string
+	// PassphraseProvider, if set, supplies passphrase which unlocks selected
+	// wallet for the duration of the operation, instead of passphrase from
+	// config. Slashing and unbonding transactions of delegation are signed while
+	// the wallet is unlocked and stored with it, so delegation is sent to babylon
+	// once staking transaction confirms even if no passphrase is configured.
+	PassphraseProvider PassphraseProvider
+	// AllowDuplicateFp creates delegation even if staker already has active
+	// delegation to one of the finality providers and configured duplicate
+	// delegation policy is refuse.
+	AllowDuplicateFp bool
+	// FeeRate in sat/kvB, if positive, is used to fund staking transaction
+	// instead of fee rate estimated by btc node. Fee rate outside of configured
+	// tolerance band around the estimate is handled according to configured fee
+	// rate sanity policy.
+	FeeRate btcutil.Amount
+	// ConfTarget, if positive, funds staking transaction using fee rate
+	// estimated by fee estimator for confirmation within ConfTarget blocks.
+	// It cannot be combined with FeeRate.
+	ConfTarget uint32
+}
+
+func (o *StakeOptions) feeRate() (stakingFeeRate, error) {
+	if o.FeeRate < 0 {
+		return stakingFeeRate{}, fmt.Errorf("fee rate must be positive")
 	}
 
-	minStakingTime := GetMinStakingTime(params)
-	if uint32(stakingTimeBlocks) < minStakingTime {
-		return nil, fmt.Errorf("staking time %d is less than minimum staking time %d",
-			stakingTimeBlocks, minStakingTime)
+	if o.FeeRate > 0 && o.ConfTarget > 0 {
+		return stakingFeeRate{}, fmt.Errorf("fee rate cannot be combined with confirmation target")
 	}
 
-	// unlock wallet for the rest of the operations
-	// TODO consider unlock/lock with defer
-	err = app.wc.UnlockWallet(defaultWalletUnlockTimeout)
+	if o.FeeRate > 0 {
+		feeRate := o.FeeRate
+		return stakingFeeRate{requested: &feeRate}, nil
+	}
+
+	return stakingFeeRate{confTarget: o.ConfTarget}, nil
+}
+
+// StakeFunds creates, signs and sends staking transaction to btc and returns
+// its hash. Operation is aborted with ctx.Err() if ctx is done before staking
+// transaction is sent to btc. After that point it always completes.
+func (app *StakerApp) StakeFunds(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	opts StakeOptions,
+) (*chainhash.Hash, error) {
+	feeRate, err := opts.feeRate()
 
 	if err != nil {
 		return nil, err
 	}
 
-	// build proof of possesion, no point moving forward if staker do not have all
-	// the necessary keys
-	stakerPubKey, err := app.wc.AddressPublicKey(stakerAddress)
+	return app.stakeFunds(
+		ctx,
+		stakerAddress,
+		stakingAmount,
+		fpPks,
+		stakingTimeBlocks,
+		opts.WalletName,
+		opts.PassphraseProvider,
+		opts.AllowDuplicateFp,
+		feeRate,
+	)
+}
+
+// stakingFeeRate selects fee rate of staking transaction. Zero value selects fee
+// rate from fee estimator.
+type stakingFeeRate struct {
+	// fee rate in sat/kvB supplied by caller
+	requested *btcutil.Amount
+	// number of blocks in which staking transaction should confirm, fee rate
+	// for it is estimated by fee estimator
+	confTarget uint32
+}
+
+// stakingTxFeeRate returns fee rate in sat/kvB of staking transaction selected by
+// feeRate. Fee rate supplied by caller is checked against estimated fee rate.
+func (app *StakerApp) stakingTxFeeRate(feeRate stakingFeeRate) (btcutil.Amount, error) {
+	switch {
+	case feeRate.requested != nil:
+		estimatedFeeRate := btcutil.Amount(app.feeEstimator.EstimateFeePerKb())
+
+		if err := app.checkFeeRateSanity(*feeRate.requested, estimatedFeeRate); err != nil {
+			return 0, err
+		}
+
+		return *feeRate.requested, nil
+	case feeRate.confTarget > 0:
+		return btcutil.Amount(app.feeEstimator.EstimateFeePerKbForTarget(feeRate.confTarget)), nil
+	default:
+		return btcutil.Amount(app.feeEstimator.EstimateFeePerKb()), nil
+	}
+}
+
+func (app *StakerApp) stakeFunds(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	walletName string,
+	passphraseProvider PassphraseProvider,
+	allowDuplicateFp bool,
+	feeRate stakingFeeRate,
+) (*chainhash.Hash, error) {
+	ctx, span := app.startSpan(
+		ctx,
+		"StakeFunds",
+		attribute.String(attrStakerAddress, stakerAddress.EncodeAddress()),
+		attribute.Int64(attrAmount, int64(stakingAmount)),
+	)
+
+	txHash, err := app.doStakeFunds(ctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, walletName, passphraseProvider, allowDuplicateFp, feeRate)
+
+	if txHash != nil {
+		span.SetAttributes(
+			attribute.String(attrTxHash, txHash.String()),
+			attribute.String(attrState, proto.TransactionState_SENT_TO_BTC.String()),
+		)
+	}
+
+	endSpan(span, err)
+
+	return txHash, err
+}
+
+// SetPreSignHook sets hook invoked with staking transactions created by
+// StakeFunds, after they are funded by the wallet, but before they are signed.
+// Passing nil removes the hook.
+func (app *StakerApp) SetPreSignHook(hook PreSignHook) {
+	app.preSignHookMu.Lock()
+	defer app.preSignHookMu.Unlock()
+	app.preSignHook = hook
+}
+
+func (app *StakerApp) getPreSignHook() PreSignHook {
+	app.preSignHookMu.RLock()
+	defer app.preSignHookMu.RUnlock()
+	return app.preSignHook
+}
+
+// txInputs returns outpoints spent by tx
+func txInputs(tx *wire.MsgTx) []wire.OutPoint {
+	outpoints := make([]wire.OutPoint, len(tx.TxIn))
+	for i, in := range tx.TxIn {
+		outpoints[i] = in.PreviousOutPoint
+	}
+	return outpoints
+}
+
+// fundAndLock runs funding operation of wallet wc and locks inputs of funded
+// transaction in the wallet
+func (app *StakerApp) fundAndLock(wc walletcontroller.WalletController, fund func() (*wire.MsgTx, error)) (*wire.MsgTx, error) {
+	app.fundingMu.Lock()
+	defer app.fundingMu.Unlock()
+
+	tx, err := fund()
 
 	if err != nil {
 		return nil, err
 	}
 
-	babylonAddrHash := tmhash.Sum(app.babylonClient.GetKeyAddress().Bytes())
+	if err := wc.LockOutputs(txInputs(tx)); err != nil {
+		return nil, fmt.Errorf("failed to lock inputs of staking transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// unlockTxInputs releases outputs of wallet wc spent by tx, which were locked
+// when tx was funded
+func (app *StakerApp) unlockTxInputs(wc walletcontroller.WalletController, tx *wire.MsgTx) {
+	if err := wc.UnlockOutputs(txInputs(tx)); err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"btcTxHash": tx.TxHash(),
+			"err":       err,
+		}).Error("Failed to unlock inputs of staking transaction")
+	}
+}
+
+// createAndSignStakingTx funds and signs transaction with given staking output
+// using wallet wc. If pre-sign hook is set, transaction is passed through it
+// before signing. Returns signed transaction and index of staking output in it.
+// Inputs of returned transaction are locked in the wallet and must be unlocked
+// by caller with unlockTxInputs.
+func (app *StakerApp) createAndSignStakingTx(
+	ctx context.Context,
+	wc walletcontroller.WalletController,
+	stakingOutput *wire.TxOut,
+	feeRate btcutil.Amount,
+	changeAddress btcutil.Address,
+) (*wire.MsgTx, uint32, error) {
+	hook := app.getPreSignHook()
+
+	if hook == nil {
+		_, span := app.startWalletSpan(ctx, "CreateAndSignTx")
+		tx, err := app.fundAndLock(wc, func() (*wire.MsgTx, error) {
+			return wc.CreateAndSignTx(ctx, []*wire.TxOut{stakingOutput}, feeRate, changeAddress, app.config.WalletConfig.SignalRbf)
+		})
+		endSpan(span, err)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return tx, 0, nil
+	}
+
+	_, span := app.startWalletSpan(ctx, "CreateTransaction")
+	tx, err := app.fundAndLock(wc, func() (*wire.MsgTx, error) {
+		return wc.CreateTransaction([]*wire.TxOut{stakingOutput}, feeRate, changeAddress, app.config.WalletConfig.SignalRbf)
+	})
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	signed := false
+	defer func() {
+		if !signed {
+			app.unlockTxInputs(wc, tx)
+		}
+	}()
+
+	modifiedTx, err := hook(tx.Copy())
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("pre-sign hook failed: %w", err)
+	}
+
+	stakingOutputIdx, err := ValidatePreSignHookTx(tx, modifiedTx, stakingOutput)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// hook may add inputs, but only wallet outputs can be signed by the wallet
+	fee, err := app.stakingTxFee(wc, modifiedTx)
+
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidPreSignHookTx, err)
+	}
+
+	if fee <= 0 {
+		return nil, 0, fmt.Errorf("%w: outputs value exceeds inputs value", ErrInvalidPreSignHookTx)
+	}
+
+	_, span = app.startWalletSpan(ctx, "SignRawTransaction")
+	signedTx, fullySigned, err := wc.SignRawTransaction(ctx, modifiedTx)
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !fullySigned {
+		return nil, 0, fmt.Errorf("not all inputs of staking transaction could be signed")
+	}
+
+	signed = true
+
+	return signedTx, stakingOutputIdx, nil
+}
+
+// checkMempoolAccept asks btc node whether it would accept staking transaction
+// to its mempool, so that fee and policy violations are reported with reject
+// reason of the node before transaction is broadcast. Check is skipped if
+// backend does not support it.
+func (app *StakerApp) checkMempoolAccept(ctx context.Context, tx *wire.MsgTx) error {
+	_, span := app.startWalletSpan(ctx, "TestMempoolAccept")
+	accepted, rejectReason, err := app.wc.TestMempoolAccept(tx)
+	endSpan(span, err)
+
+	if errors.Is(err, walletcontroller.ErrUnsupportedByBackend) {
+		app.logger.WithFields(logrus.Fields{
+			"btxTxHash": tx.TxHash(),
+		}).Debug("Wallet backend does not support mempool acceptance check, skipping it")
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to check mempool acceptance of staking transaction: %w", err)
+	}
+
+	if !accepted {
+		return fmt.Errorf("%w: %s", ErrStakingTxRejectedByMempool, rejectReason)
+	}
+
+	return nil
+}
+
+// checkBabylonLightClientReady checks that babylon btc light client has caught up
+// with btc chain enough for configured thresholds. If neither threshold is
+// configured, babylon is not queried at all.
+func (app *StakerApp) checkBabylonLightClientReady(ctx context.Context) error {
+	minTipHeight := app.config.StakerConfig.MinBabylonBtcTipHeight
+	maxLag := app.config.StakerConfig.MaxBabylonBtcLag
+
+	if minTipHeight == 0 && maxLag == 0 {
+		return nil
+	}
+
+	_, span := app.startBabylonSpan(ctx, "GetBTCHeaderChainTip")
+	babylonTipHeight, babylonTipHash, err := app.babylonClient.GetBTCHeaderChainTip(ctx)
+	endSpan(span, err)
+
+	if err != nil {
+		return fmt.Errorf("failed to query babylon btc light client tip: %w", err)
+	}
+
+	if babylonTipHeight < minTipHeight {
+		return fmt.Errorf("%w: light client tip %s at height %d is below required height %d",
+			ErrBabylonLightClientNotReady, babylonTipHash, babylonTipHeight, minTipHeight)
+	}
+
+	btcTipHeight := app.currentBestBlockHeight.Load()
+
+	if maxLag > 0 && btcTipHeight > babylonTipHeight && btcTipHeight-babylonTipHeight > maxLag {
+		return fmt.Errorf("%w: light client tip %s at height %d is %d blocks behind btc tip height %d, max allowed lag is %d",
+			ErrBabylonLightClientNotReady, babylonTipHash, babylonTipHeight, btcTipHeight-babylonTipHeight, btcTipHeight, maxLag)
+	}
+
+	return nil
+}
+
+// stakingOutputDustLimit returns the smallest value of staking output which is
+// not considered dust by the network. Staking output is always p2tr output, and
+// dust limit depends only on the type of output script.
+func stakingOutputDustLimit() (btcutil.Amount, error) {
+	// witness program of the output does not change its size
+	pkScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_1).
+		AddData(make([]byte, schnorr.PubKeyBytesLen)).
+		Script()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return btcutil.Amount(mempool.GetDustThreshold(wire.NewTxOut(0, pkScript))), nil
+}
+
+// checkWalletStakerAddress fails if wallet cannot prove possession of staker
+// key of stakerAddress. Proof of possession is bip322 signature, which wallet
+// produces only for native segwit and taproot addresses.
+func checkWalletStakerAddress(stakerAddress btcutil.Address) error {
+	switch stakerAddress.(type) {
+	case *btcutil.AddressWitnessPubKeyHash, *btcutil.AddressTaproot:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s is neither native segwit nor taproot address",
+			ErrUnsupportedStakerAddress, stakerAddress.EncodeAddress())
+	}
+}
+
+// checkMinStakingAmount fails if staking output with given amount would be
+// rejected either by the network as dust, or by babylon
+func checkMinStakingAmount(stakingAmount btcutil.Amount, params *cl.StakingParams) error {
+	dustLimit, err := stakingOutputDustLimit()
+
+	if err != nil {
+		return err
+	}
+
+	minAmount := dustLimit
+	if params.MinStakingValue > minAmount {
+		minAmount = params.MinStakingValue
+	}
+
+	if stakingAmount < minAmount {
+		return fmt.Errorf("%w: staking amount %v is less than %v, dust limit of staking output: %v, babylon minimum staking value: %v",
+			ErrStakingAmountTooLow, stakingAmount, minAmount, dustLimit, params.MinStakingValue)
+	}
+
+	return nil
+}
+
+// validateStakingRequest checks whether new delegation with given parameters can
+// be created and returns current babylon staking params
+func (app *StakerApp) validateStakingRequest(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	allowDuplicateFp bool,
+) (*cl.StakingParams, error) {
+	if len(fpPks) == 0 {
+		return nil, fmt.Errorf("no finality providers public keys provided")
+	}
+
+	if haveDuplicates(fpPks) {
+		return nil, fmt.Errorf("duplicate finality provider public keys provided")
+	}
+
+	if err := app.checkBabylonLightClientReady(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, fpPk := range fpPks {
+		_, span := app.startBabylonSpan(ctx, "QueryFinalityProvider")
+		err := app.finalityProviderExists(ctx, fpPk)
+		endSpan(span, err)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, span := app.startBabylonSpan(ctx, "QueryParams")
+	params, err := app.babylonClient.Params(ctx)
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkMinStakingAmount(stakingAmount, params); err != nil {
+		return nil, err
+	}
+
+	slashingFee := app.getSlashingFee(params.MinSlashingTxFeeSat)
+
+	if stakingAmount <= slashingFee {
+		return nil, fmt.Errorf("staking amount %d is less than minimum slashing fee %d",
+			stakingAmount, slashingFee)
+	}
+
+	if err := checkStakingTime(stakingTimeBlocks, params); err != nil {
+		return nil, err
+	}
+
+	if err := app.checkStakerDelegations(stakerAddress, fpPks, params, allowDuplicateFp); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// checkStakerDelegations checks whether delegations staker already has allow it
+// to create new delegation to given finality providers. Check is repeated by
+// command tracking new delegation, as other delegations could be created while
+// new one was being built.
+func (app *StakerApp) checkStakerDelegations(
+	stakerAddress btcutil.Address,
+	fpPks []*btcec.PublicKey,
+	params *cl.StakingParams,
+	allowDuplicateFp bool,
+) error {
+	if err := app.checkActiveDelegationsLimit(stakerAddress, params.MaxActiveDelegationsPerStaker); err != nil {
+		return err
+	}
+
+	return app.checkDuplicateFpDelegation(stakerAddress, fpPks, allowDuplicateFp)
+}
+
+func (app *StakerApp) doStakeFunds(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	walletName string,
+	passphraseProvider PassphraseProvider,
+	allowDuplicateFp bool,
+	stakingFeeRate stakingFeeRate,
+) (*chainhash.Hash, error) {
+
+	// check we are not shutting down
+	select {
+	case <-app.quit:
+		return nil, nil
+
+	default:
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := app.checkNotWatchOnly("stake funds"); err != nil {
+		return nil, err
+	}
+
+	// request is validated before wallet is unlocked, so that request with
+	// e.g unacceptable fee rate fails before anything is signed
+	validated, err := app.validateStakingTxRequest(
+		ctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, walletName, allowDuplicateFp, stakingFeeRate,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w, params, feeRate := validated.wallet, validated.params, validated.feeRate
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// unlock wallet for the rest of the operations
+	_, span := app.startWalletSpan(ctx, "UnlockWallet")
+	lockWallet, err := w.unlocker.acquire(passphraseProvider)
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer lockWallet()
+
+	// build proof of possesion, no point moving forward if staker do not have all
+	// the necessary keys
+	_, span = app.startWalletSpan(ctx, "AddressPublicKey")
+	stakerPubKey, err := w.wc.AddressPublicKey(stakerAddress)
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	babylonAddrHash := tmhash.Sum(app.babylonClient.GetKeyAddress().Bytes())
+
+	var sig wire.TxWitness
+	if _, isTaproot := stakerAddress.(*btcutil.AddressTaproot); isTaproot {
+		_, span = app.startWalletSpan(ctx, "SignBip322Taproot")
+		sig, err = w.wc.SignBip322Taproot(ctx, babylonAddrHash, stakerAddress)
+	} else {
+		_, span = app.startWalletSpan(ctx, "SignBip322NativeSegwit")
+		sig, err = w.wc.SignBip322NativeSegwit(ctx, babylonAddrHash, stakerAddress)
+	}
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pop, err := cl.NewBabylonBip322Pop(
+		babylonAddrHash,
+		sig,
+		stakerAddress,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingInfo, changeAddress, err := app.stakingTxOutputs(w.wc, stakerAddress, stakerPubKey, stakingAmount, fpPks, stakingTimeBlocks, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.trackAddress(w.wc, changeAddress); err != nil {
+		return nil, fmt.Errorf("cannot send change of staking transaction. Error importing change address: %w", err)
+	}
+
+	changeScript, err := txscript.PayToAddrScript(changeAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, stakingOutputIdx, err := app.createAndSignStakingTx(ctx, w.wc, stakingInfo.StakingOutput, feeRate, changeAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// inputs stay locked until staking transaction is sent. Once it is in
+	// mempool, wallet does not select its inputs anymore, so they are released
+	// regardless of the result
+	defer app.unlockTxInputs(w.wc, tx)
+
+	stakingTxFee, err := app.stakingTxFee(w.wc, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	feeInfo := txFeeInfo(tx, stakingTxFee)
+
+	app.logger.WithFields(logrus.Fields{
+		"stakerAddress": stakerAddress,
+		"stakingAmount": stakingInfo.StakingOutput,
+		"btxTxHash":     tx.TxHash(),
+		"fee":           feeInfo.Fee,
+		"feeRate":       feeInfo.FeeRate,
+	}).Info("Created and signed staking transaction")
+
+	if app.config.StakerConfig.MempoolAcceptCheck {
+		if err := app.checkMempoolAccept(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	// wallet unlocked with passphrase for this operation is locked again once it
+	// finishes, and staker never unlocks additional wallet on its own. In both
+	// cases staker key may not be available once staking transaction confirms,
+	// so delegation is signed now while the wallet is still unlocked.
+	var delegationData *stakerdb.WatchedTransactionData
+	if passphraseProvider != nil || walletName != "" {
+		_, span = app.startWalletSpan(ctx, "PresignDelegation")
+		delegationData, err = app.presignDelegation(
+			w.wc,
+			stakerAddress,
+			&stakerdb.StoredTransaction{
+				StakingTx:               tx,
+				StakingOutputIndex:      stakingOutputIdx,
+				StakingTime:             stakingTimeBlocks,
+				FinalityProvidersBtcPks: fpPks,
+			},
+			params,
+		)
+		endSpan(span, err)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign delegation data: %w", err)
+		}
+	}
+
+	req := newOwnedStakingRequest(
+		stakerAddress,
+		tx,
+		stakingOutputIdx,
+		stakingInfo.StakingOutput.PkScript,
+		stakingTimeBlocks,
+		stakingAmount,
+		fpPks,
+		app.requiredStakingTxConfirmations(params),
+		pop,
+		feeInfo,
+		findChangeOutput(tx, stakingOutputIdx, changeScript),
+		stakingParamsSnapshot(params),
+		walletName,
+		delegationData,
+	)
+
+	// operation can be aborted until staking transaction is sent to btc, once
+	// it is sent delegation is always stored
+	var stakingTxHash *chainhash.Hash
+
+	err = app.runCommand(ctx, "StakeFunds", func(ctx context.Context) error {
+		if err := app.checkStakerDelegations(stakerAddress, fpPks, params, allowDuplicateFp); err != nil {
+			return err
+		}
+
+		var err error
+		stakingTxHash, err = app.handleStakingRequested(ctx, req)
+		return err
+	})
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakerAddress": stakerAddress,
+			"err":           err,
+		}).Debugf("Sending staking tx failed")
+
+		return nil, err
+	}
+
+	return stakingTxHash, nil
+}
+
+// validatedStakingTxRequest is staking request which passed validation shared
+// by StakeFunds and BuildStakingTx
+type validatedStakingTxRequest struct {
+	// wallet funding staking transaction
+	wallet  *stakerWallet
+	params  *cl.StakingParams
+	feeRate btcutil.Amount
+}
+
+// validateStakingTxRequest validates staking request against staker address,
+// babylon params and balance of the wallet with given name, and resolves fee
+// rate of staking transaction
+func (app *StakerApp) validateStakingTxRequest(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	walletName string,
+	allowDuplicateFp bool,
+	stakingFeeRate stakingFeeRate,
+) (*validatedStakingTxRequest, error) {
+	if err := checkWalletStakerAddress(stakerAddress); err != nil {
+		return nil, err
+	}
+
+	w, err := app.wallet(walletName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := app.validateStakingRequest(ctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, allowDuplicateFp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	feeRate, err := app.stakingTxFeeRate(stakingFeeRate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.checkSufficientFunds(w.wc, stakingAmount); err != nil {
+		return nil, err
+	}
+
+	return &validatedStakingTxRequest{
+		wallet:  w,
+		params:  params,
+		feeRate: feeRate,
+	}, nil
+}
+
+// stakingTxOutputs builds staking output locked by stakerPubKey and returns it
+// together with change address of staking transaction funded from wallet wc
+func (app *StakerApp) stakingTxOutputs(
+	wc walletcontroller.WalletController,
+	stakerAddress btcutil.Address,
+	stakerPubKey *btcec.PublicKey,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+	params *cl.StakingParams,
+) (*staking.StakingInfo, btcutil.Address, error) {
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerPubKey,
+		fpPks,
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		stakingTimeBlocks,
+		stakingAmount,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build staking info: %w", err)
+	}
+
+	changeAddress := app.changeAddress(wc, stakerAddress)
+
+	if err := app.checkDestinationAllowed(changeAddress); err != nil {
+		return nil, nil, fmt.Errorf("cannot send change of staking transaction: %w", err)
+	}
+
+	return stakingInfo, changeAddress, nil
+}
+
+// BuildStakingTx previews staking transaction which StakeFunds would create for
+// given request, empty walletName selects the main wallet. It runs the same
+// validation, fee rate estimation, coin selection and script construction, but
+// transaction is not signed, sent to btc or tracked, and its inputs are not
+// locked in the wallet.
+func (app *StakerApp) BuildStakingTx(
+	ctx context.Context,
+	walletName string,
+	stakerAddress btcutil.Address,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+) (*StakingTxPreview, error) {
+	req, err := app.validateStakingTxRequest(
+		ctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, walletName, false, stakingFeeRate{},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	wc := req.wallet.wc
+	stakerPubKey, err := wc.AddressPublicKey(stakerAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingInfo, changeAddress, err := app.stakingTxOutputs(wc, stakerAddress, stakerPubKey, stakingAmount, fpPks, stakingTimeBlocks, req.params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return app.previewStakingTx(wc, stakingInfo.StakingOutput, req.feeRate, changeAddress)
+}
+
+// previewStakingTx funds staking transaction with stakingOutput from wallet wc
+// without signing it or locking its inputs, and describes the result
+func (app *StakerApp) previewStakingTx(
+	wc walletcontroller.WalletController,
+	stakingOutput *wire.TxOut,
+	feeRate btcutil.Amount,
+	changeAddress btcutil.Address,
+) (*StakingTxPreview, error) {
+	changeScript, err := txscript.PayToAddrScript(changeAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := wc.CreateTransaction(
+		[]*wire.TxOut{stakingOutput},
+		feeRate,
+		changeAddress,
+		app.config.WalletConfig.SignalRbf,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := wc.ListOutputs(false)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wallet outputs: %w", err)
+	}
+
+	walletOutputs := make(map[wire.OutPoint]walletcontroller.Utxo, len(utxos))
+	for _, utxo := range utxos {
+		walletOutputs[utxo.OutPoint] = utxo
+	}
+
+	preview := &StakingTxPreview{
+		UnsignedTx:            tx,
+		StakingOutputPkScript: stakingOutput.PkScript,
+		FeeRate:               feeRate,
+	}
+
+	var inputsValue btcutil.Amount
+	prevPkScripts := make([][]byte, 0, len(tx.TxIn))
+	for _, in := range tx.TxIn {
+		utxo, found := walletOutputs[in.PreviousOutPoint]
+
+		if !found {
+			return nil, fmt.Errorf("input %s of staking transaction is not wallet output", in.PreviousOutPoint)
+		}
+
+		preview.Inputs = append(preview.Inputs, StakingTxInput{
+			OutPoint: in.PreviousOutPoint,
+			Amount:   utxo.Amount,
+		})
+		inputsValue += utxo.Amount
+		prevPkScripts = append(prevPkScripts, utxo.PkScript)
+	}
+
+	var outputsValue btcutil.Amount
+	for i, out := range tx.TxOut {
+		outputsValue += btcutil.Amount(out.Value)
+
+		switch {
+		case bytes.Equal(out.PkScript, stakingOutput.PkScript):
+			preview.StakingOutputIdx = uint32(i)
+		case bytes.Equal(out.PkScript, changeScript):
+			preview.ChangeAmount = btcutil.Amount(out.Value)
+		}
+	}
+
+	preview.Fee = inputsValue - outputsValue
+	preview.VSize = int64(walletcontroller.EstimateTxVirtualSize(prevPkScripts, tx.TxOut, nil))
+
+	return preview, nil
+}
+
+// PrepareDelegation creates and funds staking transaction locked by staker key
+// which is held outside of the connected wallet. Staking transaction is funded
+// from stakerAddress, but it is not sent to btc. Instead, delegation is tracked
+// in PREPARED state until SubmitPreparedDelegation provides signatures and proof
+// of possession produced by staker key. Inputs of staking transaction are
+// locked in the wallet until it is sent to btc.
+// In watch-only mode stakerBtcPk must be the configured staker key, and staking
+// transaction is returned unsigned as psbt, which must be signed externally and
+// passed to SubmitSignedStakingTx before delegation is submitted.
+func (app *StakerApp) PrepareDelegation(
+	ctx context.Context,
+	stakerAddress btcutil.Address,
+	stakerBtcPk *btcec.PublicKey,
+	stakingAmount btcutil.Amount,
+	fpPks []*btcec.PublicKey,
+	stakingTimeBlocks uint16,
+) (*PreparedDelegation, error) {
+	// check we are not shutting down
+	select {
+	case <-app.quit:
+		return nil, nil
+
+	default:
+	}
+
+	watchOnlyKey := app.WatchOnlyStakerKey()
+
+	if watchOnlyKey != nil && !bytes.Equal(schnorr.SerializePubKey(stakerBtcPk), schnorr.SerializePubKey(watchOnlyKey)) {
+		return nil, fmt.Errorf("staker key %x does not match watch-only staker key %x",
+			schnorr.SerializePubKey(stakerBtcPk), schnorr.SerializePubKey(watchOnlyKey))
+	}
+
+	params, err := app.validateStakingRequest(ctx, stakerAddress, stakingAmount, fpPks, stakingTimeBlocks, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerBtcPk,
+		fpPks,
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		stakingTimeBlocks,
+		stakingAmount,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build staking info: %w", err)
+	}
+
+	changeAddress := app.changeAddress(app.wc, stakerAddress)
+
+	if err := app.checkDestinationAllowed(changeAddress); err != nil {
+		return nil, fmt.Errorf("cannot send change of staking transaction: %w", err)
+	}
+
+	if err := app.trackAddress(app.wc, changeAddress); err != nil {
+		return nil, fmt.Errorf("cannot send change of staking transaction. Error importing change address: %w", err)
+	}
+
+	feeRate := app.feeEstimator.EstimateFeePerKb()
+
+	var (
+		stakingTx   *wire.MsgTx
+		stakingPsbt *psbt.Packet
+	)
+
+	// inputs stay locked until prepared staking transaction is sent to btc, so
+	// that they are not spent by other staking requests in the meantime
+	stakingTx, err = app.fundAndLock(app.wc, func() (*wire.MsgTx, error) {
+		if watchOnlyKey != nil {
+			// wallet does not hold keys of the funding outputs, transaction is
+			// signed externally
+			packet, err := app.wc.CreateStakingPsbt([]*wire.TxOut{stakingInfo.StakingOutput}, btcutil.Amount(feeRate), changeAddress)
+
+			if err != nil {
+				return nil, err
+			}
+
+			stakingPsbt = packet
+			return packet.UnsignedTx, nil
+		}
+
+		return app.wc.CreateAndSignTx(ctx, []*wire.TxOut{stakingInfo.StakingOutput}, btcutil.Amount(feeRate), changeAddress, app.config.WalletConfig.SignalRbf)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	prepared := false
+	defer func() {
+		if !prepared {
+			app.unlockTxInputs(app.wc, stakingTx)
+		}
+	}()
+
+	slashingFee := app.getSlashingFee(params.MinSlashingTxFeeSat)
+	unbondingTime := params.MinUnbondingTime + 1
+
+	slashingTx, err := staking.BuildSlashingTxFromStakingTxStrict(
+		stakingTx,
+		0,
+		params.SlashingAddress,
+		stakerBtcPk,
+		unbondingTime,
+		int64(slashingFee),
+		params.SlashingRate,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build slashing transaction: %w", err)
+	}
+
+	stakingSlashingPathInfo, err := stakingInfo.SlashingPathSpendInfo()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build slashing path info: %w", err)
+	}
+
+	unbondingTxFeeRatePerKb := btcutil.Amount(app.feeEstimator.EstimateFeePerKb())
+
+	unsignedTxs, err := buildUnbondingTxs(
+		&stakerdb.StoredTransaction{
+			StakingTx:               stakingTx,
+			StakingOutputIndex:      0,
+			StakingTime:             stakingTimeBlocks,
+			FinalityProvidersBtcPks: fpPks,
+		},
+		stakerBtcPk,
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		params.SlashingAddress,
+		unbondingTxFeeRatePerKb,
+		unbondingTime,
+		slashingFee,
+		params.SlashingRate,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unbondingSlashingPathInfo, err := unsignedTxs.unbondingInfo.SlashingPathSpendInfo()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build unbonding slashing path info: %w", err)
+	}
+
+	stakerBabylonAddr := app.babylonClient.GetKeyAddress()
+
+	err = app.runCommand(ctx, "PrepareDelegation", func(ctx context.Context) error {
+		if err := app.checkStakerDelegations(stakerAddress, fpPks, params, false); err != nil {
+			return err
+		}
+
+		return app.txTracker.AddPreparedTransaction(
+			stakingTx,
+			0,
+			stakingTimeBlocks,
+			fpPks,
+			stakerAddress,
+			slashingTx,
+			stakerBabylonAddr,
+			stakerBtcPk,
+			unsignedTxs.unbondingTx,
+			unsignedTxs.slashUnbondingTx,
+			unbondingTime,
+		)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	prepared = true
+	stakingTxHash := stakingTx.TxHash()
+
+	app.logger.WithFields(logrus.Fields{
+		"stakerAddress": stakerAddress,
+		"stakingAmount": stakingAmount,
+		"btxTxHash":     stakingTxHash,
+	}).Info("Prepared delegation waiting for staker key signatures")
+
+	return &PreparedDelegation{
+		StakingTxHash:               stakingTxHash,
+		StakingTx:                   stakingTx,
+		StakingPsbt:                 stakingPsbt,
+		StakingOutputIdx:            0,
+		StakerBabylonAddr:           stakerBabylonAddr,
+		SlashingTx:                  slashingTx,
+		SlashingPathScript:          stakingSlashingPathInfo.RevealedLeaf.Script,
+		UnbondingTx:                 unsignedTxs.unbondingTx,
+		UnbondingTime:               unbondingTime,
+		SlashUnbondingTx:            unsignedTxs.slashUnbondingTx,
+		UnbondingSlashingPathScript: unbondingSlashingPathInfo.RevealedLeaf.Script,
+	}, nil
+}
+
+// SubmitPreparedDelegation completes delegation created by PrepareDelegation with
+// signatures and proof of possession produced by staker key. Provided data is
+// validated in the same way as in WatchStaking, and if it is valid, staking
+// transaction is sent to btc and delegation continues as watched one.
+func (app *StakerApp) SubmitPreparedDelegation(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	pop *cl.BabylonPop,
+	slashingTxSig *schnorr.Signature,
+	slashUnbondingTxSig *schnorr.Signature,
+) (*chainhash.Hash, error) {
+	req, err := app.preparedDelegationRequest(ctx, stakingTxHash, pop, slashingTxSig, slashUnbondingTxSig)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var txHash *chainhash.Hash
+
+	err = app.runCommand(ctx, "SubmitPreparedDelegation", func(ctx context.Context) error {
+		// delegation could be cancelled or submitted by other caller while
+		// request was validated
+		storedTx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+		if err != nil {
+			return err
+		}
+
+		if storedTx.State != proto.TransactionState_PREPARED {
+			return fmt.Errorf("cannot submit delegation in state %s: %w", storedTx.State, stakerdb.ErrTransactionNotPrepared)
+		}
+
+		txHash, err = app.handleStakingRequested(ctx, req)
+		return err
+	})
+
+	if err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"err":           err,
+		}).Debugf("Sending prepared staking tx failed")
+
+		return nil, err
+	}
+
+	return txHash, nil
+}
+
+// preparedDelegationRequest validates data completing prepared delegation and
+// builds request sending its staking transaction to btc
+func (app *StakerApp) preparedDelegationRequest(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	pop *cl.BabylonPop,
+	slashingTxSig *schnorr.Signature,
+	slashUnbondingTxSig *schnorr.Signature,
+) (*stakingRequestedEvent, error) {
+	storedTx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if storedTx.State != proto.TransactionState_PREPARED {
+		return nil, fmt.Errorf("cannot submit delegation in state %s: %w", storedTx.State, stakerdb.ErrTransactionNotPrepared)
+	}
+
+	if !txSigned(storedTx.StakingTx) {
+		return nil, fmt.Errorf("cannot submit delegation, signed staking transaction must be submitted first: %w", ErrStakingTxNotSigned)
+	}
+
+	preparedData, err := app.txTracker.GetPreparedTransactionData(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakerAddress, err := btcutil.DecodeAddress(storedTx.StakerAddress, app.network)
+
+	if err != nil {
+		return nil, err
+	}
+
+	currentParams, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit prepared delegation. Failed to get params: %w", err)
+	}
+
+	req, err := parseWatchStakingRequest(
+		storedTx.StakingTx,
+		storedTx.StakingTime,
+		btcutil.Amount(storedTx.StakingTx.TxOut[storedTx.StakingOutputIndex].Value),
+		storedTx.FinalityProvidersBtcPks,
+		preparedData.SlashingTx,
+		slashingTxSig,
+		preparedData.StakerBabylonAddr,
+		preparedData.StakerBtcPubKey,
+		stakerAddress,
+		pop,
+		preparedData.UnbondingTx,
+		preparedData.SlashingUnbondingTx,
+		slashUnbondingTxSig,
+		preparedData.UnbondingTime,
+		currentParams,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit prepared delegation. Invalid request: %w", err)
+	}
+
+	req.prepared = true
+	req.requiredDepthOnBtcChain = app.requiredStakingTxConfirmations(currentParams)
+
+	return req, nil
+}
+
+// CancelPreparedDelegation removes delegation created by PrepareDelegation which
+// was not yet submitted, and releases inputs of its staking transaction locked
+// in the wallet, so that they can fund other staking transactions.
+func (app *StakerApp) CancelPreparedDelegation(ctx context.Context, stakingTxHash *chainhash.Hash) error {
+	return app.runCommand(ctx, "CancelPreparedDelegation", func(ctx context.Context) error {
+		storedTx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+		if err != nil {
+			return err
+		}
+
+		if storedTx.State != proto.TransactionState_PREPARED {
+			return fmt.Errorf("cannot cancel delegation in state %s: %w", storedTx.State, stakerdb.ErrTransactionNotPrepared)
+		}
+
+		if err := app.txTracker.RemovePreparedTransaction(stakingTxHash); err != nil {
+			return err
+		}
+
+		app.unlockTxInputs(app.wc, storedTx.StakingTx)
+
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+		}).Info("Cancelled prepared delegation")
+
+		return nil
+	})
+}
+
+// EstimateLifecycleFees estimates full cost of staking stakingAmount for
+// stakingTime blocks i.e fee of staking transaction and fee of transaction
+// withdrawing funds through time lock path after staking time expires. Staking
+// transaction is funded by the wallet the same way as in BuildStakingTx, but with
+// throwaway keys, so no finality provider or staker address is needed. Both
+// fees are computed at feeRatePerKb in sat/kvB, zero fee rate selects fee rate
+// estimated by btc node.
+func (app *StakerApp) EstimateLifecycleFees(
+	ctx context.Context,
+	stakingAmount btcutil.Amount,
+	stakingTime uint16,
+	feeRatePerKb btcutil.Amount,
+) (*StakeCostEstimate, error) {
+	if stakingAmount <= 0 {
+		return nil, fmt.Errorf("staking amount must be positive")
+	}
+
+	if stakingTime == 0 {
+		return nil, fmt.Errorf("staking time must be positive")
+	}
+
+	if feeRatePerKb < 0 {
+		return nil, fmt.Errorf("fee rate must not be negative")
+	}
+
+	feeRate := feeRatePerKb
+	if feeRate == 0 {
+		estimated, err := app.stakingTxFeeRate(stakingFeeRate{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		feeRate = estimated
+	}
+
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// script and address sizes do not depend on actual keys, so use throwaway ones
+	stakerKey, err := btcec.NewPrivateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	fpKey, err := btcec.NewPrivateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerKey.PubKey(),
+		[]*btcec.PublicKey{fpKey.PubKey()},
+		params.CovenantPks,
+		params.CovenantQuruomThreshold,
+		stakingTime,
+		stakingAmount,
+		app.network,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build staking info: %w", err)
+	}
+
+	changeAddress, err := app.throwawayChangeAddress(stakerKey.PubKey())
+
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := app.previewStakingTx(app.wc, stakingInfo.StakingOutput, feeRate, changeAddress)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fund staking transaction: %w", err)
+	}
+
+	timeLockPathInfo, err := stakingInfo.TimeLockPathSpendInfo()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to build time lock path info: %w", err)
+	}
+
+	withdrawalTxVSize, err := estimateTimeLockPathSpendTxVSize(timeLockPathInfo, stakingTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawalFee := txrules.FeeForSerializeSize(feeRate, int(withdrawalTxVSize))
+
+	return &StakeCostEstimate{
+		StakingAmount:     stakingAmount,
+		FeeRate:           feeRate,
+		FundingFee:        preview.Fee,
+		FundingTxVSize:    preview.VSize,
+		WithdrawalFee:     withdrawalFee,
+		WithdrawalTxVSize: withdrawalTxVSize,
+		TotalCost:         stakingAmount + preview.Fee + withdrawalFee,
+	}, nil
+}
+
+func (app *StakerApp) StoredTransactions(limit, offset uint64) (*stakerdb.StoredTransactionQueryResult, error) {
+	query := stakerdb.StoredTransactionQuery{
+		IndexOffset:        offset,
+		NumMaxTransactions: limit,
+		Reversed:           false,
+	}
+	resp, err := app.txQueries.QueryStoredTransactions(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// StoredTransactionsInState works the same as StoredTransactions, but returns
+// only transactions in given state. Total count is number of all stored
+// transactions.
+func (app *StakerApp) StoredTransactionsInState(limit, offset uint64, state proto.TransactionState) (*stakerdb.StoredTransactionQueryResult, error) {
+	query := stakerdb.StoredTransactionQuery{
+		IndexOffset:        offset,
+		NumMaxTransactions: limit,
+		Reversed:           false,
+	}
+	resp, err := app.txQueries.QueryStoredTransactions(query.StateFilter(state))
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (app *StakerApp) WithdrawableTransactions(limit, offset uint64) (*stakerdb.StoredTransactionQueryResult, error) {
+	query := stakerdb.StoredTransactionQuery{
+		IndexOffset:        offset,
+		NumMaxTransactions: limit,
+		Reversed:           false,
+	}
+	resp, err := app.txQueries.QueryStoredTransactions(query.WithdrawableTransactionsFilter(app.currentBestBlockHeight.Load()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// GetUnbondableDelegations returns owned delegations which can be unbonded right
+// now i.e delegations which are active on Babylon, are not yet unbonding and
+// whose staking time lock is far enough from expiry for Babylon to still treat
+// them as active.
+func (app *StakerApp) GetUnbondableDelegations(ctx context.Context) ([]*stakerdb.StoredTransaction, error) {
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bestBlockHeight := app.currentBestBlockHeight.Load()
+
+	var unbondable []*stakerdb.StoredTransaction
+
+	err = app.txTracker.ScanTrackedTransactions(func(tx *stakerdb.StoredTransaction) error {
+		if tx.Watched || tx.State != proto.TransactionState_DELEGATION_ACTIVE {
+			return nil
+		}
+
+		if tx.StakingTxConfirmationInfo == nil {
+			return nil
+		}
+
+		// Babylon treats delegation as unbonded when it is less than finalization
+		// timeout blocks away from the end of its staking time lock
+		stakingEndHeight := tx.StakingTxConfirmationInfo.Height + uint32(tx.StakingTime)
+		if bestBlockHeight+params.FinalizationTimeoutBlocks > stakingEndHeight {
+			return nil
+		}
+
+		unbondable = append(unbondable, tx)
+		return nil
+	}, func() {
+		unbondable = nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unbondable, nil
+}
+
+// GetStoredTransaction returns tracked transaction with given hash. Transaction
+// is looked up directly by its hash, without scanning the store. Returns
+// stakerdb.ErrTransactionNotFound if transaction is not tracked.
+func (app *StakerApp) GetStoredTransaction(txHash *chainhash.Hash) (*stakerdb.StoredTransaction, error) {
+	return app.txQueries.GetTransaction(txHash)
+}
+
+// GetDelegationsByState returns all tracked delegations which are in given state
+func (app *StakerApp) GetDelegationsByState(state proto.TransactionState) ([]*stakerdb.StoredTransaction, error) {
+	return app.txTracker.GetTransactionsByState(state)
+}
+
+// GetBabylonSubmissionPayload returns serialized delegation message last
+// submitted to babylon for delegation with given staking transaction hash.
+// Returns ErrBabylonSubmissionPayloadNotFound if delegation was not submitted
+// by staker.
+func (app *StakerApp) GetBabylonSubmissionPayload(stakingTxHash *chainhash.Hash) ([]byte, error) {
+	tx, err := app.txQueries.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tx.BabylonSubmissionPayload) == 0 {
+		return nil, ErrBabylonSubmissionPayloadNotFound
+	}
+
+	return tx.BabylonSubmissionPayload, nil
+}
+
+// GetLinkedDelegations returns delegations whose staking transactions are linked
+// on chain with staking transaction with given hash, either through common
+// funding transaction or through change and spend outputs. Linked delegations
+// can be clustered together by chain observers. Only transactions persisted in
+// the store are analysed.
+func (app *StakerApp) GetLinkedDelegations(stakingTxHash *chainhash.Hash) ([]*stakerdb.StoredTransaction, error) {
+	txs, err := app.txQueries.GetAllStoredTransactions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	linked, found := linkedDelegations(txs, stakingTxHash)
+
+	if !found {
+		return nil, stakerdb.ErrTransactionNotFound
+	}
+
+	return linked, nil
+}
+
+// GetStakingSpendInfo returns tapscript leaf and control block required to spend
+// the staking output of the delegation through the time lock path. Together with
+// staker signature they form the witness of the withdrawal transaction.
+func (app *StakerApp) GetStakingSpendInfo(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+) ([]byte, []byte, byte, error) {
+	tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	stakerPubKey, err := app.stakerPublicKey(stakingTxHash, tx)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// delegation must be spent with covenant keys it was created with, not the
+	// ones currently on babylon
+	covenantPks, covenantQuorum, err := app.delegationCovenant(ctx, tx)
+
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerPubKey,
+		tx.FinalityProvidersBtcPks,
+		covenantPks,
+		covenantQuorum,
+		tx.StakingTime,
+		btcutil.Amount(tx.StakingTx.TxOut[tx.StakingOutputIndex].Value),
+		app.network,
+	)
+
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to build staking info: %w", err)
+	}
+
+	timeLockPathInfo, err := stakingInfo.TimeLockPathSpendInfo()
+
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to build time lock path info: %w", err)
+	}
+
+	controlBlock, err := timeLockPathInfo.ControlBlock.ToBytes()
+
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to serialize control block: %w", err)
+	}
+
+	return timeLockPathInfo.RevealedLeaf.Script,
+		controlBlock,
+		byte(timeLockPathInfo.RevealedLeaf.LeafVersion),
+		nil
+}
+
+// GetConfirmations returns current confirmation depth of the staking transaction
+// with given hash. It returns 0 if transaction is in mempool and -1 if transaction
+// is not known to btc node.
+func (app *StakerApp) GetConfirmations(stakingTxHash *chainhash.Hash) (int, error) {
+	tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return -1, err
+	}
+
+	status, confirmations, err := app.txConfirmations(
+		stakingTxHash,
+		tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript,
+	)
+
+	if err != nil {
+		return -1, err
+	}
+
+	if status == walletcontroller.TxNotFound {
+		return -1, nil
+	}
+
+	return confirmations, nil
+}
+
+// GetSpendTransactionStatus returns status and confirmation depth of the
+// transaction which spent staking or unbonding output of the staking transaction
+// with given hash. Contrary to SPENT_ON_BTC state, which is set only after
+// SpendStakeTxConfirmations, it allows tracking spend transaction from the moment
+// it is sent. It returns ErrSpendTxNotSent if staker did not send spend
+// transaction for this staking transaction.
+func (app *StakerApp) GetSpendTransactionStatus(
+	stakingTxHash *chainhash.Hash,
+) (walletcontroller.TxStatus, int, error) {
+	tx, err := app.txQueries.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return walletcontroller.TxNotFound, 0, err
+	}
+
+	if tx.SpendTx == nil {
+		return walletcontroller.TxNotFound, 0, ErrSpendTxNotSent
+	}
+
+	spendTxHash := tx.SpendTx.TxHash()
+
+	return app.txConfirmations(&spendTxHash, tx.SpendTx.TxOut[0].PkScript)
+}
+
+// GetWithdrawalDestinations returns outputs of the transaction which spent
+// staking or unbonding output of the staking transaction with given hash,
+// together with their current spent status. Outputs are reported as spent only
+// after both spend transaction and transaction spending the output are
+// confirmed. It returns ErrSpendTxNotSent if staker did not send spend
+// transaction for this staking transaction.
+func (app *StakerApp) GetWithdrawalDestinations(stakingTxHash *chainhash.Hash) ([]Destination, error) {
+	tx, err := app.txQueries.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if tx.SpendTx == nil {
+		return nil, ErrSpendTxNotSent
+	}
+
+	spendTxHash := tx.SpendTx.TxHash()
+
+	_, status, err := app.wc.TxDetails(&spendTxHash, tx.SpendTx.TxOut[0].PkScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	destinations := make([]Destination, len(tx.SpendTx.TxOut))
+
+	for i, out := range tx.SpendTx.TxOut {
+		destination := Destination{
+			OutputIdx: uint32(i),
+			Amount:    out.Value,
+		}
+
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, app.network)
+
+		if err == nil && len(addrs) == 1 {
+			destination.Address = addrs[0].EncodeAddress()
+		}
+
+		// outputs of unconfirmed transaction cannot be spent by confirmed one
+		if status == walletcontroller.TxInChain {
+			spent, err := app.wc.OutputSpent(&spendTxHash, uint32(i))
+
+			if err != nil {
+				return nil, err
+			}
+
+			destination.Spent = spent
+		}
+
+		destinations[i] = destination
+	}
+
+	return destinations, nil
+}
+
+// txConfirmations returns status of transaction with given hash in btc node and
+// its confirmation depth, which is 0 for transactions which are not in chain.
+func (app *StakerApp) txConfirmations(
+	txHash *chainhash.Hash,
+	pkScript []byte,
+) (walletcontroller.TxStatus, int, error) {
+	details, status, err := app.wc.TxDetails(txHash, pkScript)
+
+	if err != nil {
+		return walletcontroller.TxNotFound, 0, err
+	}
+
+	if status != walletcontroller.TxInChain {
+		return status, 0, nil
+	}
+
+	return status, confirmationsAtHeight(details, app.currentBestBlockHeight.Load()), nil
+}
+
+// confirmationsAtHeight returns number of confirmations of transaction included
+// in chain, given current best block height
+func confirmationsAtHeight(details *notifier.TxConfirmation, bestBlockHeight uint32) int {
+	// our view of the chain tip can lag behind the node for a moment
+	if bestBlockHeight < details.BlockHeight {
+		return 1
+	}
+
+	return int(bestBlockHeight-details.BlockHeight) + 1
+}
+
+// GetCovenantSignatureProgress returns number of covenant signatures gathered
+// by delegation of the staking transaction with given hash, number of signatures
+// required for delegation to become active and hex encoded BIP340 public keys
+// of covenant members which already signed the delegation.
+func (app *StakerApp) GetCovenantSignatureProgress(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+) (gathered, required uint32, signers []string, err error) {
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	di, err := app.babylonClient.QueryDelegationInfo(ctx, stakingTxHash)
+
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	signers = make([]string, len(di.CovenantSigners))
+	for i, pk := range di.CovenantSigners {
+		signers[i] = hex.EncodeToString(schnorr.SerializePubKey(pk))
+	}
+
+	return uint32(len(signers)), params.CovenantQuruomThreshold, signers, nil
+}
+
+// GetDelegationRewards returns stored delegation with given staking transaction
+// hash together with rewards accrued by its staker on babylon and penalty paid
+// if the delegation is slashed.
+func (app *StakerApp) GetDelegationRewards(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+) (*stakerdb.StoredTransaction, *cl.RewardInfo, error) {
+	tx, err := app.txQueries.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rewards, err := app.babylonClient.GetDelegationRewards(ctx, stakingTxHash)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tx, rewards, nil
+}
+
+// delegationState is the state of the delegation along with data which must be
+// stored with this state
+type delegationState struct {
+	state                     proto.TransactionState
+	stakingTxConfirmationInfo *stakerdb.BtcConfirmationInfo
+	unbondingData             *stakerdb.UnbondingStoreData
+}
+
+// determineDelegationState checks btc chain and babylon to find out in which state
+// given delegation should be, when btc chain tip is at bestBlockHeight. It returns
+// nil if state cannot be determined.
+func (app *StakerApp) determineDelegationState(
+	ctx context.Context,
+	tx *stakerdb.StoredTransaction,
+	params *cl.StakingParams,
+	bestBlockHeight uint32,
+) (*delegationState, error) {
+	stakingTxHash := tx.StakingTx.TxHash()
+
+	details, status, err := app.wc.TxDetails(
+		&stakingTxHash,
+		tx.StakingTx.TxOut[tx.StakingOutputIndex].PkScript,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case walletcontroller.TxNotFound:
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+		}).Warn("Staking transaction not found in BTC mempool or chain. Cannot determine its state")
+		return nil, nil
+	case walletcontroller.TxInMemPool:
+		return &delegationState{state: proto.TransactionState_SENT_TO_BTC}, nil
+	}
+
+	stakingTxConfirmationInfo := &stakerdb.BtcConfirmationInfo{
+		Height:    details.BlockHeight,
+		BlockHash: *details.BlockHash,
+	}
+
+	delegationInfo, err := app.babylonClient.QueryDelegationInfo(ctx, &stakingTxHash)
+
+	if err != nil && !errors.Is(err, cl.ErrDelegationNotFound) {
+		return nil, err
+	}
+
+	// prefer unbonding data from babylon, but fallback to local one if babylon
+	// does not have it
+	unbondingData := tx.UnbondingTxData
+
+	if delegationInfo != nil && delegationInfo.UndelegationInfo != nil {
+		unbondingData = &stakerdb.UnbondingStoreData{
+			UnbondingTx:        delegationInfo.UndelegationInfo.UnbondingTransaction,
+			UnbondingTime:      delegationInfo.UndelegationInfo.UnbondingTime,
+			CovenantSignatures: babylonCovSigsToDbSigSigs(delegationInfo.UndelegationInfo.CovenantUnbondingSignatures),
+		}
+	}
+
+	stakingOutputSpent, err := app.wc.OutputSpent(&stakingTxHash, tx.StakingOutputIndex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stakingOutputSpent {
+		if unbondingData == nil {
+			return &delegationState{
+				state:                     proto.TransactionState_SPENT_ON_BTC,
+				stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+			}, nil
+		}
+
+		unbondingTxHash := unbondingData.UnbondingTx.TxHash()
+
+		unbondingDetails, unbondingStatus, err := app.wc.TxDetails(
+			&unbondingTxHash,
+			unbondingData.UnbondingTx.TxOut[0].PkScript,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if unbondingStatus == walletcontroller.TxInMemPool {
+			return &delegationState{
+				state:                     proto.TransactionState_UNBONDING_STARTED,
+				stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+				unbondingData:             unbondingData,
+			}, nil
+		}
+
+		// staking output was spent by something else than unbonding transaction
+		if unbondingStatus != walletcontroller.TxInChain {
+			return &delegationState{
+				state:                     proto.TransactionState_SPENT_ON_BTC,
+				stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+				unbondingData:             unbondingData,
+			}, nil
+		}
+
+		unbondingData.UnbondingTxConfirmationInfo = &stakerdb.BtcConfirmationInfo{
+			Height:    unbondingDetails.BlockHeight,
+			BlockHash: *unbondingDetails.BlockHash,
+		}
+
+		unbondingOutputSpent, err := app.wc.OutputSpent(&unbondingTxHash, 0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		state := proto.TransactionState_UNBONDING_CONFIRMED_ON_BTC
+		if unbondingOutputSpent {
+			state = proto.TransactionState_SPENT_ON_BTC
+		}
+
+		return &delegationState{
+			state:                     state,
+			stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+			unbondingData:             unbondingData,
+		}, nil
+	}
+
+	if delegationInfo == nil {
+		var blockDepth uint32
+		if bestBlockHeight > details.BlockHeight {
+			blockDepth = bestBlockHeight - details.BlockHeight
+		}
+
+		if blockDepth >= app.requiredStakingTxConfirmations(params) {
+			return &delegationState{
+				state:                     proto.TransactionState_CONFIRMED_ON_BTC,
+				stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+			}, nil
+		}
+
+		return &delegationState{state: proto.TransactionState_SENT_TO_BTC}, nil
+	}
+
+	if delegationInfo.UndelegationInfo == nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+		}).Warn("Delegation found on babylon without unbonding data. Cannot determine its state")
+		return nil, nil
+	}
+
+	if len(unbondingData.CovenantSignatures) >= int(params.CovenantQuruomThreshold) {
+		return &delegationState{
+			state:                     proto.TransactionState_DELEGATION_ACTIVE,
+			stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+			unbondingData:             unbondingData,
+		}, nil
+	}
+
+	// covenant signatures are stored only after quorum is reached
+	unbondingData.CovenantSignatures = nil
+
+	return &delegationState{
+		state:                     proto.TransactionState_SENT_TO_BABYLON,
+		stakingTxConfirmationInfo: stakingTxConfirmationInfo,
+		unbondingData:             unbondingData,
+	}, nil
+}
+
+// RepairDelegationStates determines the correct state of every tracked delegation
+// from btc chain and babylon data, and overwrites the stored state wherever it
+// diverges. Every correction is logged. It returns the number of repaired delegations.
+// Repair must run before Start, as started app tracks stored delegations and
+// repair would change them under it. Repaired delegations are picked up by
+// Start, which also resumes unbonding found in mempool. States are determined
+// outside of event loop, and corrections are applied by single command, so
+// that they do not interleave with other commands.
+func (app *StakerApp) RepairDelegationStates(ctx context.Context) (int, error) {
+	if app.started.Load() {
+		return 0, fmt.Errorf("cannot repair delegation states: %w", ErrStakerAppStarted)
+	}
+
+	repairs, err := app.delegationStateRepairs(ctx)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var repaired int
+
+	err = app.runCommand(ctx, "RepairDelegationStates", func(ctx context.Context) error {
+		// app could have been started while states were determined
+		if app.started.Load() {
+			return ErrStakerAppStarted
+		}
+
+		for _, r := range repairs {
+			stakingTxHash := r.observed.StakingTx.TxHash()
+
+			current, err := app.txTracker.GetTransaction(&stakingTxHash)
+
+			if err != nil {
+				return err
+			}
+
+			if current.State != r.observed.State {
+				app.logger.WithFields(logrus.Fields{
+					"stakingTxHash": stakingTxHash,
+					"observedState": r.observed.State,
+					"currentState":  current.State,
+				}).Info("Delegation state changed while determining its correct state, skipping repair")
+				continue
+			}
+
+			if err := app.txTracker.RepairTxState(
+				&stakingTxHash,
+				r.expected.state,
+				r.expected.stakingTxConfirmationInfo,
+				r.expected.unbondingData,
+			); err != nil {
+				return err
+			}
+
+			app.logger.WithFields(logrus.Fields{
+				"stakingTxHash": stakingTxHash,
+				"storedState":   r.observed.State,
+				"repairedState": r.expected.state,
+			}).Info("Repaired delegation state")
+
+			repaired++
+		}
+
+		return nil
+	})
+
+	return repaired, err
+}
+
+// delegationStateRepair is correct state of delegation whose stored state
+// diverges from it
+type delegationStateRepair struct {
+	observed *stakerdb.StoredTransaction
+	expected *delegationState
+}
+
+// delegationStateRepairs returns delegations whose stored state diverges from
+// state determined from btc chain and babylon data
+func (app *StakerApp) delegationStateRepairs(ctx context.Context) ([]*delegationStateRepair, error) {
+	params, err := app.babylonClient.Params(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// app is not started yet, so best block is queried from the node
+	bestBlockHeight, err := app.wc.NodeBestHeight()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get btc best block height: %w", err)
+	}
+
+	var stakingTxHashes []*chainhash.Hash
+
+	// only collect hashes during the scan, as querying nodes inside long running
+	// read transaction would block writers
+	err = app.txTracker.ScanTrackedTransactions(func(tx *stakerdb.StoredTransaction) error {
+		stakingTxHash := tx.StakingTx.TxHash()
+		stakingTxHashes = append(stakingTxHashes, &stakingTxHash)
+		return nil
+	}, func() {
+		stakingTxHashes = make([]*chainhash.Hash, 0)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var repairs []*delegationStateRepair
+
+	for _, stakingTxHash := range stakingTxHashes {
+		tx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+		// prepared delegation could be cancelled since the scan
+		if errors.Is(err, stakerdb.ErrTransactionNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// prepared delegation was never sent to btc, there is nothing to repair
+		if tx.State == proto.TransactionState_PREPARED {
+			continue
+		}
+
+		expected, err := app.determineDelegationState(ctx, tx, params, uint32(bestBlockHeight))
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine state of delegation %s: %w", stakingTxHash, err)
+		}
+
+		if expected == nil || expected.state == tx.State {
+			continue
+		}
+
+		// unbonding tx of delegation whose unbonding started may not be sent
+		// yet, delegation is still active on btc until it is
+		if tx.State == proto.TransactionState_UNBONDING_STARTED &&
+			expected.state == proto.TransactionState_DELEGATION_ACTIVE {
+			continue
+		}
+
+		repairs = append(repairs, &delegationStateRepair{
+			observed: tx,
+			expected: expected,
+		})
+	}
+
+	return repairs, nil
+}
+
+// stakingTxFee computes fee paid by staking transaction funded by wallet wc.
+// Transaction must not be sent yet, so that its inputs are still reported as
+// unspent by the wallet.
+func (app *StakerApp) stakingTxFee(wc walletcontroller.WalletController, tx *wire.MsgTx) (btcutil.Amount, error) {
+	utxos, err := wc.ListOutputs(false)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to list wallet outputs: %w", err)
+	}
+
+	utxoValues := make(map[wire.OutPoint]btcutil.Amount, len(utxos))
+	for _, utxo := range utxos {
+		utxoValues[utxo.OutPoint] = utxo.Amount
+	}
+
+	var inputsValue btcutil.Amount
+	for _, in := range tx.TxIn {
+		value, found := utxoValues[in.PreviousOutPoint]
+
+		if !found {
+			return 0, fmt.Errorf("input %s of staking transaction is not wallet output", in.PreviousOutPoint)
+		}
+
+		inputsValue += value
+	}
+
+	var outputsValue btcutil.Amount
+	for _, out := range tx.TxOut {
+		outputsValue += btcutil.Amount(out.Value)
+	}
+
+	return inputsValue - outputsValue, nil
+}
 
-	sig, err := app.wc.SignBip322NativeSegwit(babylonAddrHash, stakerAddress)
+func (app *StakerApp) ListUnspentOutputs() ([]walletcontroller.Utxo, error) {
+	return app.wc.ListOutputs(false)
+}
 
-	if err != nil {
-		return nil, err
-	}
+// NewAddress returns new wallet address of given type, which can be used as
+// staker address
+func (app *StakerApp) NewAddress(addrType types.AddressType) (btcutil.Address, error) {
+	return app.wc.GetNewAddress(addrType)
+}
 
-	pop, err := cl.NewBabylonBip322Pop(
-		babylonAddrHash,
-		sig,
-		stakerAddress,
-	)
+// ExportUtxoSnapshot writes point in time snapshot of all wallet unspent outputs,
+// including the locked ones, as JSON to provided writer. Snapshot contains
+// the time it was taken at and height of the chain tip known to the staker.
+func (app *StakerApp) ExportUtxoSnapshot(w io.Writer) error {
+	chainTipHeight := app.currentBestBlockHeight.Load()
+
+	utxos, err := app.wc.ListOutputsDetails()
 
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to list wallet outputs: %w", err)
 	}
 
-	stakingInfo, err := staking.BuildStakingInfo(
-		stakerPubKey,
-		fpPks,
-		params.CovenantPks,
-		params.CovenantQuruomThreshold,
-		stakingTimeBlocks,
-		stakingAmount,
-		app.network,
-	)
+	snapshot := UtxoSnapshot{
+		Timestamp:      time.Now().UTC(),
+		ChainTipHeight: chainTipHeight,
+		Utxos:          make([]UtxoSnapshotEntry, len(utxos)),
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to build staking info: %w", err)
+	for i, utxo := range utxos {
+		snapshot.Utxos[i] = UtxoSnapshotEntry{
+			TxHash:        utxo.OutPoint.Hash.String(),
+			OutputIdx:     utxo.OutPoint.Index,
+			Amount:        int64(utxo.Amount),
+			PkScript:      hex.EncodeToString(utxo.PkScript),
+			Address:       utxo.Address,
+			Confirmations: utxo.Confirmations,
+			Spendable:     utxo.Spendable,
+			Locked:        utxo.Locked,
+		}
 	}
 
-	feeRate := app.feeEstimator.EstimateFeePerKb()
+	return json.NewEncoder(w).Encode(&snapshot)
+}
 
-	tx, err := app.wc.CreateAndSignTx([]*wire.TxOut{stakingInfo.StakingOutput}, btcutil.Amount(feeRate), stakerAddress)
+// ExportDelegations writes all delegations tracked by the staker, including
+// watched and prepared ones, to provided writer in versioned binary format.
+// Export can be restored on the same or another machine with ImportDelegations.
+func (app *StakerApp) ExportDelegations(w io.Writer) error {
+	numDelegations, err := app.txTracker.ExportTransactions(w)
 
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to export delegations: %w", err)
 	}
 
 	app.logger.WithFields(logrus.Fields{
-		"stakerAddress": stakerAddress,
-		"stakingAmount": stakingInfo.StakingOutput,
-		"btxTxHash":     tx.TxHash(),
-		"fee":           feeRate,
-	}).Info("Created and signed staking transaction")
-
-	req := newOwnedStakingRequest(
-		stakerAddress,
-		tx,
-		0,
-		stakingInfo.StakingOutput.PkScript,
-		stakingTimeBlocks,
-		stakingAmount,
-		fpPks,
-		params.ConfirmationTimeBlocks,
-		pop,
-	)
+		"numDelegations": numDelegations,
+	}).Info("Exported delegations")
 
-	utils.PushOrQuit[*stakingRequestedEvent](
-		app.stakingRequestedEvChan,
-		req,
-		app.quit,
-	)
-
-	select {
-	case reqErr := <-req.errChan:
-		app.logger.WithFields(logrus.Fields{
-			"stakerAddress": stakerAddress,
-			"err":           reqErr,
-		}).Debugf("Sending staking tx failed")
-
-		return nil, reqErr
-	case hash := <-req.successChan:
-		return hash, nil
-	case <-app.quit:
-		return nil, nil
-	}
+	return nil
 }
 
-func (app *StakerApp) StoredTransactions(limit, offset uint64) (*stakerdb.StoredTransactionQueryResult, error) {
-	query := stakerdb.StoredTransactionQuery{
-		IndexOffset:        offset,
-		NumMaxTransactions: limit,
-		Reversed:           false,
+// ImportDelegations restores delegations written by ExportDelegations. Every
+// record is validated before anything is stored, and import is all or nothing.
+// Delegations already tracked by the staker are not overwritten unless force
+// is set. Import must run before Start, as started app tracks stored delegations
+// and import would change them under it. Imported delegations are picked up by
+// Start, and state change listeners are notified about their imported states.
+// Returns number of imported delegations.
+func (app *StakerApp) ImportDelegations(ctx context.Context, r io.Reader, force bool) (int, error) {
+	if app.started.Load() {
+		return 0, fmt.Errorf("cannot import delegations: %w", ErrStakerAppStarted)
 	}
-	resp, err := app.txTracker.QueryStoredTransactions(query)
+
+	// export is read before it is imported by command, so that slow reader does
+	// not block event loop
+	export, err := io.ReadAll(r)
+
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to read delegations export: %w", err)
 	}
 
-	return &resp, nil
-}
+	var numDelegations int
+
+	err = app.runCommand(ctx, "ImportDelegations", func(ctx context.Context) error {
+		// app could have been started while export was read
+		if app.started.Load() {
+			return ErrStakerAppStarted
+		}
+
+		var err error
+		numDelegations, err = app.txTracker.ImportTransactions(bytes.NewReader(export), force)
+		return err
+	})
 
-func (app *StakerApp) WithdrawableTransactions(limit, offset uint64) (*stakerdb.StoredTransactionQueryResult, error) {
-	query := stakerdb.StoredTransactionQuery{
-		IndexOffset:        offset,
-		NumMaxTransactions: limit,
-		Reversed:           false,
-	}
-	resp, err := app.txTracker.QueryStoredTransactions(query.WithdrawableTransactionsFilter(app.currentBestBlockHeight.Load()))
 	if err != nil {
-		return nil, err
+		return 0, fmt.Errorf("failed to import delegations: %w", err)
 	}
 
-	return &resp, nil
-}
-
-func (app *StakerApp) GetStoredTransaction(txHash *chainhash.Hash) (*stakerdb.StoredTransaction, error) {
-	return app.txTracker.GetTransaction(txHash)
-}
+	app.logger.WithFields(logrus.Fields{
+		"numDelegations": numDelegations,
+		"force":          force,
+	}).Info("Imported delegations")
 
-func (app *StakerApp) ListUnspentOutputs() ([]walletcontroller.Utxo, error) {
-	return app.wc.ListOutputs(false)
+	return numDelegations, nil
 }
 
 func (app *StakerApp) waitForSpendConfirmation(stakingTxHash chainhash.Hash, ev *notifier.ConfirmationEvent) {
@@ -1597,59 +4576,243 @@ func (app *StakerApp) waitForSpendConfirmation(stakingTxHash chainhash.Hash, ev
 // unbonding of his stake.
 // We find in which type of output stake is locked by checking state of staking transaction, and build
 // proper spend transaction based on that state.
-func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash, *btcutil.Amount, error) {
+// Spend transaction pays feeRate in sat/kvB, if feeRate is nil fee rate estimated
+// by btc node is used. Operation is aborted with ctx.Err() if ctx is done before
+// spend transaction is signed or sent.
+func (app *StakerApp) SpendStake(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	feeRate *btcutil.Amount,
+) (*chainhash.Hash, *btcutil.Amount, error) {
+	return app.spendStake(ctx, stakingTxHash, nil, feeRate, nil)
+}
+
+// SpendStakingOutputTo works the same as SpendStake, but recovered funds are sent
+// to destination address instead of back to staker address. Destination must be
+// address on the network staker runs on. Spend transaction pays feeRatePerKb in
+// sat/kvB.
+func (app *StakerApp) SpendStakingOutputTo(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	destination btcutil.Address,
+	feeRatePerKb btcutil.Amount,
+) (*chainhash.Hash, *btcutil.Amount, error) {
+	if destination == nil {
+		return nil, nil, fmt.Errorf("destination address must be provided")
+	}
+
+	return app.spendStake(ctx, stakingTxHash, destination, &feeRatePerKb, nil)
+}
+
+// SpendStakeWithPassphrase works the same as SpendStake, but instead of using
+// passphrase from config, it unlocks the wallet using passphrase supplied by
+// passphraseProvider and locks the wallet again as soon as operation finishes.
+func (app *StakerApp) SpendStakeWithPassphrase(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	feeRate *btcutil.Amount,
+	passphraseProvider PassphraseProvider,
+) (*chainhash.Hash, *btcutil.Amount, error) {
+	if passphraseProvider == nil {
+		return nil, nil, fmt.Errorf("passphrase provider must be provided")
+	}
+
+	return app.spendStake(ctx, stakingTxHash, nil, feeRate, passphraseProvider)
+}
+
+// spendTxFeeRate returns fee rate in sat/kvB of transaction spending stake.
+// Fee rate supplied by caller is checked against estimated fee rate.
+func (app *StakerApp) spendTxFeeRate(feeRate *btcutil.Amount) (btcutil.Amount, error) {
+	estimatedFeeRate := btcutil.Amount(app.feeEstimator.EstimateFeePerKb())
+
+	if feeRate == nil {
+		return estimatedFeeRate, app.feeLimits().CheckFeeRate(estimatedFeeRate)
+	}
+
+	if *feeRate <= 0 {
+		return 0, fmt.Errorf("fee rate must be positive")
+	}
+
+	if err := app.checkFeeRateSanity(*feeRate, estimatedFeeRate); err != nil {
+		return 0, err
+	}
+
+	if err := app.feeLimits().CheckFeeRate(*feeRate); err != nil {
+		return 0, err
+	}
+
+	return *feeRate, nil
+}
+
+func (app *StakerApp) spendStake(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	destAddress btcutil.Address,
+	feeRate *btcutil.Amount,
+	passphraseProvider PassphraseProvider,
+) (*chainhash.Hash, *btcutil.Amount, error) {
+	ctx, span := app.startSpan(
+		ctx,
+		"SpendStake",
+		attribute.String(attrTxHash, stakingTxHash.String()),
+	)
+
+	var spendTxHash *chainhash.Hash
+	var spendTxValue *btcutil.Amount
+
+	req, err := app.buildSpendStakeTx(ctx, stakingTxHash, destAddress, feeRate, passphraseProvider)
+
+	if err == nil {
+		err = app.runCommand(ctx, "SpendStake", func(ctx context.Context) error {
+			var err error
+			spendTxHash, spendTxValue, err = app.sendSpendStakeTx(ctx, req)
+			return err
+		})
+	}
+
+	if spendTxHash != nil {
+		span.SetAttributes(attribute.String(attrSpendTxHash, spendTxHash.String()))
+	}
+
+	if spendTxValue != nil {
+		span.SetAttributes(attribute.Int64(attrAmount, int64(*spendTxValue)))
+	}
+
+	endSpan(span, err)
+
+	return spendTxHash, spendTxValue, err
+}
+
+// spendStakeRequest is signed transaction spending stake of delegation, built
+// from state of delegation observed before it is sent
+type spendStakeRequest struct {
+	observed      *stakerdb.StoredTransaction
+	info          *spendStakeTxInfo
+	stakerAddress btcutil.Address
+	destAddress   btcutil.Address
+}
+
+// buildSpendStakeTx builds and signs transaction spending stake of delegation.
+// It queries babylon and the wallet, so it is run outside of event loop, and
+// built transaction is sent by sendSpendStakeTx.
+func (app *StakerApp) buildSpendStakeTx(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	destAddress btcutil.Address,
+	feeRate *btcutil.Amount,
+	passphraseProvider PassphraseProvider,
+) (*spendStakeRequest, error) {
 	// check we are not shutting down
 	select {
 	case <-app.quit:
-		return nil, nil, nil
+		return nil, ErrStakerAppStopped
 
 	default:
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := app.checkNotWatchOnly("spend stake"); err != nil {
+		return nil, err
+	}
+
 	tx, err := app.txTracker.GetTransaction(stakingTxHash)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String(attrState, tx.State.String()))
+
 	// we cannont spend tx which is watch only.
 	// TODO. To make it possible additional endpoint is needed
 	if tx.Watched {
-		return nil, nil, fmt.Errorf("cannot spend staking which which is in watch only mode")
+		return nil, fmt.Errorf("cannot spend staking which which is in watch only mode")
 	}
 
 	// this coud happen if we stared staker on wrong network.
 	// TODO: consider storing data for different networks in different folders
 	// to avoid this
-	// Currently we spend funds from staking transaction to the same address. This
-	// could be improved by allowing user to specify destination address, although
-	// this destination address would need to control the expcted priv key to sign
-	// transaction
-	destAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
+	stakerAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot spend staking output. Error decoding staker address: %w", err)
+	}
+
+	// by default funds are sent back to staker address, which also controls the
+	// private key required to sign the spend transaction
+	externalDestination := destAddress != nil
+
+	if !externalDestination {
+		destAddress = stakerAddress
+	} else if !destAddress.IsForNet(app.network) {
+		return nil, fmt.Errorf("cannot spend staking output. Destination address %s is not for network %s",
+			destAddress.EncodeAddress(), app.network.Name)
+	}
+
+	if err := app.checkDestinationAllowed(destAddress); err != nil {
+		return nil, fmt.Errorf("cannot spend staking output: %w", err)
+	}
+
+	// staker address belongs to the wallet which funded staking transaction
+	w, err := app.walletForTx(tx)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Error decoding staker address: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output: %w", err)
+	}
+
+	// external destination is not controlled by the wallet, so there is no point
+	// in importing it
+	if !externalDestination {
+		if err := app.trackAddress(w.wc, destAddress); err != nil {
+			return nil, fmt.Errorf("cannot spend staking output. Error importing destination address: %w", err)
+		}
 	}
 
 	destAddressScript, err := txscript.PayToAddrScript(destAddress)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Cannot built destination script: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output. Cannot built destination script: %w", err)
+	}
+
+	_, span := app.startBabylonSpan(ctx, "QueryParams")
+	params, err := app.babylonClient.Params(ctx)
+	endSpan(span, err)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot spend staking output. Error getting params: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	params, err := app.babylonClient.Params()
+	_, span = app.startWalletSpan(ctx, "UnlockWallet")
+	lockWallet, err := w.unlocker.acquire(passphraseProvider)
+	endSpan(span, err)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Error getting params: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output. Error unlocking wallet: %w", err)
 	}
 
-	privKey, err := app.stakerPrivateKey(destAddress)
+	_, span = app.startWalletSpan(ctx, "DumpPrivateKey")
+	privKey, err := w.wc.DumpPrivateKey(stakerAddress)
+	endSpan(span, err)
+
+	// private key is the only thing which requires unlocked wallet
+	lockWallet()
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Error getting private key: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output. Error getting private key: %w", err)
 	}
 
-	currentFeeRate := app.feeEstimator.EstimateFeePerKb()
+	spendFeeRate, err := app.spendTxFeeRate(feeRate)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot spend staking output: %w", err)
+	}
 
 	spendStakeTxInfo, err := createSpendStakeTxFromStoredTx(
 		privKey.PubKey(),
@@ -1657,12 +4820,12 @@ func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash
 		params.CovenantQuruomThreshold,
 		tx,
 		destAddressScript,
-		currentFeeRate,
+		chainfee.SatPerKVByte(spendFeeRate),
 		app.network,
 	)
 
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	stakerSig, err := staking.SignTxWithOneScriptSpendInputFromTapLeaf(
@@ -1673,7 +4836,7 @@ func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash
 	)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Error building signature: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output. Error building signature: %w", err)
 	}
 
 	witness, err := spendStakeTxInfo.fundingOutputSpendInfo.CreateTimeLockPathWitness(
@@ -1681,15 +4844,46 @@ func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash
 	)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("cannot spend staking output. Error building witness: %w", err)
+		return nil, fmt.Errorf("cannot spend staking output. Error building witness: %w", err)
 	}
 
 	spendStakeTxInfo.spendStakeTx.TxIn[0].Witness = witness
 
+	if err := ValidateSpendTx(spendStakeTxInfo.spendStakeTx, spendStakeTxInfo.fundingOutput); err != nil {
+		return nil, fmt.Errorf("cannot spend staking output: %w", err)
+	}
+
+	return &spendStakeRequest{
+		observed:      tx,
+		info:          spendStakeTxInfo,
+		stakerAddress: stakerAddress,
+		destAddress:   destAddress,
+	}, nil
+}
+
+// sendSpendStakeTx sends transaction built by buildSpendStakeTx to btc, unless
+// delegation changed since the transaction was built. Must be run as command.
+func (app *StakerApp) sendSpendStakeTx(
+	ctx context.Context,
+	req *spendStakeRequest,
+) (*chainhash.Hash, *btcutil.Amount, error) {
+	stakingTxHash := req.observed.StakingTx.TxHash()
+	spendStakeTxInfo := req.info
+
+	if err := app.checkDelegationUnchanged(req.observed); err != nil {
+		return nil, nil, fmt.Errorf("cannot spend staking output: %w", err)
+	}
+
 	// We do not check if transaction is spendable i.e the staking time has passed
 	// as this is validated in mempool so in of not meeting this time requirement
 	// we will receive error here: `transaction's sequence locks on inputs not met`
-	spendTxHash, err := app.wc.SendRawTransaction(spendStakeTxInfo.spendStakeTx, true)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	_, span := app.startWalletSpan(ctx, "SendRawTransaction")
+	spendTxHash, err := app.wc.SendRawTransaction(ctx, spendStakeTxInfo.spendStakeTx, true)
+	endSpan(span, err)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot spend staking output. Error sending tx: %w", err)
@@ -1697,13 +4891,34 @@ func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash
 
 	spendTxValue := btcutil.Amount(spendStakeTxInfo.spendStakeTx.TxOut[0].Value)
 
+	// transaction is already sent, so failing to persist its fee is not a reason
+	// to fail whole operation
+	if err := app.txTracker.SetSpendTxFeeInfo(
+		&stakingTxHash,
+		txFeeInfo(spendStakeTxInfo.spendStakeTx, spendStakeTxInfo.calculatedFee),
+	); err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"spendTxHash":   spendTxHash,
+			"err":           err,
+		}).Error("Failed to store fee info of spend stake transaction")
+	}
+
+	if err := app.txTracker.SetSpendTx(&stakingTxHash, spendStakeTxInfo.spendStakeTx); err != nil {
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash": stakingTxHash,
+			"spendTxHash":   spendTxHash,
+			"err":           err,
+		}).Error("Failed to store spend stake transaction")
+	}
+
 	app.logger.WithFields(logrus.Fields{
 		"stakeValue":    btcutil.Amount(spendStakeTxInfo.fundingOutput.Value),
 		"spendTxHash":   spendTxHash,
 		"spendTxValue":  spendTxValue,
 		"fee":           spendStakeTxInfo.calculatedFee,
-		"stakerAddress": destAddress,
-		"destAddress":   destAddress,
+		"stakerAddress": req.stakerAddress,
+		"destAddress":   req.destAddress,
 	}).Infof("Successfully sent transaction spending staking output")
 
 	confEvent, err := app.notifier.RegisterConfirmationsNtfn(
@@ -1722,13 +4937,17 @@ func (app *StakerApp) SpendStake(stakingTxHash *chainhash.Hash) (*chainhash.Hash
 	// tx which will spend this staking output concurrently. In that case the first one
 	// confirmed on btc networks which will mark our staking transaction as spent on BTC network.
 	// TODO: we can reconsider this approach in the future.
-	go app.waitForSpendConfirmation(*stakingTxHash, confEvent)
+	go app.waitForSpendConfirmation(stakingTxHash, confEvent)
 
 	return spendTxHash, &spendTxValue, nil
 }
 
-func (app *StakerApp) ListActiveFinalityProviders(limit uint64, offset uint64) (*cl.FinalityProvidersClientResponse, error) {
-	return app.babylonClient.QueryFinalityProviders(limit, offset)
+func (app *StakerApp) ListActiveFinalityProviders(
+	ctx context.Context,
+	limit uint64,
+	offset uint64,
+) (*cl.FinalityProvidersClientResponse, error) {
+	return app.babylonClient.QueryFinalityProviders(ctx, limit, offset)
 }
 
 // Initiates whole unbonding process. Whole process looks like this:
@@ -1740,9 +4959,23 @@ func (app *StakerApp) ListActiveFinalityProviders(limit uint64, offset uint64) (
 // covenant and finality provider
 // 5. After gathering all signatures, unbonding transaction is sent to bitcoin
 // This function returns control to the caller after step 3. Later is up to the caller
-// to check what is state of unbonding transaction
+// to check what is state of unbonding transaction. If ctx is done before unbonding
+// is started, ctx.Err() is returned.
 func (app *StakerApp) UnbondStaking(
-	stakingTxHash chainhash.Hash, feeRate *btcutil.Amount) (*chainhash.Hash, error) {
+	ctx context.Context, stakingTxHash chainhash.Hash, feeRate *btcutil.Amount) (*chainhash.Hash, error) {
+	var unbondingTxHash *chainhash.Hash
+
+	err := app.runCommand(ctx, "UnbondStaking", func(ctx context.Context) error {
+		var err error
+		unbondingTxHash, err = app.unbondStaking(ctx, stakingTxHash, feeRate)
+		return err
+	})
+
+	return unbondingTxHash, err
+}
+
+func (app *StakerApp) unbondStaking(
+	ctx context.Context, stakingTxHash chainhash.Hash, feeRate *btcutil.Amount) (*chainhash.Hash, error) {
 	// check we are not shutting down
 	select {
 	case <-app.quit:
@@ -1751,6 +4984,14 @@ func (app *StakerApp) UnbondStaking(
 	default:
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := app.checkNotWatchOnly("unbond"); err != nil {
+		return nil, err
+	}
+
 	// 1. Check staking tx is managed by staker program
 	tx, err := app.txTracker.GetTransaction(&stakingTxHash)
 
@@ -1763,8 +5004,10 @@ func (app *StakerApp) UnbondStaking(
 		return nil, fmt.Errorf("cannot unbond watched transaction")
 	}
 
-	if tx.State != proto.TransactionState_DELEGATION_ACTIVE {
-		return nil, fmt.Errorf("cannot unbond transaction which is not active")
+	// unbonding which failed to be sent to btc can be retried
+	if tx.State != proto.TransactionState_DELEGATION_ACTIVE &&
+		tx.State != proto.TransactionState_UNBONDING_STARTED {
+		return nil, fmt.Errorf("cannot unbond transaction in state %s: %w", tx.State, ErrDelegationNotActive)
 	}
 
 	stakerAddress, err := btcutil.DecodeAddress(tx.StakerAddress, app.network)
@@ -1773,15 +5016,110 @@ func (app *StakerApp) UnbondStaking(
 		return nil, fmt.Errorf("error decoding staker address: %s. Err: %v", tx.StakerAddress, err)
 	}
 
-	// TODO: Move this to event handler to avoid somebody starting multiple unbonding routines
-	app.wg.Add(1)
-	go app.sendUnbondingTxToBtcTask(
-		&stakingTxHash,
-		stakerAddress,
-		tx,
-		tx.UnbondingTxData,
-	)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// state is persisted before sending, so that unbonding is resumed after
+	// restart
+	if tx.State == proto.TransactionState_DELEGATION_ACTIVE {
+		if err := app.txTracker.SetTxUnbondingStarted(&stakingTxHash); err != nil {
+			if errors.Is(err, stakerdb.ErrInvalidUnbondingDataUpdate) {
+				return nil, fmt.Errorf("cannot unbond: %w", ErrDelegationNotActive)
+			}
+			return nil, fmt.Errorf("cannot unbond: %w", err)
+		}
+	}
+
+	// unbonding which is still being sent is not started twice
+	if !app.startUnbondingTask(&stakingTxHash, stakerAddress, tx) {
+		return nil, fmt.Errorf("cannot unbond: %w", ErrUnbondingInProgress)
+	}
 
 	unbondingTxHash := tx.UnbondingTxData.UnbondingTx.TxHash()
 	return &unbondingTxHash, nil
 }
+
+// UnbondDelegation unbonds delegation which is active on babylon. Unbonding
+// transaction is built when delegation is sent to babylon, and it is sent to btc
+// together with covenant signatures received from babylon. Returns hash of the
+// unbonding transaction.
+func (app *StakerApp) UnbondDelegation(ctx context.Context, stakingTxHash *chainhash.Hash) (*chainhash.Hash, error) {
+	return app.UnbondStaking(ctx, *stakingTxHash, nil)
+}
+
+// UnbondingBatchResult is the outcome of unbonding single delegation as part of
+// the batch
+type UnbondingBatchResult struct {
+	StakingTxHash   chainhash.Hash
+	UnbondingTxHash *chainhash.Hash
+	Err             error
+}
+
+// UnbondStakingBatch initiates unbonding of every provided delegation. Failure
+// to unbond one delegation does not abort the whole batch, instead result of
+// every delegation is reported in the returned slice, in the same order as
+// provided hashes. Delegations are unbonded one by one, as state changes of
+// unbonding are serialized by event loop anyway. Delegations which were not
+// processed before ctx is done fail with ctx.Err().
+func (app *StakerApp) UnbondStakingBatch(
+	ctx context.Context, stakingTxHashes []*chainhash.Hash, feeRate *btcutil.Amount) []*UnbondingBatchResult {
+	results := make([]*UnbondingBatchResult, len(stakingTxHashes))
+
+	for i, stakingTxHash := range stakingTxHashes {
+		res := &UnbondingBatchResult{StakingTxHash: *stakingTxHash}
+		results[i] = res
+
+		if err := ctx.Err(); err != nil {
+			res.Err = err
+			continue
+		}
+
+		res.UnbondingTxHash, res.Err = app.UnbondStaking(ctx, res.StakingTxHash, feeRate)
+
+		if res.Err != nil {
+			app.logger.WithFields(logrus.Fields{
+				"stakingTxHash": res.StakingTxHash,
+				"err":           res.Err,
+			}).Error("Failed to unbond delegation from batch")
+		}
+	}
+
+	return results
+}
+
+// GetTxInclusionProof returns merkle proof of inclusion of tracked staking
+// transaction in the btc block, along with the header of this block
+func (app *StakerApp) GetTxInclusionProof(txHash *chainhash.Hash) (*MerkleProof, error) {
+	tx, err := app.txTracker.GetTransaction(txHash)
+
+	if err != nil {
+		return nil, err
+	}
+
+	stakingOutput := tx.StakingTx.TxOut[tx.StakingOutputIndex]
+
+	details, status, err := app.wc.TxDetails(txHash, stakingOutput.PkScript)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if status != walletcontroller.TxInChain || details == nil || details.Block == nil {
+		return nil, fmt.Errorf("cannot build inclusion proof of tx %s: %w", txHash, ErrTxNotConfirmed)
+	}
+
+	branch, err := utils.BuildMerkleBranch(details.Block, details.TxIndex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &MerkleProof{
+		BlockHeader:  details.Block.Header,
+		BlockHash:    *details.BlockHash,
+		BlockHeight:  details.BlockHeight,
+		TxIndex:      details.TxIndex,
+		MerkleBranch: branch,
+	}, nil
+}
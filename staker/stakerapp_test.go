@@ -0,0 +1,3415 @@
+package staker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	staking "github.com/babylonchain/babylon/btcstaking"
+	"github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/babylonchain/btc-staker/metrics"
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/babylonchain/btc-staker/utils"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	notifier "github.com/lightningnetwork/lnd/chainntnfs"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func makeTestStore(t *testing.T) *stakerdb.TrackedTransactionStore {
+	cfg := stakercfg.DefaultDBConfig()
+	cfg.DBPath = t.TempDir()
+
+	backend, err := stakercfg.GetDbBackend(&cfg)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		backend.Close()
+	})
+
+	store, err := stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+
+	return store
+}
+
+// testAppDeps are dependencies of staker app created by newTestStakerApp
+type testAppDeps struct {
+	cfg          *stakercfg.Config
+	logger       *logrus.Logger
+	bc           babylonclient.BabylonClient
+	wallet       walletcontroller.WalletController
+	notifier     notifier.ChainNotifier
+	feeEstimator staker.FeeEstimator
+	store        *stakerdb.TrackedTransactionStore
+	msgSender    *babylonclient.BabylonMsgSender
+	metrics      *metrics.StakerMetrics
+	tp           trace.TracerProvider
+}
+
+type testAppOption func(*testAppDeps)
+
+func withConfig(cfg *stakercfg.Config) testAppOption {
+	return func(d *testAppDeps) { d.cfg = cfg }
+}
+
+func withLogger(logger *logrus.Logger) testAppOption {
+	return func(d *testAppDeps) { d.logger = logger }
+}
+
+func withBabylonClient(bc babylonclient.BabylonClient) testAppOption {
+	return func(d *testAppDeps) { d.bc = bc }
+}
+
+func withWallet(wallet walletcontroller.WalletController) testAppOption {
+	return func(d *testAppDeps) { d.wallet = wallet }
+}
+
+func withNotifier(n notifier.ChainNotifier) testAppOption {
+	return func(d *testAppDeps) { d.notifier = n }
+}
+
+func withFeeEstimator(feeEstimator staker.FeeEstimator) testAppOption {
+	return func(d *testAppDeps) { d.feeEstimator = feeEstimator }
+}
+
+func withStore(store *stakerdb.TrackedTransactionStore) testAppOption {
+	return func(d *testAppDeps) { d.store = store }
+}
+
+func withMsgSender(msgSender *babylonclient.BabylonMsgSender) testAppOption {
+	return func(d *testAppDeps) { d.msgSender = msgSender }
+}
+
+func withMetrics(m *metrics.StakerMetrics) testAppOption {
+	return func(d *testAppDeps) { d.metrics = m }
+}
+
+func withTracerProvider(tp trace.TracerProvider) testAppOption {
+	return func(d *testAppDeps) { d.tp = tp }
+}
+
+// newTestStakerApp creates staker app which is not started. Unless overridden
+// by options, app runs on simnet with default config, mock babylon client, mock
+// wallet, fee estimator returning fee rate floor, empty store and not started
+// babylon message sender.
+func newTestStakerApp(t *testing.T, opts ...testAppOption) *staker.StakerApp {
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+
+	deps := &testAppDeps{
+		cfg:          &cfg,
+		logger:       logrus.New(),
+		bc:           babylonclient.GetMockClient(),
+		wallet:       &mockWallet{},
+		feeEstimator: staker.NewStaticBtcFeeEstimator(chainfee.FeePerKwFloor.FeePerKVByte()),
+		metrics:      metrics.NewStakerMetrics(),
+	}
+
+	for _, opt := range opts {
+		opt(deps)
+	}
+
+	if deps.store == nil {
+		deps.store = makeTestStore(t)
+	}
+
+	// app stops message sender when it is stopped
+	if deps.msgSender == nil {
+		deps.msgSender = babylonclient.NewBabylonMsgSender(deps.bc, deps.logger, 1)
+	}
+
+	app, err := staker.NewStakerAppFromDeps(
+		deps.cfg,
+		staker.NewLogrusLogger(deps.logger),
+		deps.bc,
+		deps.wallet,
+		deps.notifier,
+		deps.feeEstimator,
+		deps.store,
+		deps.msgSender,
+		deps.metrics,
+		deps.tp,
+	)
+	require.NoError(t, err)
+
+	return app
+}
+
+func makeTestStakerAddress(t *testing.T) btcutil.Address {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.SimNetParams,
+	)
+	require.NoError(t, err)
+
+	return addr
+}
+
+// mockWallet returns fixed view of the btc chain, all other wallet methods
+// are not implemented
+type mockWallet struct {
+	walletcontroller.WalletController
+	txStatus    walletcontroller.TxStatus
+	txDetails   *notifier.TxConfirmation
+	outputSpent bool
+	pubKey      *btcec.PublicKey
+	signErr     error
+	utxos       []walletcontroller.UtxoDetails
+	balance     btcutil.Amount
+	trackErr    error
+	// addresses passed to TrackAddress
+	trackedAddresses []btcutil.Address
+	// timeout passed to the last wallet unlock, wallet is unlocked also by
+	// background tasks
+	unlockTimeoutSecs atomic.Int64
+	unlockCalls       atomic.Int32
+	lockCalls         atomic.Int32
+	// returned by wallet unlocks
+	unlockErr error
+	// transactions passed to SendRawTransaction
+	sentTxs []*wire.MsgTx
+	// if set, overrides outputSpent for individual outputs
+	spentOutputs map[wire.OutPoint]bool
+	nodeHeight   int64
+	nodeErr      error
+	balanceErr   error
+	// outputs reserved with LockOutputs
+	lockMu        sync.Mutex
+	lockedOutputs map[wire.OutPoint]struct{}
+}
+
+func (w *mockWallet) UnlockWallet(timeoutSecs int64) error {
+	if w.unlockErr != nil {
+		return w.unlockErr
+	}
+	w.unlockTimeoutSecs.Store(timeoutSecs)
+	w.unlockCalls.Add(1)
+	return nil
+}
+
+func (w *mockWallet) UnlockWalletWithPassphrase(passphrase string, timeoutSecs int64) error {
+	if w.unlockErr != nil {
+		return w.unlockErr
+	}
+	w.unlockTimeoutSecs.Store(timeoutSecs)
+	w.unlockCalls.Add(1)
+	return nil
+}
+
+func (w *mockWallet) LockWallet() error {
+	w.lockCalls.Add(1)
+	return nil
+}
+
+func (w *mockWallet) AddressPublicKey(address btcutil.Address) (*btcec.PublicKey, error) {
+	return w.pubKey, nil
+}
+
+func (w *mockWallet) SignBip322NativeSegwit(_ context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	return nil, w.signErr
+}
+
+func (w *mockWallet) TxDetails(txHash *chainhash.Hash, pkScript []byte) (*notifier.TxConfirmation, walletcontroller.TxStatus, error) {
+	return w.txDetails, w.txStatus, nil
+}
+
+func (w *mockWallet) ListOutputsDetails() ([]walletcontroller.UtxoDetails, error) {
+	return w.utxos, nil
+}
+
+func (w *mockWallet) GetBalance() (btcutil.Amount, btcutil.Amount, error) {
+	if w.balanceErr != nil {
+		return 0, 0, w.balanceErr
+	}
+	return w.balance, 0, nil
+}
+
+func (w *mockWallet) NodeBestHeight() (int64, error) {
+	return w.nodeHeight, w.nodeErr
+}
+
+func (w *mockWallet) DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error) {
+	return nil, errors.New("private key not available in mock wallet")
+}
+
+func (w *mockWallet) TrackAddress(address btcutil.Address) error {
+	w.trackedAddresses = append(w.trackedAddresses, address)
+	return w.trackErr
+}
+
+func (w *mockWallet) SignRawTransaction(_ context.Context, tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
+	return tx, true, nil
+}
+
+func (w *mockWallet) SendRawTransaction(_ context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	w.sentTxs = append(w.sentTxs, tx)
+	txHash := tx.TxHash()
+	return &txHash, nil
+}
+
+func (w *mockWallet) LockOutputs(outpoints []wire.OutPoint) error {
+	w.lockMu.Lock()
+	defer w.lockMu.Unlock()
+
+	if w.lockedOutputs == nil {
+		w.lockedOutputs = make(map[wire.OutPoint]struct{})
+	}
+	for _, op := range outpoints {
+		w.lockedOutputs[op] = struct{}{}
+	}
+	return nil
+}
+
+func (w *mockWallet) UnlockOutputs(outpoints []wire.OutPoint) error {
+	w.lockMu.Lock()
+	defer w.lockMu.Unlock()
+
+	for _, op := range outpoints {
+		delete(w.lockedOutputs, op)
+	}
+	return nil
+}
+
+func (w *mockWallet) outputLocked(op wire.OutPoint) bool {
+	w.lockMu.Lock()
+	defer w.lockMu.Unlock()
+
+	_, locked := w.lockedOutputs[op]
+	return locked
+}
+
+func (w *mockWallet) OutputSpent(txHash *chainhash.Hash, outputIdx uint32) (bool, error) {
+	if w.spentOutputs != nil {
+		return w.spentOutputs[*wire.NewOutPoint(txHash, outputIdx)], nil
+	}
+	return w.outputSpent, nil
+}
+
+func makeTestStakingTx() *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(100000, []byte{0x51}))
+	return tx
+}
+
+// mockNotifier reports fixed best block height and records confirmation
+// registrations, confirmations are never delivered
+type mockNotifier struct {
+	notifier.ChainNotifier
+	bestBlockHeight int32
+	// if set, best block epoch and all later epochs are delivered through
+	// this channel
+	epochs chan *notifier.BlockEpoch
+
+	mu sync.Mutex
+	// transactions passed to RegisterConfirmationsNtfn
+	confRegistrations []chainhash.Hash
+}
+
+func (n *mockNotifier) Start() error {
+	return nil
+}
+
+func (n *mockNotifier) Stop() error {
+	return nil
+}
+
+func (n *mockNotifier) RegisterBlockEpochNtfn(_ *notifier.BlockEpoch) (*notifier.BlockEpochEvent, error) {
+	epochs := n.epochs
+	if epochs == nil {
+		epochs = make(chan *notifier.BlockEpoch, 1)
+	}
+	epochs <- &notifier.BlockEpoch{Height: n.bestBlockHeight, Hash: &chainhash.Hash{}}
+
+	return &notifier.BlockEpochEvent{
+		Epochs: epochs,
+		Cancel: func() {},
+	}, nil
+}
+
+func (n *mockNotifier) RegisterConfirmationsNtfn(
+	txid *chainhash.Hash,
+	_ []byte,
+	numConfs, _ uint32,
+	_ ...notifier.NotifierOption,
+) (*notifier.ConfirmationEvent, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.confRegistrations = append(n.confRegistrations, *txid)
+	return notifier.NewConfirmationEvent(numConfs, func() {}), nil
+}
+
+func (n *mockNotifier) registeredConfirmations() []chainhash.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return append([]chainhash.Hash(nil), n.confRegistrations...)
+}
+
+// startAppAfterCrash starts staker app over store which contains staking
+// transaction left in SENT_TO_BTC state by previous run of the app
+func startAppAfterCrash(
+	t *testing.T,
+	bc *babylonclient.MockBabylonClient,
+	wallet *mockWallet,
+	nodeNotifier *mockNotifier,
+) (*staker.StakerApp, *stakerdb.TrackedTransactionStore, *wire.MsgTx) {
+	cfg := stakercfg.DefaultConfig()
+	app, store, stakingTx := makeAppAfterCrash(t, &cfg, bc, wallet, nodeNotifier)
+
+	require.NoError(t, app.Start())
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+
+	return app, store, stakingTx
+}
+
+// makeAppAfterCrash creates not started app whose store contains staking
+// transaction sent to btc
+func makeAppAfterCrash(
+	t *testing.T,
+	cfg *stakercfg.Config,
+	bc *babylonclient.MockBabylonClient,
+	wallet *mockWallet,
+	nodeNotifier *mockNotifier,
+) (*staker.StakerApp, *stakerdb.TrackedTransactionStore, *wire.MsgTx) {
+	store := makeTestStore(t)
+
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.ReadModelRefreshInterval = 0
+	cfg.StakerConfig.ExitOnCriticalError = false
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	logger := logrus.New()
+	app := newTestStakerApp(
+		t,
+		withConfig(cfg),
+		withLogger(logger),
+		withBabylonClient(bc),
+		withWallet(wallet),
+		withNotifier(nodeNotifier),
+		withFeeEstimator(staker.NewStaticBtcFeeEstimator(chainfee.SatPerKVByte(1000))),
+		withStore(store),
+		withMsgSender(babylonclient.NewBabylonMsgSender(bc, logger, 1)),
+	)
+
+	return app, store, stakingTx
+}
+
+func TestStartLocksInputsOfPreparedDelegation(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{txStatus: walletcontroller.TxInMemPool}
+
+	cfg := stakercfg.DefaultConfig()
+	app, store, stakingTx := makeAppAfterCrash(t, &cfg, bc, wallet, &mockNotifier{bestBlockHeight: 100})
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	preparedInput := wire.NewOutPoint(&chainhash.Hash{2}, 1)
+	preparedTx := wire.NewMsgTx(2)
+	preparedTx.AddTxIn(wire.NewTxIn(preparedInput, nil, nil))
+	preparedTx.AddTxOut(wire.NewTxOut(100000, []byte{0x51}))
+
+	err = store.AddPreparedTransaction(
+		preparedTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		makeTestStakerAddress(t),
+		makeTestStakingTx(),
+		bc.GetKeyAddress(),
+		stakerKey.PubKey(),
+		makeTestStakingTx(),
+		makeTestStakingTx(),
+		101,
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, app.Start())
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+
+	// inputs of prepared staking transaction stay reserved across restart,
+	// inputs of staking transaction already sent to btc are not
+	require.True(t, wallet.outputLocked(*preparedInput))
+	require.False(t, wallet.outputLocked(stakingTx.TxIn[0].PreviousOutPoint))
+}
+
+func TestStartRebroadcastsStakingTxDroppedFromMempool(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxNotFound,
+	}
+	nodeNotifier := &mockNotifier{bestBlockHeight: 100}
+
+	_, store, stakingTx := startAppAfterCrash(t, bc, wallet, nodeNotifier)
+	stakingTxHash := stakingTx.TxHash()
+
+	require.Len(t, wallet.sentTxs, 1)
+	require.Equal(t, stakingTxHash, wallet.sentTxs[0].TxHash())
+	require.Equal(t, []chainhash.Hash{stakingTxHash}, nodeNotifier.registeredConfirmations())
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+}
+
+func TestConfirmedStakingTxReorgedOutOfChain(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	// staking transaction is not known to btc node, so it is rebroadcasted
+	// both on startup and after the reorg
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxNotFound,
+	}
+	nodeNotifier := &mockNotifier{
+		bestBlockHeight: 100,
+		epochs:          make(chan *notifier.BlockEpoch, 1),
+	}
+
+	_, store, stakingTx := startAppAfterCrash(t, bc, wallet, nodeNotifier)
+	stakingTxHash := stakingTx.TxHash()
+
+	require.Eventually(t, func() bool {
+		return len(nodeNotifier.registeredConfirmations()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// staking transaction got confirmed in block which is later replaced by
+	// competing chain not containing the transaction
+	err := store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{1}, 101)
+	require.NoError(t, err)
+
+	nodeNotifier.epochs <- &notifier.BlockEpoch{Height: 102, Hash: &chainhash.Hash{2}}
+
+	require.Eventually(t, func() bool {
+		return len(nodeNotifier.registeredConfirmations()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	require.Nil(t, storedTx.StakingTxConfirmationInfo)
+	require.Equal(t, []chainhash.Hash{stakingTxHash, stakingTxHash}, nodeNotifier.registeredConfirmations())
+}
+
+// requireNoDelegationSent fails if delegation is sent to babylon within short
+// period. Mock babylon client blocks sending delegation until it is received
+// from its channel.
+func requireNoDelegationSent(t *testing.T, bc *babylonclient.MockBabylonClient) {
+	select {
+	case msg := <-bc.SentMessages:
+		t.Fatalf("unexpected delegation sent to babylon: %v", msg)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestStartResumesDelegationAlreadySubmittedToBabylon(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	// staker crashed after delegation was submitted to babylon, but before
+	// confirmation of staking transaction was persisted
+	bc.DelegationInfo = &babylonclient.DelegationInfo{
+		UndelegationInfo: &babylonclient.UndelegationInfo{
+			UnbondingTransaction: makeTestStakingTx(),
+			UnbondingTime:        100,
+		},
+	}
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxInChain,
+		txDetails: &notifier.TxConfirmation{
+			BlockHash:   &chainhash.Hash{1},
+			BlockHeight: 100,
+		},
+	}
+	nodeNotifier := &mockNotifier{
+		bestBlockHeight: int32(100 + bc.ClientParams.ConfirmationTimeBlocks),
+	}
+
+	_, store, stakingTx := startAppAfterCrash(t, bc, wallet, nodeNotifier)
+	stakingTxHash := stakingTx.TxHash()
+
+	require.Eventually(t, func() bool {
+		storedTx, err := store.GetTransaction(&stakingTxHash)
+		require.NoError(t, err)
+		return storedTx.State == proto.TransactionState_SENT_TO_BABYLON
+	}, 5*time.Second, 10*time.Millisecond)
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, uint32(100), storedTx.StakingTxConfirmationInfo.Height)
+
+	// delegation is not submitted to babylon again
+	require.Empty(t, wallet.sentTxs)
+	requireNoDelegationSent(t, bc)
+}
+
+func TestStartWaitsForConfiguredStakingTxConfirmations(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxInChain,
+		txDetails: &notifier.TxConfirmation{
+			BlockHash:   &chainhash.Hash{1},
+			BlockHeight: 100,
+		},
+	}
+	// staking transaction is deep enough for babylon, but not deep enough for
+	// configured number of confirmations
+	nodeNotifier := &mockNotifier{
+		bestBlockHeight: int32(100 + bc.ClientParams.ConfirmationTimeBlocks),
+	}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.StakerConfig.MinStakingTxConfirmations = bc.ClientParams.ConfirmationTimeBlocks + 5
+	app, store, stakingTx := makeAppAfterCrash(t, &cfg, bc, wallet, nodeNotifier)
+	require.NoError(t, app.Start())
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+	stakingTxHash := stakingTx.TxHash()
+
+	require.Equal(t, []chainhash.Hash{stakingTxHash}, nodeNotifier.registeredConfirmations())
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	requireNoDelegationSent(t, bc)
+}
+
+func TestStartRefusesStakingTxConfirmationsBelowBabylonParams(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxNotFound,
+	}
+	nodeNotifier := &mockNotifier{bestBlockHeight: 100}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.StakerConfig.MinStakingTxConfirmations = bc.ClientParams.ConfirmationTimeBlocks - 1
+	app, _, _ := makeAppAfterCrash(t, &cfg, bc, wallet, nodeNotifier)
+
+	require.Error(t, app.Start())
+}
+
+func TestRepairDelegationStates(t *testing.T) {
+	tests := []struct {
+		name          string
+		wallet        *mockWallet
+		corruptState  func(store *stakerdb.TrackedTransactionStore, txHash *chainhash.Hash) error
+		expectedState proto.TransactionState
+	}{
+		{
+			name: "tx in mempool marked as spent",
+			wallet: &mockWallet{
+				txStatus: walletcontroller.TxInMemPool,
+			},
+			corruptState: func(store *stakerdb.TrackedTransactionStore, txHash *chainhash.Hash) error {
+				return store.SetTxSpentOnBtc(txHash)
+			},
+			expectedState: proto.TransactionState_SENT_TO_BTC,
+		},
+		{
+			name: "spent tx marked as confirmed",
+			wallet: &mockWallet{
+				txStatus: walletcontroller.TxInChain,
+				txDetails: &notifier.TxConfirmation{
+					BlockHash:   &chainhash.Hash{1},
+					BlockHeight: 100,
+				},
+				outputSpent: true,
+			},
+			corruptState: func(store *stakerdb.TrackedTransactionStore, txHash *chainhash.Hash) error {
+				return store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 100)
+			},
+			expectedState: proto.TransactionState_SPENT_ON_BTC,
+		},
+		{
+			name: "deeply confirmed tx marked as sent",
+			wallet: &mockWallet{
+				txStatus: walletcontroller.TxInChain,
+				txDetails: &notifier.TxConfirmation{
+					BlockHash:   &chainhash.Hash{1},
+					BlockHeight: 100,
+				},
+				// app is not started, so depth is determined from node best block
+				nodeHeight: 200,
+			},
+			corruptState: func(store *stakerdb.TrackedTransactionStore, txHash *chainhash.Hash) error {
+				return nil
+			},
+			expectedState: proto.TransactionState_CONFIRMED_ON_BTC,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			bc := babylonclient.GetMockClient()
+
+			app := newTestStakerApp(
+				t,
+				withBabylonClient(bc),
+				withWallet(tc.wallet),
+				withStore(store),
+			)
+
+			fpPk := bc.ActiveFinalityProvider.BtcPk
+			stakingTx := makeTestStakingTx()
+			stakingTxHash := stakingTx.TxHash()
+
+			err := store.AddTransaction(
+				stakingTx,
+				0,
+				1000,
+				[]*btcec.PublicKey{&fpPk},
+				stakerdb.NewProofOfPossession([]byte{}),
+				makeTestStakerAddress(t),
+				nil,
+			)
+			require.NoError(t, err)
+
+			err = tc.corruptState(store, &stakingTxHash)
+			require.NoError(t, err)
+
+			repaired, err := app.RepairDelegationStates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 1, repaired)
+
+			storedTx, err := store.GetTransaction(&stakingTxHash)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedState, storedTx.State)
+
+			// running repair again should not change anything
+			repaired, err = app.RepairDelegationStates(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, 0, repaired)
+		})
+	}
+
+	// started app tracks stored delegations, so repair is refused
+	startedApp, _, _ := startAppAfterCrash(
+		t,
+		babylonclient.GetMockClient(),
+		&mockWallet{txStatus: walletcontroller.TxInMemPool},
+		&mockNotifier{bestBlockHeight: 100},
+	)
+
+	_, err := startedApp.RepairDelegationStates(context.Background())
+	require.ErrorIs(t, err, staker.ErrStakerAppStarted)
+}
+
+func TestStakeFundsValidation(t *testing.T) {
+	const (
+		duplicateFpWarning = "Staker already has active delegation to finality provider"
+		estimatedFeeRate   = chainfee.SatPerKVByte(10000)
+	)
+
+	minStakingTime := uint16(staker.GetMinStakingTime(babylonclient.GetMockClient().ClientParams))
+	maxStakingTime := minStakingTime + 10
+
+	tests := []struct {
+		name  string
+		setup func(bc *babylonclient.MockBabylonClient, cfg *stakercfg.Config)
+		// existingDelegation adds delegation to the same finality provider
+		// before staking
+		existingDelegation bool
+		amount             btcutil.Amount
+		stakingTime        uint16
+		// feeRate, when set, is passed in StakeOptions.FeeRate
+		feeRate          btcutil.Amount
+		allowDuplicateFp bool
+		// expectErr is the error staking is refused with. If nil, staking
+		// passes validation and stops at signing.
+		expectErr error
+		// notErr, when set, only asserts that staking is not refused with it
+		// as valid request may still be refused by later checks e.g slashing
+		// fee check
+		notErr         error
+		errContains    string
+		logContains    string
+		logNotContains string
+	}{
+		{
+			name: "max active delegations reached",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MaxActiveDelegationsPerStaker = 1
+			},
+			existingDelegation: true,
+			expectErr:          staker.ErrMaxActiveDelegationsReached,
+		},
+		{
+			name: "babylon light client behind",
+			setup: func(bc *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				bc.BtcTipHeight = 90
+				cfg.StakerConfig.MinBabylonBtcTipHeight = 100
+			},
+			expectErr: staker.ErrBabylonLightClientNotReady,
+		},
+		{
+			name: "babylon light client caught up",
+			setup: func(bc *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				bc.BtcTipHeight = 100
+				cfg.StakerConfig.MinBabylonBtcTipHeight = 100
+			},
+		},
+		{
+			name: "duplicate fp delegation warned",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.StakerConfig.ActiveDuplicateFpDelegationPolicy = types.WarnDuplicateFpDelegation
+			},
+			existingDelegation: true,
+			logContains:        duplicateFpWarning,
+		},
+		{
+			name: "duplicate fp delegation refused",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.StakerConfig.ActiveDuplicateFpDelegationPolicy = types.RefuseDuplicateFpDelegation
+			},
+			existingDelegation: true,
+			expectErr:          staker.ErrDuplicateFpDelegation,
+		},
+		{
+			// overridden refusal still warns about duplicate delegation
+			name: "duplicate fp delegation refusal overridden",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.StakerConfig.ActiveDuplicateFpDelegationPolicy = types.RefuseDuplicateFpDelegation
+			},
+			existingDelegation: true,
+			allowDuplicateFp:   true,
+			logContains:        duplicateFpWarning,
+		},
+		{
+			name: "duplicate fp delegation allowed",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.StakerConfig.ActiveDuplicateFpDelegationPolicy = types.AllowDuplicateFpDelegation
+			},
+			existingDelegation: true,
+			logNotContains:     duplicateFpWarning,
+		},
+		{
+			// warned fee rate is used to fund staking transaction
+			name: "fee rate far below estimate warned",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy = types.WarnFeeRateOutOfTolerance
+			},
+			feeRate: 500,
+		},
+		{
+			name: "fee rate far below estimate refused",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy = types.RefuseFeeRateOutOfTolerance
+			},
+			feeRate:   500,
+			expectErr: staker.ErrFeeRateOutOfTolerance,
+		},
+		{
+			name: "fee rate far above estimate refused",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy = types.RefuseFeeRateOutOfTolerance
+			},
+			feeRate:   100000,
+			expectErr: staker.ErrFeeRateOutOfTolerance,
+		},
+		{
+			name: "fee rate within tolerance",
+			setup: func(_ *babylonclient.MockBabylonClient, cfg *stakercfg.Config) {
+				cfg.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy = types.RefuseFeeRateOutOfTolerance
+			},
+			feeRate: 5000,
+		},
+		{
+			name: "amount below dust limit",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MinStakingValue = 0
+			},
+			amount:    329,
+			expectErr: staker.ErrStakingAmountTooLow,
+		},
+		{
+			name: "amount at dust limit",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MinStakingValue = 0
+			},
+			amount: 330,
+			notErr: staker.ErrStakingAmountTooLow,
+		},
+		{
+			name: "amount below babylon minimum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MinStakingValue = 50000
+			},
+			amount:    49999,
+			expectErr: staker.ErrStakingAmountTooLow,
+		},
+		{
+			name: "amount at babylon minimum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MinStakingValue = 50000
+			},
+			amount: 50000,
+			notErr: staker.ErrStakingAmountTooLow,
+		},
+		{
+			name: "staking time below minimum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MaxStakingTime = maxStakingTime
+			},
+			stakingTime: minStakingTime - 1,
+			expectErr:   staker.ErrInvalidStakingTime,
+			errContains: fmt.Sprintf("[%d, %d]", minStakingTime, maxStakingTime),
+		},
+		{
+			name: "staking time at minimum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MaxStakingTime = maxStakingTime
+			},
+			stakingTime: minStakingTime,
+		},
+		{
+			name: "staking time at maximum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MaxStakingTime = maxStakingTime
+			},
+			stakingTime: maxStakingTime,
+		},
+		{
+			name: "staking time above maximum",
+			setup: func(bc *babylonclient.MockBabylonClient, _ *stakercfg.Config) {
+				bc.ClientParams.MaxStakingTime = maxStakingTime
+			},
+			stakingTime: maxStakingTime + 1,
+			expectErr:   staker.ErrInvalidStakingTime,
+			errContains: fmt.Sprintf("[%d, %d]", minStakingTime, maxStakingTime),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			bc := babylonclient.GetMockClient()
+
+			cfg := stakercfg.DefaultConfig()
+			cfg.ActiveNetParams = chaincfg.SimNetParams
+
+			if tc.setup != nil {
+				tc.setup(bc, &cfg)
+			}
+
+			stakerKey, err := btcec.NewPrivateKey()
+			require.NoError(t, err)
+
+			// failing signature stops staking right after request validation
+			signErr := errors.New("signing failed")
+			wallet := &mockWallet{
+				pubKey:  stakerKey.PubKey(),
+				signErr: signErr,
+				balance: 100000,
+			}
+
+			var logs bytes.Buffer
+			logger := logrus.New()
+			logger.SetOutput(&logs)
+
+			app := newTestStakerApp(
+				t,
+				withConfig(&cfg),
+				withLogger(logger),
+				withBabylonClient(bc),
+				withWallet(wallet),
+				withFeeEstimator(staker.NewStaticBtcFeeEstimator(estimatedFeeRate)),
+				withStore(store),
+			)
+
+			stakerAddress := makeTestStakerAddress(t)
+			fpPk := bc.ActiveFinalityProvider.BtcPk
+
+			amount := tc.amount
+			if amount == 0 {
+				amount = 100000
+			}
+
+			stakingTime := tc.stakingTime
+			if stakingTime == 0 {
+				stakingTime = minStakingTime
+			}
+
+			if tc.existingDelegation {
+				err = store.AddTransaction(
+					makeTestStakingTx(),
+					0,
+					stakingTime,
+					[]*btcec.PublicKey{&fpPk},
+					stakerdb.NewProofOfPossession([]byte{}),
+					stakerAddress,
+					nil,
+				)
+				require.NoError(t, err)
+			}
+
+			fpPks := []*btcec.PublicKey{&fpPk}
+
+			opts := staker.StakeOptions{
+				AllowDuplicateFp: tc.allowDuplicateFp,
+				FeeRate:          tc.feeRate,
+			}
+
+			_, err = app.StakeFunds(context.Background(), stakerAddress, amount, fpPks, stakingTime, opts)
+
+			switch {
+			case tc.notErr != nil:
+				require.NotErrorIs(t, err, tc.notErr)
+			case tc.expectErr != nil:
+				require.ErrorIs(t, err, tc.expectErr)
+			default:
+				require.ErrorIs(t, err, signErr)
+			}
+
+			if tc.errContains != "" {
+				require.Contains(t, err.Error(), tc.errContains)
+			}
+
+			if tc.logContains != "" {
+				require.Contains(t, logs.String(), tc.logContains)
+			}
+
+			if tc.logNotContains != "" {
+				require.NotContains(t, logs.String(), tc.logNotContains)
+			}
+		})
+	}
+}
+
+func TestStakeFundsWithConfTarget(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	// failing signature stops staking right after fee rate is estimated
+	signErr := errors.New("signing failed")
+	wallet := &mockWallet{
+		pubKey:  stakerKey.PubKey(),
+		signErr: signErr,
+		balance: 100000,
+	}
+
+	client := &mockSmartFeeClient{
+		results: map[int64]*btcjson.EstimateSmartFeeResult{
+			6: {
+				FeeRate: feeRateBtcPerKvB(0.0002),
+				Blocks:  6,
+			},
+		},
+	}
+
+	feeEstimator := staker.NewBitcoindFeeEstimator(
+		client,
+		string(btcjson.EstimateModeConservative),
+		6,
+		chainfee.SatPerKVByte(1000),
+		chainfee.SatPerKVByte(100000),
+		logrus.New(),
+	)
+
+	app := newTestStakerApp(
+		t,
+		withBabylonClient(bc),
+		withWallet(wallet),
+		withFeeEstimator(feeEstimator),
+	)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	_, err = app.StakeFunds(
+		context.Background(),
+		makeTestStakerAddress(t),
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		uint16(staker.GetMinStakingTime(bc.ClientParams)),
+		staker.StakeOptions{ConfTarget: 6},
+	)
+	require.ErrorIs(t, err, signErr)
+
+	// staking fee rate is estimated by fee estimator for requested target
+	require.Equal(t, []int64{6}, client.requestedTargets)
+
+	// explicit fee rate and confirmation target are mutually exclusive
+	_, err = app.StakeFunds(
+		context.Background(),
+		makeTestStakerAddress(t),
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		uint16(staker.GetMinStakingTime(bc.ClientParams)),
+		staker.StakeOptions{FeeRate: 5000, ConfTarget: 6},
+	)
+	require.ErrorContains(t, err, "cannot be combined")
+	require.Equal(t, []int64{6}, client.requestedTargets)
+}
+
+func TestStakeFundsRefusesWhenFundsInsufficient(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	wallet := &mockWallet{
+		pubKey:  stakerKey.PubKey(),
+		balance: 60000,
+	}
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet))
+
+	spendable, _, err := app.WalletBalance()
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(60000), spendable)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	_, err = app.StakeFunds(
+		context.Background(),
+		makeTestStakerAddress(t),
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		uint16(staker.GetMinStakingTime(bc.ClientParams)),
+		staker.StakeOptions{},
+	)
+	require.ErrorIs(t, err, staker.ErrInsufficientFunds)
+	require.Contains(t, err.Error(), "shortfall 0.00040000 BTC")
+}
+
+func TestStakeFundsFromTaprootStakerAddress(t *testing.T) {
+	wallet, err := walletcontroller.NewMemWalletController(bytes.Repeat([]byte{0x42}, 32), &chaincfg.SimNetParams)
+	require.NoError(t, err)
+
+	segwitAddress, err := wallet.NewAddress()
+	require.NoError(t, err)
+
+	stakerPubKey, err := wallet.AddressPublicKey(segwitAddress)
+	require.NoError(t, err)
+
+	// wallet tracks key path only taproot address of each of its keys
+	stakerAddress, err := btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(stakerPubKey)),
+		&chaincfg.SimNetParams,
+	)
+	require.NoError(t, err)
+
+	_, err = wallet.Fund(stakerAddress, 500000)
+	require.NoError(t, err)
+
+	bc := babylonclient.GetMockClient()
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.ReadModelRefreshInterval = 0
+	nodeNotifier := &mockNotifier{bestBlockHeight: 100}
+
+	app := newTestStakerApp(
+		t,
+		withConfig(&cfg),
+		withBabylonClient(bc),
+		withWallet(wallet),
+		withNotifier(nodeNotifier),
+	)
+	require.NoError(t, app.Start())
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTime := uint16(staker.GetMinStakingTime(bc.ClientParams))
+
+	stakingTxHash, err := app.StakeFunds(
+		context.Background(),
+		stakerAddress,
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		stakingTime,
+		staker.StakeOptions{},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []chainhash.Hash{*stakingTxHash}, nodeNotifier.registeredConfirmations())
+
+	// proof of possession is bip322 key path signature of taproot address
+	tx, err := app.GetStoredTransaction(stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, stakerAddress.EncodeAddress(), tx.StakerAddress)
+	require.Equal(t, uint32(babylonclient.Bip322Type), tx.Pop.BtcSigType)
+
+	// staking output is locked by internal key of taproot address
+	require.NoError(t, app.VerifyDelegationScript(context.Background(), stakingTxHash))
+
+	// legacy address cannot produce bip322 proof of possession
+	legacyAddress, err := btcutil.NewAddressPubKeyHash(
+		btcutil.Hash160(stakerPubKey.SerializeCompressed()),
+		&chaincfg.SimNetParams,
+	)
+	require.NoError(t, err)
+
+	_, err = app.StakeFunds(
+		context.Background(),
+		legacyAddress,
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		stakingTime,
+		staker.StakeOptions{},
+	)
+	require.ErrorIs(t, err, staker.ErrUnsupportedStakerAddress)
+
+	_, err = app.BuildStakingTx(context.Background(), "", legacyAddress, btcutil.Amount(100000), []*btcec.PublicKey{&fpPk}, stakingTime)
+	require.ErrorIs(t, err, staker.ErrUnsupportedStakerAddress)
+}
+
+func TestBuildStakingTxUsesSelectedWallet(t *testing.T) {
+	const walletValue = btcutil.Amount(500000)
+
+	wallet, err := walletcontroller.NewMemWalletController(bytes.Repeat([]byte{0x42}, 32), &chaincfg.SimNetParams)
+	require.NoError(t, err)
+
+	stakerAddress, err := wallet.NewAddress()
+	require.NoError(t, err)
+
+	fundingTx, err := wallet.Fund(stakerAddress, walletValue)
+	require.NoError(t, err)
+
+	bc := babylonclient.GetMockClient()
+	// main wallet is empty, staking transaction can be funded only by
+	// additional wallet
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(&mockWallet{}))
+	require.NoError(t, app.RegisterWallet("treasury", wallet))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingAmount := btcutil.Amount(100000)
+	stakingTime := uint16(staker.GetMinStakingTime(bc.ClientParams))
+
+	_, err = app.BuildStakingTx(context.Background(), "", stakerAddress, stakingAmount, []*btcec.PublicKey{&fpPk}, stakingTime)
+	require.ErrorIs(t, err, staker.ErrInsufficientFunds)
+
+	_, err = app.BuildStakingTx(context.Background(), "unknown", stakerAddress, stakingAmount, []*btcec.PublicKey{&fpPk}, stakingTime)
+	require.ErrorIs(t, err, staker.ErrUnknownWallet)
+
+	preview, err := app.BuildStakingTx(context.Background(), "treasury", stakerAddress, stakingAmount, []*btcec.PublicKey{&fpPk}, stakingTime)
+	require.NoError(t, err)
+
+	fundingOutPoint := wire.OutPoint{Hash: fundingTx.TxHash(), Index: 0}
+	require.Equal(t, []staker.StakingTxInput{{OutPoint: fundingOutPoint, Amount: walletValue}}, preview.Inputs)
+
+	tx := preview.UnsignedTx
+	require.Len(t, tx.TxOut, 2)
+	stakingOutput := tx.TxOut[preview.StakingOutputIdx]
+	require.Equal(t, int64(stakingAmount), stakingOutput.Value)
+	require.Equal(t, preview.StakingOutputPkScript, stakingOutput.PkScript)
+	require.Equal(t, walletValue-stakingAmount-preview.Fee, preview.ChangeAmount)
+	require.Equal(t, txrules.FeeForSerializeSize(preview.FeeRate, int(preview.VSize)), preview.Fee)
+
+	// preview does not lock inputs of staking transaction
+	spendable, err := wallet.ListOutputs(true)
+	require.NoError(t, err)
+	require.Len(t, spendable, 1)
+}
+
+func TestEstimateLifecycleFees(t *testing.T) {
+	const feeRate = btcutil.Amount(2000)
+
+	wallet, err := walletcontroller.NewMemWalletController(bytes.Repeat([]byte{0x42}, 32), &chaincfg.SimNetParams)
+	require.NoError(t, err)
+
+	fundingAddress, err := wallet.NewAddress()
+	require.NoError(t, err)
+
+	_, err = wallet.Fund(fundingAddress, 100000)
+	require.NoError(t, err)
+
+	bc := babylonclient.GetMockClient()
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet))
+
+	stakingAmount := btcutil.Amount(10000)
+	stakingTime := uint16(staker.GetMinStakingTime(bc.ClientParams))
+
+	estimate, err := app.EstimateLifecycleFees(context.Background(), stakingAmount, stakingTime, feeRate)
+	require.NoError(t, err)
+	require.Equal(t, stakingAmount, estimate.StakingAmount)
+	require.Equal(t, feeRate, estimate.FeeRate)
+	// funding fee is paid by transaction actually funded by the wallet, with
+	// single p2wpkh input, staking output and change
+	require.Equal(t, txrules.FeeForSerializeSize(feeRate, int(estimate.FundingTxVSize)), estimate.FundingFee)
+	require.Equal(t, txrules.FeeForSerializeSize(feeRate, int(estimate.WithdrawalTxVSize)), estimate.WithdrawalFee)
+	require.Equal(t, stakingAmount+estimate.FundingFee+estimate.WithdrawalFee, estimate.TotalCost)
+
+	// estimation does not lock wallet outputs, so the same output funds
+	// staking transaction again
+	spendable, err := wallet.ListOutputs(true)
+	require.NoError(t, err)
+	require.Len(t, spendable, 1)
+
+	again, err := app.EstimateLifecycleFees(context.Background(), stakingAmount, stakingTime, feeRate)
+	require.NoError(t, err)
+	require.Equal(t, estimate.FundingFee, again.FundingFee)
+
+	// zero fee rate selects fee rate estimated by fee estimator
+	estimate, err = app.EstimateLifecycleFees(context.Background(), stakingAmount, stakingTime, 0)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(chainfee.FeePerKwFloor.FeePerKVByte()), estimate.FeeRate)
+
+	// wallet cannot fund staking amount
+	_, err = app.EstimateLifecycleFees(context.Background(), 200000, stakingTime, feeRate)
+	require.Error(t, err)
+}
+
+func TestBumpStakingTxFee(t *testing.T) {
+	const (
+		changeValue = 50000
+		parentFee   = 1000
+		parentVSize = 200
+	)
+
+	tests := []struct {
+		name            string
+		txStatus        walletcontroller.TxStatus
+		noChange        bool
+		maxFeeRatePerKb uint64
+		// fee of child transaction of previous bump, zero if fee was not
+		// bumped yet
+		prevChildFee btcutil.Amount
+		expectErr    error
+	}{
+		{name: "staking tx in mempool", txStatus: walletcontroller.TxInMemPool},
+		{name: "previous child replaced", txStatus: walletcontroller.TxInMemPool, prevChildFee: 20000},
+		{name: "staking tx already confirmed", txStatus: walletcontroller.TxInChain, expectErr: staker.ErrStakingTxAlreadyConfirmed},
+		{name: "staking tx without change", txStatus: walletcontroller.TxInMemPool, noChange: true, expectErr: staker.ErrNoChangeOutput},
+		{name: "new fee rate above maximum fee rate", txStatus: walletcontroller.TxInMemPool, maxFeeRatePerKb: 10000, expectErr: walletcontroller.ErrFeeTooHigh},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			wallet := &mockWallet{txStatus: tc.txStatus}
+
+			cfg := stakercfg.DefaultConfig()
+			cfg.ActiveNetParams = chaincfg.SimNetParams
+			if tc.maxFeeRatePerKb > 0 {
+				cfg.BtcNodeBackendConfig.MaxFeeRatePerKb = tc.maxFeeRatePerKb
+			}
+
+			app := newTestStakerApp(t, withConfig(&cfg), withWallet(wallet), withStore(store))
+
+			stakerAddress := makeTestStakerAddress(t)
+			stakerScript, err := txscript.PayToAddrScript(stakerAddress)
+			require.NoError(t, err)
+
+			stakingTx := makeTestStakingTx()
+			// output which is not recorded as change is never spent by child
+			stakingTx.AddTxOut(wire.NewTxOut(changeValue*2, stakerScript[:len(stakerScript)-1]))
+			if !tc.noChange {
+				stakingTx.AddTxOut(wire.NewTxOut(changeValue, stakerScript))
+			}
+			stakingTxHash := stakingTx.TxHash()
+
+			fpKey, err := btcec.NewPrivateKey()
+			require.NoError(t, err)
+
+			funding := &stakerdb.StakingTxFunding{
+				FeeInfo: stakerdb.NewTxFeeInfo(parentFee, parentVSize),
+			}
+
+			if !tc.noChange {
+				funding.Change = &stakerdb.ChangeOutput{
+					OutputIdx: 2,
+					PkScript:  stakerScript,
+				}
+			}
+
+			err = store.AddFundedTransaction(
+				stakingTx,
+				0,
+				100,
+				[]*btcec.PublicKey{fpKey.PubKey()},
+				stakerdb.NewProofOfPossession([]byte{}),
+				stakerAddress,
+				nil,
+				nil,
+				funding,
+			)
+			require.NoError(t, err)
+
+			if tc.prevChildFee > 0 {
+				require.NoError(t, store.SetCpfpTx(&stakingTxHash, &chainhash.Hash{9}, tc.prevChildFee))
+			}
+
+			newFeeRate := btcutil.Amount(20000)
+			childTxHash, err := app.BumpStakingTxFee(context.Background(), &stakingTxHash, newFeeRate)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+				require.Empty(t, wallet.sentTxs)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Len(t, wallet.sentTxs, 1)
+
+			childTx := wallet.sentTxs[0]
+			require.Equal(t, childTx.TxHash(), *childTxHash)
+			require.Len(t, childTx.TxIn, 1)
+			require.Equal(t, *wire.NewOutPoint(&stakingTxHash, 2), childTx.TxIn[0].PreviousOutPoint)
+			require.Equal(t, stakerScript, childTx.TxOut[0].PkScript)
+
+			// parent and child together pay new fee rate
+			childFee := btcutil.Amount(changeValue - childTx.TxOut[0].Value)
+			childVSize := mempool.GetTxVirtualSize(btcutil.NewTx(childTx))
+			packageVSize := parentVSize + childVSize
+			require.GreaterOrEqual(t, (parentFee+childFee)*1000/btcutil.Amount(packageVSize), newFeeRate)
+
+			// replacement pays more than replaced child
+			if tc.prevChildFee > 0 {
+				require.GreaterOrEqual(t, childFee, tc.prevChildFee+txrules.FeeForSerializeSize(staker.MinFeePerKb, int(childVSize)))
+			}
+
+			stored, err := store.GetTransaction(&stakingTxHash)
+			require.NoError(t, err)
+			require.Equal(t, childTxHash, stored.CpfpTxHash)
+			require.Equal(t, childFee, stored.CpfpTxFee)
+		})
+	}
+}
+
+func TestBumpSpendTxFee(t *testing.T) {
+	const (
+		spendFee   = 1000
+		spendVSize = 200
+	)
+
+	tests := []struct {
+		name        string
+		notSpent    bool
+		spentOnBtc  bool
+		txStatus    walletcontroller.TxStatus
+		newFeeRate  btcutil.Amount
+		expectErr   error
+		errContains string
+	}{
+		{name: "stake not spent", notSpent: true, txStatus: walletcontroller.TxInMemPool, newFeeRate: 20000, errContains: "was not spent by staker"},
+		{name: "spend tx already confirmed", txStatus: walletcontroller.TxInChain, newFeeRate: 20000, expectErr: staker.ErrSpendTxAlreadyConfirmed},
+		{name: "stake already spent on btc", spentOnBtc: true, txStatus: walletcontroller.TxInMemPool, newFeeRate: 20000, expectErr: staker.ErrSpendTxAlreadyConfirmed},
+		{name: "spend tx not in mempool", txStatus: walletcontroller.TxNotFound, newFeeRate: 20000, errContains: "not in mempool"},
+		{name: "fee rate not higher", txStatus: walletcontroller.TxInMemPool, newFeeRate: 5000, errContains: "must be higher"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			wallet := &mockWallet{txStatus: tc.txStatus}
+
+			app := newTestStakerApp(t, withWallet(wallet), withStore(store))
+
+			stakerAddress := makeTestStakerAddress(t)
+			stakerScript, err := txscript.PayToAddrScript(stakerAddress)
+			require.NoError(t, err)
+
+			stakingTx := makeTestStakingTx()
+			stakingTxHash := stakingTx.TxHash()
+
+			fpKey, err := btcec.NewPrivateKey()
+			require.NoError(t, err)
+
+			err = store.AddTransaction(
+				stakingTx,
+				0,
+				100,
+				[]*btcec.PublicKey{fpKey.PubKey()},
+				stakerdb.NewProofOfPossession([]byte{}),
+				stakerAddress,
+				nil,
+			)
+			require.NoError(t, err)
+
+			if !tc.notSpent {
+				spendTx := wire.NewMsgTx(2)
+				spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&stakingTxHash, 0), nil, nil))
+				spendTx.AddTxOut(wire.NewTxOut(stakingTx.TxOut[0].Value-spendFee, stakerScript))
+
+				require.NoError(t, store.SetSpendTx(&stakingTxHash, spendTx))
+				require.NoError(t, store.SetSpendTxFeeInfo(&stakingTxHash, stakerdb.NewTxFeeInfo(spendFee, spendVSize)))
+			}
+
+			if tc.spentOnBtc {
+				require.NoError(t, store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{1}, 100))
+				require.NoError(t, store.SetTxSentToBabylon(&stakingTxHash, makeTestStakingTx(), 100))
+				require.NoError(t, store.SetTxSpentOnBtc(&stakingTxHash))
+			}
+
+			replacementTxHash, err := app.BumpSpendTxFee(context.Background(), &stakingTxHash, tc.newFeeRate)
+			require.Error(t, err)
+			require.Nil(t, replacementTxHash)
+			require.Empty(t, wallet.sentTxs)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+			}
+
+			if tc.errContains != "" {
+				require.ErrorContains(t, err, tc.errContains)
+			}
+		})
+	}
+}
+
+func TestLowWalletBalanceAlert(t *testing.T) {
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.LowBalanceThreshold = 100000
+	cfg.StakerConfig.LowBalanceHysteresis = 20000
+
+	wallet := &mockWallet{}
+	m := metrics.NewStakerMetrics()
+
+	app := newTestStakerApp(t, withConfig(&cfg), withWallet(wallet), withMetrics(m))
+
+	steps := []struct {
+		balance        btcutil.Amount
+		expectedAlerts float64
+	}{
+		{150000, 0},
+		// crossing below threshold raises alert
+		{90000, 1},
+		{80000, 1},
+		// above threshold, but within hysteresis, alert is not re-armed
+		{110000, 1},
+		{95000, 1},
+		// recovered above threshold plus hysteresis
+		{125000, 1},
+		// next crossing raises next alert
+		{90000, 2},
+		{130000, 2},
+	}
+
+	for _, step := range steps {
+		wallet.balance = step.balance
+
+		balance, err := app.CheckWalletBalance()
+		require.NoError(t, err)
+		require.Equal(t, step.balance, balance)
+		require.Equal(t, float64(step.balance), testutil.ToFloat64(m.WalletBalance))
+		require.Equal(t, step.expectedAlerts, testutil.ToFloat64(m.LowWalletBalanceAlerts))
+	}
+}
+
+func TestDelegationsByStateMetricFollowsStore(t *testing.T) {
+	store := makeTestStore(t)
+	m := metrics.NewStakerMetrics()
+
+	newTestStakerApp(t, withStore(store), withMetrics(m))
+
+	stateGauge := func(state proto.TransactionState) float64 {
+		return testutil.ToFloat64(m.DelegationsByState.WithLabelValues(state.String()))
+	}
+
+	fpKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err = store.AddTransaction(
+		stakingTx,
+		0,
+		100,
+		[]*btcec.PublicKey{fpKey.PubKey()},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+	require.Equal(t, float64(1), stateGauge(proto.TransactionState_SENT_TO_BTC))
+
+	err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{1}, 10)
+	require.NoError(t, err)
+	require.Equal(t, float64(0), stateGauge(proto.TransactionState_SENT_TO_BTC))
+	require.Equal(t, float64(1), stateGauge(proto.TransactionState_CONFIRMED_ON_BTC))
+
+	// update which does not change state leaves gauges untouched
+	err = store.SetStakingTxFeeInfo(&stakingTxHash, stakerdb.NewTxFeeInfo(1000, 200))
+	require.NoError(t, err)
+	require.Equal(t, float64(1), stateGauge(proto.TransactionState_CONFIRMED_ON_BTC))
+}
+
+func TestStakeFundsProducesSpanTree(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	// failing signature stops staking before any transaction is built
+	signErr := errors.New("signing failed")
+	wallet := &mockWallet{
+		pubKey:  stakerKey.PubKey(),
+		signErr: signErr,
+		balance: 100000,
+	}
+
+	app := newTestStakerApp(
+		t,
+		withBabylonClient(bc),
+		withWallet(wallet),
+		withStore(store),
+		withTracerProvider(tp),
+	)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	_, err = app.StakeFunds(
+		context.Background(),
+		makeTestStakerAddress(t),
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		uint16(staker.GetMinStakingTime(bc.ClientParams)),
+		staker.StakeOptions{},
+	)
+	require.ErrorIs(t, err, signErr)
+
+	spans := exporter.GetSpans()
+
+	var root *tracetest.SpanStub
+	for i := range spans {
+		if spans[i].Name == "StakeFunds" {
+			root = &spans[i]
+		}
+	}
+	require.NotNil(t, root)
+	require.False(t, root.Parent.IsValid())
+	require.Equal(t, codes.Error, root.Status.Code)
+
+	var children []string
+	for _, span := range spans {
+		if span.Name == root.Name {
+			continue
+		}
+
+		require.Equal(t, root.SpanContext.TraceID(), span.SpanContext.TraceID())
+		require.Equal(t, root.SpanContext.SpanID(), span.Parent.SpanID())
+		children = append(children, span.Name)
+	}
+
+	require.Equal(t, []string{
+		"QueryFinalityProvider",
+		"QueryParams",
+		"UnlockWallet",
+		"AddressPublicKey",
+		"SignBip322NativeSegwit",
+	}, children)
+
+	signSpan := spans[len(children)-1]
+	require.Equal(t, "SignBip322NativeSegwit", signSpan.Name)
+	require.Equal(t, codes.Error, signSpan.Status.Code)
+}
+
+func TestCovenantSignatureProgress(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+
+	// committee of 5 members with quorum of 3, where only 2 members signed
+	var covenantPks []*btcec.PublicKey
+	for i := 0; i < 5; i++ {
+		key, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		covenantPks = append(covenantPks, key.PubKey())
+	}
+	bc.ClientParams.CovenantPks = covenantPks
+	bc.ClientParams.CovenantQuruomThreshold = 3
+	bc.DelegationInfo = &babylonclient.DelegationInfo{
+		Active:          false,
+		CovenantSigners: []*btcec.PublicKey{covenantPks[1], covenantPks[3]},
+	}
+
+	app := newTestStakerApp(t, withBabylonClient(bc))
+
+	stakingTxHash := makeTestStakingTx().TxHash()
+
+	gathered, required, signers, err := app.GetCovenantSignatureProgress(context.Background(), &stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), gathered)
+	require.Equal(t, uint32(3), required)
+	require.Equal(t, []string{
+		hex.EncodeToString(schnorr.SerializePubKey(covenantPks[1])),
+		hex.EncodeToString(schnorr.SerializePubKey(covenantPks[3])),
+	}, signers)
+
+	// delegation unknown to babylon
+	bc.DelegationInfo = nil
+	_, _, _, err = app.GetCovenantSignatureProgress(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, babylonclient.ErrDelegationNotFound)
+}
+
+func TestExportUtxoSnapshot(t *testing.T) {
+	stakerAddr := makeTestStakerAddress(t)
+	pkScript, err := txscript.PayToAddrScript(stakerAddr)
+	require.NoError(t, err)
+
+	wallet := &mockWallet{
+		utxos: []walletcontroller.UtxoDetails{
+			{
+				Utxo: walletcontroller.Utxo{
+					Amount:   btcutil.Amount(150000),
+					OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+					PkScript: pkScript,
+					Address:  stakerAddr.EncodeAddress(),
+				},
+				Confirmations: 10,
+				Spendable:     true,
+			},
+			{
+				Utxo: walletcontroller.Utxo{
+					Amount:   btcutil.Amount(2000),
+					OutPoint: *wire.NewOutPoint(&chainhash.Hash{2}, 3),
+					PkScript: pkScript,
+					Address:  stakerAddr.EncodeAddress(),
+				},
+				Confirmations: 0,
+				Locked:        true,
+			},
+		},
+	}
+
+	app := newTestStakerApp(t, withWallet(wallet))
+
+	before := time.Now().UTC()
+
+	var buf bytes.Buffer
+	err = app.ExportUtxoSnapshot(&buf)
+	require.NoError(t, err)
+
+	var snapshot staker.UtxoSnapshot
+	err = json.Unmarshal(buf.Bytes(), &snapshot)
+	require.NoError(t, err)
+
+	require.False(t, snapshot.Timestamp.Before(before.Truncate(time.Second)))
+	require.Equal(t, uint32(0), snapshot.ChainTipHeight)
+	require.Equal(t, []staker.UtxoSnapshotEntry{
+		{
+			TxHash:        chainhash.Hash{1}.String(),
+			OutputIdx:     0,
+			Amount:        150000,
+			PkScript:      hex.EncodeToString(pkScript),
+			Address:       stakerAddr.EncodeAddress(),
+			Confirmations: 10,
+			Spendable:     true,
+			Locked:        false,
+		},
+		{
+			TxHash:        chainhash.Hash{2}.String(),
+			OutputIdx:     3,
+			Amount:        2000,
+			PkScript:      hex.EncodeToString(pkScript),
+			Address:       stakerAddr.EncodeAddress(),
+			Confirmations: 0,
+			Spendable:     false,
+			Locked:        true,
+		},
+	}, snapshot.Utxos)
+}
+
+func TestGetUnbondableDelegations(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakerAddress := makeTestStakerAddress(t)
+
+	// app was not started so best block height is 0, and delegation is past
+	// its time lock if it is less than 5 (finalization timeout) blocks from
+	// the end of staking time
+	tests := []struct {
+		name           string
+		stakingTime    uint16
+		advanceState   func(txHash *chainhash.Hash) error
+		expectEligible bool
+	}{
+		{
+			name:        "confirmed on btc",
+			stakingTime: 1000,
+			advanceState: func(txHash *chainhash.Hash) error {
+				return store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 10)
+			},
+		},
+		{
+			name:        "sent to babylon waiting for covenant signatures",
+			stakingTime: 1000,
+			advanceState: func(txHash *chainhash.Hash) error {
+				if err := store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 10); err != nil {
+					return err
+				}
+				return store.SetTxSentToBabylon(txHash, makeTestStakingTx(), 100)
+			},
+		},
+		{
+			name:        "active",
+			stakingTime: 1000,
+			advanceState: func(txHash *chainhash.Hash) error {
+				if err := store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 10); err != nil {
+					return err
+				}
+				if err := store.SetTxSentToBabylon(txHash, makeTestStakingTx(), 100); err != nil {
+					return err
+				}
+				return store.SetTxUnbondingSignaturesReceived(txHash, []stakerdb.PubKeySigPair{})
+			},
+			expectEligible: true,
+		},
+		{
+			name:        "active past time lock",
+			stakingTime: 3,
+			advanceState: func(txHash *chainhash.Hash) error {
+				if err := store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 0); err != nil {
+					return err
+				}
+				if err := store.SetTxSentToBabylon(txHash, makeTestStakingTx(), 100); err != nil {
+					return err
+				}
+				return store.SetTxUnbondingSignaturesReceived(txHash, []stakerdb.PubKeySigPair{})
+			},
+		},
+		{
+			name:        "unbonding confirmed on btc",
+			stakingTime: 1000,
+			advanceState: func(txHash *chainhash.Hash) error {
+				if err := store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 10); err != nil {
+					return err
+				}
+				if err := store.SetTxSentToBabylon(txHash, makeTestStakingTx(), 100); err != nil {
+					return err
+				}
+				if err := store.SetTxUnbondingSignaturesReceived(txHash, []stakerdb.PubKeySigPair{}); err != nil {
+					return err
+				}
+				return store.SetTxUnbondingConfirmedOnBtc(txHash, &chainhash.Hash{2}, 20)
+			},
+		},
+		{
+			name:        "spent on btc",
+			stakingTime: 1000,
+			advanceState: func(txHash *chainhash.Hash) error {
+				if err := store.SetTxConfirmed(txHash, &chainhash.Hash{1}, 10); err != nil {
+					return err
+				}
+				return store.SetTxSpentOnBtc(txHash)
+			},
+		},
+	}
+
+	var expectedEligible []chainhash.Hash
+
+	for i, tc := range tests {
+		stakingTx := makeTestStakingTx()
+		// make every staking tx unique
+		stakingTx.TxIn[0].PreviousOutPoint.Index = uint32(i)
+		stakingTxHash := stakingTx.TxHash()
+
+		err := store.AddTransaction(
+			stakingTx,
+			0,
+			tc.stakingTime,
+			[]*btcec.PublicKey{&fpPk},
+			stakerdb.NewProofOfPossession([]byte{}),
+			stakerAddress,
+			nil,
+		)
+		require.NoError(t, err)
+
+		err = tc.advanceState(&stakingTxHash)
+		require.NoError(t, err, tc.name)
+
+		if tc.expectEligible {
+			expectedEligible = append(expectedEligible, stakingTxHash)
+		}
+	}
+
+	unbondable, err := app.GetUnbondableDelegations(context.Background())
+	require.NoError(t, err)
+
+	var unbondableHashes []chainhash.Hash
+	for _, tx := range unbondable {
+		unbondableHashes = append(unbondableHashes, tx.StakingTx.TxHash())
+	}
+	require.ElementsMatch(t, expectedEligible, unbondableHashes)
+}
+
+func TestUnbondStakingBatchReportsPartialSuccess(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(
+		t,
+		withBabylonClient(bc),
+		withNotifier(&mockNotifier{}),
+		withStore(store),
+	)
+	// unbonding transactions are sent by background workers, which finish
+	// when app is stopped
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakerAddress := makeTestStakerAddress(t)
+
+	var stakingTxHashes []*chainhash.Hash
+	for i := 0; i < 4; i++ {
+		stakingTx := makeTestStakingTx()
+		stakingTx.TxIn[0].PreviousOutPoint.Index = uint32(i)
+		stakingTxHash := stakingTx.TxHash()
+		stakingTxHashes = append(stakingTxHashes, &stakingTxHash)
+
+		err := store.AddTransaction(
+			stakingTx,
+			0,
+			1000,
+			[]*btcec.PublicKey{&fpPk},
+			stakerdb.NewProofOfPossession([]byte{}),
+			stakerAddress,
+			nil,
+		)
+		require.NoError(t, err)
+		err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{1}, 10)
+		require.NoError(t, err)
+		err = store.SetTxSentToBabylon(&stakingTxHash, makeTestStakingTx(), 100)
+		require.NoError(t, err)
+
+		// third delegation is still waiting for covenant signatures
+		if i == 2 {
+			continue
+		}
+
+		err = store.SetTxUnbondingSignaturesReceived(&stakingTxHash, []stakerdb.PubKeySigPair{})
+		require.NoError(t, err)
+	}
+
+	results := app.UnbondStakingBatch(context.Background(), stakingTxHashes, nil)
+	require.Len(t, results, len(stakingTxHashes))
+
+	for i, res := range results {
+		require.Equal(t, *stakingTxHashes[i], res.StakingTxHash)
+
+		if i == 2 {
+			require.Error(t, res.Err)
+			require.Nil(t, res.UnbondingTxHash)
+			continue
+		}
+
+		require.NoError(t, res.Err)
+		require.NotNil(t, res.UnbondingTxHash)
+	}
+}
+
+func TestUnbondDelegationRequiresActiveDelegation(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+	err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{1}, 10)
+	require.NoError(t, err)
+	err = store.SetTxSentToBabylon(&stakingTxHash, makeTestStakingTx(), 100)
+	require.NoError(t, err)
+
+	// delegation still waits for covenant signatures
+	_, err = app.UnbondDelegation(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrDelegationNotActive)
+
+	err = store.SetTxUnbondingSignaturesReceived(&stakingTxHash, []stakerdb.PubKeySigPair{})
+	require.NoError(t, err)
+
+	unbondingTxHash, err := app.UnbondDelegation(context.Background(), &stakingTxHash)
+	require.NoError(t, err)
+	require.NotNil(t, unbondingTxHash)
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_UNBONDING_STARTED, storedTx.State)
+
+	// unbonding tx is still being sent
+	_, err = app.UnbondDelegation(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrUnbondingInProgress)
+
+	// unbonding which is not being sent anymore can be retried
+	restartedApp := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	retriedTxHash, err := restartedApp.UnbondDelegation(context.Background(), &stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, unbondingTxHash, retriedTxHash)
+
+	err = store.SetTxUnbondingConfirmedOnBtc(&stakingTxHash, &chainhash.Hash{2}, 20)
+	require.NoError(t, err)
+
+	// delegation can be unbonded only once
+	_, err = restartedApp.UnbondDelegation(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrDelegationNotActive)
+}
+
+func TestGetTxInclusionProof(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus: walletcontroller.TxInMemPool,
+	}
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = app.GetTxInclusionProof(&stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrTxNotConfirmed)
+
+	// block with odd number of transactions, so that some nodes of the tree
+	// are hashed with themselves
+	var txs []*btcutil.Tx
+	for i := 0; i < 5; i++ {
+		tx := makeTestStakingTx()
+		tx.TxIn[0].PreviousOutPoint.Index = uint32(i + 1)
+		if i == 3 {
+			tx = stakingTx
+		}
+		txs = append(txs, btcutil.NewTx(tx))
+	}
+	merkleRoot := blockchain.CalcMerkleRoot(txs, false)
+	block := wire.NewMsgBlock(wire.NewBlockHeader(1, &chainhash.Hash{}, &merkleRoot, 0, 0))
+	for _, tx := range txs {
+		require.NoError(t, block.AddTransaction(tx.MsgTx()))
+	}
+	blockHash := block.BlockHash()
+
+	wallet.txStatus = walletcontroller.TxInChain
+	wallet.txDetails = &notifier.TxConfirmation{
+		BlockHash:   &blockHash,
+		BlockHeight: 100,
+		TxIndex:     3,
+		Tx:          stakingTx,
+		Block:       block,
+	}
+
+	proof, err := app.GetTxInclusionProof(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, blockHash, proof.BlockHash)
+	require.Equal(t, blockHash, proof.BlockHeader.BlockHash())
+	require.Equal(t, uint32(3), proof.TxIndex)
+	require.Len(t, proof.MerkleBranch, 3)
+	require.True(t, utils.VerifyMerkleBranch(
+		&stakingTxHash,
+		proof.TxIndex,
+		proof.MerkleBranch,
+		&proof.BlockHeader.MerkleRoot,
+	))
+
+	// proof must not be valid for different position in the block
+	require.False(t, utils.VerifyMerkleBranch(
+		&stakingTxHash,
+		proof.TxIndex+1,
+		proof.MerkleBranch,
+		&proof.BlockHeader.MerkleRoot,
+	))
+}
+
+func TestSpendStakeRefusesNotAllowedDestination(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	stakerAddress := makeTestStakerAddress(t)
+	otherAddress := makeTestStakerAddress(t)
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{otherAddress}
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		stakerAddress,
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.ErrorIs(t, err, staker.ErrDestinationNotAllowed)
+
+	// once staker address is allowed, spending is not refused due to allow-list.
+	// It fails later, as mock wallet cannot provide private key
+	cfg.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{otherAddress, stakerAddress}
+
+	_, _, err = app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, staker.ErrDestinationNotAllowed)
+}
+
+func TestSpendStakingOutputTo(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{}
+
+	stakerAddress := makeTestStakerAddress(t)
+	externalAddress := makeTestStakerAddress(t)
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.WalletConfig.AutoImportAddresses = true
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		stakerAddress,
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = app.SpendStakingOutputTo(context.Background(), &stakingTxHash, nil, 1000)
+	require.Error(t, err)
+
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	mainnetAddress, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.MainNetParams,
+	)
+	require.NoError(t, err)
+
+	_, _, err = app.SpendStakingOutputTo(context.Background(), &stakingTxHash, mainnetAddress, 1000)
+	require.ErrorContains(t, err, "is not for network")
+
+	// external destination is subject to allow-list
+	cfg.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{stakerAddress}
+	_, _, err = app.SpendStakingOutputTo(context.Background(), &stakingTxHash, externalAddress, 1000)
+	require.ErrorIs(t, err, staker.ErrDestinationNotAllowed)
+
+	// once destination is allowed, spending fails later as mock wallet cannot
+	// provide private key. External destination is not imported to the wallet.
+	cfg.WalletConfig.ActiveAllowedDestinations = []btcutil.Address{externalAddress}
+	_, _, err = app.SpendStakingOutputTo(context.Background(), &stakingTxHash, externalAddress, 1000)
+	require.ErrorContains(t, err, "Error getting private key")
+	require.Empty(t, wallet.trackedAddresses)
+}
+
+func TestSubmitPreparedDelegationRefusesNotPreparedTx(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	_, err := app.SubmitPreparedDelegation(context.Background(), &stakingTxHash, nil, nil, nil)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+
+	// delegation created by staker itself is already sent to btc
+	err = store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = app.SubmitPreparedDelegation(context.Background(), &stakingTxHash, nil, nil, nil)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotPrepared)
+}
+
+func TestWatchOnlyMode(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.ActiveWatchOnlyStakerPubKey = stakerKey.PubKey()
+
+	wallet := &mockWallet{pubKey: stakerKey.PubKey(), balance: 100000}
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+	require.Equal(t, stakerKey.PubKey(), app.WatchOnlyStakerKey())
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	// operations requiring staker private key are unavailable
+	_, err = app.StakeFunds(
+		context.Background(),
+		makeTestStakerAddress(t),
+		btcutil.Amount(100000),
+		[]*btcec.PublicKey{&fpPk},
+		uint16(staker.GetMinStakingTime(bc.ClientParams)),
+		staker.StakeOptions{},
+	)
+	require.ErrorIs(t, err, staker.ErrWatchOnlyMode)
+
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	_, err = app.UnbondStaking(context.Background(), stakingTxHash, nil)
+	require.ErrorIs(t, err, staker.ErrWatchOnlyMode)
+
+	_, _, err = app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.ErrorIs(t, err, staker.ErrWatchOnlyMode)
+
+	err = store.AddPreparedTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		makeTestStakerAddress(t),
+		makeTestStakingTx(),
+		bc.GetKeyAddress(),
+		stakerKey.PubKey(),
+		makeTestStakingTx(),
+		makeTestStakingTx(),
+		101,
+	)
+	require.NoError(t, err)
+
+	// delegation cannot be submitted before its staking transaction is signed
+	_, err = app.SubmitPreparedDelegation(context.Background(), &stakingTxHash, nil, nil, nil)
+	require.ErrorIs(t, err, staker.ErrStakingTxNotSigned)
+
+	err = app.SubmitSignedStakingTx(context.Background(), &stakingTxHash, stakingTx)
+	require.ErrorIs(t, err, staker.ErrStakingTxNotSigned)
+
+	otherTx := makeTestStakingTx()
+	otherTx.TxOut[0].Value++
+	otherTx.TxIn[0].Witness = wire.TxWitness{[]byte{0x01}}
+	err = app.SubmitSignedStakingTx(context.Background(), &stakingTxHash, otherTx)
+	require.ErrorIs(t, err, staker.ErrStakingTxNotSigned)
+
+	signedTx := stakingTx.Copy()
+	signedTx.TxIn[0].Witness = wire.TxWitness{[]byte{0x01}}
+	err = app.SubmitSignedStakingTx(context.Background(), &stakingTxHash, signedTx)
+	require.NoError(t, err)
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, signedTx.TxIn[0].Witness, storedTx.StakingTx.TxIn[0].Witness)
+	require.Equal(t, proto.TransactionState_PREPARED, storedTx.State)
+
+	// cancelled delegation is removed and inputs locked when it was prepared
+	// are released
+	stakingInput := stakingTx.TxIn[0].PreviousOutPoint
+	require.NoError(t, wallet.LockOutputs([]wire.OutPoint{stakingInput}))
+	require.NoError(t, app.CancelPreparedDelegation(context.Background(), &stakingTxHash))
+	require.False(t, wallet.outputLocked(stakingInput))
+
+	_, err = store.GetTransaction(&stakingTxHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+
+	err = app.CancelPreparedDelegation(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+}
+
+func TestWalletUnlockedWithConfiguredTimeout(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.WalletConfig.UnlockTimeout = 42 * time.Second
+	cfg.WalletConfig.OperationUnlockTimeout = 7 * time.Second
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	// spending fails after unlocking the wallet, as mock wallet cannot provide
+	// private key
+	_, _, err = app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.Error(t, err)
+	require.Equal(t, int64(42), wallet.unlockTimeoutSecs.Load())
+
+	_, _, err = app.SpendStakeWithPassphrase(context.Background(), &stakingTxHash, nil, func() (string, error) {
+		return "passphrase", nil
+	})
+	require.Error(t, err)
+	require.Equal(t, int64(7), wallet.unlockTimeoutSecs.Load())
+}
+
+func TestWalletLockedAfterOperation(t *testing.T) {
+	tests := []struct {
+		name            string
+		walletPass      string
+		keepUnlocked    bool
+		expectedUnlocks int32
+		expectedLocks   int32
+	}{
+		{name: "unlocked just in time", walletPass: "walletpass", expectedUnlocks: 1, expectedLocks: 1},
+		{name: "kept unlocked", walletPass: "walletpass", keepUnlocked: true, expectedUnlocks: 1, expectedLocks: 0},
+		// wallet is unlocked externally, staker must not lock it
+		{name: "no passphrase", walletPass: "", expectedUnlocks: 0, expectedLocks: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bc := babylonclient.GetMockClient()
+			wallet := &mockWallet{}
+
+			cfg := stakercfg.DefaultConfig()
+			cfg.WalletConfig.WalletPass = tc.walletPass
+			cfg.WalletConfig.KeepWalletUnlocked = tc.keepUnlocked
+			app, _, stakingTx := makeAppAfterCrash(t, &cfg, bc, wallet, &mockNotifier{bestBlockHeight: 100})
+			stakingTxHash := stakingTx.TxHash()
+
+			// spending fails after retrieving private key, as mock wallet
+			// cannot provide it
+			_, _, err := app.SpendStake(context.Background(), &stakingTxHash, nil)
+			require.Error(t, err)
+			require.Equal(t, tc.expectedUnlocks, wallet.unlockCalls.Load())
+			require.Equal(t, tc.expectedLocks, wallet.lockCalls.Load())
+		})
+	}
+}
+
+func TestKeepWalletUnlocked(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{txStatus: walletcontroller.TxNotFound}
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.WalletConfig.KeepWalletUnlocked = true
+	cfg.WalletConfig.UnlockTimeout = time.Second
+	app, _, _ := makeAppAfterCrash(t, &cfg, bc, wallet, &mockNotifier{bestBlockHeight: 100})
+
+	require.NoError(t, app.Start())
+
+	// unlock is renewed before it times out
+	require.Eventually(t, func() bool {
+		return wallet.unlockCalls.Load() >= 3
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Equal(t, int64(1), wallet.unlockTimeoutSecs.Load())
+	require.Equal(t, int32(0), wallet.lockCalls.Load())
+
+	require.NoError(t, app.Stop())
+	require.Equal(t, int32(1), wallet.lockCalls.Load())
+}
+
+func TestWrongWalletPassphraseReported(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{
+		txStatus:  walletcontroller.TxNotFound,
+		unlockErr: walletcontroller.ErrWalletPassphraseIncorrect,
+	}
+
+	cfg := stakercfg.DefaultConfig()
+	app, _, stakingTx := makeAppAfterCrash(t, &cfg, bc, wallet, &mockNotifier{bestBlockHeight: 100})
+	stakingTxHash := stakingTx.TxHash()
+
+	_, _, err := app.SpendStake(context.Background(), &stakingTxHash, nil)
+	require.ErrorIs(t, err, walletcontroller.ErrWalletPassphraseIncorrect)
+	require.Equal(t, int32(0), wallet.lockCalls.Load())
+
+	// daemon keeping the wallet unlocked refuses to start
+	cfg = stakercfg.DefaultConfig()
+	cfg.WalletConfig.KeepWalletUnlocked = true
+	app, _, _ = makeAppAfterCrash(t, &cfg, bc, wallet, &mockNotifier{bestBlockHeight: 100})
+	require.ErrorIs(t, app.Start(), walletcontroller.ErrWalletPassphraseIncorrect)
+}
+
+func TestGetSpendTransactionStatus(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{}
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = app.GetSpendTransactionStatus(&stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrSpendTxNotSent)
+
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&stakingTxHash, 0), nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(9000, stakingTx.TxOut[0].PkScript))
+	require.NoError(t, store.SetSpendTx(&stakingTxHash, spendTx))
+
+	wallet.txStatus = walletcontroller.TxNotFound
+	status, confirmations, err := app.GetSpendTransactionStatus(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, walletcontroller.TxNotFound, status)
+	require.Equal(t, 0, confirmations)
+
+	wallet.txStatus = walletcontroller.TxInMemPool
+	status, confirmations, err = app.GetSpendTransactionStatus(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, walletcontroller.TxInMemPool, status)
+	require.Equal(t, 0, confirmations)
+
+	blockHash := chainhash.Hash{1}
+	wallet.txStatus = walletcontroller.TxInChain
+	wallet.txDetails = &notifier.TxConfirmation{
+		BlockHash:   &blockHash,
+		BlockHeight: 0,
+		Tx:          spendTx,
+	}
+	status, confirmations, err = app.GetSpendTransactionStatus(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, walletcontroller.TxInChain, status)
+	require.Equal(t, 1, confirmations)
+
+	// spend transaction does not change state of delegation until it is deep
+	// enough
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, spendTx.TxHash(), storedTx.SpendTx.TxHash())
+	require.NotEqual(t, proto.TransactionState_SPENT_ON_BTC, storedTx.State)
+}
+
+func TestGetWithdrawalDestinations(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{spentOutputs: map[wire.OutPoint]bool{}}
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = app.GetWithdrawalDestinations(&stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrSpendTxNotSent)
+
+	addresses := []btcutil.Address{makeTestStakerAddress(t), makeTestStakerAddress(t)}
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&stakingTxHash, 0), nil, nil))
+	for i, addr := range addresses {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		spendTx.AddTxOut(wire.NewTxOut(int64(5000*(i+1)), pkScript))
+	}
+	spendTxHash := spendTx.TxHash()
+	require.NoError(t, store.SetSpendTx(&stakingTxHash, spendTx))
+
+	checkDestinations := func(expectedSpent ...bool) {
+		destinations, err := app.GetWithdrawalDestinations(&stakingTxHash)
+		require.NoError(t, err)
+		require.Len(t, destinations, len(spendTx.TxOut))
+
+		for i, out := range spendTx.TxOut {
+			require.Equal(t, uint32(i), destinations[i].OutputIdx)
+			require.Equal(t, addresses[i].EncodeAddress(), destinations[i].Address)
+			require.Equal(t, out.Value, destinations[i].Amount)
+			require.Equal(t, expectedSpent[i], destinations[i].Spent)
+		}
+	}
+
+	// outputs of spend transaction in mempool are never reported as spent
+	wallet.txStatus = walletcontroller.TxInMemPool
+	wallet.spentOutputs[*wire.NewOutPoint(&spendTxHash, 0)] = true
+	checkDestinations(false, false)
+
+	wallet.txStatus = walletcontroller.TxInChain
+	checkDestinations(true, false)
+
+	wallet.spentOutputs[*wire.NewOutPoint(&spendTxHash, 1)] = true
+	checkDestinations(true, true)
+}
+
+func TestGetLinkedDelegations(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	// staking tx spending given outputs, with staking output and change output
+	makeStakingTx := func(inputs ...*wire.OutPoint) *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		for _, in := range inputs {
+			tx.AddTxIn(wire.NewTxIn(in, nil, nil))
+		}
+		tx.AddTxOut(wire.NewTxOut(100000, []byte{0x51}))
+		tx.AddTxOut(wire.NewTxOut(50000, []byte{0x52}))
+		return tx
+	}
+
+	addTx := func(tx *wire.MsgTx) *chainhash.Hash {
+		err := store.AddTransaction(
+			tx,
+			0,
+			1000,
+			[]*btcec.PublicKey{&fpPk},
+			stakerdb.NewProofOfPossession([]byte{}),
+			makeTestStakerAddress(t),
+			nil,
+		)
+		require.NoError(t, err)
+		txHash := tx.TxHash()
+		return &txHash
+	}
+
+	// a -> b -> c share change chain
+	txA := makeStakingTx(wire.NewOutPoint(&chainhash.Hash{1}, 0))
+	hashA := addTx(txA)
+	txB := makeStakingTx(wire.NewOutPoint(hashA, 1))
+	hashB := addTx(txB)
+	hashC := addTx(makeStakingTx(wire.NewOutPoint(hashB, 1)))
+
+	// d is funded by withdrawal of c
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hashC, 0), nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(99000, []byte{0x53}))
+	require.NoError(t, store.SetSpendTx(hashC, spendTx))
+	spendTxHash := spendTx.TxHash()
+	hashD := addTx(makeStakingTx(wire.NewOutPoint(&spendTxHash, 0)))
+
+	// e and f are funded from different outputs of the same transaction
+	hashE := addTx(makeStakingTx(wire.NewOutPoint(&chainhash.Hash{2}, 0)))
+	hashF := addTx(makeStakingTx(wire.NewOutPoint(&chainhash.Hash{2}, 1)))
+
+	// g is not linked to anything
+	hashG := addTx(makeStakingTx(wire.NewOutPoint(&chainhash.Hash{3}, 0)))
+
+	linkedHashes := func(txHash *chainhash.Hash) []chainhash.Hash {
+		linked, err := app.GetLinkedDelegations(txHash)
+		require.NoError(t, err)
+		var hashes []chainhash.Hash
+		for _, tx := range linked {
+			hashes = append(hashes, tx.StakingTx.TxHash())
+		}
+		return hashes
+	}
+
+	require.Equal(t, []chainhash.Hash{*hashB, *hashC, *hashD}, linkedHashes(hashA))
+	require.Equal(t, []chainhash.Hash{*hashA, *hashB, *hashC}, linkedHashes(hashD))
+	require.Equal(t, []chainhash.Hash{*hashF}, linkedHashes(hashE))
+	require.Empty(t, linkedHashes(hashG))
+
+	_, err = app.GetLinkedDelegations(&chainhash.Hash{4})
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+}
+
+func TestSpendStakeImportsDestinationAddress(t *testing.T) {
+	importErr := errors.New("import failed")
+
+	tests := []struct {
+		name          string
+		autoImport    bool
+		trackErr      error
+		expectTracked bool
+		expectErr     error
+	}{
+		{
+			name: "import disabled",
+		},
+		{
+			name:          "import enabled",
+			autoImport:    true,
+			expectTracked: true,
+		},
+		{
+			name:          "backend cannot import addresses",
+			autoImport:    true,
+			trackErr:      walletcontroller.ErrUnsupportedByBackend,
+			expectTracked: true,
+		},
+		{
+			name:          "import failed",
+			autoImport:    true,
+			trackErr:      importErr,
+			expectTracked: true,
+			expectErr:     importErr,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			bc := babylonclient.GetMockClient()
+			wallet := &mockWallet{trackErr: tc.trackErr}
+
+			cfg := stakercfg.DefaultConfig()
+			cfg.ActiveNetParams = chaincfg.SimNetParams
+			cfg.WalletConfig.AutoImportAddresses = tc.autoImport
+
+			app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withWallet(wallet), withStore(store))
+
+			fpPk := bc.ActiveFinalityProvider.BtcPk
+			stakingTx := makeTestStakingTx()
+			stakingTxHash := stakingTx.TxHash()
+			stakerAddress := makeTestStakerAddress(t)
+
+			err := store.AddTransaction(
+				stakingTx,
+				0,
+				1000,
+				[]*btcec.PublicKey{&fpPk},
+				stakerdb.NewProofOfPossession([]byte{}),
+				stakerAddress,
+				nil,
+			)
+			require.NoError(t, err)
+
+			// spending always fails, as mock wallet cannot provide private key
+			_, _, err = app.SpendStake(context.Background(), &stakingTxHash, nil)
+			require.Error(t, err)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+			} else {
+				require.ErrorContains(t, err, "private key")
+			}
+
+			if tc.expectTracked {
+				require.Len(t, wallet.trackedAddresses, 1)
+				require.Equal(t, stakerAddress.EncodeAddress(), wallet.trackedAddresses[0].EncodeAddress())
+			} else {
+				require.Empty(t, wallet.trackedAddresses)
+			}
+		})
+	}
+}
+
+func TestGetDelegationRewards(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	_, _, err := app.GetDelegationRewards(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+
+	err = store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, _, err = app.GetDelegationRewards(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, babylonclient.ErrRewardsNotSupported)
+
+	bc.Rewards = &babylonclient.RewardInfo{SlashingPenalty: btcutil.Amount(100)}
+
+	tx, rewards, err := app.GetDelegationRewards(context.Background(), &stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, stakingTxHash, tx.StakingTx.TxHash())
+	require.Equal(t, bc.Rewards, rewards)
+}
+
+func TestMempoolResidenceEscalatesFeeBump(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	wallet := &mockWallet{txStatus: walletcontroller.TxInMemPool}
+	m := metrics.NewStakerMetrics()
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.MaxMempoolResidence = 30 * time.Minute
+
+	app := newTestStakerApp(
+		t,
+		withConfig(&cfg),
+		withBabylonClient(bc),
+		withWallet(wallet),
+		withStore(store),
+		withMetrics(m),
+	)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	steps := []struct {
+		elapsed            time.Duration
+		status             walletcontroller.TxStatus
+		expectEscalated    bool
+		expectedEscalation float64
+	}{
+		// first seen in mempool
+		{0, walletcontroller.TxInMemPool, false, 0},
+		{29 * time.Minute, walletcontroller.TxInMemPool, false, 0},
+		{30 * time.Minute, walletcontroller.TxInMemPool, true, 1},
+		// escalation is raised once per residence period
+		{45 * time.Minute, walletcontroller.TxInMemPool, false, 1},
+		{61 * time.Minute, walletcontroller.TxInMemPool, true, 2},
+		// evicted from mempool and later seen again, residence is counted anew
+		{62 * time.Minute, walletcontroller.TxNotFound, false, 2},
+		{70 * time.Minute, walletcontroller.TxInMemPool, false, 2},
+		{95 * time.Minute, walletcontroller.TxInMemPool, false, 2},
+		{100 * time.Minute, walletcontroller.TxInMemPool, true, 3},
+	}
+
+	for _, step := range steps {
+		wallet.txStatus = step.status
+
+		escalated, err := app.CheckMempoolResidence(context.Background(), start.Add(step.elapsed))
+		require.NoError(t, err)
+
+		if step.expectEscalated {
+			require.Len(t, escalated, 1)
+			require.Equal(t, stakingTxHash, *escalated[0])
+		} else {
+			require.Empty(t, escalated)
+		}
+
+		require.Equal(t, step.expectedEscalation, testutil.ToFloat64(m.FeeBumpEscalations))
+	}
+}
+
+func TestMempoolResidenceAutoFeeBump(t *testing.T) {
+	const (
+		changeValue = 50000
+		parentFee   = 1000
+		parentVSize = 200
+	)
+
+	tests := []struct {
+		name        string
+		autoFeeBump bool
+	}{
+		{name: "auto fee bump disabled"},
+		{name: "auto fee bump enabled", autoFeeBump: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			store := makeTestStore(t)
+			wallet := &mockWallet{txStatus: walletcontroller.TxInMemPool}
+
+			cfg := stakercfg.DefaultConfig()
+			cfg.ActiveNetParams = chaincfg.SimNetParams
+			cfg.StakerConfig.MaxMempoolResidence = 30 * time.Minute
+			cfg.StakerConfig.AutoFeeBump = tc.autoFeeBump
+
+			app := newTestStakerApp(t, withConfig(&cfg), withWallet(wallet), withStore(store))
+
+			stakerAddress := makeTestStakerAddress(t)
+			stakerScript, err := txscript.PayToAddrScript(stakerAddress)
+			require.NoError(t, err)
+
+			stakingTx := makeTestStakingTx()
+			stakingTx.AddTxOut(wire.NewTxOut(changeValue, stakerScript))
+			stakingTxHash := stakingTx.TxHash()
+
+			fpKey, err := btcec.NewPrivateKey()
+			require.NoError(t, err)
+
+			err = store.AddTransaction(
+				stakingTx,
+				0,
+				100,
+				[]*btcec.PublicKey{fpKey.PubKey()},
+				stakerdb.NewProofOfPossession([]byte{}),
+				stakerAddress,
+				nil,
+			)
+			require.NoError(t, err)
+			require.NoError(t, store.SetStakingTxFeeInfo(&stakingTxHash, stakerdb.NewTxFeeInfo(parentFee, parentVSize)))
+
+			start := time.Now()
+			_, err = app.CheckMempoolResidence(context.Background(), start)
+			require.NoError(t, err)
+			require.Empty(t, wallet.sentTxs)
+
+			escalated, err := app.CheckMempoolResidence(context.Background(), start.Add(30*time.Minute))
+			require.NoError(t, err)
+			require.Len(t, escalated, 1)
+
+			stored, err := store.GetTransaction(&stakingTxHash)
+			require.NoError(t, err)
+
+			if !tc.autoFeeBump {
+				require.Empty(t, wallet.sentTxs)
+				require.Nil(t, stored.CpfpTxHash)
+				return
+			}
+
+			require.Len(t, wallet.sentTxs, 1)
+			childTx := wallet.sentTxs[0]
+			require.Equal(t, *wire.NewOutPoint(&stakingTxHash, 1), childTx.TxIn[0].PreviousOutPoint)
+			require.Equal(t, childTx.TxHash(), *stored.CpfpTxHash)
+
+			// first escalation raises fee rate of the package by a quarter
+			childFee := btcutil.Amount(changeValue - childTx.TxOut[0].Value)
+			packageVSize := parentVSize + mempool.GetTxVirtualSize(btcutil.NewTx(childTx))
+			parentFeeRate := btcutil.Amount(parentFee * 1000 / parentVSize)
+			require.GreaterOrEqual(t, (parentFee+childFee)*1000/btcutil.Amount(packageVSize), parentFeeRate*5/4)
+		})
+	}
+}
+
+func TestGetBabylonSubmissionPayload(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = app.GetBabylonSubmissionPayload(&stakingTxHash)
+	require.ErrorIs(t, err, staker.ErrBabylonSubmissionPayloadNotFound)
+
+	payload := []byte{1, 2, 3, 4}
+	require.NoError(t, store.SetBabylonSubmissionPayload(&stakingTxHash, payload))
+
+	stored, err := app.GetBabylonSubmissionPayload(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, payload, stored)
+}
+
+func TestReadModelFollowsDelegationStates(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	addTx := func(tx *wire.MsgTx) *chainhash.Hash {
+		err := store.AddTransaction(
+			tx,
+			0,
+			1000,
+			[]*btcec.PublicKey{&fpPk},
+			stakerdb.NewProofOfPossession([]byte{}),
+			makeTestStakerAddress(t),
+			nil,
+		)
+		require.NoError(t, err)
+		txHash := tx.TxHash()
+		return &txHash
+	}
+
+	// read model must always match views delivered to subscriber
+	requireConsistent := func(sub *staker.ReadModelSubscription, expected ...staker.DelegationView) {
+		for _, view := range expected {
+			select {
+			case update := <-sub.Updates:
+				require.Equal(t, view, update)
+			case <-time.After(time.Second):
+				t.Fatalf("read model update not received")
+			}
+		}
+
+		views, err := app.GetReadModel()
+		require.NoError(t, err)
+
+		for _, view := range expected {
+			require.Contains(t, views, view)
+		}
+	}
+
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := addTx(stakingTx)
+
+	views, err := app.GetReadModel()
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	view := views[0]
+	require.Equal(t, stakingTxHash.String(), view.StakingTxHash)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC.String(), view.State)
+	require.Equal(t, btcutil.Amount(100000), view.StakingValue)
+	require.Equal(t, uint64(1), view.Version)
+
+	sub, err := app.SubscribeReadModel()
+	require.NoError(t, err)
+	defer sub.Cancel()
+
+	err = store.SetTxConfirmed(stakingTxHash, &chainhash.Hash{}, 120)
+	require.NoError(t, err)
+
+	view.State = proto.TransactionState_CONFIRMED_ON_BTC.String()
+	view.StakingTxConfirmationHeight = 120
+	view.Version = 2
+	requireConsistent(sub, view)
+
+	unbondingTx := makeTestStakingTx()
+	unbondingTx.TxIn[0].PreviousOutPoint = wire.OutPoint{Hash: *stakingTxHash, Index: 0}
+	err = store.SetTxSentToBabylon(stakingTxHash, unbondingTx, 100)
+	require.NoError(t, err)
+
+	view.State = proto.TransactionState_SENT_TO_BABYLON.String()
+	view.UnbondingTxHash = unbondingTx.TxHash().String()
+	view.Version = 3
+	requireConsistent(sub, view)
+
+	// new delegation is delivered to subscriber as well
+	secondTx := makeTestStakingTx()
+	secondTx.TxIn[0].PreviousOutPoint.Index = 1
+	secondTxHash := addTx(secondTx)
+
+	views, err = app.GetReadModel()
+	require.NoError(t, err)
+	require.Len(t, views, 2)
+	require.Equal(t, secondTxHash.String(), views[1].StakingTxHash)
+	require.Equal(t, uint64(1), views[1].Version)
+	require.Equal(t, view, views[0])
+	requireConsistent(sub, views[1])
+}
+
+func TestSubscribeStateUpdates(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+	stakingTxHash := stakingTx.TxHash()
+
+	updates, cancel := app.SubscribeStateUpdates()
+	// subscriber which never reads updates must not block state transitions
+	_, cancelSlow := app.SubscribeStateUpdates()
+	defer cancelSlow()
+
+	requireUpdate := func(oldState, newState proto.TransactionState) {
+		select {
+		case update := <-updates:
+			require.Equal(t, staker.DelegationStateUpdate{
+				StakingTxHash: stakingTxHash,
+				OldState:      oldState,
+				NewState:      newState,
+			}, update)
+		case <-time.After(time.Second):
+			t.Fatalf("state update not received")
+		}
+	}
+
+	err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{}, 1)
+	require.NoError(t, err)
+	requireUpdate(proto.TransactionState_SENT_TO_BTC, proto.TransactionState_CONFIRMED_ON_BTC)
+
+	// updates which do not change state are not emitted
+	err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{}, 1)
+	require.NoError(t, err)
+	require.Empty(t, updates)
+
+	// overflow buffer of the subscriber which does not read updates
+	for i := 0; i < 60; i++ {
+		require.NoError(t, store.SetTxSpentOnBtc(&stakingTxHash))
+		require.NoError(t, store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{}, 1))
+	}
+
+	requireUpdate(proto.TransactionState_CONFIRMED_ON_BTC, proto.TransactionState_SPENT_ON_BTC)
+
+	cancel()
+	// drain buffered updates, channel must be closed after them
+	for range updates {
+	}
+	cancel()
+}
+
+func TestImportDelegations(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+
+	exportedStore := makeTestStore(t)
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+	err := exportedStore.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+	err = exportedStore.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{}, 1)
+	require.NoError(t, err)
+
+	var export bytes.Buffer
+	_, err = exportedStore.ExportTransactions(&export)
+	require.NoError(t, err)
+
+	store := makeTestStore(t)
+	app := newTestStakerApp(t, withBabylonClient(bc), withNotifier(&mockNotifier{}), withStore(store))
+	t.Cleanup(func() {
+		require.NoError(t, app.Stop())
+	})
+
+	updates, cancel := app.SubscribeStateUpdates()
+	defer cancel()
+
+	numImported, err := app.ImportDelegations(context.Background(), bytes.NewReader(export.Bytes()), false)
+	require.NoError(t, err)
+	require.Equal(t, 1, numImported)
+
+	storedTx, err := store.GetTransaction(&stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, storedTx.State)
+
+	// subscribers learn about imported state
+	select {
+	case update := <-updates:
+		require.Equal(t, staker.DelegationStateUpdate{
+			StakingTxHash: stakingTxHash,
+			OldState:      proto.TransactionState_SENT_TO_BTC,
+			NewState:      proto.TransactionState_CONFIRMED_ON_BTC,
+		}, update)
+	case <-time.After(time.Second):
+		t.Fatalf("state update not received")
+	}
+
+	// started app tracks stored delegations, so import is refused
+	startedApp, _, _ := startAppAfterCrash(
+		t,
+		bc,
+		&mockWallet{txStatus: walletcontroller.TxInMemPool},
+		&mockNotifier{bestBlockHeight: 100},
+	)
+
+	_, err = startedApp.ImportDelegations(context.Background(), bytes.NewReader(export.Bytes()), false)
+	require.ErrorIs(t, err, staker.ErrStakerAppStarted)
+}
+
+func TestCheckTimelockExpiries(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	cfg.StakerConfig.TimelockExpiryNotice = 6
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withStore(store))
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	err := store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+	stakingTxHash := stakingTx.TxHash()
+
+	notifications, cancel := app.SubscribeTimelockExpiries()
+	defer cancel()
+
+	// unconfirmed staking transaction has no known expiry
+	emitted, err := app.CheckTimelockExpiries(2000)
+	require.NoError(t, err)
+	require.Empty(t, emitted)
+
+	err = store.SetTxConfirmed(&stakingTxHash, &chainhash.Hash{}, 100)
+	require.NoError(t, err)
+
+	emitted, err = app.CheckTimelockExpiries(1092)
+	require.NoError(t, err)
+	require.Empty(t, emitted)
+
+	emitted, err = app.CheckTimelockExpiries(1093)
+	require.NoError(t, err)
+	expectedNotice := staker.TimelockExpiryNotification{
+		StakingTxHash:   stakingTxHash,
+		ExpiryHeight:    1100,
+		BestBlockHeight: 1093,
+		BlocksLeft:      6,
+	}
+	require.Equal(t, []staker.TimelockExpiryNotification{expectedNotice}, emitted)
+	require.Equal(t, expectedNotice, <-notifications)
+
+	// early notification is emitted only once
+	emitted, err = app.CheckTimelockExpiries(1095)
+	require.NoError(t, err)
+	require.Empty(t, emitted)
+
+	emitted, err = app.CheckTimelockExpiries(1099)
+	require.NoError(t, err)
+	expectedExpiry := staker.TimelockExpiryNotification{
+		StakingTxHash:   stakingTxHash,
+		ExpiryHeight:    1100,
+		BestBlockHeight: 1099,
+		Expired:         true,
+	}
+	require.Equal(t, []staker.TimelockExpiryNotification{expectedExpiry}, emitted)
+	require.Equal(t, expectedExpiry, <-notifications)
+
+	emitted, err = app.CheckTimelockExpiries(1100)
+	require.NoError(t, err)
+	require.Empty(t, emitted)
+
+	// spent delegation is not notified anymore
+	require.NoError(t, store.SetTxSpentOnBtc(&stakingTxHash))
+	emitted, err = app.CheckTimelockExpiries(1101)
+	require.NoError(t, err)
+	require.Empty(t, emitted)
+}
+
+func TestHealthcheck(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+	bc.LatestBlockHeight = 500
+	wallet := &mockWallet{nodeHeight: 120}
+
+	app := newTestStakerApp(t, withBabylonClient(bc), withWallet(wallet))
+
+	status := app.Healthcheck()
+	require.True(t, status.Healthy())
+	require.Empty(t, status.Wallet.Error)
+	require.Equal(t, int64(120), status.BtcNodeHeight)
+	require.Equal(t, uint64(500), status.BabylonHeight)
+
+	wallet.balanceErr = errors.New("wallet not loaded")
+	bc.LatestBlockHeightErr = errors.New("connection refused")
+
+	status = app.Healthcheck()
+	require.False(t, status.Healthy())
+	require.True(t, status.BtcNode.Healthy)
+	require.False(t, status.Wallet.Healthy)
+	require.Equal(t, "wallet not loaded", status.Wallet.Error)
+	require.False(t, status.Babylon.Healthy)
+	require.Equal(t, "connection refused", status.Babylon.Error)
+	require.Equal(t, int64(120), status.BtcNodeHeight)
+	require.Zero(t, status.BabylonHeight)
+}
+
+func TestReloadConfig(t *testing.T) {
+	bc := babylonclient.GetMockClient()
+
+	cfg := stakercfg.DefaultConfig()
+	cfg.ActiveNetParams = chaincfg.SimNetParams
+	feeEstimator := staker.NewStaticBtcFeeEstimator(chainfee.SatPerKVByte(cfg.BtcNodeBackendConfig.MaxFeeRate * 1000))
+
+	app := newTestStakerApp(t, withConfig(&cfg), withBabylonClient(bc), withFeeEstimator(feeEstimator))
+
+	newCfg := stakercfg.DefaultConfig()
+	newCfg.BtcNodeBackendConfig.MaxFeeRate = cfg.BtcNodeBackendConfig.MaxFeeRate + 10
+	newCfg.StakerConfig.BabylonStallingInterval = 5 * time.Minute
+	newCfg.StakerConfig.MinStakingTxConfirmations = bc.ClientParams.ConfirmationTimeBlocks + 5
+	// not reloadable, must be ignored
+	newCfg.DBConfig.DBPath = t.TempDir()
+
+	require.NoError(t, app.ReloadConfig(context.Background(), &newCfg))
+	require.Equal(t, chainfee.SatPerKVByte(newCfg.BtcNodeBackendConfig.MaxFeeRate*1000), feeEstimator.EstimateFeePerKb())
+	require.Equal(t, 5*time.Minute, cfg.StakerConfig.BabylonStallingInterval)
+	require.Equal(t, newCfg.StakerConfig.MinStakingTxConfirmations, cfg.StakerConfig.MinStakingTxConfirmations)
+	require.NotEqual(t, newCfg.DBConfig.DBPath, cfg.DBConfig.DBPath)
+
+	// invalid config is refused as a whole
+	invalidCfg := stakercfg.DefaultConfig()
+	invalidCfg.BtcNodeBackendConfig.MaxFeeRate = newCfg.BtcNodeBackendConfig.MaxFeeRate + 10
+	invalidCfg.StakerConfig.MinStakingTxConfirmations = bc.ClientParams.ConfirmationTimeBlocks - 1
+
+	require.Error(t, app.ReloadConfig(context.Background(), &invalidCfg))
+	require.Equal(t, chainfee.SatPerKVByte(newCfg.BtcNodeBackendConfig.MaxFeeRate*1000), feeEstimator.EstimateFeePerKb())
+	require.Equal(t, newCfg.StakerConfig.MinStakingTxConfirmations, cfg.StakerConfig.MinStakingTxConfirmations)
+
+	// fee limits cannot be reloaded, so maxfeerate must stay within them
+	aboveLimitCfg := stakercfg.DefaultConfig()
+	aboveLimitCfg.StakerConfig.MinStakingTxConfirmations = newCfg.StakerConfig.MinStakingTxConfirmations
+	aboveLimitCfg.BtcNodeBackendConfig.MaxFeeRate = cfg.BtcNodeBackendConfig.MaxFeeRatePerKb/1000 + 1
+
+	require.Error(t, app.ReloadConfig(context.Background(), &aboveLimitCfg))
+	require.Equal(t, chainfee.SatPerKVByte(newCfg.BtcNodeBackendConfig.MaxFeeRate*1000), feeEstimator.EstimateFeePerKb())
+}
+
+// unknownKeyWallet does not know public key of any address
+type unknownKeyWallet struct {
+	*mockWallet
+}
+
+func (w *unknownKeyWallet) AddressPublicKey(address btcutil.Address) (*btcec.PublicKey, error) {
+	return nil, fmt.Errorf("address %s is not known to the wallet", address)
+}
+
+func TestVerifyDelegationScript(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	app := newTestStakerApp(
+		t,
+		withBabylonClient(bc),
+		withWallet(&unknownKeyWallet{mockWallet: &mockWallet{}}),
+		withStore(store),
+	)
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTx := makeTestStakingTx()
+	stakingTxHash := stakingTx.TxHash()
+
+	err := app.VerifyDelegationScript(context.Background(), &stakingTxHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+
+	err = store.AddTransaction(
+		stakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+	)
+	require.NoError(t, err)
+
+	// script cannot be rebuilt without staker key
+	err = app.VerifyDelegationScript(context.Background(), &stakingTxHash)
+	require.ErrorContains(t, err, "Error getting staker public key")
+	require.NotErrorIs(t, err, staker.ErrDelegationScriptMismatch)
+
+	// key of delegation funded from additional wallet is provided by that wallet
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	err = app.RegisterWallet("treasury", &mockWallet{pubKey: stakerKey.PubKey()})
+	require.NoError(t, err)
+
+	treasuryStakingTx := makeTestStakingTx()
+	treasuryStakingTx.TxIn[0].PreviousOutPoint.Index = 1
+	treasuryStakingTxHash := treasuryStakingTx.TxHash()
+
+	err = store.AddFundedTransaction(
+		treasuryStakingTx,
+		0,
+		1000,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		nil,
+		nil,
+		&stakerdb.StakingTxFunding{WalletName: "treasury"},
+	)
+	require.NoError(t, err)
+
+	// script is rebuilt, but it does not match the dummy script of test transaction
+	err = app.VerifyDelegationScript(context.Background(), &treasuryStakingTxHash)
+	require.ErrorIs(t, err, staker.ErrDelegationScriptMismatch)
+}
+
+func TestGetStakingSpendInfoUsesParamsSnapshot(t *testing.T) {
+	store := makeTestStore(t)
+	bc := babylonclient.GetMockClient()
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	app := newTestStakerApp(
+		t,
+		withBabylonClient(bc),
+		withWallet(&mockWallet{pubKey: stakerKey.PubKey()}),
+		withStore(store),
+	)
+
+	// covenant committee rotated on babylon after delegation was created
+	snapshotCovenantKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	snapshot := &stakerdb.StakingParamsSnapshot{
+		CovenantPks:    []*btcec.PublicKey{snapshotCovenantKey.PubKey()},
+		CovenantQuorum: 1,
+	}
+
+	fpPk := bc.ActiveFinalityProvider.BtcPk
+	stakingTime := uint16(1000)
+	stakingAmount := btcutil.Amount(100000)
+
+	stakingInfo, err := staking.BuildStakingInfo(
+		stakerKey.PubKey(),
+		[]*btcec.PublicKey{&fpPk},
+		snapshot.CovenantPks,
+		snapshot.CovenantQuorum,
+		stakingTime,
+		stakingAmount,
+		&chaincfg.SimNetParams,
+	)
+	require.NoError(t, err)
+
+	stakingTx := makeTestStakingTx()
+	stakingTx.TxOut[0] = stakingInfo.StakingOutput
+	stakingTxHash := stakingTx.TxHash()
+
+	err = store.AddTransaction(
+		stakingTx,
+		0,
+		stakingTime,
+		[]*btcec.PublicKey{&fpPk},
+		stakerdb.NewProofOfPossession([]byte{}),
+		makeTestStakerAddress(t),
+		snapshot,
+	)
+	require.NoError(t, err)
+
+	script, controlBlockBytes, leafVersion, err := app.GetStakingSpendInfo(context.Background(), &stakingTxHash)
+	require.NoError(t, err)
+	require.Equal(t, byte(txscript.BaseLeafVersion), leafVersion)
+
+	// revealed leaf and control block must commit to the stored staking output
+	controlBlock, err := txscript.ParseControlBlock(controlBlockBytes)
+	require.NoError(t, err)
+	rootHash := controlBlock.RootHash(script)
+	outputKey := txscript.ComputeTaprootOutputKey(controlBlock.InternalKey, rootHash)
+	pkScript, err := txscript.PayToTaprootScript(outputKey)
+	require.NoError(t, err)
+	require.Equal(t, stakingTx.TxOut[0].PkScript, pkScript)
+}
@@ -0,0 +1,99 @@
+package staker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// stateUpdatesSubscriptionBuffer is number of state updates buffered for each
+// subscriber. Updates which do not fit into the buffer of slow subscriber are
+// dropped for that subscriber.
+const stateUpdatesSubscriptionBuffer = 100
+
+// DelegationStateUpdate is emitted each time tracked delegation changes state
+type DelegationStateUpdate struct {
+	StakingTxHash chainhash.Hash
+	OldState      proto.TransactionState
+	NewState      proto.TransactionState
+}
+
+type stateUpdates struct {
+	mu               sync.Mutex
+	subscribers      map[uint64]chan DelegationStateUpdate
+	nextSubscriberID uint64
+}
+
+func newStateUpdates() *stateUpdates {
+	return &stateUpdates{
+		subscribers: make(map[uint64]chan DelegationStateUpdate),
+	}
+}
+
+// publish delivers update to all subscribers without blocking. Subscribers with
+// full buffer miss the update.
+func (su *stateUpdates) publish(update DelegationStateUpdate) {
+	su.mu.Lock()
+	defer su.mu.Unlock()
+
+	for _, sub := range su.subscribers {
+		select {
+		case sub <- update:
+		default:
+		}
+	}
+}
+
+// onTransactionStateChanged publishes state change of delegation committed to
+// the store and moves delegation between delegations by state gauges
+func (app *StakerApp) onTransactionStateChanged(
+	txHash *chainhash.Hash,
+	prevState, newState proto.TransactionState,
+) {
+	update := DelegationStateUpdate{
+		StakingTxHash: *txHash,
+		OldState:      prevState,
+		NewState:      newState,
+	}
+
+	app.m.DelegationsByState.WithLabelValues(prevState.String()).Dec()
+	app.m.DelegationsByState.WithLabelValues(newState.String()).Inc()
+
+	app.stateUpdates.publish(update)
+
+	if app.webhookSender != nil {
+		app.webhookSender.Send(update, time.Now())
+	}
+}
+
+// SubscribeStateUpdates returns channel receiving update each time tracked
+// delegation changes state, and function cancelling the subscription. State
+// machine never waits for subscribers: updates are buffered and if subscriber
+// does not keep up and its buffer is full, new updates are dropped for that
+// subscriber. Channel is closed when subscription is cancelled.
+func (app *StakerApp) SubscribeStateUpdates() (<-chan DelegationStateUpdate, func()) {
+	su := app.stateUpdates
+
+	su.mu.Lock()
+	defer su.mu.Unlock()
+
+	id := su.nextSubscriberID
+	su.nextSubscriberID++
+
+	updates := make(chan DelegationStateUpdate, stateUpdatesSubscriptionBuffer)
+	su.subscribers[id] = updates
+
+	cancel := func() {
+		su.mu.Lock()
+		defer su.mu.Unlock()
+
+		if sub, found := su.subscribers[id]; found {
+			close(sub)
+			delete(su.subscribers, id)
+		}
+	}
+
+	return updates, cancel
+}
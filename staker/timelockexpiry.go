@@ -0,0 +1,197 @@
+package staker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// timelockExpirySubscriptionBuffer is number of notifications buffered for
+	// each subscriber. Notifications which do not fit into the buffer of slow
+	// subscriber are dropped for that subscriber.
+	timelockExpirySubscriptionBuffer = 100
+)
+
+// TimelockExpiryNotification is emitted when staking timelock of delegation is
+// about to expire and once again when it expires and staking output can be
+// spent back to staker.
+type TimelockExpiryNotification struct {
+	StakingTxHash chainhash.Hash
+	// height of the first block which can include transaction spending staking
+	// output through timelock path
+	ExpiryHeight    uint32
+	BestBlockHeight uint32
+	// number of blocks which must be mined before spend transaction can be
+	// included, zero once timelock expired
+	BlocksLeft uint32
+	Expired    bool
+}
+
+type timelockExpiries struct {
+	mu               sync.Mutex
+	subscribers      map[uint64]chan TimelockExpiryNotification
+	nextSubscriberID uint64
+	// delegations for which early notification was already emitted
+	noticed map[chainhash.Hash]struct{}
+	// delegations for which expiry notification was already emitted
+	expired map[chainhash.Hash]struct{}
+}
+
+func newTimelockExpiries() *timelockExpiries {
+	return &timelockExpiries{
+		subscribers: make(map[uint64]chan TimelockExpiryNotification),
+		noticed:     make(map[chainhash.Hash]struct{}),
+		expired:     make(map[chainhash.Hash]struct{}),
+	}
+}
+
+// publish delivers notification to all subscribers without blocking. Must be
+// called with mu held.
+func (te *timelockExpiries) publish(n TimelockExpiryNotification) {
+	for _, sub := range te.subscribers {
+		select {
+		case sub <- n:
+		default:
+		}
+	}
+}
+
+// timelockSpendableStates are states in which staking output of delegation is
+// unspent and will be spendable by staker through timelock path. Delegation
+// in UNBONDING_STARTED state is included, as its staking output stays
+// spendable through timelock path if unbonding transaction never confirms.
+var timelockSpendableStates = []proto.TransactionState{
+	proto.TransactionState_CONFIRMED_ON_BTC,
+	proto.TransactionState_SENT_TO_BABYLON,
+	proto.TransactionState_DELEGATION_ACTIVE,
+	proto.TransactionState_UNBONDING_STARTED,
+}
+
+// CheckTimelockExpiries checks staking timelocks of delegations owned by staker
+// against btc chain at bestBlockHeight. It emits notification once when
+// timelock is within configured number of notice blocks from expiry and once
+// when timelock expires. Notifications are kept in memory only, so after
+// restart they are emitted again for delegations which were not spent yet. It
+// returns notifications emitted by this check.
+func (app *StakerApp) CheckTimelockExpiries(bestBlockHeight uint32) ([]TimelockExpiryNotification, error) {
+	var txs []*stakerdb.StoredTransaction
+
+	for _, state := range timelockSpendableStates {
+		stateTxs, err := app.txTracker.GetTransactionsByState(state)
+
+		if err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, stateTxs...)
+	}
+
+	noticeBlocks := app.config.StakerConfig.TimelockExpiryNotice
+	// spend transaction can be included only in the next block
+	nextBlockHeight := bestBlockHeight + 1
+
+	te := app.timelockExpiries
+	te.mu.Lock()
+	defer te.mu.Unlock()
+
+	tracked := make(map[chainhash.Hash]struct{})
+	var emitted []TimelockExpiryNotification
+
+	for _, tx := range txs {
+		// watched delegations cannot be spent by staker
+		if tx.Watched || tx.StakingTxConfirmationInfo == nil {
+			continue
+		}
+
+		stakingTxHash := tx.StakingTx.TxHash()
+		tracked[stakingTxHash] = struct{}{}
+
+		expiryHeight := tx.StakingTxConfirmationInfo.Height + uint32(tx.StakingTime)
+
+		n := TimelockExpiryNotification{
+			StakingTxHash:   stakingTxHash,
+			ExpiryHeight:    expiryHeight,
+			BestBlockHeight: bestBlockHeight,
+		}
+
+		if nextBlockHeight >= expiryHeight {
+			if _, ok := te.expired[stakingTxHash]; ok {
+				continue
+			}
+
+			te.expired[stakingTxHash] = struct{}{}
+			n.Expired = true
+		} else {
+			n.BlocksLeft = expiryHeight - nextBlockHeight
+
+			if _, ok := te.noticed[stakingTxHash]; ok || n.BlocksLeft > noticeBlocks {
+				continue
+			}
+
+			te.noticed[stakingTxHash] = struct{}{}
+		}
+
+		te.publish(n)
+		if app.webhookSender != nil {
+			app.webhookSender.SendTimelockExpiry(n, time.Now())
+		}
+		emitted = append(emitted, n)
+
+		app.logger.WithFields(logrus.Fields{
+			"stakingTxHash":   stakingTxHash,
+			"expiryHeight":    expiryHeight,
+			"bestBlockHeight": bestBlockHeight,
+			"blocksLeft":      n.BlocksLeft,
+		}).Info("Staking timelock expiry approaching or reached")
+	}
+
+	// forget delegations which were spent, unbonded or reorged out, so that
+	// they are notified again if they become spendable again
+	for hash := range te.noticed {
+		if _, ok := tracked[hash]; !ok {
+			delete(te.noticed, hash)
+		}
+	}
+
+	for hash := range te.expired {
+		if _, ok := tracked[hash]; !ok {
+			delete(te.expired, hash)
+		}
+	}
+
+	return emitted, nil
+}
+
+// SubscribeTimelockExpiries returns channel receiving timelock expiry
+// notifications, and function cancelling the subscription. Notifications are
+// buffered and dropped for subscriber whose buffer is full. Channel is closed
+// when subscription is cancelled.
+func (app *StakerApp) SubscribeTimelockExpiries() (<-chan TimelockExpiryNotification, func()) {
+	te := app.timelockExpiries
+
+	te.mu.Lock()
+	defer te.mu.Unlock()
+
+	id := te.nextSubscriberID
+	te.nextSubscriberID++
+
+	notifications := make(chan TimelockExpiryNotification, timelockExpirySubscriptionBuffer)
+	te.subscribers[id] = notifications
+
+	cancel := func() {
+		te.mu.Lock()
+		defer te.mu.Unlock()
+
+		if sub, found := te.subscribers[id]; found {
+			close(sub)
+			delete(te.subscribers, id)
+		}
+	}
+
+	return notifications, cancel
+}
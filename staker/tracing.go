@@ -0,0 +1,50 @@
+package staker
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/babylonchain/btc-staker/staker"
+
+	babylonBackend = "babylon"
+
+	attrTxHash        = "txid"
+	attrSpendTxHash   = "spend_txid"
+	attrAmount        = "amount"
+	attrState         = "state"
+	attrBackend       = "backend"
+	attrStakerAddress = "staker_address"
+)
+
+// startSpan starts new span as child of span stored in ctx (if any)
+func (app *StakerApp) startSpan(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return app.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startBabylonSpan starts span for rpc call to babylon node
+func (app *StakerApp) startBabylonSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return app.startSpan(ctx, name, attribute.String(attrBackend, babylonBackend))
+}
+
+// startWalletSpan starts span for rpc call to btc wallet
+func (app *StakerApp) startWalletSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return app.startSpan(ctx, name, attribute.String(attrBackend, app.config.BtcNodeBackendConfig.WalletType))
+}
+
+// endSpan records err in span, if err is not nil, and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
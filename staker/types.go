@@ -14,11 +14,14 @@ import (
 	cl "github.com/babylonchain/btc-staker/babylonclient"
 	"github.com/babylonchain/btc-staker/proto"
 	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/wallet/txrules"
 	"github.com/btcsuite/btcwallet/wallet/txsizes"
@@ -26,6 +29,80 @@ import (
 	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 )
 
+// StakingTxInput is wallet output selected to fund staking transaction
+type StakingTxInput struct {
+	OutPoint wire.OutPoint
+	Amount   btcutil.Amount
+}
+
+// StakingTxPreview describes staking transaction which would be created by
+// StakeFunds for the same request
+type StakingTxPreview struct {
+	// unsigned staking transaction, its hash changes once it is signed if any
+	// of the inputs is not segwit
+	UnsignedTx            *wire.MsgTx
+	StakingOutputIdx      uint32
+	StakingOutputPkScript []byte
+	Inputs                []StakingTxInput
+	// zero if transaction does not have change output
+	ChangeAmount btcutil.Amount
+	Fee          btcutil.Amount
+	// virtual size of transaction once it is signed
+	VSize int64
+	// fee rate in sat/kvB used to fund the transaction
+	FeeRate btcutil.Amount
+}
+
+// StakeCostEstimate breaks down full cost of staking given amount, from funding
+// staking transaction to withdrawing staked funds once staking time expires
+type StakeCostEstimate struct {
+	StakingAmount btcutil.Amount
+	// fee rate in sat/kvB used to estimate fees of both transactions
+	FeeRate btcutil.Amount
+	// fee of staking transaction funded by the wallet
+	FundingFee     btcutil.Amount
+	FundingTxVSize int64
+	// fee of transaction spending staking output through timelock path back
+	// to staker, estimated at current fee rate
+	WithdrawalFee     btcutil.Amount
+	WithdrawalTxVSize int64
+	// staking amount together with funding and withdrawal fees
+	TotalCost btcutil.Amount
+}
+
+// PreparedDelegation holds delegation data which must be signed by staker key
+// held outside of the connected wallet
+type PreparedDelegation struct {
+	StakingTxHash chainhash.Hash
+	StakingTx     *wire.MsgTx
+	// unsigned staking transaction with funding data required by external
+	// signer, set only in watch-only mode
+	StakingPsbt      *psbt.Packet
+	StakingOutputIdx uint32
+	// babylon address over which staker key must produce proof of possession
+	StakerBabylonAddr sdk.AccAddress
+	SlashingTx        *wire.MsgTx
+	// script of staking output slashing path, used to sign slashing transaction
+	SlashingPathScript []byte
+	UnbondingTx        *wire.MsgTx
+	UnbondingTime      uint16
+	SlashUnbondingTx   *wire.MsgTx
+	// script of unbonding output slashing path, used to sign slash unbonding transaction
+	UnbondingSlashingPathScript []byte
+}
+
+// MerkleProof proves inclusion of transaction in the btc block
+type MerkleProof struct {
+	BlockHeader wire.BlockHeader
+	BlockHash   chainhash.Hash
+	BlockHeight uint32
+	// TxIndex is the position of transaction in the block
+	TxIndex uint32
+	// MerkleBranch holds sibling hashes on the path from transaction to the
+	// merkle root, ordered from the leaf level up
+	MerkleBranch []chainhash.Hash
+}
+
 type spendStakeTxInfo struct {
 	spendStakeTx           *wire.MsgTx
 	fundingOutput          *wire.TxOut
@@ -41,6 +118,26 @@ func babylonPopToDbPop(pop *cl.BabylonPop) *stakerdb.ProofOfPossession {
 	}
 }
 
+// stakingParamsSnapshot captures babylon staking params in effect when delegation
+// is created, so that they can be inspected later even if params change
+func stakingParamsSnapshot(params *cl.StakingParams) *stakerdb.StakingParamsSnapshot {
+	var slashingAddress string
+	if params.SlashingAddress != nil {
+		slashingAddress = params.SlashingAddress.EncodeAddress()
+	}
+
+	return &stakerdb.StakingParamsSnapshot{
+		ConfirmationTimeBlocks:    params.ConfirmationTimeBlocks,
+		FinalizationTimeoutBlocks: params.FinalizationTimeoutBlocks,
+		MinSlashingTxFeeSat:       params.MinSlashingTxFeeSat,
+		CovenantPks:               params.CovenantPks,
+		CovenantQuorum:            params.CovenantQuruomThreshold,
+		MinUnbondingTime:          params.MinUnbondingTime,
+		SlashingAddress:           slashingAddress,
+		SlashingRate:              params.SlashingRate.String(),
+	}
+}
+
 func babylonCovSigToDbCovSig(covSig cl.CovenantSignatureInfo) stakerdb.PubKeySigPair {
 	return stakerdb.NewCovenantMemberSignature(covSig.Signature, covSig.PubKey)
 }
@@ -202,9 +299,12 @@ func createSpendStakeTx(
 	stakingOutputOutpoint := wire.NewOutPoint(fundingTxHash, fundingOutputIdx)
 	stakingOutputAsInput := wire.NewTxIn(stakingOutputOutpoint, nil, nil)
 	// need to set valid sequence to unlock tx.
-	stakingOutputAsInput.Sequence = uint32(lockTime)
+	stakingOutputAsInput.Sequence = timeLockPathSequence(lockTime)
 
+	// relative time lock is enforced only for transactions with version 2 or
+	// higher. Absolute lock time is not used by time lock path.
 	spendTx := wire.NewMsgTx(2)
+	spendTx.LockTime = 0
 	spendTx.AddTxIn(stakingOutputAsInput)
 	spendTx.AddTxOut(newOutput)
 
@@ -219,9 +319,50 @@ func createSpendStakeTx(
 		return nil, nil, fmt.Errorf("too big fee rate for spend stake tx. calculated fee: %d. funding output value: %d", fee, fundingOutput.Value)
 	}
 
+	if txrules.IsDustOutput(spendTx.TxOut[0], MinFeePerKb) {
+		return nil, nil, fmt.Errorf("output of spend stake tx with value %d is dust after paying fee %d", spendTx.TxOut[0].Value, fee)
+	}
+
 	return spendTx, &fee, nil
 }
 
+// txFeeInfo returns fee info of signed transaction which paid given fee.
+// Transaction must have witness data filled, otherwise virtual size would be
+// underestimated.
+func txFeeInfo(tx *wire.MsgTx, fee btcutil.Amount) *stakerdb.TxFeeInfo {
+	return stakerdb.NewTxFeeInfo(fee, mempool.GetTxVirtualSize(btcutil.NewTx(tx)))
+}
+
+// estimateTimeLockPathSpendTxVSize returns virtual size of transaction which
+// spends output through time lock path to single p2wpkh output. Contrary to
+// key path spend estimation, it accounts for full witness i.e staker signature,
+// revealed time lock script and taproot control block.
+func estimateTimeLockPathSpendTxVSize(spendInfo *staking.SpendInfo, lockTime uint16) (int64, error) {
+	controlBlock, err := spendInfo.ControlBlock.ToBytes()
+
+	if err != nil {
+		return 0, err
+	}
+
+	input := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, 0), nil, nil)
+	input.Sequence = timeLockPathSequence(lockTime)
+	input.Witness = wire.TxWitness{
+		make([]byte, schnorr.SignatureSize),
+		spendInfo.RevealedLeaf.Script,
+		controlBlock,
+	}
+
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(input)
+	spendTx.AddTxOut(wire.NewTxOut(0, make([]byte, txsizes.P2WPKHPkScriptSize)))
+
+	baseSize := int64(spendTx.SerializeSizeStripped())
+	totalSize := int64(spendTx.SerializeSize())
+	weight := baseSize*(blockchain.WitnessScaleFactor-1) + totalSize
+
+	return (weight + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor, nil
+}
+
 func createSpendStakeTxFromStoredTx(
 	stakerBtcPk *btcec.PublicKey,
 	covenantPublicKeys []*btcec.PublicKey,
@@ -326,9 +467,18 @@ func createSpendStakeTxFromStoredTx(
 	}
 }
 
-func createUndelegationData(
+// unsignedUnbondingTxs holds unbonding transaction and transaction slashing
+// unbonding output, before any of them is signed by staker
+type unsignedUnbondingTxs struct {
+	unbondingInfo        *staking.UnbondingInfo
+	unbondingTx          *wire.MsgTx
+	unbondingOutputValue int64
+	slashUnbondingTx     *wire.MsgTx
+}
+
+func buildUnbondingTxs(
 	storedTx *stakerdb.StoredTransaction,
-	stakerPrivKey *btcec.PrivateKey,
+	stakerPubKey *btcec.PublicKey,
 	covenantPubKeys []*btcec.PublicKey,
 	covenantThreshold uint32,
 	slashingAddress btcutil.Address,
@@ -337,7 +487,7 @@ func createUndelegationData(
 	slashingFee btcutil.Amount,
 	slashingRate sdkmath.LegacyDec,
 	btcNetwork *chaincfg.Params,
-) (*cl.UndelegationData, error) {
+) (*unsignedUnbondingTxs, error) {
 	stakingTxHash := storedTx.StakingTx.TxHash()
 
 	stakingOutpout := storedTx.StakingTx.TxOut[storedTx.StakingOutputIndex]
@@ -346,8 +496,6 @@ func createUndelegationData(
 
 	unbondingOutputValue := stakingOutpout.Value - int64(unbondingTxFee)
 
-	stakerPubKey := stakerPrivKey.PubKey()
-
 	if unbondingOutputValue <= 0 {
 		return nil, fmt.Errorf(
 			"too large fee rate %d sats/kb. Staking output value:%d sats. Unbonding tx fee:%d sats", int64(feeRatePerKb), stakingOutpout.Value, int64(unbondingTxFee),
@@ -393,6 +541,48 @@ func createUndelegationData(
 		return nil, fmt.Errorf("failed to build unbonding data: failed to build slashing tx: %w", err)
 	}
 
+	return &unsignedUnbondingTxs{
+		unbondingInfo:        unbondingInfo,
+		unbondingTx:          unbondingTx,
+		unbondingOutputValue: unbondingOutputValue,
+		slashUnbondingTx:     slashUnbondingTx,
+	}, nil
+}
+
+func createUndelegationData(
+	storedTx *stakerdb.StoredTransaction,
+	stakerPrivKey *btcec.PrivateKey,
+	covenantPubKeys []*btcec.PublicKey,
+	covenantThreshold uint32,
+	slashingAddress btcutil.Address,
+	feeRatePerKb btcutil.Amount,
+	unbondingTime uint16,
+	slashingFee btcutil.Amount,
+	slashingRate sdkmath.LegacyDec,
+	btcNetwork *chaincfg.Params,
+) (*cl.UndelegationData, error) {
+	unsignedTxs, err := buildUnbondingTxs(
+		storedTx,
+		stakerPrivKey.PubKey(),
+		covenantPubKeys,
+		covenantThreshold,
+		slashingAddress,
+		feeRatePerKb,
+		unbondingTime,
+		slashingFee,
+		slashingRate,
+		btcNetwork,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unbondingInfo := unsignedTxs.unbondingInfo
+	unbondingTx := unsignedTxs.unbondingTx
+	slashUnbondingTx := unsignedTxs.slashUnbondingTx
+	unbondingOutputValue := unsignedTxs.unbondingOutputValue
+
 	slashingPathInfo, err := unbondingInfo.SlashingPathSpendInfo()
 
 	if err != nil {
@@ -426,7 +616,8 @@ func createWitnessToSendUnbondingTx(
 	params *cl.StakingParams,
 	net *chaincfg.Params,
 ) (wire.TxWitness, error) {
-	if storedTx.State < proto.TransactionState_DELEGATION_ACTIVE {
+	if storedTx.State != proto.TransactionState_DELEGATION_ACTIVE &&
+		storedTx.State != proto.TransactionState_UNBONDING_STARTED {
 		return nil, fmt.Errorf("cannot create witness for sending unbonding tx. Staking transaction is in invalid state: %s", storedTx.State)
 	}
 
@@ -643,6 +834,7 @@ func parseWatchStakingRequest(
 		fpBtcPks,
 		currentParams.ConfirmationTimeBlocks,
 		pop,
+		stakingParamsSnapshot(currentParams),
 		slashingTx,
 		slashingTxSig,
 		stakerBabylonAddr,
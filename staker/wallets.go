@@ -0,0 +1,112 @@
+package staker
+
+import (
+	"fmt"
+	"sort"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/sirupsen/logrus"
+)
+
+// stakerWallet is wallet from which staker funds delegations, together with
+// unlocker managing its unlocks
+type stakerWallet struct {
+	// empty for the main wallet
+	name     string
+	wc       walletcontroller.WalletController
+	unlocker *walletUnlocker
+}
+
+// newAdditionalWallet wraps wallet controller of additional wallet. Passphrase
+// from config belongs to the main wallet, so additional wallet is only unlocked
+// with passphrase provided for operation and locked again once it finishes.
+func newAdditionalWallet(
+	name string,
+	wc walletcontroller.WalletController,
+	cfg *scfg.WalletConfig,
+	logger *logrus.Logger,
+) *stakerWallet {
+	walletCfg := *cfg
+	walletCfg.WalletPass = ""
+	walletCfg.WalletPassFile = ""
+	walletCfg.WalletPassEnv = ""
+	walletCfg.KeepWalletUnlocked = false
+
+	unlocker := newWalletUnlocker(wc, &walletCfg, logger)
+
+	return &stakerWallet{
+		name:     name,
+		wc:       &unlockingWallet{WalletController: wc, unlocker: unlocker},
+		unlocker: unlocker,
+	}
+}
+
+// RegisterWallet adds additional wallet with given name, from which delegations
+// can be funded by passing the name in StakeOptions.WalletName. Delegations
+// funded from additional wallet are signed, unbonded and withdrawn using the
+// same wallet. Wallets must be registered before the app is started.
+func (app *StakerApp) RegisterWallet(name string, wc walletcontroller.WalletController) error {
+	if name == "" {
+		return fmt.Errorf("wallet name must not be empty")
+	}
+
+	if wc == nil {
+		return fmt.Errorf("wallet controller of wallet %s must not be nil", name)
+	}
+
+	app.walletsMu.Lock()
+	defer app.walletsMu.Unlock()
+
+	if _, ok := app.wallets[name]; ok {
+		return fmt.Errorf("wallet %s is already registered", name)
+	}
+
+	app.wallets[name] = newAdditionalWallet(name, wc, app.config.WalletConfig, app.logger)
+
+	return nil
+}
+
+// WalletNames returns sorted names of registered additional wallets. Main wallet
+// is not included.
+func (app *StakerApp) WalletNames() []string {
+	app.walletsMu.RLock()
+	defer app.walletsMu.RUnlock()
+
+	names := make([]string, 0, len(app.wallets))
+	for name := range app.wallets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// wallet returns wallet with given name. Empty name selects the main wallet.
+func (app *StakerApp) wallet(name string) (*stakerWallet, error) {
+	if name == "" {
+		return &stakerWallet{
+			wc:       app.wc,
+			unlocker: app.walletUnlocker,
+		}, nil
+	}
+
+	app.walletsMu.RLock()
+	defer app.walletsMu.RUnlock()
+
+	w, ok := app.wallets[name]
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWallet, name)
+	}
+
+	return w, nil
+}
+
+// walletForTx returns wallet which funded given staking transaction. Transactions
+// not owned by staker use the main wallet.
+func (app *StakerApp) walletForTx(tx *stakerdb.StoredTransaction) (*stakerWallet, error) {
+	return app.wallet(tx.WalletName)
+}
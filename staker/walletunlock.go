@@ -0,0 +1,235 @@
+package staker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/walletcontroller"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// walletUnlocker keeps track of operations which need unlocked wallet. Wallet
+// is unlocked when the first of them starts and locked again when the last of
+// them finishes, unless wallet is configured to stay unlocked.
+type walletUnlocker struct {
+	wc     walletcontroller.WalletController
+	cfg    *scfg.WalletConfig
+	logger *logrus.Logger
+
+	mu sync.Mutex
+	// number of operations currently holding the wallet unlocked
+	holders int
+}
+
+func newWalletUnlocker(
+	wc walletcontroller.WalletController,
+	cfg *scfg.WalletConfig,
+	logger *logrus.Logger,
+) *walletUnlocker {
+	return &walletUnlocker{
+		wc:     wc,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// acquire makes sure wallet is unlocked until returned function is called.
+// If passphraseProvider is nil, passphrase from config is used. If no passphrase
+// is configured, wallet is assumed to be unlocked externally and is left
+// untouched.
+func (u *walletUnlocker) acquire(passphraseProvider PassphraseProvider) (func(), error) {
+	if passphraseProvider == nil && !u.cfg.HasPassphrase() {
+		return func() {}, nil
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	// wallet is already unlocked by other operation. Passphrase from config is
+	// not used here, as the wallet may be unlocked with passphrase provided for
+	// the operation
+	if passphraseProvider == nil && u.holders > 0 {
+		u.holders++
+		return u.releaseFunc(), nil
+	}
+
+	if err := u.unlock(passphraseProvider); err != nil {
+		return nil, err
+	}
+
+	u.holders++
+	return u.releaseFunc(), nil
+}
+
+func (u *walletUnlocker) unlock(passphraseProvider PassphraseProvider) error {
+	if passphraseProvider == nil {
+		return u.wc.UnlockWallet(u.cfg.UnlockTimeoutSecs())
+	}
+
+	passphrase, err := passphraseProvider()
+
+	if err != nil {
+		return fmt.Errorf("failed to retrieve wallet passphrase: %w", err)
+	}
+
+	return u.wc.UnlockWalletWithPassphrase(passphrase, u.cfg.OperationUnlockTimeoutSecs())
+}
+
+func (u *walletUnlocker) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(u.release)
+	}
+}
+
+func (u *walletUnlocker) release() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.holders--
+
+	if u.holders > 0 || u.cfg.KeepWalletUnlocked {
+		return
+	}
+
+	if err := u.wc.LockWallet(); err != nil {
+		u.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to lock the wallet after operation")
+	}
+}
+
+// renew unlocks the wallet with passphrase from config, extending the unlock
+// timeout. Used to keep the wallet unlocked when KeepWalletUnlocked is set.
+func (u *walletUnlocker) renew() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.wc.UnlockWallet(u.cfg.UnlockTimeoutSecs())
+}
+
+// lock locks the wallet, unless some operation still needs it unlocked
+func (u *walletUnlocker) lock() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.holders > 0 {
+		return nil
+	}
+
+	return u.wc.LockWallet()
+}
+
+// keepWalletUnlocked renews wallet unlock before it times out, until the app
+// is stopped. Wallet is locked on stop.
+func (app *StakerApp) keepWalletUnlocked() {
+	defer app.wg.Done()
+
+	// renew well before the timeout, so that slow rpc call does not leave
+	// the wallet locked
+	ticker := time.NewTicker(app.config.WalletConfig.UnlockTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := app.walletUnlocker.renew(); err != nil {
+				app.logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Error("Failed to renew wallet unlock")
+			}
+		case <-app.quit:
+			if err := app.walletUnlocker.lock(); err != nil {
+				app.logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Warn("Failed to lock the wallet on stop")
+			}
+			return
+		}
+	}
+}
+
+// unlockingWallet makes sure wallet is unlocked for the duration of every call
+// which needs wallet private keys
+type unlockingWallet struct {
+	walletcontroller.WalletController
+	unlocker *walletUnlocker
+}
+
+var _ walletcontroller.WalletController = (*unlockingWallet)(nil)
+
+func (w *unlockingWallet) DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return w.WalletController.DumpPrivateKey(address)
+}
+
+func (w *unlockingWallet) SignRawTransaction(ctx context.Context, tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
+	return w.WalletController.SignRawTransaction(ctx, tx)
+}
+
+func (w *unlockingWallet) SignRawTransactionWithPrevouts(
+	ctx context.Context,
+	tx *wire.MsgTx,
+	prevOuts []walletcontroller.PrevOut,
+) (*wire.MsgTx, bool, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer release()
+
+	return w.WalletController.SignRawTransactionWithPrevouts(ctx, tx, prevOuts)
+}
+
+func (w *unlockingWallet) CreateAndSignTx(
+	ctx context.Context,
+	output []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeAddress btcutil.Address,
+	signalRbf bool,
+) (*wire.MsgTx, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return w.WalletController.CreateAndSignTx(ctx, output, feeRatePerKb, changeAddress, signalRbf)
+}
+
+func (w *unlockingWallet) SignBip322NativeSegwit(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return w.WalletController.SignBip322NativeSegwit(ctx, msg, address)
+}
+
+func (w *unlockingWallet) SignBip322Taproot(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	release, err := w.unlocker.acquire(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return w.WalletController.SignBip322Taproot(ctx, msg, address)
+}
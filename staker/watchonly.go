@@ -0,0 +1,102 @@
+package staker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/sirupsen/logrus"
+)
+
+// WatchOnlyStakerKey returns staker public key configured for watch-only mode,
+// or nil if staker does not run in watch-only mode.
+//
+// In watch-only mode staker private key is held outside of the connected wallet
+// e.g on air-gapped machine, and staker only builds transactions and tracks
+// delegations. Delegations are created using PrepareDelegation, which returns
+// unsigned staking transaction as psbt, followed by SubmitSignedStakingTx and
+// SubmitPreparedDelegation. Following operations require staker private key and
+// return ErrWatchOnlyMode:
+//   - StakeFunds and its variants
+//   - UnbondStaking
+//   - SpendStake
+//   - BumpStakingTxFee
+//   - BumpSpendTxFee
+func (app *StakerApp) WatchOnlyStakerKey() *btcec.PublicKey {
+	return app.config.StakerConfig.ActiveWatchOnlyStakerPubKey
+}
+
+func (app *StakerApp) checkNotWatchOnly(operation string) error {
+	if app.WatchOnlyStakerKey() != nil {
+		return fmt.Errorf("cannot %s: %w", operation, ErrWatchOnlyMode)
+	}
+
+	return nil
+}
+
+// txSigned returns true if all inputs of transaction carry signature script or
+// witness
+func txSigned(tx *wire.MsgTx) bool {
+	for _, in := range tx.TxIn {
+		if len(in.SignatureScript) == 0 && len(in.Witness) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SubmitSignedStakingTx replaces unsigned staking transaction of delegation
+// created by PrepareDelegation with the same transaction signed externally.
+// Signed transaction must spend the same inputs to the same outputs i.e it must
+// have the same hash as prepared one. Delegation stays in PREPARED state until
+// SubmitPreparedDelegation, which sends signed staking transaction to btc.
+func (app *StakerApp) SubmitSignedStakingTx(
+	ctx context.Context,
+	stakingTxHash *chainhash.Hash,
+	signedTx *wire.MsgTx,
+) error {
+	return app.runCommand(ctx, "SubmitSignedStakingTx", func(ctx context.Context) error {
+		return app.submitSignedStakingTx(stakingTxHash, signedTx)
+	})
+}
+
+func (app *StakerApp) submitSignedStakingTx(
+	stakingTxHash *chainhash.Hash,
+	signedTx *wire.MsgTx,
+) error {
+	storedTx, err := app.txTracker.GetTransaction(stakingTxHash)
+
+	if err != nil {
+		return err
+	}
+
+	if storedTx.State != proto.TransactionState_PREPARED {
+		return fmt.Errorf("cannot submit signed staking transaction in state %s: %w", storedTx.State, stakerdb.ErrTransactionNotPrepared)
+	}
+
+	signedTxHash := signedTx.TxHash()
+
+	if !signedTxHash.IsEqual(stakingTxHash) {
+		return fmt.Errorf("signed transaction %s does not match prepared staking transaction %s: %w",
+			signedTxHash, stakingTxHash, ErrStakingTxNotSigned)
+	}
+
+	if !txSigned(signedTx) {
+		return fmt.Errorf("not all inputs of staking transaction %s are signed: %w", stakingTxHash, ErrStakingTxNotSigned)
+	}
+
+	if err := app.txTracker.SetPreparedStakingTx(stakingTxHash, signedTx); err != nil {
+		return err
+	}
+
+	app.logger.WithFields(logrus.Fields{
+		"stakingTxHash": stakingTxHash,
+	}).Info("Received signed staking transaction of prepared delegation")
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package staker
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/sirupsen/logrus"
+)
+
+// WebhookSignatureHeader is http header carrying hex encoded hmac-sha256 of
+// webhook payload computed with configured secret
+const WebhookSignatureHeader = "X-Staker-Signature"
+
+const (
+	// WebhookEventStateTransition is event of payload posted on delegation
+	// state transition
+	WebhookEventStateTransition = "state_transition"
+	// WebhookEventTimelockExpiry is event of payload posted when staking
+	// timelock is about to expire or expired
+	WebhookEventTimelockExpiry = "timelock_expiry"
+)
+
+// WebhookPayload is json body posted to webhook on each delegation state
+// transition
+type WebhookPayload struct {
+	Event         string    `json:"event"`
+	StakingTxHash string    `json:"staking_tx_hash"`
+	OldState      string    `json:"old_state"`
+	NewState      string    `json:"new_state"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// TimelockExpiryWebhookPayload is json body posted to webhook when staking
+// timelock of delegation is about to expire and when it expires
+type TimelockExpiryWebhookPayload struct {
+	Event           string    `json:"event"`
+	StakingTxHash   string    `json:"staking_tx_hash"`
+	ExpiryHeight    uint32    `json:"expiry_height"`
+	BestBlockHeight uint32    `json:"best_block_height"`
+	BlocksLeft      uint32    `json:"blocks_left"`
+	Expired         bool      `json:"expired"`
+	Timestamp       time.Time `json:"timestamp"`
+}
+
+// WebhookSignature returns hex encoded hmac-sha256 of payload body computed
+// with secret
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookSender delivers delegation state transitions and timelock expiry
+// notifications to configured webhook.
+// Deliveries are queued and posted one by one by single worker, so that
+// webhook receives transitions in order they happened. Failed delivery is
+// retried with exponential backoff, capped at configured maximum delay, and
+// dropped after configured number of retries.
+type WebhookSender struct {
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	quit      chan struct{}
+
+	cfg    *scfg.WebhookConfig
+	client *http.Client
+	logger *logrus.Logger
+	queue  chan []byte
+}
+
+func NewWebhookSender(cfg *scfg.WebhookConfig, logger *logrus.Logger) *WebhookSender {
+	return &WebhookSender{
+		quit:   make(chan struct{}),
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		logger: logger,
+		queue:  make(chan []byte, cfg.QueueSize),
+	}
+}
+
+func (s *WebhookSender) Start() {
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go s.deliverQueued()
+	})
+}
+
+// Stop stops delivery worker. Queued deliveries which were not delivered yet
+// are dropped.
+func (s *WebhookSender) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		s.wg.Wait()
+	})
+}
+
+// Send queues state transition for delivery without blocking. Transition is
+// dropped if delivery queue is full.
+func (s *WebhookSender) Send(update DelegationStateUpdate, timestamp time.Time) {
+	s.enqueue(WebhookPayload{
+		Event:         WebhookEventStateTransition,
+		StakingTxHash: update.StakingTxHash.String(),
+		OldState:      update.OldState.String(),
+		NewState:      update.NewState.String(),
+		Timestamp:     timestamp.UTC(),
+	}, logrus.Fields{
+		"stakingTxHash": update.StakingTxHash,
+		"newState":      update.NewState,
+	})
+}
+
+// SendTimelockExpiry queues timelock expiry notification for delivery without
+// blocking. Notification is dropped if delivery queue is full.
+func (s *WebhookSender) SendTimelockExpiry(n TimelockExpiryNotification, timestamp time.Time) {
+	s.enqueue(TimelockExpiryWebhookPayload{
+		Event:           WebhookEventTimelockExpiry,
+		StakingTxHash:   n.StakingTxHash.String(),
+		ExpiryHeight:    n.ExpiryHeight,
+		BestBlockHeight: n.BestBlockHeight,
+		BlocksLeft:      n.BlocksLeft,
+		Expired:         n.Expired,
+		Timestamp:       timestamp.UTC(),
+	}, logrus.Fields{
+		"stakingTxHash": n.StakingTxHash,
+		"expired":       n.Expired,
+	})
+}
+
+func (s *WebhookSender) enqueue(payload interface{}, fields logrus.Fields) {
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to encode webhook payload")
+		return
+	}
+
+	select {
+	case s.queue <- body:
+	default:
+		s.logger.WithFields(fields).Warn("Webhook delivery queue is full, dropping event")
+	}
+}
+
+func (s *WebhookSender) deliverQueued() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case body := <-s.queue:
+			s.deliverWithRetries(body)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *WebhookSender) deliverWithRetries(body []byte) {
+	delay := s.cfg.RetryBaseDelay
+
+	for attempt := uint32(0); ; attempt++ {
+		err := s.deliver(body)
+
+		if err == nil {
+			return
+		}
+
+		if attempt >= s.cfg.MaxRetries {
+			s.logger.WithFields(logrus.Fields{
+				"attempts": attempt + 1,
+				"err":      err,
+			}).Error("Failed to deliver event to webhook, dropping it")
+			return
+		}
+
+		s.logger.WithFields(logrus.Fields{
+			"attempt": attempt + 1,
+			"delay":   delay,
+			"err":     err,
+		}).Warn("Failed to deliver event to webhook, retrying")
+
+		select {
+		case <-time.After(delay):
+		case <-s.quit:
+			return
+		}
+
+		delay *= 2
+		if delay > s.cfg.MaxRetryDelay {
+			delay = s.cfg.MaxRetryDelay
+		}
+	}
+}
+
+func (s *WebhookSender) deliver(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.cfg.Url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.cfg.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, WebhookSignature(s.cfg.Secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+
+	return nil
+}
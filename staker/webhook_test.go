@@ -0,0 +1,173 @@
+package staker_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/staker"
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSenderRetriesAndSignsPayload(t *testing.T) {
+	const secret = "webhook-secret"
+
+	// request received by the webhook, checked by the test goroutine
+	type delivery struct {
+		body      []byte
+		signature string
+		err       error
+	}
+
+	var attempts atomic.Int32
+	delivered := make(chan delivery, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+
+		// first delivery fails, so that it must be retried
+		if err == nil && attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		delivered <- delivery{
+			body:      body,
+			signature: r.Header.Get(staker.WebhookSignatureHeader),
+			err:       err,
+		}
+	}))
+	defer server.Close()
+
+	cfg := stakercfg.DefaultWebhookConfig()
+	cfg.Url = server.URL
+	cfg.Secret = secret
+	cfg.RetryBaseDelay = 10 * time.Millisecond
+	require.NoError(t, cfg.Validate())
+
+	sender := staker.NewWebhookSender(&cfg, logrus.New())
+	sender.Start()
+	defer sender.Stop()
+
+	timestamp := time.Unix(1700000000, 0)
+	sender.Send(staker.DelegationStateUpdate{
+		StakingTxHash: chainhash.Hash{1},
+		OldState:      proto.TransactionState_SENT_TO_BTC,
+		NewState:      proto.TransactionState_CONFIRMED_ON_BTC,
+	}, timestamp)
+
+	select {
+	case d := <-delivered:
+		require.NoError(t, d.err)
+		require.Equal(t, staker.WebhookSignature(secret, d.body), d.signature)
+
+		var payload staker.WebhookPayload
+		require.NoError(t, json.Unmarshal(d.body, &payload))
+		require.Equal(t, staker.WebhookPayload{
+			Event:         staker.WebhookEventStateTransition,
+			StakingTxHash: chainhash.Hash{1}.String(),
+			OldState:      proto.TransactionState_SENT_TO_BTC.String(),
+			NewState:      proto.TransactionState_CONFIRMED_ON_BTC.String(),
+			Timestamp:     timestamp.UTC(),
+		}, payload)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("state transition not delivered to webhook")
+	}
+
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestWebhookSenderCapsRetryDelay(t *testing.T) {
+	const failedAttempts = 4
+
+	var attempts atomic.Int32
+	delivered := make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= failedAttempts {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		delivered <- struct{}{}
+	}))
+	defer server.Close()
+
+	cfg := stakercfg.DefaultWebhookConfig()
+	cfg.Url = server.URL
+	cfg.MaxRetries = failedAttempts
+	// without the cap, retries would wait 100ms, 200ms, 400ms and 800ms
+	cfg.RetryBaseDelay = 100 * time.Millisecond
+	cfg.MaxRetryDelay = 100 * time.Millisecond
+	require.NoError(t, cfg.Validate())
+
+	sender := staker.NewWebhookSender(&cfg, logrus.New())
+	sender.Start()
+	defer sender.Stop()
+
+	start := time.Now()
+	sender.Send(staker.DelegationStateUpdate{
+		StakingTxHash: chainhash.Hash{1},
+		OldState:      proto.TransactionState_SENT_TO_BTC,
+		NewState:      proto.TransactionState_CONFIRMED_ON_BTC,
+	}, time.Now())
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("state transition not delivered to webhook")
+	}
+
+	require.Less(t, time.Since(start), 1200*time.Millisecond)
+	require.Equal(t, int32(failedAttempts+1), attempts.Load())
+}
+
+func TestWebhookSenderSendsTimelockExpiry(t *testing.T) {
+	delivered := make(chan []byte, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delivered <- body
+	}))
+	defer server.Close()
+
+	cfg := stakercfg.DefaultWebhookConfig()
+	cfg.Url = server.URL
+	require.NoError(t, cfg.Validate())
+
+	sender := staker.NewWebhookSender(&cfg, logrus.New())
+	sender.Start()
+	defer sender.Stop()
+
+	timestamp := time.Unix(1700000000, 0)
+	sender.SendTimelockExpiry(staker.TimelockExpiryNotification{
+		StakingTxHash:   chainhash.Hash{1},
+		ExpiryHeight:    1100,
+		BestBlockHeight: 1099,
+		Expired:         true,
+	}, timestamp)
+
+	select {
+	case body := <-delivered:
+		var payload staker.TimelockExpiryWebhookPayload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, staker.TimelockExpiryWebhookPayload{
+			Event:           staker.WebhookEventTimelockExpiry,
+			StakingTxHash:   chainhash.Hash{1}.String(),
+			ExpiryHeight:    1100,
+			BestBlockHeight: 1099,
+			Expired:         true,
+			Timestamp:       timestamp.UTC(),
+		}, payload)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timelock expiry not delivered to webhook")
+	}
+}
@@ -21,6 +21,18 @@ type BBNConfig struct {
 	BlockTimeout   time.Duration `long:"block-timeout" description:"block timeout when waiting for block events"`
 	OutputFormat   string        `long:"output-format" description:"default output when printint responses"`
 	SignModeStr    string        `long:"sign-mode" description:"sign mode to use"`
+	// Submissions signed with the same key are serialized by default, to avoid
+	// account sequence mismatch between concurrently built transactions
+	DisableSubmissionQueue bool `long:"disable-submission-queue" description:"do not serialize concurrent submissions signed with the same key"`
+	// Submissions rejected due to insufficient fee or gas are resubmitted with
+	// gas adjustment multiplied by this factor, until max gas adjustment is reached
+	InsufficientFeeGasFactor float64 `long:"insufficient-fee-gas-factor" description:"factor by which gas adjustment is raised when submission is rejected due to insufficient fee or gas. Value of 1 disables resubmission"`
+	MaxGasAdjustment         float64 `long:"max-gas-adjustment" description:"maximum gas adjustment used when resubmitting transactions rejected due to insufficient fee or gas"`
+	// Submissions rejected due to account sequence mismatch, full mempool or
+	// timeout height are resubmitted with exponential backoff with jitter
+	SubmissionMaxAttempts    uint          `long:"submission-max-attempts" description:"maximum number of attempts to submit transaction rejected due to account sequence mismatch, full mempool or timeout height. Delegation is not resubmitted if it is already included in babylon. Value of 1 disables resubmission"`
+	SubmissionRetryBaseDelay time.Duration `long:"submission-retry-base-delay" description:"delay before first resubmission of transaction, doubled with each next resubmission"`
+	SubmissionRetryMaxDelay  time.Duration `long:"submission-retry-max-delay" description:"maximum delay between resubmissions of transaction"`
 }
 
 func DefaultBBNConfig() BBNConfig {
@@ -40,9 +52,14 @@ func DefaultBBNConfig() BBNConfig {
 		Timeout:        dc.Timeout,
 		// Setting this to relatively low value, out currnet babylon client (lens) will
 		// block for this amout of time to wait for transaction inclusion in block
-		BlockTimeout: 1 * time.Minute,
-		OutputFormat: dc.OutputFormat,
-		SignModeStr:  dc.SignModeStr,
+		BlockTimeout:             1 * time.Minute,
+		OutputFormat:             dc.OutputFormat,
+		SignModeStr:              dc.SignModeStr,
+		InsufficientFeeGasFactor: 1.5,
+		MaxGasAdjustment:         4,
+		SubmissionMaxAttempts:    5,
+		SubmissionRetryBaseDelay: 500 * time.Millisecond,
+		SubmissionRetryMaxDelay:  30 * time.Second,
 	}
 }
 
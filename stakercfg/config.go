@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -15,6 +16,8 @@ import (
 	"github.com/babylonchain/btc-staker/types"
 	"go.uber.org/zap"
 
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/jessevdk/go-flags"
@@ -30,6 +33,7 @@ const (
 	defaultLogDirname      = "logs"
 	defaultLogFilename     = "stakerd.log"
 	DefaultRPCPort         = 15812
+	DefaultGRPCPort        = 15813
 	// DefaultAutogenValidity is the default validity of a self-signed
 	// certificate. The value corresponds to 14 months
 	// (14 months * 30 days * 24 hours).
@@ -41,6 +45,27 @@ const (
 	// we risk into having transactions rejected by the network due to low fee.
 	DefaultMinFeeRate = 2
 	DefaultMaxFeeRate = 25
+	// DefaultMaxFeeRatePerKb is ceiling of fee rate of transactions created by
+	// staker in sat/kvB
+	DefaultMaxFeeRatePerKb = 500000
+	// DefaultMinRelayFeeRatePerKb is floor of fee rate of transactions created
+	// by staker in sat/kvB, equal to default relay fee of bitcoin core
+	DefaultMinRelayFeeRatePerKb = 1000
+	// DefaultFeeRateTolerance is factor by which fee rate supplied by caller
+	// can differ from estimated fee rate before fee rate sanity policy applies
+	DefaultFeeRateTolerance = 5
+	// DefaultMaxFeeEstimationTarget is the widest confirmation target in blocks,
+	// to which fee estimation target is widened when estimate at the desired
+	// target is stale or unreliable
+	DefaultMaxFeeEstimationTarget = 6
+	// DefaultFallbackFeeRate is fee rate in sat/vbyte used when neither btc node
+	// nor fee api can estimate fee rate
+	DefaultFallbackFeeRate = DefaultMinFeeRate
+	// DefaultFeeApiTimeout is timeout of single request to fee api
+	DefaultFeeApiTimeout = 10 * time.Second
+
+	defaultWalletReadRetryAttempts = 3
+	defaultWalletReadRetryDelay    = 500 * time.Millisecond
 )
 
 var (
@@ -67,33 +92,87 @@ func DefaultChainConfig() ChainConfig {
 	}
 }
 
+const (
+	// DefaultWalletUnlockTimeout is long enough for staker to retrieve all keys
+	// needed by single staking operation
+	DefaultWalletUnlockTimeout = 15 * time.Second
+
+	// DefaultOperationUnlockTimeout is short, as wallet unlocked for single
+	// operation is locked again as soon as operation finishes
+	DefaultOperationUnlockTimeout = 5 * time.Second
+)
+
 type WalletConfig struct {
-	WalletName string `long:"walletname" description:"name of the wallet to sign Bitcoin transactions"`
-	WalletPass string `long:"walletpassphrase" description:"passphrase to unlock the wallet"`
+	WalletName              string        `long:"walletname" description:"name of the wallet to sign Bitcoin transactions"`
+	WalletPass              string        `long:"walletpassphrase" description:"passphrase to unlock the wallet. Optional, if empty wallet must be unlocked externally or passphrase must be provided per operation"`
+	WalletPassFile          string        `long:"walletpassphrasefile" description:"path to the file containing passphrase to unlock the wallet. File must be accessible only by its owner and is re-read on every unlock. Takes precedence over walletpassphraseenv and walletpassphrase"`
+	WalletPassEnv           string        `long:"walletpassphraseenv" description:"name of the environment variable containing passphrase to unlock the wallet. Takes precedence over walletpassphrase"`
+	ChangeAddressType       string        `long:"changeaddresstype" description:"type of address receiving change from staking transactions {default, p2wpkh, p2tr}. default sends change back to staker address. If the wallet cannot generate chosen type, default is used"`
+	MinChangeAmount         uint64        `long:"minchangeamount" description:"minimum change in satoshis for which change output is created. Smaller change is avoided by spending additional wallet outputs, if the wallet cannot afford that it is added to the transaction fee. Change below dust limit is always added to the fee"`
+	ConsolidateBelow        uint64        `long:"consolidatebelow" description:"if greater than 0, wallet outputs with value in satoshis below this amount are spent by staking transactions, even if they are not needed to fund them, consolidating them into the change. Outputs worth less than fee of spending them are skipped and at most 100 outputs are consolidated by one transaction. Reduces number of wallet outputs at the cost of higher fees"`
+	UnlockTimeout           time.Duration `long:"unlocktimeout" description:"duration for which wallet is unlocked with passphrase from config, whenever staker needs wallet private keys. Wallet is locked again as soon as signing finishes, unless keepwalletunlocked is set, timeout only bounds how long the wallet stays unlocked if locking fails"`
+	KeepWalletUnlocked      bool          `long:"keepwalletunlocked" description:"keep the wallet unlocked with passphrase from config for the whole lifetime of the daemon, renewing the unlock before unlocktimeout expires. By default wallet is unlocked just before signing and locked again right after"`
+	OperationUnlockTimeout  time.Duration `long:"operationunlocktimeout" description:"duration for which wallet is unlocked with passphrase provided for single operation. Wallet is locked again as soon as operation finishes, timeout only bounds how long the wallet stays unlocked if locking fails"`
+	AllowedDestinations     []string      `long:"alloweddestination" description:"address allowed to receive funds sent out by staker i.e change of staking transactions and spent stake. Can be specified multiple times. If none is provided, funds can be sent to any address"`
+	AutoImportAddresses     bool          `long:"autoimportaddresses" description:"import addresses receiving change of staking transactions and spent stake into the wallet, if wallet does not track them yet. Only supported by bitcoind backend, ignored for other backends"`
+	SignalRbf               bool          `long:"signalrbf" description:"signal opt-in replace-by-fee (BIP125) in inputs of staking transactions, so that they can be replaced with transactions paying higher fee"`
+	CoinSelectionStrategy   string        `long:"coinselection" description:"strategy of choosing wallet outputs funding transactions {largest-first, smallest-first, branch-and-bound}. branch-and-bound looks for outputs funding transaction without change and falls back to largest-first if there are none"`
+	AdditionalWallets       []string      `long:"additionalwallet" description:"name of additional bitcoind wallet from which delegations can be funded, reached through /wallet/<name> endpoint of the wallet rpc server with the same credentials. Can be specified multiple times. Additional wallets must be unlocked externally or with passphrase provided per operation. Only supported by bitcoind backend"`
+	ActiveChangeAddressType types.ChangeAddressType
+	// ActiveCoinSelectionStrategy is parsed CoinSelectionStrategy
+	ActiveCoinSelectionStrategy types.CoinSelectionStrategy
+	// ActiveAllowedDestinations are decoded AllowedDestinations
+	ActiveAllowedDestinations []btcutil.Address
 }
 
 func DefaultWalletConfig() WalletConfig {
 	return WalletConfig{
-		WalletName: "wallet",
-		WalletPass: "walletpass",
+		WalletName:             "wallet",
+		WalletPass:             "walletpass",
+		ChangeAddressType:      "default",
+		CoinSelectionStrategy:  "largest-first",
+		UnlockTimeout:          DefaultWalletUnlockTimeout,
+		OperationUnlockTimeout: DefaultOperationUnlockTimeout,
 	}
 }
 
+// UnlockTimeoutSecs returns UnlockTimeout in seconds, as expected by walletpassphrase
+func (cfg *WalletConfig) UnlockTimeoutSecs() int64 {
+	return int64(cfg.UnlockTimeout.Seconds())
+}
+
+// HasPassphrase returns true if passphrase to unlock the wallet is configured
+// in any way. Otherwise wallet is expected to be unlocked externally.
+func (cfg *WalletConfig) HasPassphrase() bool {
+	return cfg.WalletPass != "" || cfg.WalletPassFile != "" || cfg.WalletPassEnv != ""
+}
+
+// OperationUnlockTimeoutSecs returns OperationUnlockTimeout in seconds, as expected
+// by walletpassphrase
+func (cfg *WalletConfig) OperationUnlockTimeoutSecs() int64 {
+	return int64(cfg.OperationUnlockTimeout.Seconds())
+}
+
 type WalletRpcConfig struct {
-	Host             string `long:"wallethost" description:"location of the wallet rpc server"`
-	User             string `long:"walletuser" description:"user auth for the wallet rpc server"`
-	Pass             string `long:"walletpassword" description:"password auth for the wallet rpc server"`
-	DisableTls       bool   `long:"noclienttls" description:"disables tls for the wallet rpc client"`
-	RPCWalletCert    string `long:"rpcwalletcert" description:"File containing the wallet daemon's certificate file"`
-	RawRPCWalletCert string `long:"rawrpcwalletcert" description:"The raw bytes of the wallet daemon's PEM-encoded certificate chain which will be used to authenticate the RPC connection."`
+	Host              string        `long:"wallethost" description:"location of the wallet rpc server"`
+	User              string        `long:"walletuser" description:"user auth for the wallet rpc server"`
+	Pass              string        `long:"walletpassword" description:"password auth for the wallet rpc server"`
+	CookieFile        string        `long:"walletcookiefile" description:"path to the cookie file of the wallet rpc server e.g bitcoind .cookie file. It is re-read whenever it changes. Ignored if walletuser or walletpassword is provided explicitly"`
+	DisableTls        bool          `long:"noclienttls" description:"disables tls for the wallet rpc client"`
+	RPCWalletCert     string        `long:"rpcwalletcert" description:"File containing the wallet daemon's certificate file"`
+	RawRPCWalletCert  string        `long:"rawrpcwalletcert" description:"The raw bytes of the wallet daemon's PEM-encoded certificate chain which will be used to authenticate the RPC connection."`
+	ReadRetryAttempts uint          `long:"readretryattempts" description:"number of attempts of idempotent wallet reads (e.g public key or private key queries) in case of connection errors. 1 means no retries"`
+	ReadRetryDelay    time.Duration `long:"readretrydelay" description:"delay between attempts of idempotent wallet reads"`
 }
 
 func DefaultWalletRpcConfig() WalletRpcConfig {
 	return WalletRpcConfig{
-		DisableTls: true,
-		Host:       "localhost:18556",
-		User:       "rpcuser",
-		Pass:       "rpcpass",
+		DisableTls:        true,
+		Host:              "localhost:18556",
+		User:              "rpcuser",
+		Pass:              "rpcpass",
+		ReadRetryAttempts: defaultWalletReadRetryAttempts,
+		ReadRetryDelay:    defaultWalletReadRetryDelay,
 	}
 }
 
@@ -101,30 +180,68 @@ type JsonRpcServerConfig struct {
 	RawRPCListeners []string `long:"rpclisten" description:"Add an interface/port/socket to listen for RPC connections"`
 }
 
+type GrpcServerConfig struct {
+	RawGRPCListen string `long:"grpclisten" description:"The interface/port/socket to listen for gRPC connections. If empty, gRPC server is not started"`
+}
+
 type BtcNodeBackendConfig struct {
-	Nodetype            string    `long:"nodetype" description:"type of node to connect to {bitcoind, btcd}"`
-	WalletType          string    `long:"wallettype" description:"type of wallet to connect to {bitcoind, btcwallet}"`
-	FeeMode             string    `long:"feemode" description:"fee mode to use for fee estimation {static, dynamic}. In dynamic mode fee will be estimated using backend node"`
-	MinFeeRate          uint64    `long:"minfeerate" description:"minimum fee rate to use for fee estimation in sat/vbyte. If fee estimation by connected btc node returns a lower fee rate, this value will be used instead"`
-	MaxFeeRate          uint64    `long:"maxfeerate" description:"maximum fee rate to use for fee estimation in sat/vbyte. If fee estimation by connected btc node returns a higher fee rate, this value will be used instead. It is also used as fallback if fee estimation by connected btc node fails and as fee rate in case of static estimator"`
-	Btcd                *Btcd     `group:"btcd" namespace:"btcd"`
-	Bitcoind            *Bitcoind `group:"bitcoind" namespace:"bitcoind"`
-	EstimationMode      types.FeeEstimationMode
-	ActiveNodeBackend   types.SupportedNodeBackend
-	ActiveWalletBackend types.SupportedWalletBackend
+	Nodetype                  string        `long:"nodetype" description:"type of node to connect to {bitcoind, btcd}"`
+	WalletType                string        `long:"wallettype" description:"type of wallet to connect to {bitcoind, btcwallet}"`
+	FeeMode                   string        `long:"feemode" description:"fee mode to use for fee estimation {static, dynamic}. In dynamic mode fee will be estimated using backend node"`
+	MinFeeRate                uint64        `long:"minfeerate" description:"minimum fee rate to use for fee estimation in sat/vbyte. If fee estimation by connected btc node returns a lower fee rate, this value will be used instead. It is also the lower bound of fee rate estimated by fee api or fallback fee rate"`
+	MaxFeeRate                uint64        `long:"maxfeerate" description:"maximum fee rate to use for fee estimation in sat/vbyte. If fee estimation by connected btc node returns a higher fee rate, this value will be used instead. It is also the upper bound of fee rate estimated by fee api or fallback fee rate and fee rate in case of static estimator"`
+	MaxEstimationTarget       uint32        `long:"maxestimationtarget" description:"maximum confirmation target in blocks used in dynamic fee mode with bitcoind node. If estimate for the desired target is stale or unreliable, target is widened up to this value"`
+	FeeApiUrl                 string        `long:"feeapiurl" description:"url of external fee api queried in dynamic fee mode when connected btc node cannot estimate fee rate. Api must respond with json object mapping confirmation target in blocks to fee rate in sat/vbyte. If empty, fee api is not used"`
+	FeeApiTimeout             time.Duration `long:"feeapitimeout" description:"timeout of single request to fee api"`
+	FallbackFeeRate           uint64        `long:"fallbackfeerate" description:"fee rate in sat/vbyte used in dynamic fee mode when neither connected btc node nor fee api can estimate fee rate"`
+	MinRelayFeeRatePerKb      uint64        `long:"minrelayfeerateperkb" description:"minimum fee rate in sat/kvB of transactions created by staker i.e staking transactions, child transactions bumping their fee and transactions spending stake. Transactions paying lower fee rate would not be relayed by the network, so they are refused"`
+	MaxFeeRatePerKb           uint64        `long:"maxfeerateperkb" description:"maximum fee rate in sat/kvB of transactions created by staker i.e staking transactions, child transactions bumping their fee and transactions spending stake. Transaction is refused if either requested fee rate or fee paid by it, including change added to the fee, exceeds it. Must be 0 or at least maxfeerate. Zero disables the limit"`
+	FeeRateSanityPolicy       string        `long:"feeratesanitypolicy" description:"What to do when fee rate supplied by caller of staking or spending request is outside of tolerance band around estimated fee rate {warn, refuse}"`
+	FeeRateTolerance          float64       `long:"feeratetolerance" description:"Factor by which fee rate supplied by caller of staking or spending request can be lower or higher than estimated fee rate before fee rate sanity policy is applied. Must be at least 1"`
+	Btcd                      *Btcd         `group:"btcd" namespace:"btcd"`
+	Bitcoind                  *Bitcoind     `group:"bitcoind" namespace:"bitcoind"`
+	EstimationMode            types.FeeEstimationMode
+	ActiveFeeRateSanityPolicy types.FeeRateSanityPolicy
+	ActiveNodeBackend         types.SupportedNodeBackend
+	ActiveWalletBackend       types.SupportedWalletBackend
+}
+
+// ValidateFeeLimits checks that every fee rate returned by fee estimator, which
+// is bounded by minfeerate and maxfeerate, is within fee limits of transactions
+// created by staker
+func (c *BtcNodeBackendConfig) ValidateFeeLimits() error {
+	if c.MinFeeRate*1000 < c.MinRelayFeeRatePerKb {
+		return fmt.Errorf("minfeerate in sat/kvB must be at least minrelayfeerateperkb. minfeerate: %d, minrelayfeerateperkb: %d",
+			c.MinFeeRate*1000, c.MinRelayFeeRatePerKb)
+	}
+
+	// zero disables the ceiling
+	if c.MaxFeeRatePerKb > 0 && c.MaxFeeRatePerKb < c.MaxFeeRate*1000 {
+		return fmt.Errorf("maxfeerateperkb must be 0 or at least maxfeerate in sat/kvB. maxfeerateperkb: %d, maxfeerate: %d",
+			c.MaxFeeRatePerKb, c.MaxFeeRate*1000)
+	}
+
+	return nil
 }
 
 func DefaultBtcNodeBackendConfig() BtcNodeBackendConfig {
 	btcdConfig := DefaultBtcdConfig()
 	bitcoindConfig := DefaultBitcoindConfig()
 	return BtcNodeBackendConfig{
-		Nodetype:   "btcd",
-		WalletType: "btcwallet",
-		FeeMode:    defaultFeeMode,
-		MinFeeRate: DefaultMinFeeRate,
-		MaxFeeRate: DefaultMaxFeeRate,
-		Btcd:       &btcdConfig,
-		Bitcoind:   &bitcoindConfig,
+		Nodetype:             "btcd",
+		WalletType:           "btcwallet",
+		FeeMode:              defaultFeeMode,
+		MinFeeRate:           DefaultMinFeeRate,
+		MaxFeeRate:           DefaultMaxFeeRate,
+		MaxEstimationTarget:  DefaultMaxFeeEstimationTarget,
+		FeeApiTimeout:        DefaultFeeApiTimeout,
+		FallbackFeeRate:      DefaultFallbackFeeRate,
+		MinRelayFeeRatePerKb: DefaultMinRelayFeeRatePerKb,
+		MaxFeeRatePerKb:      DefaultMaxFeeRatePerKb,
+		FeeRateSanityPolicy:  "warn",
+		FeeRateTolerance:     DefaultFeeRateTolerance,
+		Btcd:                 &btcdConfig,
+		Bitcoind:             &bitcoindConfig,
 	}
 }
 
@@ -132,7 +249,30 @@ type StakerConfig struct {
 	BabylonStallingInterval   time.Duration `long:"babylonstallinginterval" description:"The interval for Babylon node BTC light client to catch up with the real chain before re-sending delegation request"`
 	UnbondingTxCheckInterval  time.Duration `long:"unbondingtxcheckinterval" description:"The interval for staker whether delegation received all covenant signatures"`
 	MaxConcurrentTransactions uint32        `long:"maxconcurrenttransactions" description:"Maximum concurrent transactions in flight to babylon node"`
+	MaxConcurrentStatusChecks uint32        `long:"maxconcurrentstatuschecks" description:"Maximum number of tracked delegations whose status is checked concurrently when staker starts"`
+	MinStakingTxConfirmations uint32        `long:"minstakingtxconfirmations" description:"Number of confirmations staking transaction must have before delegation is sent to Babylon. Must not be lower than confirmation time required by Babylon. Zero means confirmation time required by Babylon"`
+	MinBabylonBtcTipHeight    uint32        `long:"minbabylonbtctipheight" description:"Minimum height of Babylon BTC light client tip required before staking is allowed. Zero disables the check"`
+	MaxBabylonBtcLag          uint32        `long:"maxbabylonbtclag" description:"Maximum number of blocks Babylon BTC light client tip can lag behind BTC chain tip before staking is refused. Zero disables the check"`
+	LowBalanceThreshold       uint64        `long:"lowbalancethreshold" description:"Wallet balance in satoshis below which low balance alert is raised. Zero disables the monitor"`
+	LowBalanceHysteresis      uint64        `long:"lowbalancehysteresis" description:"Amount in satoshis by which wallet balance must rise above low balance threshold before next alert can be raised"`
+	BalanceCheckInterval      time.Duration `long:"balancecheckinterval" description:"The interval in which wallet balance is checked against low balance threshold"`
+	MaxMempoolResidence       time.Duration `long:"maxmempoolresidence" description:"Time staking transaction can stay unconfirmed in mempool before fee bump is escalated. Each further period of the same length escalates again. Zero disables the monitor"`
+	MempoolCheckInterval      time.Duration `long:"mempoolcheckinterval" description:"The interval in which time spent in mempool by unconfirmed staking transactions is checked"`
+	AutoFeeBump               bool          `long:"autofeebump" description:"Automatically bump fee of staking transaction with child-pays-for-parent transaction each time fee bump is escalated by exceeding maxmempoolresidence. Each escalation raises fee rate by a quarter of fee rate of staking transaction, but never below current fee estimate"`
+	ReadModelRefreshInterval  time.Duration `long:"readmodelrefreshinterval" description:"The interval in which babylon status of delegations cached in read model is refreshed. Zero disables the refresh"`
+	DuplicateFpDelegation     string        `long:"duplicatefpdelegation" description:"What to do when staker creates new delegation to finality provider it already has active delegation to {warn, refuse, allow}. refuse can be overridden per staking request"`
 	ExitOnCriticalError       bool          `long:"exitoncriticalerror" description:"Exit stakerd on critical error"`
+	SimulateOnly              bool          `long:"simulateonly" description:"Run staker against simulated btc chain and babylon. No transactions are broadcasted to btc network nor submitted to babylon. Data is stored in temporary database removed on exit, instead of configured one"`
+	SimulatedBlockInterval    time.Duration `long:"simulatedblockinterval" description:"The interval in which new blocks are mined by simulated btc chain. Used only in simulate only mode"`
+	MempoolAcceptCheck        bool          `long:"mempoolacceptcheck" description:"Check with btc node whether staking transaction would be accepted to mempool before broadcasting it, so that it is rejected early with reason reported by the node. Supported only by bitcoind backend, skipped for other backends"`
+	TimelockExpiryNotice      uint32        `long:"timelockexpirynotice" description:"Number of blocks before staking timelock of delegation expires at which early expiry notification is emitted. Notification is always emitted also when timelock expires and funds can be withdrawn. Zero disables early notification"`
+	CommandTimeout            time.Duration `long:"commandtimeout" description:"Maximum time single state changing operation e.g staking, unbonding or spending stake can spend changing state of delegations. Operations are executed one at a time, so this bounds time other operations wait when btc node or babylon node stops responding"`
+	WatchOnlyStakerPubKey     string        `long:"watchonlystakerpubkey" description:"Hex encoded x-only public key of staker key held outside of the wallet e.g on air-gapped machine. If set, staker runs in watch-only mode: staking transactions are built as unsigned psbts and signed externally. Staking with wallet keys, unbonding, spending stake and fee bumping are unavailable in this mode"`
+
+	ActiveDuplicateFpDelegationPolicy types.DuplicateFpDelegationPolicy
+	// ActiveWatchOnlyStakerPubKey is decoded WatchOnlyStakerPubKey, nil if
+	// staker does not run in watch-only mode
+	ActiveWatchOnlyStakerPubKey *btcec.PublicKey
 }
 
 func DefaultStakerConfig() StakerConfig {
@@ -140,7 +280,24 @@ func DefaultStakerConfig() StakerConfig {
 		BabylonStallingInterval:   1 * time.Minute,
 		UnbondingTxCheckInterval:  30 * time.Second,
 		MaxConcurrentTransactions: 1,
+		MaxConcurrentStatusChecks: 10,
+		MinStakingTxConfirmations: 0,
+		MinBabylonBtcTipHeight:    0,
+		MaxBabylonBtcLag:          0,
+		LowBalanceThreshold:       0,
+		LowBalanceHysteresis:      100000,
+		BalanceCheckInterval:      1 * time.Minute,
+		MaxMempoolResidence:       0,
+		AutoFeeBump:               false,
+		MempoolCheckInterval:      1 * time.Minute,
+		ReadModelRefreshInterval:  1 * time.Minute,
+		DuplicateFpDelegation:     "warn",
 		ExitOnCriticalError:       true,
+		SimulateOnly:              false,
+		SimulatedBlockInterval:    10 * time.Second,
+		MempoolAcceptCheck:        false,
+		TimelockExpiryNotice:      6,
+		CommandTimeout:            1 * time.Minute,
 	}
 }
 
@@ -170,11 +327,20 @@ type Config struct {
 
 	MetricsConfig *MetricsConfig `group:"metricsconfig" namespace:"metricsconfig"`
 
+	TracingConfig *TracingConfig `group:"tracingconfig" namespace:"tracingconfig"`
+
+	WebhookConfig *WebhookConfig `group:"webhookconfig" namespace:"webhookconfig"`
+
 	JsonRpcServerConfig *JsonRpcServerConfig
 
+	GrpcServerConfig *GrpcServerConfig
+
 	ActiveNetParams chaincfg.Params
 
 	RpcListeners []net.Addr
+
+	// nil if gRPC server is disabled
+	GrpcListener net.Addr
 }
 
 func DefaultConfig() Config {
@@ -186,6 +352,8 @@ func DefaultConfig() Config {
 	dbConfig := DefaultDBConfig()
 	stakerConfig := DefaultStakerConfig()
 	metricsCfg := DefaultMetricsConfig()
+	tracingCfg := DefaultTracingConfig()
+	webhookCfg := DefaultWebhookConfig()
 	return Config{
 		StakerdDir:           DefaultStakerdDir,
 		ConfigFile:           DefaultConfigFile,
@@ -200,6 +368,8 @@ func DefaultConfig() Config {
 		DBConfig:             &dbConfig,
 		StakerConfig:         &stakerConfig,
 		MetricsConfig:        &metricsCfg,
+		TracingConfig:        &tracingCfg,
+		WebhookConfig:        &webhookCfg,
 	}
 }
 
@@ -215,28 +385,27 @@ func (u *usageError) Error() string {
 	return u.err.Error()
 }
 
-// LoadConfig initializes and parses the config using a config file and command
-// line options.
-//
-// The configuration proceeds as follows:
-//  1. Start with a default config with sane settings
-//  2. Pre-parse the command line to check for an alternative config file
-//  3. Load configuration file overwriting defaults with any specified options
-//  4. Parse CLI options and overwrite/add any specified options
-func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
+// parsedConfig is configuration read from config file and command line, before
+// it is validated
+type parsedConfig struct {
+	cfg             Config
+	configFilePath  string
+	configFileError error
+	// set if wallet cookie file was dropped in favour of explicit credentials
+	walletCookieFileIgnored bool
+}
+
+// parseConfig reads configuration from default values, config file and command
+// line options, in that order of precedence
+func parseConfig() (*parsedConfig, error) {
 	// Pre-parse the command line options to pick up an alternative config
 	// file.
 	preCfg := DefaultConfig()
 
 	if _, err := flags.Parse(&preCfg); err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
-	// Show the version and exit if the version flag was specified.
-	appName := filepath.Base(os.Args[0])
-	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
-	usageMessage := fmt.Sprintf("Use %s -h to show usage", appName)
-
 	// If the config file path has not been modified by the user, then
 	// we'll use the default config file path. However, if the user has
 	// modified their default dir, then we should assume they intend to use
@@ -255,7 +424,7 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 	// exist under that path to avoid surprises.
 	case configFilePath != DefaultConfigFile:
 		if !FileExists(configFilePath) {
-			return nil, nil, nil, fmt.Errorf("specified config file does "+
+			return nil, fmt.Errorf("specified config file does "+
 				"not exist in %s", configFilePath)
 		}
 	}
@@ -270,7 +439,7 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 		// immediately, otherwise we can proceed as possibly the config
 		// file doesn't exist which is OK.
 		if _, ok := err.(*flags.IniError); ok {
-			return nil, nil, nil, err
+			return nil, err
 		}
 
 		configFileError = err
@@ -280,9 +449,72 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 	// they take precedence.
 	flagParser := flags.NewParser(&cfg, flags.Default)
 	if _, err := flagParser.Parse(); err != nil {
+		return nil, err
+	}
+
+	// Credentials provided explicitly take precedence over the cookie file,
+	// while default credentials are replaced by it
+	var walletCookieFileIgnored bool
+	if cfg.WalletRpcConfig.CookieFile != "" {
+		if isOptionSet("walletrpcconfig.walletuser", fileParser, flagParser) ||
+			isOptionSet("walletrpcconfig.walletpassword", fileParser, flagParser) {
+			cfg.WalletRpcConfig.CookieFile = ""
+			walletCookieFileIgnored = true
+		} else {
+			cfg.WalletRpcConfig.User = ""
+			cfg.WalletRpcConfig.Pass = ""
+		}
+	}
+
+	// Plaintext passphrase must not be readable by other users of the host
+	if configFileError == nil && isOptionSet("walletconfig.walletpassphrase", fileParser) {
+		if err := checkConfigFileNotWorldReadable(configFilePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &parsedConfig{
+		cfg:                     cfg,
+		configFilePath:          configFilePath,
+		configFileError:         configFileError,
+		walletCookieFileIgnored: walletCookieFileIgnored,
+	}, nil
+}
+
+// ReloadConfig reads and validates configuration in the same way as LoadConfig.
+// It is used to pick up changes of config file while staker is running, so
+// unlike LoadConfig it does not set up loggers nor write the config file.
+func ReloadConfig() (*Config, error) {
+	parsed, err := parseConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return ValidateConfig(parsed.cfg)
+}
+
+// LoadConfig initializes and parses the config using a config file and command
+// line options.
+//
+// The configuration proceeds as follows:
+//  1. Start with a default config with sane settings
+//  2. Pre-parse the command line to check for an alternative config file
+//  3. Load configuration file overwriting defaults with any specified options
+//  4. Parse CLI options and overwrite/add any specified options
+func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
+	parsed, err := parseConfig()
+	if err != nil {
 		return nil, nil, nil, err
 	}
 
+	cfg := parsed.cfg
+	configFilePath := parsed.configFilePath
+	configFileError := parsed.configFileError
+
+	appName := filepath.Base(os.Args[0])
+	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
+	usageMessage := fmt.Sprintf("Use %s -h to show usage", appName)
+
 	cfgLogger := logrus.New()
 	cfgLogger.Out = os.Stdout
 	// Make sure everything we just loaded makes sense.
@@ -312,6 +544,10 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 	cfgLogger.Out = mw
 	cfgLogger.Level = logRuslLevel
 
+	if parsed.walletCookieFileIgnored {
+		cfgLogger.Warn("Both wallet credentials and wallet cookie file provided, using credentials")
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done. This prevents the warning on help messages and invalid
 	// options.  Note this should go directly before the return.
@@ -320,7 +556,7 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 		if cleanCfg.DumpCfg {
 			cfgLogger.Infof("Writing configuration file to %s", configFilePath)
 			fileParser := flags.NewParser(&cfg, flags.Default)
-			err := flags.NewIniParser(fileParser).WriteFile(configFilePath, flags.IniIncludeComments|flags.IniIncludeDefaults)
+			err := WriteConfigFile(fileParser, configFilePath)
 			if err != nil {
 				cfgLogger.Warnf("Error writing configuration file: %v", err)
 				return nil, nil, nil, err
@@ -338,6 +574,18 @@ func LoadConfig() (*Config, *logrus.Logger, *zap.Logger, error) {
 	return cleanCfg, cfgLogger, zapLogger, nil
 }
 
+// isOptionSet returns true if option with given long name was set by any of
+// the parsers i.e provided in config file or on command line
+func isOptionSet(longName string, parsers ...*flags.Parser) bool {
+	for _, parser := range parsers {
+		if opt := parser.FindOptionByLongName(longName); opt != nil && opt.IsSet() {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ValidateConfig check the given configuration to be sane. This makes sure no
 // illegal values or combination of values are set. All file system paths are
 // normalized. The cleaned up config is returned on success.
@@ -380,6 +628,13 @@ func ValidateConfig(cfg Config) (*Config, error) {
 	// attempting to use them later on.
 	cfg.DataDir = CleanAndExpandPath(cfg.DataDir)
 	cfg.LogDir = CleanAndExpandPath(cfg.LogDir)
+	if cfg.WalletConfig.WalletPassFile != "" {
+		cfg.WalletConfig.WalletPassFile = CleanAndExpandPath(cfg.WalletConfig.WalletPassFile)
+	}
+
+	if cfg.WalletRpcConfig.CookieFile != "" {
+		cfg.WalletRpcConfig.CookieFile = CleanAndExpandPath(cfg.WalletRpcConfig.CookieFile)
+	}
 
 	// Multiple networks can't be selected simultaneously.  Count number of
 	// network flags passed; assign active network params
@@ -432,6 +687,67 @@ func ValidateConfig(cfg Config) (*Config, error) {
 	}
 	cfg.BtcNodeBackendConfig.ActiveWalletBackend = walletBackend
 
+	changeAddressType, err := types.NewChangeAddressType(cfg.WalletConfig.ChangeAddressType)
+	if err != nil {
+		return nil, mkErr("error getting change address type: %v", err)
+	}
+	cfg.WalletConfig.ActiveChangeAddressType = changeAddressType
+
+	coinSelectionStrategy, err := types.NewCoinSelectionStrategy(cfg.WalletConfig.CoinSelectionStrategy)
+	if err != nil {
+		return nil, mkErr("error getting coin selection strategy: %v", err)
+	}
+	cfg.WalletConfig.ActiveCoinSelectionStrategy = coinSelectionStrategy
+
+	duplicateFpDelegationPolicy, err := types.NewDuplicateFpDelegationPolicy(cfg.StakerConfig.DuplicateFpDelegation)
+	if err != nil {
+		return nil, mkErr("error getting duplicate finality provider delegation policy: %v", err)
+	}
+	cfg.StakerConfig.ActiveDuplicateFpDelegationPolicy = duplicateFpDelegationPolicy
+
+	if cfg.StakerConfig.WatchOnlyStakerPubKey != "" {
+		pkBytes, err := hex.DecodeString(cfg.StakerConfig.WatchOnlyStakerPubKey)
+		if err != nil {
+			return nil, mkErr("invalid watch-only staker public key: %v", err)
+		}
+
+		pk, err := schnorr.ParsePubKey(pkBytes)
+		if err != nil {
+			return nil, mkErr("invalid watch-only staker public key: %v", err)
+		}
+		cfg.StakerConfig.ActiveWatchOnlyStakerPubKey = pk
+	}
+
+	for _, encodedAddr := range cfg.WalletConfig.AllowedDestinations {
+		addr, err := btcutil.DecodeAddress(encodedAddr, &cfg.ActiveNetParams)
+		if err != nil {
+			return nil, mkErr("invalid allowed destination address %s: %v", encodedAddr, err)
+		}
+
+		if !addr.IsForNet(&cfg.ActiveNetParams) {
+			return nil, mkErr("allowed destination address %s is not for network %s", encodedAddr, cfg.ActiveNetParams.Name)
+		}
+
+		cfg.WalletConfig.ActiveAllowedDestinations = append(cfg.WalletConfig.ActiveAllowedDestinations, addr)
+	}
+
+	if len(cfg.WalletConfig.AdditionalWallets) > 0 && cfg.BtcNodeBackendConfig.ActiveWalletBackend != types.BitcoindWalletBackend {
+		return nil, mkErr("additionalwallet is only supported by bitcoind wallet backend")
+	}
+
+	additionalWallets := make(map[string]struct{}, len(cfg.WalletConfig.AdditionalWallets))
+	for _, walletName := range cfg.WalletConfig.AdditionalWallets {
+		if walletName == "" {
+			return nil, mkErr("additionalwallet must not be empty")
+		}
+
+		if _, ok := additionalWallets[walletName]; ok {
+			return nil, mkErr("additionalwallet %s specified more than once", walletName)
+		}
+
+		additionalWallets[walletName] = struct{}{}
+	}
+
 	switch cfg.BtcNodeBackendConfig.FeeMode {
 	case "static":
 		cfg.BtcNodeBackendConfig.EstimationMode = types.StaticFeeEstimation
@@ -449,10 +765,126 @@ func ValidateConfig(cfg Config) (*Config, error) {
 		return nil, mkErr("maxfeerate rate must be greater than 0")
 	}
 
+	if cfg.WalletRpcConfig.ReadRetryAttempts == 0 {
+		return nil, mkErr("readretryattempts must be greater than 0")
+	}
+
+	if cfg.BtcNodeBackendConfig.MaxEstimationTarget == 0 {
+		return nil, mkErr("maxestimationtarget must be greater than 0")
+	}
+
+	if cfg.BtcNodeBackendConfig.FallbackFeeRate == 0 {
+		return nil, mkErr("fallbackfeerate must be greater than 0")
+	}
+
+	if cfg.BtcNodeBackendConfig.FeeApiUrl != "" {
+		feeApiUrl, err := url.Parse(cfg.BtcNodeBackendConfig.FeeApiUrl)
+
+		if err != nil || (feeApiUrl.Scheme != "http" && feeApiUrl.Scheme != "https") || feeApiUrl.Host == "" {
+			return nil, mkErr(fmt.Sprintf("feeapiurl must be valid http or https url. feeapiurl: %s", cfg.BtcNodeBackendConfig.FeeApiUrl))
+		}
+
+		if cfg.BtcNodeBackendConfig.FeeApiTimeout <= 0 {
+			return nil, mkErr("feeapitimeout must be greater than 0")
+		}
+	}
+
 	if cfg.BtcNodeBackendConfig.MinFeeRate > cfg.BtcNodeBackendConfig.MaxFeeRate {
 		return nil, mkErr(fmt.Sprintf("minfeerate must be less or equal maxfeerate. minfeerate: %d, maxfeerate: %d", cfg.BtcNodeBackendConfig.MinFeeRate, cfg.BtcNodeBackendConfig.MaxFeeRate))
 	}
 
+	if err := cfg.BtcNodeBackendConfig.ValidateFeeLimits(); err != nil {
+		return nil, mkErr("%v", err)
+	}
+
+	feeRateSanityPolicy, err := types.NewFeeRateSanityPolicy(cfg.BtcNodeBackendConfig.FeeRateSanityPolicy)
+	if err != nil {
+		return nil, mkErr("error getting fee rate sanity policy: %v", err)
+	}
+	cfg.BtcNodeBackendConfig.ActiveFeeRateSanityPolicy = feeRateSanityPolicy
+
+	if cfg.BtcNodeBackendConfig.FeeRateTolerance < 1 {
+		return nil, mkErr(fmt.Sprintf("feeratetolerance must be at least 1. feeratetolerance: %f", cfg.BtcNodeBackendConfig.FeeRateTolerance))
+	}
+
+	// walletpassphrase accepts timeout in whole seconds
+	if cfg.WalletConfig.UnlockTimeout < time.Second {
+		return nil, mkErr("unlocktimeout must be at least 1s")
+	}
+
+	if cfg.WalletConfig.OperationUnlockTimeout < time.Second {
+		return nil, mkErr("operationunlocktimeout must be at least 1s")
+	}
+
+	if cfg.WalletConfig.KeepWalletUnlocked && !cfg.WalletConfig.HasPassphrase() {
+		return nil, mkErr("keepwalletunlocked requires wallet passphrase to be configured")
+	}
+
+	if cfg.StakerConfig.MaxConcurrentStatusChecks == 0 {
+		return nil, mkErr("maxconcurrentstatuschecks must be greater than 0")
+	}
+
+	if cfg.StakerConfig.LowBalanceThreshold > 0 && cfg.StakerConfig.BalanceCheckInterval <= 0 {
+		return nil, mkErr("balancecheckinterval must be greater than 0 when lowbalancethreshold is set")
+	}
+
+	if cfg.BabylonConfig.InsufficientFeeGasFactor < 1 {
+		return nil, mkErr("insufficient-fee-gas-factor must be at least 1")
+	}
+
+	if cfg.BabylonConfig.InsufficientFeeGasFactor > 1 &&
+		cfg.BabylonConfig.MaxGasAdjustment < cfg.BabylonConfig.GasAdjustment {
+		return nil, mkErr("max-gas-adjustment must not be lower than gas-adjustment")
+	}
+
+	if cfg.BabylonConfig.SubmissionMaxAttempts == 0 {
+		return nil, mkErr("submission-max-attempts must be greater than 0")
+	}
+
+	if cfg.BabylonConfig.SubmissionRetryBaseDelay <= 0 {
+		return nil, mkErr("submission-retry-base-delay must be greater than 0")
+	}
+
+	if cfg.BabylonConfig.SubmissionRetryMaxDelay < cfg.BabylonConfig.SubmissionRetryBaseDelay {
+		return nil, mkErr("submission-retry-max-delay must not be lower than submission-retry-base-delay")
+	}
+
+	if cfg.StakerConfig.ReadModelRefreshInterval < 0 {
+		return nil, mkErr("readmodelrefreshinterval must not be negative")
+	}
+
+	if cfg.StakerConfig.MaxMempoolResidence < 0 {
+		return nil, mkErr("maxmempoolresidence must not be negative")
+	}
+
+	if cfg.StakerConfig.MaxMempoolResidence > 0 && cfg.StakerConfig.MempoolCheckInterval <= 0 {
+		return nil, mkErr("mempoolcheckinterval must be greater than 0 when maxmempoolresidence is set")
+	}
+
+	if cfg.StakerConfig.AutoFeeBump && cfg.StakerConfig.MaxMempoolResidence <= 0 {
+		return nil, mkErr("autofeebump requires maxmempoolresidence to be set")
+	}
+
+	if cfg.StakerConfig.CommandTimeout <= 0 {
+		return nil, mkErr("commandtimeout must be greater than 0")
+	}
+
+	if cfg.StakerConfig.SimulateOnly && cfg.StakerConfig.SimulatedBlockInterval <= 0 {
+		return nil, mkErr("simulatedblockinterval must be greater than 0 in simulate only mode")
+	}
+
+	if err := cfg.DBConfig.Validate(); err != nil {
+		return nil, mkErr("invalid db config: %v", err)
+	}
+
+	if err := cfg.TracingConfig.Validate(); err != nil {
+		return nil, mkErr("invalid tracing config: %v", err)
+	}
+
+	if err := cfg.WebhookConfig.Validate(); err != nil {
+		return nil, mkErr("invalid webhook config: %v", err)
+	}
+
 	// TODO: Validate node host and port
 	// TODO: Validate babylon config!
 
@@ -519,6 +951,25 @@ func ValidateConfig(cfg Config) (*Config, error) {
 		return nil, mkErr("error normalizing RPC listen addrs: %v", err)
 	}
 
+	if cfg.GrpcServerConfig != nil && cfg.GrpcServerConfig.RawGRPCListen != "" {
+		grpcListeners, err := lncfg.NormalizeAddresses(
+			[]string{cfg.GrpcServerConfig.RawGRPCListen}, strconv.Itoa(DefaultGRPCPort),
+			net.ResolveTCPAddr,
+		)
+
+		if err != nil {
+			return nil, mkErr("error normalizing gRPC listen addr: %v", err)
+		}
+
+		cfg.GrpcListener = grpcListeners[0]
+
+		for _, rpcListener := range cfg.RpcListeners {
+			if rpcListener.String() == cfg.GrpcListener.String() {
+				return nil, mkErr("gRPC listen addr %s is already used by RPC server", cfg.GrpcListener)
+			}
+		}
+	}
+
 	// All good, return the sanitized result.
 	return &cfg, nil
 }
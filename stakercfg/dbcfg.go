@@ -1,16 +1,30 @@
 package stakercfg
 
 import (
+	"context"
+	"fmt"
+	"os"
 	"time"
 
 	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/kvdb/etcd"
 )
 
 const (
 	defaultDbName = "staker.db"
+
+	// BoltDbBackend stores data in local bbolt database file
+	BoltDbBackend = "bbolt"
+
+	// EtcdDbBackend stores data in remote etcd cluster, which allows multiple
+	// staker instances to share the same state
+	EtcdDbBackend = "etcd"
 )
 
 type DBConfig struct {
+	// Backend is the type of database backend used to store staker data
+	Backend string `long:"backend" description:"The database backend to use. Etcd backend requires stakerd built with kvdb_etcd build tag" choice:"bbolt" choice:"etcd"`
+
 	// DBPath is the directory path in which the database file should be
 	// stored.
 	DBPath string `long:"dbpath" description:"The directory path in which the database file should be stored."`
@@ -38,16 +52,44 @@ type DBConfig struct {
 	// DBTimeout specifies the timeout value to use when opening the wallet
 	// database.
 	DBTimeout time.Duration `long:"dbtimeout" description:"Specifies the timeout value to use when opening the wallet database."`
+
+	// Etcd holds connection settings used when etcd backend is selected.
+	Etcd *etcd.Config `group:"etcd" namespace:"etcd"`
+}
+
+func (db *DBConfig) Validate() error {
+	switch db.Backend {
+	case BoltDbBackend:
+		return nil
+	case EtcdDbBackend:
+		if !kvdb.EtcdBackend {
+			return fmt.Errorf("etcd backend is not available, stakerd must be built with kvdb_etcd build tag")
+		}
+
+		if db.Etcd == nil || db.Etcd.Host == "" {
+			return fmt.Errorf("etcd host must be provided when etcd backend is selected")
+		}
+
+		if (db.Etcd.CertFile == "") != (db.Etcd.KeyFile == "") {
+			return fmt.Errorf("etcd cert file and key file must be provided together")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown db backend: %s", db.Backend)
+	}
 }
 
 func DefaultDBConfig() DBConfig {
 	return DBConfig{
+		Backend:           BoltDbBackend,
 		DBPath:            defaultDataDir,
 		DBFileName:        defaultDbName,
 		NoFreelistSync:    true,
 		AutoCompact:       false,
 		AutoCompactMinAge: kvdb.DefaultBoltAutoCompactMinAge,
 		DBTimeout:         kvdb.DefaultDBTimeout,
+		Etcd:              &etcd.Config{},
 	}
 }
 
@@ -63,6 +105,34 @@ func DBConfigToBoltBackenCondfig(db *DBConfig) kvdb.BoltBackendConfig {
 }
 
 func GetDbBackend(cfg *DBConfig) (kvdb.Backend, error) {
-	boltConfig := DBConfigToBoltBackenCondfig(cfg)
-	return kvdb.GetBoltBackend(&boltConfig)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Backend {
+	case EtcdDbBackend:
+		return kvdb.Open(kvdb.EtcdBackendName, context.Background(), cfg.Etcd)
+	default:
+		boltConfig := DBConfigToBoltBackenCondfig(cfg)
+		return kvdb.GetBoltBackend(&boltConfig)
+	}
+}
+
+// TemporaryDBConfig returns copy of db config which stores data in bolt
+// database inside new temporary directory. It is used in simulate only mode, so
+// that simulated delegations never end up in the real database. It is up to the
+// caller to remove the directory.
+func TemporaryDBConfig(db *DBConfig) (*DBConfig, error) {
+	dir, err := os.MkdirTemp("", "stakerd-simulation-")
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary db directory: %w", err)
+	}
+
+	tmp := *db
+	tmp.Backend = BoltDbBackend
+	tmp.DBPath = dir
+	tmp.DBFileName = defaultDbName
+
+	return &tmp, nil
 }
@@ -0,0 +1,47 @@
+package stakercfg
+
+import (
+	"fmt"
+)
+
+const (
+	defaultTracingExporterEndpoint = "127.0.0.1:4317"
+	defaultTracingSamplingRatio    = 1.0
+)
+
+// TracingConfig defines configuration of OpenTelemetry tracing
+type TracingConfig struct {
+	// Whether spans are exported at all
+	Enabled bool `long:"enabled" description:"enables exporting OpenTelemetry traces of staking operations"`
+	// Address of the OTLP gRPC collector
+	ExporterEndpoint string `long:"exporterendpoint" description:"host:port of OTLP gRPC collector receiving traces"`
+	// Whether to connect to the collector without TLS
+	Insecure bool `long:"insecure" description:"disables tls for connection to OTLP collector"`
+	// Fraction of traces which are sampled
+	SamplingRatio float64 `long:"samplingratio" description:"fraction of traces which are sampled, between 0 and 1"`
+}
+
+func (cfg *TracingConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if cfg.ExporterEndpoint == "" {
+		return fmt.Errorf("exporter endpoint must be provided when tracing is enabled")
+	}
+
+	if cfg.SamplingRatio < 0 || cfg.SamplingRatio > 1 {
+		return fmt.Errorf("invalid sampling ratio: %f", cfg.SamplingRatio)
+	}
+
+	return nil
+}
+
+func DefaultTracingConfig() TracingConfig {
+	return TracingConfig{
+		Enabled:          false,
+		ExporterEndpoint: defaultTracingExporterEndpoint,
+		Insecure:         false,
+		SamplingRatio:    defaultTracingSamplingRatio,
+	}
+}
@@ -2,8 +2,11 @@ package stakercfg
 
 import (
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
+
+	"github.com/jessevdk/go-flags"
 )
 
 func ReadCertFile(rawCert string, certFilePath string) ([]byte, error) {
@@ -29,3 +32,33 @@ func ReadCertFile(rawCert string, certFilePath string) ([]byte, error) {
 		return rpcCert, nil
 	}
 }
+
+// checkConfigFileNotWorldReadable returns error if config file at given path
+// can be read by any user of the host.
+func checkConfigFileNotWorldReadable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0004 != 0 {
+		return fmt.Errorf("config file %s containing walletpassphrase is world-readable (permissions %04o), "+
+			"restrict its permissions or provide passphrase with walletpassphrasefile or walletpassphraseenv",
+			path, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+// WriteConfigFile writes config parsed by the parser to the file at given path.
+// File is created accessible only by its owner, as it may contain wallet
+// passphrase.
+func WriteConfigFile(parser *flags.Parser, path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	flags.NewIniParser(parser).Write(f, flags.IniIncludeComments|flags.IniIncludeDefaults)
+	return f.Close()
+}
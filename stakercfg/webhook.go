@@ -0,0 +1,80 @@
+package stakercfg
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultWebhookMaxRetries     = 5
+	defaultWebhookRetryBaseDelay = 1 * time.Second
+	defaultWebhookMaxRetryDelay  = 1 * time.Minute
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultWebhookQueueSize      = 1000
+)
+
+// WebhookConfig defines configuration of webhook receiving delegation state
+// transitions
+type WebhookConfig struct {
+	// Url to which state transitions are posted, empty disables the webhook
+	Url string `long:"url" description:"http or https url to which each delegation state transition is posted as json. Empty disables the webhook"`
+	// Secret used to sign the payload
+	Secret string `long:"secret" description:"secret used to sign payload with hmac-sha256. Signature is sent in X-Staker-Signature header. Empty disables signing"`
+	// Number of retries of failed delivery
+	MaxRetries uint32 `long:"maxretries" description:"number of times failed delivery is retried before it is dropped"`
+	// Delay before the first retry, doubled on each next retry
+	RetryBaseDelay time.Duration `long:"retrybasedelay" description:"delay before the first retry of failed delivery, doubled with each next retry"`
+	// Upper bound of delay between retries
+	MaxRetryDelay time.Duration `long:"maxretrydelay" description:"maximum delay between retries of failed delivery"`
+	// Timeout of single delivery attempt
+	Timeout time.Duration `long:"timeout" description:"timeout of single delivery attempt"`
+	// Number of deliveries waiting for delivery worker
+	QueueSize uint32 `long:"queuesize" description:"number of state transitions waiting for delivery. State transitions which do not fit into the queue are dropped"`
+}
+
+func (cfg *WebhookConfig) Enabled() bool {
+	return cfg.Url != ""
+}
+
+func (cfg *WebhookConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	webhookUrl, err := url.Parse(cfg.Url)
+
+	if err != nil || (webhookUrl.Scheme != "http" && webhookUrl.Scheme != "https") || webhookUrl.Host == "" {
+		return fmt.Errorf("url must be valid http or https url: %s", cfg.Url)
+	}
+
+	if cfg.RetryBaseDelay <= 0 {
+		return fmt.Errorf("retry base delay must be greater than 0")
+	}
+
+	if cfg.MaxRetryDelay < cfg.RetryBaseDelay {
+		return fmt.Errorf("max retry delay must not be lower than retry base delay")
+	}
+
+	if cfg.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+
+	if cfg.QueueSize == 0 {
+		return fmt.Errorf("queue size must be greater than 0")
+	}
+
+	return nil
+}
+
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Url:            "",
+		Secret:         "",
+		MaxRetries:     defaultWebhookMaxRetries,
+		RetryBaseDelay: defaultWebhookRetryBaseDelay,
+		MaxRetryDelay:  defaultWebhookMaxRetryDelay,
+		Timeout:        defaultWebhookTimeout,
+		QueueSize:      defaultWebhookQueueSize,
+	}
+}
@@ -0,0 +1,23 @@
+//go:build kvdb_etcd
+
+package stakerdb_test
+
+import (
+	"testing"
+
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreOnEtcdBackend(t *testing.T) {
+	etcdCfg, cleanup, err := kvdb.StartEtcdTestBackend(t.TempDir(), 0, 0, "")
+	require.NoError(t, err)
+	defer cleanup()
+
+	cfg := stakercfg.DefaultDBConfig()
+	cfg.Backend = stakercfg.EtcdDbBackend
+	cfg.Etcd = etcdCfg
+
+	checkStoreOnBackend(t, &cfg)
+}
@@ -0,0 +1,75 @@
+package stakerdb_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/stretchr/testify/require"
+)
+
+// checkStoreOnBackend opens store on backend described by provided config,
+// stores transaction and checks it can be read back after reopening the store
+func checkStoreOnBackend(t *testing.T, cfg *stakercfg.DBConfig) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	storedTx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(storedTx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	backend, err := stakercfg.GetDbBackend(cfg)
+	require.NoError(t, err)
+
+	s, err := stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+
+	err = s.AddTransaction(
+		storedTx.StakingTx,
+		storedTx.StakingOutputIndex,
+		storedTx.StakingTime,
+		storedTx.FinalityProvidersBtcPks,
+		storedTx.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+
+	backend, err = stakercfg.GetDbBackend(cfg)
+	require.NoError(t, err)
+	defer backend.Close()
+
+	s, err = stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+
+	txHash := storedTx.StakingTx.TxHash()
+	tx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, storedTx.StakingTx, tx.StakingTx)
+	require.Equal(t, storedTx.StakingTime, tx.StakingTime)
+	require.Equal(t, storedTx.StakerAddress, tx.StakerAddress)
+}
+
+func TestStoreOnBoltBackend(t *testing.T) {
+	cfg := stakercfg.DefaultDBConfig()
+	cfg.DBPath = t.TempDir()
+
+	checkStoreOnBackend(t, &cfg)
+}
+
+func TestEtcdBackendRequiresBuildTag(t *testing.T) {
+	if kvdb.EtcdBackend {
+		t.Skip("etcd backend is available")
+	}
+
+	cfg := stakercfg.DefaultDBConfig()
+	cfg.Backend = stakercfg.EtcdDbBackend
+	cfg.Etcd.Host = "localhost:2379"
+
+	_, err := stakercfg.GetDbBackend(&cfg)
+	require.Error(t, err)
+}
@@ -15,7 +15,23 @@ var (
 	// ErrWatchedDataNotFound given watched data do not exists
 	ErrWatchedDataNotFound = errors.New("watched transaction data not found")
 
+	// ErrPreparedDataNotFound given prepared data do not exists
+	ErrPreparedDataNotFound = errors.New("prepared transaction data not found")
+
+	// ErrTransactionNotPrepared transaction is not waiting for external signatures
+	ErrTransactionNotPrepared = errors.New("transaction is not in prepared state")
+
+	// ErrTransactionNotConfirmed transaction is not in confirmed on btc state
+	ErrTransactionNotConfirmed = errors.New("transaction is not in confirmed on btc state")
+
 	ErrInvalidUnbondingDataUpdate = errors.New("invalid unbonding data update")
 
 	ErrUnbondingDataNotFound = errors.New("unbonding transaction data not found")
+
+	// ErrInvalidExport given stream is not valid export of transactions db
+	ErrInvalidExport = errors.New("invalid transactions db export")
+
+	// ErrUnsupportedExportVersion export was written in format this version
+	// of the staker cannot read
+	ErrUnsupportedExportVersion = errors.New("unsupported transactions db export version")
 )
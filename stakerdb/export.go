@@ -0,0 +1,441 @@
+package stakerdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcwallet/walletdb"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"google.golang.org/protobuf/encoding/protodelim"
+	pm "google.golang.org/protobuf/proto"
+)
+
+const (
+	// ExportVersion is version of the export format written by ExportTransactions.
+	// It must be bumped whenever the format changes in a way older versions of
+	// the staker cannot read.
+	ExportVersion uint32 = 1
+)
+
+var (
+	// exportMagic starts every export stream, so that random files are rejected
+	// before any record is parsed
+	exportMagic = []byte("btcstakerdb")
+)
+
+// Export stream consists of header, which is exportMagic followed by big endian
+// uint32 version, and then of delegation records. Every record is
+// proto.DelegationRecord prefixed with its size encoded as uvarint.
+
+func writeExportHeader(w io.Writer) error {
+	if _, err := w.Write(exportMagic); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.BigEndian, ExportVersion)
+}
+
+func readExportHeader(r io.Reader) error {
+	magic := make([]byte, len(exportMagic))
+
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return fmt.Errorf("%w: failed to read header: %v", ErrInvalidExport, err)
+	}
+
+	if !bytes.Equal(magic, exportMagic) {
+		return fmt.Errorf("%w: not a staker delegation export", ErrInvalidExport)
+	}
+
+	var version uint32
+	if err := binary.Read(r, binary.BigEndian, &version); err != nil {
+		return fmt.Errorf("%w: failed to read version: %v", ErrInvalidExport, err)
+	}
+
+	if version != ExportVersion {
+		return fmt.Errorf("%w: %d, supported version: %d", ErrUnsupportedExportVersion, version, ExportVersion)
+	}
+
+	return nil
+}
+
+// ExportTransactions writes all tracked transactions together with their
+// watched or prepared data to provided writer, in order in which they were
+// added to the store. All transactions are read from single read transaction,
+// so export is consistent even if store is modified in the meantime.
+// Returns number of exported transactions.
+func (c *TrackedTransactionStore) ExportTransactions(w io.Writer) (int, error) {
+	var records []*proto.DelegationRecord
+
+	err := kvdb.View(c.db, func(tx kvdb.RTx) error {
+		txIdxBucket := tx.ReadBucket(transactionIndexName)
+		txBucket := tx.ReadBucket(transactionBucketName)
+		watchedTxBucket := tx.ReadBucket(watchedTxDataBucketName)
+		preparedTxBucket := tx.ReadBucket(preparedTxDataBucketName)
+
+		if txIdxBucket == nil || txBucket == nil || watchedTxBucket == nil || preparedTxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		// transactions bucket is keyed by transaction index, so to export
+		// transactions in order they were added we need to map index back to
+		// transaction hash
+		hashByKey := make(map[string][]byte)
+
+		err := txIdxBucket.ForEach(func(k, v []byte) error {
+			if bytes.Equal(k, numTxKey) {
+				return nil
+			}
+
+			hashByKey[string(v)] = k
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return txBucket.ForEach(func(k, v []byte) error {
+			txHashBytes, ok := hashByKey[string(k)]
+			if !ok {
+				return ErrCorruptedTransactionsDb
+			}
+
+			var record proto.DelegationRecord
+			record.StakingTxHash = txHashBytes
+			record.TrackedTransaction = &proto.TrackedTransaction{}
+
+			if err := pm.Unmarshal(v, record.TrackedTransaction); err != nil {
+				return ErrCorruptedTransactionsDb
+			}
+
+			if watchedData := watchedTxBucket.Get(txHashBytes); watchedData != nil {
+				record.WatchedTxData = &proto.WatchedTxData{}
+
+				if err := pm.Unmarshal(watchedData, record.WatchedTxData); err != nil {
+					return ErrCorruptedTransactionsDb
+				}
+			}
+
+			if preparedData := preparedTxBucket.Get(txHashBytes); preparedData != nil {
+				record.PreparedTxData = &proto.WatchedTxData{}
+
+				if err := pm.Unmarshal(preparedData, record.PreparedTxData); err != nil {
+					return ErrCorruptedTransactionsDb
+				}
+			}
+
+			records = append(records, &record)
+			return nil
+		})
+	}, func() {
+		records = nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+
+	if err := writeExportHeader(bw); err != nil {
+		return 0, err
+	}
+
+	for _, record := range records {
+		if _, err := protodelim.MarshalTo(bw, record); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return 0, err
+	}
+
+	return len(records), nil
+}
+
+// validateDelegationRecord checks that record holds tracked transaction which
+// can be read back from the store, together with all data required by its state
+func validateDelegationRecord(record *proto.DelegationRecord) (*chainhash.Hash, error) {
+	txHash, err := chainhash.NewHash(record.StakingTxHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid staking transaction hash: %w", err)
+	}
+
+	if record.TrackedTransaction == nil {
+		return nil, fmt.Errorf("missing tracked transaction of %s", txHash)
+	}
+
+	storedTx, err := protoTxToStoredTransaction(record.TrackedTransaction)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracked transaction %s: %w", txHash, err)
+	}
+
+	if storedTx.StakingTx.TxHash() != *txHash {
+		return nil, fmt.Errorf("staking transaction does not match hash %s", txHash)
+	}
+
+	if len(storedTx.FinalityProvidersBtcPks) == 0 {
+		return nil, fmt.Errorf("transaction %s has no finality providers public keys", txHash)
+	}
+
+	if _, ok := proto.TransactionState_name[int32(storedTx.State)]; !ok {
+		return nil, fmt.Errorf("transaction %s has unknown state %d", txHash, storedTx.State)
+	}
+
+	if record.WatchedTxData != nil {
+		if _, err := protoWatchedDataToWatchedTransactionData(record.WatchedTxData); err != nil {
+			return nil, fmt.Errorf("invalid watched data of transaction %s: %w", txHash, err)
+		}
+	}
+
+	if record.PreparedTxData != nil {
+		if _, err := protoPreparedDataToPreparedTransactionData(record.PreparedTxData); err != nil {
+			return nil, fmt.Errorf("invalid prepared data of transaction %s: %w", txHash, err)
+		}
+	}
+
+	switch {
+	case storedTx.State == proto.TransactionState_PREPARED && record.PreparedTxData == nil:
+		return nil, fmt.Errorf("prepared transaction %s has no prepared data", txHash)
+	case storedTx.State != proto.TransactionState_PREPARED && storedTx.Watched && record.WatchedTxData == nil:
+		return nil, fmt.Errorf("watched transaction %s has no watched data", txHash)
+	}
+
+	return txHash, nil
+}
+
+// readDelegationRecords reads and validates whole export stream, so that
+// nothing is imported if any of the records is invalid
+func readDelegationRecords(r io.Reader) ([]*proto.DelegationRecord, error) {
+	br := bufio.NewReader(r)
+
+	if err := readExportHeader(br); err != nil {
+		return nil, err
+	}
+
+	var records []*proto.DelegationRecord
+	seen := make(map[chainhash.Hash]struct{})
+
+	for {
+		var record proto.DelegationRecord
+		err := protodelim.UnmarshalFrom(br, &record)
+
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read record %d: %v", ErrInvalidExport, len(records), err)
+		}
+
+		txHash, err := validateDelegationRecord(&record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: %v", ErrInvalidExport, len(records), err)
+		}
+
+		if _, ok := seen[*txHash]; ok {
+			return nil, fmt.Errorf("%w: transaction %s exported more than once", ErrInvalidExport, txHash)
+		}
+
+		seen[*txHash] = struct{}{}
+		records = append(records, &record)
+	}
+}
+
+// removeFromIndexes removes transaction from finality provider and state indexes
+func removeFromIndexes(rwTx kvdb.RwTx, txHashBytes []byte, tx *proto.TrackedTransaction) error {
+	fpIdxBucket := rwTx.ReadWriteBucket(finalityProviderIdxBucketName)
+	stateIdxBucket := rwTx.ReadWriteBucket(stateIdxBucketName)
+
+	if fpIdxBucket == nil || stateIdxBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	for _, fpPk := range tx.FinalityProvidersBtcPks {
+		if fpBucket := fpIdxBucket.NestedReadWriteBucket(fpPk); fpBucket != nil {
+			if err := fpBucket.Delete(txHashBytes); err != nil {
+				return err
+			}
+		}
+	}
+
+	if stateBucket := stateIdxBucket.NestedReadWriteBucket(stateIdxKey(tx.State)); stateBucket != nil {
+		return stateBucket.Delete(txHashBytes)
+	}
+
+	return nil
+}
+
+func putOptionalData(bucket walletdb.ReadWriteBucket, txHashBytes []byte, data *proto.WatchedTxData) error {
+	if data == nil {
+		return bucket.Delete(txHashBytes)
+	}
+
+	marshalled, err := pm.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(txHashBytes, marshalled)
+}
+
+func importDelegationRecord(rwTx kvdb.RwTx, record *proto.DelegationRecord, force bool) (proto.TransactionState, bool, error) {
+	txIdxBucket := rwTx.ReadWriteBucket(transactionIndexName)
+	txBucket := rwTx.ReadWriteBucket(transactionBucketName)
+	watchedTxBucket := rwTx.ReadWriteBucket(watchedTxDataBucketName)
+	preparedTxBucket := rwTx.ReadWriteBucket(preparedTxDataBucketName)
+	fpIdxBucket := rwTx.ReadWriteBucket(finalityProviderIdxBucketName)
+	stateIdxBucket := rwTx.ReadWriteBucket(stateIdxBucketName)
+
+	if txIdxBucket == nil || txBucket == nil || watchedTxBucket == nil ||
+		preparedTxBucket == nil || fpIdxBucket == nil || stateIdxBucket == nil {
+		return 0, false, ErrCorruptedTransactionsDb
+	}
+
+	txHashBytes := record.StakingTxHash
+	// copy, so that imported records are not modified if db transaction
+	// needs to be retried
+	tt := pm.Clone(record.TrackedTransaction).(*proto.TrackedTransaction)
+
+	// new delegation is reported as leaving initial state, the same as
+	// delegation added by staker
+	prevState := proto.TransactionState_SENT_TO_BTC
+	txKey := txIdxBucket.Get(txHashBytes)
+	added := txKey == nil
+
+	if txKey != nil {
+		if !force {
+			txHash, _ := chainhash.NewHash(txHashBytes)
+			return 0, false, fmt.Errorf("%w: %s", ErrDuplicateTransaction, txHash)
+		}
+
+		// existing transaction is overwritten in place, so it keeps its index
+		maybeTx := txBucket.Get(txKey)
+		if maybeTx == nil {
+			return 0, false, ErrCorruptedTransactionsDb
+		}
+
+		var existingTx proto.TrackedTransaction
+		if err := pm.Unmarshal(maybeTx, &existingTx); err != nil {
+			return 0, false, ErrCorruptedTransactionsDb
+		}
+
+		if err := removeFromIndexes(rwTx, txHashBytes, &existingTx); err != nil {
+			return 0, false, err
+		}
+
+		prevState = existingTx.State
+	} else {
+		nextKey := nextTxKey(txIdxBucket)
+		txKey = uint64KeyToBytes(nextKey)
+
+		if err := txIdxBucket.Put(txHashBytes, txKey); err != nil {
+			return 0, false, err
+		}
+
+		if err := txIdxBucket.Put(numTxKey, uint64KeyToBytes(nextKey+1)); err != nil {
+			return 0, false, err
+		}
+	}
+
+	tt.TrackedTransactionIdx = binary.BigEndian.Uint64(txKey)
+
+	marshalled, err := pm.Marshal(tt)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if err := txBucket.Put(txKey, marshalled); err != nil {
+		return 0, false, err
+	}
+
+	if err := putOptionalData(watchedTxBucket, txHashBytes, record.WatchedTxData); err != nil {
+		return 0, false, err
+	}
+
+	if err := putOptionalData(preparedTxBucket, txHashBytes, record.PreparedTxData); err != nil {
+		return 0, false, err
+	}
+
+	if err := indexTransactionByFinalityProviders(fpIdxBucket, txHashBytes, tt.FinalityProvidersBtcPks); err != nil {
+		return 0, false, err
+	}
+
+	if err := indexTransactionByState(stateIdxBucket, txHashBytes, tt.State); err != nil {
+		return 0, false, err
+	}
+
+	return prevState, added, nil
+}
+
+// ImportTransactions restores transactions written by ExportTransactions.
+// Whole stream is validated before anything is written and all transactions are
+// imported in single db transaction, so either all of them are imported or none.
+// If transaction already exists in the store, import fails with
+// ErrDuplicateTransaction unless force is set, in which case stored transaction
+// is overwritten by imported one. Update listeners are notified about every
+// imported transaction and state change listeners about its imported state.
+// New transactions are reported to tracked listeners as added in initial
+// SENT_TO_BTC state.
+// Returns number of imported transactions.
+func (c *TrackedTransactionStore) ImportTransactions(r io.Reader, force bool) (int, error) {
+	records, err := readDelegationRecords(r)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		prevStates []proto.TransactionState
+		added      []bool
+	)
+
+	err = kvdb.Update(c.db, func(tx kvdb.RwTx) error {
+		for _, record := range records {
+			prevState, isNew, err := importDelegationRecord(tx, record, force)
+			if err != nil {
+				return err
+			}
+
+			prevStates = append(prevStates, prevState)
+			added = append(added, isNew)
+		}
+
+		return nil
+	}, func() {
+		prevStates = nil
+		added = nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	for i, record := range records {
+		txHash, err := chainhash.NewHash(record.StakingTxHash)
+		if err != nil {
+			return 0, err
+		}
+
+		c.listenersMu.RLock()
+		for _, listener := range c.listeners {
+			listener(txHash)
+		}
+		c.listenersMu.RUnlock()
+
+		if added[i] {
+			c.notifyTracked(txHash, prevStates[i], true)
+		}
+
+		c.notifyStateChange(txHash, prevStates[i], record.TrackedTransaction.State)
+	}
+
+	return len(records), nil
+}
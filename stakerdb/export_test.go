@@ -0,0 +1,186 @@
+package stakerdb_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/babylon/testutil/datagen"
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+func addStoredTransaction(t *testing.T, s *stakerdb.TrackedTransactionStore, tx *stakerdb.StoredTransaction) {
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	err = s.AddTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+}
+
+func TestExportImportTransactions(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+
+	txs := genNStoredTransactions(t, r, 5, 200)
+	for _, tx := range txs {
+		addStoredTransaction(t, s, tx)
+	}
+
+	confirmedTxHash := txs[1].StakingTx.TxHash()
+	blockHash := datagen.GenRandomBtcdHash(r)
+	err := s.SetTxConfirmed(&confirmedTxHash, &blockHash, 100)
+	require.NoError(t, err)
+
+	prepared := genStoredTransaction(t, r, 200)
+	preparedTxHash := prepared.StakingTx.TxHash()
+	stakerAddr, err := btcutil.DecodeAddress(prepared.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	err = s.AddPreparedTransaction(
+		prepared.StakingTx,
+		prepared.StakingOutputIndex,
+		prepared.StakingTime,
+		prepared.FinalityProvidersBtcPks,
+		stakerAddr,
+		datagen.GenRandomTx(r),
+		datagen.GenRandomAccount().GetAddress(),
+		stakerKey.PubKey(),
+		datagen.GenRandomTx(r),
+		datagen.GenRandomTx(r),
+		101,
+	)
+	require.NoError(t, err)
+
+	var export bytes.Buffer
+	numExported, err := s.ExportTransactions(&export)
+	require.NoError(t, err)
+	require.Equal(t, 6, numExported)
+
+	// import into empty store restores all transactions with their indexes
+	imported := MakeTestStore(t)
+
+	type stateChange struct {
+		prev, next proto.TransactionState
+	}
+	stateChanges := make(map[chainhash.Hash]stateChange)
+	imported.AddStateChangeListener(func(txHash *chainhash.Hash, prevState, newState proto.TransactionState) {
+		stateChanges[*txHash] = stateChange{prevState, newState}
+	})
+
+	numImported, err := imported.ImportTransactions(bytes.NewReader(export.Bytes()), false)
+	require.NoError(t, err)
+	require.Equal(t, numExported, numImported)
+
+	// new delegations are reported as leaving initial state
+	require.Equal(t, map[chainhash.Hash]stateChange{
+		confirmedTxHash: {proto.TransactionState_SENT_TO_BTC, proto.TransactionState_CONFIRMED_ON_BTC},
+		preparedTxHash:  {proto.TransactionState_SENT_TO_BTC, proto.TransactionState_PREPARED},
+	}, stateChanges)
+
+	expectedTxs, err := s.GetAllStoredTransactions()
+	require.NoError(t, err)
+	importedTxs, err := imported.GetAllStoredTransactions()
+	require.NoError(t, err)
+	require.Equal(t, expectedTxs, importedTxs)
+
+	expectedPreparedData, err := s.GetPreparedTransactionData(&preparedTxHash)
+	require.NoError(t, err)
+	importedPreparedData, err := imported.GetPreparedTransactionData(&preparedTxHash)
+	require.NoError(t, err)
+	require.Equal(t, expectedPreparedData, importedPreparedData)
+
+	confirmedTxs, err := imported.GetTransactionsByState(proto.TransactionState_CONFIRMED_ON_BTC)
+	require.NoError(t, err)
+	require.Len(t, confirmedTxs, 1)
+	require.Equal(t, confirmedTxHash, confirmedTxs[0].StakingTx.TxHash())
+
+	fpTxs, err := imported.GetTransactionsByFinalityProvider(txs[0].FinalityProvidersBtcPks[0])
+	require.NoError(t, err)
+	require.Len(t, fpTxs, 1)
+
+	// transactions added after import get next free index
+	next := genStoredTransaction(t, r, 200)
+	addStoredTransaction(t, imported, next)
+	nextHash := next.StakingTx.TxHash()
+	nextTx, err := imported.GetTransaction(&nextHash)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), nextTx.StoredTransactionIdx)
+
+	// existing transactions are not overwritten without force
+	_, err = imported.ImportTransactions(bytes.NewReader(export.Bytes()), false)
+	require.ErrorIs(t, err, stakerdb.ErrDuplicateTransaction)
+
+	// with force existing transactions are overwritten in place
+	err = imported.SetTxSpentOnBtc(&confirmedTxHash)
+	require.NoError(t, err)
+	clear(stateChanges)
+	numImported, err = imported.ImportTransactions(bytes.NewReader(export.Bytes()), true)
+	require.NoError(t, err)
+	require.Equal(t, numExported, numImported)
+
+	// overwritten delegation is reported as leaving its stored state
+	require.Equal(t, map[chainhash.Hash]stateChange{
+		confirmedTxHash: {proto.TransactionState_SPENT_ON_BTC, proto.TransactionState_CONFIRMED_ON_BTC},
+	}, stateChanges)
+
+	importedTx, err := imported.GetTransaction(&confirmedTxHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, importedTx.State)
+
+	spentTxs, err := imported.GetTransactionsByState(proto.TransactionState_SPENT_ON_BTC)
+	require.NoError(t, err)
+	require.Empty(t, spentTxs)
+
+	allTxs, err := imported.GetAllStoredTransactions()
+	require.NoError(t, err)
+	require.Len(t, allTxs, 7)
+}
+
+func TestImportInvalidExport(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	addStoredTransaction(t, s, genStoredTransaction(t, r, 200))
+
+	var export bytes.Buffer
+	_, err := s.ExportTransactions(&export)
+	require.NoError(t, err)
+	exportBytes := export.Bytes()
+
+	imported := MakeTestStore(t)
+
+	_, err = imported.ImportTransactions(bytes.NewReader([]byte("not an export")), false)
+	require.ErrorIs(t, err, stakerdb.ErrInvalidExport)
+
+	// version follows the magic bytes at the start of the stream
+	versionOffset := bytes.IndexByte(exportBytes, 0)
+	futureVersion := bytes.Clone(exportBytes)
+	binary.BigEndian.PutUint32(futureVersion[versionOffset:], stakerdb.ExportVersion+1)
+	_, err = imported.ImportTransactions(bytes.NewReader(futureVersion), false)
+	require.ErrorIs(t, err, stakerdb.ErrUnsupportedExportVersion)
+
+	_, err = imported.ImportTransactions(bytes.NewReader(exportBytes[:len(exportBytes)-1]), false)
+	require.ErrorIs(t, err, stakerdb.ErrInvalidExport)
+
+	// nothing is imported from invalid export
+	txs, err := imported.GetAllStoredTransactions()
+	require.NoError(t, err)
+	require.Empty(t, txs)
+}
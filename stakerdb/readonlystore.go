@@ -0,0 +1,264 @@
+package stakerdb
+
+import (
+	"math"
+
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/lightningnetwork/lnd/kvdb"
+	pm "google.golang.org/protobuf/proto"
+)
+
+// ReadOnlyTrackedTransactionStore gives access to query methods of the store
+// only. It is meant for monitoring and reporting queries, which should not
+// contend with the write path.
+// Bolt backend takes exclusive lock on the db file, so the store cannot be opened
+// second time in read only mode while staker is running. Instead, every query is
+// executed in read transaction, which bolt serves from consistent snapshot of
+// the db without taking the writer lock, so long queries do not block writes.
+type ReadOnlyTrackedTransactionStore struct {
+	db kvdb.Backend
+}
+
+// StoreSnapshot is a consistent view of the store at the moment read transaction
+// was started. All queries made through the same snapshot observe the same state
+// of the store, regardless of writes made in the meantime.
+type StoreSnapshot struct {
+	tx kvdb.RTx
+}
+
+// ReadOnly returns read only accessor sharing db backend with the store
+func (c *TrackedTransactionStore) ReadOnly() *ReadOnlyTrackedTransactionStore {
+	return &ReadOnlyTrackedTransactionStore{db: c.db}
+}
+
+// View runs viewFn against snapshot of the store. Snapshot must not be used
+// after viewFn returns. reset is called before viewFn is retried, which may
+// happen with remote backends.
+func (r *ReadOnlyTrackedTransactionStore) View(viewFn func(s *StoreSnapshot) error, reset func()) error {
+	return kvdb.View(r.db, func(tx kvdb.RTx) error {
+		return viewFn(&StoreSnapshot{tx: tx})
+	}, reset)
+}
+
+func (r *ReadOnlyTrackedTransactionStore) GetTransaction(txHash *chainhash.Hash) (*StoredTransaction, error) {
+	var storedTx *StoredTransaction
+
+	err := r.View(func(s *StoreSnapshot) error {
+		var err error
+		storedTx, err = s.GetTransaction(txHash)
+		return err
+	}, func() {
+		storedTx = nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedTx, nil
+}
+
+func (r *ReadOnlyTrackedTransactionStore) QueryStoredTransactions(q StoredTransactionQuery) (StoredTransactionQueryResult, error) {
+	var resp StoredTransactionQueryResult
+
+	err := r.View(func(s *StoreSnapshot) error {
+		var err error
+		resp, err = s.QueryStoredTransactions(q)
+		return err
+	}, func() {
+		resp = StoredTransactionQueryResult{}
+	})
+
+	return resp, err
+}
+
+func (r *ReadOnlyTrackedTransactionStore) GetAllStoredTransactions() ([]StoredTransaction, error) {
+	var txs []StoredTransaction
+
+	err := r.View(func(s *StoreSnapshot) error {
+		var err error
+		txs, err = s.GetAllStoredTransactions()
+		return err
+	}, func() {
+		txs = nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return txs, nil
+}
+
+func (r *ReadOnlyTrackedTransactionStore) ScanTrackedTransactions(scanFunc StoredTransactionScanFn, reset func()) error {
+	return r.View(func(s *StoreSnapshot) error {
+		return s.ScanTrackedTransactions(scanFunc)
+	}, reset)
+}
+
+func (s *StoreSnapshot) GetTransaction(txHash *chainhash.Hash) (*StoredTransaction, error) {
+	transactionIdxBucket := s.tx.ReadBucket(transactionIndexName)
+
+	if transactionIdxBucket == nil {
+		return nil, ErrCorruptedTransactionsDb
+	}
+
+	transactionsBucket := s.tx.ReadBucket(transactionBucketName)
+	if transactionsBucket == nil {
+		return nil, ErrCorruptedTransactionsDb
+	}
+
+	maybeTx, _, err := getTxByHash(txHash.CloneBytes(), transactionIdxBucket, transactionsBucket)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var storedTxProto proto.TrackedTransaction
+	err = pm.Unmarshal(maybeTx, &storedTxProto)
+	if err != nil {
+		return nil, ErrCorruptedTransactionsDb
+	}
+
+	return protoTxToStoredTransaction(&storedTxProto)
+}
+
+func (s *StoreSnapshot) QueryStoredTransactions(q StoredTransactionQuery) (StoredTransactionQueryResult, error) {
+	var resp StoredTransactionQueryResult
+
+	transactionsBucket := s.tx.ReadBucket(transactionBucketName)
+	if transactionsBucket == nil {
+		return resp, ErrCorruptedTransactionsDb
+	}
+
+	transactionIdxBucket := s.tx.ReadBucket(transactionIndexName)
+
+	if transactionIdxBucket == nil {
+		return resp, ErrCorruptedTransactionsDb
+	}
+
+	numTransactions := getNumTx(transactionIdxBucket, s.tx.ReadBucket(countersBucketName))
+
+	if numTransactions == 0 {
+		return resp, nil
+	}
+
+	resp.Total = numTransactions
+
+	paginator := newPaginator(
+		transactionsBucket.ReadCursor(), q.Reversed, q.IndexOffset,
+		q.NumMaxTransactions,
+	)
+
+	accumulateTransactions := func(key, transaction []byte) (bool, error) {
+		protoTx := proto.TrackedTransaction{}
+
+		err := pm.Unmarshal(transaction, &protoTx)
+		if err != nil {
+			return false, err
+		}
+
+		txFromDb, err := protoTxToStoredTransaction(&protoTx)
+
+		if err != nil {
+			return false, err
+		}
+
+		if q.stateFilter != nil && txFromDb.State != *q.stateFilter {
+			return false, nil
+		}
+
+		// we have query only for withdrawable transaction i.e transactions which
+		// either in SENT_TO_BABYLON or DELEGATION_ACTIVE or UNBONDING_CONFIRMED_ON_BTC state and which timelock has expired
+		if q.withdrawableTransactionsFilter != nil {
+			var confirmationHeight uint32
+			var scriptTimeLock uint16
+
+			if txFromDb.Watched {
+				// cannot withdraw watched transaction directly through staker program
+				// at least for now.
+				return false, nil
+			}
+
+			if txFromDb.StakingTxConfirmedOnBtc() {
+				scriptTimeLock = txFromDb.StakingTime
+				confirmationHeight = txFromDb.StakingTxConfirmationInfo.Height
+			} else if txFromDb.IsUnbonded() {
+				scriptTimeLock = txFromDb.UnbondingTxData.UnbondingTime
+				confirmationHeight = txFromDb.UnbondingTxData.UnbondingTxConfirmationInfo.Height
+			} else {
+				return false, nil
+			}
+
+			timeLockExpired := isTimeLockExpired(
+				confirmationHeight,
+				scriptTimeLock,
+				q.withdrawableTransactionsFilter.currentBestBlockHeight,
+			)
+
+			if timeLockExpired {
+				resp.Transactions = append(resp.Transactions, *txFromDb)
+				return true, nil
+			} else {
+				return false, nil
+			}
+		} else {
+			resp.Transactions = append(resp.Transactions, *txFromDb)
+			return true, nil
+		}
+	}
+
+	if err := paginator.query(accumulateTransactions); err != nil {
+		return resp, err
+	}
+
+	if q.Reversed {
+		numTx := len(resp.Transactions)
+		for i := 0; i < numTx/2; i++ {
+			reverse := numTx - i - 1
+			resp.Transactions[i], resp.Transactions[reverse] =
+				resp.Transactions[reverse], resp.Transactions[i]
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *StoreSnapshot) GetAllStoredTransactions() ([]StoredTransaction, error) {
+	q := DefaultStoredTransactionQuery()
+	// MaxUint64 indicates we will scan over all transactions
+	q.NumMaxTransactions = math.MaxUint64
+
+	resp, err := s.QueryStoredTransactions(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Transactions, nil
+}
+
+func (s *StoreSnapshot) ScanTrackedTransactions(scanFunc StoredTransactionScanFn) error {
+	transactionsBucket := s.tx.ReadBucket(transactionBucketName)
+
+	if transactionsBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	return transactionsBucket.ForEach(func(k, v []byte) error {
+		var storedTxProto proto.TrackedTransaction
+		err := pm.Unmarshal(v, &storedTxProto)
+
+		if err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		txFromDb, err := protoTxToStoredTransaction(&storedTxProto)
+
+		if err != nil {
+			return err
+		}
+
+		return scanFunc(txFromDb)
+	})
+}
@@ -0,0 +1,152 @@
+package stakerdb_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/babylon/testutil/datagen"
+	"github.com/babylonchain/btc-staker/proto"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+// checkSnapshotConsistent checks invariants maintained by writer in
+// TestReadOnlyStoreConcurrentWithWrites: every transaction is confirmed at
+// height equal to its index, except the last one, which may not be confirmed yet
+func checkSnapshotConsistent(s *stakerdb.StoreSnapshot) (int, error) {
+	all, err := s.GetAllStoredTransactions()
+	if err != nil {
+		return 0, err
+	}
+
+	page, err := s.QueryStoredTransactions(stakerdb.DefaultStoredTransactionQuery())
+	if err != nil {
+		return 0, err
+	}
+
+	if page.Total != uint64(len(all)) {
+		return 0, fmt.Errorf("query total %d does not match number of transactions %d", page.Total, len(all))
+	}
+
+	for i, tx := range all {
+		switch tx.State {
+		case proto.TransactionState_CONFIRMED_ON_BTC:
+			if tx.StakingTxConfirmationInfo == nil || tx.StakingTxConfirmationInfo.Height != uint32(i) {
+				return 0, fmt.Errorf("transaction %d has invalid confirmation info", i)
+			}
+		case proto.TransactionState_SENT_TO_BTC:
+			if i != len(all)-1 {
+				return 0, fmt.Errorf("transaction %d is not confirmed, but it is not the last one", i)
+			}
+		default:
+			return 0, fmt.Errorf("transaction %d has unexpected state %s", i, tx.State)
+		}
+
+		txHash := tx.StakingTx.TxHash()
+		byHash, err := s.GetTransaction(&txHash)
+		if err != nil {
+			return 0, err
+		}
+
+		if byHash.State != tx.State {
+			return 0, fmt.Errorf("transaction %d has different state when queried by hash", i)
+		}
+	}
+
+	return len(all), nil
+}
+
+func TestReadOnlyStoreConcurrentWithWrites(t *testing.T) {
+	const (
+		numTxs     = 100
+		numReaders = 4
+	)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	ro := s.ReadOnly()
+
+	txs := genNStoredTransactions(t, r, numTxs, 200)
+	writerDone := make(chan struct{})
+
+	var g errgroup.Group
+
+	g.Go(func() error {
+		defer close(writerDone)
+
+		for i, tx := range txs {
+			stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+			if err != nil {
+				return err
+			}
+
+			err = s.AddTransaction(
+				tx.StakingTx,
+				tx.StakingOutputIndex,
+				tx.StakingTime,
+				tx.FinalityProvidersBtcPks,
+				tx.Pop,
+				stakerAddr,
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+
+			txHash := tx.StakingTx.TxHash()
+			blockHash := datagen.GenRandomBtcdHash(r)
+			if err := s.SetTxConfirmed(&txHash, &blockHash, uint32(i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	for i := 0; i < numReaders; i++ {
+		g.Go(func() error {
+			lastSeen := 0
+
+			for {
+				select {
+				case <-writerDone:
+					return nil
+				default:
+				}
+
+				var seen int
+				err := ro.View(func(snapshot *stakerdb.StoreSnapshot) error {
+					var err error
+					seen, err = checkSnapshotConsistent(snapshot)
+					return err
+				}, func() {
+					seen = 0
+				})
+				if err != nil {
+					return err
+				}
+
+				if seen < lastSeen {
+					return fmt.Errorf("snapshot has %d transactions, previous one had %d", seen, lastSeen)
+				}
+				lastSeen = seen
+			}
+		})
+	}
+
+	require.NoError(t, g.Wait())
+
+	all, err := ro.GetAllStoredTransactions()
+	require.NoError(t, err)
+	require.Len(t, all, numTxs)
+
+	for i, tx := range all {
+		require.Equal(t, txs[i].StakingTx, tx.StakingTx)
+		require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, tx.State)
+	}
+}
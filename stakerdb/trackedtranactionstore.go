@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"sync"
+	"time"
 
 	"github.com/babylonchain/btc-staker/proto"
 	"github.com/babylonchain/btc-staker/utils"
@@ -18,6 +20,7 @@ import (
 	pm "google.golang.org/protobuf/proto"
 
 	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 )
 
 var (
@@ -31,16 +34,54 @@ var (
 	// It holds additional data for staking transaction in watch only mode
 	watchedTxDataBucketName = []byte("watched")
 
+	// mapping txHash -> proto.WatchedData
+	// It holds data of prepared staking transaction which still waits for
+	// signatures of staker key. Signature fields are always empty.
+	preparedTxDataBucketName = []byte("prepared")
+
+	// mapping finality provider btc pk -> bucket of txHash -> empty value
+	// It holds all staking transactions delegating to given finality provider
+	finalityProviderIdxBucketName = []byte("fpIdx")
+
+	// mapping transaction state -> bucket of txHash -> empty value
+	// It holds all staking transactions which are in given state
+	stateIdxBucketName = []byte("stateIdx")
+
 	// key for next transaction
 	numTxKey = []byte("ntk")
+
+	// mapping counter name -> uint64
+	// It holds counters describing the store as a whole
+	countersBucketName = []byte("counters")
+
+	// key for number of transactions removed from the store
+	numRemovedTxKey = []byte("nrtk")
 )
 
 type StoredTransactionScanFn func(tx *StoredTransaction) error
 
 type TrackedTransactionStore struct {
 	db kvdb.Backend
+
+	listenersMu      sync.RWMutex
+	listeners        []TransactionUpdateListener
+	stateListeners   []TransactionStateChangeListener
+	trackedListeners []TransactionTrackedListener
 }
 
+// TransactionUpdateListener is called after tracked transaction with given hash
+// was added to the store or modified
+type TransactionUpdateListener func(txHash *chainhash.Hash)
+
+// TransactionStateChangeListener is called after state of tracked transaction
+// with given hash changed from prevState to newState
+type TransactionStateChangeListener func(txHash *chainhash.Hash, prevState, newState proto.TransactionState)
+
+// TransactionTrackedListener is called after transaction with given hash in
+// given state started to be tracked (tracked is true) or was removed from the
+// store (tracked is false)
+type TransactionTrackedListener func(txHash *chainhash.Hash, state proto.TransactionState, tracked bool)
+
 type ProofOfPossession struct {
 	BtcSigType            uint32
 	BtcSigOverBabylonAddr []byte
@@ -110,6 +151,64 @@ type BtcConfirmationInfo struct {
 	BlockHash chainhash.Hash
 }
 
+// TxFeeInfo holds fee actually paid by btc transaction
+type TxFeeInfo struct {
+	Fee btcutil.Amount
+	// virtual size of transaction in vbytes
+	VSize int64
+	// effective fee rate i.e Fee / VSize
+	FeeRate chainfee.SatPerKVByte
+}
+
+// NewTxFeeInfo computes fee info of transaction which paid given fee
+func NewTxFeeInfo(fee btcutil.Amount, vsize int64) *TxFeeInfo {
+	var feeRate chainfee.SatPerKVByte
+	if vsize > 0 {
+		feeRate = chainfee.SatPerKVByte(fee * 1000 / btcutil.Amount(vsize))
+	}
+
+	return &TxFeeInfo{
+		Fee:     fee,
+		VSize:   vsize,
+		FeeRate: feeRate,
+	}
+}
+
+// ChangeOutput is change output of transaction created by staker
+type ChangeOutput struct {
+	OutputIdx uint32
+	PkScript  []byte
+}
+
+// StakingTxFunding describes how staker funded staking transaction it created.
+// It is stored in the same db transaction as the staking transaction, so that
+// sent transaction is never tracked without it.
+type StakingTxFunding struct {
+	// name of additional wallet which funded the transaction, empty if it was
+	// funded by the default wallet
+	WalletName string
+	// fee paid by the transaction, it is required to bump its fee
+	FeeInfo *TxFeeInfo
+	// change output of the transaction, nil if it does not have one. Fee of
+	// transaction without change output cannot be bumped.
+	Change *ChangeOutput
+}
+
+// StakingParamsSnapshot holds babylon staking params which were in effect when
+// delegation was created
+type StakingParamsSnapshot struct {
+	ConfirmationTimeBlocks    uint32
+	FinalizationTimeoutBlocks uint32
+	MinSlashingTxFeeSat       btcutil.Amount
+	CovenantPks               []*btcec.PublicKey
+	CovenantQuorum            uint32
+	MinUnbondingTime          uint16
+	// Keeping slashing address and rate as strings, to avoid having to know
+	// network and decimal representation used by babylon
+	SlashingAddress string
+	SlashingRate    string
+}
+
 type StoredTransaction struct {
 	StoredTransactionIdx      uint64
 	StakingTx                 *wire.MsgTx
@@ -124,6 +223,38 @@ type StoredTransaction struct {
 	State           proto.TransactionState
 	Watched         bool
 	UnbondingTxData *UnbondingStoreData
+	// fee paid by staking transaction, nil for watched transactions
+	StakingTxFeeInfo *TxFeeInfo
+	// fee paid by transaction spending staking or unbonding output, nil if
+	// stake was not spent by staker
+	SpendTxFeeInfo *TxFeeInfo
+	// transaction spending staking or unbonding output, nil if stake was not
+	// spent by staker
+	SpendTx *wire.MsgTx
+	// staking params in effect when delegation was created, nil for transactions
+	// tracked before params snapshots were persisted
+	StakingParamsSnapshot *StakingParamsSnapshot
+	// serialized delegation message last submitted to babylon, nil if delegation
+	// was not submitted by staker
+	BabylonSubmissionPayload []byte
+	// hash of the last child-pays-for-parent transaction bumping fee of staking
+	// transaction, nil if staker did not bump the fee
+	CpfpTxHash *chainhash.Hash
+	// fee paid by the last child-pays-for-parent transaction, zero if staker
+	// did not bump the fee
+	CpfpTxFee btcutil.Amount
+	// change output of staking transaction, nil if staking transaction has no
+	// change or was not created by staker
+	StakingTxChange *ChangeOutput
+	// time when transaction started to be tracked, zero for transactions tracked
+	// before creation time was persisted
+	CreatedAt time.Time
+	// time of the last state change, zero if state did not change since state
+	// change times are persisted
+	LastStateChangeAt time.Time
+	// name of the additional wallet which funded staking transaction, empty
+	// for the main wallet
+	WalletName string
 }
 
 // StakingTxConfirmedOnBtc returns true only if staking transaction was sent and confirmed on bitcoin
@@ -150,6 +281,17 @@ type WatchedTransactionData struct {
 	UnbondingTime          uint16
 }
 
+// PreparedTransactionData holds data of delegation prepared by staker, which
+// must be signed by staker key held outside of the connected wallet
+type PreparedTransactionData struct {
+	SlashingTx          *wire.MsgTx
+	StakerBabylonAddr   sdk.AccAddress
+	StakerBtcPubKey     *btcec.PublicKey
+	UnbondingTx         *wire.MsgTx
+	SlashingUnbondingTx *wire.MsgTx
+	UnbondingTime       uint16
+}
+
 type UnbondingStoreData struct {
 	UnbondingTx                 *wire.MsgTx
 	UnbondingTime               uint16
@@ -192,6 +334,8 @@ type StoredTransactionQuery struct {
 	Reversed bool
 
 	withdrawableTransactionsFilter *WithdrawableTransactionsFilter
+
+	stateFilter *proto.TransactionState
 }
 
 func DefaultStoredTransactionQuery() StoredTransactionQuery {
@@ -211,6 +355,13 @@ func (q *StoredTransactionQuery) WithdrawableTransactionsFilter(currentBestBlock
 	return *q
 }
 
+// StateFilter restricts query to transactions in given state
+func (q *StoredTransactionQuery) StateFilter(state proto.TransactionState) StoredTransactionQuery {
+	q.stateFilter = &state
+
+	return *q
+}
+
 type StoredTransactionQueryResult struct {
 	Transactions []StoredTransaction
 	Total        uint64
@@ -220,7 +371,7 @@ type StoredTransactionQueryResult struct {
 func NewTrackedTransactionStore(db kvdb.Backend) (*TrackedTransactionStore,
 	error) {
 
-	store := &TrackedTransactionStore{db}
+	store := &TrackedTransactionStore{db: db}
 	if err := store.initBuckets(); err != nil {
 		return nil, err
 	}
@@ -228,6 +379,88 @@ func NewTrackedTransactionStore(db kvdb.Backend) (*TrackedTransactionStore,
 	return store, nil
 }
 
+// AddUpdateListener registers listener called after each successful update of
+// tracked transaction. Listeners are called synchronously, after update is
+// committed, so they must not block.
+func (c *TrackedTransactionStore) AddUpdateListener(listener TransactionUpdateListener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	c.listeners = append(c.listeners, listener)
+}
+
+// AddStateChangeListener registers listener called after each committed change
+// of tracked transaction state. Listeners are called synchronously, after update
+// is committed, so they must not block.
+func (c *TrackedTransactionStore) AddStateChangeListener(listener TransactionStateChangeListener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	c.stateListeners = append(c.stateListeners, listener)
+}
+
+// AddTrackedListener registers listener called after each transaction added to
+// or removed from the store. Listeners are called synchronously, after update is
+// committed, so they must not block.
+func (c *TrackedTransactionStore) AddTrackedListener(listener TransactionTrackedListener) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+
+	c.trackedListeners = append(c.trackedListeners, listener)
+}
+
+func (c *TrackedTransactionStore) notifyTracked(
+	txHash *chainhash.Hash,
+	state proto.TransactionState,
+	tracked bool,
+) {
+	c.listenersMu.RLock()
+	defer c.listenersMu.RUnlock()
+
+	for _, listener := range c.trackedListeners {
+		listener(txHash, state, tracked)
+	}
+}
+
+func (c *TrackedTransactionStore) notifyStateChange(
+	txHash *chainhash.Hash,
+	prevState, newState proto.TransactionState,
+) {
+	if prevState == newState {
+		return
+	}
+
+	c.listenersMu.RLock()
+	defer c.listenersMu.RUnlock()
+
+	for _, listener := range c.stateListeners {
+		listener(txHash, prevState, newState)
+	}
+}
+
+// updateTx runs update of tracked transaction with given hash and notifies update
+// listeners if update was committed
+func (c *TrackedTransactionStore) updateTx(txHashBytes []byte, update func(tx kvdb.RwTx) error) error {
+	if err := kvdb.Batch(c.db, update); err != nil {
+		return err
+	}
+
+	txHash, err := chainhash.NewHash(txHashBytes)
+
+	if err != nil {
+		return err
+	}
+
+	c.listenersMu.RLock()
+	defer c.listenersMu.RUnlock()
+
+	for _, listener := range c.listeners {
+		listener(txHash)
+	}
+
+	return nil
+}
+
 func (c *TrackedTransactionStore) initBuckets() error {
 	return kvdb.Batch(c.db, func(tx kvdb.RwTx) error {
 		_, err := tx.CreateTopLevelBucket(transactionBucketName)
@@ -245,7 +478,157 @@ func (c *TrackedTransactionStore) initBuckets() error {
 			return err
 		}
 
-		return nil
+		_, err = tx.CreateTopLevelBucket(preparedTxDataBucketName)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.CreateTopLevelBucket(countersBucketName)
+		if err != nil {
+			return err
+		}
+
+		fpIdxExists := tx.ReadWriteBucket(finalityProviderIdxBucketName) != nil
+
+		fpIdxBucket, err := tx.CreateTopLevelBucket(finalityProviderIdxBucketName)
+		if err != nil {
+			return err
+		}
+
+		if !fpIdxExists {
+			// index was introduced after transactions bucket, so it needs to be
+			// built from transactions already stored in db
+			if err := buildFinalityProviderIndex(tx, fpIdxBucket); err != nil {
+				return err
+			}
+		}
+
+		stateIdxExists := tx.ReadWriteBucket(stateIdxBucketName) != nil
+
+		stateIdxBucket, err := tx.CreateTopLevelBucket(stateIdxBucketName)
+		if err != nil {
+			return err
+		}
+
+		if stateIdxExists {
+			return nil
+		}
+
+		return buildStateIndex(tx, stateIdxBucket)
+	})
+}
+
+func indexTransactionByFinalityProviders(
+	fpIdxBucket walletdb.ReadWriteBucket,
+	txHashBytes []byte,
+	fpPks [][]byte,
+) error {
+	for _, fpPk := range fpPks {
+		fpBucket, err := fpIdxBucket.CreateBucketIfNotExists(fpPk)
+		if err != nil {
+			return err
+		}
+
+		if err := fpBucket.Put(txHashBytes, []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func buildFinalityProviderIndex(tx kvdb.RwTx, fpIdxBucket walletdb.ReadWriteBucket) error {
+	txIdxBucket := tx.ReadWriteBucket(transactionIndexName)
+	txBucket := tx.ReadWriteBucket(transactionBucketName)
+
+	if txIdxBucket == nil || txBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	return txIdxBucket.ForEach(func(k, v []byte) error {
+		if bytes.Equal(k, numTxKey) {
+			return nil
+		}
+
+		maybeTx := txBucket.Get(v)
+		if maybeTx == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		var storedTxProto proto.TrackedTransaction
+		if err := pm.Unmarshal(maybeTx, &storedTxProto); err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		return indexTransactionByFinalityProviders(fpIdxBucket, k, storedTxProto.FinalityProvidersBtcPks)
+	})
+}
+
+func stateIdxKey(state proto.TransactionState) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, uint32(state))
+	return key
+}
+
+func indexTransactionByState(
+	stateIdxBucket walletdb.ReadWriteBucket,
+	txHashBytes []byte,
+	state proto.TransactionState,
+) error {
+	stateBucket, err := stateIdxBucket.CreateBucketIfNotExists(stateIdxKey(state))
+	if err != nil {
+		return err
+	}
+
+	return stateBucket.Put(txHashBytes, []byte{})
+}
+
+// moveTransactionBetweenStates updates state index after transaction changed
+// its state
+func moveTransactionBetweenStates(
+	rwTx kvdb.RwTx,
+	txHashBytes []byte,
+	from proto.TransactionState,
+	to proto.TransactionState,
+) error {
+	stateIdxBucket := rwTx.ReadWriteBucket(stateIdxBucketName)
+	if stateIdxBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	if fromBucket := stateIdxBucket.NestedReadWriteBucket(stateIdxKey(from)); fromBucket != nil {
+		if err := fromBucket.Delete(txHashBytes); err != nil {
+			return err
+		}
+	}
+
+	return indexTransactionByState(stateIdxBucket, txHashBytes, to)
+}
+
+func buildStateIndex(tx kvdb.RwTx, stateIdxBucket walletdb.ReadWriteBucket) error {
+	txIdxBucket := tx.ReadWriteBucket(transactionIndexName)
+	txBucket := tx.ReadWriteBucket(transactionBucketName)
+
+	if txIdxBucket == nil || txBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	return txIdxBucket.ForEach(func(k, v []byte) error {
+		if bytes.Equal(k, numTxKey) {
+			return nil
+		}
+
+		maybeTx := txBucket.Get(v)
+		if maybeTx == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		var storedTxProto proto.TrackedTransaction
+		if err := pm.Unmarshal(maybeTx, &storedTxProto); err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		return indexTransactionByState(stateIdxBucket, k, storedTxProto.State)
 	})
 }
 
@@ -306,6 +689,93 @@ func protoUnbondingDataToUnbondingStoreData(ud *proto.UnbondingTxData) (*Unbondi
 	}, nil
 }
 
+// protoTimestampToTime converts unix time in seconds to time. Transactions stored
+// before timestamps were introduced have 0 timestamps, which are converted to
+// zero time.
+func protoTimestampToTime(ts int64) time.Time {
+	if ts == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(ts, 0)
+}
+
+func protoTxFeeInfoToTxFeeInfo(fi *proto.TxFeeInfo) *TxFeeInfo {
+	if fi == nil {
+		return nil
+	}
+
+	return &TxFeeInfo{
+		Fee:     btcutil.Amount(fi.Fee),
+		VSize:   fi.Vsize,
+		FeeRate: chainfee.SatPerKVByte(fi.FeeRate),
+	}
+}
+
+func txFeeInfoToProto(fi *TxFeeInfo) *proto.TxFeeInfo {
+	return &proto.TxFeeInfo{
+		Fee:     int64(fi.Fee),
+		Vsize:   fi.VSize,
+		FeeRate: int64(fi.FeeRate),
+	}
+}
+
+func protoStakingParamsSnapshotToSnapshot(ps *proto.StakingParamsSnapshot) (*StakingParamsSnapshot, error) {
+	if ps == nil {
+		return nil, nil
+	}
+
+	if ps.MinUnbondingTime > math.MaxUint16 {
+		return nil, fmt.Errorf("min unbonding time is too large. Max value is %d", math.MaxUint16)
+	}
+
+	covenantPks := make([]*btcec.PublicKey, len(ps.CovenantPks))
+
+	for i, pk := range ps.CovenantPks {
+		parsed, err := schnorr.ParsePubKey(pk)
+
+		if err != nil {
+			return nil, err
+		}
+
+		covenantPks[i] = parsed
+	}
+
+	return &StakingParamsSnapshot{
+		ConfirmationTimeBlocks:    ps.ConfirmationTimeBlocks,
+		FinalizationTimeoutBlocks: ps.FinalizationTimeoutBlocks,
+		MinSlashingTxFeeSat:       btcutil.Amount(ps.MinSlashingTxFeeSat),
+		CovenantPks:               covenantPks,
+		CovenantQuorum:            ps.CovenantQuorum,
+		MinUnbondingTime:          uint16(ps.MinUnbondingTime),
+		SlashingAddress:           ps.SlashingAddress,
+		SlashingRate:              ps.SlashingRate,
+	}, nil
+}
+
+func stakingParamsSnapshotToProto(ps *StakingParamsSnapshot) *proto.StakingParamsSnapshot {
+	if ps == nil {
+		return nil
+	}
+
+	covenantPks := make([][]byte, len(ps.CovenantPks))
+
+	for i, pk := range ps.CovenantPks {
+		covenantPks[i] = schnorr.SerializePubKey(pk)
+	}
+
+	return &proto.StakingParamsSnapshot{
+		ConfirmationTimeBlocks:    ps.ConfirmationTimeBlocks,
+		FinalizationTimeoutBlocks: ps.FinalizationTimeoutBlocks,
+		MinSlashingTxFeeSat:       int64(ps.MinSlashingTxFeeSat),
+		CovenantPks:               covenantPks,
+		CovenantQuorum:            ps.CovenantQuorum,
+		MinUnbondingTime:          uint32(ps.MinUnbondingTime),
+		SlashingAddress:           ps.SlashingAddress,
+		SlashingRate:              ps.SlashingRate,
+	}
+}
+
 func protoTxToStoredTransaction(ttx *proto.TrackedTransaction) (*StoredTransaction, error) {
 	var stakingTx wire.MsgTx
 	err := stakingTx.Deserialize(bytes.NewReader(ttx.StakingTransaction))
@@ -346,6 +816,41 @@ func protoTxToStoredTransaction(ttx *proto.TrackedTransaction) (*StoredTransacti
 		}
 	}
 
+	paramsSnapshot, err := protoStakingParamsSnapshotToSnapshot(ttx.StakingParamsSnapshot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var spendTx *wire.MsgTx
+
+	if len(ttx.SpendTx) > 0 {
+		spendTx = &wire.MsgTx{}
+
+		if err := spendTx.Deserialize(bytes.NewReader(ttx.SpendTx)); err != nil {
+			return nil, err
+		}
+	}
+
+	var cpfpTxHash *chainhash.Hash
+
+	if len(ttx.CpfpTxHash) > 0 {
+		cpfpTxHash, err = chainhash.NewHash(ttx.CpfpTxHash)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var stakingTxChange *ChangeOutput
+
+	if len(ttx.StakingTxChangePkScript) > 0 {
+		stakingTxChange = &ChangeOutput{
+			OutputIdx: ttx.StakingTxChangeOutputIdx,
+			PkScript:  ttx.StakingTxChangePkScript,
+		}
+	}
+
 	return &StoredTransaction{
 		StoredTransactionIdx:      ttx.TrackedTransactionIdx,
 		StakingTx:                 &stakingTx,
@@ -357,10 +862,64 @@ func protoTxToStoredTransaction(ttx *proto.TrackedTransaction) (*StoredTransacti
 			BtcSigType:            ttx.BtcSigType,
 			BtcSigOverBabylonAddr: ttx.BtcSigOverBbnStakerAddr,
 		},
-		StakerAddress:   ttx.StakerAddress,
-		State:           ttx.State,
-		Watched:         ttx.Watched,
-		UnbondingTxData: utd,
+		StakerAddress:            ttx.StakerAddress,
+		State:                    ttx.State,
+		Watched:                  ttx.Watched,
+		UnbondingTxData:          utd,
+		StakingTxFeeInfo:         protoTxFeeInfoToTxFeeInfo(ttx.StakingTxFeeInfo),
+		SpendTxFeeInfo:           protoTxFeeInfoToTxFeeInfo(ttx.SpendTxFeeInfo),
+		SpendTx:                  spendTx,
+		StakingParamsSnapshot:    paramsSnapshot,
+		BabylonSubmissionPayload: ttx.BabylonSubmissionPayload,
+		CpfpTxHash:               cpfpTxHash,
+		CpfpTxFee:                btcutil.Amount(ttx.CpfpTxFee),
+		StakingTxChange:          stakingTxChange,
+		CreatedAt:                protoTimestampToTime(ttx.CreatedAt),
+		LastStateChangeAt:        protoTimestampToTime(ttx.LastStateChangeAt),
+		WalletName:               ttx.WalletName,
+	}, nil
+}
+
+func protoPreparedDataToPreparedTransactionData(pd *proto.WatchedTxData) (*PreparedTransactionData, error) {
+	var slashingTx wire.MsgTx
+	err := slashingTx.Deserialize(bytes.NewReader(pd.SlashingTransaction))
+	if err != nil {
+		return nil, err
+	}
+
+	stakerBtcKey, err := schnorr.ParsePubKey(pd.StakerBtcPk)
+	if err != nil {
+		return nil, err
+	}
+
+	var unbondingTx wire.MsgTx
+	err = unbondingTx.Deserialize(bytes.NewReader(pd.UnbondingTransaction))
+	if err != nil {
+		return nil, err
+	}
+
+	var slashingUnbondingTx wire.MsgTx
+	err = slashingUnbondingTx.Deserialize(bytes.NewReader(pd.SlashingUnbondingTransaction))
+	if err != nil {
+		return nil, err
+	}
+
+	if pd.UnbondingTime > math.MaxUint16 {
+		return nil, fmt.Errorf("unbonding time is too large. Max value is %d", math.MaxUint16)
+	}
+
+	stakerBabylonAddr, err := sdk.AccAddressFromBech32(pd.StakerBabylonAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreparedTransactionData{
+		SlashingTx:          &slashingTx,
+		StakerBabylonAddr:   stakerBabylonAddr,
+		StakerBtcPubKey:     stakerBtcKey,
+		UnbondingTx:         &unbondingTx,
+		SlashingUnbondingTx: &slashingUnbondingTx,
+		UnbondingTime:       uint16(pd.UnbondingTime),
 	}, nil
 }
 
@@ -420,17 +979,45 @@ func protoWatchedDataToWatchedTransactionData(wd *proto.WatchedTxData) (*Watched
 	}, nil
 }
 
-func uint64KeyToBytes(key uint64) []byte {
-	var keyBytes = make([]byte, 8)
-	binary.BigEndian.PutUint64(keyBytes, key)
-	return keyBytes
-}
+func watchedTransactionDataToProto(wd *WatchedTransactionData) (*proto.WatchedTxData, error) {
+	serializedSlashingtx, err := utils.SerializeBtcTransaction(wd.SlashingTx)
+	if err != nil {
+		return nil, err
+	}
 
-func nextTxKey(txIdxBucket walletdb.ReadBucket) uint64 {
-	numTxBytes := txIdxBucket.Get(numTxKey)
-	var currKey uint64
-	if numTxBytes == nil {
-		currKey = 1
+	serializedUnbondingTx, err := utils.SerializeBtcTransaction(wd.UnbondingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	serializedSlashUnbondingTx, err := utils.SerializeBtcTransaction(wd.SlashingUnbondingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &proto.WatchedTxData{
+		SlashingTransaction:             serializedSlashingtx,
+		SlashingTransactionSig:          wd.SlashingTxSig.Serialize(),
+		StakerBabylonAddr:               wd.StakerBabylonAddr.String(),
+		StakerBtcPk:                     schnorr.SerializePubKey(wd.StakerBtcPubKey),
+		UnbondingTransaction:            serializedUnbondingTx,
+		SlashingUnbondingTransaction:    serializedSlashUnbondingTx,
+		SlashingUnbondingTransactionSig: wd.SlashingUnbondingTxSig.Serialize(),
+		UnbondingTime:                   uint32(wd.UnbondingTime),
+	}, nil
+}
+
+func uint64KeyToBytes(key uint64) []byte {
+	var keyBytes = make([]byte, 8)
+	binary.BigEndian.PutUint64(keyBytes, key)
+	return keyBytes
+}
+
+func nextTxKey(txIdxBucket walletdb.ReadBucket) uint64 {
+	numTxBytes := txIdxBucket.Get(numTxKey)
+	var currKey uint64
+	if numTxBytes == nil {
+		currKey = 1
 	} else {
 		currKey = binary.BigEndian.Uint64(numTxBytes)
 	}
@@ -438,11 +1025,26 @@ func nextTxKey(txIdxBucket walletdb.ReadBucket) uint64 {
 	return currKey
 }
 
-func getNumTx(txIdxBucket walletdb.ReadBucket) uint64 {
+func getNumTx(txIdxBucket walletdb.ReadBucket, countersBucket walletdb.ReadBucket) uint64 {
 	// we are starting indexing transactions from 1, and nextTxKey always return next key
 	// which should be used when indexing transaction, so to get number of transactions
 	// we need to subtract 1
-	return nextTxKey(txIdxBucket) - 1
+	return nextTxKey(txIdxBucket) - 1 - getNumRemovedTx(countersBucket)
+}
+
+func getNumRemovedTx(countersBucket walletdb.ReadBucket) uint64 {
+	// counters bucket does not exist in db opened read only before it was
+	// introduced
+	if countersBucket == nil {
+		return 0
+	}
+
+	numRemovedBytes := countersBucket.Get(numRemovedTxKey)
+	if numRemovedBytes == nil {
+		return 0
+	}
+
+	return binary.BigEndian.Uint64(numRemovedBytes)
 }
 
 // getTxByHash retruns transaction and transaction key if transaction with given hash exsits
@@ -481,6 +1083,8 @@ func saveTrackedTransaction(
 	nextTxKey := nextTxKey(txIdxBucket)
 
 	tx.TrackedTransactionIdx = nextTxKey
+	tx.CreatedAt = time.Now().Unix()
+	tx.LastStateChangeAt = tx.CreatedAt
 
 	marshalled, err := pm.Marshal(tx)
 
@@ -502,6 +1106,28 @@ func saveTrackedTransaction(
 		return err
 	}
 
+	fpIdxBucket := rwTx.ReadWriteBucket(finalityProviderIdxBucketName)
+	if fpIdxBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	err = indexTransactionByFinalityProviders(fpIdxBucket, txHashBytes, tx.FinalityProvidersBtcPks)
+
+	if err != nil {
+		return err
+	}
+
+	stateIdxBucket := rwTx.ReadWriteBucket(stateIdxBucketName)
+	if stateIdxBucket == nil {
+		return ErrCorruptedTransactionsDb
+	}
+
+	err = indexTransactionByState(stateIdxBucket, txHashBytes, tx.State)
+
+	if err != nil {
+		return err
+	}
+
 	if watchedTxData != nil {
 		watchedTxBucket := rwTx.ReadWriteBucket(watchedTxDataBucketName)
 		if watchedTxBucket == nil {
@@ -530,7 +1156,7 @@ func (c *TrackedTransactionStore) addTransactionInternal(
 	tt *proto.TrackedTransaction,
 	wd *proto.WatchedTxData,
 ) error {
-	return kvdb.Batch(c.db, func(tx kvdb.RwTx) error {
+	err := c.updateTx(txHashBytes, func(tx kvdb.RwTx) error {
 		transactionsBucketIdxBucket := tx.ReadWriteBucket(transactionIndexName)
 
 		if transactionsBucketIdxBucket == nil {
@@ -550,6 +1176,28 @@ func (c *TrackedTransactionStore) addTransactionInternal(
 
 		return saveTrackedTransaction(tx, transactionsBucketIdxBucket, transactionsBucket, txHashBytes, tt, wd)
 	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.notifyTrackedHash(txHashBytes, tt.State, true)
+}
+
+func (c *TrackedTransactionStore) notifyTrackedHash(
+	txHashBytes []byte,
+	state proto.TransactionState,
+	tracked bool,
+) error {
+	txHash, err := chainhash.NewHash(txHashBytes)
+
+	if err != nil {
+		return err
+	}
+
+	c.notifyTracked(txHash, state, tracked)
+
+	return nil
 }
 
 func (c *TrackedTransactionStore) AddTransaction(
@@ -559,6 +1207,70 @@ func (c *TrackedTransactionStore) AddTransaction(
 	fpPubKeys []*btcec.PublicKey,
 	pop *ProofOfPossession,
 	stakerAddress btcutil.Address,
+	paramsSnapshot *StakingParamsSnapshot,
+) error {
+	return c.addOwnedTransaction(
+		btcTx, stakingOutputIndex, stakingTime, fpPubKeys, pop, stakerAddress, paramsSnapshot, nil, nil,
+	)
+}
+
+// AddTransactionWithDelegationData works the same as AddTransaction, but also
+// stores slashing and unbonding transactions of delegation signed by staker key
+// when staking transaction was created. Stored data is used to send delegation
+// to babylon, so staker key is not needed once staking transaction is confirmed.
+func (c *TrackedTransactionStore) AddTransactionWithDelegationData(
+	btcTx *wire.MsgTx,
+	stakingOutputIndex uint32,
+	stakingTime uint16,
+	fpPubKeys []*btcec.PublicKey,
+	pop *ProofOfPossession,
+	stakerAddress btcutil.Address,
+	paramsSnapshot *StakingParamsSnapshot,
+	delegationData *WatchedTransactionData,
+) error {
+	if delegationData == nil {
+		return fmt.Errorf("cannot add transaction without delegation data")
+	}
+
+	return c.addOwnedTransaction(
+		btcTx, stakingOutputIndex, stakingTime, fpPubKeys, pop, stakerAddress, paramsSnapshot, delegationData, nil,
+	)
+}
+
+// AddFundedTransaction adds staking transaction created and funded by staker.
+// Delegation data is optional and works the same as in
+// AddTransactionWithDelegationData. Funding of the transaction is stored
+// together with it.
+func (c *TrackedTransactionStore) AddFundedTransaction(
+	btcTx *wire.MsgTx,
+	stakingOutputIndex uint32,
+	stakingTime uint16,
+	fpPubKeys []*btcec.PublicKey,
+	pop *ProofOfPossession,
+	stakerAddress btcutil.Address,
+	paramsSnapshot *StakingParamsSnapshot,
+	delegationData *WatchedTransactionData,
+	funding *StakingTxFunding,
+) error {
+	if funding == nil {
+		return fmt.Errorf("cannot add funded transaction without funding")
+	}
+
+	return c.addOwnedTransaction(
+		btcTx, stakingOutputIndex, stakingTime, fpPubKeys, pop, stakerAddress, paramsSnapshot, delegationData, funding,
+	)
+}
+
+func (c *TrackedTransactionStore) addOwnedTransaction(
+	btcTx *wire.MsgTx,
+	stakingOutputIndex uint32,
+	stakingTime uint16,
+	fpPubKeys []*btcec.PublicKey,
+	pop *ProofOfPossession,
+	stakerAddress btcutil.Address,
+	paramsSnapshot *StakingParamsSnapshot,
+	delegationData *WatchedTransactionData,
+	funding *StakingTxFunding,
 ) error {
 	txHash := btcTx.TxHash()
 	txHashBytes := txHash[:]
@@ -592,10 +1304,33 @@ func (c *TrackedTransactionStore) AddTransaction(
 		State:                        proto.TransactionState_SENT_TO_BTC,
 		Watched:                      false,
 		UnbondingTxData:              nil,
+		StakingParamsSnapshot:        stakingParamsSnapshotToProto(paramsSnapshot),
+	}
+
+	if funding != nil {
+		msg.WalletName = funding.WalletName
+
+		if funding.FeeInfo != nil {
+			msg.StakingTxFeeInfo = txFeeInfoToProto(funding.FeeInfo)
+		}
+
+		if funding.Change != nil {
+			msg.StakingTxChangeOutputIdx = funding.Change.OutputIdx
+			msg.StakingTxChangePkScript = funding.Change.PkScript
+		}
+	}
+
+	var wd *proto.WatchedTxData
+	if delegationData != nil {
+		wd, err = watchedTransactionDataToProto(delegationData)
+
+		if err != nil {
+			return err
+		}
 	}
 
 	return c.addTransactionInternal(
-		txHashBytes, &msg, nil,
+		txHashBytes, &msg, wd,
 	)
 }
 
@@ -614,6 +1349,7 @@ func (c *TrackedTransactionStore) AddWatchedTransaction(
 	slashUnbondingTx *wire.MsgTx,
 	slashUnbondingTxSig *schnorr.Signature,
 	unbondingTime uint16,
+	paramsSnapshot *StakingParamsSnapshot,
 ) error {
 	txHash := btcTx.TxHash()
 	txHashBytes := txHash[:]
@@ -647,14 +1383,81 @@ func (c *TrackedTransactionStore) AddWatchedTransaction(
 		State:                        proto.TransactionState_SENT_TO_BTC,
 		Watched:                      true,
 		UnbondingTxData:              nil,
+		StakingParamsSnapshot:        stakingParamsSnapshotToProto(paramsSnapshot),
 	}
 
-	serializedSlashingtx, err := utils.SerializeBtcTransaction(slashingTx)
+	watchedData, err := watchedTransactionDataToProto(&WatchedTransactionData{
+		SlashingTx:             slashingTx,
+		SlashingTxSig:          slashingTxSig,
+		StakerBabylonAddr:      stakerBabylonAddr,
+		StakerBtcPubKey:        stakerBtcPk,
+		UnbondingTx:            unbondingTx,
+		SlashingUnbondingTx:    slashUnbondingTx,
+		SlashingUnbondingTxSig: slashUnbondingTxSig,
+		UnbondingTime:          unbondingTime,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return c.addTransactionInternal(
+		txHashBytes, &msg, watchedData,
+	)
+}
+
+// AddPreparedTransaction adds staking transaction which is not yet sent to btc,
+// as it waits for signatures of staker key produced outside of the staker.
+// Transaction is tracked as watched one, as staker key is not part of the connected wallet.
+func (c *TrackedTransactionStore) AddPreparedTransaction(
+	btcTx *wire.MsgTx,
+	stakingOutputIndex uint32,
+	stakingTime uint16,
+	fpPubKeys []*btcec.PublicKey,
+	stakerAddress btcutil.Address,
+	slashingTx *wire.MsgTx,
+	stakerBabylonAddr sdk.AccAddress,
+	stakerBtcPk *btcec.PublicKey,
+	unbondingTx *wire.MsgTx,
+	slashUnbondingTx *wire.MsgTx,
+	unbondingTime uint16,
+) error {
+	txHash := btcTx.TxHash()
+	txHashBytes := txHash[:]
+	serializedTx, err := utils.SerializeBtcTransaction(btcTx)
+
 	if err != nil {
 		return err
 	}
 
-	serializedSig := slashingTxSig.Serialize()
+	if len(fpPubKeys) == 0 {
+		return fmt.Errorf("cannot add transaction without finality providers public keys")
+	}
+
+	var fpPubKeysBytes [][]byte = make([][]byte, len(fpPubKeys))
+
+	for i, pk := range fpPubKeys {
+		fpPubKeysBytes[i] = schnorr.SerializePubKey(pk)
+	}
+
+	msg := proto.TrackedTransaction{
+		// Setting it to 0, proper number will be filled by `saveTrackedTransaction`
+		TrackedTransactionIdx:        0,
+		StakingTransaction:           serializedTx,
+		StakingOutputIdx:             stakingOutputIndex,
+		StakerAddress:                stakerAddress.EncodeAddress(),
+		StakingTime:                  uint32(stakingTime),
+		FinalityProvidersBtcPks:      fpPubKeysBytes,
+		StakingTxBtcConfirmationInfo: nil,
+		State:                        proto.TransactionState_PREPARED,
+		Watched:                      true,
+		UnbondingTxData:              nil,
+	}
+
+	serializedSlashingtx, err := utils.SerializeBtcTransaction(slashingTx)
+	if err != nil {
+		return err
+	}
 
 	serializedUnbondingTx, err := utils.SerializeBtcTransaction(unbondingTx)
 	if err != nil {
@@ -662,38 +1465,76 @@ func (c *TrackedTransactionStore) AddWatchedTransaction(
 	}
 
 	serializedSlashUnbondingTx, err := utils.SerializeBtcTransaction(slashUnbondingTx)
+	if err != nil {
+		return err
+	}
+
+	preparedData := proto.WatchedTxData{
+		SlashingTransaction:          serializedSlashingtx,
+		StakerBabylonAddr:            stakerBabylonAddr.String(),
+		StakerBtcPk:                  schnorr.SerializePubKey(stakerBtcPk),
+		UnbondingTransaction:         serializedUnbondingTx,
+		SlashingUnbondingTransaction: serializedSlashUnbondingTx,
+		UnbondingTime:                uint32(unbondingTime),
+	}
 
+	marshalledPreparedData, err := pm.Marshal(&preparedData)
 	if err != nil {
 		return err
 	}
 
-	serializedSlashUnbondingTxSig := slashUnbondingTxSig.Serialize()
+	err = c.updateTx(txHashBytes, func(tx kvdb.RwTx) error {
+		transactionsBucketIdxBucket := tx.ReadWriteBucket(transactionIndexName)
 
-	watchedData := proto.WatchedTxData{
-		SlashingTransaction:             serializedSlashingtx,
-		SlashingTransactionSig:          serializedSig,
-		StakerBabylonAddr:               stakerBabylonAddr.String(),
-		StakerBtcPk:                     schnorr.SerializePubKey(stakerBtcPk),
-		UnbondingTransaction:            serializedUnbondingTx,
-		SlashingUnbondingTransaction:    serializedSlashUnbondingTx,
-		SlashingUnbondingTransactionSig: serializedSlashUnbondingTxSig,
-		UnbondingTime:                   uint32(unbondingTime),
+		if transactionsBucketIdxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		// check index first to avoid duplicates
+		maybeTx := transactionsBucketIdxBucket.Get(txHashBytes)
+		if maybeTx != nil {
+			return ErrDuplicateTransaction
+		}
+
+		transactionsBucket := tx.ReadWriteBucket(transactionBucketName)
+		if transactionsBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		preparedTxBucket := tx.ReadWriteBucket(preparedTxDataBucketName)
+		if preparedTxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		if err := preparedTxBucket.Put(txHashBytes, marshalledPreparedData); err != nil {
+			return err
+		}
+
+		return saveTrackedTransaction(tx, transactionsBucketIdxBucket, transactionsBucket, txHashBytes, &msg, nil)
+	})
+
+	if err != nil {
+		return err
 	}
 
-	return c.addTransactionInternal(
-		txHashBytes, &msg, &watchedData,
-	)
+	return c.notifyTrackedHash(txHashBytes, msg.State, true)
 }
 
-func (c *TrackedTransactionStore) setTxState(
+// SetPreparedTransactionSigned completes prepared transaction with signatures
+// produced by staker key. Prepared data becomes watched data of the transaction
+// and transaction moves to SENT_TO_BTC state. Staking params snapshot taken when
+// delegation is submitted is stored together with the signatures.
+func (c *TrackedTransactionStore) SetPreparedTransactionSigned(
 	txHash *chainhash.Hash,
-	stateTransitionFn func(*proto.TrackedTransaction) error,
+	pop *ProofOfPossession,
+	slashingTxSig *schnorr.Signature,
+	slashUnbondingTxSig *schnorr.Signature,
+	paramsSnapshot *StakingParamsSnapshot,
 ) error {
 	txHashBytes := txHash.CloneBytes()
 
-	return kvdb.Batch(c.db, func(tx kvdb.RwTx) error {
+	err := c.updateTx(txHashBytes, func(tx kvdb.RwTx) error {
 		transactionIdxBucket := tx.ReadWriteBucket(transactionIndexName)
-
 		if transactionIdxBucket == nil {
 			return ErrCorruptedTransactionsDb
 		}
@@ -703,48 +1544,263 @@ func (c *TrackedTransactionStore) setTxState(
 			return ErrCorruptedTransactionsDb
 		}
 
-		maybeTx, txKey, err := getTxByHash(txHashBytes, transactionIdxBucket, transactionsBucket)
+		preparedTxBucket := tx.ReadWriteBucket(preparedTxDataBucketName)
+		if preparedTxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		watchedTxBucket := tx.ReadWriteBucket(watchedTxDataBucketName)
+		if watchedTxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
 
+		maybeTx, txKey, err := getTxByHash(txHashBytes, transactionIdxBucket, transactionsBucket)
 		if err != nil {
 			return err
 		}
 
 		var storedTx proto.TrackedTransaction
-		err = pm.Unmarshal(maybeTx, &storedTx)
-		if err != nil {
+		if err := pm.Unmarshal(maybeTx, &storedTx); err != nil {
 			return ErrCorruptedTransactionsDb
 		}
 
-		if err := stateTransitionFn(&storedTx); err != nil {
-			return err
+		if storedTx.State != proto.TransactionState_PREPARED {
+			return fmt.Errorf("cannot set signatures for transaction in state %s: %w", storedTx.State, ErrTransactionNotPrepared)
 		}
 
-		marshalled, err := pm.Marshal(&storedTx)
+		maybePreparedData := preparedTxBucket.Get(txHashBytes)
+		if maybePreparedData == nil {
+			return ErrPreparedDataNotFound
+		}
+
+		var watchedData proto.WatchedTxData
+		if err := pm.Unmarshal(maybePreparedData, &watchedData); err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		watchedData.SlashingTransactionSig = slashingTxSig.Serialize()
+		watchedData.SlashingUnbondingTransactionSig = slashUnbondingTxSig.Serialize()
 
+		marshalledWatchedData, err := pm.Marshal(&watchedData)
 		if err != nil {
 			return err
 		}
 
-		err = transactionsBucket.Put(txKey, marshalled)
+		if err := watchedTxBucket.Put(txHashBytes, marshalledWatchedData); err != nil {
+			return err
+		}
+
+		if err := preparedTxBucket.Delete(txHashBytes); err != nil {
+			return err
+		}
+
+		storedTx.BtcSigType = pop.BtcSigType
+		storedTx.BtcSigOverBbnStakerAddr = pop.BtcSigOverBabylonAddr
+		storedTx.State = proto.TransactionState_SENT_TO_BTC
+		storedTx.LastStateChangeAt = time.Now().Unix()
+		storedTx.StakingParamsSnapshot = stakingParamsSnapshotToProto(paramsSnapshot)
+
+		if err := moveTransactionBetweenStates(
+			tx, txHashBytes, proto.TransactionState_PREPARED, storedTx.State,
+		); err != nil {
+			return err
+		}
 
+		marshalled, err := pm.Marshal(&storedTx)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return transactionsBucket.Put(txKey, marshalled)
 	})
+
+	if err != nil {
+		return err
+	}
+
+	c.notifyStateChange(txHash, proto.TransactionState_PREPARED, proto.TransactionState_SENT_TO_BTC)
+
+	return nil
 }
 
-func (c *TrackedTransactionStore) SetTxConfirmed(
+// SetPreparedStakingTx replaces unsigned staking transaction of prepared
+// delegation with the same transaction signed by external signer. Caller must
+// make sure signed transaction has the same hash as the stored one.
+func (c *TrackedTransactionStore) SetPreparedStakingTx(
 	txHash *chainhash.Hash,
-	blockHash *chainhash.Hash,
-	blockHeight uint32,
+	signedTx *wire.MsgTx,
 ) error {
-	setTxConfirmed := func(tx *proto.TrackedTransaction) error {
-		tx.State = proto.TransactionState_CONFIRMED_ON_BTC
-		tx.StakingTxBtcConfirmationInfo = &proto.BTCConfirmationInfo{
-			BlockHash:   blockHash.CloneBytes(),
-			BlockHeight: blockHeight,
+	serializedTx, err := utils.SerializeBtcTransaction(signedTx)
+
+	if err != nil {
+		return err
+	}
+
+	setStakingTx := func(tx *proto.TrackedTransaction) error {
+		if tx.State != proto.TransactionState_PREPARED {
+			return fmt.Errorf("cannot set staking transaction of transaction in state %s: %w", tx.State, ErrTransactionNotPrepared)
+		}
+
+		tx.StakingTransaction = serializedTx
+		return nil
+	}
+
+	return c.setTxState(txHash, setStakingTx)
+}
+
+// RemovePreparedTransaction removes delegation created by AddPreparedTransaction
+// which was not yet sent to btc, together with its prepared data. Index of
+// removed transaction is not reused.
+func (c *TrackedTransactionStore) RemovePreparedTransaction(txHash *chainhash.Hash) error {
+	txHashBytes := txHash.CloneBytes()
+
+	err := c.updateTx(txHashBytes, func(tx kvdb.RwTx) error {
+		transactionIdxBucket := tx.ReadWriteBucket(transactionIndexName)
+		if transactionIdxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		transactionsBucket := tx.ReadWriteBucket(transactionBucketName)
+		if transactionsBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		preparedTxBucket := tx.ReadWriteBucket(preparedTxDataBucketName)
+		if preparedTxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		countersBucket := tx.ReadWriteBucket(countersBucketName)
+		if countersBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		maybeTx, txKey, err := getTxByHash(txHashBytes, transactionIdxBucket, transactionsBucket)
+		if err != nil {
+			return err
+		}
+
+		var storedTx proto.TrackedTransaction
+		if err := pm.Unmarshal(maybeTx, &storedTx); err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		if storedTx.State != proto.TransactionState_PREPARED {
+			return fmt.Errorf("cannot remove transaction in state %s: %w", storedTx.State, ErrTransactionNotPrepared)
+		}
+
+		if err := removeFromIndexes(tx, txHashBytes, &storedTx); err != nil {
+			return err
+		}
+
+		if err := preparedTxBucket.Delete(txHashBytes); err != nil {
+			return err
+		}
+
+		if err := transactionsBucket.Delete(txKey); err != nil {
+			return err
+		}
+
+		if err := transactionIdxBucket.Delete(txHashBytes); err != nil {
+			return err
+		}
+
+		numRemoved := getNumRemovedTx(countersBucket)
+
+		return countersBucket.Put(numRemovedTxKey, uint64KeyToBytes(numRemoved+1))
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.notifyTracked(txHash, proto.TransactionState_PREPARED, false)
+
+	return nil
+}
+
+func (c *TrackedTransactionStore) setTxState(
+	txHash *chainhash.Hash,
+	stateTransitionFn func(*proto.TrackedTransaction) error,
+) error {
+	txHashBytes := txHash.CloneBytes()
+
+	var prevState, newState proto.TransactionState
+
+	err := c.updateTx(txHashBytes, func(tx kvdb.RwTx) error {
+		transactionIdxBucket := tx.ReadWriteBucket(transactionIndexName)
+
+		if transactionIdxBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		transactionsBucket := tx.ReadWriteBucket(transactionBucketName)
+		if transactionsBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		maybeTx, txKey, err := getTxByHash(txHashBytes, transactionIdxBucket, transactionsBucket)
+
+		if err != nil {
+			return err
+		}
+
+		var storedTx proto.TrackedTransaction
+		err = pm.Unmarshal(maybeTx, &storedTx)
+		if err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		prevState = storedTx.State
+
+		if err := stateTransitionFn(&storedTx); err != nil {
+			return err
+		}
+
+		newState = storedTx.State
+
+		if storedTx.State != prevState {
+			storedTx.LastStateChangeAt = time.Now().Unix()
+
+			if err := moveTransactionBetweenStates(tx, txHashBytes, prevState, storedTx.State); err != nil {
+				return err
+			}
+		}
+
+		marshalled, err := pm.Marshal(&storedTx)
+
+		if err != nil {
+			return err
+		}
+
+		err = transactionsBucket.Put(txKey, marshalled)
+
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	c.notifyStateChange(txHash, prevState, newState)
+
+	return nil
+}
+
+func (c *TrackedTransactionStore) SetTxConfirmed(
+	txHash *chainhash.Hash,
+	blockHash *chainhash.Hash,
+	blockHeight uint32,
+) error {
+	setTxConfirmed := func(tx *proto.TrackedTransaction) error {
+		tx.State = proto.TransactionState_CONFIRMED_ON_BTC
+		tx.StakingTxBtcConfirmationInfo = &proto.BTCConfirmationInfo{
+			BlockHash:   blockHash.CloneBytes(),
+			BlockHeight: blockHeight,
 		}
 		return nil
 	}
@@ -752,6 +1808,23 @@ func (c *TrackedTransactionStore) SetTxConfirmed(
 	return c.setTxState(txHash, setTxConfirmed)
 }
 
+// SetTxUnconfirmed moves transaction confirmed on btc back to SENT_TO_BTC state
+// and forgets its confirmation info. Used when block containing the transaction
+// was reorged out of btc chain.
+func (c *TrackedTransactionStore) SetTxUnconfirmed(txHash *chainhash.Hash) error {
+	setTxUnconfirmed := func(tx *proto.TrackedTransaction) error {
+		if tx.State != proto.TransactionState_CONFIRMED_ON_BTC {
+			return fmt.Errorf("cannot unconfirm transaction in state %s: %w", tx.State, ErrTransactionNotConfirmed)
+		}
+
+		tx.State = proto.TransactionState_SENT_TO_BTC
+		tx.StakingTxBtcConfirmationInfo = nil
+		return nil
+	}
+
+	return c.setTxState(txHash, setTxUnconfirmed)
+}
+
 func (c *TrackedTransactionStore) SetTxSentToBabylon(
 	txHash *chainhash.Hash,
 	unbondingTx *wire.MsgTx,
@@ -806,6 +1879,26 @@ func (c *TrackedTransactionStore) SetTxUnbondingSignaturesReceived(
 	return c.setTxState(txHash, setUnbondingSignaturesReceived)
 }
 
+// SetTxUnbondingStarted marks that unbonding of active delegation was started
+// i.e its unbonding transaction is being sent to btc. Delegation can be unbonded only once, so starting unbonding of
+// delegation which is not active fails.
+func (c *TrackedTransactionStore) SetTxUnbondingStarted(txHash *chainhash.Hash) error {
+	setUnbondingStarted := func(tx *proto.TrackedTransaction) error {
+		if tx.UnbondingTxData == nil {
+			return fmt.Errorf("cannot set unbonding started, because unbonding tx data does not exist: %w", ErrUnbondingDataNotFound)
+		}
+
+		if tx.State != proto.TransactionState_DELEGATION_ACTIVE {
+			return fmt.Errorf("cannot set unbonding started, because transaction is in state %s: %w", tx.State, ErrInvalidUnbondingDataUpdate)
+		}
+
+		tx.State = proto.TransactionState_UNBONDING_STARTED
+		return nil
+	}
+
+	return c.setTxState(txHash, setUnbondingStarted)
+}
+
 func (c *TrackedTransactionStore) SetTxUnbondingConfirmedOnBtc(
 	txHash *chainhash.Hash,
 	blockHash *chainhash.Hash,
@@ -827,49 +1920,301 @@ func (c *TrackedTransactionStore) SetTxUnbondingConfirmedOnBtc(
 	return c.setTxState(txHash, setUnbondingConfirmedOnBtc)
 }
 
+// SetStakingTxFeeInfo persists fee paid by staking transaction
+func (c *TrackedTransactionStore) SetStakingTxFeeInfo(
+	txHash *chainhash.Hash,
+	feeInfo *TxFeeInfo,
+) error {
+	setFeeInfo := func(tx *proto.TrackedTransaction) error {
+		tx.StakingTxFeeInfo = txFeeInfoToProto(feeInfo)
+		return nil
+	}
+
+	return c.setTxState(txHash, setFeeInfo)
+}
+
+// SetSpendTxFeeInfo persists fee paid by transaction spending staking or unbonding
+// output
+func (c *TrackedTransactionStore) SetSpendTxFeeInfo(
+	txHash *chainhash.Hash,
+	feeInfo *TxFeeInfo,
+) error {
+	setFeeInfo := func(tx *proto.TrackedTransaction) error {
+		tx.SpendTxFeeInfo = txFeeInfoToProto(feeInfo)
+		return nil
+	}
+
+	return c.setTxState(txHash, setFeeInfo)
+}
+
+// SetSpendTx persists transaction spending staking or unbonding output sent by
+// staker. If staker sent more than one such transaction, the last one is kept.
+func (c *TrackedTransactionStore) SetSpendTx(
+	txHash *chainhash.Hash,
+	spendTx *wire.MsgTx,
+) error {
+	serializedTx, err := utils.SerializeBtcTransaction(spendTx)
+
+	if err != nil {
+		return err
+	}
+
+	setSpendTx := func(tx *proto.TrackedTransaction) error {
+		tx.SpendTx = serializedTx
+		return nil
+	}
+
+	return c.setTxState(txHash, setSpendTx)
+}
+
+// SetBabylonSubmissionPayload persists serialized delegation message submitted
+// to babylon. If delegation was submitted more than once, the last payload is kept.
+func (c *TrackedTransactionStore) SetBabylonSubmissionPayload(
+	txHash *chainhash.Hash,
+	payload []byte,
+) error {
+	setPayload := func(tx *proto.TrackedTransaction) error {
+		tx.BabylonSubmissionPayload = payload
+		return nil
+	}
+
+	return c.setTxState(txHash, setPayload)
+}
+
+// SetCpfpTx persists hash and fee of child-pays-for-parent transaction bumping
+// fee of staking transaction. If fee was bumped more than once, the last child
+// transaction is kept, as it replaced the previous ones.
+func (c *TrackedTransactionStore) SetCpfpTx(
+	txHash *chainhash.Hash,
+	cpfpTxHash *chainhash.Hash,
+	cpfpTxFee btcutil.Amount,
+) error {
+	setCpfpTx := func(tx *proto.TrackedTransaction) error {
+		tx.CpfpTxHash = cpfpTxHash.CloneBytes()
+		tx.CpfpTxFee = int64(cpfpTxFee)
+		return nil
+	}
+
+	return c.setTxState(txHash, setCpfpTx)
+}
+
+func btcConfirmationInfoToProto(ci *BtcConfirmationInfo) *proto.BTCConfirmationInfo {
+	if ci == nil {
+		return nil
+	}
+
+	return &proto.BTCConfirmationInfo{
+		BlockHash:   ci.BlockHash.CloneBytes(),
+		BlockHeight: ci.Height,
+	}
+}
+
+func unbondingStoreDataToProto(ud *UnbondingStoreData) (*proto.UnbondingTxData, error) {
+	if ud == nil {
+		return nil, nil
+	}
+
+	data, err := newInitialUnbondingTxData(ud.UnbondingTx, ud.UnbondingTime)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data.CovenantSignatures = covenantSigsToProto(ud.CovenantSignatures)
+	data.UnbondingTxBtcConfirmationInfo = btcConfirmationInfoToProto(ud.UnbondingTxConfirmationInfo)
+
+	return data, nil
+}
+
+// RepairTxState overwrites state of the transaction along with all the data
+// associated with this state. Contrary to other state setters, it does not check
+// whether transition is valid, so it should only be used to fix state which
+// diverged from the state of the chains.
+func (c *TrackedTransactionStore) RepairTxState(
+	txHash *chainhash.Hash,
+	state proto.TransactionState,
+	stakingTxConfirmationInfo *BtcConfirmationInfo,
+	unbondingData *UnbondingStoreData,
+) error {
+	unbondingDataProto, err := unbondingStoreDataToProto(unbondingData)
+
+	if err != nil {
+		return err
+	}
+
+	repairState := func(tx *proto.TrackedTransaction) error {
+		tx.State = state
+		tx.StakingTxBtcConfirmationInfo = btcConfirmationInfoToProto(stakingTxConfirmationInfo)
+		tx.UnbondingTxData = unbondingDataProto
+		return nil
+	}
+
+	return c.setTxState(txHash, repairState)
+}
+
 func (c *TrackedTransactionStore) GetTransaction(txHash *chainhash.Hash) (*StoredTransaction, error) {
 	var storedTx *StoredTransaction
-	txHashBytes := txHash.CloneBytes()
 
 	err := c.db.View(func(tx kvdb.RTx) error {
+		txFromDb, err := (&StoreSnapshot{tx: tx}).GetTransaction(txHash)
+
+		if err != nil {
+			return err
+		}
+
+		storedTx = txFromDb
+		return nil
+	}, func() {})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedTx, nil
+}
+
+// GetTransactionsByFinalityProvider returns all stored transactions delegating
+// to given finality provider, regardless of their state
+func (c *TrackedTransactionStore) GetTransactionsByFinalityProvider(fpPk *btcec.PublicKey) ([]*StoredTransaction, error) {
+	var storedTxs []*StoredTransaction
+	fpPkBytes := schnorr.SerializePubKey(fpPk)
+
+	err := c.db.View(func(tx kvdb.RTx) error {
+		fpIdxBucket := tx.ReadBucket(finalityProviderIdxBucketName)
 		transactionIdxBucket := tx.ReadBucket(transactionIndexName)
+		transactionsBucket := tx.ReadBucket(transactionBucketName)
 
-		if transactionIdxBucket == nil {
+		if fpIdxBucket == nil || transactionIdxBucket == nil || transactionsBucket == nil {
 			return ErrCorruptedTransactionsDb
 		}
 
+		fpBucket := fpIdxBucket.NestedReadBucket(fpPkBytes)
+
+		if fpBucket == nil {
+			// no delegations to given finality provider
+			return nil
+		}
+
+		return fpBucket.ForEach(func(k, _ []byte) error {
+			maybeTx, _, err := getTxByHash(k, transactionIdxBucket, transactionsBucket)
+
+			if err != nil {
+				return err
+			}
+
+			var storedTxProto proto.TrackedTransaction
+			if err := pm.Unmarshal(maybeTx, &storedTxProto); err != nil {
+				return ErrCorruptedTransactionsDb
+			}
+
+			txFromDb, err := protoTxToStoredTransaction(&storedTxProto)
+
+			if err != nil {
+				return err
+			}
+
+			storedTxs = append(storedTxs, txFromDb)
+			return nil
+		})
+	}, func() {
+		storedTxs = nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedTxs, nil
+}
+
+// GetTransactionsByState returns all stored transactions which are in given
+// state. Transactions are looked up in state index, without scanning the store.
+func (c *TrackedTransactionStore) GetTransactionsByState(state proto.TransactionState) ([]*StoredTransaction, error) {
+	var storedTxs []*StoredTransaction
+
+	err := c.db.View(func(tx kvdb.RTx) error {
+		stateIdxBucket := tx.ReadBucket(stateIdxBucketName)
+		transactionIdxBucket := tx.ReadBucket(transactionIndexName)
 		transactionsBucket := tx.ReadBucket(transactionBucketName)
-		if transactionsBucket == nil {
+
+		if stateIdxBucket == nil || transactionIdxBucket == nil || transactionsBucket == nil {
 			return ErrCorruptedTransactionsDb
 		}
 
-		maybeTx, _, err := getTxByHash(txHashBytes, transactionIdxBucket, transactionsBucket)
+		stateBucket := stateIdxBucket.NestedReadBucket(stateIdxKey(state))
 
-		if err != nil {
-			return err
+		if stateBucket == nil {
+			// no transaction was ever in given state
+			return nil
 		}
 
-		var storedTxProto proto.TrackedTransaction
-		err = pm.Unmarshal(maybeTx, &storedTxProto)
-		if err != nil {
+		return stateBucket.ForEach(func(k, _ []byte) error {
+			maybeTx, _, err := getTxByHash(k, transactionIdxBucket, transactionsBucket)
+
+			if err != nil {
+				return err
+			}
+
+			var storedTxProto proto.TrackedTransaction
+			if err := pm.Unmarshal(maybeTx, &storedTxProto); err != nil {
+				return ErrCorruptedTransactionsDb
+			}
+
+			txFromDb, err := protoTxToStoredTransaction(&storedTxProto)
+
+			if err != nil {
+				return err
+			}
+
+			storedTxs = append(storedTxs, txFromDb)
+			return nil
+		})
+	}, func() {
+		storedTxs = nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return storedTxs, nil
+}
+
+// CountTransactionsByState returns number of tracked transactions in each state.
+// States without any transaction are omitted.
+func (c *TrackedTransactionStore) CountTransactionsByState() (map[proto.TransactionState]int, error) {
+	counts := make(map[proto.TransactionState]int)
+
+	err := c.db.View(func(tx kvdb.RTx) error {
+		stateIdxBucket := tx.ReadBucket(stateIdxBucketName)
+
+		if stateIdxBucket == nil {
 			return ErrCorruptedTransactionsDb
 		}
 
-		txFromDb, err := protoTxToStoredTransaction(&storedTxProto)
+		return stateIdxBucket.ForEach(func(k, _ []byte) error {
+			stateBucket := stateIdxBucket.NestedReadBucket(k)
 
-		if err != nil {
-			return err
-		}
+			if stateBucket == nil || len(k) != 4 {
+				return ErrCorruptedTransactionsDb
+			}
 
-		storedTx = txFromDb
-		return nil
-	}, func() {})
+			state := proto.TransactionState(binary.BigEndian.Uint32(k))
+
+			return stateBucket.ForEach(func(_, _ []byte) error {
+				counts[state]++
+				return nil
+			})
+		})
+	}, func() {
+		counts = make(map[proto.TransactionState]int)
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return storedTx, nil
+	return counts, nil
 }
 
 func (c *TrackedTransactionStore) GetWatchedTransactionData(txHash *chainhash.Hash) (*WatchedTransactionData, error) {
@@ -914,6 +2259,48 @@ func (c *TrackedTransactionStore) GetWatchedTransactionData(txHash *chainhash.Ha
 	return watchedData, nil
 }
 
+func (c *TrackedTransactionStore) GetPreparedTransactionData(txHash *chainhash.Hash) (*PreparedTransactionData, error) {
+	var preparedData *PreparedTransactionData
+	txHashBytes := txHash.CloneBytes()
+
+	err := c.db.View(func(tx kvdb.RTx) error {
+		preparedTxDataBucket := tx.ReadBucket(preparedTxDataBucketName)
+
+		if preparedTxDataBucket == nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		maybePreparedData := preparedTxDataBucket.Get(txHashBytes)
+
+		if maybePreparedData == nil {
+			return ErrPreparedDataNotFound
+		}
+
+		var preparedDataProto proto.WatchedTxData
+		err := pm.Unmarshal(maybePreparedData, &preparedDataProto)
+
+		if err != nil {
+			return ErrCorruptedTransactionsDb
+		}
+
+		preparedDataFromDb, err := protoPreparedDataToPreparedTransactionData(&preparedDataProto)
+
+		if err != nil {
+			return err
+		}
+
+		preparedData = preparedDataFromDb
+
+		return nil
+	}, func() {})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return preparedData, nil
+}
+
 func (c *TrackedTransactionStore) GetAllStoredTransactions() ([]StoredTransaction, error) {
 	q := DefaultStoredTransactionQuery()
 	// MaxUint64 indicates we will scan over all transactions
@@ -927,109 +2314,88 @@ func (c *TrackedTransactionStore) GetAllStoredTransactions() ([]StoredTransactio
 	return resp.Transactions, nil
 }
 
-func isTimeLockExpired(confirmationBlockHeight uint32, lockTime uint16, currentBestBlockHeight uint32) bool {
-	// transaction maybe included/executed only in next possible block
-	nexBlockHeight := int64(currentBestBlockHeight) + 1
-	pastLock := nexBlockHeight - int64(confirmationBlockHeight) - int64(lockTime)
-	return pastLock >= 0
-}
-
-func (c *TrackedTransactionStore) QueryStoredTransactions(q StoredTransactionQuery) (StoredTransactionQueryResult, error) {
-	var resp StoredTransactionQueryResult
+// confirmedAtOrAbove returns true if transaction with given confirmation info
+// was confirmed at height equal or greater than provided height
+func confirmedAtOrAbove(ci *BtcConfirmationInfo, height int32) bool {
+	if ci == nil {
+		return false
+	}
 
-	err := c.db.View(func(tx kvdb.RTx) error {
-		transactionsBucket := tx.ReadBucket(transactionBucketName)
-		if transactionsBucket == nil {
-			return ErrCorruptedTransactionsDb
-		}
+	return int64(ci.Height) >= int64(height)
+}
 
-		transactionIdxBucket := tx.ReadBucket(transactionIndexName)
+// confirmedInBlock returns true if transaction with given confirmation info
+// was confirmed in block with provided hash
+func confirmedInBlock(ci *BtcConfirmationInfo, blockHash *chainhash.Hash) bool {
+	if ci == nil {
+		return false
+	}
 
-		if transactionIdxBucket == nil {
-			return ErrCorruptedTransactionsDb
-		}
+	return ci.BlockHash.IsEqual(blockHash)
+}
 
-		numTransactions := getNumTx(transactionIdxBucket)
+// filterStoredTransactions returns all stored transactions for which filter returns true
+func (c *TrackedTransactionStore) filterStoredTransactions(filter func(tx *StoredTransaction) bool) ([]*StoredTransaction, error) {
+	var result []*StoredTransaction
 
-		if numTransactions == 0 {
-			return nil
+	err := c.ScanTrackedTransactions(func(tx *StoredTransaction) error {
+		if filter(tx) {
+			result = append(result, tx)
 		}
+		return nil
+	}, func() {
+		result = nil
+	})
 
-		resp.Total = numTransactions
-
-		paginator := newPaginator(
-			transactionsBucket.ReadCursor(), q.Reversed, q.IndexOffset,
-			q.NumMaxTransactions,
-		)
-
-		accumulateTransactions := func(key, transaction []byte) (bool, error) {
-			protoTx := proto.TrackedTransaction{}
+	if err != nil {
+		return nil, err
+	}
 
-			err := pm.Unmarshal(transaction, &protoTx)
-			if err != nil {
-				return false, err
-			}
+	return result, nil
+}
 
-			txFromDb, err := protoTxToStoredTransaction(&protoTx)
+// GetDelegationsConfirmedInBlock returns all delegations whose staking transaction
+// or unbonding transaction was confirmed in block with given hash
+func (c *TrackedTransactionStore) GetDelegationsConfirmedInBlock(blockHash *chainhash.Hash) ([]*StoredTransaction, error) {
+	return c.filterStoredTransactions(func(tx *StoredTransaction) bool {
+		if confirmedInBlock(tx.StakingTxConfirmationInfo, blockHash) {
+			return true
+		}
 
-			if err != nil {
-				return false, err
-			}
+		return tx.UnbondingTxData != nil &&
+			confirmedInBlock(tx.UnbondingTxData.UnbondingTxConfirmationInfo, blockHash)
+	})
+}
 
-			// we have query only for withdrawable transaction i.e transactions which
-			// either in SENT_TO_BABYLON or DELEGATION_ACTIVE or UNBONDING_CONFIRMED_ON_BTC state and which timelock has expired
-			if q.withdrawableTransactionsFilter != nil {
-				var confirmationHeight uint32
-				var scriptTimeLock uint16
-
-				if txFromDb.Watched {
-					// cannot withdraw watched transaction directly through staker program
-					// at least for now.
-					return false, nil
-				}
-
-				if txFromDb.StakingTxConfirmedOnBtc() {
-					scriptTimeLock = txFromDb.StakingTime
-					confirmationHeight = txFromDb.StakingTxConfirmationInfo.Height
-				} else if txFromDb.IsUnbonded() {
-					scriptTimeLock = txFromDb.UnbondingTxData.UnbondingTime
-					confirmationHeight = txFromDb.UnbondingTxData.UnbondingTxConfirmationInfo.Height
-				} else {
-					return false, nil
-				}
-
-				timeLockExpired := isTimeLockExpired(
-					confirmationHeight,
-					scriptTimeLock,
-					q.withdrawableTransactionsFilter.currentBestBlockHeight,
-				)
-
-				if timeLockExpired {
-					resp.Transactions = append(resp.Transactions, *txFromDb)
-					return true, nil
-				} else {
-					return false, nil
-				}
-			} else {
-				resp.Transactions = append(resp.Transactions, *txFromDb)
-				return true, nil
-			}
+// GetDelegationsAffectedByReorg returns all delegations whose staking transaction
+// or unbonding transaction was confirmed at height equal or greater than forkHeight
+// i.e delegations whose confirmations would be invalidated by reorg which forks
+// the chain at forkHeight
+func (c *TrackedTransactionStore) GetDelegationsAffectedByReorg(forkHeight int32) ([]*StoredTransaction, error) {
+	return c.filterStoredTransactions(func(tx *StoredTransaction) bool {
+		if confirmedAtOrAbove(tx.StakingTxConfirmationInfo, forkHeight) {
+			return true
 		}
 
-		if err := paginator.query(accumulateTransactions); err != nil {
-			return err
-		}
+		return tx.UnbondingTxData != nil &&
+			confirmedAtOrAbove(tx.UnbondingTxData.UnbondingTxConfirmationInfo, forkHeight)
+	})
+}
 
-		if q.Reversed {
-			numTx := len(resp.Transactions)
-			for i := 0; i < numTx/2; i++ {
-				reverse := numTx - i - 1
-				resp.Transactions[i], resp.Transactions[reverse] =
-					resp.Transactions[reverse], resp.Transactions[i]
-			}
-		}
+func isTimeLockExpired(confirmationBlockHeight uint32, lockTime uint16, currentBestBlockHeight uint32) bool {
+	// transaction maybe included/executed only in next possible block
+	nexBlockHeight := int64(currentBestBlockHeight) + 1
+	pastLock := nexBlockHeight - int64(confirmationBlockHeight) - int64(lockTime)
+	return pastLock >= 0
+}
 
-		return nil
+func (c *TrackedTransactionStore) QueryStoredTransactions(q StoredTransactionQuery) (StoredTransactionQueryResult, error) {
+	var resp StoredTransactionQueryResult
+
+	err := c.db.View(func(tx kvdb.RTx) error {
+		var err error
+		resp, err = (&StoreSnapshot{tx: tx}).QueryStoredTransactions(q)
+		return err
 	}, func() {
 		resp = StoredTransactionQueryResult{}
 	})
@@ -1043,27 +2409,6 @@ func (c *TrackedTransactionStore) QueryStoredTransactions(q StoredTransactionQue
 
 func (c *TrackedTransactionStore) ScanTrackedTransactions(scanFunc StoredTransactionScanFn, reset func()) error {
 	return kvdb.View(c.db, func(tx kvdb.RTx) error {
-		transactionsBucket := tx.ReadBucket(transactionBucketName)
-
-		if transactionsBucket == nil {
-			return ErrCorruptedTransactionsDb
-		}
-
-		return transactionsBucket.ForEach(func(k, v []byte) error {
-			var storedTxProto proto.TrackedTransaction
-			err := pm.Unmarshal(v, &storedTxProto)
-
-			if err != nil {
-				return ErrCorruptedTransactionsDb
-			}
-
-			txFromDb, err := protoTxToStoredTransaction(&storedTxProto)
-
-			if err != nil {
-				return err
-			}
-
-			return scanFunc(txFromDb)
-		})
+		return (&StoreSnapshot{tx: tx}).ScanTrackedTransactions(scanFunc)
 	}, reset)
 }
@@ -16,6 +16,9 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightningnetwork/lnd/kvdb"
+	"github.com/lightningnetwork/lnd/lnwallet/chainfee"
 	"github.com/stretchr/testify/require"
 )
 
@@ -130,6 +133,7 @@ func FuzzStoringTxs(f *testing.F) {
 				storedTx.FinalityProvidersBtcPks,
 				storedTx.Pop,
 				stakerAddr,
+				nil,
 			)
 			require.NoError(t, err)
 		}
@@ -189,6 +193,7 @@ func TestStateTransitions(t *testing.T) {
 		tx.FinalityProvidersBtcPks,
 		tx.Pop,
 		stakerAddr,
+		nil,
 	)
 	require.NoError(t, err)
 
@@ -197,6 +202,9 @@ func TestStateTransitions(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
 	require.Equal(t, uint64(1), storedTx.StoredTransactionIdx)
+	require.False(t, storedTx.CreatedAt.IsZero())
+	require.Equal(t, storedTx.CreatedAt, storedTx.LastStateChangeAt)
+	createdAt := storedTx.CreatedAt
 	// Confirmed
 	hash := datagen.GenRandomBtcdHash(r)
 	height := r.Uint32()
@@ -206,6 +214,8 @@ func TestStateTransitions(t *testing.T) {
 	storedTx, err = s.GetTransaction(&txHash)
 	require.NoError(t, err)
 	require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, storedTx.State)
+	require.Equal(t, createdAt, storedTx.CreatedAt)
+	require.False(t, storedTx.LastStateChangeAt.Before(createdAt))
 	require.NotNil(t, storedTx.StakingTxConfirmationInfo)
 	require.True(t, hash.IsEqual(&storedTx.StakingTxConfirmationInfo.BlockHash))
 	require.Equal(t, height, storedTx.StakingTxConfirmationInfo.Height)
@@ -228,6 +238,746 @@ func TestStateTransitions(t *testing.T) {
 	require.Equal(t, tx.StakingTime, storedTx.UnbondingTxData.UnbondingTime)
 }
 
+func TestUnconfirmTransaction(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+	err = s.AddTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+
+	// Only confirmed transactions can be unconfirmed
+	err = s.SetTxUnconfirmed(&txHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotConfirmed)
+
+	hash := datagen.GenRandomBtcdHash(r)
+	err = s.SetTxConfirmed(&txHash, &hash, r.Uint32())
+	require.NoError(t, err)
+
+	err = s.SetTxUnconfirmed(&txHash)
+	require.NoError(t, err)
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	require.Nil(t, storedTx.StakingTxConfirmationInfo)
+
+	sentToBtc, err := s.GetTransactionsByState(proto.TransactionState_SENT_TO_BTC)
+	require.NoError(t, err)
+	require.Len(t, sentToBtc, 1)
+	confirmed, err := s.GetTransactionsByState(proto.TransactionState_CONFIRMED_ON_BTC)
+	require.NoError(t, err)
+	require.Len(t, confirmed, 0)
+
+	// Transaction can be confirmed again in other block
+	newHash := datagen.GenRandomBtcdHash(r)
+	err = s.SetTxConfirmed(&txHash, &newHash, r.Uint32())
+	require.NoError(t, err)
+	storedTx, err = s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_CONFIRMED_ON_BTC, storedTx.State)
+	require.True(t, newHash.IsEqual(&storedTx.StakingTxConfirmationInfo.BlockHash))
+}
+
+func TestStoreFeeInfo(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+	err = s.AddTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Nil(t, storedTx.StakingTxFeeInfo)
+	require.Nil(t, storedTx.SpendTxFeeInfo)
+
+	stakingFeeInfo := stakerdb.NewTxFeeInfo(btcutil.Amount(2500), 250)
+	require.Equal(t, chainfee.SatPerKVByte(10000), stakingFeeInfo.FeeRate)
+	err = s.SetStakingTxFeeInfo(&txHash, stakingFeeInfo)
+	require.NoError(t, err)
+
+	spendFeeInfo := stakerdb.NewTxFeeInfo(btcutil.Amount(1000), 150)
+	err = s.SetSpendTxFeeInfo(&txHash, spendFeeInfo)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	// setting fee info does not change state of the transaction
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	require.Equal(t, stakingFeeInfo, storedTx.StakingTxFeeInfo)
+	require.Equal(t, spendFeeInfo, storedTx.SpendTxFeeInfo)
+
+	// fee of funded transaction is stored together with the transaction
+	fundedTx := genStoredTransaction(t, r, 200)
+	fundedTxHash := fundedTx.StakingTx.TxHash()
+	err = s.AddFundedTransaction(
+		fundedTx.StakingTx,
+		fundedTx.StakingOutputIndex,
+		fundedTx.StakingTime,
+		fundedTx.FinalityProvidersBtcPks,
+		fundedTx.Pop,
+		stakerAddr,
+		nil,
+		nil,
+		&stakerdb.StakingTxFunding{FeeInfo: stakingFeeInfo},
+	)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&fundedTxHash)
+	require.NoError(t, err)
+	require.Equal(t, stakingFeeInfo, storedTx.StakingTxFeeInfo)
+	require.Nil(t, storedTx.SpendTxFeeInfo)
+}
+
+func TestStoreWalletName(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+	err = s.AddFundedTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+		nil,
+		&stakerdb.StakingTxFunding{WalletName: "treasury"},
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, "treasury", storedTx.WalletName)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+
+	// transaction funded by default wallet does not record wallet name
+	defaultTx := genStoredTransaction(t, r, 200)
+	defaultTxHash := defaultTx.StakingTx.TxHash()
+	err = s.AddFundedTransaction(
+		defaultTx.StakingTx,
+		defaultTx.StakingOutputIndex,
+		defaultTx.StakingTime,
+		defaultTx.FinalityProvidersBtcPks,
+		defaultTx.Pop,
+		stakerAddr,
+		nil,
+		nil,
+		&stakerdb.StakingTxFunding{},
+	)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&defaultTxHash)
+	require.NoError(t, err)
+	require.Empty(t, storedTx.WalletName)
+}
+
+func TestStoreStakingTxChange(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+
+	change := &stakerdb.ChangeOutput{
+		OutputIdx: 1,
+		PkScript:  []byte{0x00, 0x14, 0x01},
+	}
+
+	err = s.AddFundedTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+		nil,
+		&stakerdb.StakingTxFunding{Change: change},
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, change, storedTx.StakingTxChange)
+
+	// transaction added without funding has no recorded change
+	otherTx := genStoredTransaction(t, r, 200)
+	otherTxHash := otherTx.StakingTx.TxHash()
+	err = s.AddTransaction(
+		otherTx.StakingTx,
+		otherTx.StakingOutputIndex,
+		otherTx.StakingTime,
+		otherTx.FinalityProvidersBtcPks,
+		otherTx.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&otherTxHash)
+	require.NoError(t, err)
+	require.Nil(t, storedTx.StakingTxChange)
+}
+
+func TestStoreStakingParamsSnapshot(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+
+	covenantKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	// covenant keys are stored in schnorr format, which only preserves x coordinate
+	covenantPk, err := schnorr.ParsePubKey(schnorr.SerializePubKey(covenantKey.PubKey()))
+	require.NoError(t, err)
+
+	snapshot := &stakerdb.StakingParamsSnapshot{
+		ConfirmationTimeBlocks:    6,
+		FinalizationTimeoutBlocks: 20,
+		MinSlashingTxFeeSat:       btcutil.Amount(1000),
+		CovenantPks:               []*btcec.PublicKey{covenantPk},
+		CovenantQuorum:            1,
+		MinUnbondingTime:          101,
+		SlashingAddress:           stakerAddr.EncodeAddress(),
+		SlashingRate:              "0.100000000000000000",
+	}
+
+	err = s.AddTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		snapshot,
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	require.Equal(t, snapshot, storedTx.StakingParamsSnapshot)
+
+	// transaction added without snapshot does not have one
+	tx2 := genStoredTransaction(t, r, 200)
+	txHash2 := tx2.StakingTx.TxHash()
+	err = s.AddTransaction(
+		tx2.StakingTx,
+		tx2.StakingOutputIndex,
+		tx2.StakingTime,
+		tx2.FinalityProvidersBtcPks,
+		tx2.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+
+	storedTx2, err := s.GetTransaction(&txHash2)
+	require.NoError(t, err)
+	require.Nil(t, storedTx2.StakingParamsSnapshot)
+}
+
+func TestStoreTransactionWithDelegationData(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	// keys are stored in schnorr format, which only preserves x coordinate
+	stakerPk, err := schnorr.ParsePubKey(schnorr.SerializePubKey(stakerKey.PubKey()))
+	require.NoError(t, err)
+	slashingTxSig, err := schnorr.Sign(stakerKey, datagen.GenRandomByteArray(r, 32))
+	require.NoError(t, err)
+	slashUnbondingTxSig, err := schnorr.Sign(stakerKey, datagen.GenRandomByteArray(r, 32))
+	require.NoError(t, err)
+
+	delegationData := &stakerdb.WatchedTransactionData{
+		SlashingTx:             datagen.GenRandomTx(r),
+		SlashingTxSig:          slashingTxSig,
+		StakerBabylonAddr:      datagen.GenRandomAccount().GetAddress(),
+		StakerBtcPubKey:        stakerPk,
+		UnbondingTx:            datagen.GenRandomTx(r),
+		SlashingUnbondingTx:    datagen.GenRandomTx(r),
+		SlashingUnbondingTxSig: slashUnbondingTxSig,
+		UnbondingTime:          101,
+	}
+
+	err = s.AddTransactionWithDelegationData(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		tx.Pop,
+		stakerAddr,
+		nil,
+		delegationData,
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	// transaction is still owned by staker
+	require.False(t, storedTx.Watched)
+
+	storedData, err := s.GetWatchedTransactionData(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, delegationData.SlashingTx, storedData.SlashingTx)
+	require.Equal(t, slashingTxSig.Serialize(), storedData.SlashingTxSig.Serialize())
+	require.Equal(t, delegationData.StakerBabylonAddr, storedData.StakerBabylonAddr)
+	require.Equal(t, stakerPk, storedData.StakerBtcPubKey)
+	require.Equal(t, delegationData.UnbondingTx, storedData.UnbondingTx)
+	require.Equal(t, delegationData.SlashingUnbondingTx, storedData.SlashingUnbondingTx)
+	require.Equal(t, slashUnbondingTxSig.Serialize(), storedData.SlashingUnbondingTxSig.Serialize())
+	require.Equal(t, uint16(101), storedData.UnbondingTime)
+
+	// transaction added without delegation data has none
+	tx2 := genStoredTransaction(t, r, 200)
+	txHash2 := tx2.StakingTx.TxHash()
+	err = s.AddTransaction(
+		tx2.StakingTx,
+		tx2.StakingOutputIndex,
+		tx2.StakingTime,
+		tx2.FinalityProvidersBtcPks,
+		tx2.Pop,
+		stakerAddr,
+		nil,
+	)
+	require.NoError(t, err)
+
+	_, err = s.GetWatchedTransactionData(&txHash2)
+	require.ErrorIs(t, err, stakerdb.ErrWatchedDataNotFound)
+}
+
+func TestStorePreparedTransaction(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+	tx := genStoredTransaction(t, r, 200)
+	stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	txHash := tx.StakingTx.TxHash()
+
+	stakerKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	// keys are stored in schnorr format, which only preserves x coordinate
+	stakerPk, err := schnorr.ParsePubKey(schnorr.SerializePubKey(stakerKey.PubKey()))
+	require.NoError(t, err)
+	stakerBabylonAddr := datagen.GenRandomAccount().GetAddress()
+	slashingTx := datagen.GenRandomTx(r)
+	unbondingTx := datagen.GenRandomTx(r)
+	slashUnbondingTx := datagen.GenRandomTx(r)
+
+	err = s.AddPreparedTransaction(
+		tx.StakingTx,
+		tx.StakingOutputIndex,
+		tx.StakingTime,
+		tx.FinalityProvidersBtcPks,
+		stakerAddr,
+		slashingTx,
+		stakerBabylonAddr,
+		stakerPk,
+		unbondingTx,
+		slashUnbondingTx,
+		101,
+	)
+	require.NoError(t, err)
+
+	storedTx, err := s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_PREPARED, storedTx.State)
+	require.True(t, storedTx.Watched)
+
+	preparedData, err := s.GetPreparedTransactionData(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, slashingTx, preparedData.SlashingTx)
+	require.Equal(t, stakerBabylonAddr, preparedData.StakerBabylonAddr)
+	require.Equal(t, stakerPk, preparedData.StakerBtcPubKey)
+	require.Equal(t, unbondingTx, preparedData.UnbondingTx)
+	require.Equal(t, slashUnbondingTx, preparedData.SlashingUnbondingTx)
+	require.Equal(t, uint16(101), preparedData.UnbondingTime)
+
+	// watched data is available only after transaction is signed
+	_, err = s.GetWatchedTransactionData(&txHash)
+	require.ErrorIs(t, err, stakerdb.ErrWatchedDataNotFound)
+
+	// staking transaction signed externally replaces unsigned one
+	signedStakingTx := tx.StakingTx.Copy()
+	signedStakingTx.TxIn[0].Witness = wire.TxWitness{datagen.GenRandomByteArray(r, 64)}
+	err = s.SetPreparedStakingTx(&txHash, signedStakingTx)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, signedStakingTx, storedTx.StakingTx)
+	require.Equal(t, proto.TransactionState_PREPARED, storedTx.State)
+
+	slashingTxSig, err := schnorr.Sign(stakerKey, datagen.GenRandomByteArray(r, 32))
+	require.NoError(t, err)
+	slashUnbondingTxSig, err := schnorr.Sign(stakerKey, datagen.GenRandomByteArray(r, 32))
+	require.NoError(t, err)
+
+	err = s.SetPreparedTransactionSigned(&txHash, tx.Pop, slashingTxSig, slashUnbondingTxSig, nil)
+	require.NoError(t, err)
+
+	storedTx, err = s.GetTransaction(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, proto.TransactionState_SENT_TO_BTC, storedTx.State)
+	require.Equal(t, tx.Pop, storedTx.Pop)
+
+	watchedData, err := s.GetWatchedTransactionData(&txHash)
+	require.NoError(t, err)
+	require.Equal(t, slashingTx, watchedData.SlashingTx)
+	require.Equal(t, slashingTxSig.Serialize(), watchedData.SlashingTxSig.Serialize())
+	require.Equal(t, slashUnbondingTxSig.Serialize(), watchedData.SlashingUnbondingTxSig.Serialize())
+	require.Equal(t, stakerPk, watchedData.StakerBtcPubKey)
+
+	_, err = s.GetPreparedTransactionData(&txHash)
+	require.ErrorIs(t, err, stakerdb.ErrPreparedDataNotFound)
+
+	// transaction can be signed only once
+	err = s.SetPreparedTransactionSigned(&txHash, tx.Pop, slashingTxSig, slashUnbondingTxSig, nil)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotPrepared)
+
+	err = s.SetPreparedStakingTx(&txHash, signedStakingTx)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotPrepared)
+}
+
+func TestRemovePreparedTransaction(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+
+	addPrepared := func(tx *stakerdb.StoredTransaction) {
+		stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		stakerKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+
+		err = s.AddPreparedTransaction(
+			tx.StakingTx,
+			tx.StakingOutputIndex,
+			tx.StakingTime,
+			tx.FinalityProvidersBtcPks,
+			stakerAddr,
+			datagen.GenRandomTx(r),
+			datagen.GenRandomAccount().GetAddress(),
+			stakerKey.PubKey(),
+			datagen.GenRandomTx(r),
+			datagen.GenRandomTx(r),
+			101,
+		)
+		require.NoError(t, err)
+	}
+
+	removed := genStoredTransaction(t, r, 200)
+	removedHash := removed.StakingTx.TxHash()
+	addPrepared(removed)
+
+	kept := genStoredTransaction(t, r, 200)
+	keptHash := kept.StakingTx.TxHash()
+	addPrepared(kept)
+
+	require.NoError(t, s.RemovePreparedTransaction(&removedHash))
+
+	_, err := s.GetTransaction(&removedHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotFound)
+	_, err = s.GetPreparedTransactionData(&removedHash)
+	require.ErrorIs(t, err, stakerdb.ErrPreparedDataNotFound)
+
+	prepared, err := s.GetTransactionsByState(proto.TransactionState_PREPARED)
+	require.NoError(t, err)
+	require.Len(t, prepared, 1)
+	require.Equal(t, keptHash, prepared[0].StakingTx.TxHash())
+
+	result, err := s.QueryStoredTransactions(stakerdb.DefaultStoredTransactionQuery())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), result.Total)
+	require.Len(t, result.Transactions, 1)
+
+	// removed transaction can be prepared again
+	addPrepared(removed)
+	_, err = s.GetTransaction(&removedHash)
+	require.NoError(t, err)
+
+	// only prepared transaction can be removed
+	signer, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	sig, err := schnorr.Sign(signer, datagen.GenRandomByteArray(r, 32))
+	require.NoError(t, err)
+	require.NoError(t, s.SetPreparedTransactionSigned(&keptHash, kept.Pop, sig, sig, nil))
+	err = s.RemovePreparedTransaction(&keptHash)
+	require.ErrorIs(t, err, stakerdb.ErrTransactionNotPrepared)
+}
+
+func TestQueryTransactionsByFinalityProvider(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+
+	fpA, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	fpB, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	fpC, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	fpSets := [][]*btcec.PublicKey{
+		{fpA.PubKey()},
+		{fpA.PubKey(), fpB.PubKey()},
+		{fpB.PubKey()},
+	}
+
+	hashes := make([]chainhash.Hash, len(fpSets))
+	for i, fps := range fpSets {
+		tx := genStoredTransaction(t, r, 200)
+		stakerAddr, err := btcutil.DecodeAddress(tx.StakerAddress, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+
+		err = s.AddTransaction(
+			tx.StakingTx,
+			tx.StakingOutputIndex,
+			tx.StakingTime,
+			fps,
+			tx.Pop,
+			stakerAddr,
+			nil,
+		)
+		require.NoError(t, err)
+		hashes[i] = tx.StakingTx.TxHash()
+	}
+
+	txHashes := func(txs []*stakerdb.StoredTransaction) []chainhash.Hash {
+		var res []chainhash.Hash
+		for _, tx := range txs {
+			res = append(res, tx.StakingTx.TxHash())
+		}
+		return res
+	}
+
+	txs, err := s.GetTransactionsByFinalityProvider(fpA.PubKey())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{hashes[0], hashes[1]}, txHashes(txs))
+
+	txs, err = s.GetTransactionsByFinalityProvider(fpB.PubKey())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{hashes[1], hashes[2]}, txHashes(txs))
+
+	txs, err = s.GetTransactionsByFinalityProvider(fpC.PubKey())
+	require.NoError(t, err)
+	require.Empty(t, txs)
+}
+
+func TestQueryTransactionsByState(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	cfg := stakercfg.DefaultDBConfig()
+	cfg.DBPath = t.TempDir()
+
+	backend, err := stakercfg.GetDbBackend(&cfg)
+	require.NoError(t, err)
+
+	s, err := stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+
+	generatedStoredTxs := genNStoredTransactions(t, r, 3, 200)
+	hashes := make([]chainhash.Hash, len(generatedStoredTxs))
+
+	for i, storedTx := range generatedStoredTxs {
+		stakerAddr, err := btcutil.DecodeAddress(storedTx.StakerAddress, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		err = s.AddTransaction(
+			storedTx.StakingTx,
+			storedTx.StakingOutputIndex,
+			storedTx.StakingTime,
+			storedTx.FinalityProvidersBtcPks,
+			storedTx.Pop,
+			stakerAddr,
+			nil,
+		)
+		require.NoError(t, err)
+		hashes[i] = storedTx.StakingTx.TxHash()
+	}
+
+	// first two transactions are confirmed, first one is also sent to babylon
+	for i := 0; i < 2; i++ {
+		err = s.SetTxConfirmed(&hashes[i], &chainhash.Hash{1}, 10)
+		require.NoError(t, err)
+	}
+	err = s.SetTxSentToBabylon(&hashes[0], generatedStoredTxs[0].StakingTx, generatedStoredTxs[0].StakingTime)
+	require.NoError(t, err)
+
+	// updates which do not change state do not affect the index
+	err = s.SetStakingTxFeeInfo(&hashes[1], stakerdb.NewTxFeeInfo(1000, 200))
+	require.NoError(t, err)
+
+	txHashes := func(txs []*stakerdb.StoredTransaction) []chainhash.Hash {
+		var hashes []chainhash.Hash
+		for _, tx := range txs {
+			hashes = append(hashes, tx.StakingTx.TxHash())
+		}
+		return hashes
+	}
+
+	checkIndex := func(s *stakerdb.TrackedTransactionStore) {
+		txs, err := s.GetTransactionsByState(proto.TransactionState_SENT_TO_BTC)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []chainhash.Hash{hashes[2]}, txHashes(txs))
+
+		txs, err = s.GetTransactionsByState(proto.TransactionState_CONFIRMED_ON_BTC)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []chainhash.Hash{hashes[1]}, txHashes(txs))
+
+		txs, err = s.GetTransactionsByState(proto.TransactionState_SENT_TO_BABYLON)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []chainhash.Hash{hashes[0]}, txHashes(txs))
+
+		counts, err := s.CountTransactionsByState()
+		require.NoError(t, err)
+		require.Equal(t, map[proto.TransactionState]int{
+			proto.TransactionState_SENT_TO_BTC:      1,
+			proto.TransactionState_CONFIRMED_ON_BTC: 1,
+			proto.TransactionState_SENT_TO_BABYLON:  1,
+		}, counts)
+
+		txs, err = s.GetTransactionsByState(proto.TransactionState_DELEGATION_ACTIVE)
+		require.NoError(t, err)
+		require.Empty(t, txs)
+
+		query := stakerdb.DefaultStoredTransactionQuery()
+		result, err := s.QueryStoredTransactions(query.StateFilter(proto.TransactionState_CONFIRMED_ON_BTC))
+		require.NoError(t, err)
+		require.Len(t, result.Transactions, 1)
+		require.Equal(t, hashes[1], result.Transactions[0].StakingTx.TxHash())
+	}
+
+	checkIndex(s)
+
+	// index survives restart
+	require.NoError(t, backend.Close())
+	backend, err = stakercfg.GetDbBackend(&cfg)
+	require.NoError(t, err)
+	s, err = stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+	checkIndex(s)
+
+	// index is rebuilt for db created before it was introduced
+	err = kvdb.Update(backend, func(tx kvdb.RwTx) error {
+		return tx.DeleteTopLevelBucket([]byte("stateIdx"))
+	}, func() {})
+	require.NoError(t, err)
+	require.NoError(t, backend.Close())
+	backend, err = stakercfg.GetDbBackend(&cfg)
+	require.NoError(t, err)
+	defer backend.Close()
+	s, err = stakerdb.NewTrackedTransactionStore(backend)
+	require.NoError(t, err)
+	checkIndex(s)
+}
+
+func TestQueryDelegationsAffectedByReorg(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	s := MakeTestStore(t)
+
+	// first 5 transactions are confirmed at heights 100..104, last one is unconfirmed
+	confirmationHeights := []uint32{100, 101, 102, 103, 104}
+	generatedStoredTxs := genNStoredTransactions(t, r, len(confirmationHeights)+1, 200)
+	blockHashes := make([]chainhash.Hash, len(confirmationHeights))
+
+	for i, storedTx := range generatedStoredTxs {
+		stakerAddr, err := btcutil.DecodeAddress(storedTx.StakerAddress, &chaincfg.MainNetParams)
+		require.NoError(t, err)
+		err = s.AddTransaction(
+			storedTx.StakingTx,
+			storedTx.StakingOutputIndex,
+			storedTx.StakingTime,
+			storedTx.FinalityProvidersBtcPks,
+			storedTx.Pop,
+			stakerAddr,
+			nil,
+		)
+		require.NoError(t, err)
+
+		if i < len(confirmationHeights) {
+			txHash := storedTx.StakingTx.TxHash()
+			blockHashes[i] = datagen.GenRandomBtcdHash(r)
+			err = s.SetTxConfirmed(&txHash, &blockHashes[i], confirmationHeights[i])
+			require.NoError(t, err)
+		}
+	}
+
+	// first delegation was unbonded in block at height 103
+	firstTxHash := generatedStoredTxs[0].StakingTx.TxHash()
+	err := s.SetTxSentToBabylon(&firstTxHash, generatedStoredTxs[0].StakingTx, generatedStoredTxs[0].StakingTime)
+	require.NoError(t, err)
+	err = s.SetTxUnbondingConfirmedOnBtc(&firstTxHash, &blockHashes[3], confirmationHeights[3])
+	require.NoError(t, err)
+
+	txHashes := func(txs []*stakerdb.StoredTransaction) []chainhash.Hash {
+		var hashes []chainhash.Hash
+		for _, tx := range txs {
+			hashes = append(hashes, tx.StakingTx.TxHash())
+		}
+		return hashes
+	}
+
+	inBlock, err := s.GetDelegationsConfirmedInBlock(&blockHashes[3])
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{
+		firstTxHash,
+		generatedStoredTxs[3].StakingTx.TxHash(),
+	}, txHashes(inBlock))
+
+	unknownBlock := datagen.GenRandomBtcdHash(r)
+	inBlock, err = s.GetDelegationsConfirmedInBlock(&unknownBlock)
+	require.NoError(t, err)
+	require.Empty(t, inBlock)
+
+	affected, err := s.GetDelegationsAffectedByReorg(102)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{
+		firstTxHash,
+		generatedStoredTxs[2].StakingTx.TxHash(),
+		generatedStoredTxs[3].StakingTx.TxHash(),
+		generatedStoredTxs[4].StakingTx.TxHash(),
+	}, txHashes(affected))
+
+	affected, err = s.GetDelegationsAffectedByReorg(105)
+	require.NoError(t, err)
+	require.Empty(t, affected)
+
+	affected, err = s.GetDelegationsAffectedByReorg(0)
+	require.NoError(t, err)
+	require.Len(t, affected, len(confirmationHeights))
+}
+
 func TestPaginator(t *testing.T) {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	s := MakeTestStore(t)
@@ -246,6 +996,7 @@ func TestPaginator(t *testing.T) {
 			storedTx.FinalityProvidersBtcPks,
 			storedTx.Pop,
 			stakerAddr,
+			nil,
 		)
 		require.NoError(t, err)
 	}
@@ -316,6 +1067,7 @@ func FuzzQuerySpendableTx(f *testing.F) {
 				storedTx.FinalityProvidersBtcPks,
 				storedTx.Pop,
 				stakerAddr,
+				nil,
 			)
 			require.NoError(t, err)
 		}
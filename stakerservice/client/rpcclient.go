@@ -41,6 +41,19 @@ func (c *StakerServiceJsonRpcClient) ListOutputs(ctx context.Context) (*service.
 	return result, nil
 }
 
+func (c *StakerServiceJsonRpcClient) NewAddress(ctx context.Context, addressType string) (*service.NewAddressResponse, error) {
+	result := new(service.NewAddressResponse)
+
+	params := make(map[string]interface{})
+	params["addressType"] = addressType
+
+	_, err := c.client.Call(ctx, "new_address", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *StakerServiceJsonRpcClient) BabylonFinalityProviders(ctx context.Context, offset *int, limit *int) (*service.FinalityProvidersResponse, error) {
 	result := new(service.FinalityProvidersResponse)
 
@@ -83,6 +96,141 @@ func (c *StakerServiceJsonRpcClient) Stake(
 	return result, nil
 }
 
+// StakeFromWallet works the same as Stake, but staking transaction is funded by
+// additional wallet with given name registered in the daemon
+func (c *StakerServiceJsonRpcClient) StakeFromWallet(
+	ctx context.Context,
+	walletName string,
+	stakerAddress string,
+	stakingAmount int64,
+	fpPks []string,
+	stakingTimeBlocks int64,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakerAddress"] = stakerAddress
+	params["stakingAmount"] = stakingAmount
+	params["fpBtcPks"] = fpPks
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+	params["walletName"] = walletName
+
+	_, err := c.client.Call(ctx, "stake", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StakeWithFeeRate works the same as Stake, but staking transaction is funded
+// using fee rate in sat/kvB supplied by caller instead of fee rate estimated by
+// the daemon
+func (c *StakerServiceJsonRpcClient) StakeWithFeeRate(
+	ctx context.Context,
+	stakerAddress string,
+	stakingAmount int64,
+	fpPks []string,
+	stakingTimeBlocks int64,
+	feeRate int64,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakerAddress"] = stakerAddress
+	params["stakingAmount"] = stakingAmount
+	params["fpBtcPks"] = fpPks
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+	params["feeRate"] = feeRate
+
+	_, err := c.client.Call(ctx, "stake", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// BuildStakingTx previews staking transaction which Stake with the same
+// arguments would create, without signing or sending it. Empty walletName
+// selects the main wallet of the daemon.
+func (c *StakerServiceJsonRpcClient) BuildStakingTx(
+	ctx context.Context,
+	walletName string,
+	stakerAddress string,
+	stakingAmount int64,
+	fpPks []string,
+	stakingTimeBlocks int64,
+) (*service.StakingTxPreviewResponse, error) {
+	result := new(service.StakingTxPreviewResponse)
+
+	params := make(map[string]interface{})
+	params["stakerAddress"] = stakerAddress
+	params["stakingAmount"] = stakingAmount
+	params["fpBtcPks"] = fpPks
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+
+	if walletName != "" {
+		params["walletName"] = walletName
+	}
+
+	_, err := c.client.Call(ctx, "build_staking_tx", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// EstimateStakeCost estimates staking amount together with fees of funding and
+// withdrawing the stake. If feeRate is nil, fee rate estimated by btc node is
+// used
+func (c *StakerServiceJsonRpcClient) EstimateStakeCost(
+	ctx context.Context,
+	stakingAmount int64,
+	stakingTimeBlocks int64,
+	feeRate *int64,
+) (*service.StakeCostEstimateResponse, error) {
+	result := new(service.StakeCostEstimateResponse)
+
+	params := make(map[string]interface{})
+	params["stakingAmount"] = stakingAmount
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+
+	if feeRate != nil {
+		params["feeRate"] = feeRate
+	}
+
+	_, err := c.client.Call(ctx, "estimate_stake_cost", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StakeAllowDuplicateFp works the same as Stake, but asks staker to create
+// delegation even if it already has active delegation to one of the finality
+// providers and staker is configured to refuse such delegations.
+func (c *StakerServiceJsonRpcClient) StakeAllowDuplicateFp(
+	ctx context.Context,
+	stakerAddress string,
+	stakingAmount int64,
+	fpPks []string,
+	stakingTimeBlocks int64,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakerAddress"] = stakerAddress
+	params["stakingAmount"] = stakingAmount
+	params["fpBtcPks"] = fpPks
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+	params["allowDuplicateFp"] = true
+
+	_, err := c.client.Call(ctx, "stake", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *StakerServiceJsonRpcClient) ListStakingTransactions(ctx context.Context, offset *int, limit *int) (*service.ListStakingTransactionsResponse, error) {
 	result := new(service.ListStakingTransactionsResponse)
 
@@ -103,6 +251,30 @@ func (c *StakerServiceJsonRpcClient) ListStakingTransactions(ctx context.Context
 	return result, nil
 }
 
+// ListStakingTransactionsInState works the same as ListStakingTransactions,
+// but returns only transactions in given state
+func (c *StakerServiceJsonRpcClient) ListStakingTransactionsInState(ctx context.Context, offset *int, limit *int, state string) (*service.ListStakingTransactionsResponse, error) {
+	result := new(service.ListStakingTransactionsResponse)
+
+	params := make(map[string]interface{})
+
+	if limit != nil {
+		params["limit"] = limit
+	}
+
+	if offset != nil {
+		params["offset"] = offset
+	}
+
+	params["state"] = state
+
+	_, err := c.client.Call(ctx, "list_staking_transactions", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *StakerServiceJsonRpcClient) WithdrawableTransactions(ctx context.Context, offset *int, limit *int) (*service.WithdrawableTransactionsResponse, error) {
 	result := new(service.WithdrawableTransactionsResponse)
 
@@ -136,12 +308,94 @@ func (c *StakerServiceJsonRpcClient) StakingDetails(ctx context.Context, txHash
 	return result, nil
 }
 
-func (c *StakerServiceJsonRpcClient) SpendStakingTransaction(ctx context.Context, txHash string) (*service.SpendTxDetails, error) {
+func (c *StakerServiceJsonRpcClient) StakingTxConfirmations(ctx context.Context, txHash string) (*service.StakingConfirmationsResponse, error) {
+	result := new(service.StakingConfirmationsResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = txHash
+
+	_, err := c.client.Call(ctx, "staking_tx_confirmations", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) SpendTxStatus(ctx context.Context, stakingTxHash string) (*service.SpendTxStatusResponse, error) {
+	result := new(service.SpendTxStatusResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = stakingTxHash
+
+	_, err := c.client.Call(ctx, "spend_tx_status", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) CovenantSignatureProgress(ctx context.Context, txHash string) (*service.CovenantSignatureProgressResponse, error) {
+	result := new(service.CovenantSignatureProgressResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = txHash
+
+	_, err := c.client.Call(ctx, "covenant_signature_progress", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) DelegationRewards(ctx context.Context, txHash string) (*service.DelegationRewardsResponse, error) {
+	result := new(service.DelegationRewardsResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = txHash
+
+	_, err := c.client.Call(ctx, "delegation_rewards", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) WithdrawalDestinations(ctx context.Context, txHash string) (*service.WithdrawalDestinationsResponse, error) {
+	result := new(service.WithdrawalDestinationsResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = txHash
+
+	_, err := c.client.Call(ctx, "withdrawal_destinations", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) BabylonSubmissionPayload(ctx context.Context, txHash string) (*service.BabylonSubmissionPayloadResponse, error) {
+	result := new(service.BabylonSubmissionPayloadResponse)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = txHash
+
+	_, err := c.client.Call(ctx, "babylon_submission_payload", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) SpendStakingTransaction(ctx context.Context, txHash string, feeRate *int) (*service.SpendTxDetails, error) {
 	result := new(service.SpendTxDetails)
 
 	params := make(map[string]interface{})
 	params["stakingTxHash"] = txHash
 
+	if feeRate != nil {
+		params["feeRate"] = feeRate
+	}
+
 	_, err := c.client.Call(ctx, "spend_stake", params, result)
 	if err != nil {
 		return nil, err
@@ -193,6 +447,88 @@ func (c *StakerServiceJsonRpcClient) WatchStaking(
 	return result, nil
 }
 
+func (c *StakerServiceJsonRpcClient) PrepareDelegation(
+	ctx context.Context,
+	stakerAddress string,
+	stakerBtcPk string,
+	stakingAmount int64,
+	fpBtcPks []string,
+	stakingTimeBlocks int64,
+) (*service.PreparedDelegationResponse, error) {
+	result := new(service.PreparedDelegationResponse)
+
+	params := make(map[string]interface{})
+	params["stakerAddress"] = stakerAddress
+	params["stakerBtcPk"] = stakerBtcPk
+	params["stakingAmount"] = stakingAmount
+	params["fpBtcPks"] = fpBtcPks
+	params["stakingTimeBlocks"] = stakingTimeBlocks
+
+	_, err := c.client.Call(ctx, "prepare_delegation", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) SubmitPreparedDelegation(
+	ctx context.Context,
+	stakingTxHash string,
+	stakerBtcSig string,
+	popType int,
+	slashingTxSig string,
+	slashUnbondingTxSig string,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = stakingTxHash
+	params["stakerBtcSig"] = stakerBtcSig
+	params["popType"] = popType
+	params["slashingTxSig"] = slashingTxSig
+	params["slashUnbondingTxSig"] = slashUnbondingTxSig
+
+	_, err := c.client.Call(ctx, "submit_prepared_delegation", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) SubmitSignedStakingTx(
+	ctx context.Context,
+	stakingTxHash string,
+	signedStakingTx string,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = stakingTxHash
+	params["signedStakingTx"] = signedStakingTx
+
+	_, err := c.client.Call(ctx, "submit_signed_staking_tx", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *StakerServiceJsonRpcClient) CancelPreparedDelegation(
+	ctx context.Context,
+	stakingTxHash string,
+) (*service.ResultStake, error) {
+	result := new(service.ResultStake)
+
+	params := make(map[string]interface{})
+	params["stakingTxHash"] = stakingTxHash
+
+	_, err := c.client.Call(ctx, "cancel_prepared_delegation", params, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (c *StakerServiceJsonRpcClient) UnbondStaking(ctx context.Context, txHash string, feeRate *int) (*service.UnbondingResponse, error) {
 	result := new(service.UnbondingResponse)
 
@@ -0,0 +1,244 @@
+package stakerservice
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net"
+	"time"
+
+	"github.com/babylonchain/btc-staker/proto"
+	str "github.com/babylonchain/btc-staker/staker"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcServer exposes subset of staker app operations over gRPC
+type GrpcServer struct {
+	proto.UnimplementedStakerServiceServer
+
+	staker    *str.StakerApp
+	netParams *chaincfg.Params
+	logger    *logrus.Logger
+	server    *grpc.Server
+}
+
+func NewGrpcServer(
+	s *str.StakerApp,
+	netParams *chaincfg.Params,
+	l *logrus.Logger,
+) *GrpcServer {
+	server := &GrpcServer{
+		staker:    s,
+		netParams: netParams,
+		logger:    l,
+		server:    grpc.NewServer(),
+	}
+
+	proto.RegisterStakerServiceServer(server.server, server)
+
+	return server
+}
+
+// Serve accepts gRPC connections on listener until server is stopped
+func (s *GrpcServer) Serve(listener net.Listener) error {
+	return s.server.Serve(listener)
+}
+
+// Stop stops server, waiting for pending requests to finish
+func (s *GrpcServer) Stop() {
+	s.server.GracefulStop()
+}
+
+// toGrpcError translates errors returned by staker app to gRPC status errors
+func toGrpcError(err error) error {
+	var code codes.Code
+
+	switch {
+	case errors.Is(err, stakerdb.ErrTransactionNotFound):
+		code = codes.NotFound
+	case errors.Is(err, str.ErrDuplicateFpDelegation):
+		code = codes.AlreadyExists
+	case errors.Is(err, str.ErrDestinationNotAllowed):
+		code = codes.PermissionDenied
+	case errors.Is(err, str.ErrFeeRateOutOfTolerance),
+		errors.Is(err, str.ErrUnsupportedStakerAddress):
+		code = codes.InvalidArgument
+	case errors.Is(err, str.ErrMaxActiveDelegationsReached):
+		code = codes.ResourceExhausted
+	case errors.Is(err, str.ErrBabylonLightClientNotReady),
+		errors.Is(err, str.ErrTxNotConfirmed),
+		errors.Is(err, str.ErrDelegationNotActive):
+		code = codes.FailedPrecondition
+	case errors.Is(err, str.ErrDelegationChanged):
+		code = codes.Aborted
+	default:
+		code = codes.Internal
+	}
+
+	return status.Error(code, err.Error())
+}
+
+func (s *GrpcServer) StakeFunds(ctx context.Context, req *proto.StakeFundsRequest) (*proto.StakeFundsResponse, error) {
+	if req.StakingAmount <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "staking amount must be positive")
+	}
+
+	stakerAddr, err := btcutil.DecodeAddress(req.StakerAddress, s.netParams)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid staker address: %v", err)
+	}
+
+	fpPubKeys := make([]*btcec.PublicKey, 0, len(req.FpBtcPks))
+
+	for _, fpPk := range req.FpBtcPks {
+		fpPkBytes, err := hex.DecodeString(fpPk)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid finality provider public key %s: %v", fpPk, err)
+		}
+
+		fpSchnorrKey, err := schnorr.ParsePubKey(fpPkBytes)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid finality provider public key %s: %v", fpPk, err)
+		}
+
+		fpPubKeys = append(fpPubKeys, fpSchnorrKey)
+	}
+
+	if req.StakingTimeBlocks == 0 || req.StakingTimeBlocks > math.MaxUint16 {
+		return nil, status.Errorf(codes.InvalidArgument, "staking time must be positive and lower than %d", math.MaxUint16)
+	}
+
+	stakingTxHash, err := s.staker.StakeFunds(ctx,
+		stakerAddr,
+		btcutil.Amount(req.StakingAmount),
+		fpPubKeys,
+		uint16(req.StakingTimeBlocks),
+		str.StakeOptions{},
+	)
+
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	return &proto.StakeFundsResponse{
+		TxHash: stakingTxHash.String(),
+	}, nil
+}
+
+func (s *GrpcServer) GetAllDelegations(_ context.Context, req *proto.GetAllDelegationsRequest) (*proto.GetAllDelegationsResponse, error) {
+	limit := req.Limit
+
+	if limit == 0 {
+		limit = defaultLimit
+	}
+
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	txResult, err := s.staker.StoredTransactions(limit, req.Offset)
+
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	delegations := make([]*proto.Delegation, 0, len(txResult.Transactions))
+
+	for _, tx := range txResult.Transactions {
+		delegations = append(delegations, storedTxToDelegation(&tx))
+	}
+
+	return &proto.GetAllDelegationsResponse{
+		Delegations:          delegations,
+		TotalDelegationCount: txResult.Total,
+	}, nil
+}
+
+func (s *GrpcServer) GetDelegation(_ context.Context, req *proto.GetDelegationRequest) (*proto.GetDelegationResponse, error) {
+	txHash, err := chainhash.NewHashFromStr(req.StakingTxHash)
+
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid staking transaction hash: %v", err)
+	}
+
+	tx, err := s.staker.GetStoredTransaction(txHash)
+
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	return &proto.GetDelegationResponse{
+		Delegation: storedTxToDelegation(tx),
+	}, nil
+}
+
+func (s *GrpcServer) SpendStakingOutput(ctx context.Context, req *proto.SpendStakingOutputRequest) (*proto.SpendStakingOutputResponse, error) {
+	txHash, err := chainhash.NewHashFromStr(req.StakingTxHash)
+
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid staking transaction hash: %v", err)
+	}
+
+	spendTxHash, value, err := s.staker.SpendStake(ctx, txHash, nil)
+
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	return &proto.SpendStakingOutputResponse{
+		TxHash:  spendTxHash.String(),
+		TxValue: int64(*value),
+	}, nil
+}
+
+func (s *GrpcServer) ListUnspentOutputs(_ context.Context, _ *proto.ListUnspentOutputsRequest) (*proto.ListUnspentOutputsResponse, error) {
+	outputs, err := s.staker.ListUnspentOutputs()
+
+	if err != nil {
+		return nil, toGrpcError(err)
+	}
+
+	unspentOutputs := make([]*proto.UnspentOutput, 0, len(outputs))
+
+	for _, output := range outputs {
+		unspentOutputs = append(unspentOutputs, &proto.UnspentOutput{
+			Address: output.Address,
+			Amount:  int64(output.Amount),
+		})
+	}
+
+	return &proto.ListUnspentOutputsResponse{
+		Outputs: unspentOutputs,
+	}, nil
+}
+
+func storedTxToDelegation(tx *stakerdb.StoredTransaction) *proto.Delegation {
+	return &proto.Delegation{
+		StakingTxHash:     tx.StakingTx.TxHash().String(),
+		StakerAddress:     tx.StakerAddress,
+		StakingState:      tx.State.String(),
+		Watched:           tx.Watched,
+		TransactionIdx:    tx.StoredTransactionIdx,
+		CreatedAt:         unixTimestamp(tx.CreatedAt),
+		LastStateChangeAt: unixTimestamp(tx.LastStateChangeAt),
+	}
+}
+
+// unixTimestamp returns unix time in seconds, or 0 for zero time
+func unixTimestamp(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.Unix()
+}
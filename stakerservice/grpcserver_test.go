@@ -0,0 +1,82 @@
+package stakerservice
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/babylonchain/btc-staker/proto"
+	str "github.com/babylonchain/btc-staker/staker"
+	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestToGrpcError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("query failed: %w", stakerdb.ErrTransactionNotFound), codes.NotFound},
+		{str.ErrDuplicateFpDelegation, codes.AlreadyExists},
+		{str.ErrDestinationNotAllowed, codes.PermissionDenied},
+		{str.ErrBabylonLightClientNotReady, codes.FailedPrecondition},
+		{fmt.Errorf("unexpected"), codes.Internal},
+	}
+
+	for _, tc := range tests {
+		require.Equal(t, tc.code, status.Code(toGrpcError(tc.err)), tc.err.Error())
+	}
+}
+
+func TestGrpcServerRejectsInvalidRequests(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewGrpcServer(nil, &chaincfg.SimNetParams, logrus.New())
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := proto.NewStakerServiceClient(conn)
+	ctx := context.Background()
+
+	_, err = client.StakeFunds(ctx, &proto.StakeFundsRequest{
+		StakerAddress:     "invalid",
+		StakingAmount:     10000,
+		StakingTimeBlocks: 100,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.StakeFunds(ctx, &proto.StakeFundsRequest{
+		StakingAmount: 0,
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.SpendStakingOutput(ctx, &proto.SpendStakingOutputRequest{
+		StakingTxHash: "not a hash",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.GetDelegation(ctx, &proto.GetDelegationRequest{
+		StakingTxHash: "not a hash",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -2,19 +2,29 @@ package stakerservice
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net"
 	"net/http"
+	"os"
+	ossignal "os/signal"
 	"strconv"
 	"strings"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/babylonchain/btc-staker/babylonclient"
+	"github.com/babylonchain/btc-staker/proto"
 	str "github.com/babylonchain/btc-staker/staker"
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
 	"github.com/babylonchain/btc-staker/stakerdb"
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/babylonchain/btc-staker/utils"
+	"github.com/babylonchain/btc-staker/walletcontroller"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
@@ -64,25 +74,140 @@ func NewStakerService(
 	}
 }
 
+func txFeeInfoToFeeDetails(feeInfo *stakerdb.TxFeeInfo) *TxFeeDetails {
+	if feeInfo == nil {
+		return nil
+	}
+
+	return &TxFeeDetails{
+		Fee:     strconv.FormatInt(int64(feeInfo.Fee), 10),
+		VSize:   strconv.FormatInt(feeInfo.VSize, 10),
+		FeeRate: strconv.FormatInt(int64(feeInfo.FeeRate), 10),
+	}
+}
+
+func paramsSnapshotToParamsDetails(snapshot *stakerdb.StakingParamsSnapshot) *StakingParamsDetails {
+	if snapshot == nil {
+		return nil
+	}
+
+	covenantPks := make([]string, len(snapshot.CovenantPks))
+
+	for i, pk := range snapshot.CovenantPks {
+		covenantPks[i] = hex.EncodeToString(schnorr.SerializePubKey(pk))
+	}
+
+	return &StakingParamsDetails{
+		ConfirmationTimeBlocks:    strconv.FormatUint(uint64(snapshot.ConfirmationTimeBlocks), 10),
+		FinalizationTimeoutBlocks: strconv.FormatUint(uint64(snapshot.FinalizationTimeoutBlocks), 10),
+		MinSlashingTxFeeSat:       strconv.FormatInt(int64(snapshot.MinSlashingTxFeeSat), 10),
+		CovenantPks:               covenantPks,
+		CovenantQuorum:            strconv.FormatUint(uint64(snapshot.CovenantQuorum), 10),
+		MinUnbondingTime:          strconv.FormatUint(uint64(snapshot.MinUnbondingTime), 10),
+		SlashingAddress:           snapshot.SlashingAddress,
+		SlashingRate:              snapshot.SlashingRate,
+	}
+}
+
 func storedTxToStakingDetails(storedTx *stakerdb.StoredTransaction) StakingDetails {
 	return StakingDetails{
-		StakingTxHash:  storedTx.StakingTx.TxHash().String(),
-		StakerAddress:  storedTx.StakerAddress,
-		StakingState:   storedTx.State.String(),
-		Watched:        storedTx.Watched,
-		TransactionIdx: strconv.FormatUint(storedTx.StoredTransactionIdx, 10),
+		StakingTxHash:     storedTx.StakingTx.TxHash().String(),
+		StakerAddress:     storedTx.StakerAddress,
+		StakingState:      storedTx.State.String(),
+		StakingAmount:     stakingAmount(storedTx),
+		StakingTime:       strconv.FormatUint(uint64(storedTx.StakingTime), 10),
+		Watched:           storedTx.Watched,
+		TransactionIdx:    strconv.FormatUint(storedTx.StoredTransactionIdx, 10),
+		StakingTxFee:      txFeeInfoToFeeDetails(storedTx.StakingTxFeeInfo),
+		SpendTxFee:        txFeeInfoToFeeDetails(storedTx.SpendTxFeeInfo),
+		StakingParams:     paramsSnapshotToParamsDetails(storedTx.StakingParamsSnapshot),
+		CreatedAt:         formatTimestamp(storedTx.CreatedAt),
+		LastStateChangeAt: formatTimestamp(storedTx.LastStateChangeAt),
 	}
 }
 
+// stakingAmount returns value of staking output in satoshis
+func stakingAmount(storedTx *stakerdb.StoredTransaction) string {
+	if int(storedTx.StakingOutputIndex) >= len(storedTx.StakingTx.TxOut) {
+		return ""
+	}
+
+	return strconv.FormatInt(storedTx.StakingTx.TxOut[storedTx.StakingOutputIndex].Value, 10)
+}
+
+// formatTimestamp formats time as RFC3339 string, zero time is formatted as
+// empty string
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func healthResponse(status *str.HealthStatus) *ResultHealth {
+	toComponentHealth := func(h str.ComponentHealth) ComponentHealth {
+		return ComponentHealth{Healthy: h.Healthy, Error: h.Error}
+	}
+
+	return &ResultHealth{
+		Healthy:         status.Healthy(),
+		BtcNode:         toComponentHealth(status.BtcNode),
+		Wallet:          toComponentHealth(status.Wallet),
+		Babylon:         toComponentHealth(status.Babylon),
+		BtcNodeHeight:   strconv.FormatInt(status.BtcNodeHeight, 10),
+		StakerBtcHeight: strconv.FormatUint(uint64(status.StakerBtcHeight), 10),
+		BabylonHeight:   strconv.FormatUint(status.BabylonHeight, 10),
+	}
+}
+
+// health returns connectivity status of staker backends. Unhealthy backends are
+// reported in the result instead of as error, so that caller can see which of
+// them failed.
 func (s *StakerService) health(_ *rpctypes.Context) (*ResultHealth, error) {
-	return &ResultHealth{}, nil
+	return healthResponse(s.staker.Healthcheck()), nil
+}
+
+// healthHandler serves plain http health check for load balancers and
+// orchestrators. It responds with 200 if all backends are healthy and with 503
+// otherwise, body contains the same result as health rpc.
+func (s *StakerService) healthHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeHealthResponse(w, healthResponse(s.staker.Healthcheck()))
+}
+
+func writeHealthResponse(w http.ResponseWriter, res *ResultHealth) {
+	body, err := json.Marshal(res)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if res.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_, _ = w.Write(body)
 }
 
-func (s *StakerService) stake(_ *rpctypes.Context,
+func (s *StakerService) stake(ctx *rpctypes.Context,
 	stakerAddress string,
 	stakingAmount int64,
 	fpBtcPks []string,
 	stakingTimeBlocks int64,
+	allowDuplicateFp *bool,
+	walletName *string,
+	feeRate *int64,
 ) (*ResultStake, error) {
 
 	if stakingAmount <= 0 {
@@ -118,7 +243,26 @@ func (s *StakerService) stake(_ *rpctypes.Context,
 
 	stakingTimeUint16 := uint16(stakingTimeBlocks)
 
-	stakingTxHash, err := s.staker.StakeFunds(stakerAddr, amount, fpPubKeys, stakingTimeUint16)
+	var opts str.StakeOptions
+
+	if allowDuplicateFp != nil {
+		opts.AllowDuplicateFp = *allowDuplicateFp
+	}
+
+	if walletName != nil {
+		opts.WalletName = *walletName
+	}
+
+	if feeRate != nil {
+		if *feeRate <= 0 {
+			return nil, fmt.Errorf("fee rate must be positive")
+		}
+
+		opts.FeeRate = btcutil.Amount(*feeRate)
+	}
+
+	stakingTxHash, err := s.staker.StakeFunds(ctx.Context(), stakerAddr, amount, fpPubKeys, stakingTimeUint16, opts)
+
 	if err != nil {
 		return nil, err
 	}
@@ -128,6 +272,129 @@ func (s *StakerService) stake(_ *rpctypes.Context,
 	}, nil
 }
 
+// buildStakingTx previews staking transaction which stake request with the same
+// arguments would create, without signing or sending it. If walletName is not
+// provided, transaction is funded by the main wallet
+func (s *StakerService) buildStakingTx(ctx *rpctypes.Context,
+	stakerAddress string,
+	stakingAmount int64,
+	fpBtcPks []string,
+	stakingTimeBlocks int64,
+	walletName *string,
+) (*StakingTxPreviewResponse, error) {
+	if stakingAmount <= 0 {
+		return nil, fmt.Errorf("staking amount must be positive")
+	}
+
+	stakerAddr, err := btcutil.DecodeAddress(stakerAddress, &s.config.ActiveNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	fpPubKeys := make([]*btcec.PublicKey, 0, len(fpBtcPks))
+
+	for _, fpPk := range fpBtcPks {
+		fpSchnorrKey, err := decodeBtcPk(fpPk)
+		if err != nil {
+			return nil, err
+		}
+
+		fpPubKeys = append(fpPubKeys, fpSchnorrKey)
+	}
+
+	if stakingTimeBlocks <= 0 || stakingTimeBlocks > math.MaxUint16 {
+		return nil, fmt.Errorf("staking time must be positive and lower than %d", math.MaxUint16)
+	}
+
+	var wallet string
+	if walletName != nil {
+		wallet = *walletName
+	}
+
+	preview, err := s.staker.BuildStakingTx(
+		ctx.Context(),
+		wallet,
+		stakerAddr,
+		btcutil.Amount(stakingAmount),
+		fpPubKeys,
+		uint16(stakingTimeBlocks),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedTx, err := encodeBtcTx(preview.UnsignedTx)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]StakingTxInputDetails, len(preview.Inputs))
+	for i, in := range preview.Inputs {
+		inputs[i] = StakingTxInputDetails{
+			TxHash:    in.OutPoint.Hash.String(),
+			OutputIdx: strconv.FormatUint(uint64(in.OutPoint.Index), 10),
+			Amount:    strconv.FormatInt(int64(in.Amount), 10),
+		}
+	}
+
+	return &StakingTxPreviewResponse{
+		UnsignedStakingTx:     unsignedTx,
+		StakingOutputIdx:      strconv.FormatUint(uint64(preview.StakingOutputIdx), 10),
+		StakingOutputPkScript: hex.EncodeToString(preview.StakingOutputPkScript),
+		Inputs:                inputs,
+		ChangeAmount:          strconv.FormatInt(int64(preview.ChangeAmount), 10),
+		Fee:                   strconv.FormatInt(int64(preview.Fee), 10),
+		FeeRate:               strconv.FormatInt(int64(preview.FeeRate), 10),
+	}, nil
+}
+
+// estimateStakeCost estimates staking amount together with fees of funding and
+// withdrawing the stake. If feeRate is not provided, fee rate estimated by btc
+// node is used
+func (s *StakerService) estimateStakeCost(ctx *rpctypes.Context,
+	stakingAmount int64,
+	stakingTimeBlocks int64,
+	feeRate *int64,
+) (*StakeCostEstimateResponse, error) {
+	if stakingAmount <= 0 {
+		return nil, fmt.Errorf("staking amount must be positive")
+	}
+
+	if stakingTimeBlocks <= 0 || stakingTimeBlocks > math.MaxUint16 {
+		return nil, fmt.Errorf("staking time must be positive and lower than %d", math.MaxUint16)
+	}
+
+	var feeRatePerKb btcutil.Amount
+
+	if feeRate != nil {
+		if *feeRate <= 0 {
+			return nil, fmt.Errorf("fee rate must be positive")
+		}
+
+		feeRatePerKb = btcutil.Amount(*feeRate)
+	}
+
+	estimate, err := s.staker.EstimateLifecycleFees(
+		ctx.Context(),
+		btcutil.Amount(stakingAmount),
+		uint16(stakingTimeBlocks),
+		feeRatePerKb,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StakeCostEstimateResponse{
+		StakingAmount:     strconv.FormatInt(int64(estimate.StakingAmount), 10),
+		FeeRate:           strconv.FormatInt(int64(estimate.FeeRate), 10),
+		FundingFee:        strconv.FormatInt(int64(estimate.FundingFee), 10),
+		FundingTxVSize:    strconv.FormatInt(estimate.FundingTxVSize, 10),
+		WithdrawalFee:     strconv.FormatInt(int64(estimate.WithdrawalFee), 10),
+		WithdrawalTxVSize: strconv.FormatInt(estimate.WithdrawalTxVSize, 10),
+		TotalCost:         strconv.FormatInt(int64(estimate.TotalCost), 10),
+	}, nil
+}
+
 func (s *StakerService) stakingDetails(_ *rpctypes.Context,
 	stakingTxHash string) (*StakingDetails, error) {
 
@@ -145,15 +412,171 @@ func (s *StakerService) stakingDetails(_ *rpctypes.Context,
 	return &details, nil
 }
 
-func (s *StakerService) spendStake(_ *rpctypes.Context,
-	stakingTxHash string) (*SpendTxDetails, error) {
+func (s *StakerService) stakingTxConfirmations(_ *rpctypes.Context,
+	stakingTxHash string) (*StakingConfirmationsResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmations, err := s.staker.GetConfirmations(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StakingConfirmationsResponse{
+		StakingTxHash: stakingTxHash,
+		Confirmations: strconv.Itoa(confirmations),
+	}, nil
+}
+
+func (s *StakerService) spendTxStatus(_ *rpctypes.Context,
+	stakingTxHash string) (*SpendTxStatusResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	status, confirmations, err := s.staker.GetSpendTransactionStatus(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// spend transaction is never removed once stored
+	storedTx, err := s.staker.GetStoredTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var statusStr string
+	switch status {
+	case walletcontroller.TxInMemPool:
+		statusStr = "in_mempool"
+	case walletcontroller.TxInChain:
+		statusStr = "in_chain"
+	default:
+		statusStr = "not_found"
+	}
+
+	return &SpendTxStatusResponse{
+		StakingTxHash: stakingTxHash,
+		SpendTxHash:   storedTx.SpendTx.TxHash().String(),
+		Status:        statusStr,
+		Confirmations: strconv.Itoa(confirmations),
+	}, nil
+}
+
+func (s *StakerService) covenantSignatureProgress(ctx *rpctypes.Context,
+	stakingTxHash string) (*CovenantSignatureProgressResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	gathered, required, signers, err := s.staker.GetCovenantSignatureProgress(ctx.Context(), txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CovenantSignatureProgressResponse{
+		StakingTxHash: stakingTxHash,
+		Gathered:      strconv.FormatUint(uint64(gathered), 10),
+		Required:      strconv.FormatUint(uint64(required), 10),
+		Signers:       signers,
+		Progress:      fmt.Sprintf("%d of %d covenant signatures gathered", gathered, required),
+	}, nil
+}
+
+func (s *StakerService) delegationRewards(ctx *rpctypes.Context,
+	stakingTxHash string) (*DelegationRewardsResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	storedTx, rewards, err := s.staker.GetDelegationRewards(ctx.Context(), txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DelegationRewardsResponse{
+		Delegation:           storedTxToStakingDetails(storedTx),
+		StakerBabylonAddress: rewards.StakerAddress.String(),
+		AccruedRewards:       rewards.AccruedRewards.String(),
+		WithdrawnRewards:     rewards.WithdrawnRewards.String(),
+		SlashingPenalty:      strconv.FormatInt(int64(rewards.SlashingPenalty), 10),
+	}, nil
+}
+
+func (s *StakerService) withdrawalDestinations(_ *rpctypes.Context,
+	stakingTxHash string) (*WithdrawalDestinationsResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	destinations, err := s.staker.GetWithdrawalDestinations(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &WithdrawalDestinationsResponse{
+		StakingTxHash: txHash.String(),
+		Destinations:  make([]WithdrawalDestination, len(destinations)),
+	}
+
+	for i, d := range destinations {
+		response.Destinations[i] = WithdrawalDestination{
+			OutputIdx: d.OutputIdx,
+			Address:   d.Address,
+			Amount:    strconv.FormatInt(d.Amount, 10),
+			Spent:     d.Spent,
+		}
+	}
+
+	return response, nil
+}
+
+func (s *StakerService) babylonSubmissionPayload(_ *rpctypes.Context,
+	stakingTxHash string) (*BabylonSubmissionPayloadResponse, error) {
+
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := s.staker.GetBabylonSubmissionPayload(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BabylonSubmissionPayloadResponse{
+		StakingTxHash: stakingTxHash,
+		PayloadHex:    hex.EncodeToString(payload),
+	}, nil
+}
+
+func (s *StakerService) spendStake(ctx *rpctypes.Context,
+	stakingTxHash string, feeRate *int) (*SpendTxDetails, error) {
 	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
 
 	if err != nil {
 		return nil, err
 	}
 
-	spendTxHash, value, err := s.staker.SpendStake(txHash)
+	var feeRateBtc *btcutil.Amount = nil
+
+	if feeRate != nil {
+		amt := btcutil.Amount(*feeRate)
+		feeRateBtc = &amt
+	}
+
+	spendTxHash, value, err := s.staker.SpendStake(ctx.Context(), txHash, feeRateBtc)
 
 	if err != nil {
 		return nil, err
@@ -189,6 +612,24 @@ func (s *StakerService) listOutputs(_ *rpctypes.Context) (*OutputsResponse, erro
 	}, nil
 }
 
+func (s *StakerService) newAddress(_ *rpctypes.Context, addressType string) (*NewAddressResponse, error) {
+	addrType, err := types.NewAddressType(addressType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := s.staker.NewAddress(addrType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &NewAddressResponse{
+		Address: addr.EncodeAddress(),
+	}, nil
+}
+
 type PageParams struct {
 	Offset uint64
 	Limit  uint64
@@ -221,11 +662,11 @@ func getPageParams(offsetPtr *int, limitPtr *int) PageParams {
 	}
 }
 
-func (s *StakerService) providers(_ *rpctypes.Context, offset, limit *int) (*FinalityProvidersResponse, error) {
+func (s *StakerService) providers(ctx *rpctypes.Context, offset, limit *int) (*FinalityProvidersResponse, error) {
 
 	pageParams := getPageParams(offset, limit)
 
-	providersResp, err := s.staker.ListActiveFinalityProviders(pageParams.Limit, pageParams.Offset)
+	providersResp, err := s.staker.ListActiveFinalityProviders(ctx.Context(), pageParams.Limit, pageParams.Offset)
 
 	if err != nil {
 		return nil, err
@@ -250,10 +691,34 @@ func (s *StakerService) providers(_ *rpctypes.Context, offset, limit *int) (*Fin
 	}, nil
 }
 
-func (s *StakerService) listStakingTransactions(_ *rpctypes.Context, offset, limit *int) (*ListStakingTransactionsResponse, error) {
+// parseTransactionState parses name of transaction state, case insensitive
+func parseTransactionState(state string) (proto.TransactionState, error) {
+	value, found := proto.TransactionState_value[strings.ToUpper(state)]
+
+	if !found {
+		return 0, fmt.Errorf("invalid transaction state: %s", state)
+	}
+
+	return proto.TransactionState(value), nil
+}
+
+func (s *StakerService) listStakingTransactions(_ *rpctypes.Context, offset, limit *int, state *string) (*ListStakingTransactionsResponse, error) {
 	pageParams := getPageParams(offset, limit)
 
-	txResult, err := s.staker.StoredTransactions(pageParams.Limit, pageParams.Offset)
+	var txResult *stakerdb.StoredTransactionQueryResult
+	var err error
+
+	if state != nil && *state != "" {
+		txState, parseErr := parseTransactionState(*state)
+
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		txResult, err = s.staker.StoredTransactionsInState(pageParams.Limit, pageParams.Offset, txState)
+	} else {
+		txResult, err = s.staker.StoredTransactions(pageParams.Limit, pageParams.Offset)
+	}
 
 	if err != nil {
 		return nil, err
@@ -361,7 +826,7 @@ func parseStakingValue(stakingValue int) (btcutil.Amount, error) {
 }
 
 func (s *StakerService) watchStaking(
-	_ *rpctypes.Context,
+	ctx *rpctypes.Context,
 	stakingTx string,
 	stakingTime int,
 	stakingValue int,
@@ -492,6 +957,7 @@ func (s *StakerService) watchStaking(
 	}
 
 	hash, err := s.staker.WatchStaking(
+		ctx.Context(),
 		stkTx,
 		stakingTimeUint16,
 		stakingValueBtc,
@@ -516,7 +982,211 @@ func (s *StakerService) watchStaking(
 	}, nil
 }
 
-func (s *StakerService) unbondStaking(_ *rpctypes.Context, stakingTxHash string, feeRate *int) (*UnbondingResponse, error) {
+func encodeBtcTx(tx *wire.MsgTx) (string, error) {
+	txBytes, err := utils.SerializeBtcTransaction(tx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(txBytes), nil
+}
+
+func (s *StakerService) prepareDelegation(ctx *rpctypes.Context,
+	stakerAddress string,
+	stakerBtcPk string,
+	stakingAmount int64,
+	fpBtcPks []string,
+	stakingTimeBlocks int64,
+) (*PreparedDelegationResponse, error) {
+	if stakingAmount <= 0 {
+		return nil, fmt.Errorf("staking amount must be positive")
+	}
+
+	stakerAddr, err := btcutil.DecodeAddress(stakerAddress, &s.config.ActiveNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	stakerBtcPkParsed, err := decodeBtcPk(stakerBtcPk)
+	if err != nil {
+		return nil, err
+	}
+
+	var fpPubKeys []*btcec.PublicKey = make([]*btcec.PublicKey, 0)
+
+	for _, fpPk := range fpBtcPks {
+		fpSchnorrKey, err := decodeBtcPk(fpPk)
+		if err != nil {
+			return nil, err
+		}
+
+		fpPubKeys = append(fpPubKeys, fpSchnorrKey)
+	}
+
+	if stakingTimeBlocks <= 0 || stakingTimeBlocks > math.MaxUint16 {
+		return nil, fmt.Errorf("staking time must be positive and lower than %d", math.MaxUint16)
+	}
+
+	prepared, err := s.staker.PrepareDelegation(
+		ctx.Context(),
+		stakerAddr,
+		stakerBtcPkParsed,
+		btcutil.Amount(stakingAmount),
+		fpPubKeys,
+		uint16(stakingTimeBlocks),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	stakingTx, err := encodeBtcTx(prepared.StakingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	slashingTx, err := encodeBtcTx(prepared.SlashingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	unbondingTx, err := encodeBtcTx(prepared.UnbondingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	slashUnbondingTx, err := encodeBtcTx(prepared.SlashUnbondingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stakingPsbt string
+	if prepared.StakingPsbt != nil {
+		stakingPsbt, err = prepared.StakingPsbt.B64Encode()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &PreparedDelegationResponse{
+		StakingTxHash:               prepared.StakingTxHash.String(),
+		StakingTx:                   stakingTx,
+		StakingOutputIdx:            strconv.FormatUint(uint64(prepared.StakingOutputIdx), 10),
+		StakerBabylonAddr:           prepared.StakerBabylonAddr.String(),
+		SlashingTx:                  slashingTx,
+		SlashingPathScript:          hex.EncodeToString(prepared.SlashingPathScript),
+		UnbondingTx:                 unbondingTx,
+		UnbondingTime:               strconv.FormatUint(uint64(prepared.UnbondingTime), 10),
+		SlashUnbondingTx:            slashUnbondingTx,
+		UnbondingSlashingPathScript: hex.EncodeToString(prepared.UnbondingSlashingPathScript),
+		StakingPsbt:                 stakingPsbt,
+	}, nil
+}
+
+func (s *StakerService) submitSignedStakingTx(ctx *rpctypes.Context,
+	stakingTxHash string,
+	signedStakingTx string,
+) (*ResultStake, error) {
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := decodeBtcTx(signedStakingTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.staker.SubmitSignedStakingTx(ctx.Context(), txHash, signedTx); err != nil {
+		return nil, err
+	}
+
+	return &ResultStake{
+		TxHash: txHash.String(),
+	}, nil
+}
+
+func (s *StakerService) submitPreparedDelegation(ctx *rpctypes.Context,
+	stakingTxHash string,
+	stakerBtcSig string,
+	popType int,
+	slashingTxSig string,
+	slashUnbondingTxSig string,
+) (*ResultStake, error) {
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	stakerBtcSigBytes, err := hex.DecodeString(stakerBtcSig)
+	if err != nil {
+		return nil, err
+	}
+
+	btcPopType, err := babylonclient.IntToPopType(popType)
+	if err != nil {
+		return nil, err
+	}
+
+	proofOfPossesion, err := babylonclient.NewBabylonPop(btcPopType, stakerBtcSigBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	slashTxSigBytes, err := hex.DecodeString(slashingTxSig)
+	if err != nil {
+		return nil, err
+	}
+
+	slashingTxSchnorSig, err := schnorr.ParseSignature(slashTxSigBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	slashUnbTxSigBytes, err := hex.DecodeString(slashUnbondingTxSig)
+	if err != nil {
+		return nil, err
+	}
+
+	slashUnbTxSig, err := schnorr.ParseSignature(slashUnbTxSigBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := s.staker.SubmitPreparedDelegation(
+		ctx.Context(),
+		txHash,
+		proofOfPossesion,
+		slashingTxSchnorSig,
+		slashUnbTxSig,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResultStake{
+		TxHash: hash.String(),
+	}, nil
+}
+
+func (s *StakerService) cancelPreparedDelegation(ctx *rpctypes.Context,
+	stakingTxHash string,
+) (*ResultStake, error) {
+	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.staker.CancelPreparedDelegation(ctx.Context(), txHash); err != nil {
+		return nil, err
+	}
+
+	return &ResultStake{
+		TxHash: txHash.String(),
+	}, nil
+}
+
+func (s *StakerService) unbondStaking(ctx *rpctypes.Context, stakingTxHash string, feeRate *int) (*UnbondingResponse, error) {
 	txHash, err := chainhash.NewHashFromStr(stakingTxHash)
 
 	if err != nil {
@@ -530,7 +1200,7 @@ func (s *StakerService) unbondStaking(_ *rpctypes.Context, stakingTxHash string,
 		feeRateBtc = &amt
 	}
 
-	unbondingTxHash, err := s.staker.UnbondStaking(*txHash, feeRateBtc)
+	unbondingTxHash, err := s.staker.UnbondStaking(ctx.Context(), *txHash, feeRateBtc)
 
 	if err != nil {
 		return nil, err
@@ -546,17 +1216,31 @@ func (s *StakerService) GetRoutes() RoutesMap {
 		// info AP
 		"health": rpc.NewRPCFunc(s.health, ""),
 		// staking API
-		"stake":                     rpc.NewRPCFunc(s.stake, "stakerAddress,stakingAmount,fpBtcPks,stakingTimeBlocks"),
-		"staking_details":           rpc.NewRPCFunc(s.stakingDetails, "stakingTxHash"),
-		"spend_stake":               rpc.NewRPCFunc(s.spendStake, "stakingTxHash"),
-		"list_staking_transactions": rpc.NewRPCFunc(s.listStakingTransactions, "offset,limit"),
-		"unbond_staking":            rpc.NewRPCFunc(s.unbondStaking, "stakingTxHash,feeRate"),
-		"withdrawable_transactions": rpc.NewRPCFunc(s.withdrawableTransactions, "offset,limit"),
+		"stake":                       rpc.NewRPCFunc(s.stake, "stakerAddress,stakingAmount,fpBtcPks,stakingTimeBlocks,allowDuplicateFp,walletName,feeRate"),
+		"build_staking_tx":            rpc.NewRPCFunc(s.buildStakingTx, "stakerAddress,stakingAmount,fpBtcPks,stakingTimeBlocks,walletName"),
+		"estimate_stake_cost":         rpc.NewRPCFunc(s.estimateStakeCost, "stakingAmount,stakingTimeBlocks,feeRate"),
+		"staking_details":             rpc.NewRPCFunc(s.stakingDetails, "stakingTxHash"),
+		"staking_tx_confirmations":    rpc.NewRPCFunc(s.stakingTxConfirmations, "stakingTxHash"),
+		"spend_tx_status":             rpc.NewRPCFunc(s.spendTxStatus, "stakingTxHash"),
+		"covenant_signature_progress": rpc.NewRPCFunc(s.covenantSignatureProgress, "stakingTxHash"),
+		"delegation_rewards":          rpc.NewRPCFunc(s.delegationRewards, "stakingTxHash"),
+		"babylon_submission_payload":  rpc.NewRPCFunc(s.babylonSubmissionPayload, "stakingTxHash"),
+		"spend_stake":                 rpc.NewRPCFunc(s.spendStake, "stakingTxHash,feeRate"),
+		"withdrawal_destinations":     rpc.NewRPCFunc(s.withdrawalDestinations, "stakingTxHash"),
+		"list_staking_transactions":   rpc.NewRPCFunc(s.listStakingTransactions, "offset,limit,state"),
+		"unbond_staking":              rpc.NewRPCFunc(s.unbondStaking, "stakingTxHash,feeRate"),
+		"withdrawable_transactions":   rpc.NewRPCFunc(s.withdrawableTransactions, "offset,limit"),
+		// cold staker key api
+		"prepare_delegation":         rpc.NewRPCFunc(s.prepareDelegation, "stakerAddress,stakerBtcPk,stakingAmount,fpBtcPks,stakingTimeBlocks"),
+		"submit_prepared_delegation": rpc.NewRPCFunc(s.submitPreparedDelegation, "stakingTxHash,stakerBtcSig,popType,slashingTxSig,slashUnbondingTxSig"),
+		"submit_signed_staking_tx":   rpc.NewRPCFunc(s.submitSignedStakingTx, "stakingTxHash,signedStakingTx"),
+		"cancel_prepared_delegation": rpc.NewRPCFunc(s.cancelPreparedDelegation, "stakingTxHash"),
 		// watch api
 		"watch_staking_tx": rpc.NewRPCFunc(s.watchStaking, "stakingTx,stakingTime,stakingValue,stakerBtcPk,fpBtcPks,slashingTx,slashingTxSig,stakerBabylonAddr,stakerAddress,stakerBtcSig,unbondingTx,slashUnbondingTx,slashUnbondingTxSig,unbondingTime,popType"),
 
 		// Wallet api
 		"list_outputs": rpc.NewRPCFunc(s.listOutputs, ""),
+		"new_address":  rpc.NewRPCFunc(s.newAddress, "addressType"),
 
 		// Babylon api
 		"babylon_finality_providers": rpc.NewRPCFunc(s.providers, "offset,limit"),
@@ -605,8 +1289,14 @@ func (s *StakerService) RunUntilShutdown() error {
 	listeners := make([]net.Listener, len(s.config.RpcListeners))
 	for i, listenAddr := range s.config.RpcListeners {
 		listenAddressStr := listenAddr.Network() + "://" + listenAddr.String()
+		rpcMux := http.NewServeMux()
+		rpc.RegisterRPCFuncs(rpcMux, routes, rpcLogger)
+
+		// plain http health endpoint takes precedence over uri handler of
+		// health rpc, so that its status code reflects backend health
 		mux := http.NewServeMux()
-		rpc.RegisterRPCFuncs(mux, routes, rpcLogger)
+		mux.HandleFunc("/health", s.healthHandler)
+		mux.Handle("/", rpcMux)
 
 		listener, err := rpc.Listen(
 			listenAddressStr,
@@ -644,13 +1334,68 @@ func (s *StakerService) RunUntilShutdown() error {
 		listeners[i] = listener
 	}
 
+	if s.config.GrpcListener != nil {
+		grpcListener, err := net.Listen(s.config.GrpcListener.Network(), s.config.GrpcListener.String())
+
+		if err != nil {
+			return mkErr("unable to listen on %s for gRPC: %v",
+				s.config.GrpcListener, err)
+		}
+
+		grpcServer := NewGrpcServer(s.staker, &s.config.ActiveNetParams, s.logger)
+
+		// stopping the server also closes the listener
+		defer grpcServer.Stop()
+
+		go func() {
+			s.logger.Debug("Starting gRPC server ", "address", s.config.GrpcListener)
+
+			err := grpcServer.Serve(grpcListener)
+
+			s.logger.Error("gRPC server stopped ", "err", err)
+		}()
+	}
+
 	s.logger.Info("Staker Service fully started")
 
-	// Wait for shutdown signal from either a graceful service stop or from
-	// the interrupt handler.
-	<-s.interceptor.ShutdownChannel()
+	// SIGHUP is not handled by shutdown interceptor, it is used to reload
+	// settings which can be changed at runtime
+	reloadChan := make(chan os.Signal, 1)
+	ossignal.Notify(reloadChan, syscall.SIGHUP)
+	defer ossignal.Stop(reloadChan)
+
+	for {
+		select {
+		case <-reloadChan:
+			s.reloadConfig()
+
+		// Wait for shutdown signal from either a graceful service stop or from
+		// the interrupt handler.
+		case <-s.interceptor.ShutdownChannel():
+			s.logger.Info("Received shutdown signal. Stopping...")
+			return nil
+		}
+	}
+}
+
+// reloadConfig re-reads config file and applies settings which can be changed
+// while staker is running. Invalid config is logged and current settings are
+// kept.
+func (s *StakerService) reloadConfig() {
+	s.logger.Info("Received SIGHUP. Reloading config...")
+
+	newConfig, err := scfg.ReloadConfig()
 
-	s.logger.Info("Received shutdown signal. Stopping...")
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to load config, keeping current settings")
+		return
+	}
 
-	return nil
+	if err := s.staker.ReloadConfig(context.Background(), newConfig); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Error("Failed to apply reloaded config, keeping current settings")
+	}
 }
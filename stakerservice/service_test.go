@@ -0,0 +1,31 @@
+package stakerservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHealthResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeHealthResponse(rec, &ResultHealth{Healthy: true})
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	unhealthy := &ResultHealth{
+		Healthy: false,
+		BtcNode: ComponentHealth{Healthy: true},
+		Wallet:  ComponentHealth{Healthy: true},
+		Babylon: ComponentHealth{Healthy: false, Error: "connection refused"},
+	}
+	rec = httptest.NewRecorder()
+	writeHealthResponse(rec, unhealthy)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var res ResultHealth
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Equal(t, *unhealthy, res)
+}
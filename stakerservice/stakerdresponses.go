@@ -1,17 +1,167 @@
 package stakerservice
 
-type ResultHealth struct{}
+type ComponentHealth struct {
+	Healthy bool `json:"healthy"`
+	// empty if component is healthy
+	Error string `json:"error,omitempty"`
+}
+
+// ResultHealth reports connectivity to backends of the staker. Heights are zero
+// for unhealthy components.
+type ResultHealth struct {
+	Healthy         bool            `json:"healthy"`
+	BtcNode         ComponentHealth `json:"btc_node"`
+	Wallet          ComponentHealth `json:"wallet"`
+	Babylon         ComponentHealth `json:"babylon"`
+	BtcNodeHeight   string          `json:"btc_node_height"`
+	StakerBtcHeight string          `json:"staker_btc_height"`
+	BabylonHeight   string          `json:"babylon_height"`
+}
 
 type ResultStake struct {
 	TxHash string `json:"tx_hash"`
 }
 
+// PreparedDelegationResponse holds data which must be signed by staker key
+// to complete prepared delegation. All transactions and scripts are hex encoded.
+type PreparedDelegationResponse struct {
+	StakingTxHash     string `json:"staking_tx_hash"`
+	StakingTx         string `json:"staking_tx"`
+	StakingOutputIdx  string `json:"staking_output_idx"`
+	StakerBabylonAddr string `json:"staker_babylon_addr"`
+	SlashingTx        string `json:"slashing_tx"`
+	// script of staking output slashing path, used to sign slashing tx
+	SlashingPathScript string `json:"slashing_path_script"`
+	UnbondingTx        string `json:"unbonding_tx"`
+	UnbondingTime      string `json:"unbonding_time"`
+	SlashUnbondingTx   string `json:"slash_unbonding_tx"`
+	// script of unbonding output slashing path, used to sign slash unbonding tx
+	UnbondingSlashingPathScript string `json:"unbonding_slashing_path_script"`
+	// base64 encoded psbt of unsigned staking tx, set only in watch-only mode
+	StakingPsbt string `json:"staking_psbt,omitempty"`
+}
+
+type StakingTxInputDetails struct {
+	TxHash    string `json:"tx_hash"`
+	OutputIdx string `json:"output_idx"`
+	Amount    string `json:"amount"`
+}
+
+// StakingTxPreviewResponse describes staking transaction which would be created
+// by stake request. Transaction is hex encoded and not signed.
+type StakingTxPreviewResponse struct {
+	UnsignedStakingTx     string                  `json:"unsigned_staking_tx"`
+	StakingOutputIdx      string                  `json:"staking_output_idx"`
+	StakingOutputPkScript string                  `json:"staking_output_pk_script"`
+	Inputs                []StakingTxInputDetails `json:"inputs"`
+	ChangeAmount          string                  `json:"change_amount"`
+	Fee                   string                  `json:"fee"`
+	// fee rate in sat/kvb
+	FeeRate string `json:"fee_rate"`
+}
+
+// StakeCostEstimateResponse breaks down full cost of staking, amounts are in
+// satoshis
+type StakeCostEstimateResponse struct {
+	StakingAmount string `json:"staking_amount"`
+	// fee rate in sat/kvb
+	FeeRate           string `json:"fee_rate"`
+	FundingFee        string `json:"funding_fee"`
+	FundingTxVSize    string `json:"funding_tx_vsize"`
+	WithdrawalFee     string `json:"withdrawal_fee"`
+	WithdrawalTxVSize string `json:"withdrawal_tx_vsize"`
+	TotalCost         string `json:"total_cost"`
+}
+
+type TxFeeDetails struct {
+	Fee   string `json:"fee"`
+	VSize string `json:"vsize"`
+	// effective fee rate in sat/kvb
+	FeeRate string `json:"fee_rate"`
+}
+
+// StakingParamsDetails are babylon staking params which were in effect when
+// delegation was created
+type StakingParamsDetails struct {
+	ConfirmationTimeBlocks    string   `json:"confirmation_time_blocks"`
+	FinalizationTimeoutBlocks string   `json:"finalization_timeout_blocks"`
+	MinSlashingTxFeeSat       string   `json:"min_slashing_tx_fee_sat"`
+	CovenantPks               []string `json:"covenant_pks"`
+	CovenantQuorum            string   `json:"covenant_quorum"`
+	MinUnbondingTime          string   `json:"min_unbonding_time"`
+	SlashingAddress           string   `json:"slashing_address"`
+	SlashingRate              string   `json:"slashing_rate"`
+}
+
 type StakingDetails struct {
-	StakingTxHash  string `json:"staking_tx_hash"`
-	StakerAddress  string `json:"staker_address"`
-	StakingState   string `json:"staking_state"`
-	Watched        bool   `json:"watched"`
-	TransactionIdx string `json:"transaction_idx"`
+	StakingTxHash  string        `json:"staking_tx_hash"`
+	StakerAddress  string        `json:"staker_address"`
+	StakingState   string        `json:"staking_state"`
+	StakingAmount  string        `json:"staking_amount"` // in satoshis
+	StakingTime    string        `json:"staking_time"`
+	Watched        bool          `json:"watched"`
+	TransactionIdx string        `json:"transaction_idx"`
+	StakingTxFee   *TxFeeDetails `json:"staking_tx_fee,omitempty"`
+	SpendTxFee     *TxFeeDetails `json:"spend_tx_fee,omitempty"`
+	// nil for delegations created before params snapshots were persisted
+	StakingParams *StakingParamsDetails `json:"staking_params,omitempty"`
+	// RFC3339 timestamps, empty for delegations tracked before timestamps were
+	// persisted
+	CreatedAt         string `json:"created_at,omitempty"`
+	LastStateChangeAt string `json:"last_state_change_at,omitempty"`
+}
+
+type StakingConfirmationsResponse struct {
+	StakingTxHash string `json:"staking_tx_hash"`
+	// -1 if transaction is not found, 0 if it is in mempool
+	Confirmations string `json:"confirmations"`
+}
+
+type SpendTxStatusResponse struct {
+	StakingTxHash string `json:"staking_tx_hash"`
+	SpendTxHash   string `json:"spend_tx_hash"`
+	// one of not_found, in_mempool, in_chain
+	Status string `json:"status"`
+	// 0 if transaction is not in chain
+	Confirmations string `json:"confirmations"`
+}
+
+type CovenantSignatureProgressResponse struct {
+	StakingTxHash string   `json:"staking_tx_hash"`
+	Gathered      string   `json:"gathered"`
+	Required      string   `json:"required"`
+	Signers       []string `json:"signers"`
+	// human readable progress e.g "3 of 5 covenant signatures gathered"
+	Progress string `json:"progress"`
+}
+
+type DelegationRewardsResponse struct {
+	Delegation StakingDetails `json:"delegation"`
+	// babylon address accruing rewards of all delegations of the staker
+	StakerBabylonAddress string `json:"staker_babylon_address"`
+	AccruedRewards       string `json:"accrued_rewards"`
+	WithdrawnRewards     string `json:"withdrawn_rewards"`
+	// satoshis sent to slashing address if delegation is slashed
+	SlashingPenalty string `json:"slashing_penalty"`
+}
+
+type BabylonSubmissionPayloadResponse struct {
+	StakingTxHash string `json:"staking_tx_hash"`
+	// hex encoded serialized delegation message
+	PayloadHex string `json:"payload_hex"`
+}
+
+type WithdrawalDestination struct {
+	OutputIdx uint32 `json:"output_idx"`
+	Address   string `json:"address"`
+	Amount    string `json:"amount"`
+	// true if output was spent by confirmed transaction
+	Spent bool `json:"spent"`
+}
+
+type WithdrawalDestinationsResponse struct {
+	StakingTxHash string                  `json:"staking_tx_hash"`
+	Destinations  []WithdrawalDestination `json:"destinations"`
 }
 
 type OutputDetail struct {
@@ -22,6 +172,11 @@ type OutputDetail struct {
 type OutputsResponse struct {
 	Outputs []OutputDetail `json:"outputs"`
 }
+
+type NewAddressResponse struct {
+	Address string `json:"address"`
+}
+
 type SpendTxDetails struct {
 	TxHash  string `json:"tx_hash"`
 	TxValue string `json:"tx_value"`
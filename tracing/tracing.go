@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/stakercfg"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const serviceName = "stakerd"
+
+// ShutdownFunc flushes all pending spans and stops the tracer provider
+type ShutdownFunc func(ctx context.Context) error
+
+// NewTracerProvider creates tracer provider based on provided config. If tracing
+// is disabled, returned provider is a no-op provider which does not record anything.
+func NewTracerProvider(ctx context.Context, cfg *stakercfg.TracingConfig) (trace.TracerProvider, ShutdownFunc, error) {
+	if cfg == nil || !cfg.Enabled {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.ExporterEndpoint),
+	}
+
+	if cfg.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create otlp trace exporter: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplingRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+
+	return tp, tp.Shutdown, nil
+}
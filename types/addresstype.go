@@ -0,0 +1,48 @@
+package types
+
+import "fmt"
+
+// AddressType is type of address generated by the wallet. Names are the same as
+// address types accepted by bitcoind.
+type AddressType int
+
+const (
+	// p2pkh address
+	LegacyAddress AddressType = iota
+	// p2wpkh nested in p2sh
+	P2SHSegwitAddress
+	// p2wpkh address
+	Bech32Address
+	// p2tr address
+	Bech32mAddress
+)
+
+func NewAddressType(addrType string) (AddressType, error) {
+	switch addrType {
+	case "legacy":
+		return LegacyAddress, nil
+	case "p2sh-segwit":
+		return P2SHSegwitAddress, nil
+	case "bech32":
+		return Bech32Address, nil
+	case "bech32m":
+		return Bech32mAddress, nil
+	default:
+		return LegacyAddress, fmt.Errorf("invalid address type: %s", addrType)
+	}
+}
+
+func (t AddressType) String() string {
+	switch t {
+	case LegacyAddress:
+		return "legacy"
+	case P2SHSegwitAddress:
+		return "p2sh-segwit"
+	case Bech32Address:
+		return "bech32"
+	case Bech32mAddress:
+		return "bech32m"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(t))
+	}
+}
@@ -0,0 +1,25 @@
+package types
+
+import "fmt"
+
+type ChangeAddressType int
+
+const (
+	// change is sent back to the address which funds the transaction
+	DefaultChangeAddress ChangeAddressType = iota
+	P2WPKHChangeAddress
+	P2TRChangeAddress
+)
+
+func NewChangeAddressType(addrType string) (ChangeAddressType, error) {
+	switch addrType {
+	case "default":
+		return DefaultChangeAddress, nil
+	case "p2wpkh":
+		return P2WPKHChangeAddress, nil
+	case "p2tr":
+		return P2TRChangeAddress, nil
+	default:
+		return DefaultChangeAddress, fmt.Errorf("invalid change address type: %s", addrType)
+	}
+}
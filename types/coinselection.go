@@ -0,0 +1,29 @@
+package types
+
+import "fmt"
+
+// CoinSelectionStrategy decides which wallet outputs fund created transactions
+type CoinSelectionStrategy int
+
+const (
+	// largest outputs are used first, which minimizes number of inputs
+	LargestFirstCoinSelection CoinSelectionStrategy = iota
+	// smallest outputs are used first, which consolidates dust outputs
+	SmallestFirstCoinSelection
+	// branch and bound search for set of outputs which funds transaction
+	// without change. Falls back to largest first if there is no such set
+	BranchAndBoundCoinSelection
+)
+
+func NewCoinSelectionStrategy(strategy string) (CoinSelectionStrategy, error) {
+	switch strategy {
+	case "largest-first":
+		return LargestFirstCoinSelection, nil
+	case "smallest-first":
+		return SmallestFirstCoinSelection, nil
+	case "branch-and-bound":
+		return BranchAndBoundCoinSelection, nil
+	default:
+		return LargestFirstCoinSelection, fmt.Errorf("invalid coin selection strategy: %s", strategy)
+	}
+}
@@ -0,0 +1,29 @@
+package types
+
+import "fmt"
+
+// DuplicateFpDelegationPolicy decides what happens when staker creates new
+// delegation to finality provider it already has active delegation to
+type DuplicateFpDelegationPolicy int
+
+const (
+	// new delegation is created, but warning is logged
+	WarnDuplicateFpDelegation DuplicateFpDelegationPolicy = iota
+	// new delegation is refused, unless caller explicitly allows it
+	RefuseDuplicateFpDelegation
+	// new delegation is created without any notice
+	AllowDuplicateFpDelegation
+)
+
+func NewDuplicateFpDelegationPolicy(policy string) (DuplicateFpDelegationPolicy, error) {
+	switch policy {
+	case "warn":
+		return WarnDuplicateFpDelegation, nil
+	case "refuse":
+		return RefuseDuplicateFpDelegation, nil
+	case "allow":
+		return AllowDuplicateFpDelegation, nil
+	default:
+		return WarnDuplicateFpDelegation, fmt.Errorf("invalid duplicate finality provider delegation policy: %s", policy)
+	}
+}
@@ -0,0 +1,25 @@
+package types
+
+import "fmt"
+
+// FeeRateSanityPolicy decides what happens when fee rate supplied by caller is
+// outside of configured tolerance band around fee rate estimated by btc node
+type FeeRateSanityPolicy int
+
+const (
+	// transaction is created with caller fee rate, but warning is logged
+	WarnFeeRateOutOfTolerance FeeRateSanityPolicy = iota
+	// transaction is refused
+	RefuseFeeRateOutOfTolerance
+)
+
+func NewFeeRateSanityPolicy(policy string) (FeeRateSanityPolicy, error) {
+	switch policy {
+	case "warn":
+		return WarnFeeRateOutOfTolerance, nil
+	case "refuse":
+		return RefuseFeeRateOutOfTolerance, nil
+	default:
+		return WarnFeeRateOutOfTolerance, fmt.Errorf("invalid fee rate sanity policy: %s", policy)
+	}
+}
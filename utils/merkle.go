@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BuildMerkleBranch returns hashes of sibling nodes on the path from transaction
+// at txIdx to the merkle root of the block, ordered from the leaf level up
+func BuildMerkleBranch(block *wire.MsgBlock, txIdx uint32) ([]chainhash.Hash, error) {
+	if int(txIdx) >= len(block.Transactions) {
+		return nil, fmt.Errorf("transaction index %d out of range, block has %d transactions", txIdx, len(block.Transactions))
+	}
+
+	level := make([]chainhash.Hash, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		level[i] = tx.TxHash()
+	}
+
+	var branch []chainhash.Hash
+	idx := txIdx
+
+	for len(level) > 1 {
+		// odd number of nodes at given level, last one is hashed with itself
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+
+		branch = append(branch, level[idx^1])
+
+		nextLevel := make([]chainhash.Hash, len(level)/2)
+		for i := range nextLevel {
+			nextLevel[i] = blockchain.HashMerkleBranches(&level[2*i], &level[2*i+1])
+		}
+
+		level = nextLevel
+		idx /= 2
+	}
+
+	return branch, nil
+}
+
+// VerifyMerkleBranch checks that merkle branch proves inclusion of transaction
+// with txHash at txIdx in block with given merkle root
+func VerifyMerkleBranch(
+	txHash *chainhash.Hash,
+	txIdx uint32,
+	branch []chainhash.Hash,
+	merkleRoot *chainhash.Hash,
+) bool {
+	current := *txHash
+	idx := txIdx
+
+	for i := range branch {
+		if idx%2 == 0 {
+			current = blockchain.HashMerkleBranches(&current, &branch[i])
+		} else {
+			current = blockchain.HashMerkleBranches(&branch[i], &current)
+		}
+		idx /= 2
+	}
+
+	// index must be fully consumed by the branch, otherwise proof is for
+	// different position in the tree
+	return idx == 0 && current.IsEqual(merkleRoot)
+}
@@ -0,0 +1,112 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+type importDescriptorRequest struct {
+	Desc string `json:"desc"`
+	// either "now" or unix time from which the chain is rescanned, 0 rescans
+	// the whole chain
+	Timestamp interface{} `json:"timestamp"`
+}
+
+type importDescriptorResult struct {
+	Success bool `json:"success"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// TrackAddress makes sure wallet tracks outputs sent to given address, so that
+// they can be later found and spent. Addresses which are already known to the
+// wallet are left untouched, other addresses are imported as watch-only without
+// rescan, as they are expected to be used only as future output targets.
+// Bitcoind descriptor wallets import address descriptor, legacy wallets import
+// address. Returns ErrUnsupportedByBackend for btcwallet backend.
+func (w *RpcWalletController) TrackAddress(address btcutil.Address) error {
+	if w.backend != types.BitcoindWalletBackend {
+		return fmt.Errorf("tracking address: %w", ErrUnsupportedByBackend)
+	}
+
+	encoded := address.EncodeAddress()
+
+	info, err := retryRead(w, func() (*btcjson.GetAddressInfoResult, error) {
+		return w.GetAddressInfo(encoded)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if info.IsMine || info.IsWatchOnly {
+		return nil
+	}
+
+	err = w.importAddressDescriptor(encoded)
+
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCWallet {
+		// legacy wallets do not support descriptors
+		return w.ImportAddressRescan(encoded, "", false)
+	}
+
+	return err
+}
+
+func (w *RpcWalletController) importAddressDescriptor(encodedAddress string) error {
+	desc, err := withDescriptorChecksum(fmt.Sprintf("addr(%s)", encodedAddress))
+
+	if err != nil {
+		return err
+	}
+
+	return w.importDescriptors([]importDescriptorRequest{{Desc: desc, Timestamp: "now"}})
+}
+
+// importDescriptors imports all descriptors in single importdescriptors call, so
+// that wallet rescans the chain at most once
+func (w *RpcWalletController) importDescriptors(requests []importDescriptorRequest) error {
+	param, err := json.Marshal(requests)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := w.Client.RawRequest("importdescriptors", []json.RawMessage{param})
+
+	if err != nil {
+		return err
+	}
+
+	var results []importDescriptorResult
+	if err := json.Unmarshal(res, &results); err != nil {
+		return err
+	}
+
+	if len(results) != len(requests) {
+		return fmt.Errorf("unexpected number of import results: %d, expected: %d", len(results), len(requests))
+	}
+
+	for i, result := range results {
+		if result.Success {
+			continue
+		}
+
+		// descriptors with private keys must not end up in logs
+		if result.Error != nil {
+			return fmt.Errorf("failed to import descriptor at index %d: %s", i, result.Error.Message)
+		}
+
+		return fmt.Errorf("failed to import descriptor at index %d", i)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackAddress(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		backend         types.SupportedWalletBackend
+		isMine          bool
+		legacyWallet    bool
+		expectedMethods []string
+		expectErr       error
+	}{
+		{
+			name:            "address already tracked",
+			backend:         types.BitcoindWalletBackend,
+			isMine:          true,
+			expectedMethods: []string{"getaddressinfo"},
+		},
+		{
+			name:            "descriptor wallet imports address descriptor",
+			backend:         types.BitcoindWalletBackend,
+			expectedMethods: []string{"getaddressinfo", "importdescriptors"},
+		},
+		{
+			name:            "legacy wallet imports address",
+			backend:         types.BitcoindWalletBackend,
+			legacyWallet:    true,
+			expectedMethods: []string{"getaddressinfo", "importdescriptors", "importaddress"},
+		},
+		{
+			name:      "btcwallet backend is not supported",
+			backend:   types.BtcwalletWalletBackend,
+			expectErr: ErrUnsupportedByBackend,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var methods []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req btcjson.Request
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				methods = append(methods, req.Method)
+
+				var result interface{}
+				var rpcErr *btcjson.RPCError
+
+				switch req.Method {
+				case "getaddressinfo":
+					result = map[string]interface{}{"address": addr.EncodeAddress(), "ismine": tc.isMine}
+				case "importdescriptors":
+					if tc.legacyWallet {
+						rpcErr = btcjson.NewRPCError(btcjson.ErrRPCWallet, "importdescriptors is not available for non-descriptor wallets")
+						break
+					}
+
+					var requests []importDescriptorRequest
+					require.NoError(t, json.Unmarshal(req.Params[0], &requests))
+					require.Len(t, requests, 1)
+					require.NoError(t, verifyDescriptorChecksum(requests[0].Desc))
+					require.True(t, strings.HasPrefix(requests[0].Desc, fmt.Sprintf("addr(%s)#", addr.EncodeAddress())))
+					result = []map[string]interface{}{{"success": true}}
+				case "importaddress":
+					require.Equal(t, fmt.Sprintf("%q", addr.EncodeAddress()), string(req.Params[0]))
+				default:
+					t.Fatalf("unexpected method %s", req.Method)
+				}
+
+				err := json.NewEncoder(w).Encode(map[string]interface{}{
+					"result": result,
+					"error":  rpcErr,
+					"id":     req.ID,
+				})
+				require.NoError(t, err)
+			}))
+			defer server.Close()
+
+			wc, err := NewRpcWalletControllerFromArgs(
+				strings.TrimPrefix(server.URL, "http://"),
+				"user",
+				"pass",
+				"",
+				chaincfg.RegressionNetParams.Name,
+				"",
+				tc.backend,
+				&chaincfg.RegressionNetParams,
+				true,
+				"",
+				"",
+				1,
+				10*time.Millisecond,
+				ChangePolicy{},
+				FeeLimits{},
+				types.LargestFirstCoinSelection,
+			)
+			require.NoError(t, err)
+			defer wc.Shutdown()
+
+			err = wc.TrackAddress(addr)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expectedMethods, methods)
+		})
+	}
+}
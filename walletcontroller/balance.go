@@ -0,0 +1,335 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// number of wallet transactions requested in one listtransactions call
+	listTransactionsPageSize = 1000
+)
+
+// GetBalance returns spendable balance of the wallet and balance of unconfirmed
+// transactions, which cannot be spent yet. Immature coinbase outputs are not
+// counted. Bitcoind spendable balance includes unconfirmed transactions created
+// by the wallet itself e.g change outputs, other backends count only confirmed
+// outputs as spendable.
+func (w *RpcWalletController) GetBalance() (btcutil.Amount, btcutil.Amount, error) {
+	if w.backend == types.BitcoindWalletBackend {
+		balances, err := retryRead(w, w.Client.GetBalances)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		spendable, err := btcutil.NewAmount(balances.Mine.Trusted)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		unconfirmed, err := btcutil.NewAmount(balances.Mine.UntrustedPending)
+
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return spendable, unconfirmed, nil
+	}
+
+	spendable, err := retryRead(w, func() (btcutil.Amount, error) {
+		return w.Client.GetBalance("*")
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	unconfirmed, err := retryRead(w, func() (btcutil.Amount, error) {
+		return w.Client.GetUnconfirmedBalance("*")
+	})
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return spendable, unconfirmed, nil
+}
+
+// historicalCredit is wallet output together with height of the block which
+// included transaction creating it
+type historicalCredit struct {
+	outPoint wire.OutPoint
+	amount   btcutil.Amount
+	height   int32
+	coinbase bool
+}
+
+// historicalTx is confirmed wallet transaction reduced to the outputs it spends
+type historicalTx struct {
+	height int32
+	inputs []wire.OutPoint
+}
+
+// balanceAtHeight sums wallet credits which were confirmed at or before given
+// height and were not spent by transaction confirmed at or before that height.
+// Coinbase credits are counted only if they were mature at given height.
+func balanceAtHeight(
+	credits []historicalCredit,
+	txs []historicalTx,
+	height int32,
+	coinbaseMaturity uint16,
+) btcutil.Amount {
+	spent := make(map[wire.OutPoint]struct{})
+	for _, tx := range txs {
+		if tx.height > height {
+			continue
+		}
+
+		for _, in := range tx.inputs {
+			spent[in] = struct{}{}
+		}
+	}
+
+	var balance btcutil.Amount
+	for _, credit := range credits {
+		if credit.height > height {
+			continue
+		}
+
+		if _, isSpent := spent[credit.outPoint]; isSpent {
+			continue
+		}
+
+		confirmations := height - credit.height + 1
+		if credit.coinbase && confirmations < int32(coinbaseMaturity) {
+			continue
+		}
+
+		balance += credit.amount
+	}
+
+	return balance
+}
+
+// entryHeight returns height of the block which included transaction from
+// listtransactions entry. Second return value is false for unconfirmed and
+// conflicted transactions.
+func entryHeight(entry *btcjson.ListTransactionsResult, tipHeight int32) (int32, bool) {
+	if entry.Confirmations <= 0 {
+		return 0, false
+	}
+
+	if entry.BlockHeight != nil {
+		return *entry.BlockHeight, true
+	}
+
+	return tipHeight - int32(entry.Confirmations) + 1, true
+}
+
+func (w *RpcWalletController) listAllTransactions() ([]btcjson.ListTransactionsResult, error) {
+	var all []btcjson.ListTransactionsResult
+
+	for from := 0; ; from += listTransactionsPageSize {
+		page, err := retryRead(w, func() ([]btcjson.ListTransactionsResult, error) {
+			return w.Client.ListTransactionsCountFrom("*", listTransactionsPageSize, from)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, page...)
+
+		if len(page) < listTransactionsPageSize {
+			return all, nil
+		}
+	}
+}
+
+func (w *RpcWalletController) walletTransaction(txHash *chainhash.Hash) (*wire.MsgTx, error) {
+	res, err := retryRead(w, func() (*btcjson.GetTransactionResult, error) {
+		return w.Client.GetTransaction(txHash)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	txBytes, err := hex.DecodeString(res.Hex)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, err
+	}
+
+	return &tx, nil
+}
+
+// isOwnScript checks whether output with given script belongs to the wallet.
+// Results are cached in ownScripts, keyed by script.
+func (w *RpcWalletController) isOwnScript(pkScript []byte, ownScripts map[string]bool) (bool, error) {
+	if own, ok := ownScripts[string(pkScript)]; ok {
+		return own, nil
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, w.netParams)
+
+	if err != nil || len(addrs) != 1 {
+		// non standard outputs and bare multisig are never wallet outputs
+		ownScripts[string(pkScript)] = false
+		return false, nil
+	}
+
+	encoded := addrs[0].EncodeAddress()
+	info, err := retryRead(w, func() (*btcjson.GetAddressInfoResult, error) {
+		return w.GetAddressInfo(encoded)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	ownScripts[string(pkScript)] = info.IsMine
+
+	return info.IsMine, nil
+}
+
+// GetBalanceAtHeight reconstructs wallet balance as it was at given block height,
+// from wallet transaction history. Balance consists of wallet outputs created at
+// or before given height and not spent by transaction confirmed at or before
+// that height. Immature coinbase outputs are not counted.
+// Listtransactions does not list change outputs of transactions sent by the
+// wallet, so outputs of those transactions are checked against wallet
+// addresses with getaddressinfo.
+// Result is only correct if wallet transaction history is complete i.e wallet
+// was rescanned from its birthday, or node has enabled transaction index.
+// Transactions conflicted or removed by reorg are not taken into account.
+func (w *RpcWalletController) GetBalanceAtHeight(height int32) (btcutil.Amount, error) {
+	if height < 0 {
+		return 0, fmt.Errorf("invalid height %d, height must be non-negative", height)
+	}
+
+	tipHeight, err := retryRead(w, w.GetBlockCount)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if int64(height) > tipHeight {
+		return 0, fmt.Errorf("height %d is above current tip height %d", height, tipHeight)
+	}
+
+	entries, err := w.listAllTransactions()
+
+	if err != nil {
+		return 0, err
+	}
+
+	var credits []historicalCredit
+	credited := make(map[wire.OutPoint]struct{})
+	txHeights := make(map[chainhash.Hash]int32)
+	// transactions sent by the wallet, which may have change outputs
+	sentTxs := make(map[chainhash.Hash]struct{})
+
+	for i := range entries {
+		entry := &entries[i]
+
+		txHeight, confirmed := entryHeight(entry, int32(tipHeight))
+		if !confirmed || txHeight > height {
+			continue
+		}
+
+		txHash, err := chainhash.NewHashFromStr(entry.TxID)
+
+		if err != nil {
+			return 0, err
+		}
+
+		txHeights[*txHash] = txHeight
+
+		switch entry.Category {
+		case "send":
+			sentTxs[*txHash] = struct{}{}
+		case "receive", "generate", "immature":
+			amount, err := btcutil.NewAmount(entry.Amount)
+
+			if err != nil {
+				return 0, err
+			}
+
+			outPoint := *wire.NewOutPoint(txHash, entry.Vout)
+			credited[outPoint] = struct{}{}
+			credits = append(credits, historicalCredit{
+				outPoint: outPoint,
+				amount:   amount,
+				height:   txHeight,
+				coinbase: entry.Category != "receive",
+			})
+		}
+	}
+
+	ownScripts := make(map[string]bool)
+	txs := make([]historicalTx, 0, len(txHeights))
+	for txHash, txHeight := range txHeights {
+		txHash := txHash
+		tx, err := w.walletTransaction(&txHash)
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve wallet transaction %s: %w", txHash, err)
+		}
+
+		inputs := make([]wire.OutPoint, len(tx.TxIn))
+		for i, in := range tx.TxIn {
+			inputs[i] = in.PreviousOutPoint
+		}
+
+		txs = append(txs, historicalTx{
+			height: txHeight,
+			inputs: inputs,
+		})
+
+		if _, sent := sentTxs[txHash]; !sent {
+			continue
+		}
+
+		for i, out := range tx.TxOut {
+			outPoint := *wire.NewOutPoint(&txHash, uint32(i))
+
+			if _, ok := credited[outPoint]; ok {
+				continue
+			}
+
+			own, err := w.isOwnScript(out.PkScript, ownScripts)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if !own {
+				continue
+			}
+
+			credits = append(credits, historicalCredit{
+				outPoint: outPoint,
+				amount:   btcutil.Amount(out.Value),
+				height:   txHeight,
+			})
+		}
+	}
+
+	return balanceAtHeight(credits, txs, height, w.netParams.CoinbaseMaturity), nil
+}
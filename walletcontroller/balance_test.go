@@ -0,0 +1,199 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutPoint(b byte, idx uint32) wire.OutPoint {
+	return wire.OutPoint{Hash: chainhash.Hash{b}, Index: idx}
+}
+
+func TestBalanceAtHeight(t *testing.T) {
+	const coinbaseMaturity = 10
+
+	coinbase := testOutPoint(1, 0)
+	received := testOutPoint(2, 0)
+	change := testOutPoint(3, 1)
+	lateReceived := testOutPoint(4, 0)
+
+	credits := []historicalCredit{
+		{outPoint: coinbase, amount: 50 * btcutil.SatoshiPerBitcoin, height: 1, coinbase: true},
+		{outPoint: received, amount: 2 * btcutil.SatoshiPerBitcoin, height: 5},
+		// change of transaction at height 20 spending coinbase and received outputs
+		{outPoint: change, amount: 40 * btcutil.SatoshiPerBitcoin, height: 20},
+		{outPoint: lateReceived, amount: 1 * btcutil.SatoshiPerBitcoin, height: 30},
+	}
+
+	txs := []historicalTx{
+		{height: 1},
+		{height: 5, inputs: []wire.OutPoint{testOutPoint(9, 0)}},
+		{height: 20, inputs: []wire.OutPoint{coinbase, received}},
+		{height: 30, inputs: []wire.OutPoint{testOutPoint(9, 1)}},
+	}
+
+	tests := []struct {
+		name   string
+		height int32
+		want   btcutil.Amount
+	}{
+		{"before any credit", 0, 0},
+		{"immature coinbase", 5, 2 * btcutil.SatoshiPerBitcoin},
+		{"coinbase becomes mature", 10, 52 * btcutil.SatoshiPerBitcoin},
+		{"before spend", 19, 52 * btcutil.SatoshiPerBitcoin},
+		{"after spend", 20, 40 * btcutil.SatoshiPerBitcoin},
+		{"all credits", 30, 41 * btcutil.SatoshiPerBitcoin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, balanceAtHeight(credits, txs, tt.height, coinbaseMaturity))
+		})
+	}
+}
+
+func testWitnessAddress(t *testing.T) btcutil.Address {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+
+	return addr
+}
+
+func TestGetBalanceAtHeightCountsChange(t *testing.T) {
+	ownAddr := testWitnessAddress(t)
+	changeAddr := testWitnessAddress(t)
+	foreignAddr := testWitnessAddress(t)
+
+	payToAddr := func(addr btcutil.Address, amount int64) *wire.TxOut {
+		script, err := txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+		return wire.NewTxOut(amount, script)
+	}
+
+	// received at height 5
+	receiveTx := wire.NewMsgTx(2)
+	receiveTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}}, nil, nil))
+	receiveTx.AddTxOut(payToAddr(ownAddr, 100000))
+	receiveHash := receiveTx.TxHash()
+
+	// sent at height 10, output 1 is change which listtransactions does not list
+	sendTx := wire.NewMsgTx(2)
+	sendTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&receiveHash, 0), nil, nil))
+	sendTx.AddTxOut(payToAddr(foreignAddr, 40000))
+	sendTx.AddTxOut(payToAddr(changeAddr, 59000))
+	sendHash := sendTx.TxHash()
+
+	txHex := func(tx *wire.MsgTx) string {
+		var buf bytes.Buffer
+		require.NoError(t, tx.Serialize(&buf))
+		return hex.EncodeToString(buf.Bytes())
+	}
+	txs := map[string]string{
+		receiveHash.String(): txHex(receiveTx),
+		sendHash.String():    txHex(sendTx),
+	}
+
+	const tipHeight = 20
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var result interface{}
+		var rpcErr *btcjson.RPCError
+
+		switch req.Method {
+		case "getblockcount":
+			result = tipHeight
+		case "listtransactions":
+			result = []map[string]interface{}{
+				{
+					"category":      "receive",
+					"amount":        0.001,
+					"confirmations": tipHeight - 5 + 1,
+					"txid":          receiveHash.String(),
+					"vout":          0,
+				},
+				{
+					"category":      "send",
+					"amount":        -0.0004,
+					"confirmations": tipHeight - 10 + 1,
+					"txid":          sendHash.String(),
+					"vout":          0,
+				},
+			}
+		case "gettransaction":
+			var txid string
+			_ = json.Unmarshal(req.Params[0], &txid)
+			result = map[string]interface{}{"txid": txid, "hex": txs[txid]}
+		case "getaddressinfo":
+			var addr string
+			_ = json.Unmarshal(req.Params[0], &addr)
+			result = map[string]interface{}{
+				"address": addr,
+				"ismine":  addr == ownAddr.EncodeAddress() || addr == changeAddr.EncodeAddress(),
+			}
+		default:
+			rpcErr = btcjson.NewRPCError(btcjson.ErrRPCMethodNotFound, req.Method)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": result,
+			"error":  rpcErr,
+			"id":     req.ID,
+		})
+	}))
+	defer server.Close()
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"",
+		types.BitcoindWalletBackend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		10*time.Millisecond,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	defer wc.Shutdown()
+
+	balance, err := wc.GetBalanceAtHeight(9)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(100000), balance)
+
+	balance, err = wc.GetBalanceAtHeight(10)
+	require.NoError(t, err)
+	require.Equal(t, btcutil.Amount(59000), balance)
+}
@@ -0,0 +1,75 @@
+package walletcontroller
+
+import (
+	"sort"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// MaxConsolidatedInputs is the maximum number of small outputs consolidated by
+// single transaction. It keeps the transaction well below standard size, even
+// if all consolidated outputs are p2pkh. Remaining small outputs are
+// consolidated by following transactions.
+const MaxConsolidatedInputs = 100
+
+// ChangePolicy decides how change of transaction funded by the wallet is handled
+type ChangePolicy struct {
+	// change below this amount is avoided by funding transaction with
+	// additional wallet outputs. If wallet cannot fund it, the change is added
+	// to the fee instead of creating change output. Change below dust limit is
+	// always added to the fee
+	MinChange btcutil.Amount
+	// if greater than zero, wallet outputs with value below this amount are
+	// used to fund the transaction, even if they are not needed to cover its
+	// outputs and fee, so that they are consolidated into the change. Outputs
+	// worth less than fee of spending them are never consolidated, and at most
+	// MaxConsolidatedInputs outputs are consolidated at once.
+	ConsolidateBelow btcutil.Amount
+}
+
+func NewChangePolicy(cfg *scfg.WalletConfig) ChangePolicy {
+	return ChangePolicy{
+		MinChange:        btcutil.Amount(cfg.MinChangeAmount),
+		ConsolidateBelow: btcutil.Amount(cfg.ConsolidateBelow),
+	}
+}
+
+// consolidates returns true if policy pulls in additional small outputs
+func (p ChangePolicy) consolidates() bool {
+	return p.ConsolidateBelow > 0
+}
+
+// splitConsolidated splits utxos into outputs which are consolidated according
+// to policy when paying feeRatePerKb and the remaining ones. If there are more
+// outputs to consolidate than MaxConsolidatedInputs, the largest ones are
+// consolidated first.
+func (p ChangePolicy) splitConsolidated(utxos []Utxo, feeRatePerKb btcutil.Amount) ([]Utxo, []Utxo) {
+	if !p.consolidates() {
+		return nil, utxos
+	}
+
+	var consolidated, rest []Utxo
+	for _, utxo := range utxos {
+		if utxo.Amount < p.ConsolidateBelow && utxo.Amount > inputFee(utxo, feeRatePerKb) {
+			consolidated = append(consolidated, utxo)
+		} else {
+			rest = append(rest, utxo)
+		}
+	}
+
+	if len(consolidated) > MaxConsolidatedInputs {
+		sort.Stable(sort.Reverse(byAmount(consolidated)))
+		rest = append(rest, consolidated[MaxConsolidatedInputs:]...)
+		consolidated = consolidated[:MaxConsolidatedInputs]
+	}
+
+	return consolidated, rest
+}
+
+// inputFee returns fee paid at feeRatePerKb for spending utxo
+func inputFee(utxo Utxo, feeRatePerKb btcutil.Amount) btcutil.Amount {
+	inputSize := EstimateTxVirtualSize([][]byte{utxo.PkScript}, nil, nil) - EstimateTxVirtualSize(nil, nil, nil)
+	return txrules.FeeForSerializeSize(feeRatePerKb, inputSize)
+}
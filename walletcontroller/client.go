@@ -1,17 +1,24 @@
 package walletcontroller
 
 import (
+	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"sort"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/babylonchain/babylon/crypto/bip322"
 	"github.com/babylonchain/btc-staker/stakercfg"
 	scfg "github.com/babylonchain/btc-staker/stakercfg"
 	"github.com/babylonchain/btc-staker/types"
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
@@ -22,30 +29,97 @@ import (
 
 type RpcWalletController struct {
 	*rpcclient.Client
-	walletPassphrase string
+	// passphrase used by UnlockWallet, it is fetched only for the duration of
+	// the unlock
+	walletPassphrase passphraseSource
 	network          string
+	netParams        *chaincfg.Params
 	backend          types.SupportedWalletBackend
+	// idempotent reads are retried on transient connection errors
+	readRetryAttempts uint
+	readRetryDelay    time.Duration
+	// change policy of transactions funded and signed by the wallet
+	changePolicy ChangePolicy
+	// limits of fee rate of funded transactions
+	feeLimits     FeeLimits
+	coinSelection types.CoinSelectionStrategy
+	outputLocks   *outputLocks
 }
 
 var _ WalletController = (*RpcWalletController)(nil)
 
+const (
+	// DefaultXpubGapLimit is number of addresses derived from imported xpub,
+	// when no other limit is provided. It is the same as gap limit recommended
+	// by BIP44.
+	DefaultXpubGapLimit = 20
+)
+
 const (
 	txNotFoundErrMsgBtcd     = "No information available about transaction"
 	txNotFoundErrMsgBitcoind = "No such mempool or blockchain transaction"
 )
 
 func NewRpcWalletController(scfg *stakercfg.Config) (*RpcWalletController, error) {
+	passphrase, err := newPassphraseSource(
+		scfg.WalletConfig.WalletPassFile,
+		scfg.WalletConfig.WalletPassEnv,
+		scfg.WalletConfig.WalletPass,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	wc, err := newRpcWalletControllerWithHost(scfg, scfg.WalletRpcConfig.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	wc.walletPassphrase = passphrase
+	return wc, nil
+}
+
+// NewNamedRpcWalletController creates controller of additional bitcoind wallet
+// with given name, reached through wallet scoped endpoint of the wallet rpc
+// server configured in scfg. Passphrase from config belongs to the main wallet,
+// so the named wallet must be unlocked externally or with passphrase provided
+// per operation.
+func NewNamedRpcWalletController(scfg *stakercfg.Config, walletName string) (*RpcWalletController, error) {
+	if walletName == "" {
+		return nil, fmt.Errorf("wallet name must not be empty")
+	}
+
+	if scfg.BtcNodeBackendConfig.ActiveWalletBackend != types.BitcoindWalletBackend {
+		return nil, fmt.Errorf("named wallets are only supported by bitcoind backend: %w", ErrUnsupportedByBackend)
+	}
+
+	return newRpcWalletControllerWithHost(scfg, WalletScopedHost(scfg.WalletRpcConfig.Host, walletName))
+}
+
+// WalletScopedHost returns host of bitcoind endpoint serving wallet rpc calls
+// of the wallet with given name i.e <host>/wallet/<name>
+func WalletScopedHost(host string, walletName string) string {
+	return strings.TrimSuffix(host, "/") + "/wallet/" + url.PathEscape(walletName)
+}
+
+func newRpcWalletControllerWithHost(scfg *stakercfg.Config, host string) (*RpcWalletController, error) {
 	return NewRpcWalletControllerFromArgs(
-		scfg.WalletRpcConfig.Host,
+		host,
 		scfg.WalletRpcConfig.User,
 		scfg.WalletRpcConfig.Pass,
+		scfg.WalletRpcConfig.CookieFile,
 		scfg.ActiveNetParams.Name,
-		scfg.WalletConfig.WalletPass,
+		"",
 		scfg.BtcNodeBackendConfig.ActiveWalletBackend,
 		&scfg.ActiveNetParams,
 		scfg.WalletRpcConfig.DisableTls,
 		scfg.WalletRpcConfig.RawRPCWalletCert,
 		scfg.WalletRpcConfig.RPCWalletCert,
+		scfg.WalletRpcConfig.ReadRetryAttempts,
+		scfg.WalletRpcConfig.ReadRetryDelay,
+		NewChangePolicy(scfg.WalletConfig),
+		NewFeeLimits(scfg.BtcNodeBackendConfig),
+		scfg.WalletConfig.ActiveCoinSelectionStrategy,
 	)
 }
 
@@ -53,14 +127,24 @@ func NewRpcWalletControllerFromArgs(
 	host string,
 	user string,
 	pass string,
+	cookieFilePath string,
 	network string,
 	walletPassphrase string,
 	nodeBackend types.SupportedWalletBackend,
 	params *chaincfg.Params,
 	disableTls bool,
 	rawWalletCert string, walletCertFilePath string,
+	readRetryAttempts uint,
+	readRetryDelay time.Duration,
+	changePolicy ChangePolicy,
+	feeLimits FeeLimits,
+	coinSelection types.CoinSelectionStrategy,
 ) (*RpcWalletController, error) {
 
+	if readRetryAttempts == 0 {
+		return nil, fmt.Errorf("read retry attempts must be greater than 0")
+	}
+
 	connCfg := &rpcclient.ConnConfig{
 		Host:                 host,
 		User:                 user,
@@ -73,6 +157,17 @@ func NewRpcWalletControllerFromArgs(
 		HTTPPostMode: true,
 	}
 
+	// explicit credentials take precedence over the cookie file
+	if cookieFilePath != "" && pass == "" {
+		// cookie is read upfront, so that invalid cookie file is reported on
+		// start. Rpc client re-reads it whenever it changes e.g when node is
+		// restarted and rotates the cookie.
+		if _, _, err := readCookieFile(cookieFilePath); err != nil {
+			return nil, err
+		}
+		connCfg.CookiePath = cookieFilePath
+	}
+
 	if !connCfg.DisableTLS {
 		cert, err := scfg.ReadCertFile(rawWalletCert, walletCertFilePath)
 		if err != nil {
@@ -87,21 +182,62 @@ func NewRpcWalletControllerFromArgs(
 	}
 
 	return &RpcWalletController{
-		Client:           rpcclient,
-		walletPassphrase: walletPassphrase,
-		network:          params.Name,
-		backend:          nodeBackend,
+		Client:            rpcclient,
+		walletPassphrase:  staticPassphraseSource(walletPassphrase),
+		network:           params.Name,
+		netParams:         params,
+		backend:           nodeBackend,
+		readRetryAttempts: readRetryAttempts,
+		readRetryDelay:    readRetryDelay,
+		changePolicy:      changePolicy,
+		feeLimits:         feeLimits,
+		coinSelection:     coinSelection,
+		outputLocks:       newOutputLocks(),
 	}, nil
 }
 
+// UnlockWallet unlocks the wallet using passphrase from config. Stored passphrase
+// is optional, if it is not provided it is assumed wallet is either not encrypted
+// or it is unlocked per operation using UnlockWalletWithPassphrase.
 func (w *RpcWalletController) UnlockWallet(timoutSec int64) error {
-	return w.WalletPassphrase(w.walletPassphrase, timoutSec)
+	passphrase, err := w.walletPassphrase()
+	if err != nil {
+		return err
+	}
+	defer clear(passphrase)
+
+	if len(passphrase) == 0 {
+		return nil
+	}
+
+	return unlockErr(w.WalletPassphrase(string(passphrase), timoutSec))
+}
+
+func (w *RpcWalletController) UnlockWalletWithPassphrase(passphrase string, timoutSec int64) error {
+	return unlockErr(w.WalletPassphrase(passphrase, timoutSec))
+}
+
+// unlockErr translates wallet rejecting passphrase to ErrWalletPassphraseIncorrect,
+// so that it is not reported as generic rpc failure
+func unlockErr(err error) error {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCWalletPassphraseIncorrect {
+		return fmt.Errorf("%w: %s", ErrWalletPassphraseIncorrect, rpcErr.Message)
+	}
+
+	return err
+}
+
+func (w *RpcWalletController) LockWallet() error {
+	return w.WalletLock()
 }
 
 func (w *RpcWalletController) AddressPublicKey(address btcutil.Address) (*btcec.PublicKey, error) {
 	encoded := address.EncodeAddress()
 
-	info, err := w.GetAddressInfo(encoded)
+	info, err := retryRead(w, func() (*btcjson.GetAddressInfoResult, error) {
+		return w.GetAddressInfo(encoded)
+	})
 
 	if err != nil {
 		return nil, err
@@ -121,7 +257,9 @@ func (w *RpcWalletController) AddressPublicKey(address btcutil.Address) (*btcec.
 }
 
 func (w *RpcWalletController) DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error) {
-	privKey, err := w.DumpPrivKey(address)
+	privKey, err := retryRead(w, func() (*btcutil.WIF, error) {
+		return w.DumpPrivKey(address)
+	})
 
 	if err != nil {
 		return nil, err
@@ -130,14 +268,241 @@ func (w *RpcWalletController) DumpPrivateKey(address btcutil.Address) (*btcec.Pr
 	return privKey.PrivKey, nil
 }
 
+// ImportPrivKey imports key and rescans the chain for its outputs
+func (w *RpcWalletController) ImportPrivKey(privKeyWIF *btcutil.WIF) error {
+	return w.ImportPrivKeys([]*btcutil.WIF{privKeyWIF}, true)
+}
+
+// ImportPrivKeys imports all provided keys without rescanning the chain after each
+// of them. If rescanAtEnd is true, only the last import triggers a rescan. Rescan
+// is wallet wide, so outputs of all imported keys become visible after it.
+// Bitcoind descriptor wallets, which do not support importprivkey, import p2wpkh
+// and p2tr descriptors of the keys instead. Returns ErrWatchOnlyWallet if bitcoind
+// wallet has private keys disabled.
+func (w *RpcWalletController) ImportPrivKeys(keys []*btcutil.WIF, rescanAtEnd bool) error {
+	if w.backend == types.BitcoindWalletBackend {
+		info, err := w.getWalletInfo()
+
+		if err != nil {
+			return err
+		}
+
+		if !info.PrivateKeysEnabled {
+			return ErrWatchOnlyWallet
+		}
+
+		if info.Descriptors {
+			return w.importPrivKeyDescriptors(keys, rescanAtEnd)
+		}
+	}
+
+	for i, key := range keys {
+		rescan := rescanAtEnd && i == len(keys)-1
+
+		if err := w.ImportPrivKeyRescan(key, "", rescan); err != nil {
+			return fmt.Errorf("failed to import private key at index %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ImportXpub imports first gapLimit public keys derived from provided xpub as
+// watch-only keys. Keys are derived as non-hardened children of xpub, so xpub
+// should be the key of the chain i.e m/purpose'/coin'/account'/change. Funds
+// sent to addresses beyond gap limit are not tracked until RescanAddressRange
+// is called for their indexes.
+func (w *RpcWalletController) ImportXpub(xpub *hdkeychain.ExtendedKey, gapLimit uint32, rescan bool) error {
+	if gapLimit == 0 {
+		return fmt.Errorf("gap limit must be greater than 0")
+	}
+
+	return w.importXpubRange(xpub, 0, gapLimit, rescan)
+}
+
+// RescanAddressRange extends tracking of already imported xpub, by importing
+// public keys at indexes [start, end) and rescanning the chain for their outputs.
+func (w *RpcWalletController) RescanAddressRange(xpub *hdkeychain.ExtendedKey, start, end uint32) error {
+	return w.importXpubRange(xpub, start, end, true)
+}
+
+func (w *RpcWalletController) importXpubRange(
+	xpub *hdkeychain.ExtendedKey,
+	start, end uint32,
+	rescanAtEnd bool,
+) error {
+	pubKeys, err := deriveXpubPubKeys(xpub, start, end)
+
+	if err != nil {
+		return err
+	}
+
+	for i, pubKey := range pubKeys {
+		rescan := rescanAtEnd && i == len(pubKeys)-1
+
+		pubKeyHex := hex.EncodeToString(pubKey.SerializeCompressed())
+
+		if err := w.ImportPubKeyRescan(pubKeyHex, rescan); err != nil {
+			return fmt.Errorf("failed to import public key %s: %w", pubKeyHex, err)
+		}
+	}
+
+	return nil
+}
+
+// deriveXpubPubKeys derives public keys of non-hardened children of xpub at
+// indexes [start, end)
+func deriveXpubPubKeys(xpub *hdkeychain.ExtendedKey, start, end uint32) ([]*btcec.PublicKey, error) {
+	if xpub.IsPrivate() {
+		return nil, fmt.Errorf("provided extended key is private, expected xpub")
+	}
+
+	if start >= end {
+		return nil, fmt.Errorf("invalid address range [%d, %d)", start, end)
+	}
+
+	if end > hdkeychain.HardenedKeyStart {
+		return nil, fmt.Errorf("address range end %d exceeds max non-hardened index", end)
+	}
+
+	pubKeys := make([]*btcec.PublicKey, 0, end-start)
+	for i := start; i < end; i++ {
+		child, err := xpub.Derive(i)
+
+		// derivation can fail for some indexes with negligible probability,
+		// such indexes are skipped as wallets do not generate addresses for them
+		if errors.Is(err, hdkeychain.ErrInvalidChild) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive child key at index %d: %w", i, err)
+		}
+
+		pubKey, err := child.ECPubKey()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get public key at index %d: %w", i, err)
+		}
+
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	return pubKeys, nil
+}
+
 func (w *RpcWalletController) NetworkName() string {
 	return w.network
 }
 
+func (w *RpcWalletController) NewChangeAddress(addrType types.ChangeAddressType) (btcutil.Address, error) {
+	var walletAddrType types.AddressType
+
+	switch addrType {
+	case types.P2WPKHChangeAddress:
+		walletAddrType = types.Bech32Address
+	case types.P2TRChangeAddress:
+		walletAddrType = types.Bech32mAddress
+	default:
+		return nil, fmt.Errorf("wallet cannot generate change address of type: %d", addrType)
+	}
+
+	return w.requestAddress("getrawchangeaddress", walletAddrType)
+}
+
+// GetNewAddress returns new receive address of given type. Returns error if
+// wallet returns address of other type or for other network.
+func (w *RpcWalletController) GetNewAddress(addrType types.AddressType) (btcutil.Address, error) {
+	return w.requestAddress("getnewaddress", addrType)
+}
+
+// requestAddress requests new address of given type using either getnewaddress
+// or getrawchangeaddress, and makes sure wallet did not silently fallback to
+// other address type
+func (w *RpcWalletController) requestAddress(method string, addrType types.AddressType) (btcutil.Address, error) {
+	var args []string
+
+	switch {
+	// bitcoind getrawchangeaddress takes address type as its only argument
+	case w.backend == types.BitcoindWalletBackend && method == "getrawchangeaddress":
+		args = []string{addrType.String()}
+	// bitcoind getnewaddress takes label as the first argument
+	case w.backend == types.BitcoindWalletBackend:
+		args = []string{"", addrType.String()}
+	// btcwallet takes account as the first argument
+	case w.backend == types.BtcwalletWalletBackend:
+		args = []string{"default", addrType.String()}
+	default:
+		return nil, fmt.Errorf("invalid bitcoin backend")
+	}
+
+	addr, err := w.rawAddressRequest(method, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !addr.IsForNet(w.netParams) {
+		return nil, fmt.Errorf("wallet returned address %s for network other than %s", addr.EncodeAddress(), w.netParams.Name)
+	}
+
+	if !addressHasType(addr, addrType) {
+		return nil, fmt.Errorf("wallet returned address %s of unexpected type, expected %s", addr.EncodeAddress(), addrType)
+	}
+
+	return addr, nil
+}
+
+// addressHasType returns true if address is of given wallet address type
+func addressHasType(addr btcutil.Address, addrType types.AddressType) bool {
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return addrType == types.LegacyAddress
+	case *btcutil.AddressScriptHash:
+		return addrType == types.P2SHSegwitAddress
+	case *btcutil.AddressWitnessPubKeyHash:
+		return addrType == types.Bech32Address
+	case *btcutil.AddressTaproot:
+		return addrType == types.Bech32mAddress
+	default:
+		return false
+	}
+}
+
+// rawAddressRequest is used instead of rpcclient helpers, as those decode
+// address using network params of the rpc client which are not configured
+func (w *RpcWalletController) rawAddressRequest(method string, args ...string) (btcutil.Address, error) {
+	params := make([]json.RawMessage, len(args))
+
+	for i, arg := range args {
+		param, err := json.Marshal(arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		params[i] = param
+	}
+
+	res, err := w.Client.RawRequest(method, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var encodedAddr string
+	if err := json.Unmarshal(res, &encodedAddr); err != nil {
+		return nil, err
+	}
+
+	return btcutil.DecodeAddress(encodedAddr, w.netParams)
+}
+
 func (w *RpcWalletController) CreateTransaction(
 	outputs []*wire.TxOut,
 	feeRatePerKb btcutil.Amount,
-	changeAddres btcutil.Address) (*wire.MsgTx, error) {
+	changeAddres btcutil.Address,
+	signalRbf bool) (*wire.MsgTx, error) {
 
 	utxoResults, err := w.ListUnspent()
 
@@ -151,9 +516,7 @@ func (w *RpcWalletController) CreateTransaction(
 		return nil, err
 	}
 
-	// sort utxos by amount from highest to lowest, this is effectively strategy of using
-	// largest inputs first
-	sort.Sort(sort.Reverse(byAmount(utxos)))
+	utxos = w.outputLocks.unlocked(utxos)
 
 	changeScript, err := txscript.PayToAddrScript(changeAddres)
 
@@ -161,7 +524,7 @@ func (w *RpcWalletController) CreateTransaction(
 		return nil, err
 	}
 
-	tx, err := buildTxFromOutputs(utxos, outputs, feeRatePerKb, changeScript)
+	tx, err := buildTxFromOutputs(utxos, outputs, feeRatePerKb, w.feeLimits, changeScript, w.changePolicy, signalRbf, w.coinSelection)
 
 	if err != nil {
 		return nil, err
@@ -171,17 +534,19 @@ func (w *RpcWalletController) CreateTransaction(
 }
 
 func (w *RpcWalletController) CreateAndSignTx(
+	ctx context.Context,
 	outputs []*wire.TxOut,
 	feeRatePerKb btcutil.Amount,
 	changeAddress btcutil.Address,
+	signalRbf bool,
 ) (*wire.MsgTx, error) {
-	tx, err := w.CreateTransaction(outputs, feeRatePerKb, changeAddress)
+	tx, err := w.CreateTransaction(outputs, feeRatePerKb, changeAddress, signalRbf)
 
 	if err != nil {
 		return nil, err
 	}
 
-	fundedTx, signed, err := w.SignRawTransaction(tx)
+	fundedTx, signed, err := w.SignRawTransaction(ctx, tx)
 
 	if err != nil {
 		return nil, err
@@ -196,23 +561,53 @@ func (w *RpcWalletController) CreateAndSignTx(
 	return fundedTx, nil
 }
 
-func (w *RpcWalletController) SignRawTransaction(tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
+// signedTx is result of signing transaction by the wallet
+type signedTx struct {
+	tx       *wire.MsgTx
+	complete bool
+}
+
+func (w *RpcWalletController) SignRawTransaction(ctx context.Context, tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
+	var sign func(*wire.MsgTx) (*wire.MsgTx, bool, error)
+
 	switch w.backend {
 	case types.BitcoindWalletBackend:
-		return w.Client.SignRawTransactionWithWallet(tx)
+		sign = w.Client.SignRawTransactionWithWallet
 	case types.BtcwalletWalletBackend:
-		return w.Client.SignRawTransaction(tx)
+		sign = w.Client.SignRawTransaction
 	default:
 		return nil, false, fmt.Errorf("invalid bitcoin backend")
 	}
+
+	res, err := awaitRpc(ctx, func() (signedTx, error) {
+		signed, complete, err := sign(tx)
+		return signedTx{tx: signed, complete: complete}, err
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return res.tx, res.complete, nil
 }
 
-func (w *RpcWalletController) SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+// SendRawTransaction sends transaction to the node. ctx is checked only before
+// transaction is sent, broadcast which reached the node is always awaited, so
+// that caller learns whether transaction was accepted.
+func (w *RpcWalletController) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	return w.Client.SendRawTransaction(tx, allowHighFees)
 }
 
+func (w *RpcWalletController) listUnspent() ([]btcjson.ListUnspentResult, error) {
+	return retryRead(w, w.ListUnspent)
+}
+
 func (w *RpcWalletController) ListOutputs(onlySpendable bool) ([]Utxo, error) {
-	utxoResults, err := w.ListUnspent()
+	utxoResults, err := w.listUnspent()
 
 	if err != nil {
 		return nil, err
@@ -227,6 +622,77 @@ func (w *RpcWalletController) ListOutputs(onlySpendable bool) ([]Utxo, error) {
 	return utxos, nil
 }
 
+func (w *RpcWalletController) ListOutputsDetails() ([]UtxoDetails, error) {
+	utxoResults, err := w.listUnspent()
+
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := resultsToUtxos(utxoResults, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]UtxoDetails, len(utxos))
+	for i, utxo := range utxos {
+		details[i] = UtxoDetails{
+			Utxo:          utxo,
+			Confirmations: utxoResults[i].Confirmations,
+			Spendable:     utxoResults[i].Spendable,
+			Locked:        false,
+		}
+	}
+
+	// locked outputs are not returned by listunspent, so they need to be queried
+	// one by one
+	lockedOutpoints, err := retryRead(w, w.ListLockUnspent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, outpoint := range lockedOutpoints {
+		txOut, err := w.getTxOut(&outpoint.Hash, outpoint.Index, true)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// output was spent after being locked
+		if txOut == nil {
+			continue
+		}
+
+		amount, err := btcutil.NewAmount(txOut.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		script, err := hex.DecodeString(txOut.ScriptPubKey.Hex)
+
+		if err != nil {
+			return nil, err
+		}
+
+		details = append(details, UtxoDetails{
+			Utxo: Utxo{
+				Amount:   amount,
+				OutPoint: *outpoint,
+				PkScript: script,
+				Address:  txOut.ScriptPubKey.Address,
+			},
+			Confirmations: txOut.Confirmations,
+			Spendable:     false,
+			Locked:        true,
+		})
+	}
+
+	return details, nil
+}
+
 func nofitierStateToWalletState(state notifier.TxConfStatus) TxStatus {
 	switch state {
 	case notifier.TxNotFoundIndex:
@@ -254,6 +720,19 @@ func (w *RpcWalletController) getTxDetails(req notifier.ConfRequest, msg string)
 	return res, nofitierStateToWalletState(state), nil
 }
 
+// NodeBestHeight returns height of the best block of the node, queried with
+// getblockchaininfo. It is not retried, so that unreachable node is reported
+// quickly.
+func (w *RpcWalletController) NodeBestHeight() (int64, error) {
+	info, err := w.GetBlockChainInfo()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(info.Blocks), nil
+}
+
 // Fetch info about transaction from mempool or blockchain, requires node to have enabled  transaction index
 func (w *RpcWalletController) TxDetails(txHash *chainhash.Hash, pkScript []byte) (*notifier.TxConfirmation, TxStatus, error) {
 	req, err := notifier.NewConfRequest(txHash, pkScript)
@@ -272,12 +751,33 @@ func (w *RpcWalletController) TxDetails(txHash *chainhash.Hash, pkScript []byte)
 	}
 }
 
+func (w *RpcWalletController) getTxOut(txHash *chainhash.Hash, index uint32, mempool bool) (*btcjson.GetTxOutResult, error) {
+	return retryRead(w, func() (*btcjson.GetTxOutResult, error) {
+		return w.Client.GetTxOut(txHash, index, mempool)
+	})
+}
+
+// OutputSpent returns true if given output was spent by transaction included in
+// the btc chain. Transaction containing the output must be already included in
+// the chain, otherwise output is also reported as spent.
+func (w *RpcWalletController) OutputSpent(txHash *chainhash.Hash, outputIdx uint32) (bool, error) {
+	// mempool spends are not taken into account, as only confirmed spend is
+	// meaningful for staking transactions
+	res, err := w.getTxOut(txHash, outputIdx, false)
+
+	if err != nil {
+		return false, err
+	}
+
+	return res == nil, nil
+}
+
 // SignBip322NativeSegwit signs arbitrary message using bip322 signing scheme.
 // To work properly:
 // - wallet must be unlocked
 // - address must be under wallet control
 // - address must be native segwit address
-func (w *RpcWalletController) SignBip322NativeSegwit(msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+func (w *RpcWalletController) SignBip322NativeSegwit(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
 	toSpend, err := bip322.GetToSpendTx(msg, address)
 
 	if err != nil {
@@ -293,22 +793,91 @@ func (w *RpcWalletController) SignBip322NativeSegwit(msg []byte, address btcutil
 	toSign := bip322.GetToSignTx(toSpend)
 
 	amt := float64(0)
-	signed, all, err := w.SignRawTransactionWithWallet2(toSign, []btcjson.RawTxWitnessInput{
-		{
-			Txid:         toSpendhash.String(),
-			Vout:         0,
-			ScriptPubKey: hex.EncodeToString(toSpend.TxOut[0].PkScript),
-			Amount:       &amt,
-		},
+	signed, err := awaitRpc(ctx, func() (signedTx, error) {
+		signed, complete, err := w.SignRawTransactionWithWallet2(toSign, []btcjson.RawTxWitnessInput{
+			{
+				Txid:         toSpendhash.String(),
+				Vout:         0,
+				ScriptPubKey: hex.EncodeToString(toSpend.TxOut[0].PkScript),
+				Amount:       &amt,
+			},
+		})
+		return signedTx{tx: signed, complete: complete}, err
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign raw transaction while creating bip322 signature: %w", err)
 	}
 
-	if !all {
+	if !signed.complete {
 		return nil, fmt.Errorf("failed to create bip322 signature, address %s is not under wallet control", address)
 	}
 
-	return signed.TxIn[0].Witness, nil
+	return signed.tx.TxIn[0].Witness, nil
+}
+
+// checkTaprootKeySpendWitness returns ErrTaprootKeySpendUnavailable if witness
+// spending taproot output is not key path spend i.e it does not consist of
+// single BIP340 signature
+func checkTaprootKeySpendWitness(witness wire.TxWitness, address btcutil.Address) error {
+	if len(witness) != 1 ||
+		(len(witness[0]) != schnorr.SignatureSize && len(witness[0]) != schnorr.SignatureSize+1) {
+		return fmt.Errorf("address %s: %w", address, ErrTaprootKeySpendUnavailable)
+	}
+
+	return nil
+}
+
+// SignBip322Taproot signs arbitrary message using bip322 signing scheme, producing
+// key path spend witness of taproot output. To work properly:
+// - wallet must be unlocked
+// - address must be under wallet control
+// - address must be taproot address which wallet can spend using key path
+func (w *RpcWalletController) SignBip322Taproot(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	toSpend, err := bip322.GetToSpendTx(msg, address)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to bip322 to spend tx: %w", err)
+	}
+
+	if !txscript.IsPayToTaproot(toSpend.TxOut[0].PkScript) {
+		return nil, fmt.Errorf("Bip322Taproot support only taproot addresses")
+	}
+
+	toSpendhash := toSpend.TxHash()
+
+	toSign := bip322.GetToSignTx(toSpend)
+
+	// taproot sighash commits to all spent outputs, so wallet must be given
+	// the output of to spend transaction
+	amt := float64(0)
+	signed, err := awaitRpc(ctx, func() (signedTx, error) {
+		signed, complete, err := w.SignRawTransactionWithWallet2(toSign, []btcjson.RawTxWitnessInput{
+			{
+				Txid:         toSpendhash.String(),
+				Vout:         0,
+				ScriptPubKey: hex.EncodeToString(toSpend.TxOut[0].PkScript),
+				Amount:       &amt,
+			},
+		})
+		return signedTx{tx: signed, complete: complete}, err
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign raw transaction while creating bip322 signature: %w", err)
+	}
+
+	if !signed.complete {
+		return nil, fmt.Errorf("failed to create bip322 signature, address %s is not under wallet control", address)
+	}
+
+	witness := signed.tx.TxIn[0].Witness
+
+	// wallet may only know the key of one of the script leaves, in which case
+	// it produces script path spend
+	if err := checkTaprootKeySpendWitness(witness, address); err != nil {
+		return nil, err
+	}
+
+	return witness, nil
 }
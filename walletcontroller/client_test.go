@@ -0,0 +1,295 @@
+package walletcontroller
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveXpubPubKeys(t *testing.T) {
+	seed, err := hdkeychain.GenerateSeed(hdkeychain.RecommendedSeedLen)
+	require.NoError(t, err)
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	xpub, err := master.Neuter()
+	require.NoError(t, err)
+
+	pubKeys, err := deriveXpubPubKeys(xpub, 5, 15)
+	require.NoError(t, err)
+	require.Len(t, pubKeys, 10)
+
+	// keys must match keys derived from private master key
+	child, err := master.Derive(14)
+	require.NoError(t, err)
+	privKey, err := child.ECPrivKey()
+	require.NoError(t, err)
+	require.True(t, privKey.PubKey().IsEqual(pubKeys[9]))
+
+	_, err = deriveXpubPubKeys(master, 0, 10)
+	require.Error(t, err)
+
+	_, err = deriveXpubPubKeys(xpub, 10, 10)
+	require.Error(t, err)
+
+	_, err = deriveXpubPubKeys(xpub, 0, hdkeychain.HardenedKeyStart+1)
+	require.Error(t, err)
+}
+
+func TestAddressPublicKeyRetriedOnTransientErrors(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKeyHex := hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+		&chaincfg.RegressionNetParams,
+	)
+	require.NoError(t, err)
+
+	writeResult := func(t *testing.T, w http.ResponseWriter, id interface{}, result interface{}, rpcErr *btcjson.RPCError) {
+		err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": result,
+			"error":  rpcErr,
+			"id":     id,
+		})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name             string
+		failFirstRequest func(t *testing.T, w http.ResponseWriter, id interface{})
+		expectedRequests int32
+		expectErr        bool
+	}{
+		{
+			name: "connection dropped while sending response",
+			failFirstRequest: func(t *testing.T, w http.ResponseWriter, id interface{}) {
+				hj, ok := w.(http.Hijacker)
+				require.True(t, ok)
+				conn, buf, err := hj.Hijack()
+				require.NoError(t, err)
+				_, err = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\n{\"result\":")
+				require.NoError(t, err)
+				require.NoError(t, buf.Flush())
+				conn.Close()
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "node work queue exceeded",
+			failFirstRequest: func(t *testing.T, w http.ResponseWriter, id interface{}) {
+				http.Error(w, "Work queue depth exceeded", http.StatusServiceUnavailable)
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "node warming up",
+			failFirstRequest: func(t *testing.T, w http.ResponseWriter, id interface{}) {
+				writeResult(t, w, id, nil, btcjson.NewRPCError(btcjson.ErrRPCInWarmup, "Loading wallet..."))
+			},
+			expectedRequests: 2,
+		},
+		{
+			name: "wallet rejected request",
+			failFirstRequest: func(t *testing.T, w http.ResponseWriter, id interface{}) {
+				writeResult(t, w, id, nil, btcjson.NewRPCError(btcjson.ErrRPCInvalidAddressOrKey, "Invalid address"))
+			},
+			expectedRequests: 1,
+			expectErr:        true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req btcjson.Request
+				err := json.NewDecoder(r.Body).Decode(&req)
+				require.NoError(t, err)
+				require.Equal(t, "getaddressinfo", req.Method)
+
+				if requests.Add(1) == 1 {
+					tc.failFirstRequest(t, w, req.ID)
+					return
+				}
+
+				writeResult(t, w, req.ID, map[string]interface{}{"pubkey": pubKeyHex}, nil)
+			}))
+			defer server.Close()
+
+			wc, err := NewRpcWalletControllerFromArgs(
+				strings.TrimPrefix(server.URL, "http://"),
+				"user",
+				"pass",
+				"",
+				chaincfg.RegressionNetParams.Name,
+				"",
+				types.BitcoindWalletBackend,
+				&chaincfg.RegressionNetParams,
+				true,
+				"",
+				"",
+				3,
+				10*time.Millisecond,
+				ChangePolicy{},
+				FeeLimits{},
+				types.LargestFirstCoinSelection,
+			)
+			require.NoError(t, err)
+			defer wc.Shutdown()
+
+			pubKey, err := wc.AddressPublicKey(addr)
+			require.Equal(t, tc.expectedRequests, requests.Load())
+
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, privKey.PubKey().IsEqual(pubKey))
+		})
+	}
+}
+
+func TestCheckTaprootKeySpendWitness(t *testing.T) {
+	addr, err := btcutil.NewAddressTaproot(make([]byte, 32), &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+
+	require.NoError(t, checkTaprootKeySpendWitness(wire.TxWitness{make([]byte, 64)}, addr))
+	// signature with explicit sighash type
+	require.NoError(t, checkTaprootKeySpendWitness(wire.TxWitness{make([]byte, 65)}, addr))
+
+	// script path spend: signature, leaf script and control block
+	scriptPathWitness := wire.TxWitness{make([]byte, 64), make([]byte, 34), make([]byte, 33)}
+	require.ErrorIs(t, checkTaprootKeySpendWitness(scriptPathWitness, addr), ErrTaprootKeySpendUnavailable)
+	require.ErrorIs(t, checkTaprootKeySpendWitness(wire.TxWitness{make([]byte, 72)}, addr), ErrTaprootKeySpendUnavailable)
+}
+
+func TestWalletScopedHost(t *testing.T) {
+	require.Equal(t, "127.0.0.1:18443/wallet/treasury", WalletScopedHost("127.0.0.1:18443", "treasury"))
+	require.Equal(t, "127.0.0.1:18443/wallet/treasury", WalletScopedHost("127.0.0.1:18443/", "treasury"))
+	require.Equal(t, "127.0.0.1:18443/wallet/cold%20wallet", WalletScopedHost("127.0.0.1:18443", "cold wallet"))
+}
+
+func TestGetNewAddress(t *testing.T) {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	p2pkh, err := btcutil.NewAddressPubKeyHash(pubKeyHash, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	mainnetP2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		backend        types.SupportedWalletBackend
+		addrType       types.AddressType
+		walletAddr     btcutil.Address
+		expectedParams []string
+		expectErr      bool
+	}{
+		{
+			name:           "bitcoind bech32 address",
+			backend:        types.BitcoindWalletBackend,
+			addrType:       types.Bech32Address,
+			walletAddr:     p2wpkh,
+			expectedParams: []string{`""`, `"bech32"`},
+		},
+		{
+			name:           "btcwallet legacy address",
+			backend:        types.BtcwalletWalletBackend,
+			addrType:       types.LegacyAddress,
+			walletAddr:     p2pkh,
+			expectedParams: []string{`"default"`, `"legacy"`},
+		},
+		{
+			name:           "wallet returned address of other type",
+			backend:        types.BitcoindWalletBackend,
+			addrType:       types.Bech32mAddress,
+			walletAddr:     p2wpkh,
+			expectedParams: []string{`""`, `"bech32m"`},
+			expectErr:      true,
+		},
+		{
+			name:           "wallet returned address for other network",
+			backend:        types.BitcoindWalletBackend,
+			addrType:       types.Bech32Address,
+			walletAddr:     mainnetP2wpkh,
+			expectedParams: []string{`""`, `"bech32"`},
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req btcjson.Request
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				require.Equal(t, "getnewaddress", req.Method)
+
+				params := make([]string, len(req.Params))
+				for i, param := range req.Params {
+					params[i] = string(param)
+				}
+				require.Equal(t, tc.expectedParams, params)
+
+				err := json.NewEncoder(w).Encode(map[string]interface{}{
+					"result": tc.walletAddr.EncodeAddress(),
+					"error":  nil,
+					"id":     req.ID,
+				})
+				require.NoError(t, err)
+			}))
+			defer server.Close()
+
+			wc, err := NewRpcWalletControllerFromArgs(
+				strings.TrimPrefix(server.URL, "http://"),
+				"user",
+				"pass",
+				"",
+				chaincfg.RegressionNetParams.Name,
+				"",
+				tc.backend,
+				&chaincfg.RegressionNetParams,
+				true,
+				"",
+				"",
+				1,
+				10*time.Millisecond,
+				ChangePolicy{},
+				FeeLimits{},
+				types.LargestFirstCoinSelection,
+			)
+			require.NoError(t, err)
+			defer wc.Shutdown()
+
+			addr, err := wc.GetNewAddress(tc.addrType)
+
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.walletAddr.EncodeAddress(), addr.EncodeAddress())
+		})
+	}
+}
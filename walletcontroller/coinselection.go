@@ -0,0 +1,175 @@
+package walletcontroller
+
+import (
+	"sort"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+)
+
+// maxBranchAndBoundTries bounds number of visited nodes of branch and bound
+// search tree, so that selection from big wallets terminates quickly
+const maxBranchAndBoundTries = 100000
+
+// sortUtxos returns copy of utxos in the order they should be used to fund
+// transaction according to strategy
+func sortUtxos(utxos []Utxo, strategy types.CoinSelectionStrategy) []Utxo {
+	sorted := make([]Utxo, len(utxos))
+	copy(sorted, utxos)
+
+	if strategy == types.SmallestFirstCoinSelection {
+		sort.Stable(byAmount(sorted))
+	} else {
+		sort.Stable(sort.Reverse(byAmount(sorted)))
+	}
+
+	return sorted
+}
+
+// dustThreshold returns the smallest value of output with given script which is
+// not considered dust at default relay fee. txrules.IsDustOutput treats output
+// as dust if its value is below mempool.GetDustThreshold, which assumes the
+// default relay fee of 1000 sat/kvB.
+func dustThreshold(pkScript []byte) btcutil.Amount {
+	return btcutil.Amount(mempool.GetDustThreshold(wire.NewTxOut(0, pkScript)))
+}
+
+// changeThreshold returns the smallest change for which change output with
+// given script is created. Smaller change is added to the fee.
+func changeThreshold(changeScript []byte, minChange btcutil.Amount) btcutil.Amount {
+	threshold := dustThreshold(changeScript)
+	if minChange > threshold {
+		return minChange
+	}
+
+	return threshold
+}
+
+// branchAndBound searches for subset of values which sums to at least target and
+// at most target+window. Values must be sorted from highest to lowest. It returns
+// indexes of the subset with the smallest excess found within the tries limit,
+// or nil if there is no such subset.
+func branchAndBound(values []btcutil.Amount, target, window btcutil.Amount) []int {
+	// remaining[i] is sum of values[i:], used to prune branches which cannot
+	// reach the target
+	remaining := make([]btcutil.Amount, len(values)+1)
+	for i := len(values) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + values[i]
+	}
+
+	var (
+		best       []int
+		bestExcess btcutil.Amount
+		selected   []int
+		tries      int
+	)
+
+	var search func(idx int, sum btcutil.Amount) bool
+	search = func(idx int, sum btcutil.Amount) bool {
+		tries++
+
+		if sum > target+window {
+			return false
+		}
+
+		if sum >= target {
+			excess := sum - target
+			if best == nil || excess < bestExcess {
+				best = append([]int(nil), selected...)
+				bestExcess = excess
+			}
+			// exact match cannot be improved
+			return excess == 0
+		}
+
+		if idx == len(values) || sum+remaining[idx] < target || tries >= maxBranchAndBoundTries {
+			return false
+		}
+
+		// include value first, so that solutions with fewer inputs are found
+		// earlier
+		selected = append(selected, idx)
+		if search(idx+1, sum+values[idx]) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+
+		return search(idx+1, sum)
+	}
+
+	search(0, 0)
+
+	return best
+}
+
+// changelessTx looks for set of utxos which funds outputs at given fee rate, so
+// that value left after paying the fee is below dust threshold of change output
+// and below minChange. Such value is added to the fee instead of creating
+// change. Returns nil if there is no such set.
+func changelessTx(
+	utxos []Utxo,
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeScript []byte,
+	minChange btcutil.Amount,
+) *wire.MsgTx {
+	// selection is done on effective values i.e values decreased by fee paid
+	// for spending the output
+	candidates := make([]Utxo, 0, len(utxos))
+	values := make([]btcutil.Amount, 0, len(utxos))
+
+	for _, utxo := range sortUtxos(utxos, types.LargestFirstCoinSelection) {
+		inputFee := txrules.FeeForSerializeSize(feeRatePerKb, txsizes.GetMinInputVirtualSize(utxo.PkScript))
+		if utxo.Amount <= inputFee {
+			continue
+		}
+
+		candidates = append(candidates, utxo)
+		values = append(values, utxo.Amount-inputFee)
+	}
+
+	var outputsValue btcutil.Amount
+	for _, out := range outputs {
+		outputsValue += btcutil.Amount(out.Value)
+	}
+
+	// fee of transaction without any inputs
+	target := outputsValue + txrules.FeeForSerializeSize(feeRatePerKb, EstimateTxVirtualSize(nil, outputs, nil))
+	window := changeThreshold(changeScript, minChange) - 1
+
+	selected := branchAndBound(values, target, window)
+
+	if selected == nil {
+		return nil
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevScripts := make([][]byte, 0, len(selected))
+	var inputsValue btcutil.Amount
+
+	for _, idx := range selected {
+		utxo := candidates[idx]
+		tx.AddTxIn(wire.NewTxIn(&utxo.OutPoint, nil, nil))
+		prevScripts = append(prevScripts, utxo.PkScript)
+		inputsValue += utxo.Amount
+	}
+
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+
+	// effective values are estimated per input, so check that the whole
+	// transaction pays required fee and does not leave change
+	fee := txrules.FeeForSerializeSize(feeRatePerKb, EstimateTxVirtualSize(prevScripts, outputs, nil))
+	excess := inputsValue - outputsValue - fee
+
+	if excess < 0 || excess > window {
+		return nil
+	}
+
+	return tx
+}
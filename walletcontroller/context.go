@@ -0,0 +1,37 @@
+package walletcontroller
+
+import (
+	"context"
+)
+
+type rpcResult[T any] struct {
+	res T
+	err error
+}
+
+// awaitRpc runs call and returns its result, or ctx.Err() if ctx is done before
+// wallet responds. Rpc client cannot cancel request which was already sent, so
+// call keeps running in background and its result is discarded. It must be used
+// only with calls which do not change state of the wallet or the node e.g
+// signing.
+func awaitRpc[T any](ctx context.Context, call func() (T, error)) (T, error) {
+	var zero T
+
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	done := make(chan rpcResult[T], 1)
+
+	go func() {
+		res, err := call()
+		done <- rpcResult[T]{res: res, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package walletcontroller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwaitRpc(t *testing.T) {
+	rpcErr := errors.New("rpc failed")
+
+	res, err := awaitRpc(context.Background(), func() (int, error) {
+		return 1, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, res)
+
+	_, err = awaitRpc(context.Background(), func() (int, error) {
+		return 0, rpcErr
+	})
+	require.ErrorIs(t, err, rpcErr)
+
+	// call is not started if context is already done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err = awaitRpc(ctx, func() (int, error) {
+		called = true
+		return 1, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, called)
+
+	// hung call is abandoned once context is done
+	ctx, cancel = context.WithCancel(context.Background())
+	hung := make(chan struct{})
+	defer close(hung)
+
+	started := make(chan struct{})
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	_, err = awaitRpc(ctx, func() (int, error) {
+		close(started)
+		<-hung
+		return 1, nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
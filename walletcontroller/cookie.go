@@ -0,0 +1,31 @@
+package walletcontroller
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readCookieFile parses user and password from cookie file written by bitcoind.
+// Cookie file consists of single line in format user:password.
+func readCookieFile(path string) (string, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open cookie file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan()
+	if err := scanner.Err(); err != nil {
+		return "", "", fmt.Errorf("failed to read cookie file %s: %w", path, err)
+	}
+
+	user, pass, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+	if !found || user == "" || pass == "" {
+		return "", "", fmt.Errorf("malformed cookie file %s", path)
+	}
+
+	return user, pass, nil
+}
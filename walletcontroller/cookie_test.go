@@ -0,0 +1,96 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCookieFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), ".cookie")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReadCookieFile(t *testing.T) {
+	user, pass, err := readCookieFile(writeCookieFile(t, "__cookie__:abc:def\n"))
+	require.NoError(t, err)
+	require.Equal(t, "__cookie__", user)
+	require.Equal(t, "abc:def", pass)
+
+	_, _, err = readCookieFile(writeCookieFile(t, "__cookie__"))
+	require.Error(t, err)
+
+	_, _, err = readCookieFile(writeCookieFile(t, ""))
+	require.Error(t, err)
+
+	_, _, err = readCookieFile(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestRpcWalletControllerCookieAuth(t *testing.T) {
+	var user, pass string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		user, pass, _ = r.BasicAuth()
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": 100,
+			"error":  nil,
+			"id":     req.ID,
+		}))
+	}))
+	defer server.Close()
+
+	newController := func(user, pass, cookiePath string) (*RpcWalletController, error) {
+		return NewRpcWalletControllerFromArgs(
+			strings.TrimPrefix(server.URL, "http://"),
+			user,
+			pass,
+			cookiePath,
+			chaincfg.RegressionNetParams.Name,
+			"",
+			types.BitcoindWalletBackend,
+			&chaincfg.RegressionNetParams,
+			true,
+			"",
+			"",
+			1,
+			0,
+			ChangePolicy{},
+			FeeLimits{},
+			types.LargestFirstCoinSelection,
+		)
+	}
+
+	wc, err := newController("", "", writeCookieFile(t, "__cookie__:secret"))
+	require.NoError(t, err)
+	_, err = wc.GetBlockCount()
+	require.NoError(t, err)
+	require.Equal(t, "__cookie__", user)
+	require.Equal(t, "secret", pass)
+	wc.Shutdown()
+
+	// invalid cookie file is reported on creation
+	_, err = newController("", "", writeCookieFile(t, "malformed"))
+	require.Error(t, err)
+
+	// explicit credentials win, so cookie file is not read at all
+	wc, err = newController("user", "pass", filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	_, err = wc.GetBlockCount()
+	require.NoError(t, err)
+	require.Equal(t, "user", user)
+	require.Equal(t, "pass", pass)
+	wc.Shutdown()
+}
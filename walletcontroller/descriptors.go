@@ -0,0 +1,227 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	descriptorChecksumLength  = 8
+)
+
+func descriptorPolyMod(c uint64, val uint64) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ val
+
+	for i, gen := range []uint64{
+		0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd,
+	} {
+		if c0&(1<<i) != 0 {
+			c ^= gen
+		}
+	}
+
+	return c
+}
+
+// descriptorChecksum computes checksum of output script descriptor as defined
+// in BIP-380
+func descriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	cls := uint64(0)
+	clsCount := 0
+
+	for _, ch := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+
+		if pos < 0 {
+			return "", fmt.Errorf("invalid character %q in descriptor", ch)
+		}
+
+		c = descriptorPolyMod(c, uint64(pos)&31)
+		cls = cls*3 + uint64(pos>>5)
+		clsCount++
+
+		if clsCount == 3 {
+			c = descriptorPolyMod(c, cls)
+			cls = 0
+			clsCount = 0
+		}
+	}
+
+	if clsCount > 0 {
+		c = descriptorPolyMod(c, cls)
+	}
+
+	for i := 0; i < descriptorChecksumLength; i++ {
+		c = descriptorPolyMod(c, 0)
+	}
+
+	c ^= 1
+
+	checksum := make([]byte, descriptorChecksumLength)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+
+	return string(checksum), nil
+}
+
+// withDescriptorChecksum appends checksum to descriptor
+func withDescriptorChecksum(desc string) (string, error) {
+	checksum, err := descriptorChecksum(desc)
+
+	if err != nil {
+		return "", err
+	}
+
+	return desc + "#" + checksum, nil
+}
+
+// verifyDescriptorChecksum checks that descriptor ends with valid checksum
+func verifyDescriptorChecksum(descWithChecksum string) error {
+	desc, checksum, found := strings.Cut(descWithChecksum, "#")
+
+	if !found {
+		return fmt.Errorf("descriptor %s does not have checksum", descWithChecksum)
+	}
+
+	expected, err := descriptorChecksum(desc)
+
+	if err != nil {
+		return err
+	}
+
+	if checksum != expected {
+		return fmt.Errorf("invalid checksum of descriptor %s, expected %s", descWithChecksum, expected)
+	}
+
+	return nil
+}
+
+type listDescriptorsResult struct {
+	WalletName  string `json:"wallet_name"`
+	Descriptors []struct {
+		Desc string `json:"desc"`
+	} `json:"descriptors"`
+}
+
+// ListDescriptors returns output script descriptors of the wallet, with
+// checksums. Private keys are included only if includePrivate is true, which
+// requires unlocked wallet. Only supported by bitcoind descriptor wallets.
+func (w *RpcWalletController) ListDescriptors(includePrivate bool) ([]string, error) {
+	if w.backend != types.BitcoindWalletBackend {
+		return nil, fmt.Errorf("listing descriptors: %w", ErrUnsupportedByBackend)
+	}
+
+	param, err := json.Marshal(includePrivate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := w.Client.RawRequest("listdescriptors", []json.RawMessage{param})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result listDescriptorsResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]string, len(result.Descriptors))
+	for i, d := range result.Descriptors {
+		if err := verifyDescriptorChecksum(d.Desc); err != nil {
+			return nil, fmt.Errorf("wallet returned invalid descriptor: %w", err)
+		}
+
+		descriptors[i] = d.Desc
+	}
+
+	return descriptors, nil
+}
+
+// walletInfoResult contains fields of bitcoind getwalletinfo result, which are
+// missing in btcjson.GetWalletInfoResult
+type walletInfoResult struct {
+	PrivateKeysEnabled bool `json:"private_keys_enabled"`
+	Descriptors        bool `json:"descriptors"`
+}
+
+func (w *RpcWalletController) getWalletInfo() (*walletInfoResult, error) {
+	return retryRead(w, func() (*walletInfoResult, error) {
+		res, err := w.Client.RawRequest("getwalletinfo", nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var info walletInfoResult
+		if err := json.Unmarshal(res, &info); err != nil {
+			return nil, err
+		}
+
+		return &info, nil
+	})
+}
+
+// privKeyDescriptors returns descriptors of p2wpkh and p2tr outputs spendable
+// by the key, as it is not known which of them the key is used for
+func privKeyDescriptors(key *btcutil.WIF) ([]string, error) {
+	if !key.CompressPubKey {
+		return nil, fmt.Errorf("descriptor wallets do not support uncompressed keys")
+	}
+
+	encoded := key.String()
+	descriptors := make([]string, 0, 2)
+
+	for _, desc := range []string{
+		fmt.Sprintf("wpkh(%s)", encoded),
+		fmt.Sprintf("tr(%s)", encoded),
+	} {
+		withChecksum, err := withDescriptorChecksum(desc)
+
+		if err != nil {
+			return nil, err
+		}
+
+		descriptors = append(descriptors, withChecksum)
+	}
+
+	return descriptors, nil
+}
+
+// importPrivKeyDescriptors imports p2wpkh and p2tr descriptors of all keys in
+// single call. If rescan is true, the whole chain is rescanned once all
+// descriptors are imported.
+func (w *RpcWalletController) importPrivKeyDescriptors(keys []*btcutil.WIF, rescan bool) error {
+	var timestamp interface{} = "now"
+	if rescan {
+		timestamp = 0
+	}
+
+	requests := make([]importDescriptorRequest, 0, 2*len(keys))
+	for i, key := range keys {
+		descriptors, err := privKeyDescriptors(key)
+
+		if err != nil {
+			return fmt.Errorf("failed to import private key at index %d: %w", i, err)
+		}
+
+		for _, desc := range descriptors {
+			requests = append(requests, importDescriptorRequest{Desc: desc, Timestamp: timestamp})
+		}
+	}
+
+	return w.importDescriptors(requests)
+}
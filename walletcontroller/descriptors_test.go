@@ -0,0 +1,251 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+// descriptors as returned by regtest bitcoind descriptor wallet, checksums
+// computed with reference implementation from BIP-380
+var regtestDescriptors = []string{
+	"wpkh([d34db33f/84h/1h/0h]tpubD6NzVbkrYhZ4WaWSyoBvQwbpLkojyoTZPRsgXELWz3Popb3qkjcJyJUGLnL4qHHoQvao8ESaAstxYSnhyswJ76uZPStJRJCTKvosUCJZL5B/0/*)#86xgux3k",
+	"tr([d34db33f/86h/1h/0h]tpubD6NzVbkrYhZ4WaWSyoBvQwbpLkojyoTZPRsgXELWz3Popb3qkjcJyJUGLnL4qHHoQvao8ESaAstxYSnhyswJ76uZPStJRJCTKvosUCJZL5B/1/*)#yq980zee",
+}
+
+func TestDescriptorChecksum(t *testing.T) {
+	checksum, err := descriptorChecksum("raw(deadbeef)")
+	require.NoError(t, err)
+	require.Equal(t, "89f8spxm", checksum)
+
+	for _, desc := range regtestDescriptors {
+		require.NoError(t, verifyDescriptorChecksum(desc))
+	}
+
+	require.Error(t, verifyDescriptorChecksum("raw(deadbeef)"))
+	require.Error(t, verifyDescriptorChecksum("raw(deadbeef)#89f8spxn"))
+	require.Error(t, verifyDescriptorChecksum("raw(deadbeee)#89f8spxm"))
+}
+
+func makeDescriptorsTestController(
+	t *testing.T,
+	backend types.SupportedWalletBackend,
+	descriptors []string,
+) *RpcWalletController {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "listdescriptors", req.Method)
+		require.Len(t, req.Params, 1)
+		require.Equal(t, "false", string(req.Params[0]))
+
+		result := listDescriptorsResult{WalletName: "staker"}
+		for _, desc := range descriptors {
+			result.Descriptors = append(result.Descriptors, struct {
+				Desc string `json:"desc"`
+			}{Desc: desc})
+		}
+
+		err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": result,
+			"error":  nil,
+			"id":     req.ID,
+		})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"",
+		backend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		10*time.Millisecond,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	t.Cleanup(wc.Shutdown)
+
+	return wc
+}
+
+func TestListDescriptors(t *testing.T) {
+	wc := makeDescriptorsTestController(t, types.BitcoindWalletBackend, regtestDescriptors)
+	descriptors, err := wc.ListDescriptors(false)
+	require.NoError(t, err)
+	require.Equal(t, regtestDescriptors, descriptors)
+
+	corrupted := strings.Replace(regtestDescriptors[0], "/0/*", "/2/*", 1)
+	wc = makeDescriptorsTestController(t, types.BitcoindWalletBackend, []string{corrupted})
+	_, err = wc.ListDescriptors(false)
+	require.Error(t, err)
+
+	wc = makeDescriptorsTestController(t, types.BtcwalletWalletBackend, regtestDescriptors)
+	_, err = wc.ListDescriptors(false)
+	require.ErrorIs(t, err, ErrUnsupportedByBackend)
+}
+
+func TestMemWalletListDescriptors(t *testing.T) {
+	w := makeMemWallet(t)
+
+	descriptors, err := w.ListDescriptors(false)
+	require.NoError(t, err)
+	require.Len(t, descriptors, 4)
+
+	for _, desc := range descriptors {
+		require.NoError(t, verifyDescriptorChecksum(desc))
+		require.NotContains(t, desc, "tprv")
+	}
+
+	descriptors, err = w.ListDescriptors(true)
+	require.NoError(t, err)
+
+	for _, desc := range descriptors {
+		require.NoError(t, verifyDescriptorChecksum(desc))
+		require.Contains(t, desc, "tprv")
+	}
+}
+
+func TestImportPrivKeys(t *testing.T) {
+	keys := make([]*btcutil.WIF, 2)
+	for i := range keys {
+		privKey, err := btcec.NewPrivateKey()
+		require.NoError(t, err)
+		keys[i], err = btcutil.NewWIF(privKey, &chaincfg.RegressionNetParams, true)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name               string
+		backend            types.SupportedWalletBackend
+		descriptors        bool
+		privateKeysEnabled bool
+		expectedMethods    []string
+		expectErr          error
+	}{
+		{
+			name:               "descriptor wallet imports key descriptors",
+			backend:            types.BitcoindWalletBackend,
+			descriptors:        true,
+			privateKeysEnabled: true,
+			expectedMethods:    []string{"getwalletinfo", "importdescriptors"},
+		},
+		{
+			name:               "legacy wallet imports keys",
+			backend:            types.BitcoindWalletBackend,
+			privateKeysEnabled: true,
+			expectedMethods:    []string{"getwalletinfo", "importprivkey", "importprivkey"},
+		},
+		{
+			name:            "watch-only wallet cannot import keys",
+			backend:         types.BitcoindWalletBackend,
+			descriptors:     true,
+			expectedMethods: []string{"getwalletinfo"},
+			expectErr:       ErrWatchOnlyWallet,
+		},
+		{
+			name:            "btcwallet imports keys",
+			backend:         types.BtcwalletWalletBackend,
+			expectedMethods: []string{"importprivkey", "importprivkey"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var methods []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req btcjson.Request
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				methods = append(methods, req.Method)
+
+				var result interface{}
+
+				switch req.Method {
+				case "getwalletinfo":
+					result = walletInfoResult{
+						PrivateKeysEnabled: tc.privateKeysEnabled,
+						Descriptors:        tc.descriptors,
+					}
+				case "importdescriptors":
+					var requests []importDescriptorRequest
+					require.NoError(t, json.Unmarshal(req.Params[0], &requests))
+					require.Len(t, requests, 2*len(keys))
+
+					results := make([]map[string]interface{}, len(requests))
+					for i, r := range requests {
+						require.NoError(t, verifyDescriptorChecksum(r.Desc))
+						require.EqualValues(t, 0, r.Timestamp)
+						results[i] = map[string]interface{}{"success": true}
+					}
+
+					require.True(t, strings.HasPrefix(requests[0].Desc, fmt.Sprintf("wpkh(%s)#", keys[0].String())))
+					require.True(t, strings.HasPrefix(requests[1].Desc, fmt.Sprintf("tr(%s)#", keys[0].String())))
+					result = results
+				case "importprivkey":
+				default:
+					t.Fatalf("unexpected method %s", req.Method)
+				}
+
+				err := json.NewEncoder(w).Encode(map[string]interface{}{
+					"result": result,
+					"error":  nil,
+					"id":     req.ID,
+				})
+				require.NoError(t, err)
+			}))
+			defer server.Close()
+
+			wc, err := NewRpcWalletControllerFromArgs(
+				strings.TrimPrefix(server.URL, "http://"),
+				"user",
+				"pass",
+				"",
+				chaincfg.RegressionNetParams.Name,
+				"",
+				tc.backend,
+				&chaincfg.RegressionNetParams,
+				true,
+				"",
+				"",
+				1,
+				10*time.Millisecond,
+				ChangePolicy{},
+				FeeLimits{},
+				types.LargestFirstCoinSelection,
+			)
+			require.NoError(t, err)
+			defer wc.Shutdown()
+
+			err = wc.ImportPrivKeys(keys, true)
+
+			if tc.expectErr != nil {
+				require.ErrorIs(t, err, tc.expectErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expectedMethods, methods)
+		})
+	}
+}
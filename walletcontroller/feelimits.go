@@ -0,0 +1,66 @@
+package walletcontroller
+
+import (
+	"fmt"
+
+	scfg "github.com/babylonchain/btc-staker/stakercfg"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// FeeLimits bounds fee rate of every transaction created by staker: staking
+// transactions funded by the wallet, child transactions bumping their fee and
+// transactions spending stake
+type FeeLimits struct {
+	// MinFeeRatePerKb is the lowest fee rate in sat/kvB at which transactions
+	// are relayed by the network. Zero disables the floor.
+	MinFeeRatePerKb btcutil.Amount
+	// MaxFeeRatePerKb is the highest fee rate in sat/kvB transaction can pay.
+	// Zero disables the ceiling.
+	MaxFeeRatePerKb btcutil.Amount
+}
+
+// relayFeeLimits only refuses fee rates below default network relay minimum
+var relayFeeLimits = FeeLimits{MinFeeRatePerKb: txrules.DefaultRelayFeePerKb}
+
+func NewFeeLimits(cfg *scfg.BtcNodeBackendConfig) FeeLimits {
+	return FeeLimits{
+		MinFeeRatePerKb: btcutil.Amount(cfg.MinRelayFeeRatePerKb),
+		MaxFeeRatePerKb: btcutil.Amount(cfg.MaxFeeRatePerKb),
+	}
+}
+
+// CheckFeeRate returns ErrFeeRateTooLow if fee rate is below the floor and
+// ErrFeeTooHigh if it is above the ceiling
+func (l FeeLimits) CheckFeeRate(feeRatePerKb btcutil.Amount) error {
+	if feeRatePerKb < l.MinFeeRatePerKb {
+		return fmt.Errorf("fee rate %d sat/kvB is lower than %d sat/kvB: %w",
+			feeRatePerKb, l.MinFeeRatePerKb, ErrFeeRateTooLow)
+	}
+
+	if l.MaxFeeRatePerKb > 0 && feeRatePerKb > l.MaxFeeRatePerKb {
+		return fmt.Errorf("fee rate %d sat/kvB is higher than %d sat/kvB: %w",
+			feeRatePerKb, l.MaxFeeRatePerKb, ErrFeeTooHigh)
+	}
+
+	return nil
+}
+
+// CheckFee returns ErrFeeTooHigh if fee paid by transaction of given virtual
+// size is higher than fee of the same transaction paying the ceiling fee rate.
+// Fee paid by transaction can be higher than fee at requested fee rate e.g when
+// small change is added to the fee.
+func (l FeeLimits) CheckFee(fee btcutil.Amount, vsize int) error {
+	if l.MaxFeeRatePerKb == 0 {
+		return nil
+	}
+
+	maxFee := txrules.FeeForSerializeSize(l.MaxFeeRatePerKb, vsize)
+
+	if fee > maxFee {
+		return fmt.Errorf("transaction fee %d exceeds fee %d at maximum fee rate %d sat/kvB: %w",
+			fee, maxFee, l.MaxFeeRatePerKb, ErrFeeTooHigh)
+	}
+
+	return nil
+}
@@ -1,13 +1,43 @@
 package walletcontroller
 
 import (
+	"context"
+	"errors"
+
+	"github.com/babylonchain/btc-staker/types"
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	notifier "github.com/lightningnetwork/lnd/chainntnfs"
 )
 
+// ErrUnsupportedByBackend is returned when operation is not supported by
+// configured wallet backend
+var ErrUnsupportedByBackend = errors.New("operation not supported by wallet backend")
+
+// ErrTaprootKeySpendUnavailable is returned when wallet cannot spend taproot
+// output using key path, e.g when output commits to scripts only
+var ErrTaprootKeySpendUnavailable = errors.New("taproot output cannot be spent using key path")
+
+// ErrFeeRateTooLow is returned when transaction would be funded with fee rate
+// below network relay minimum, so it would not be relayed by nodes
+var ErrFeeRateTooLow = errors.New("fee rate is below network relay minimum")
+
+// ErrFeeTooHigh is returned when transaction would be funded with fee rate or
+// would pay fee above configured maximum fee rate
+var ErrFeeTooHigh = errors.New("fee exceeds maximum fee rate")
+
+// ErrWatchOnlyWallet is returned when private key is imported into wallet which
+// has private keys disabled
+var ErrWatchOnlyWallet = errors.New("wallet is watch-only and cannot import private keys")
+
+// ErrWalletPassphraseIncorrect is returned when wallet refuses to unlock with
+// provided passphrase
+var ErrWalletPassphraseIncorrect = errors.New("wallet passphrase is incorrect")
+
 type TxStatus int
 
 const (
@@ -18,23 +48,90 @@ const (
 
 type WalletController interface {
 	UnlockWallet(timeoutSecs int64) error
+	// unlocks wallet using provided passphrase instead of the one from config
+	UnlockWalletWithPassphrase(passphrase string, timeoutSecs int64) error
+	LockWallet() error
 	AddressPublicKey(address btcutil.Address) (*btcec.PublicKey, error)
 	DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error)
 	ImportPrivKey(privKeyWIF *btcutil.WIF) error
+	// imports multiple keys, triggering at most one rescan
+	ImportPrivKeys(keys []*btcutil.WIF, rescanAtEnd bool) error
+	// imports first gapLimit public keys derived from xpub as watch-only
+	ImportXpub(xpub *hdkeychain.ExtendedKey, gapLimit uint32, rescan bool) error
+	// imports public keys derived from xpub at indexes [start, end) as watch-only
+	// and rescans the chain
+	RescanAddressRange(xpub *hdkeychain.ExtendedKey, start, end uint32) error
+	// makes sure wallet tracks outputs sent to address, importing it if needed
+	TrackAddress(address btcutil.Address) error
 	NetworkName() string
+	// returns new wallet address of given type, which can be used to receive change
+	NewChangeAddress(addrType types.ChangeAddressType) (btcutil.Address, error)
+	// returns new wallet receive address of given type
+	GetNewAddress(addrType types.AddressType) (btcutil.Address, error)
+	// if signalRbf is true, inputs of created transaction signal opt-in
+	// replace-by-fee (BIP125). Change is handled according to change policy
+	// the wallet was created with
 	CreateTransaction(
 		outputs []*wire.TxOut,
 		feeRatePerKb btcutil.Amount,
-		changeScript btcutil.Address) (*wire.MsgTx, error)
-	SignRawTransaction(tx *wire.MsgTx) (*wire.MsgTx, bool, error)
+		changeScript btcutil.Address,
+		signalRbf bool) (*wire.MsgTx, error)
+	// signs transaction inputs spending wallet outputs. Returns ctx.Err() if
+	// ctx is done before wallet signs the transaction
+	SignRawTransaction(ctx context.Context, tx *wire.MsgTx) (*wire.MsgTx, bool, error)
+	// signs transaction using provided previous outputs for inputs unknown to
+	// the wallet e.g external multisig. If not all inputs are signed,
+	// *IncompleteSigningError carrying partially signed transaction is returned
+	SignRawTransactionWithPrevouts(ctx context.Context, tx *wire.MsgTx, prevOuts []PrevOut) (*wire.MsgTx, bool, error)
+	// reserves outputs, so that transactions created by CreateTransaction do not
+	// spend them until they are unlocked
+	LockOutputs(outpoints []wire.OutPoint) error
+	UnlockOutputs(outpoints []wire.OutPoint) error
 	// requires wallet to be unlocked
 	CreateAndSignTx(
+		ctx context.Context,
 		output []*wire.TxOut,
 		feeRatePerKb btcutil.Amount,
 		changeAddress btcutil.Address,
+		signalRbf bool,
 	) (*wire.MsgTx, error)
-	SendRawTransaction(tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error)
+	// sends transaction to the network. Returns ctx.Err() if ctx is done before
+	// transaction is sent, broadcast which reached the node is always awaited
+	SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error)
+	// checks whether node would accept transaction to its mempool without
+	// broadcasting it. Returns reject reason reported by the node if it would
+	// not. Returns ErrUnsupportedByBackend if backend cannot run the check
+	TestMempoolAccept(tx *wire.MsgTx) (accepted bool, rejectReason string, err error)
+	// creates unsigned transaction funded by wallet outputs as BIP174 psbt
+	// packet, with witness utxo and key derivation info of each input, so that
+	// it can be signed by offline signer
+	CreateStakingPsbt(
+		outputs []*wire.TxOut,
+		feeRatePerKb btcutil.Amount,
+		changeAddress btcutil.Address,
+	) (*psbt.Packet, error)
+	// finalizes fully signed psbt packet, extracts final transaction and sends it
+	FinalizeAndSendPsbt(ctx context.Context, packet *psbt.Packet) (*chainhash.Hash, error)
 	ListOutputs(onlySpendable bool) ([]Utxo, error)
+	// returns all wallet unspent outputs, including outputs locked by the wallet
+	ListOutputsDetails() ([]UtxoDetails, error)
+	// returns balance of the wallet which can be spent and balance of
+	// unconfirmed transactions received from outside of the wallet
+	GetBalance() (spendable btcutil.Amount, unconfirmed btcutil.Amount, err error)
+	// returns wallet balance at given block height, reconstructed from wallet
+	// transaction history. Requires node with enabled transaction index or
+	// wallet with complete transaction history
+	GetBalanceAtHeight(height int32) (btcutil.Amount, error)
+	// returns output script descriptors of the wallet with checksums, private
+	// keys are included only if includePrivate is true
+	ListDescriptors(includePrivate bool) ([]string, error)
+	// returns height of the best block of the btc node backing the wallet
+	NodeBestHeight() (int64, error)
 	TxDetails(txHash *chainhash.Hash, pkScript []byte) (*notifier.TxConfirmation, TxStatus, error)
-	SignBip322NativeSegwit(msg []byte, address btcutil.Address) (wire.TxWitness, error)
+	// returns true if output of transaction included in chain was spent by confirmed transaction
+	OutputSpent(txHash *chainhash.Hash, outputIdx uint32) (bool, error)
+	SignBip322NativeSegwit(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error)
+	// produces bip322 key path spend witness for taproot address. Returns
+	// ErrTaprootKeySpendUnavailable if wallet cannot spend address using key path
+	SignBip322Taproot(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error)
 }
@@ -0,0 +1,82 @@
+package walletcontroller
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/babylonchain/btc-staker/utils"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestMempoolAccept checks whether node would accept transaction to its mempool,
+// without broadcasting it. If transaction is rejected, reject reason reported
+// by the node is returned verbatim.
+// Only supported by bitcoind backend. For btcwallet backend connected to btcd,
+// ErrUnsupportedByBackend is returned and caller should fall back to sending the
+// transaction and handling the broadcast error.
+func (w *RpcWalletController) TestMempoolAccept(tx *wire.MsgTx) (bool, string, error) {
+	if w.backend != types.BitcoindWalletBackend {
+		return false, "", fmt.Errorf("testing mempool acceptance: %w", ErrUnsupportedByBackend)
+	}
+
+	serializedTx, err := utils.SerializeBtcTransaction(tx)
+
+	if err != nil {
+		return false, "", err
+	}
+
+	rawTxs, err := json.Marshal([]string{hex.EncodeToString(serializedTx)})
+
+	if err != nil {
+		return false, "", err
+	}
+
+	// zero disables node fee rate limit, as staking transactions are sent with
+	// high fees allowed and their fee rate is checked when they are funded
+	maxFeeRate, err := json.Marshal(0)
+
+	if err != nil {
+		return false, "", err
+	}
+
+	resp, err := w.Client.RawRequest("testmempoolaccept", []json.RawMessage{rawTxs, maxFeeRate})
+
+	if err != nil {
+		return false, "", err
+	}
+
+	var results []btcjson.TestMempoolAcceptResult
+
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return false, "", err
+	}
+
+	if len(results) != 1 {
+		return false, "", fmt.Errorf("unexpected number of testmempoolaccept results: %d", len(results))
+	}
+
+	return results[0].Allowed, results[0].RejectReason, nil
+}
+
+// TestMempoolAccept checks transaction against outputs known to memory wallet
+// i.e that it was not sent before and does not spend already spent output.
+// Reject reasons mimic the ones reported by bitcoind.
+func (w *MemWalletController) TestMempoolAccept(tx *wire.MsgTx) (bool, string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, found := w.txs[tx.TxHash()]; found {
+		return false, "txn-already-in-mempool", nil
+	}
+
+	for _, in := range tx.TxIn {
+		if _, spent := w.spentBy[in.PreviousOutPoint]; spent {
+			return false, "txn-mempool-conflict", nil
+		}
+	}
+
+	return true, "", nil
+}
@@ -0,0 +1,130 @@
+package walletcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func makeMempoolAcceptTestController(
+	t *testing.T,
+	backend types.SupportedWalletBackend,
+	result btcjson.TestMempoolAcceptResult,
+) *RpcWalletController {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "testmempoolaccept", req.Method)
+		require.Len(t, req.Params, 2)
+
+		var rawTxs []string
+		require.NoError(t, json.Unmarshal(req.Params[0], &rawTxs))
+		require.Len(t, rawTxs, 1)
+		require.Equal(t, "0", string(req.Params[1]))
+
+		err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": []btcjson.TestMempoolAcceptResult{result},
+			"error":  nil,
+			"id":     req.ID,
+		})
+		require.NoError(t, err)
+	}))
+	t.Cleanup(server.Close)
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"",
+		backend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		10*time.Millisecond,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	t.Cleanup(wc.Shutdown)
+
+	return wc
+}
+
+func TestTestMempoolAccept(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+	wc := makeMempoolAcceptTestController(t, types.BitcoindWalletBackend, btcjson.TestMempoolAcceptResult{
+		Txid:    tx.TxHash().String(),
+		Allowed: true,
+	})
+	accepted, rejectReason, err := wc.TestMempoolAccept(tx)
+	require.NoError(t, err)
+	require.True(t, accepted)
+	require.Empty(t, rejectReason)
+
+	wc = makeMempoolAcceptTestController(t, types.BitcoindWalletBackend, btcjson.TestMempoolAcceptResult{
+		Txid:         tx.TxHash().String(),
+		Allowed:      false,
+		RejectReason: "min relay fee not met, 100 < 141",
+	})
+	accepted, rejectReason, err = wc.TestMempoolAccept(tx)
+	require.NoError(t, err)
+	require.False(t, accepted)
+	require.Equal(t, "min relay fee not met, 100 < 141", rejectReason)
+
+	wc = makeMempoolAcceptTestController(t, types.BtcwalletWalletBackend, btcjson.TestMempoolAcceptResult{})
+	_, _, err = wc.TestMempoolAccept(tx)
+	require.ErrorIs(t, err, ErrUnsupportedByBackend)
+}
+
+func TestMemWalletTestMempoolAccept(t *testing.T) {
+	w := makeMemWallet(t)
+
+	fundAddr, err := w.NewAddress()
+	require.NoError(t, err)
+	_, err = w.Fund(fundAddr, 60000)
+	require.NoError(t, err)
+
+	changeAddr, err := w.NewChangeAddress(types.P2WPKHChangeAddress)
+	require.NoError(t, err)
+
+	stakingTx, err := w.CreateAndSignTx(context.Background(), []*wire.TxOut{makeStakingOutput(t, 40000)}, 2000, changeAddr, false)
+	require.NoError(t, err)
+
+	accepted, rejectReason, err := w.TestMempoolAccept(stakingTx)
+	require.NoError(t, err)
+	require.True(t, accepted)
+	require.Empty(t, rejectReason)
+
+	_, err = w.SendRawTransaction(context.Background(), stakingTx, true)
+	require.NoError(t, err)
+
+	accepted, rejectReason, err = w.TestMempoolAccept(stakingTx)
+	require.NoError(t, err)
+	require.False(t, accepted)
+	require.Equal(t, "txn-already-in-mempool", rejectReason)
+
+	conflictingTx := stakingTx.Copy()
+	conflictingTx.TxOut[0].Value--
+	accepted, rejectReason, err = w.TestMempoolAccept(conflictingTx)
+	require.NoError(t, err)
+	require.False(t, accepted)
+	require.Equal(t, "txn-mempool-conflict", rejectReason)
+}
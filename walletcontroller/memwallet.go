@@ -0,0 +1,952 @@
+package walletcontroller
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/babylonchain/babylon/crypto/bip322"
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	notifier "github.com/lightningnetwork/lnd/chainntnfs"
+)
+
+const (
+	// bip84 purpose, keys are used for both p2wpkh and p2tr key spend addresses
+	memWalletPurpose = 84
+
+	memWalletExternalChain = 0
+	memWalletInternalChain = 1
+)
+
+// memWalletKey is a key known to in-memory wallet. Private key is nil for
+// watch-only keys.
+type memWalletKey struct {
+	privKey *btcec.PrivateKey
+	pubKey  *btcec.PublicKey
+	// derivation path from master key, nil for imported keys
+	path []uint32
+}
+
+type memWalletUtxo struct {
+	utxo Utxo
+	// height of the block which included transaction creating the output, -1 if
+	// transaction is still in mempool
+	height int32
+}
+
+type memWalletTx struct {
+	tx        *wire.MsgTx
+	height    int32
+	blockHash chainhash.Hash
+	txIndex   uint32
+}
+
+func (t *memWalletTx) confirmed() bool {
+	return t.height >= 0
+}
+
+// MemWalletController is in-memory wallet with keys deterministically derived
+// from a seed, meant for tests which exercise staking flows without a real
+// node. It tracks its own outputs and a minimal chain: transactions sent through
+// SendRawTransaction land in mempool and are confirmed by MineBlock.
+// Wallet is never encrypted, so lock and unlock operations are no-ops. Imported
+// xpubs are tracked only for transactions sent after the import.
+type MemWalletController struct {
+	mu sync.Mutex
+
+	params            *chaincfg.Params
+	account           *hdkeychain.ExtendedKey
+	masterFingerprint uint32
+	nextIndex         [2]uint32
+
+	// keys by hex encoded pkScript of addresses they control
+	keys map[string]*memWalletKey
+
+	utxos   map[wire.OutPoint]*memWalletUtxo
+	spentBy map[wire.OutPoint]chainhash.Hash
+	txs     map[chainhash.Hash]*memWalletTx
+	mempool []chainhash.Hash
+	height  int32
+
+	// history used to reconstruct balance at past heights
+	credits []historicalCredit
+	history []historicalTx
+
+	fundingTxs uint32
+
+	outputLocks *outputLocks
+}
+
+var _ WalletController = (*MemWalletController)(nil)
+
+// NewMemWalletController creates in-memory wallet with keys derived from seed
+// along m/84'/coin'/0' path. Wallets created from the same seed derive the same
+// sequence of addresses.
+func NewMemWalletController(seed []byte, params *chaincfg.Params) (*MemWalletController, error) {
+	master, err := hdkeychain.NewMaster(seed, params)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key from seed: %w", err)
+	}
+
+	account := master
+	for _, idx := range []uint32{memWalletPurpose, params.HDCoinType, 0} {
+		account, err = account.Derive(hdkeychain.HardenedKeyStart + idx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive account key: %w", err)
+		}
+	}
+
+	masterPubKey, err := master.ECPubKey()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive master public key: %w", err)
+	}
+
+	return &MemWalletController{
+		params:  params,
+		account: account,
+		// fingerprint is first 4 bytes of hash160 of master public key,
+		// serialized as little endian in psbt packets
+		masterFingerprint: binary.LittleEndian.Uint32(btcutil.Hash160(masterPubKey.SerializeCompressed())[:4]),
+		keys:              make(map[string]*memWalletKey),
+		utxos:             make(map[wire.OutPoint]*memWalletUtxo),
+		spentBy:           make(map[wire.OutPoint]chainhash.Hash),
+		txs:               make(map[chainhash.Hash]*memWalletTx),
+		outputLocks:       newOutputLocks(),
+	}, nil
+}
+
+func (w *MemWalletController) p2wpkhAddress(pubKey *btcec.PublicKey) (btcutil.Address, error) {
+	return btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(pubKey.SerializeCompressed()), w.params,
+	)
+}
+
+func (w *MemWalletController) p2trAddress(pubKey *btcec.PublicKey) (btcutil.Address, error) {
+	return btcutil.NewAddressTaproot(
+		schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(pubKey)), w.params,
+	)
+}
+
+// addKey makes wallet track p2wpkh and p2tr addresses of the key
+func (w *MemWalletController) addKey(key *memWalletKey) error {
+	for _, toAddress := range []func(*btcec.PublicKey) (btcutil.Address, error){
+		w.p2wpkhAddress, w.p2trAddress,
+	} {
+		addr, err := toAddress(key.pubKey)
+
+		if err != nil {
+			return err
+		}
+
+		pkScript, err := txscript.PayToAddrScript(addr)
+
+		if err != nil {
+			return err
+		}
+
+		existing, found := w.keys[hex.EncodeToString(pkScript)]
+
+		// do not downgrade owned key to watch-only
+		if found && existing.privKey != nil {
+			continue
+		}
+
+		w.keys[hex.EncodeToString(pkScript)] = key
+	}
+
+	return nil
+}
+
+func (w *MemWalletController) deriveNextKey(chain uint32) (*btcec.PublicKey, error) {
+	chainKey, err := w.account.Derive(chain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		idx := w.nextIndex[chain]
+		w.nextIndex[chain]++
+
+		child, err := chainKey.Derive(idx)
+
+		// skip invalid children, same as other hd wallets
+		if err == hdkeychain.ErrInvalidChild {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		privKey, err := child.ECPrivKey()
+
+		if err != nil {
+			return nil, err
+		}
+
+		path := []uint32{
+			hdkeychain.HardenedKeyStart + memWalletPurpose,
+			hdkeychain.HardenedKeyStart + w.params.HDCoinType,
+			hdkeychain.HardenedKeyStart,
+			chain,
+			idx,
+		}
+
+		if err := w.addKey(&memWalletKey{privKey: privKey, pubKey: privKey.PubKey(), path: path}); err != nil {
+			return nil, err
+		}
+
+		return privKey.PubKey(), nil
+	}
+}
+
+func (w *MemWalletController) keyForScript(pkScript []byte) (*memWalletKey, bool) {
+	key, found := w.keys[hex.EncodeToString(pkScript)]
+	return key, found
+}
+
+// NewAddress returns next p2wpkh address of the external chain
+func (w *MemWalletController) NewAddress() (btcutil.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pubKey, err := w.deriveNextKey(memWalletExternalChain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.p2wpkhAddress(pubKey)
+}
+
+// Fund creates transaction paying amount to given address, from outside of the
+// wallet, and confirms it in a new block.
+func (w *MemWalletController) Fund(address btcutil.Address, amount btcutil.Amount) (*wire.MsgTx, error) {
+	pkScript, err := txscript.PayToAddrScript(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+
+	// every funding transaction spends distinct fake outpoint, so that they
+	// have distinct hashes and do not conflict with each other
+	fundingSource := wire.NewOutPoint(&chainhash.Hash{}, w.fundingTxs)
+	w.fundingTxs++
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(fundingSource, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(int64(amount), pkScript))
+
+	w.mu.Unlock()
+
+	if _, err := w.SendRawTransaction(context.Background(), tx, true); err != nil {
+		return nil, err
+	}
+
+	w.MineBlock()
+
+	return tx, nil
+}
+
+// MineBlock confirms all mempool transactions in a new block and returns its
+// height
+func (w *MemWalletController) MineBlock() int32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.height++
+
+	var heightBytes [4]byte
+	binary.BigEndian.PutUint32(heightBytes[:], uint32(w.height))
+	blockHash := chainhash.DoubleHashH(heightBytes[:])
+
+	for i, txHash := range w.mempool {
+		memTx := w.txs[txHash]
+		memTx.height = w.height
+		memTx.blockHash = blockHash
+		// index 0 is taken by coinbase transaction
+		memTx.txIndex = uint32(i + 1)
+
+		inputs := make([]wire.OutPoint, len(memTx.tx.TxIn))
+		for j, in := range memTx.tx.TxIn {
+			inputs[j] = in.PreviousOutPoint
+		}
+
+		w.history = append(w.history, historicalTx{height: w.height, inputs: inputs})
+
+		for j, out := range memTx.tx.TxOut {
+			outpoint := wire.OutPoint{Hash: txHash, Index: uint32(j)}
+			key, found := w.keyForScript(out.PkScript)
+
+			if !found || key.privKey == nil {
+				continue
+			}
+
+			w.credits = append(w.credits, historicalCredit{
+				outPoint: outpoint,
+				amount:   btcutil.Amount(out.Value),
+				height:   w.height,
+			})
+
+			if utxo, unspent := w.utxos[outpoint]; unspent {
+				utxo.height = w.height
+			}
+		}
+	}
+
+	w.mempool = nil
+
+	return w.height
+}
+
+func (w *MemWalletController) UnlockWallet(timeoutSecs int64) error {
+	return nil
+}
+
+func (w *MemWalletController) UnlockWalletWithPassphrase(passphrase string, timeoutSecs int64) error {
+	return nil
+}
+
+func (w *MemWalletController) LockWallet() error {
+	return nil
+}
+
+func (w *MemWalletController) AddressPublicKey(address btcutil.Address) (*btcec.PublicKey, error) {
+	pkScript, err := txscript.PayToAddrScript(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key, found := w.keyForScript(pkScript)
+
+	if !found {
+		return nil, fmt.Errorf("address %s is not under wallet control", address)
+	}
+
+	return key.pubKey, nil
+}
+
+func (w *MemWalletController) DumpPrivateKey(address btcutil.Address) (*btcec.PrivateKey, error) {
+	pkScript, err := txscript.PayToAddrScript(address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	key, found := w.keyForScript(pkScript)
+
+	if !found || key.privKey == nil {
+		return nil, fmt.Errorf("private key of address %s is not known to the wallet", address)
+	}
+
+	return key.privKey, nil
+}
+
+func (w *MemWalletController) ImportPrivKey(privKeyWIF *btcutil.WIF) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.addKey(&memWalletKey{
+		privKey: privKeyWIF.PrivKey,
+		pubKey:  privKeyWIF.PrivKey.PubKey(),
+	})
+}
+
+func (w *MemWalletController) ImportPrivKeys(keys []*btcutil.WIF, rescanAtEnd bool) error {
+	for i, key := range keys {
+		if err := w.ImportPrivKey(key); err != nil {
+			return fmt.Errorf("failed to import private key at index %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *MemWalletController) ImportXpub(xpub *hdkeychain.ExtendedKey, gapLimit uint32, rescan bool) error {
+	if gapLimit == 0 {
+		return fmt.Errorf("gap limit must be greater than 0")
+	}
+
+	return w.RescanAddressRange(xpub, 0, gapLimit)
+}
+
+func (w *MemWalletController) RescanAddressRange(xpub *hdkeychain.ExtendedKey, start, end uint32) error {
+	pubKeys, err := deriveXpubPubKeys(xpub, start, end)
+
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, pubKey := range pubKeys {
+		if err := w.addKey(&memWalletKey{pubKey: pubKey}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TrackAddress succeeds only for addresses of wallet keys, as in-memory wallet
+// tracks outputs by keys and cannot import bare addresses
+func (w *MemWalletController) TrackAddress(address btcutil.Address) error {
+	pkScript, err := txscript.PayToAddrScript(address)
+
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, found := w.keyForScript(pkScript); found {
+		return nil
+	}
+
+	return fmt.Errorf("importing address %s: %w", address, ErrUnsupportedByBackend)
+}
+
+func (w *MemWalletController) NetworkName() string {
+	return w.params.Name
+}
+
+func (w *MemWalletController) NewChangeAddress(addrType types.ChangeAddressType) (btcutil.Address, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pubKey, err := w.deriveNextKey(memWalletInternalChain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch addrType {
+	case types.P2TRChangeAddress:
+		return w.p2trAddress(pubKey)
+	default:
+		return w.p2wpkhAddress(pubKey)
+	}
+}
+
+// GetNewAddress returns new receive address. Only bech32 and bech32m addresses
+// are supported, as wallet does not track other output types.
+func (w *MemWalletController) GetNewAddress(addrType types.AddressType) (btcutil.Address, error) {
+	if addrType != types.Bech32Address && addrType != types.Bech32mAddress {
+		return nil, fmt.Errorf("generating %s address: %w", addrType, ErrUnsupportedByBackend)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pubKey, err := w.deriveNextKey(memWalletExternalChain)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if addrType == types.Bech32mAddress {
+		return w.p2trAddress(pubKey)
+	}
+
+	return w.p2wpkhAddress(pubKey)
+}
+
+func (w *MemWalletController) spendableUtxos() []Utxo {
+	var utxos []Utxo
+	for _, utxo := range w.utxos {
+		key, found := w.keyForScript(utxo.utxo.PkScript)
+
+		if !found || key.privKey == nil {
+			continue
+		}
+
+		utxos = append(utxos, utxo.utxo)
+	}
+
+	return utxos
+}
+
+func (w *MemWalletController) CreateTransaction(
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeAddress btcutil.Address,
+	signalRbf bool,
+) (*wire.MsgTx, error) {
+	changeScript, err := txscript.PayToAddrScript(changeAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	utxos := w.outputLocks.unlocked(w.spendableUtxos())
+	w.mu.Unlock()
+
+	return buildTxFromOutputs(utxos, outputs, feeRatePerKb, relayFeeLimits, changeScript, ChangePolicy{}, signalRbf, types.LargestFirstCoinSelection)
+}
+
+// SignRawTransaction signs all inputs of the transaction spending p2wpkh or p2tr
+// wallet outputs. Transaction is signed only if all its inputs are known wallet
+// outputs, otherwise unsigned copy is returned with false.
+func (w *MemWalletController) SignRawTransaction(ctx context.Context, tx *wire.MsgTx) (*wire.MsgTx, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	signedTx := tx.Copy()
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	keys := make([]*btcec.PrivateKey, len(tx.TxIn))
+
+	for i, in := range tx.TxIn {
+		utxo, found := w.utxos[in.PreviousOutPoint]
+
+		if !found {
+			return signedTx, false, nil
+		}
+
+		key, found := w.keyForScript(utxo.utxo.PkScript)
+
+		if !found || key.privKey == nil {
+			return signedTx, false, nil
+		}
+
+		keys[i] = key.privKey
+		prevOuts.AddPrevOut(in.PreviousOutPoint, wire.NewTxOut(int64(utxo.utxo.Amount), utxo.utxo.PkScript))
+	}
+
+	sigHashes := txscript.NewTxSigHashes(signedTx, prevOuts)
+
+	for i, in := range signedTx.TxIn {
+		prevOut := prevOuts.FetchPrevOutput(in.PreviousOutPoint)
+
+		var (
+			witness wire.TxWitness
+			err     error
+		)
+
+		switch {
+		case txscript.IsPayToWitnessPubKeyHash(prevOut.PkScript):
+			witness, err = txscript.WitnessSignature(
+				signedTx, sigHashes, i, prevOut.Value, prevOut.PkScript,
+				txscript.SigHashAll, keys[i], true,
+			)
+		case txscript.IsPayToTaproot(prevOut.PkScript):
+			witness, err = txscript.TaprootWitnessSignature(
+				signedTx, sigHashes, i, prevOut.Value, prevOut.PkScript,
+				txscript.SigHashDefault, keys[i],
+			)
+		default:
+			return tx.Copy(), false, nil
+		}
+
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+
+		in.Witness = witness
+	}
+
+	return signedTx, true, nil
+}
+
+func (w *MemWalletController) CreateAndSignTx(
+	ctx context.Context,
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeAddress btcutil.Address,
+	signalRbf bool,
+) (*wire.MsgTx, error) {
+	tx, err := w.CreateTransaction(outputs, feeRatePerKb, changeAddress, signalRbf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, signed, err := w.SignRawTransaction(ctx, tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !signed {
+		return nil, fmt.Errorf("not all transactions inputs could be signed")
+	}
+
+	return signedTx, nil
+}
+
+// CreateStakingPsbt creates unsigned transaction funded by wallet outputs as
+// psbt packet, with witness utxo and key derivation of each input populated
+func (w *MemWalletController) CreateStakingPsbt(
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeAddress btcutil.Address,
+) (*psbt.Packet, error) {
+	tx, err := w.CreateTransaction(outputs, feeRatePerKb, changeAddress, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	packet, err := newUnsignedPsbt(tx, w.spendableUtxos())
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range packet.Inputs {
+		pkScript := packet.Inputs[i].WitnessUtxo.PkScript
+		key, found := w.keyForScript(pkScript)
+
+		if !found || key.path == nil {
+			continue
+		}
+
+		addInputDerivation(&packet.Inputs[i], pkScript, &keyDerivation{
+			pubKey:            key.pubKey,
+			masterFingerprint: w.masterFingerprint,
+			path:              key.path,
+		})
+	}
+
+	return packet, nil
+}
+
+// FinalizeAndSendPsbt finalizes fully signed psbt packet, extracts final
+// transaction and sends it to mempool
+func (w *MemWalletController) FinalizeAndSendPsbt(ctx context.Context, packet *psbt.Packet) (*chainhash.Hash, error) {
+	tx, err := finalizePsbt(packet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.SendRawTransaction(ctx, tx, true)
+}
+
+// SendRawTransaction adds transaction to the mempool. Wallet outputs spent by
+// transaction are removed from the wallet and outputs paying to wallet addresses
+// are added to it. Signatures are not verified.
+func (w *MemWalletController) SendRawTransaction(ctx context.Context, tx *wire.MsgTx, allowHighFees bool) (*chainhash.Hash, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	txHash := tx.TxHash()
+
+	if _, found := w.txs[txHash]; found {
+		return nil, fmt.Errorf("transaction %s already sent", txHash)
+	}
+
+	for _, in := range tx.TxIn {
+		if spender, spent := w.spentBy[in.PreviousOutPoint]; spent {
+			return nil, fmt.Errorf("output %s already spent by transaction %s", in.PreviousOutPoint, spender)
+		}
+	}
+
+	for _, in := range tx.TxIn {
+		w.spentBy[in.PreviousOutPoint] = txHash
+		delete(w.utxos, in.PreviousOutPoint)
+	}
+
+	for i, out := range tx.TxOut {
+		key, found := w.keyForScript(out.PkScript)
+
+		if !found {
+			continue
+		}
+
+		addr, err := w.p2wpkhAddress(key.pubKey)
+
+		if txscript.IsPayToTaproot(out.PkScript) {
+			addr, err = w.p2trAddress(key.pubKey)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		outpoint := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+		w.utxos[outpoint] = &memWalletUtxo{
+			utxo: Utxo{
+				Amount:   btcutil.Amount(out.Value),
+				OutPoint: outpoint,
+				PkScript: out.PkScript,
+				Address:  addr.EncodeAddress(),
+			},
+			height: -1,
+		}
+	}
+
+	w.txs[txHash] = &memWalletTx{tx: tx.Copy(), height: -1}
+	w.mempool = append(w.mempool, txHash)
+
+	return &txHash, nil
+}
+
+func (w *MemWalletController) ListOutputs(onlySpendable bool) ([]Utxo, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if onlySpendable {
+		return w.spendableUtxos(), nil
+	}
+
+	utxos := make([]Utxo, 0, len(w.utxos))
+	for _, utxo := range w.utxos {
+		utxos = append(utxos, utxo.utxo)
+	}
+
+	return utxos, nil
+}
+
+func (w *MemWalletController) ListOutputsDetails() ([]UtxoDetails, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	details := make([]UtxoDetails, 0, len(w.utxos))
+	for _, utxo := range w.utxos {
+		var confirmations int64
+		if utxo.height >= 0 {
+			confirmations = int64(w.height-utxo.height) + 1
+		}
+
+		key, _ := w.keyForScript(utxo.utxo.PkScript)
+
+		details = append(details, UtxoDetails{
+			Utxo:          utxo.utxo,
+			Confirmations: confirmations,
+			Spendable:     key != nil && key.privKey != nil,
+			Locked:        false,
+		})
+	}
+
+	return details, nil
+}
+
+// ListDescriptors returns descriptors of p2wpkh and p2tr addresses derived from
+// wallet account key. Individually imported keys are not included.
+func (w *MemWalletController) ListDescriptors(includePrivate bool) ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	accountKey := w.account
+
+	if !includePrivate {
+		neutered, err := w.account.Neuter()
+
+		if err != nil {
+			return nil, err
+		}
+
+		accountKey = neutered
+	}
+
+	var descriptors []string
+	for _, scriptType := range []string{"wpkh", "tr"} {
+		for _, chain := range []uint32{memWalletExternalChain, memWalletInternalChain} {
+			desc, err := withDescriptorChecksum(
+				fmt.Sprintf("%s(%s/%d/*)", scriptType, accountKey.String(), chain),
+			)
+
+			if err != nil {
+				return nil, err
+			}
+
+			descriptors = append(descriptors, desc)
+		}
+	}
+
+	return descriptors, nil
+}
+
+// NodeBestHeight returns number of blocks mined by memory wallet
+func (w *MemWalletController) NodeBestHeight() (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return int64(w.height), nil
+}
+
+func (w *MemWalletController) TxDetails(txHash *chainhash.Hash, pkScript []byte) (*notifier.TxConfirmation, TxStatus, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	memTx, found := w.txs[*txHash]
+
+	if !found {
+		return nil, TxNotFound, nil
+	}
+
+	if !memTx.confirmed() {
+		return nil, TxInMemPool, nil
+	}
+
+	blockHash := memTx.blockHash
+
+	return &notifier.TxConfirmation{
+		BlockHash:   &blockHash,
+		BlockHeight: uint32(memTx.height),
+		TxIndex:     memTx.txIndex,
+		Tx:          memTx.tx.Copy(),
+	}, TxInChain, nil
+}
+
+// OutputSpent returns true if given output was spent by confirmed transaction.
+// Same as in rpc wallet, outputs of transactions which are not confirmed are
+// also reported as spent.
+func (w *MemWalletController) OutputSpent(txHash *chainhash.Hash, outputIdx uint32) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	memTx, found := w.txs[*txHash]
+
+	if !found || !memTx.confirmed() || int(outputIdx) >= len(memTx.tx.TxOut) {
+		return true, nil
+	}
+
+	spender, spent := w.spentBy[wire.OutPoint{Hash: *txHash, Index: outputIdx}]
+
+	if !spent {
+		return false, nil
+	}
+
+	return w.txs[spender].confirmed(), nil
+}
+
+func (w *MemWalletController) SignBip322NativeSegwit(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	toSpend, err := bip322.GetToSpendTx(msg, address)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to bip322 to spend tx: %w", err)
+	}
+
+	pkScript := toSpend.TxOut[0].PkScript
+
+	if !txscript.IsPayToWitnessPubKeyHash(pkScript) {
+		return nil, fmt.Errorf("Bip322NativeSegwit support only native segwit addresses")
+	}
+
+	w.mu.Lock()
+	key, found := w.keyForScript(pkScript)
+	w.mu.Unlock()
+
+	if !found || key.privKey == nil {
+		return nil, fmt.Errorf("failed to create bip322 signature, address %s is not under wallet control", address)
+	}
+
+	toSign := bip322.GetToSignTx(toSpend)
+
+	prevOuts := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+
+	return txscript.WitnessSignature(
+		toSign, txscript.NewTxSigHashes(toSign, prevOuts), 0, 0, pkScript,
+		txscript.SigHashAll, key.privKey, true,
+	)
+}
+
+// SignBip322Taproot produces bip322 key path spend witness for p2tr wallet
+// address. Wallet p2tr addresses do not commit to any scripts, so all of them
+// can be spent using key path.
+func (w *MemWalletController) SignBip322Taproot(ctx context.Context, msg []byte, address btcutil.Address) (wire.TxWitness, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	toSpend, err := bip322.GetToSpendTx(msg, address)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to bip322 to spend tx: %w", err)
+	}
+
+	pkScript := toSpend.TxOut[0].PkScript
+
+	if !txscript.IsPayToTaproot(pkScript) {
+		return nil, fmt.Errorf("Bip322Taproot support only taproot addresses")
+	}
+
+	w.mu.Lock()
+	key, found := w.keyForScript(pkScript)
+	w.mu.Unlock()
+
+	if !found || key.privKey == nil {
+		return nil, fmt.Errorf("failed to create bip322 signature, address %s is not under wallet control", address)
+	}
+
+	toSign := bip322.GetToSignTx(toSpend)
+
+	prevOuts := txscript.NewCannedPrevOutputFetcher(pkScript, 0)
+
+	return txscript.TaprootWitnessSignature(
+		toSign, txscript.NewTxSigHashes(toSign, prevOuts), 0, 0, pkScript,
+		txscript.SigHashDefault, key.privKey,
+	)
+}
+
+// GetBalance returns sum of confirmed wallet outputs and sum of unconfirmed
+// wallet outputs
+func (w *MemWalletController) GetBalance() (btcutil.Amount, btcutil.Amount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var spendable, unconfirmed btcutil.Amount
+	for _, utxo := range w.spendableUtxos() {
+		if w.utxos[utxo.OutPoint].height >= 0 {
+			spendable += utxo.Amount
+		} else {
+			unconfirmed += utxo.Amount
+		}
+	}
+
+	return spendable, unconfirmed, nil
+}
+
+func (w *MemWalletController) GetBalanceAtHeight(height int32) (btcutil.Amount, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if height < 0 || height > w.height {
+		return 0, fmt.Errorf("invalid height %d, current tip height is %d", height, w.height)
+	}
+
+	return balanceAtHeight(w.credits, w.history, height, w.params.CoinbaseMaturity), nil
+}
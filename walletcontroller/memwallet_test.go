@@ -0,0 +1,263 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+
+	"github.com/babylonchain/btc-staker/types"
+)
+
+var memWalletTestSeed = bytes.Repeat([]byte{0x42}, 32)
+
+func makeMemWallet(t *testing.T) *MemWalletController {
+	w, err := NewMemWalletController(memWalletTestSeed, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	return w
+}
+
+// makeStakingOutput returns taproot output standing in for staking output, from
+// the wallet point of view it is just external output
+func makeStakingOutput(t *testing.T, amount btcutil.Amount) *wire.TxOut {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	pkScript, err := txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(privKey.PubKey()))
+	require.NoError(t, err)
+	return wire.NewTxOut(int64(amount), pkScript)
+}
+
+func verifyTxSignatures(t *testing.T, tx *wire.MsgTx, prevTxs ...*wire.MsgTx) {
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, prevTx := range prevTxs {
+		for i, out := range prevTx.TxOut {
+			prevOuts.AddPrevOut(wire.OutPoint{Hash: prevTx.TxHash(), Index: uint32(i)}, out)
+		}
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range tx.TxIn {
+		prevOut := prevOuts.FetchPrevOutput(in.PreviousOutPoint)
+		require.NotNil(t, prevOut)
+
+		vm, err := txscript.NewEngine(
+			prevOut.PkScript, tx, i, txscript.StandardVerifyFlags, nil,
+			sigHashes, prevOut.Value, prevOuts,
+		)
+		require.NoError(t, err)
+		require.NoError(t, vm.Execute())
+	}
+}
+
+func TestMemWalletDeterministicKeys(t *testing.T) {
+	w1 := makeMemWallet(t)
+	w2 := makeMemWallet(t)
+
+	for i := 0; i < 3; i++ {
+		addr1, err := w1.NewAddress()
+		require.NoError(t, err)
+		addr2, err := w2.NewAddress()
+		require.NoError(t, err)
+		require.Equal(t, addr1.EncodeAddress(), addr2.EncodeAddress())
+
+		key1, err := w1.DumpPrivateKey(addr1)
+		require.NoError(t, err)
+		key2, err := w2.DumpPrivateKey(addr2)
+		require.NoError(t, err)
+		require.Equal(t, key1.Serialize(), key2.Serialize())
+	}
+
+	other, err := NewMemWalletController(bytes.Repeat([]byte{0x43}, 32), &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	addr, err := other.NewAddress()
+	require.NoError(t, err)
+	_, err = w1.AddressPublicKey(addr)
+	require.Error(t, err)
+}
+
+func TestMemWalletStakingTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		changeType types.ChangeAddressType
+		fundP2TR   bool
+	}{
+		{name: "p2wpkh funds", changeType: types.P2WPKHChangeAddress},
+		{name: "p2tr funds", changeType: types.P2TRChangeAddress, fundP2TR: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := makeMemWallet(t)
+
+			fundAddr, err := w.NewAddress()
+			require.NoError(t, err)
+
+			if tt.fundP2TR {
+				pubKey, err := w.AddressPublicKey(fundAddr)
+				require.NoError(t, err)
+				fundAddr, err = w.p2trAddress(pubKey)
+				require.NoError(t, err)
+			}
+
+			fundingTx1, err := w.Fund(fundAddr, 60000)
+			require.NoError(t, err)
+			fundingTx2, err := w.Fund(fundAddr, 70000)
+			require.NoError(t, err)
+
+			balance, unconfirmed, err := w.GetBalance()
+			require.NoError(t, err)
+			require.Equal(t, btcutil.Amount(130000), balance)
+			require.Equal(t, btcutil.Amount(0), unconfirmed)
+
+			changeAddr, err := w.NewChangeAddress(tt.changeType)
+			require.NoError(t, err)
+
+			stakingOutput := makeStakingOutput(t, 100000)
+			stakingTx, err := w.CreateAndSignTx(context.Background(), []*wire.TxOut{stakingOutput}, 2000, changeAddr, false)
+			require.NoError(t, err)
+			require.Len(t, stakingTx.TxIn, 2)
+			verifyTxSignatures(t, stakingTx, fundingTx1, fundingTx2)
+
+			txHash, err := w.SendRawTransaction(context.Background(), stakingTx, true)
+			require.NoError(t, err)
+
+			_, status, err := w.TxDetails(txHash, stakingOutput.PkScript)
+			require.NoError(t, err)
+			require.Equal(t, TxInMemPool, status)
+
+			// spending the same outputs twice is rejected
+			_, err = w.SendRawTransaction(context.Background(), stakingTx, true)
+			require.Error(t, err)
+
+			// change of unconfirmed staking transaction cannot be spent yet
+			balance, unconfirmed, err = w.GetBalance()
+			require.NoError(t, err)
+			require.Equal(t, btcutil.Amount(0), balance)
+			require.Equal(t, btcutil.Amount(stakingTx.TxOut[1].Value), unconfirmed)
+
+			height := w.MineBlock()
+
+			details, status, err := w.TxDetails(txHash, stakingOutput.PkScript)
+			require.NoError(t, err)
+			require.Equal(t, TxInChain, status)
+			require.Equal(t, uint32(height), details.BlockHeight)
+
+			spent, err := w.OutputSpent(txHash, 0)
+			require.NoError(t, err)
+			require.False(t, spent)
+			fundingHash := fundingTx1.TxHash()
+			spent, err = w.OutputSpent(&fundingHash, 0)
+			require.NoError(t, err)
+			require.True(t, spent)
+
+			// only change is left in the wallet
+			utxos, err := w.ListOutputs(true)
+			require.NoError(t, err)
+			require.Len(t, utxos, 1)
+			require.Equal(t, changeAddr.EncodeAddress(), utxos[0].Address)
+
+			fee := btcutil.Amount(130000) - btcutil.Amount(stakingOutput.Value) - utxos[0].Amount
+			require.Greater(t, fee, btcutil.Amount(0))
+
+			balance, unconfirmed, err = w.GetBalance()
+			require.NoError(t, err)
+			require.Equal(t, utxos[0].Amount, balance)
+			require.Equal(t, btcutil.Amount(0), unconfirmed)
+
+			balance, err = w.GetBalanceAtHeight(height - 1)
+			require.NoError(t, err)
+			require.Equal(t, btcutil.Amount(130000), balance)
+		})
+	}
+}
+
+func TestMemWalletDoesNotSignForeignInputs(t *testing.T) {
+	w := makeMemWallet(t)
+	other, err := NewMemWalletController(bytes.Repeat([]byte{0x43}, 32), &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+
+	addr, err := other.NewAddress()
+	require.NoError(t, err)
+	fundingTx, err := other.Fund(addr, 50000)
+	require.NoError(t, err)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: fundingTx.TxHash(), Index: 0}, nil, nil))
+	tx.AddTxOut(makeStakingOutput(t, 40000))
+
+	signedTx, signed, err := w.SignRawTransaction(context.Background(), tx)
+	require.NoError(t, err)
+	require.False(t, signed)
+	require.Empty(t, signedTx.TxIn[0].Witness)
+
+	// watch-only keys can not sign either
+	pubKey, err := other.AddressPublicKey(addr)
+	require.NoError(t, err)
+	require.NoError(t, w.addKey(&memWalletKey{pubKey: pubKey}))
+	_, err = w.DumpPrivateKey(addr)
+	require.Error(t, err)
+}
+
+func TestMemWalletLockedOutputsNotSelected(t *testing.T) {
+	w := makeMemWallet(t)
+
+	addr, err := w.NewAddress()
+	require.NoError(t, err)
+	fundingTx1, err := w.Fund(addr, 100000)
+	require.NoError(t, err)
+	fundingTx2, err := w.Fund(addr, 50000)
+	require.NoError(t, err)
+
+	changeAddr, err := w.NewChangeAddress(types.P2WPKHChangeAddress)
+	require.NoError(t, err)
+
+	outputs := []*wire.TxOut{makeStakingOutput(t, 40000)}
+	largest := wire.OutPoint{Hash: fundingTx1.TxHash(), Index: 0}
+
+	tx, err := w.CreateTransaction(outputs, 2000, changeAddr, false)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 1)
+	require.Equal(t, largest, tx.TxIn[0].PreviousOutPoint)
+
+	require.NoError(t, w.LockOutputs([]wire.OutPoint{largest}))
+
+	tx, err = w.CreateTransaction(outputs, 2000, changeAddr, false)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 1)
+	require.Equal(t, wire.OutPoint{Hash: fundingTx2.TxHash(), Index: 0}, tx.TxIn[0].PreviousOutPoint)
+
+	// remaining output cannot fund bigger transaction
+	_, err = w.CreateTransaction([]*wire.TxOut{makeStakingOutput(t, 60000)}, 2000, changeAddr, false)
+	require.Error(t, err)
+
+	require.NoError(t, w.UnlockOutputs([]wire.OutPoint{largest}))
+
+	tx, err = w.CreateTransaction(outputs, 2000, changeAddr, false)
+	require.NoError(t, err)
+	require.Equal(t, largest, tx.TxIn[0].PreviousOutPoint)
+}
+
+func TestMemWalletGetNewAddress(t *testing.T) {
+	w := makeMemWallet(t)
+
+	addr, err := w.GetNewAddress(types.Bech32Address)
+	require.NoError(t, err)
+	require.IsType(t, &btcutil.AddressWitnessPubKeyHash{}, addr)
+
+	addr, err = w.GetNewAddress(types.Bech32mAddress)
+	require.NoError(t, err)
+	require.IsType(t, &btcutil.AddressTaproot{}, addr)
+
+	// generated addresses are owned by the wallet
+	_, err = w.AddressPublicKey(addr)
+	require.NoError(t, err)
+
+	_, err = w.GetNewAddress(types.LegacyAddress)
+	require.ErrorIs(t, err, ErrUnsupportedByBackend)
+}
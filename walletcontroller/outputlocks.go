@@ -0,0 +1,83 @@
+package walletcontroller
+
+import (
+	"sync"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// outputLocks is in-memory set of wallet outputs reserved by the staker. Locked
+// outputs are not used to fund transactions, so that transactions created
+// concurrently do not spend the same outputs. Locks do not survive restart.
+type outputLocks struct {
+	mu     sync.Mutex
+	locked map[wire.OutPoint]struct{}
+}
+
+func newOutputLocks() *outputLocks {
+	return &outputLocks{
+		locked: make(map[wire.OutPoint]struct{}),
+	}
+}
+
+func (l *outputLocks) lock(outpoints []wire.OutPoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, op := range outpoints {
+		l.locked[op] = struct{}{}
+	}
+}
+
+func (l *outputLocks) unlock(outpoints []wire.OutPoint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, op := range outpoints {
+		delete(l.locked, op)
+	}
+}
+
+// unlocked returns utxos which are not locked
+func (l *outputLocks) unlocked(utxos []Utxo) []Utxo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]Utxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		if _, locked := l.locked[utxo.OutPoint]; locked {
+			continue
+		}
+		result = append(result, utxo)
+	}
+
+	return result
+}
+
+// LockOutputs reserves outputs, so that they are not used to fund transactions
+// created by CreateTransaction until unlocked
+func (w *RpcWalletController) LockOutputs(outpoints []wire.OutPoint) error {
+	w.outputLocks.lock(outpoints)
+	return nil
+}
+
+// UnlockOutputs releases outputs reserved by LockOutputs. Unlocking output which
+// is not locked is no-op.
+func (w *RpcWalletController) UnlockOutputs(outpoints []wire.OutPoint) error {
+	w.outputLocks.unlock(outpoints)
+	return nil
+}
+
+// LockOutputs reserves outputs, so that they are not used to fund transactions
+// created by CreateTransaction until unlocked
+func (w *MemWalletController) LockOutputs(outpoints []wire.OutPoint) error {
+	w.outputLocks.lock(outpoints)
+	return nil
+}
+
+// UnlockOutputs releases outputs reserved by LockOutputs. Unlocking output which
+// is not locked is no-op.
+func (w *MemWalletController) UnlockOutputs(outpoints []wire.OutPoint) error {
+	w.outputLocks.unlock(outpoints)
+	return nil
+}
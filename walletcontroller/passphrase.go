@@ -0,0 +1,80 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// passphraseSource returns wallet passphrase. Each call returns new slice owned
+// by the caller, which should zero it as soon as passphrase is no longer needed.
+type passphraseSource func() ([]byte, error)
+
+// newPassphraseSource chooses where wallet passphrase is read from. Passphrase
+// file takes precedence over environment variable, which takes precedence over
+// passphrase provided directly.
+func newPassphraseSource(passFile, passEnv, pass string) (passphraseSource, error) {
+	switch {
+	case passFile != "":
+		if err := checkPassphraseFilePermissions(passFile); err != nil {
+			return nil, err
+		}
+		// file is re-read on every unlock, so passphrase is not kept in memory
+		// between unlocks and rotated passphrase is picked up without restart
+		return func() ([]byte, error) {
+			return readPassphraseFile(passFile)
+		}, nil
+	case passEnv != "":
+		if _, ok := os.LookupEnv(passEnv); !ok {
+			return nil, fmt.Errorf("wallet passphrase environment variable %s is not set", passEnv)
+		}
+		return func() ([]byte, error) {
+			return []byte(os.Getenv(passEnv)), nil
+		}, nil
+	default:
+		return staticPassphraseSource(pass), nil
+	}
+}
+
+// staticPassphraseSource returns passphrase provided upfront. Copy is returned
+// on each call, so that zeroing it by the caller does not affect later unlocks.
+func staticPassphraseSource(pass string) passphraseSource {
+	stored := []byte(pass)
+	return func() ([]byte, error) {
+		return bytes.Clone(stored), nil
+	}
+}
+
+// checkPassphraseFilePermissions ensures passphrase file exists and is not
+// accessible by anyone other than its owner.
+func checkPassphraseFilePermissions(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to access wallet passphrase file %s: %w", path, err)
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("wallet passphrase file %s must be accessible only by its owner, current permissions %04o",
+			path, info.Mode().Perm())
+	}
+
+	return nil
+}
+
+// readPassphraseFile reads passphrase from the file, stripping trailing line
+// ending. Read buffer is zeroed if it is not returned to the caller.
+func readPassphraseFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet passphrase file %s: %w", path, err)
+	}
+
+	pass := bytes.TrimRight(content, "\r\n")
+	if len(pass) == len(content) {
+		return content, nil
+	}
+
+	trimmed := bytes.Clone(pass)
+	clear(content)
+	return trimmed, nil
+}
@@ -0,0 +1,188 @@
+package walletcontroller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/require"
+)
+
+func writePassphraseFile(t *testing.T, content string, perm os.FileMode) string {
+	path := filepath.Join(t.TempDir(), "walletpass")
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+	// explicit chmod, so that test does not depend on umask
+	require.NoError(t, os.Chmod(path, perm))
+	return path
+}
+
+func readPassphrase(t *testing.T, source passphraseSource) string {
+	pass, err := source()
+	require.NoError(t, err)
+	return string(pass)
+}
+
+func TestPassphraseSourcePriority(t *testing.T) {
+	const envName = "STAKER_TEST_WALLET_PASSPHRASE"
+	t.Setenv(envName, "from-env")
+	passFile := writePassphraseFile(t, "from-file\n", 0600)
+
+	source, err := newPassphraseSource(passFile, envName, "from-config")
+	require.NoError(t, err)
+	require.Equal(t, "from-file", readPassphrase(t, source))
+
+	source, err = newPassphraseSource("", envName, "from-config")
+	require.NoError(t, err)
+	require.Equal(t, "from-env", readPassphrase(t, source))
+
+	source, err = newPassphraseSource("", "", "from-config")
+	require.NoError(t, err)
+	require.Equal(t, "from-config", readPassphrase(t, source))
+
+	// environment variable is re-read on every unlock
+	t.Setenv(envName, "rotated")
+	source, err = newPassphraseSource("", envName, "")
+	require.NoError(t, err)
+	require.Equal(t, "rotated", readPassphrase(t, source))
+
+	// file is re-read on every unlock
+	source, err = newPassphraseSource(passFile, "", "")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(passFile, []byte("rotated\r\n"), 0600))
+	require.Equal(t, "rotated", readPassphrase(t, source))
+}
+
+func TestPassphraseSourceErrors(t *testing.T) {
+	_, err := newPassphraseSource(writePassphraseFile(t, "pass", 0640), "", "")
+	require.ErrorContains(t, err, "accessible only by its owner")
+
+	_, err = newPassphraseSource(writePassphraseFile(t, "pass", 0604), "", "")
+	require.Error(t, err)
+
+	_, err = newPassphraseSource(filepath.Join(t.TempDir(), "missing"), "", "")
+	require.Error(t, err)
+
+	_, err = newPassphraseSource("", "STAKER_TEST_WALLET_PASSPHRASE_UNSET", "")
+	require.ErrorContains(t, err, "is not set")
+}
+
+func TestStaticPassphraseSourceSurvivesZeroing(t *testing.T) {
+	source := staticPassphraseSource("secret")
+
+	pass, err := source()
+	require.NoError(t, err)
+	clear(pass)
+
+	require.Equal(t, "secret", readPassphrase(t, source))
+}
+
+func TestUnlockWalletUsesPassphraseSource(t *testing.T) {
+	var unlockCalls int
+	var lastPassphrase string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "walletpassphrase", req.Method)
+
+		unlockCalls++
+		require.NoError(t, json.Unmarshal(req.Params[0], &lastPassphrase))
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": nil,
+			"error":  nil,
+			"id":     req.ID,
+		}))
+	}))
+	defer server.Close()
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"from-config",
+		types.BitcoindWalletBackend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		0,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	defer wc.Shutdown()
+
+	require.NoError(t, wc.UnlockWallet(10))
+	require.Equal(t, 1, unlockCalls)
+	require.Equal(t, "from-config", lastPassphrase)
+
+	// passphrase is zeroed after each unlock, so source must provide it again
+	require.NoError(t, wc.UnlockWallet(10))
+	require.Equal(t, 2, unlockCalls)
+	require.Equal(t, "from-config", lastPassphrase)
+
+	// empty passphrase means wallet is unlocked externally
+	wc.walletPassphrase = staticPassphraseSource("")
+	require.NoError(t, wc.UnlockWallet(10))
+	require.Equal(t, 2, unlockCalls)
+
+	passFile := writePassphraseFile(t, "from-file\n", 0600)
+	wc.walletPassphrase, err = newPassphraseSource(passFile, "", "")
+	require.NoError(t, err)
+	require.NoError(t, wc.UnlockWallet(10))
+	require.Equal(t, "from-file", lastPassphrase)
+
+	// failure to read passphrase is reported by unlock
+	require.NoError(t, os.Remove(passFile))
+	require.Error(t, wc.UnlockWallet(10))
+}
+
+func TestUnlockWalletReportsIncorrectPassphrase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": nil,
+			"error": btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
+				Message: "Error: The wallet passphrase entered was incorrect.",
+			},
+			"id": req.ID,
+		}))
+	}))
+	defer server.Close()
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"wrong",
+		types.BitcoindWalletBackend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		0,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	defer wc.Shutdown()
+
+	require.ErrorIs(t, wc.UnlockWallet(10), ErrWalletPassphraseIncorrect)
+	require.ErrorIs(t, wc.UnlockWalletWithPassphrase("wrong", 10), ErrWalletPassphraseIncorrect)
+}
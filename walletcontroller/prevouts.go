@@ -0,0 +1,182 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/babylonchain/btc-staker/utils"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PrevOut describes output spent by transaction input, which wallet cannot
+// infer on its own e.g output of multisig funded by multiple parties
+type PrevOut struct {
+	OutPoint wire.OutPoint
+	PkScript []byte
+	Amount   btcutil.Amount
+	// redeem script of p2sh output, including p2sh wrapped segwit output
+	RedeemScript []byte
+	// witness script of p2wsh output
+	WitnessScript []byte
+}
+
+// InputSigningError is error reported by the wallet for single input it could
+// not sign
+type InputSigningError struct {
+	OutPoint wire.OutPoint
+	Error    string
+}
+
+// IncompleteSigningError is returned when wallet could not provide all
+// signatures of the transaction. It carries partially signed transaction, so
+// that it can be passed to other signers.
+type IncompleteSigningError struct {
+	Inputs []InputSigningError
+	Tx     *wire.MsgTx
+}
+
+func (e *IncompleteSigningError) Error() string {
+	if len(e.Inputs) == 0 {
+		return "transaction not fully signed"
+	}
+
+	inputErrs := make([]string, len(e.Inputs))
+	for i, in := range e.Inputs {
+		inputErrs[i] = fmt.Sprintf("%s: %s", in.OutPoint, in.Error)
+	}
+
+	return fmt.Sprintf("transaction not fully signed: %s", strings.Join(inputErrs, "; "))
+}
+
+// SignRawTransactionWithPrevouts signs transaction with the wallet, using provided
+// previous outputs for inputs whose scripts and amounts wallet does not know.
+// If wallet could not sign all inputs, *IncompleteSigningError describing each
+// input which was not signed is returned. Returns ctx.Err() if ctx is done
+// before wallet signs the transaction.
+// Only supported by bitcoind backend.
+func (w *RpcWalletController) SignRawTransactionWithPrevouts(
+	ctx context.Context,
+	tx *wire.MsgTx,
+	prevOuts []PrevOut,
+) (*wire.MsgTx, bool, error) {
+	if w.backend != types.BitcoindWalletBackend {
+		return nil, false, fmt.Errorf("signing with previous outputs: %w", ErrUnsupportedByBackend)
+	}
+
+	serializedTx, err := utils.SerializeBtcTransaction(tx)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	rawTx, err := json.Marshal(hex.EncodeToString(serializedTx))
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	rawInputs, err := json.Marshal(prevOutsToRawInputs(prevOuts))
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := awaitRpc(ctx, func() (json.RawMessage, error) {
+		return w.Client.RawRequest("signrawtransactionwithwallet", []json.RawMessage{rawTx, rawInputs})
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	var result btcjson.SignRawTransactionWithWalletResult
+
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, false, err
+	}
+
+	signedTxBytes, err := hex.DecodeString(result.Hex)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	signedTx := &wire.MsgTx{}
+	if err := signedTx.Deserialize(bytes.NewReader(signedTxBytes)); err != nil {
+		return nil, false, err
+	}
+
+	if result.Complete {
+		return signedTx, true, nil
+	}
+
+	inputErrs, err := inputSigningErrors(result.Errors)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return nil, false, &IncompleteSigningError{Inputs: inputErrs, Tx: signedTx}
+}
+
+func prevOutsToRawInputs(prevOuts []PrevOut) []btcjson.RawTxWitnessInput {
+	inputs := make([]btcjson.RawTxWitnessInput, len(prevOuts))
+
+	for i, prevOut := range prevOuts {
+		amount := prevOut.Amount.ToBTC()
+		inputs[i] = btcjson.RawTxWitnessInput{
+			Txid:         prevOut.OutPoint.Hash.String(),
+			Vout:         prevOut.OutPoint.Index,
+			ScriptPubKey: hex.EncodeToString(prevOut.PkScript),
+			Amount:       &amount,
+		}
+
+		if len(prevOut.RedeemScript) > 0 {
+			redeemScript := hex.EncodeToString(prevOut.RedeemScript)
+			inputs[i].RedeemScript = &redeemScript
+		}
+
+		if len(prevOut.WitnessScript) > 0 {
+			witnessScript := hex.EncodeToString(prevOut.WitnessScript)
+			inputs[i].WitnessScript = &witnessScript
+		}
+	}
+
+	return inputs
+}
+
+func inputSigningErrors(errs []btcjson.SignRawTransactionError) ([]InputSigningError, error) {
+	inputErrs := make([]InputSigningError, len(errs))
+
+	for i, e := range errs {
+		outPoint, err := wire.NewOutPointFromString(fmt.Sprintf("%s:%d", e.TxID, e.Vout))
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid input in signing error: %w", err)
+		}
+
+		inputErrs[i] = InputSigningError{
+			OutPoint: *outPoint,
+			Error:    e.Error,
+		}
+	}
+
+	return inputErrs, nil
+}
+
+// SignRawTransactionWithPrevouts is not supported by memory wallet, which only
+// signs its own p2wpkh and p2tr outputs
+func (w *MemWalletController) SignRawTransactionWithPrevouts(
+	_ context.Context,
+	tx *wire.MsgTx,
+	prevOuts []PrevOut,
+) (*wire.MsgTx, bool, error) {
+	return nil, false, fmt.Errorf("signing with previous outputs: %w", ErrUnsupportedByBackend)
+}
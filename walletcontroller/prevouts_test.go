@@ -0,0 +1,184 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/babylonchain/btc-staker/types"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// signRequest is signrawtransactionwithwallet request received by test server
+type signRequest struct {
+	tx     *wire.MsgTx
+	inputs []btcjson.RawTxWitnessInput
+}
+
+// makeSignWithPrevoutsTestController returns controller connected to server
+// which answers signrawtransactionwithwallet with respond. Received requests
+// are sent to returned channel, so that they are checked by the test goroutine.
+func makeSignWithPrevoutsTestController(
+	t *testing.T,
+	backend types.SupportedWalletBackend,
+	respond func(tx *wire.MsgTx) btcjson.SignRawTransactionWithWalletResult,
+) (*RpcWalletController, <-chan signRequest) {
+	requests := make(chan signRequest, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req btcjson.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) != 2 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var rawTx string
+		var inputs []btcjson.RawTxWitnessInput
+		tx := &wire.MsgTx{}
+		if err := json.Unmarshal(req.Params[0], &rawTx); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		txBytes, err := hex.DecodeString(rawTx)
+		if err == nil {
+			err = tx.Deserialize(bytes.NewReader(txBytes))
+		}
+		if err == nil {
+			err = json.Unmarshal(req.Params[1], &inputs)
+		}
+		if err != nil || req.Method != "signrawtransactionwithwallet" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		requests <- signRequest{tx: tx, inputs: inputs}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": respond(tx),
+			"error":  nil,
+			"id":     req.ID,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	wc, err := NewRpcWalletControllerFromArgs(
+		strings.TrimPrefix(server.URL, "http://"),
+		"user",
+		"pass",
+		"",
+		chaincfg.RegressionNetParams.Name,
+		"",
+		backend,
+		&chaincfg.RegressionNetParams,
+		true,
+		"",
+		"",
+		1,
+		10*time.Millisecond,
+		ChangePolicy{},
+		FeeLimits{},
+		types.LargestFirstCoinSelection,
+	)
+	require.NoError(t, err)
+	t.Cleanup(wc.Shutdown)
+
+	return wc, requests
+}
+
+func serializeTxHex(t *testing.T, tx *wire.MsgTx) string {
+	var buf bytes.Buffer
+	require.NoError(t, tx.Serialize(&buf))
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func TestSignRawTransactionWithPrevouts(t *testing.T) {
+	multisigOutPoint := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 1}
+	walletOutPoint := wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 0}
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&multisigOutPoint, nil, nil))
+	tx.AddTxIn(wire.NewTxIn(&walletOutPoint, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+	prevOuts := []PrevOut{{
+		OutPoint:      multisigOutPoint,
+		PkScript:      []byte{0x00, 0x20, 0xaa},
+		Amount:        150000000,
+		WitnessScript: []byte{0x52, 0xae},
+	}}
+
+	prevOuts = append(prevOuts, PrevOut{
+		OutPoint:     walletOutPoint,
+		PkScript:     []byte{0xa9, 0x14, 0xbb, 0x87},
+		Amount:       20000,
+		RedeemScript: []byte{0x00, 0x14, 0xcc},
+	})
+
+	wc, requests := makeSignWithPrevoutsTestController(t, types.BitcoindWalletBackend,
+		func(tx *wire.MsgTx) btcjson.SignRawTransactionWithWalletResult {
+			signedTx := tx.Copy()
+			signedTx.TxIn[1].Witness = wire.TxWitness{[]byte{0x01}}
+			return btcjson.SignRawTransactionWithWalletResult{
+				Hex:      serializeTxHex(t, signedTx),
+				Complete: false,
+				Errors: []btcjson.SignRawTransactionError{{
+					TxID:  multisigOutPoint.Hash.String(),
+					Vout:  multisigOutPoint.Index,
+					Error: "CHECK(MULTI)SIG failing with non-zero signature (possibly need more signatures)",
+				}},
+			}
+		})
+
+	signedTx, complete, err := wc.SignRawTransactionWithPrevouts(context.Background(), tx, prevOuts)
+	require.False(t, complete)
+	require.Nil(t, signedTx)
+
+	// prevouts are passed to the wallet
+	req := <-requests
+	require.Equal(t, tx.TxHash(), req.tx.TxHash())
+	require.Len(t, req.inputs, 2)
+	require.Equal(t, multisigOutPoint.Hash.String(), req.inputs[0].Txid)
+	require.Equal(t, uint32(1), req.inputs[0].Vout)
+	require.Equal(t, "0020aa", req.inputs[0].ScriptPubKey)
+	require.Equal(t, 1.5, *req.inputs[0].Amount)
+	require.Nil(t, req.inputs[0].RedeemScript)
+	require.Equal(t, "52ae", *req.inputs[0].WitnessScript)
+	require.Equal(t, "a914bb87", req.inputs[1].ScriptPubKey)
+	require.Equal(t, 0.0002, *req.inputs[1].Amount)
+	require.Equal(t, "0014cc", *req.inputs[1].RedeemScript)
+	require.Nil(t, req.inputs[1].WitnessScript)
+
+	// per input errors are returned along with partially signed transaction
+	var incompleteErr *IncompleteSigningError
+	require.ErrorAs(t, err, &incompleteErr)
+	require.Len(t, incompleteErr.Inputs, 1)
+	require.Equal(t, multisigOutPoint, incompleteErr.Inputs[0].OutPoint)
+	require.Contains(t, incompleteErr.Inputs[0].Error, "possibly need more signatures")
+	require.Equal(t, wire.TxWitness{[]byte{0x01}}, incompleteErr.Tx.TxIn[1].Witness)
+
+	wc, _ = makeSignWithPrevoutsTestController(t, types.BitcoindWalletBackend,
+		func(tx *wire.MsgTx) btcjson.SignRawTransactionWithWalletResult {
+			return btcjson.SignRawTransactionWithWalletResult{
+				Hex:      serializeTxHex(t, tx),
+				Complete: true,
+			}
+		})
+	signedTx, complete, err = wc.SignRawTransactionWithPrevouts(context.Background(), tx, prevOuts)
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Equal(t, tx.TxHash(), signedTx.TxHash())
+
+	wc, _ = makeSignWithPrevoutsTestController(t, types.BtcwalletWalletBackend, nil)
+	_, _, err = wc.SignRawTransactionWithPrevouts(context.Background(), tx, prevOuts)
+	require.ErrorIs(t, err, ErrUnsupportedByBackend)
+}
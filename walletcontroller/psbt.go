@@ -0,0 +1,260 @@
+package walletcontroller
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// keyDerivation describes how key controlling wallet output was derived from
+// wallet master key
+type keyDerivation struct {
+	pubKey            *btcec.PublicKey
+	masterFingerprint uint32
+	path              []uint32
+}
+
+// addInputDerivation populates derivation info of psbt input spending output
+// with pkScript. For taproot outputs pubKey is the internal key of the output.
+func addInputDerivation(in *psbt.PInput, pkScript []byte, d *keyDerivation) {
+	if txscript.IsPayToTaproot(pkScript) {
+		xOnlyPubKey := schnorr.SerializePubKey(d.pubKey)
+		in.TaprootInternalKey = xOnlyPubKey
+		in.TaprootBip32Derivation = []*psbt.TaprootBip32Derivation{{
+			XOnlyPubKey:          xOnlyPubKey,
+			MasterKeyFingerprint: d.masterFingerprint,
+			Bip32Path:            d.path,
+		}}
+		return
+	}
+
+	in.Bip32Derivation = []*psbt.Bip32Derivation{{
+		PubKey:               d.pubKey.SerializeCompressed(),
+		MasterKeyFingerprint: d.masterFingerprint,
+		Bip32Path:            d.path,
+	}}
+}
+
+// newUnsignedPsbt creates psbt packet from unsigned transaction spending given
+// wallet utxos and populates witness utxo of each input
+func newUnsignedPsbt(tx *wire.MsgTx, utxos []Utxo) (*psbt.Packet, error) {
+	packet, err := psbt.NewFromUnsignedTx(tx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	utxosByOutpoint := make(map[wire.OutPoint]Utxo, len(utxos))
+	for _, utxo := range utxos {
+		utxosByOutpoint[utxo.OutPoint] = utxo
+	}
+
+	for i, in := range tx.TxIn {
+		utxo, found := utxosByOutpoint[in.PreviousOutPoint]
+
+		if !found {
+			return nil, fmt.Errorf("input %s of transaction is not wallet output", in.PreviousOutPoint)
+		}
+
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(int64(utxo.Amount), utxo.PkScript)
+
+		if len(utxo.RedeemScript) > 0 {
+			packet.Inputs[i].RedeemScript = utxo.RedeemScript
+		}
+	}
+
+	return packet, nil
+}
+
+// finalizePsbt finalizes all inputs of fully signed psbt packet and extracts
+// final transaction
+func finalizePsbt(packet *psbt.Packet) (*wire.MsgTx, error) {
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return nil, fmt.Errorf("failed to finalize psbt: %w", err)
+	}
+
+	tx, err := psbt.Extract(packet)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract transaction from psbt: %w", err)
+	}
+
+	return tx, nil
+}
+
+// parseBip32Path parses derivation path in format m/84'/1'/0'/0/5, hardened
+// indexes can be marked with ' or h
+func parseBip32Path(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %s", path)
+	}
+
+	indexes := make([]uint32, 0, len(parts)-1)
+
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		part = strings.TrimRight(part, "'h")
+
+		idx, err := strconv.ParseUint(part, 10, 31)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %s: %w", path, err)
+		}
+
+		if hardened {
+			idx += hdkeychain.HardenedKeyStart
+		}
+
+		indexes = append(indexes, uint32(idx))
+	}
+
+	return indexes, nil
+}
+
+// addressKeyDerivation returns derivation of the key controlling address based
+// on wallet address info. Returns nil if wallet does not report derivation of
+// the key, e.g when key was imported.
+func addressKeyDerivation(info *btcjson.GetAddressInfoResult) (*keyDerivation, error) {
+	if info.PubKey == nil || info.HDKeyPath == nil || info.HDMasterFingerprint == nil {
+		return nil, nil
+	}
+
+	pubKeyBytes, err := hex.DecodeString(*info.PubKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pubKey *btcec.PublicKey
+
+	if len(pubKeyBytes) == schnorr.PubKeyBytesLen {
+		pubKey, err = schnorr.ParsePubKey(pubKeyBytes)
+	} else {
+		pubKey, err = btcec.ParsePubKey(pubKeyBytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := hex.DecodeString(*info.HDMasterFingerprint)
+
+	if err != nil || len(fingerprint) != 4 {
+		return nil, fmt.Errorf("invalid master key fingerprint %s", *info.HDMasterFingerprint)
+	}
+
+	path, err := parseBip32Path(*info.HDKeyPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyDerivation{
+		pubKey:            pubKey,
+		masterFingerprint: binary.LittleEndian.Uint32(fingerprint),
+		path:              path,
+	}, nil
+}
+
+// CreateStakingPsbt creates unsigned transaction funded by wallet outputs, which
+// sends funds to provided outputs, and returns it as BIP174 psbt packet. Each
+// input has its spent output and derivation of the controlling key populated,
+// so that packet can be signed by offline signer. Derivation is omitted for
+// inputs whose keys are not derived from wallet master key.
+func (w *RpcWalletController) CreateStakingPsbt(
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeAddress btcutil.Address,
+) (*psbt.Packet, error) {
+	utxos, err := w.ListOutputs(true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := w.CreateTransaction(outputs, feeRatePerKb, changeAddress, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	packet, err := newUnsignedPsbt(tx, utxos)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, in := range tx.TxIn {
+		pkScript := packet.Inputs[i].WitnessUtxo.PkScript
+
+		// legacy inputs require whole previous transaction to be signed safely
+		if !txscript.IsWitnessProgram(pkScript) && len(packet.Inputs[i].RedeemScript) == 0 {
+			prevTxHash := in.PreviousOutPoint.Hash
+			prevTx, err := retryRead(w, func() (*btcutil.Tx, error) {
+				return w.GetRawTransaction(&prevTxHash)
+			})
+
+			if err != nil {
+				return nil, err
+			}
+
+			packet.Inputs[i].WitnessUtxo = nil
+			packet.Inputs[i].NonWitnessUtxo = prevTx.MsgTx()
+		}
+
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, w.netParams)
+
+		if err != nil || len(addrs) != 1 {
+			continue
+		}
+
+		encoded := addrs[0].EncodeAddress()
+
+		info, err := retryRead(w, func() (*btcjson.GetAddressInfoResult, error) {
+			return w.GetAddressInfo(encoded)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		derivation, err := addressKeyDerivation(info)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation info of address %s: %w", encoded, err)
+		}
+
+		if derivation != nil {
+			addInputDerivation(&packet.Inputs[i], pkScript, derivation)
+		}
+	}
+
+	return packet, nil
+}
+
+// FinalizeAndSendPsbt finalizes fully signed psbt packet, extracts final
+// transaction and sends it to the network
+func (w *RpcWalletController) FinalizeAndSendPsbt(ctx context.Context, packet *psbt.Packet) (*chainhash.Hash, error) {
+	tx, err := finalizePsbt(packet)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return w.SendRawTransaction(ctx, tx, true)
+}
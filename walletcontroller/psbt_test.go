@@ -0,0 +1,123 @@
+package walletcontroller
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+
+	"github.com/babylonchain/btc-staker/types"
+)
+
+func TestParseBip32Path(t *testing.T) {
+	path, err := parseBip32Path("m/84'/1h/0'/1/5")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{
+		hdkeychain.HardenedKeyStart + 84,
+		hdkeychain.HardenedKeyStart + 1,
+		hdkeychain.HardenedKeyStart,
+		1,
+		5,
+	}, path)
+
+	_, err = parseBip32Path("84'/1'/0'")
+	require.Error(t, err)
+
+	_, err = parseBip32Path("m/x/1")
+	require.Error(t, err)
+}
+
+func TestMemWalletPsbtSignedOffline(t *testing.T) {
+	w := makeMemWallet(t)
+
+	fundAddr, err := w.NewAddress()
+	require.NoError(t, err)
+	fundingTx, err := w.Fund(fundAddr, 60000)
+	require.NoError(t, err)
+
+	changeAddr, err := w.NewChangeAddress(types.P2WPKHChangeAddress)
+	require.NoError(t, err)
+
+	packet, err := w.CreateStakingPsbt(
+		[]*wire.TxOut{makeStakingOutput(t, 40000)}, 2000, changeAddr,
+	)
+	require.NoError(t, err)
+	require.Len(t, packet.Inputs, 1)
+
+	in := &packet.Inputs[0]
+	require.NotNil(t, in.WitnessUtxo)
+	require.Len(t, in.Bip32Derivation, 1)
+
+	// offline signer holding the seed derives signing key from derivation info
+	// in the packet
+	master, err := hdkeychain.NewMaster(memWalletTestSeed, &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	masterPubKey, err := master.ECPubKey()
+	require.NoError(t, err)
+
+	derivation := in.Bip32Derivation[0]
+	require.Equal(t,
+		btcutil.Hash160(masterPubKey.SerializeCompressed())[:4],
+		binary.LittleEndian.AppendUint32(nil, derivation.MasterKeyFingerprint),
+	)
+
+	key := master
+	for _, idx := range derivation.Bip32Path {
+		key, err = key.Derive(idx)
+		require.NoError(t, err)
+	}
+	privKey, err := key.ECPrivKey()
+	require.NoError(t, err)
+	require.Equal(t, derivation.PubKey, privKey.PubKey().SerializeCompressed())
+
+	tx := packet.UnsignedTx
+	prevOuts := txscript.NewCannedPrevOutputFetcher(in.WitnessUtxo.PkScript, in.WitnessUtxo.Value)
+	sig, err := txscript.RawTxInWitnessSignature(
+		tx, txscript.NewTxSigHashes(tx, prevOuts), 0, in.WitnessUtxo.Value,
+		in.WitnessUtxo.PkScript, txscript.SigHashAll, privKey,
+	)
+	require.NoError(t, err)
+
+	in.PartialSigs = []*psbt.PartialSig{{
+		PubKey:    derivation.PubKey,
+		Signature: sig,
+	}}
+
+	txHash, err := w.FinalizeAndSendPsbt(context.Background(), packet)
+	require.NoError(t, err)
+
+	_, status, err := w.TxDetails(txHash, tx.TxOut[0].PkScript)
+	require.NoError(t, err)
+	require.Equal(t, TxInMemPool, status)
+
+	signedTx, err := psbt.Extract(packet)
+	require.NoError(t, err)
+	verifyTxSignatures(t, signedTx, fundingTx)
+}
+
+func TestFinalizePsbtRequiresSignatures(t *testing.T) {
+	w := makeMemWallet(t)
+
+	fundAddr, err := w.NewAddress()
+	require.NoError(t, err)
+	_, err = w.Fund(fundAddr, 60000)
+	require.NoError(t, err)
+
+	changeAddr, err := w.NewChangeAddress(types.P2TRChangeAddress)
+	require.NoError(t, err)
+
+	packet, err := w.CreateStakingPsbt(
+		[]*wire.TxOut{makeStakingOutput(t, 40000)}, 2000, changeAddr,
+	)
+	require.NoError(t, err)
+
+	_, err = w.FinalizeAndSendPsbt(context.Background(), packet)
+	require.Error(t, err)
+}
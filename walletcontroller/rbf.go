@@ -0,0 +1,168 @@
+package walletcontroller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// replacementTx builds unsigned replacement of signed transaction oldTx which
+// spends the same inputs, with values given in prevOutValues, and pays fee at
+// newFeeRatePerKb. Fee increase is subtracted from output with index changeIdx.
+// Replacement must follow BIP125 rules i.e pay strictly higher absolute fee than
+// original transaction and pay for its own bandwidth at minimum relay fee rate.
+func replacementTx(
+	oldTx *wire.MsgTx,
+	prevOutValues []btcutil.Amount,
+	changeIdx int,
+	newFeeRatePerKb btcutil.Amount,
+) (*wire.MsgTx, error) {
+	if len(prevOutValues) != len(oldTx.TxIn) {
+		return nil, fmt.Errorf("expected %d input values, got %d", len(oldTx.TxIn), len(prevOutValues))
+	}
+
+	if changeIdx < 0 || changeIdx >= len(oldTx.TxOut) {
+		return nil, fmt.Errorf("invalid change output index %d", changeIdx)
+	}
+
+	var inputsValue btcutil.Amount
+	for _, value := range prevOutValues {
+		inputsValue += value
+	}
+
+	var outputsValue btcutil.Amount
+	for _, out := range oldTx.TxOut {
+		outputsValue += btcutil.Amount(out.Value)
+	}
+
+	oldFee := inputsValue - outputsValue
+
+	// replacement has the same inputs and outputs, so its size is equal to size
+	// of the original signed transaction
+	vsize := int(mempool.GetTxVirtualSize(btcutil.NewTx(oldTx)))
+	newFee := txrules.FeeForSerializeSize(newFeeRatePerKb, vsize)
+
+	if newFee <= oldFee {
+		return nil, fmt.Errorf("replacement fee %d at fee rate %d sat/kvB must be higher than fee %d of original transaction",
+			newFee, newFeeRatePerKb, oldFee)
+	}
+
+	feeIncrease := newFee - oldFee
+	minFeeIncrease := txrules.FeeForSerializeSize(txrules.DefaultRelayFeePerKb, vsize)
+
+	if feeIncrease < minFeeIncrease {
+		return nil, fmt.Errorf("replacement must pay for its own bandwidth. Fee increase %d is lower than %d required at minimum relay fee rate %d sat/kvB",
+			feeIncrease, minFeeIncrease, txrules.DefaultRelayFeePerKb)
+	}
+
+	newTx := oldTx.Copy()
+
+	for _, in := range newTx.TxIn {
+		in.SignatureScript = nil
+		in.Witness = nil
+		in.Sequence = RbfSequence
+	}
+
+	change := newTx.TxOut[changeIdx]
+	change.Value -= int64(feeIncrease)
+
+	if change.Value <= 0 || txrules.IsDustOutput(change, txrules.DefaultRelayFeePerKb) {
+		return nil, fmt.Errorf("change output with value %d cannot pay fee increase %d of replacement",
+			oldTx.TxOut[changeIdx].Value, feeIncrease)
+	}
+
+	return newTx, nil
+}
+
+// ReplaceTransaction creates and signs transaction replacing signed transaction
+// oldTx, which spends the same inputs and pays fee at newFeeRatePerKb. Fee
+// increase is paid from the biggest wallet output of oldTx. Inputs of oldTx
+// must be confirmed, as their values are read from the utxo set. New fee rate
+// must be within fee limits of the wallet. Replacement is not broadcasted.
+// Requires wallet to be unlocked.
+func (w *RpcWalletController) ReplaceTransaction(
+	ctx context.Context,
+	oldTx *wire.MsgTx,
+	newFeeRatePerKb btcutil.Amount,
+) (*wire.MsgTx, error) {
+	if err := w.feeLimits.CheckFeeRate(newFeeRatePerKb); err != nil {
+		return nil, err
+	}
+
+	prevOutValues := make([]btcutil.Amount, len(oldTx.TxIn))
+
+	for i, in := range oldTx.TxIn {
+		prevOut := in.PreviousOutPoint
+
+		// outputs spent only in mempool are still part of the utxo set
+		res, err := w.getTxOut(&prevOut.Hash, prevOut.Index, false)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil {
+			return nil, fmt.Errorf("input %s of replaced transaction is not confirmed unspent output", prevOut)
+		}
+
+		value, err := btcutil.NewAmount(res.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		prevOutValues[i] = value
+	}
+
+	changeIdx := -1
+
+	for i, out := range oldTx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, w.netParams)
+
+		if err != nil || len(addrs) != 1 {
+			continue
+		}
+
+		encoded := addrs[0].EncodeAddress()
+
+		info, err := retryRead(w, func() (*btcjson.GetAddressInfoResult, error) {
+			return w.GetAddressInfo(encoded)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsMine && (changeIdx < 0 || out.Value > oldTx.TxOut[changeIdx].Value) {
+			changeIdx = i
+		}
+	}
+
+	if changeIdx < 0 {
+		return nil, fmt.Errorf("replaced transaction does not have wallet output which could pay higher fee")
+	}
+
+	newTx, err := replacementTx(oldTx, prevOutValues, changeIdx, newFeeRatePerKb)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, signed, err := w.SignRawTransaction(ctx, newTx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !signed {
+		return nil, fmt.Errorf("not all replacement transaction inputs could be signed")
+	}
+
+	return signedTx, nil
+}
@@ -0,0 +1,95 @@
+package walletcontroller
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplacementTx(t *testing.T) {
+	const inputValue = btcutil.Amount(100000)
+
+	paymentScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	// signed p2wpkh spend paying given fee, with given change
+	makeOldTx := func(fee, change btcutil.Amount) *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		in := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil)
+		in.Witness = wire.TxWitness{make([]byte, 72), make([]byte, 33)}
+		tx.AddTxIn(in)
+		tx.AddTxOut(wire.NewTxOut(int64(inputValue-fee-change), paymentScript))
+		tx.AddTxOut(wire.NewTxOut(int64(change), changeScript))
+		return tx
+	}
+
+	vsize := int(mempool.GetTxVirtualSize(btcutil.NewTx(makeOldTx(0, 0))))
+	oldFeeRate := btcutil.Amount(10000)
+	oldFee := txrules.FeeForSerializeSize(oldFeeRate, vsize)
+
+	tests := []struct {
+		name       string
+		change     btcutil.Amount
+		newFeeRate btcutil.Amount
+		expectErr  string
+	}{
+		{
+			name:       "higher fee rate",
+			change:     50000,
+			newFeeRate: 3 * oldFeeRate,
+		},
+		{
+			name:       "fee not higher than original",
+			change:     50000,
+			newFeeRate: oldFeeRate,
+			expectErr:  "must be higher than fee",
+		},
+		{
+			name:       "fee increase does not pay for bandwidth",
+			change:     50000,
+			newFeeRate: oldFeeRate + txrules.DefaultRelayFeePerKb/2,
+			expectErr:  "must pay for its own bandwidth",
+		},
+		{
+			name:       "change cannot pay fee increase",
+			change:     1000,
+			newFeeRate: 3 * oldFeeRate,
+			expectErr:  "cannot pay fee increase",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldTx := makeOldTx(oldFee, tc.change)
+
+			newTx, err := replacementTx(oldTx, []btcutil.Amount{inputValue}, 1, tc.newFeeRate)
+
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+
+			require.NoError(t, err)
+
+			// original transaction is not modified
+			require.Equal(t, int64(tc.change), oldTx.TxOut[1].Value)
+			require.NotNil(t, oldTx.TxIn[0].Witness)
+
+			require.Len(t, newTx.TxIn, 1)
+			require.Equal(t, oldTx.TxIn[0].PreviousOutPoint, newTx.TxIn[0].PreviousOutPoint)
+			require.Equal(t, uint32(RbfSequence), newTx.TxIn[0].Sequence)
+			require.Nil(t, newTx.TxIn[0].Witness)
+
+			// payment is untouched, fee increase is paid from change
+			require.Equal(t, oldTx.TxOut[0].Value, newTx.TxOut[0].Value)
+			newFee := inputValue - btcutil.Amount(newTx.TxOut[0].Value+newTx.TxOut[1].Value)
+			require.Equal(t, txrules.FeeForSerializeSize(tc.newFeeRate, vsize), newFee)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package walletcontroller
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/avast/retry-go/v4"
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+// isTransientRpcError returns true if error was caused by failed connection to
+// the wallet or by node being temporarily unable to serve requests, rather than
+// by wallet rejecting the request.
+// Note: rpc client already retries requests which fail to reach the node, so
+// those are mostly covered by net.Error check. Errors which occur after request
+// reached the node are returned by rpc client as plain strings, so they need to
+// be matched by their content.
+func isTransientRpcError(err error) bool {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == btcjson.ErrRPCInWarmup
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+
+	// connection dropped while reading response, or node responded with
+	// non json error e.g bitcoind `Work queue depth exceeded`
+	return strings.HasPrefix(msg, "error reading json reply") ||
+		strings.HasPrefix(msg, "status code: 5")
+}
+
+// retryRead retries read on transient rpc errors. It must be used only with
+// idempotent reads, as request may have reached the wallet before connection
+// failed.
+func retryRead[T any](w *RpcWalletController, read func() (T, error)) (T, error) {
+	var result T
+
+	err := retry.Do(func() error {
+		res, err := read()
+
+		if err != nil {
+			if !isTransientRpcError(err) {
+				return retry.Unrecoverable(err)
+			}
+
+			return err
+		}
+
+		result = res
+		return nil
+	},
+		retry.Attempts(w.readRetryAttempts),
+		retry.Delay(w.readRetryDelay),
+		retry.LastErrorOnly(true),
+	)
+
+	return result, err
+}
@@ -2,15 +2,22 @@ package walletcontroller
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 
+	"github.com/babylonchain/btc-staker/types"
 	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
 )
 
+// RbfSequence is sequence number of inputs signaling opt-in replace-by-fee as
+// defined in BIP125
+const RbfSequence = wire.MaxTxInSequenceNum - 2
+
 type Utxo struct {
 	Amount       btcutil.Amount
 	OutPoint     wire.OutPoint
@@ -19,6 +26,17 @@ type Utxo struct {
 	Address      string
 }
 
+// UtxoDetails is the full wallet view of unspent output, including outputs
+// locked by the wallet which are not returned by ListOutputs
+type UtxoDetails struct {
+	Utxo
+	Confirmations int64
+	// Spendable is always false for locked outputs, as wallet will not use them
+	// for funding until they are unlocked
+	Spendable bool
+	Locked    bool
+}
+
 type byAmount []Utxo
 
 func (s byAmount) Len() int           { return len(s) }
@@ -71,7 +89,9 @@ func resultsToUtxos(results []btcjson.ListUnspentResult, onlySpendable bool) ([]
 	return utxos, nil
 }
 
-func makeInputSource(utxos []Utxo) txauthor.InputSource {
+// makeInputSource returns input source using utxos in order. First minInputs
+// utxos are always used, even if they are not needed to reach the target.
+func makeInputSource(utxos []Utxo, minInputs int) txauthor.InputSource {
 	currentTotal := btcutil.Amount(0)
 	currentInputs := make([]*wire.TxIn, 0, len(utxos))
 	currentScripts := make([][]byte, 0, len(utxos))
@@ -80,7 +100,7 @@ func makeInputSource(utxos []Utxo) txauthor.InputSource {
 	return func(target btcutil.Amount) (btcutil.Amount, []*wire.TxIn,
 		[]btcutil.Amount, [][]byte, error) {
 
-		for currentTotal < target && len(utxos) != 0 {
+		for (currentTotal < target || len(currentInputs) < minInputs) && len(utxos) != 0 {
 			nextCredit := &utxos[0]
 			utxos = utxos[1:]
 			nextInput := wire.NewTxIn(&nextCredit.OutPoint, nil, nil)
@@ -93,11 +113,62 @@ func makeInputSource(utxos []Utxo) txauthor.InputSource {
 	}
 }
 
+// signalReplaceability makes all inputs of tx signal opt-in replace-by-fee
+func signalReplaceability(tx *wire.MsgTx) {
+	for _, in := range tx.TxIn {
+		in.Sequence = RbfSequence
+	}
+}
+
+// checkTxFee returns error if transaction funded from utxos pays more fee than
+// allowed by fee limits. Fee paid by transaction can be higher than requested,
+// as small change is added to the fee.
+func checkTxFee(tx *wire.MsgTx, utxos []Utxo, limits FeeLimits) error {
+	utxosByOutpoint := make(map[wire.OutPoint]Utxo, len(utxos))
+	for _, utxo := range utxos {
+		utxosByOutpoint[utxo.OutPoint] = utxo
+	}
+
+	prevScripts := make([][]byte, 0, len(tx.TxIn))
+	var inputsValue btcutil.Amount
+
+	for _, in := range tx.TxIn {
+		utxo, found := utxosByOutpoint[in.PreviousOutPoint]
+
+		if !found {
+			return fmt.Errorf("input %s of transaction is not wallet output", in.PreviousOutPoint)
+		}
+
+		prevScripts = append(prevScripts, utxo.PkScript)
+		inputsValue += utxo.Amount
+	}
+
+	var outputsValue btcutil.Amount
+	for _, out := range tx.TxOut {
+		outputsValue += btcutil.Amount(out.Value)
+	}
+
+	fee := inputsValue - outputsValue
+
+	return limits.CheckFee(fee, EstimateTxVirtualSize(prevScripts, tx.TxOut, nil))
+}
+
+// buildTxFromOutputs funds transaction sending to outputs from utxos. Fee rate
+// must be within fee limits and fee paid by funded transaction must not exceed
+// fee at maximum fee rate.
 func buildTxFromOutputs(
 	utxos []Utxo,
 	outputs []*wire.TxOut,
 	feeRatePerKb btcutil.Amount,
-	changeScript []byte) (*wire.MsgTx, error) {
+	limits FeeLimits,
+	changeScript []byte,
+	changePolicy ChangePolicy,
+	signalRbf bool,
+	strategy types.CoinSelectionStrategy) (*wire.MsgTx, error) {
+
+	if err := limits.CheckFeeRate(feeRatePerKb); err != nil {
+		return nil, err
+	}
 
 	if len(utxos) == 0 {
 		return nil, fmt.Errorf("there must be at least 1 usable UTXO to build transaction")
@@ -107,6 +178,23 @@ func buildTxFromOutputs(
 		return nil, fmt.Errorf("there must be at least 1 output in transaction")
 	}
 
+	consolidated, rest := changePolicy.splitConsolidated(utxos, feeRatePerKb)
+
+	// transaction without change would not consolidate anything
+	if strategy == types.BranchAndBoundCoinSelection && len(consolidated) == 0 {
+		if tx := changelessTx(utxos, outputs, feeRatePerKb, changeScript, changePolicy.MinChange); tx != nil {
+			if err := checkTxFee(tx, utxos, limits); err != nil {
+				return nil, err
+			}
+
+			if signalRbf {
+				signalReplaceability(tx)
+			}
+
+			return tx, nil
+		}
+	}
+
 	ch := txauthor.ChangeSource{
 		NewScript: func() ([]byte, error) {
 			return changeScript, nil
@@ -114,12 +202,13 @@ func buildTxFromOutputs(
 		ScriptSize: len(changeScript),
 	}
 
-	inputSource := makeInputSource(utxos)
+	// consolidated outputs are used first, remaining ones only if needed
+	orderedUtxos := append(sortUtxos(consolidated, strategy), sortUtxos(rest, strategy)...)
 
 	authoredTx, err := txauthor.NewUnsignedTransaction(
 		outputs,
 		feeRatePerKb,
-		inputSource,
+		makeInputSource(orderedUtxos, len(consolidated)),
 		&ch,
 	)
 
@@ -127,5 +216,95 @@ func buildTxFromOutputs(
 		return nil, err
 	}
 
-	return authoredTx.Tx, nil
+	tx := authoredTx.Tx
+
+	// txauthor creates change output for any change above dust limit. If change
+	// is below configured threshold, more inputs are added to reach it. If
+	// wallet cannot fund that, change is added to the fee instead.
+	if authoredTx.ChangeIndex >= 0 &&
+		btcutil.Amount(tx.TxOut[authoredTx.ChangeIndex].Value) < changePolicy.MinChange {
+		minChangeTx, err := fundMinChange(
+			orderedUtxos,
+			len(consolidated),
+			outputs,
+			feeRatePerKb,
+			changeScript,
+			changePolicy.MinChange,
+		)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if minChangeTx != nil {
+			tx = minChangeTx
+		} else {
+			tx.TxOut = append(tx.TxOut[:authoredTx.ChangeIndex], tx.TxOut[authoredTx.ChangeIndex+1:]...)
+		}
+	}
+
+	if err := checkTxFee(tx, utxos, limits); err != nil {
+		return nil, err
+	}
+
+	if signalRbf {
+		signalReplaceability(tx)
+	}
+
+	return tx, nil
+}
+
+// fundMinChange funds outputs from utxos in order, so that change is at least
+// minChange. Returns nil if utxos are not worth enough.
+func fundMinChange(
+	utxos []Utxo,
+	minInputs int,
+	outputs []*wire.TxOut,
+	feeRatePerKb btcutil.Amount,
+	changeScript []byte,
+	minChange btcutil.Amount,
+) (*wire.MsgTx, error) {
+	// minimum change is funded as additional output, any change left above it
+	// is merged into that output
+	changeIdx := len(outputs)
+	withChange := append(outputs[:changeIdx:changeIdx], wire.NewTxOut(int64(minChange), changeScript))
+
+	ch := txauthor.ChangeSource{
+		NewScript: func() ([]byte, error) {
+			return changeScript, nil
+		},
+		ScriptSize: len(changeScript),
+	}
+
+	authoredTx, err := txauthor.NewUnsignedTransaction(
+		withChange,
+		feeRatePerKb,
+		makeInputSource(utxos, minInputs),
+		&ch,
+	)
+
+	var inputErr txauthor.InputSourceError
+	if errors.As(err, &inputErr) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	tx := authoredTx.Tx
+	tx.TxOut = tx.TxOut[:changeIdx+1]
+
+	// fee was estimated with both outputs, recompute it for merged change
+	var outputsValue btcutil.Amount
+	for _, out := range outputs {
+		outputsValue += btcutil.Amount(out.Value)
+	}
+
+	fee := txrules.FeeForSerializeSize(feeRatePerKb, EstimateTxVirtualSize(authoredTx.PrevScripts, tx.TxOut, nil))
+	if change := authoredTx.TotalInput - outputsValue - fee; change >= minChange {
+		tx.TxOut[changeIdx].Value = int64(change)
+	}
+
+	return tx, nil
 }
@@ -0,0 +1,455 @@
+package walletcontroller
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/babylonchain/btc-staker/types"
+)
+
+func makeChangeScript(t *testing.T, class txscript.ScriptClass) []byte {
+	privKey, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	var addr btcutil.Address
+
+	switch class {
+	case txscript.WitnessV0PubKeyHashTy:
+		addr, err = btcutil.NewAddressWitnessPubKeyHash(
+			btcutil.Hash160(privKey.PubKey().SerializeCompressed()),
+			&chaincfg.SimNetParams,
+		)
+	case txscript.WitnessV1TaprootTy:
+		addr, err = btcutil.NewAddressTaproot(
+			schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(privKey.PubKey())),
+			&chaincfg.SimNetParams,
+		)
+	default:
+		t.Fatalf("unsupported change script class: %s", class)
+	}
+	require.NoError(t, err)
+
+	script, err := txscript.PayToAddrScript(addr)
+	require.NoError(t, err)
+
+	return script
+}
+
+func TestBuildTxFromOutputsChangeType(t *testing.T) {
+	feeRate := btcutil.Amount(10000)
+
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	utxos := []Utxo{
+		{
+			Amount:   btcutil.Amount(100000000),
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+			PkScript: fundingScript,
+		},
+	}
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000000, fundingScript),
+	}
+
+	fees := make(map[txscript.ScriptClass]btcutil.Amount)
+
+	for _, class := range []txscript.ScriptClass{txscript.WitnessV0PubKeyHashTy, txscript.WitnessV1TaprootTy} {
+		changeScript := makeChangeScript(t, class)
+
+		tx, err := buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+		require.NoError(t, err)
+		require.Len(t, tx.TxOut, 2)
+
+		var changeOutput *wire.TxOut
+		var totalOut btcutil.Amount
+		for _, out := range tx.TxOut {
+			totalOut += btcutil.Amount(out.Value)
+			if bytes.Equal(out.PkScript, changeScript) {
+				changeOutput = out
+			}
+		}
+		require.NotNil(t, changeOutput)
+		require.Equal(t, class, txscript.GetScriptClass(changeOutput.PkScript))
+
+		// fee must be estimated with size of the chosen change script
+		expectedSize := txsizes.EstimateVirtualSize(0, 0, 1, 0, outputs, len(changeScript))
+		expectedFee := txrules.FeeForSerializeSize(feeRate, expectedSize)
+		fee := utxos[0].Amount - totalOut
+		require.Equal(t, expectedFee, fee)
+
+		fees[class] = fee
+	}
+
+	// taproot output is larger than p2wpkh output
+	require.Greater(t, fees[txscript.WitnessV1TaprootTy], fees[txscript.WitnessV0PubKeyHashTy])
+}
+
+func TestBuildTxFromOutputsMinChange(t *testing.T) {
+	feeRate := btcutil.Amount(10000)
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000000, fundingScript),
+	}
+
+	// fund transaction so that exactly changeAmount is left as change
+	changeAmount := btcutil.Amount(1000)
+	sizeWithChange := txsizes.EstimateVirtualSize(0, 0, 1, 0, outputs, len(changeScript))
+	feeWithChange := txrules.FeeForSerializeSize(feeRate, sizeWithChange)
+	inputAmount := btcutil.Amount(outputs[0].Value) + feeWithChange + changeAmount
+
+	utxos := []Utxo{
+		{
+			Amount:   inputAmount,
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+			PkScript: fundingScript,
+		},
+	}
+
+	// change equal to the threshold is created as output
+	tx, err := buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{MinChange: changeAmount}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxOut, 2)
+	require.Equal(t, changeScript, tx.TxOut[1].PkScript)
+	require.Equal(t, int64(changeAmount), tx.TxOut[1].Value)
+
+	// change below the threshold is added to the fee
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{MinChange: changeAmount + 1}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxOut, 1)
+	require.Equal(t, outputs[0].Value, tx.TxOut[0].Value)
+	require.Equal(t, feeWithChange+changeAmount, inputAmount-btcutil.Amount(tx.TxOut[0].Value))
+
+	// another output is added to reach the threshold, if wallet has one
+	extraAmount := btcutil.Amount(100000)
+	utxos = append(utxos, Utxo{
+		Amount:   extraAmount,
+		OutPoint: *wire.NewOutPoint(&chainhash.Hash{2}, 0),
+		PkScript: fundingScript,
+	})
+
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{MinChange: changeAmount + 1}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 2)
+	require.Len(t, tx.TxOut, 2)
+	require.Equal(t, changeScript, tx.TxOut[1].PkScript)
+
+	sizeWithTwoInputs := EstimateTxVirtualSize([][]byte{fundingScript, fundingScript}, tx.TxOut, nil)
+	feeWithTwoInputs := txrules.FeeForSerializeSize(feeRate, sizeWithTwoInputs)
+	require.Equal(t, int64(inputAmount+extraAmount-feeWithTwoInputs)-outputs[0].Value, tx.TxOut[1].Value)
+	require.GreaterOrEqual(t, tx.TxOut[1].Value, int64(changeAmount+1))
+}
+
+func TestBuildTxFromOutputsDustChange(t *testing.T) {
+	feeRate := btcutil.Amount(10000)
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000000, fundingScript),
+	}
+
+	// smallest change which is not dust
+	dustLimit := btcutil.Amount(1)
+	for txrules.IsDustOutput(wire.NewTxOut(int64(dustLimit), changeScript), txrules.DefaultRelayFeePerKb) {
+		dustLimit++
+	}
+
+	sizeWithChange := txsizes.EstimateVirtualSize(0, 0, 1, 0, outputs, len(changeScript))
+	feeWithChange := txrules.FeeForSerializeSize(feeRate, sizeWithChange)
+
+	buildWithChange := func(changeAmount btcutil.Amount) (*wire.MsgTx, btcutil.Amount) {
+		inputAmount := btcutil.Amount(outputs[0].Value) + feeWithChange + changeAmount
+		utxos := []Utxo{
+			{
+				Amount:   inputAmount,
+				OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+				PkScript: fundingScript,
+			},
+		}
+
+		tx, err := buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+		require.NoError(t, err)
+		return tx, inputAmount
+	}
+
+	// change at the dust limit is created as output
+	tx, _ := buildWithChange(dustLimit)
+	require.Len(t, tx.TxOut, 2)
+	require.Equal(t, int64(dustLimit), tx.TxOut[1].Value)
+
+	// dust change is added to the fee
+	tx, inputAmount := buildWithChange(dustLimit - 1)
+	require.Len(t, tx.TxOut, 1)
+	require.Equal(t, feeWithChange+dustLimit-1, inputAmount-btcutil.Amount(tx.TxOut[0].Value))
+}
+
+func TestBuildTxFromOutputsConsolidation(t *testing.T) {
+	feeRate := btcutil.Amount(2000)
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	consolidateBelow := btcutil.Amount(10000)
+	utxos := []Utxo{
+		{Amount: 100000000, OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0), PkScript: fundingScript},
+		{Amount: 5000, OutPoint: *wire.NewOutPoint(&chainhash.Hash{2}, 0), PkScript: fundingScript},
+		{Amount: consolidateBelow - 1, OutPoint: *wire.NewOutPoint(&chainhash.Hash{3}, 0), PkScript: fundingScript},
+		// outputs at the threshold are not consolidated
+		{Amount: consolidateBelow, OutPoint: *wire.NewOutPoint(&chainhash.Hash{4}, 0), PkScript: fundingScript},
+		{Amount: 50000, OutPoint: *wire.NewOutPoint(&chainhash.Hash{5}, 0), PkScript: fundingScript},
+		// outputs worth less than fee of spending them are not consolidated
+		{Amount: 100, OutPoint: *wire.NewOutPoint(&chainhash.Hash{6}, 0), PkScript: fundingScript},
+	}
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000000, fundingScript),
+	}
+
+	inputs := func(tx *wire.MsgTx) []chainhash.Hash {
+		var hashes []chainhash.Hash
+		for _, in := range tx.TxIn {
+			hashes = append(hashes, in.PreviousOutPoint.Hash)
+		}
+		return hashes
+	}
+
+	tx, err := buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Equal(t, []chainhash.Hash{{1}}, inputs(tx))
+
+	policy := ChangePolicy{ConsolidateBelow: consolidateBelow}
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, policy, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{{1}, {2}, {3}}, inputs(tx))
+	require.Len(t, tx.TxOut, 2)
+	require.Equal(t, changeScript, tx.TxOut[1].PkScript)
+
+	// consolidated outputs are spent into change, so changeless transaction
+	// is not searched for
+	changelessOutputs := []*wire.TxOut{
+		wire.NewTxOut(int64(utxos[0].Amount)-5000, fundingScript),
+	}
+	tx, err = buildTxFromOutputs(utxos, changelessOutputs, feeRate, relayFeeLimits, changeScript, policy, false, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chainhash.Hash{{1}, {2}, {3}}, inputs(tx))
+	require.Len(t, tx.TxOut, 2)
+}
+
+func TestSplitConsolidatedCapsInputCount(t *testing.T) {
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	numSmall := MaxConsolidatedInputs + 10
+	utxos := make([]Utxo, numSmall)
+	for i := range utxos {
+		utxos[i] = Utxo{
+			Amount:   btcutil.Amount(5000 + i),
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{byte(i)}, uint32(i)),
+			PkScript: fundingScript,
+		}
+	}
+
+	policy := ChangePolicy{ConsolidateBelow: 10000}
+	consolidated, rest := policy.splitConsolidated(utxos, 2000)
+	require.Len(t, consolidated, MaxConsolidatedInputs)
+	require.Len(t, rest, numSmall-MaxConsolidatedInputs)
+
+	// the largest outputs are consolidated first
+	for _, utxo := range rest {
+		require.Less(t, utxo.Amount, consolidated[len(consolidated)-1].Amount)
+	}
+}
+
+func TestBuildTxFromOutputsSignalRbf(t *testing.T) {
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	utxos := []Utxo{
+		{
+			Amount:   btcutil.Amount(100000),
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+			PkScript: fundingScript,
+		},
+		{
+			Amount:   btcutil.Amount(100000),
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{2}, 0),
+			PkScript: fundingScript,
+		},
+	}
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(150000, fundingScript),
+	}
+
+	tx, err := buildTxFromOutputs(utxos, outputs, 2000, relayFeeLimits, fundingScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	for _, in := range tx.TxIn {
+		require.Equal(t, uint32(wire.MaxTxInSequenceNum), in.Sequence)
+	}
+
+	tx, err = buildTxFromOutputs(utxos, outputs, 2000, relayFeeLimits, fundingScript, ChangePolicy{}, true, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 2)
+	for _, in := range tx.TxIn {
+		require.Equal(t, uint32(RbfSequence), in.Sequence)
+	}
+}
+
+func TestBuildTxFromOutputsCoinSelection(t *testing.T) {
+	feeRate := btcutil.Amount(2000)
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+
+	makeUtxos := func(amounts ...btcutil.Amount) []Utxo {
+		utxos := make([]Utxo, len(amounts))
+		for i, amount := range amounts {
+			utxos[i] = Utxo{
+				Amount:   amount,
+				OutPoint: *wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, 0),
+				PkScript: fundingScript,
+			}
+		}
+		return utxos
+	}
+
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000, makeChangeScript(t, txscript.WitnessV1TaprootTy)),
+	}
+
+	// two inputs of 30000 and 20000 sats fund outputs exactly, when fee is
+	// added to one of them
+	sizeWithTwoInputs := txsizes.EstimateVirtualSize(0, 0, 2, 0, outputs, 0)
+	exactFee := txrules.FeeForSerializeSize(feeRate, sizeWithTwoInputs)
+	utxos := makeUtxos(100000, 30000+exactFee, 20000, 5000)
+
+	tx, err := buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 1)
+	require.Equal(t, utxos[0].OutPoint, tx.TxIn[0].PreviousOutPoint)
+	require.Len(t, tx.TxOut, 2)
+
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.SmallestFirstCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 3)
+	require.Equal(t, utxos[3].OutPoint, tx.TxIn[0].PreviousOutPoint)
+	require.Equal(t, utxos[2].OutPoint, tx.TxIn[1].PreviousOutPoint)
+
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, true, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 2)
+	require.Equal(t, utxos[1].OutPoint, tx.TxIn[0].PreviousOutPoint)
+	require.Equal(t, utxos[2].OutPoint, tx.TxIn[1].PreviousOutPoint)
+	require.Len(t, tx.TxOut, 1)
+	for _, in := range tx.TxIn {
+		require.Equal(t, uint32(RbfSequence), in.Sequence)
+	}
+
+	// excess below dust threshold of change is added to the fee
+	utxos = makeUtxos(100000, 30000+exactFee+100, 20000)
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 2)
+	require.Len(t, tx.TxOut, 1)
+
+	// excess above dust threshold but below minimum change is also added to
+	// the fee
+	utxos = makeUtxos(100000, 30000+exactFee+5000, 20000)
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 1)
+	require.Len(t, tx.TxOut, 2)
+
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{MinChange: 6000}, false, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 2)
+	require.Len(t, tx.TxOut, 1)
+
+	// without changeless match, branch and bound falls back to largest first
+	utxos = makeUtxos(100000, 40000, 20000)
+	tx, err = buildTxFromOutputs(utxos, outputs, feeRate, relayFeeLimits, changeScript, ChangePolicy{}, false, types.BranchAndBoundCoinSelection)
+	require.NoError(t, err)
+	require.Len(t, tx.TxIn, 1)
+	require.Equal(t, utxos[0].OutPoint, tx.TxIn[0].PreviousOutPoint)
+	require.Len(t, tx.TxOut, 2)
+}
+
+func TestBranchAndBound(t *testing.T) {
+	values := []btcutil.Amount{10, 7, 5, 3, 1}
+
+	require.Equal(t, []int{0}, branchAndBound(values, 10, 0))
+	selected := branchAndBound(values, 9, 0)
+	var sum btcutil.Amount
+	for _, idx := range selected {
+		sum += values[idx]
+	}
+	require.Equal(t, btcutil.Amount(9), sum)
+
+	// smallest excess within window is chosen
+	selected = branchAndBound([]btcutil.Amount{10, 6}, 5, 2)
+	require.Equal(t, []int{1}, selected)
+
+	require.Nil(t, branchAndBound(values, 27, 0))
+	require.Nil(t, branchAndBound([]btcutil.Amount{10, 8}, 5, 2))
+}
+
+func TestDustThreshold(t *testing.T) {
+	for _, class := range []txscript.ScriptClass{txscript.WitnessV0PubKeyHashTy, txscript.WitnessV1TaprootTy} {
+		script := makeChangeScript(t, class)
+		threshold := dustThreshold(script)
+
+		require.True(t, txrules.IsDustOutput(wire.NewTxOut(int64(threshold)-1, script), txrules.DefaultRelayFeePerKb))
+		require.False(t, txrules.IsDustOutput(wire.NewTxOut(int64(threshold), script), txrules.DefaultRelayFeePerKb))
+
+		require.Equal(t, threshold, changeThreshold(script, threshold-1))
+		require.Equal(t, threshold+1, changeThreshold(script, threshold+1))
+	}
+}
+
+func TestBuildTxFromOutputsFeeLimits(t *testing.T) {
+	maxFeeRate := btcutil.Amount(10000)
+
+	fundingScript := makeChangeScript(t, txscript.WitnessV0PubKeyHashTy)
+	changeScript := makeChangeScript(t, txscript.WitnessV1TaprootTy)
+	utxos := []Utxo{
+		{
+			Amount:   btcutil.Amount(100000000),
+			OutPoint: *wire.NewOutPoint(&chainhash.Hash{1}, 0),
+			PkScript: fundingScript,
+		},
+	}
+	outputs := []*wire.TxOut{
+		wire.NewTxOut(50000000, fundingScript),
+	}
+
+	limits := FeeLimits{MinFeeRatePerKb: txrules.DefaultRelayFeePerKb, MaxFeeRatePerKb: maxFeeRate}
+	build := func(feeRate btcutil.Amount, minChange btcutil.Amount) error {
+		_, err := buildTxFromOutputs(utxos, outputs, feeRate, limits, changeScript, ChangePolicy{MinChange: minChange}, false, types.LargestFirstCoinSelection)
+		return err
+	}
+
+	// fee rate at network relay minimum is accepted, lower one is not
+	require.NoError(t, build(txrules.DefaultRelayFeePerKb, 0))
+	require.ErrorIs(t, build(txrules.DefaultRelayFeePerKb-1, 0), ErrFeeRateTooLow)
+
+	// fee rate at maximum is accepted, higher one is not
+	require.NoError(t, build(maxFeeRate, 0))
+	require.ErrorIs(t, build(maxFeeRate+1, 0), ErrFeeTooHigh)
+
+	// change added to the fee makes transaction pay more than maximum fee rate
+	require.ErrorIs(t, build(maxFeeRate, utxos[0].Amount), ErrFeeTooHigh)
+
+	// zero maximum disables the ceiling
+	_, err := buildTxFromOutputs(utxos, outputs, maxFeeRate+1, relayFeeLimits, changeScript, ChangePolicy{MinChange: utxos[0].Amount}, false, types.LargestFirstCoinSelection)
+	require.NoError(t, err)
+
+	// floor can be raised above network relay minimum
+	floorLimits := FeeLimits{MinFeeRatePerKb: 2 * txrules.DefaultRelayFeePerKb}
+	_, err = buildTxFromOutputs(utxos, outputs, 2*txrules.DefaultRelayFeePerKb-1, floorLimits, changeScript, ChangePolicy{}, false, types.LargestFirstCoinSelection)
+	require.ErrorIs(t, err, ErrFeeRateTooLow)
+}
@@ -0,0 +1,47 @@
+package walletcontroller
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+	"github.com/btcsuite/btcwallet/wallet/txsizes"
+)
+
+// EstimateTxVirtualSize returns virtual size of transaction spending wallet
+// outputs with given pkScripts to provided outputs, once it is signed by the
+// wallet. Witness data is weighted according to BIP141, so segwit inputs are
+// counted with witness discount. Signatures are assumed to have maximal size,
+// so estimate is never lower than the size of signed transaction.
+// If changeScript is not nil, change output paying to it is included.
+// Same as the wallet, p2sh outputs are assumed to be nested p2wpkh and outputs
+// of other unknown types p2pkh.
+func EstimateTxVirtualSize(prevPkScripts [][]byte, outputs []*wire.TxOut, changeScript []byte) int {
+	var nested, p2wpkh, p2tr, p2pkh int
+	for _, pkScript := range prevPkScripts {
+		switch {
+		case txscript.IsPayToScriptHash(pkScript):
+			nested++
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			p2wpkh++
+		case txscript.IsPayToTaproot(pkScript):
+			p2tr++
+		default:
+			p2pkh++
+		}
+	}
+
+	return txsizes.EstimateVirtualSize(p2pkh, p2tr, p2wpkh, nested, outputs, len(changeScript))
+}
+
+// EstimateTxFee returns fee paid at feeRatePerKb by transaction described by
+// arguments of EstimateTxVirtualSize. It can be used to estimate fee of planned
+// transaction before it is funded.
+func EstimateTxFee(
+	prevPkScripts [][]byte,
+	outputs []*wire.TxOut,
+	changeScript []byte,
+	feeRatePerKb btcutil.Amount,
+) btcutil.Amount {
+	return txrules.FeeForSerializeSize(feeRatePerKb, EstimateTxVirtualSize(prevPkScripts, outputs, changeScript))
+}
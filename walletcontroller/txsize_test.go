@@ -0,0 +1,202 @@
+package walletcontroller
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mempool"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+type inputType int
+
+const (
+	p2pkhInput inputType = iota
+	nestedP2wpkhInput
+	p2wpkhInput
+	p2trInput
+)
+
+type signableInput struct {
+	inputType inputType
+	privKey   *btcec.PrivateKey
+	pkScript  []byte
+	// p2wpkh program of nested p2wpkh input
+	witnessProgram []byte
+}
+
+// keys are deterministic, so that sizes of signatures do not change between runs
+var nextInputKey byte
+
+func makeSignableInput(t *testing.T, inputType inputType) *signableInput {
+	nextInputKey++
+	privKey, _ := btcec.PrivKeyFromBytes(chainhash.HashB([]byte{nextInputKey}))
+	var err error
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	params := &chaincfg.RegressionNetParams
+
+	in := &signableInput{inputType: inputType, privKey: privKey}
+
+	switch inputType {
+	case p2pkhInput:
+		addr, err := btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+		require.NoError(t, err)
+		in.pkScript, err = txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+	case nestedP2wpkhInput:
+		witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+		require.NoError(t, err)
+		in.witnessProgram, err = txscript.PayToAddrScript(witnessAddr)
+		require.NoError(t, err)
+		addr, err := btcutil.NewAddressScriptHash(in.witnessProgram, params)
+		require.NoError(t, err)
+		in.pkScript, err = txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+	case p2wpkhInput:
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+		require.NoError(t, err)
+		in.pkScript, err = txscript.PayToAddrScript(addr)
+		require.NoError(t, err)
+	case p2trInput:
+		in.pkScript, err = txscript.PayToTaprootScript(txscript.ComputeTaprootKeyNoScript(privKey.PubKey()))
+		require.NoError(t, err)
+	}
+
+	return in
+}
+
+// makeSignedTx returns transaction spending inputs of given types to outputs,
+// signed the same way as wallet signs it
+func makeSignedTx(t *testing.T, inputTypes []inputType, outputs []*wire.TxOut) (*wire.MsgTx, [][]byte) {
+	const inputValue = 100000
+
+	inputs := make([]*signableInput, len(inputTypes))
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	for i, inputType := range inputTypes {
+		inputs[i] = makeSignableInput(t, inputType)
+		prevTx.AddTxOut(wire.NewTxOut(inputValue, inputs[i].pkScript))
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	prevScripts := make([][]byte, len(inputs))
+	for i, in := range inputs {
+		outPoint := wire.OutPoint{Hash: prevTx.TxHash(), Index: uint32(i)}
+		tx.AddTxIn(wire.NewTxIn(&outPoint, nil, nil))
+		prevOuts.AddPrevOut(outPoint, prevTx.TxOut[i])
+		prevScripts[i] = in.pkScript
+	}
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range inputs {
+		var err error
+		txIn := tx.TxIn[i]
+
+		switch in.inputType {
+		case p2pkhInput:
+			txIn.SignatureScript, err = txscript.SignatureScript(
+				tx, i, in.pkScript, txscript.SigHashAll, in.privKey, true,
+			)
+		case nestedP2wpkhInput:
+			txIn.Witness, err = txscript.WitnessSignature(
+				tx, sigHashes, i, inputValue, in.witnessProgram, txscript.SigHashAll, in.privKey, true,
+			)
+			require.NoError(t, err)
+			txIn.SignatureScript, err = txscript.NewScriptBuilder().AddData(in.witnessProgram).Script()
+		case p2wpkhInput:
+			txIn.Witness, err = txscript.WitnessSignature(
+				tx, sigHashes, i, inputValue, in.pkScript, txscript.SigHashAll, in.privKey, true,
+			)
+		case p2trInput:
+			txIn.Witness, err = txscript.TaprootWitnessSignature(
+				tx, sigHashes, i, inputValue, in.pkScript, txscript.SigHashDefault, in.privKey,
+			)
+		}
+		require.NoError(t, err)
+	}
+
+	verifyTxSignatures(t, tx, prevTx)
+
+	return tx, prevScripts
+}
+
+func TestEstimateTxVirtualSize(t *testing.T) {
+	p2wpkhOutput := wire.NewTxOut(50000, makeSignableInput(t, p2wpkhInput).pkScript)
+	p2trOutput := makeStakingOutput(t, 50000)
+
+	tests := []struct {
+		name       string
+		inputTypes []inputType
+		outputs    []*wire.TxOut
+	}{
+		{
+			name:       "p2wpkh inputs",
+			inputTypes: []inputType{p2wpkhInput, p2wpkhInput, p2wpkhInput},
+			outputs:    []*wire.TxOut{p2trOutput},
+		},
+		{
+			name:       "p2tr inputs",
+			inputTypes: []inputType{p2trInput, p2trInput},
+			outputs:    []*wire.TxOut{p2trOutput, p2wpkhOutput},
+		},
+		{
+			name:       "p2pkh inputs",
+			inputTypes: []inputType{p2pkhInput, p2pkhInput},
+			outputs:    []*wire.TxOut{p2wpkhOutput},
+		},
+		{
+			name:       "mixed inputs",
+			inputTypes: []inputType{p2pkhInput, nestedP2wpkhInput, p2wpkhInput, p2trInput},
+			outputs:    []*wire.TxOut{p2trOutput, p2wpkhOutput},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, prevScripts := makeSignedTx(t, tt.inputTypes, tt.outputs)
+			actual := int(mempool.GetTxVirtualSize(btcutil.NewTx(tx)))
+
+			estimated := EstimateTxVirtualSize(prevScripts, tt.outputs, nil)
+			// estimate assumes ecdsa signatures of maximal size, which can be
+			// up to two bytes longer than actual ones. For witness inputs the
+			// difference is discounted
+			require.GreaterOrEqual(t, estimated, actual)
+			require.LessOrEqual(t, estimated-actual, 2*len(tt.inputTypes))
+
+			// change output is included in the estimate
+			changeScript := p2wpkhOutput.PkScript
+			withChange := EstimateTxVirtualSize(prevScripts, tt.outputs, changeScript)
+			require.Equal(t, estimated+wire.NewTxOut(0, changeScript).SerializeSize(), withChange)
+		})
+	}
+}
+
+func TestEstimateTxVirtualSizeWitnessDiscount(t *testing.T) {
+	outputs := []*wire.TxOut{makeStakingOutput(t, 50000)}
+
+	p2pkhTx, p2pkhScripts := makeSignedTx(t, []inputType{p2pkhInput}, outputs)
+	p2wpkhTx, p2wpkhScripts := makeSignedTx(t, []inputType{p2wpkhInput}, outputs)
+
+	// serialized size of signed segwit transaction is about the same as of
+	// legacy one, but witness data is discounted
+	require.Less(t, EstimateTxVirtualSize(p2wpkhScripts, outputs, nil), EstimateTxVirtualSize(p2pkhScripts, outputs, nil))
+	require.Less(t,
+		mempool.GetTxVirtualSize(btcutil.NewTx(p2wpkhTx)),
+		int64(p2wpkhTx.SerializeSize()),
+	)
+	require.Equal(t, mempool.GetTxVirtualSize(btcutil.NewTx(p2pkhTx)), int64(p2pkhTx.SerializeSize()))
+
+	feeRate := btcutil.Amount(2000)
+	require.Equal(t,
+		btcutil.Amount(EstimateTxVirtualSize(p2wpkhScripts, outputs, nil))*feeRate/1000,
+		EstimateTxFee(p2wpkhScripts, outputs, nil, feeRate),
+	)
+}